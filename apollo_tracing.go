@@ -30,6 +30,7 @@ type tracerResolver struct {
 	ParentType  string          `json:"parentType"`
 	FieldName   string          `json:"fieldName"`
 	ReturnType  string          `json:"returnType"`
+	Owner       string          `json:"owner,omitempty"`
 	StartOffset int64           `json:"startOffset"`
 	Duration    int64           `json:"duration"`
 }
@@ -0,0 +1,344 @@
+package yarql
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// checkArguments checks the arguments of all fields and directives of the operation at the current charNr, also the ones that will be skipped
+// Arguments must be known by the field or directive, can only be set once and required arguments must be set
+// Variables must only be used at locations that accept the type of the variable
+// The charNr is restored afterwards
+// - https://spec.graphql.org/October2021/#sec-Validation.Arguments
+// - https://spec.graphql.org/October2021/#sec-All-Variable-Usages-are-Allowed
+func (ctx *Ctx) checkArguments() bool {
+	startCharNr := ctx.charNr
+	_, root, criticalErr := ctx.readOperation()
+	if criticalErr {
+		ctx.charNr = startCharNr
+		return criticalErr
+	}
+
+	errorsLen := len(ctx.query.Errors)
+	ctx.argumentsSelectionSet(root)
+	ctx.charNr = startCharNr
+	return len(ctx.query.Errors) > errorsLen
+}
+
+// argumentsSelectionSet checks the arguments of the fields and directives within the selection set at the current charNr
+func (ctx *Ctx) argumentsSelectionSet(typeObj *obj) {
+	for {
+		switch ctx.readInst() {
+		case bytecode.ActionField:
+			ctx.argumentsField(typeObj)
+		case bytecode.ActionSpread:
+			ctx.argumentsSpread(typeObj)
+		default:
+			// End of the selection set
+			return
+		}
+	}
+}
+
+// argumentsField checks the arguments of the field at the current charNr, its directives and its selection set
+func (ctx *Ctx) argumentsField(typeObj *obj) {
+	fieldAt := ctx.charNr - 1
+	directivesCount := ctx.readInst()
+	fieldLen := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	nameKey := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	endOfField := ctx.charNr + int(fieldLen)
+
+	aliasLen := int(ctx.readInst())
+	name := ctx.query.Res[ctx.charNr : ctx.charNr+aliasLen]
+	ctx.skipInst(aliasLen)
+	nameLen := int(ctx.readInst())
+	if nameLen != 0 {
+		name = ctx.query.Res[ctx.charNr : ctx.charNr+nameLen]
+		ctx.skipInst(nameLen)
+	}
+	ctx.skipInst(1)
+
+	ctx.argumentsDirectives(fieldAt, directivesCount, DirectiveLocationField)
+
+	var field *obj
+	if typeObj != nil {
+		field, _ = typeObj.objContents.get(nameKey, name)
+	}
+
+	hasArguments := ctx.seekInst() == bytecode.ActionValue
+	if field != nil && field.valueType == valueTypeMethod && field.customResolver == nil {
+		// Fields with a custom resolver, like remote fields, handle their arguments themselves
+		ctx.checkArgumentSet(fieldAt, field.method.inFields, hasArguments)
+	} else if hasArguments {
+		ctx.skipArguments()
+	}
+
+	if ctx.seekInst() != bytecode.ActionEnd {
+		var fieldType *obj
+		if field != nil && field.customResolver == nil {
+			fieldType = ctx.schema.complexityType(field)
+		}
+		ctx.argumentsSelectionSet(fieldType)
+	}
+
+	ctx.charNr = endOfField + 1
+}
+
+// argumentsSpread checks the arguments used within the fragment spread at the current charNr
+func (ctx *Ctx) argumentsSpread(typeObj *obj) {
+	spreadAt := ctx.charNr - 1
+	isInline := ctx.readInst() == 't'
+	directivesCount := ctx.readInst()
+	lenOfSpread := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read name or on inline fragment the type name
+	}
+	name := ctx.query.Res[nameStart : ctx.charNr-1]
+	endOfSpread := nameStart + int(lenOfSpread) + 1
+
+	location := DirectiveLocationFragment
+	if isInline {
+		location = DirectiveLocationFragmentInline
+	}
+	ctx.argumentsDirectives(spreadAt, directivesCount, location)
+
+	if isInline {
+		ctx.argumentsSelectionSet(ctx.schema.complexityFragmentType(typeObj, name))
+	} else if typeName, selectionSetAt, ok := ctx.findFragment(name); ok {
+		ctx.charNr = selectionSetAt
+		ctx.argumentsSelectionSet(ctx.schema.complexityFragmentType(typeObj, typeName))
+	}
+
+	ctx.charNr = endOfSpread
+}
+
+// argumentsDirectives checks the arguments of the directives at the current charNr
+func (ctx *Ctx) argumentsDirectives(fieldAt int, directivesCount uint8, location DirectiveLocation) {
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.skipInst(1) // read 'd'
+		hasArguments := ctx.readInst() == 't'
+		nameStart := ctx.charNr
+		for ctx.readInst() != 0 {
+			// Read name
+		}
+
+		directiveName := b2s(ctx.query.Res[nameStart : ctx.charNr-1])
+		var method *objMethod
+		for _, directive := range ctx.schema.definedDirectives[location] {
+			if directive.Name == directiveName {
+				method = directive.parsedMethod
+				break
+			}
+		}
+		if method != nil {
+			ctx.checkArgumentSet(fieldAt, method.inFields, hasArguments)
+		} else if hasArguments {
+			ctx.skipArguments()
+		}
+	}
+}
+
+// checkArgumentSet checks the arguments at the current charNr against inFields
+// If hasArguments is false there are no arguments to read and only the required arguments are checked
+func (ctx *Ctx) checkArgumentSet(fieldAt int, inFields map[string]referToInput, hasArguments bool) {
+	argumentsStart := len(ctx.argumentNames)
+	if hasArguments {
+		ctx.walkInputObject(func(key []byte) bool {
+			for _, name := range ctx.argumentNames[argumentsStart:] {
+				if bytes.Equal(name, key) {
+					ctx.addErrAtField(fieldAt, errors.New("argument "+string(key)+" can only be set once"))
+					break
+				}
+			}
+			ctx.argumentNames = append(ctx.argumentNames, key)
+
+			inField, ok := inFields[b2s(key)]
+			if ok {
+				ctx.variableTypesValue(fieldAt, ctx.charNr, &inField.input)
+			} else {
+				ctx.addErrAtField(fieldAt, errors.New("undefined input: "+string(key)))
+			}
+			// Skip ActionValue, the value kind, the length of the value and the value itself
+			ctx.skipInst(6 + int(ctx.readUint32(ctx.charNr+2)))
+			return false
+		})
+	}
+
+	for key, inField := range inFields {
+		if !inField.input.required {
+			continue
+		}
+		found := false
+		for _, name := range ctx.argumentNames[argumentsStart:] {
+			if b2s(name) == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ctx.addErrAtField(fieldAt, errors.New("missing required argument "+key))
+		}
+	}
+
+	ctx.argumentNames = ctx.argumentNames[:argumentsStart]
+}
+
+// variableTypesValue checks the variables used by the value at valueAt that is assigned to in
+// Lists and input objects are walked to check the variables within them
+func (ctx *Ctx) variableTypesValue(fieldAt int, valueAt int, in *input) {
+	res := ctx.query.Res
+	contentStart := valueAt + 6
+	contentEnd := contentStart + int(ctx.readUint32(valueAt+2))
+
+	switch res[valueAt+1] {
+	case bytecode.ValueVariable:
+		ctx.checkVariableType(fieldAt, res[contentStart:contentEnd], in)
+	case bytecode.ValueList:
+		elem := in
+		for elem.kind == reflect.Ptr && !elem.isFile {
+			elem = elem.elem
+		}
+		if elem.kind != reflect.Slice && elem.kind != reflect.Array {
+			return
+		}
+		// Items are written as NULL byte followed by the value, the list ends with a NULL byte followed by 'e'
+		for i := contentStart; i < contentEnd && res[i+1] != 'e'; i += 7 + int(ctx.readUint32(i+3)) {
+			ctx.variableTypesValue(fieldAt, i+1, elem.elem)
+		}
+	case bytecode.ValueObject:
+		structInput := in
+		for structInput.kind == reflect.Ptr && !structInput.isFile {
+			structInput = structInput.elem
+		}
+		if structInput.kind != reflect.Struct {
+			return
+		}
+		if structInput.isStructPointers {
+			structInput = ctx.schema.inTypes[structInput.structName]
+		}
+		// Fields are written as NULL byte, 'u', the key, NULL byte followed by the value, the object ends with a NULL byte followed by 'e'
+		for i := contentStart; i < contentEnd && res[i+1] != 'e'; {
+			keyStart := i + 2
+			keyEnd := keyStart
+			for res[keyEnd] != 0 {
+				keyEnd++
+			}
+			fieldValueAt := keyEnd + 1
+			field, ok := structInput.structContent[b2s(res[keyStart:keyEnd])]
+			if ok {
+				ctx.variableTypesValue(fieldAt, fieldValueAt, &field)
+			}
+			i = fieldValueAt + 6 + int(ctx.readUint32(fieldValueAt+2))
+		}
+	}
+}
+
+// checkVariableType reports an error if the variable with name cannot be assigned to in
+// Undefined variables are ignored as they are reported while parsing
+func (ctx *Ctx) checkVariableType(fieldAt int, name []byte, in *input) {
+	startCharNr := ctx.charNr
+	defer func() {
+		ctx.charNr = startCharNr
+	}()
+
+	if !ctx.findOperatorArgument(b2s(name)) {
+		return
+	}
+	typeStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read the type
+	}
+	variableType := ctx.query.Res[typeStart : ctx.charNr-1]
+	if len(variableType) == 0 {
+		return
+	}
+
+	location := ctx.variableLocationType(in)
+	if variableTypeAllowed(variableType, location) {
+		return
+	}
+
+	ctx.addErrAtField(fieldAt, errors.New("variable $"+string(name)+" of type "+variableTypeString(variableType)+" cannot be used where "+qlTypeString(location)+" is expected"))
+}
+
+// variableLocationType returns the type of a argument as used to check variables against
+// Nullability is left out as null values and missing values are bound as the zero value of the go type
+func (ctx *Ctx) variableLocationType(in *input) *qlType {
+	for in.kind == reflect.Ptr && !in.isFile {
+		in = in.elem
+	}
+	if in.isEnum {
+		return &ctx.schema.definedEnums[in.enumTypeIndex].qlType
+	}
+	if (in.kind == reflect.Slice || in.kind == reflect.Array) && !in.isFile {
+		return &qlType{
+			Kind:   typeKindList,
+			OfType: ctx.variableLocationType(in.elem),
+		}
+	}
+	res, _ := ctx.schema.inputToQLType(in)
+	return res
+}
+
+// variableTypeAllowed returns true if a variable of variableType, as written in the bytecode, can be used at location
+// Next to the exact types the types accepted when binding variables are also allowed, for example a String variable for a ID
+func variableTypeAllowed(variableType []byte, location *qlType) bool {
+	if location == nil || len(variableType) == 0 {
+		return true
+	}
+
+	if variableType[0] == 'l' || variableType[0] == 'L' {
+		return location.Kind == typeKindList && variableTypeAllowed(variableType[1:], location.OfType)
+	}
+	if location.Kind == typeKindList {
+		return false
+	}
+	if location.Name == nil {
+		return true
+	}
+
+	name := b2s(variableType[1:])
+	expected := *location.Name
+	if name == expected {
+		return true
+	}
+	switch {
+	case location.Kind == typeKindEnum:
+		return name == "String"
+	case expected == "ID" || expected == "Time":
+		return name == "String"
+	case expected == "Int64":
+		return name == "Int" || name == "String"
+	case expected == "File":
+		return name == "Upload" || name == "String"
+	case location.Kind == typeKindScalar && expected != "String" && expected != "Int" && expected != "Float" && expected != "Boolean":
+		// Custom scalars are bound from their underlying go type
+		return name == "String"
+	}
+	return false
+}
+
+// variableTypeString returns the variable type as written in the query
+func variableTypeString(variableType []byte) string {
+	if len(variableType) == 0 {
+		return ""
+	}
+	switch variableType[0] {
+	case 'l':
+		return "[" + variableTypeString(variableType[1:]) + "]"
+	case 'L':
+		return "[" + variableTypeString(variableType[1:]) + "]!"
+	case 'N':
+		return string(variableType[1:]) + "!"
+	default:
+		return string(variableType[1:])
+	}
+}
@@ -0,0 +1,120 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestVariableTypesData struct{}
+
+type TestVariableTypesFilter struct {
+	Name *string
+	Ages []int
+}
+
+func (TestVariableTypesData) ResolveUser(args struct {
+	Id   uint `gq:",id,required"`
+	Name string
+}) uint {
+	return args.Id
+}
+
+func (TestVariableTypesData) ResolveUsers(args struct {
+	First  int
+	Names  []string
+	Filter TestVariableTypesFilter
+	Id     uint `gq:",id"`
+}) int {
+	return args.First
+}
+
+func TestVariableTypes(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestVariableTypesData{}, M{}, nil))
+
+	validQueries := []string{
+		`query($first: Int) {users(first: $first)}`,
+		`query($first: Int! = 1) {users(first: $first)}`,
+		`query($names: [String!]) {users(names: $names)}`,
+		`query($name: String) {users(names: [$name])}`,
+		`query($age: Int) {users(filter: {ages: [$age]})}`,
+		`query($filter: TestVariableTypesFilter) {users(filter: $filter)}`,
+		`query($id: String) {users(id: $id)}`,
+		`query($if: Boolean!) {users(first: 1) @include(if: $if)}`,
+	}
+	for _, query := range validQueries {
+		a.Equal(t, 0, len(s.Validate(query)), query)
+	}
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`query($first: String) {users(first: $first)}`, "variable $first of type String cannot be used where Int is expected"},
+		{`query($names: String) {users(names: $names)}`, "variable $names of type String cannot be used where [String] is expected"},
+		{`query($name: [String]) {users(names: [$name])}`, "variable $name of type [String] cannot be used where String is expected"},
+		{`query($name: Int) {users(filter: {name: $name})}`, "variable $name of type Int cannot be used where String is expected"},
+		{`query($if: String) {users(first: 1) @include(if: $if)}`, "variable $if of type String cannot be used where Boolean is expected"},
+		{`query($first: Boolean) {...F} fragment F on TestVariableTypesData {users(first: $first)}`, "variable $first of type Boolean cannot be used where Int is expected"},
+	}
+	for _, test := range tests {
+		errs := s.Resolve([]byte(test.query), ResolveOptions{NoMeta: true})
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+		a.Equal(t, `{}`, string(s.Result), test.query)
+
+		// Validate reports the same error
+		errs = s.Validate(test.query)
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+	}
+}
+
+func TestArguments(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestVariableTypesData{}, M{}, nil))
+
+	errs := s.Resolve([]byte(`{user(id: 1, name: "a") users @include(if: true)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"user":1,"users":0}`, string(s.Result))
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{users(last: 1)}`, "undefined input: last"},
+		{`{users(last: 1) @skip(if: true)}`, "undefined input: last"},
+		{`{users(first: 1, first: 2)}`, "argument first can only be set once"},
+		{`{user(name: "a")}`, "missing required argument id"},
+		{`{users @include}`, "missing required argument if"},
+		{`{users @include(if: true, unless: false)}`, "undefined input: unless"},
+		{`{...F} fragment F on TestVariableTypesData {users(last: 1)}`, "undefined input: last"},
+	}
+	for _, test := range tests {
+		errs := s.Resolve([]byte(test.query), ResolveOptions{NoMeta: true})
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+		a.Equal(t, `{}`, string(s.Result), test.query)
+
+		// Validate reports the same error
+		errs = s.Validate(test.query)
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+	}
+}
+
+func TestArgumentsNullability(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestVariableTypesData{}, M{}, nil))
+
+	// Only required arguments are non-null as other arguments can be left out or set to null
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "\tuser(id: ID!, name: String): Int!\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\tusers(filter: TestVariableTypesFilter, first: Int, id: ID, names: [String!]): Int!\n"), sdl)
+
+	errs := s.Resolve([]byte(`{user(id: 1, name: null) users(first: null)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"user":1,"users":0}`, string(s.Result))
+}
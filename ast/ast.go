@@ -0,0 +1,471 @@
+// Package ast contains the parsed structure of a graphql query
+//
+// The query is parsed by the bytecode parser, the document is created from the resulting bytecode so queries are never parsed twice
+package ast
+
+import (
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// Node is implemented by all nodes of a document
+type Node interface {
+	node()
+}
+
+// Selection is implemented by the nodes that can be part of a selection set, *Field, *FragmentSpread and *InlineFragment
+type Selection interface {
+	Node
+	selection()
+}
+
+// OperationType is the type of a operation
+type OperationType string
+
+// All possible operation types
+const (
+	Query        OperationType = "query"
+	Mutation     OperationType = "mutation"
+	Subscription OperationType = "subscription"
+)
+
+// ValueKind defines the kind of a input value
+type ValueKind = bytecode.ValueKind
+
+// All possible value kinds
+const (
+	ValueVariable = bytecode.ValueVariable
+	ValueInt      = bytecode.ValueInt
+	ValueFloat    = bytecode.ValueFloat
+	ValueString   = bytecode.ValueString
+	ValueBoolean  = bytecode.ValueBoolean
+	ValueNull     = bytecode.ValueNull
+	ValueEnum     = bytecode.ValueEnum
+	ValueList     = bytecode.ValueList
+	ValueObject   = bytecode.ValueObject
+)
+
+// Document is a parsed query with all of its operations and fragments
+type Document struct {
+	Operations []*Operation
+	Fragments  []*Fragment
+}
+
+// Operation is a query, mutation or subscription
+type Operation struct {
+	Type         OperationType
+	Name         string // empty for anonymous operations
+	Variables    []*VariableDefinition
+	Directives   []*Directive
+	SelectionSet []Selection
+}
+
+// VariableDefinition is a variable defined by a operation, like: $id: ID! = "1"
+type VariableDefinition struct {
+	Name         string // without the $ prefix
+	Type         *Type
+	DefaultValue *Value // nil if the variable has no default value
+	Directives   []*Directive
+}
+
+// Type is the type of a variable definition
+type Type struct {
+	Name    string // set if the type is a named type
+	Elem    *Type  // set if the type is a list
+	NonNull bool
+}
+
+// String returns the type as written in a query, for example [String!]!
+func (t *Type) String() string {
+	res := t.Name
+	if t.Elem != nil {
+		res = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		res += "!"
+	}
+	return res
+}
+
+// Fragment is a fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	SelectionSet  []Selection
+}
+
+// Field is a field within a selection set
+type Field struct {
+	Alias        string // equal to Name if the field has no alias
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+
+	// The location of the field within the query, both start at 1
+	// Zero if the location is unknown
+	Line   uint
+	Column uint
+}
+
+// FragmentSpread is a spread of a named fragment, like: ...UserFields
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+
+	// The location of the spread within the query, both start at 1
+	// Zero if the location is unknown
+	Line   uint
+	Column uint
+}
+
+// InlineFragment is a inline fragment, like: ... on User { name }
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+
+	// The location of the spread within the query, both start at 1
+	// Zero if the location is unknown
+	Line   uint
+	Column uint
+}
+
+// Directive is a directive used on a operation, field or fragment spread
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+}
+
+// Argument is a argument of a field or directive
+type Argument struct {
+	Name  string
+	Value *Value
+}
+
+// Value is a input value
+type Value struct {
+	Kind ValueKind
+	// Raw contains the value for scalars and enums, the unescaped string for strings, "true" or "false" for booleans and the name of a variable without the $ prefix
+	Raw    string
+	List   []*Value       // Kind == ValueList
+	Fields []*ObjectField // Kind == ValueObject
+}
+
+// ObjectField is a field of a input object value
+type ObjectField struct {
+	Name  string
+	Value *Value
+}
+
+func (*Document) node()           {}
+func (*Operation) node()          {}
+func (*VariableDefinition) node() {}
+func (*Fragment) node()           {}
+func (*Field) node()              {}
+func (*FragmentSpread) node()     {}
+func (*InlineFragment) node()     {}
+func (*Directive) node()          {}
+func (*Argument) node()           {}
+func (*Value) node()              {}
+func (*ObjectField) node()        {}
+
+func (*Field) selection()          {}
+func (*FragmentSpread) selection() {}
+func (*InlineFragment) selection() {}
+
+// Parse parses query into a document
+// The errors are the syntax errors of the query, the document is nil if there are errors
+func Parse(query string) (*Document, []error) {
+	parser := bytecode.NewParserCtx()
+	parser.Query = append(parser.Query, query...)
+	parser.ParseQueryToBytecode(nil)
+	if len(parser.Errors) > 0 {
+		return nil, parser.Errors
+	}
+	return FromBytecode(parser), nil
+}
+
+// FromBytecode creates the document of a query parsed by the bytecode parser
+// The parser is expected to have parsed the query without errors
+func FromBytecode(parser *bytecode.ParserCtx) *Document {
+	r := reader{parser: parser, res: parser.Res}
+	doc := &Document{}
+	for r.charNr+1 < len(r.res) {
+		switch r.res[r.charNr+1] {
+		case bytecode.ActionOperator:
+			doc.Operations = append(doc.Operations, r.readOperation())
+		case bytecode.ActionFragment:
+			doc.Fragments = append(doc.Fragments, r.readFragment())
+		default:
+			return doc
+		}
+	}
+	return doc
+}
+
+// reader reads the bytecode of a parsed query
+type reader struct {
+	parser *bytecode.ParserCtx
+	res    []byte
+	charNr int
+}
+
+func (r *reader) readInst() byte {
+	c := r.res[r.charNr]
+	r.charNr++
+	return c
+}
+
+func (r *reader) seekInst() byte {
+	return r.res[r.charNr]
+}
+
+func (r *reader) readUint32(startAt int) uint32 {
+	data := r.res[startAt : startAt+4]
+	return uint32(data[0]) |
+		(uint32(data[1]) << 8) |
+		(uint32(data[2]) << 16) |
+		(uint32(data[3]) << 24)
+}
+
+// readName reads until the next NULL byte
+func (r *reader) readName() string {
+	start := r.charNr
+	for r.readInst() != 0 {
+	}
+	return string(r.res[start : r.charNr-1])
+}
+
+func (r *reader) location(at int) (line uint, column uint) {
+	line, column, _ = r.parser.FieldLocationOf(at)
+	return line, column
+}
+
+func (r *reader) readOperation() *Operation {
+	r.charNr += 2 // read 0, [ActionOperator]
+
+	op := &Operation{}
+	switch r.readInst() {
+	case bytecode.OperatorMutation:
+		op.Type = Mutation
+	case bytecode.OperatorSubscription:
+		op.Type = Subscription
+	default:
+		op.Type = Query
+	}
+	hasArguments := r.readInst() == 't'
+	directivesCount := r.readInst()
+	op.Name = r.readName()
+
+	if hasArguments {
+		argumentsEnd := r.charNr + int(r.readUint32(r.charNr)) + 5
+		r.charNr += 5 // the length of the arguments and NULL byte
+
+		// Arguments are written as [ActionOperatorArgs] followed by [ActionOperatorArg] and the argument for every argument
+		r.charNr += 2 // read [ActionOperatorArgs] and NULL byte
+		for r.seekInst() == bytecode.ActionOperatorArg {
+			startOfArg := r.charNr
+			argLen := int(r.readUint32(r.charNr + 1))
+			r.charNr += 5
+
+			variable := &VariableDefinition{Name: r.readName()}
+			variable.Type = readType([]byte(r.readName()))
+			if r.readInst() == 't' {
+				r.charNr++ // read the NULL byte before the value
+				variable.DefaultValue = r.readValue(r.charNr)
+				r.charNr += 6 + int(r.readUint32(r.charNr+2))
+			}
+
+			// The directives of the variable are written after the default value up to the end of the argument
+			endOfArg := startOfArg + argLen
+			if r.charNr < endOfArg {
+				r.charNr++ // read the NULL byte before the directives
+				for r.charNr < endOfArg {
+					variable.Directives = append(variable.Directives, r.readDirectives(1)...)
+				}
+			}
+			op.Variables = append(op.Variables, variable)
+			r.charNr = startOfArg + argLen + 1
+		}
+		r.charNr = argumentsEnd
+	}
+
+	op.Directives = r.readDirectives(directivesCount)
+	op.SelectionSet = r.readSelectionSet()
+	return op
+}
+
+func (r *reader) readFragment() *Fragment {
+	r.charNr += 2 // read 0, [ActionFragment]
+
+	fragment := &Fragment{}
+	fragment.Name = r.readName()
+	fragment.TypeCondition = r.readName()
+	fragment.SelectionSet = r.readSelectionSet()
+	return fragment
+}
+
+// readType converts a bytecode graphql type like LNInt into a type
+func readType(qlType []byte) *Type {
+	if len(qlType) == 0 {
+		return nil
+	}
+	res := &Type{NonNull: qlType[0] == 'L' || qlType[0] == 'N'}
+	if qlType[0] == 'l' || qlType[0] == 'L' {
+		res.Elem = readType(qlType[1:])
+	} else {
+		res.Name = string(qlType[1:])
+	}
+	return res
+}
+
+// readSelectionSet reads the selection set at the current charNr including the end instruction
+func (r *reader) readSelectionSet() []Selection {
+	var res []Selection
+	for {
+		switch r.readInst() {
+		case bytecode.ActionField:
+			res = append(res, r.readField())
+		case bytecode.ActionSpread:
+			res = append(res, r.readSpread())
+		default:
+			// End of the selection set
+			return res
+		}
+	}
+}
+
+func (r *reader) readField() *Field {
+	fieldAt := r.charNr - 1
+	directivesCount := r.readInst()
+	fieldLen := r.readUint32(r.charNr)
+	r.charNr += 8 // field length and name key
+	endOfField := r.charNr + int(fieldLen)
+
+	field := &Field{}
+	field.Line, field.Column = r.location(fieldAt)
+
+	aliasLen := int(r.readInst())
+	field.Alias = string(r.res[r.charNr : r.charNr+aliasLen])
+	field.Name = field.Alias
+	r.charNr += aliasLen
+	nameLen := int(r.readInst())
+	if nameLen != 0 {
+		field.Name = string(r.res[r.charNr : r.charNr+nameLen])
+		r.charNr += nameLen
+	}
+	r.charNr++
+
+	field.Directives = r.readDirectives(directivesCount)
+	if r.seekInst() == bytecode.ActionValue {
+		field.Arguments = r.readArguments()
+	}
+	if r.seekInst() != bytecode.ActionEnd {
+		field.SelectionSet = r.readSelectionSet()
+	}
+
+	r.charNr = endOfField + 1
+	return field
+}
+
+func (r *reader) readSpread() Selection {
+	spreadAt := r.charNr - 1
+	isInline := r.readInst() == 't'
+	directivesCount := r.readInst()
+	lenOfSpread := r.readUint32(r.charNr)
+	r.charNr += 4
+
+	nameStart := r.charNr
+	name := r.readName()
+	endOfSpread := nameStart + int(lenOfSpread) + 1
+	line, column := r.location(spreadAt)
+	directives := r.readDirectives(directivesCount)
+
+	if !isInline {
+		r.charNr = endOfSpread
+		return &FragmentSpread{
+			Name:       name,
+			Directives: directives,
+			Line:       line,
+			Column:     column,
+		}
+	}
+
+	fragment := &InlineFragment{
+		TypeCondition: name,
+		Directives:    directives,
+		SelectionSet:  r.readSelectionSet(),
+		Line:          line,
+		Column:        column,
+	}
+	r.charNr = endOfSpread
+	return fragment
+}
+
+func (r *reader) readDirectives(count uint8) []*Directive {
+	var res []*Directive
+	for i := uint8(0); i < count; i++ {
+		r.charNr++ // read [ActionDirective]
+		hasArguments := r.readInst() == 't'
+		directive := &Directive{Name: r.readName()}
+		if hasArguments {
+			directive.Arguments = r.readArguments()
+		}
+		res = append(res, directive)
+	}
+	return res
+}
+
+// readArguments reads the arguments object at the current charNr and the NULL byte after it
+func (r *reader) readArguments() []*Argument {
+	value := r.readValue(r.charNr)
+	r.charNr += 6 + int(r.readUint32(r.charNr+2)) + 1
+
+	res := make([]*Argument, len(value.Fields))
+	for i, field := range value.Fields {
+		res[i] = &Argument{Name: field.Name, Value: field.Value}
+	}
+	return res
+}
+
+// readValue reads the value of which the [ActionValue] instruction is at valueAt
+func (r *reader) readValue(valueAt int) *Value {
+	kind := r.res[valueAt+1]
+	contentStart := valueAt + 6
+	contentEnd := contentStart + int(r.readUint32(valueAt+2))
+	value := &Value{Kind: kind}
+
+	switch kind {
+	case bytecode.ValueList:
+		// Items are written as NULL byte followed by the value, the list ends with a NULL byte followed by [ActionEnd]
+		for i := contentStart; i < contentEnd && r.res[i+1] != bytecode.ActionEnd; i += 7 + int(r.readUint32(i+3)) {
+			value.List = append(value.List, r.readValue(i+1))
+		}
+	case bytecode.ValueObject:
+		// Fields are written as NULL byte, [ActionObjectValueField], the key, NULL byte followed by the value, the object ends with a NULL byte followed by [ActionEnd]
+		for i := contentStart; i < contentEnd && r.res[i+1] != bytecode.ActionEnd; {
+			keyStart := i + 2
+			keyEnd := keyStart
+			for r.res[keyEnd] != 0 {
+				keyEnd++
+			}
+			fieldValueAt := keyEnd + 1
+			value.Fields = append(value.Fields, &ObjectField{
+				Name:  string(r.res[keyStart:keyEnd]),
+				Value: r.readValue(fieldValueAt),
+			})
+			i = fieldValueAt + 6 + int(r.readUint32(fieldValueAt+2))
+		}
+	case bytecode.ValueBoolean:
+		if contentEnd > contentStart && r.res[contentStart] == '1' {
+			value.Raw = "true"
+		} else {
+			value.Raw = "false"
+		}
+	case bytecode.ValueNull:
+		value.Raw = "null"
+	default:
+		value.Raw = string(r.res[contentStart:contentEnd])
+	}
+	return value
+}
@@ -0,0 +1,161 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func TestParse(t *testing.T) {
+	doc, errs := Parse(`query Users($first: Int = 10, $ids: [ID!]!, $skip: Boolean @deprecated(reason: "use include")) {
+	users(first: $first, filter: {ids: $ids, name: "a\nb", age: null, roles: [ADMIN, USER]}) @skip(if: $skip) {
+		name
+		a: age
+		...UserFields
+		... on Admin @include(if: true) {
+			level
+		}
+	}
+}
+
+mutation Rename {
+	rename(name: 1.5)
+}
+
+fragment UserFields on User {
+	id
+}`)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, 2, len(doc.Operations))
+	a.Equal(t, 1, len(doc.Fragments))
+
+	op := doc.Operations[0]
+	a.Equal(t, Query, op.Type)
+	a.Equal(t, "Users", op.Name)
+	a.Equal(t, 3, len(op.Variables))
+	a.Equal(t, "first", op.Variables[0].Name)
+	a.Equal(t, "Int", op.Variables[0].Type.String())
+	a.Equal(t, ValueInt, op.Variables[0].DefaultValue.Kind)
+	a.Equal(t, "10", op.Variables[0].DefaultValue.Raw)
+	a.Equal(t, "[ID!]!", op.Variables[1].Type.String())
+	a.Nil(t, op.Variables[1].DefaultValue)
+	a.Equal(t, "Boolean", op.Variables[2].Type.String())
+	a.Equal(t, 0, len(op.Variables[0].Directives))
+	a.Equal(t, 1, len(op.Variables[2].Directives))
+	a.Equal(t, "deprecated", op.Variables[2].Directives[0].Name)
+	a.Equal(t, "use include", op.Variables[2].Directives[0].Arguments[0].Value.Raw)
+
+	a.Equal(t, 1, len(op.SelectionSet))
+	users := op.SelectionSet[0].(*Field)
+	a.Equal(t, "users", users.Name)
+	a.Equal(t, "users", users.Alias)
+	a.Equal(t, uint(2), users.Line)
+	a.Equal(t, uint(2), users.Column)
+
+	a.Equal(t, 2, len(users.Arguments))
+	a.Equal(t, "first", users.Arguments[0].Name)
+	a.Equal(t, ValueVariable, users.Arguments[0].Value.Kind)
+	a.Equal(t, "first", users.Arguments[0].Value.Raw)
+	filter := users.Arguments[1].Value
+	a.Equal(t, ValueObject, filter.Kind)
+	a.Equal(t, 4, len(filter.Fields))
+	a.Equal(t, "ids", filter.Fields[0].Name)
+	a.Equal(t, "a\nb", filter.Fields[1].Value.Raw)
+	a.Equal(t, ValueNull, filter.Fields[2].Value.Kind)
+	roles := filter.Fields[3].Value
+	a.Equal(t, ValueList, roles.Kind)
+	a.Equal(t, 2, len(roles.List))
+	a.Equal(t, ValueEnum, roles.List[1].Kind)
+	a.Equal(t, "USER", roles.List[1].Raw)
+
+	a.Equal(t, 1, len(users.Directives))
+	a.Equal(t, "skip", users.Directives[0].Name)
+	a.Equal(t, "skip", users.Directives[0].Arguments[0].Value.Raw)
+
+	a.Equal(t, 4, len(users.SelectionSet))
+	a.Equal(t, "name", users.SelectionSet[0].(*Field).Name)
+	age := users.SelectionSet[1].(*Field)
+	a.Equal(t, "a", age.Alias)
+	a.Equal(t, "age", age.Name)
+	a.Equal(t, "UserFields", users.SelectionSet[2].(*FragmentSpread).Name)
+	inline := users.SelectionSet[3].(*InlineFragment)
+	a.Equal(t, "Admin", inline.TypeCondition)
+	a.Equal(t, "include", inline.Directives[0].Name)
+	a.Equal(t, ValueBoolean, inline.Directives[0].Arguments[0].Value.Kind)
+	a.Equal(t, "true", inline.Directives[0].Arguments[0].Value.Raw)
+	a.Equal(t, "level", inline.SelectionSet[0].(*Field).Name)
+
+	mutation := doc.Operations[1]
+	a.Equal(t, Mutation, mutation.Type)
+	a.Equal(t, "Rename", mutation.Name)
+	rename := mutation.SelectionSet[0].(*Field)
+	a.Equal(t, ValueFloat, rename.Arguments[0].Value.Kind)
+	a.Equal(t, "1.5", rename.Arguments[0].Value.Raw)
+
+	fragment := doc.Fragments[0]
+	a.Equal(t, "UserFields", fragment.Name)
+	a.Equal(t, "User", fragment.TypeCondition)
+	a.Equal(t, "id", fragment.SelectionSet[0].(*Field).Name)
+}
+
+func TestParseErrors(t *testing.T) {
+	doc, errs := Parse(`{a`)
+	a.Nil(t, doc)
+	a.Equal(t, 1, len(errs))
+}
+
+func TestInspect(t *testing.T) {
+	doc, errs := Parse(`{a b(c: [1, {d: 2}]) {e ...F ... on G {h}}} fragment F on B {i}`)
+	a.Equal(t, 0, len(errs))
+
+	fields := []string{}
+	values := 0
+	Inspect(doc, func(node Node) bool {
+		switch n := node.(type) {
+		case *Field:
+			fields = append(fields, n.Name)
+		case *Value:
+			values++
+		}
+		return true
+	})
+	a.Equal(t, "a,b,e,h,i", strings.Join(fields, ","))
+	a.Equal(t, 4, values)
+
+	// Returning false skips the children of a node
+	fields = fields[:0]
+	Inspect(doc, func(node Node) bool {
+		if field, ok := node.(*Field); ok {
+			fields = append(fields, field.Name)
+			return false
+		}
+		return true
+	})
+	a.Equal(t, "a,b,i", strings.Join(fields, ","))
+}
+
+type depthVisitor struct {
+	depth    int
+	maxDepth *int
+}
+
+func (v depthVisitor) Visit(node Node) Visitor {
+	if _, ok := node.(*Field); !ok {
+		return v
+	}
+	v.depth++
+	if v.depth > *v.maxDepth {
+		*v.maxDepth = v.depth
+	}
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	doc, errs := Parse(`{a {b {c}} d}`)
+	a.Equal(t, 0, len(errs))
+
+	maxDepth := 0
+	Walk(depthVisitor{maxDepth: &maxDepth}, doc)
+	a.Equal(t, 3, maxDepth)
+}
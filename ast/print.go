@@ -0,0 +1,313 @@
+package ast
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Print returns the document as a query without insignificant whitespace and comments
+func Print(doc *Document) string {
+	p := printer{}
+	for _, operation := range doc.Operations {
+		p.operation(operation)
+	}
+	for _, fragment := range doc.Fragments {
+		p.fragment(fragment)
+	}
+	return p.out.String()
+}
+
+// Operation returns the operation with name, if name is empty the last operation is returned like the operation that is executed if no operation name is send
+// Returns nil if no operation was found
+func (doc *Document) Operation(name string) *Operation {
+	if len(name) == 0 {
+		if len(doc.Operations) == 0 {
+			return nil
+		}
+		return doc.Operations[len(doc.Operations)-1]
+	}
+	for _, operation := range doc.Operations {
+		if operation.Name == name {
+			return operation
+		}
+	}
+	return nil
+}
+
+// Normalize returns a normalized version of the operation with operationName (see (*Document).Operation) and the fragments it uses
+// Queries that only differ in whitespace, comments, literal values, the order of arguments, the order of variables or the order of fragments have the same normalized version
+// This makes it useful for grouping metrics, rate limiting and cache keys
+// Literal values are replaced with a placeholder: strings with "", numbers with 0, lists with [] and objects with {}, booleans, enums, null and variables are kept
+// Fragments that are not used by the operation, for example because they are used by another operation, are left out
+// Returns an empty string if the operation is not found
+func Normalize(doc *Document, operationName string) string {
+	operation := doc.Operation(operationName)
+	if operation == nil {
+		return ""
+	}
+
+	p := printer{normalize: true}
+	p.operation(operation)
+
+	// Only include the fragments used by the operation sorted by name
+	used := map[string]bool{}
+	collectFragments(doc, operation.SelectionSet, used)
+	fragments := []*Fragment{}
+	for _, fragment := range doc.Fragments {
+		if used[fragment.Name] {
+			fragments = append(fragments, fragment)
+		}
+	}
+	sort.SliceStable(fragments, func(i, j int) bool { return fragments[i].Name < fragments[j].Name })
+	for _, fragment := range fragments {
+		p.fragment(fragment)
+	}
+	return p.out.String()
+}
+
+// Fingerprint returns a stable hex encoded sha256 hash of the normalized operation, see Normalize
+// Returns an empty string if the operation is not found
+func Fingerprint(doc *Document, operationName string) string {
+	normalized := Normalize(doc, operationName)
+	if len(normalized) == 0 {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
+
+// collectFragments adds the names of the fragments used within selectionSet to used
+func collectFragments(doc *Document, selectionSet []Selection, used map[string]bool) {
+	for _, selection := range selectionSet {
+		switch s := selection.(type) {
+		case *Field:
+			collectFragments(doc, s.SelectionSet, used)
+		case *InlineFragment:
+			collectFragments(doc, s.SelectionSet, used)
+		case *FragmentSpread:
+			if used[s.Name] {
+				continue
+			}
+			used[s.Name] = true
+			for _, fragment := range doc.Fragments {
+				if fragment.Name == s.Name {
+					collectFragments(doc, fragment.SelectionSet, used)
+				}
+			}
+		}
+	}
+}
+
+// printer writes nodes as a query with only the whitespace required to separate names
+type printer struct {
+	out strings.Builder
+	// normalize sorts arguments and variables and replaces literal values with placeholders
+	normalize bool
+}
+
+// space writes a space if the previous character is part of a name
+func (p *printer) space() {
+	if p.out.Len() == 0 {
+		return
+	}
+	s := p.out.String()
+	c := s[len(s)-1]
+	if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+		p.out.WriteByte(' ')
+	}
+}
+
+func (p *printer) operation(operation *Operation) {
+	p.space()
+	p.out.WriteString(string(operation.Type))
+	if len(operation.Name) > 0 {
+		p.out.WriteByte(' ')
+		p.out.WriteString(operation.Name)
+	}
+
+	if len(operation.Variables) > 0 {
+		variables := operation.Variables
+		if p.normalize {
+			variables = append([]*VariableDefinition{}, variables...)
+			sort.SliceStable(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+		}
+
+		p.out.WriteByte('(')
+		for i, variable := range variables {
+			if i > 0 {
+				p.out.WriteByte(',')
+			}
+			p.out.WriteByte('$')
+			p.out.WriteString(variable.Name)
+			p.out.WriteByte(':')
+			p.out.WriteString(variable.Type.String())
+			if variable.DefaultValue != nil {
+				p.out.WriteByte('=')
+				p.value(variable.DefaultValue)
+			}
+			p.directives(variable.Directives)
+		}
+		p.out.WriteByte(')')
+	}
+
+	p.directives(operation.Directives)
+	p.selectionSet(operation.SelectionSet)
+}
+
+func (p *printer) fragment(fragment *Fragment) {
+	p.space()
+	p.out.WriteString("fragment ")
+	p.out.WriteString(fragment.Name)
+	p.out.WriteString(" on ")
+	p.out.WriteString(fragment.TypeCondition)
+	p.selectionSet(fragment.SelectionSet)
+}
+
+func (p *printer) selectionSet(selectionSet []Selection) {
+	p.out.WriteByte('{')
+	for i, selection := range selectionSet {
+		if i > 0 {
+			p.out.WriteByte(' ')
+		}
+		switch s := selection.(type) {
+		case *Field:
+			if s.Alias != s.Name {
+				p.out.WriteString(s.Alias)
+				p.out.WriteByte(':')
+			}
+			p.out.WriteString(s.Name)
+			p.arguments(s.Arguments)
+			p.directives(s.Directives)
+			if len(s.SelectionSet) > 0 {
+				p.selectionSet(s.SelectionSet)
+			}
+		case *FragmentSpread:
+			p.out.WriteString("...")
+			p.out.WriteString(s.Name)
+			p.directives(s.Directives)
+		case *InlineFragment:
+			p.out.WriteString("...")
+			if len(s.TypeCondition) > 0 {
+				p.out.WriteString("on ")
+				p.out.WriteString(s.TypeCondition)
+			}
+			p.directives(s.Directives)
+			p.selectionSet(s.SelectionSet)
+		}
+	}
+	p.out.WriteByte('}')
+}
+
+func (p *printer) directives(directives []*Directive) {
+	for _, directive := range directives {
+		p.space()
+		p.out.WriteByte('@')
+		p.out.WriteString(directive.Name)
+		p.arguments(directive.Arguments)
+	}
+}
+
+func (p *printer) arguments(arguments []*Argument) {
+	if len(arguments) == 0 {
+		return
+	}
+	if p.normalize {
+		arguments = append([]*Argument{}, arguments...)
+		sort.SliceStable(arguments, func(i, j int) bool { return arguments[i].Name < arguments[j].Name })
+	}
+
+	p.out.WriteByte('(')
+	for i, argument := range arguments {
+		if i > 0 {
+			p.out.WriteByte(',')
+		}
+		p.out.WriteString(argument.Name)
+		p.out.WriteByte(':')
+		p.value(argument.Value)
+	}
+	p.out.WriteByte(')')
+}
+
+func (p *printer) value(value *Value) {
+	switch value.Kind {
+	case ValueVariable:
+		p.out.WriteByte('$')
+		p.out.WriteString(value.Raw)
+	case ValueString:
+		if p.normalize {
+			p.out.WriteString(`""`)
+		} else {
+			p.string(value.Raw)
+		}
+	case ValueInt, ValueFloat:
+		if p.normalize {
+			p.out.WriteByte('0')
+		} else {
+			p.out.WriteString(value.Raw)
+		}
+	case ValueList:
+		if p.normalize {
+			p.out.WriteString("[]")
+			return
+		}
+		p.out.WriteByte('[')
+		for i, item := range value.List {
+			if i > 0 {
+				p.out.WriteByte(',')
+			}
+			p.value(item)
+		}
+		p.out.WriteByte(']')
+	case ValueObject:
+		if p.normalize {
+			p.out.WriteString("{}")
+			return
+		}
+		p.out.WriteByte('{')
+		for i, field := range value.Fields {
+			if i > 0 {
+				p.out.WriteByte(',')
+			}
+			p.out.WriteString(field.Name)
+			p.out.WriteByte(':')
+			p.value(field.Value)
+		}
+		p.out.WriteByte('}')
+	default:
+		// Booleans, enums and null
+		p.out.WriteString(value.Raw)
+	}
+}
+
+// string writes value as a graphql string
+func (p *printer) string(value string) {
+	p.out.WriteByte('"')
+	for _, c := range value {
+		switch c {
+		case '"':
+			p.out.WriteString(`\"`)
+		case '\\':
+			p.out.WriteString(`\\`)
+		case '\n':
+			p.out.WriteString(`\n`)
+		case '\r':
+			p.out.WriteString(`\r`)
+		case '\t':
+			p.out.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				p.out.WriteString(`\u00`)
+				if c < 0x10 {
+					p.out.WriteByte('0')
+				}
+				p.out.WriteString(strconv.FormatInt(int64(c), 16))
+			} else {
+				p.out.WriteRune(c)
+			}
+		}
+	}
+	p.out.WriteByte('"')
+}
@@ -0,0 +1,62 @@
+package ast
+
+import (
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func TestPrint(t *testing.T) {
+	query := `query Users($first: Int = 10 @constraint(max: 100), $ids: [ID!]! @a @b) @cache(maxAge: 60) {
+	# the users
+	u: users(first: $first, filter: {ids: $ids, name: "a\"b\n", roles: [ADMIN, USER], active: true, age: null}) {
+		name
+		...UserFields @include(if: true)
+		... on Admin { level }
+	}
+}
+
+fragment UserFields on User { id }`
+	doc, errs := Parse(query)
+	a.Equal(t, 0, len(errs))
+
+	printed := Print(doc)
+	a.Equal(t, `query Users($first:Int=10 @constraint(max:100),$ids:[ID!]!@a @b)@cache(maxAge:60){u:users(first:$first,filter:{ids:$ids,name:"a\"b\n",roles:[ADMIN,USER],active:true,age:null}){name ...UserFields @include(if:true) ...on Admin{level}}}fragment UserFields on User{id}`, printed)
+
+	// The printed query parses into the same document
+	reparsed, errs := Parse(printed)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, printed, Print(reparsed))
+}
+
+func TestNormalize(t *testing.T) {
+	doc, errs := Parse(`query A($b: Int, $a: String) {
+	users(name: "john", first: 10, filter: {ids: [1, 2]}, active: true, role: ADMIN, after: $a) {
+		...F
+	}
+	other(x: $b)
+}
+query B { c }
+fragment F on User { id ...G }
+fragment G on User { name }`)
+	a.Equal(t, 0, len(errs))
+
+	a.Equal(t, `query A($a:String,$b:Int){users(active:true,after:$a,filter:{},first:0,name:"",role:ADMIN){...F} other(x:$b)}fragment F on User{id ...G}fragment G on User{name}`, Normalize(doc, "A"))
+	a.Equal(t, `query B{c}`, Normalize(doc, "B"))
+	a.Equal(t, `query B{c}`, Normalize(doc, ""))
+	a.Equal(t, "", Normalize(doc, "C"))
+
+	// Equal operations with different literals, formatting and argument order have the same fingerprint
+	other, errs := Parse(`# comment
+query A($a: String, $b: Int) {
+	users(first: 20 name: "jane" active: true role: ADMIN after: $a filter: {ids: []}) { ...F }
+	other(x: $b)
+}
+fragment G on User { name }
+fragment F on User { id ...G }`)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, Fingerprint(doc, "A"), Fingerprint(other, "A"))
+	a.Equal(t, 64, len(Fingerprint(doc, "A")))
+	a.NotEqual(t, Fingerprint(doc, "A"), Fingerprint(doc, "B"))
+	a.Equal(t, "", Fingerprint(doc, "C"))
+}
@@ -0,0 +1,93 @@
+package ast
+
+// Visitor is called for every node visited by Walk
+// If the returned visitor w is not nil, Walk visits each of the children of node with w followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the document in depth-first order starting at node
+// Fragment spreads are not followed into their fragment definition, the fragments are visited as part of the document
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Document:
+		for _, operation := range n.Operations {
+			Walk(v, operation)
+		}
+		for _, fragment := range n.Fragments {
+			Walk(v, fragment)
+		}
+	case *Operation:
+		for _, variable := range n.Variables {
+			Walk(v, variable)
+		}
+		walkDirectives(v, n.Directives)
+		walkSelectionSet(v, n.SelectionSet)
+	case *VariableDefinition:
+		if n.DefaultValue != nil {
+			Walk(v, n.DefaultValue)
+		}
+		walkDirectives(v, n.Directives)
+	case *Fragment:
+		walkSelectionSet(v, n.SelectionSet)
+	case *Field:
+		for _, argument := range n.Arguments {
+			Walk(v, argument)
+		}
+		walkDirectives(v, n.Directives)
+		walkSelectionSet(v, n.SelectionSet)
+	case *FragmentSpread:
+		walkDirectives(v, n.Directives)
+	case *InlineFragment:
+		walkDirectives(v, n.Directives)
+		walkSelectionSet(v, n.SelectionSet)
+	case *Directive:
+		for _, argument := range n.Arguments {
+			Walk(v, argument)
+		}
+	case *Argument:
+		Walk(v, n.Value)
+	case *Value:
+		for _, item := range n.List {
+			Walk(v, item)
+		}
+		for _, field := range n.Fields {
+			Walk(v, field)
+		}
+	case *ObjectField:
+		Walk(v, n.Value)
+	}
+
+	v.Visit(nil)
+}
+
+func walkDirectives(v Visitor, directives []*Directive) {
+	for _, directive := range directives {
+		Walk(v, directive)
+	}
+}
+
+func walkSelectionSet(v Visitor, selectionSet []Selection) {
+	for _, selection := range selectionSet {
+		Walk(v, selection)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the document in depth-first order starting at node
+// It calls f(node) for every node, if f returns true the children of node are inspected followed by a call of f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
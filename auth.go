@@ -0,0 +1,159 @@
+package yarql
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// Authorizer tells the @auth directive who made the request, see (*yarql.Ctx).SetAuthorizer
+type Authorizer interface {
+	// Authenticated returns true if the request is made by an authenticated user
+	Authenticated() bool
+	// HasRole returns true if the authenticated user has role
+	HasRole(role string) bool
+}
+
+// AuthError is the error of a field or type that is not resolved because the @auth check failed
+// The error is added to the response with Code in its extensions
+type AuthError struct {
+	Code string // UNAUTHENTICATED if the user is not authenticated or FORBIDDEN if the user doesn't have the Role
+	Role string // The role required by the field or type, empty if the field or type only requires the user to be authenticated
+}
+
+func (e *AuthError) Error() string {
+	if e.Code == "UNAUTHENTICATED" {
+		return "not authenticated"
+	}
+	return "requires the " + e.Role + " role"
+}
+
+// fieldAuth is a role registered using (*Schema).RegisterFieldAuth
+type fieldAuth struct {
+	goType    reflect.Type
+	fieldName string
+	role      string
+}
+
+// RegisterTypeAuth requires users to have role to resolve values of a struct or interface type, an empty role only requires the user to be authenticated
+// Values of the type are null with an error if the check fails, the type is marked with the @auth directive in the SDL
+//
+// Example:
+//
+//	s.RegisterTypeAuth(Invoice{}, "ADMIN")
+func (s *Schema) RegisterTypeAuth(goType interface{}, role string) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterTypeAuth() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t, ok := structOrInterfaceType(goType)
+	if !ok {
+		return errors.New("can only register auth on struct and interface types")
+	}
+	if t.Name() == "" {
+		return errors.New("cannot register auth on an inline type")
+	}
+
+	s.typeAuth[t] = role
+	return nil
+}
+
+// RegisterFieldAuth requires users to have role to resolve a field, an empty role only requires the user to be authenticated
+// fieldName is the graphql name of the field, this can also be a method or root resolver
+// The role can also be set using the auth field tag (`gq:",auth=ADMIN"` or `gq:",auth"`)
+// The field is null with an error if the check fails and the resolver of the field is not called
+//
+// Example:
+//
+//	s.RegisterFieldAuth(QueryRoot{}, "users", "ADMIN")
+func (s *Schema) RegisterFieldAuth(goType interface{}, fieldName string, role string) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterFieldAuth() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t, ok := structOrInterfaceType(goType)
+	if !ok {
+		return errors.New("can only register auth on fields of struct and interface types")
+	}
+
+	s.fieldAuth = append(s.fieldAuth, fieldAuth{
+		goType:    t,
+		fieldName: fieldName,
+		role:      role,
+	})
+	return nil
+}
+
+// checkFieldAuth sets the roles registered using (*Schema).RegisterFieldAuth on the fields
+func (c *parseCtx) checkFieldAuth() error {
+	for _, fieldAuth := range c.schema.fieldAuth {
+		typeObj, ok, err := c.registeredType(fieldAuth.goType)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("cannot register auth on " + fieldAuth.goType.String())
+		}
+		field, ok := typeObj.objContents.getByName(fieldAuth.fieldName)
+		if !ok {
+			return errors.New(typeObj.typeName + " has no field " + fieldAuth.fieldName + " to register auth on")
+		}
+		role := fieldAuth.role
+		field.auth = &role
+		c.schema.auth = true
+	}
+	return nil
+}
+
+// SetAuthorizer sets the authorizer used to check the @auth directives of the fields and types resolved within this request
+// Extensions can use this within the ParseStart hook to create the authorizer from the request headers, see (yarql.ResolveOptions).Authorizer
+func (ctx *Ctx) SetAuthorizer(authorizer Authorizer) {
+	ctx.authorizer = authorizer
+}
+
+// Authorizer returns the authorizer of the request, nil if none is set
+func (ctx *Ctx) Authorizer() Authorizer {
+	return ctx.authorizer
+}
+
+// fieldAuthorized checks the @auth requirement of field and reports the error if the user is not allowed to resolve it
+// This is used by both the default executor and the Engine so an ExecutionStrategy cannot skip the check
+func (ctx *Ctx) fieldAuthorized(field *obj) bool {
+	return field.auth == nil || ctx.authorized(*field.auth)
+}
+
+// authorized checks if the user is allowed to resolve a field or type that requires role and reports the error if not
+// Users are never authenticated if no authorizer is set
+func (ctx *Ctx) authorized(role string) bool {
+	if ctx.authorizer == nil || !ctx.authorizer.Authenticated() {
+		ctx.addErr(&AuthError{Code: "UNAUTHENTICATED", Role: role})
+		return false
+	}
+	if len(role) > 0 && !ctx.authorizer.HasRole(role) {
+		ctx.addErr(&AuthError{Code: "FORBIDDEN", Role: role})
+		return false
+	}
+	return true
+}
+
+// authDirectiveDefinition is the definition of the @auth directive added to the SDL if a type or field requires auth
+const authDirectiveDefinition = "directive @auth(requires: String) on OBJECT | INTERFACE | FIELD_DEFINITION\n"
+
+// writeSDLAuth writes the @auth directive of a type or field
+func writeSDLAuth(res *bytes.Buffer, auth *string) {
+	if auth == nil {
+		return
+	}
+	if len(*auth) == 0 {
+		res.WriteString(" @auth")
+	} else {
+		res.WriteString(" @auth(requires: " + strconv.Quote(*auth) + ")")
+	}
+}
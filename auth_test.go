@@ -0,0 +1,147 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestAuthQuery struct {
+	Name    string
+	Email   string `gq:",auth"`
+	Salary  int    `gq:",auth=ADMIN"`
+	Invoice TestAuthInvoice
+}
+
+// testAuthUsersCalls counts the calls of ResolveUsers
+var testAuthUsersCalls = 0
+
+func (TestAuthQuery) ResolveUsers() []string {
+	testAuthUsersCalls++
+	return []string{"a", "b"}
+}
+
+type TestAuthInvoice struct {
+	Total int
+}
+
+type testAuthorizer struct {
+	roles []string
+}
+
+func (a testAuthorizer) Authenticated() bool {
+	return true
+}
+
+func (a testAuthorizer) HasRole(role string) bool {
+	for _, r := range a.roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type testAuthExtension struct {
+	BaseExtension
+}
+
+func (testAuthExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) {
+	if ctx.Header().Get("Authorization") == "admin" {
+		ctx.SetAuthorizer(testAuthorizer{roles: []string{"ADMIN"}})
+	}
+	return query, nil
+}
+
+var testAuthData = TestAuthQuery{Name: "a", Email: "a@example.com", Salary: 10, Invoice: TestAuthInvoice{Total: 5}}
+
+func registerTestAuth(t *testing.T, s *Schema) {
+	a.NoError(t, s.RegisterFieldAuth(TestAuthQuery{}, "users", "ADMIN"))
+	a.NoError(t, s.RegisterTypeAuth(TestAuthInvoice{}, "BILLING"))
+	a.NoError(t, s.RegisterExtension(testAuthExtension{}))
+}
+
+func TestAuthNotAuthenticated(t *testing.T) {
+	s := NewSchema()
+	registerTestAuth(t, s)
+
+	testAuthUsersCalls = 0
+	res, errs := bytecodeParse(t, s, `{name email salary users invoice {total}}`, testAuthData, M{}, ResolveOptions{})
+	a.Equal(t, 4, len(errs))
+	a.Equal(t, 0, testAuthUsersCalls)
+	a.Equal(t, `{"data":{"name":"a","email":null,"salary":null,"users":null,"invoice":null},"errors":[`+
+		`{"message":"not authenticated","path":["email"],"locations":[{"line":1,"column":7}],"extensions":{"code":"UNAUTHENTICATED"}},`+
+		`{"message":"not authenticated","path":["salary"],"locations":[{"line":1,"column":13}],"extensions":{"code":"UNAUTHENTICATED"}},`+
+		`{"message":"not authenticated","path":["users"],"locations":[{"line":1,"column":20}],"extensions":{"code":"UNAUTHENTICATED"}},`+
+		`{"message":"not authenticated","path":["invoice"],"locations":[{"line":1,"column":26}],"extensions":{"code":"UNAUTHENTICATED"}}`+
+		`],"extensions":{}}`, res)
+}
+
+func TestAuthRoles(t *testing.T) {
+	query := `{name email salary users invoice {total}}`
+
+	s := NewSchema()
+	registerTestAuth(t, s)
+	res, errs := bytecodeParse(t, s, query, testAuthData, M{}, ResolveOptions{NoMeta: true, Authorizer: testAuthorizer{}})
+	a.Equal(t, 3, len(errs))
+	a.Equal(t, "requires the ADMIN role", errs[0].Error())
+	a.Equal(t, "requires the BILLING role", errs[2].Error())
+	a.Equal(t, `{"name":"a","email":"a@example.com","salary":null,"users":null,"invoice":null}`, res)
+
+	s = NewSchema()
+	registerTestAuth(t, s)
+	testAuthUsersCalls = 0
+	res, errs = bytecodeParse(t, s, query, testAuthData, M{}, ResolveOptions{NoMeta: true, Authorizer: testAuthorizer{roles: []string{"ADMIN", "BILLING"}}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, 1, testAuthUsersCalls)
+	a.Equal(t, `{"name":"a","email":"a@example.com","salary":10,"users":["a","b"],"invoice":{"total":5}}`, res)
+}
+
+func TestAuthFromExtension(t *testing.T) {
+	s := NewSchema()
+	registerTestAuth(t, s)
+
+	// The authorizer can be set by extensions
+	res, errs := bytecodeParse(t, s, `{salary}`, testAuthData, M{}, ResolveOptions{NoMeta: true, Header: map[string][]string{"Authorization": {"admin"}}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"salary":10}`, res)
+}
+
+func TestAuthSDL(t *testing.T) {
+	s := NewSchema()
+	registerTestAuth(t, s)
+	a.NoError(t, s.Parse(testAuthData, M{}, nil))
+
+	sdl := s.SDL()
+	for _, expected := range []string{
+		"directive @auth(requires: String) on OBJECT | INTERFACE | FIELD_DEFINITION\n",
+		"type TestAuthInvoice @auth(requires: \"BILLING\") {\n",
+		"\temail: String! @auth\n",
+		"\tsalary: Int! @auth(requires: \"ADMIN\")\n",
+		"\tusers: [String!] @auth(requires: \"ADMIN\")\n",
+	} {
+		a.True(t, strings.Contains(sdl, expected), expected+"\n\n"+sdl)
+	}
+
+	s = NewSchema()
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{}, M{}, nil))
+	a.False(t, strings.Contains(s.SDL(), "@auth"))
+}
+
+func TestRegisterAuthInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterTypeAuth(nil, "ADMIN"))
+	a.Error(t, s.RegisterTypeAuth("", "ADMIN"))
+	a.Error(t, s.RegisterTypeAuth(struct{}{}, "ADMIN"))
+	a.Error(t, s.RegisterFieldAuth(nil, "name", "ADMIN"))
+	a.Error(t, s.RegisterFieldAuth("", "name", "ADMIN"))
+
+	a.NoError(t, s.RegisterFieldAuth(TestAuthQuery{}, "unknown", "ADMIN"))
+	a.Error(t, s.Parse(TestAuthQuery{}, M{}, nil))
+
+	s = NewSchema()
+	a.NoError(t, s.Parse(TestAuthQuery{}, M{}, nil))
+	a.Error(t, s.RegisterTypeAuth(TestAuthInvoice{}, "ADMIN"))
+	a.Error(t, s.RegisterFieldAuth(TestAuthQuery{}, "name", "ADMIN"))
+}
@@ -0,0 +1,83 @@
+package yarql
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/valyala/fastjson"
+)
+
+// batchResult is the response of a single operation within a batched request
+type batchResult struct {
+	response []byte
+	errs     []error
+}
+
+// handleBatchConcurrently resolves the operations of a batched request using copies of the schema borrowed from the pool
+// At most (*Schema).BatchConcurrency operations are resolved at the same time, the responses are written in the order of the batch
+func (s *Schema) handleBatchConcurrently(items []*fastjson.Value, options *RequestOptions, loaders *batchLoaders) ([]byte, []error) {
+	results := make([]batchResult, len(items))
+	slots := make(chan struct{}, s.BatchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+
+		query, operationName, variables, extensions, err := getBodyData(item)
+		if err != nil {
+			response, errs := requestErrResponse(err.Error())
+			results[i] = batchResult{response: response, errs: errs}
+			continue
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			c := s.pool.Get().(*Schema)
+			errs := c.handleSingleRequest(query, variables, operationName, extensions, batchEntryOptions(options), loaders)
+			results[i] = batchResult{
+				response: append([]byte(nil), c.Result...),
+				errs:     append([]error(nil), errs...),
+			}
+			c.release()
+			s.pool.Put(c)
+		}(i)
+	}
+	wg.Wait()
+
+	responseErrs := []error{}
+	response := bytes.NewBuffer([]byte("["))
+	for _, result := range results {
+		if result.response == nil {
+			continue
+		}
+		if response.Len() > 1 {
+			response.WriteByte(',')
+		}
+		response.Write(result.response)
+		responseErrs = append(responseErrs, result.errs...)
+	}
+	response.WriteByte(']')
+	return response.Bytes(), responseErrs
+}
+
+// batchEntryOptions returns the options for a single operation of a concurrently resolved batch
+// The values are copied as (*Ctx).SetValue writes to the same map
+func batchEntryOptions(options *RequestOptions) *RequestOptions {
+	if options == nil || options.Values == nil {
+		return options
+	}
+	entryOptions := *options
+	entryOptions.Values = make(map[string]interface{}, len(options.Values))
+	for key, value := range options.Values {
+		entryOptions.Values[key] = value
+	}
+	return &entryOptions
+}
@@ -0,0 +1,83 @@
+package yarql
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestBatchData struct{}
+
+var testBatchRunning, testBatchMaxRunning int
+var testBatchLock sync.Mutex
+
+func (TestBatchData) ResolveEcho(ctx *Ctx, args struct{ Value int }) int {
+	testBatchLock.Lock()
+	testBatchRunning++
+	if testBatchRunning > testBatchMaxRunning {
+		testBatchMaxRunning = testBatchRunning
+	}
+	testBatchLock.Unlock()
+
+	ctx.SetValue("value", args.Value)
+	time.Sleep(time.Millisecond * 10)
+
+	testBatchLock.Lock()
+	testBatchRunning--
+	testBatchLock.Unlock()
+	return ctx.GetValue("value").(int)
+}
+
+func handleTestBatch(s *Schema, body string) (string, []error) {
+	res, errs := s.HandleRequest(
+		"POST",
+		func(key string) string { return "" },
+		func(key string) (string, error) { return "", errors.New("this should not be called") },
+		func() []byte { return []byte(body) },
+		"application/json",
+		&RequestOptions{Values: map[string]interface{}{}},
+	)
+	return string(res), errs
+}
+
+func TestHandleRequestBatchConcurrency(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestBatchData{}, M{}, nil))
+	s.BatchConcurrency = 3
+
+	operations := []string{}
+	expected := []string{}
+	for i := 0; i < 8; i++ {
+		operations = append(operations, `{"query": "{echo(value: `+strconv.Itoa(i)+`)}"}`)
+		expected = append(expected, `{"data":{"echo":`+strconv.Itoa(i)+`}}`)
+	}
+	operations = append(operations, `{}`)
+	expected = append(expected, `{"data":{},"errors":[{"message":"query should be defined"}],"extensions":{}}`)
+
+	testBatchMaxRunning = 0
+	res, errs := handleTestBatch(s, "["+strings.Join(operations, ",")+"]")
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "query should be defined", errs[0].Error())
+	a.Equal(t, "["+strings.Join(expected, ",")+"]", res)
+	a.True(t, testBatchMaxRunning > 1)
+	a.True(t, testBatchMaxRunning <= 3)
+}
+
+func TestHandleRequestMaxBatchSize(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestBatchData{}, M{}, nil))
+	s.MaxBatchSize = 2
+
+	res, errs := handleTestBatch(s, `[{"query": "{echo(value: 1)}"}, {"query": "{echo(value: 2)}"}]`)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `[{"data":{"echo":1}},{"data":{"echo":2}}]`, res)
+
+	_, errs = handleTestBatch(s, `[{"query": "{echo(value: 1)}"}, {"query": "{echo(value: 2)}"}, {"query": "{echo(value: 3)}"}]`)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "batch contains 3 operations, the max is 2", errs[0].Error())
+}
@@ -0,0 +1,437 @@
+// Package bindgen generates static bindings (yarql.Binding) for the go types of a schema
+// With the bindings the executor reads struct fields and calls resolver methods directly instead of using reflection
+//
+// The generator uses reflection on the root types so it needs to be ran from a small program that imports them, for example:
+//
+//	//go:build ignore
+//
+//	package main
+//
+//	func main() {
+//		src, err := bindgen.Generate([]interface{}{QueryRoot{}, MethodRoot{}}, bindgen.Options{PkgPath: "example.com/app/graph"})
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		os.WriteFile("bindings_gen.go", src, 0644)
+//	}
+//
+// The generated file contains a RegisterBindings(s *yarql.Schema) error function that should be called before (*yarql.Schema).Parse
+package bindgen
+
+import (
+	"bytes"
+	"errors"
+	"go/format"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options changes the generated code
+type Options struct {
+	// Package is the package name of the generated code, defaults to the package name of the first root type
+	Package string
+	// PkgPath is the import path of the package the generated code is placed in, types of this package are used without qualifier
+	// Defaults to the package path of the first root type
+	PkgPath string
+}
+
+const yarqlPkgPath = "github.com/mjarkk/yarql"
+
+// Generate generates the go source code of the bindings of roots and all struct types reachable from roots
+// A root is a struct value like QueryRoot{} or a pointer to a struct
+// Types that are only reachable through interfaces are not found and should be added to roots
+func Generate(roots []interface{}, opts Options) ([]byte, error) {
+	if len(roots) == 0 {
+		return nil, errors.New("no root types given")
+	}
+
+	g := generator{
+		opts:     opts,
+		seen:     map[reflect.Type]bool{},
+		imports:  map[string]string{},
+		pkgNames: map[string]string{},
+	}
+	for _, root := range roots {
+		t := reflect.TypeOf(root)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct || t.Name() == "" {
+			return nil, errors.New("root types must be named structs")
+		}
+		if len(g.opts.PkgPath) == 0 {
+			g.opts.PkgPath = t.PkgPath()
+		}
+		if len(g.opts.Package) == 0 {
+			g.opts.Package = pkgName(t)
+		}
+		g.walk(t)
+	}
+	if g.opts.PkgPath != yarqlPkgPath {
+		g.imports[yarqlPkgPath] = "yarql"
+		g.pkgNames["yarql"] = yarqlPkgPath
+	}
+
+	return g.generate()
+}
+
+type generator struct {
+	opts  Options
+	seen  map[reflect.Type]bool
+	types []reflect.Type
+	body  bytes.Buffer
+	// imports maps package paths to the names used in the generated code
+	imports map[string]string
+	// pkgNames maps the names used in the generated code back to the package path
+	pkgNames map[string]string
+}
+
+func (g *generator) line(parts ...string) {
+	for _, part := range parts {
+		g.body.WriteString(part)
+	}
+	g.body.WriteByte('\n')
+}
+
+// walk adds t and all struct types reachable through its fields and resolver methods
+func (g *generator) walk(t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		g.walk(t.Elem())
+		return
+	case reflect.Func:
+		for i := 0; i < t.NumOut(); i++ {
+			g.walk(t.Out(i))
+		}
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+
+	if g.seen[t] {
+		return
+	}
+	g.seen[t] = true
+	if t.Name() == "" {
+		// Inline structs cannot have a binding but their fields can contain named structs
+		for i := 0; i < t.NumField(); i++ {
+			g.walk(t.Field(i).Type)
+		}
+		return
+	}
+	g.types = append(g.types, t)
+
+	for _, field := range reflect.VisibleFields(t) {
+		if field.IsExported() && !isIgnored(field) {
+			g.walk(field.Type)
+		}
+	}
+	// The method set of the pointer type also contains the methods with a pointer receiver
+	ptrType := reflect.PtrTo(t)
+	for i := 0; i < ptrType.NumMethod(); i++ {
+		method := ptrType.Method(i)
+		if isResolver(method) {
+			g.walk(method.Type)
+		}
+	}
+}
+
+func (g *generator) generate() ([]byte, error) {
+	sort.SliceStable(g.types, func(i, j int) bool {
+		return g.types[i].PkgPath()+"."+g.types[i].Name() < g.types[j].PkgPath()+"."+g.types[j].Name()
+	})
+
+	yarql := g.qualifier(yarqlPkgPath)
+	registered := 0
+	for _, t := range g.types {
+		typeName, ok := g.typeExpr(t)
+		if !ok {
+			// The type cannot be referenced from the generated package, the executor uses reflection for it
+			continue
+		}
+
+		fields := g.fields(t, typeName)
+		methods := g.methods(t, typeName)
+		if len(fields) == 0 && len(methods) == 0 {
+			continue
+		}
+		if registered == 0 {
+			g.line("var err error")
+		}
+		registered++
+
+		g.line("err = s.RegisterBinding(", typeName, "{}, ", yarql, "Binding{")
+		if len(fields) > 0 {
+			g.line("Fields: map[string]", yarql, "FieldBinding{")
+			for _, field := range fields {
+				g.line(field)
+			}
+			g.line("},")
+		}
+		if len(methods) > 0 {
+			g.line("Methods: map[string]", yarql, "MethodBinding{")
+			for _, method := range methods {
+				g.line(method)
+			}
+			g.line("},")
+		}
+		g.line("})")
+		g.line("if err != nil {")
+		g.line("return err")
+		g.line("}")
+	}
+	g.line("return nil")
+	g.line("}")
+	body := g.body.Bytes()
+
+	header := bytes.NewBuffer(nil)
+	header.WriteString("// Code generated by github.com/mjarkk/yarql/bindgen. DO NOT EDIT.\n\n")
+	header.WriteString("package " + g.opts.Package + "\n\nimport (\n")
+	paths := []string{}
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		name := g.imports[path]
+		if name == pkgNameOfPath(path) {
+			header.WriteString(strconv.Quote(path) + "\n")
+		} else {
+			header.WriteString(name + " " + strconv.Quote(path) + "\n")
+		}
+	}
+	header.WriteString(")\n\n")
+	header.WriteString("// RegisterBindings registers the generated bindings on s, call it before (*yarql.Schema).Parse\n")
+	header.WriteString("func RegisterBindings(s *" + yarql + "Schema) error {\n")
+	header.Write(body)
+
+	res, err := format.Source(header.Bytes())
+	if err != nil {
+		return nil, errors.New("generated invalid go code, " + err.Error())
+	}
+	return res, nil
+}
+
+// fields returns the field bindings of t
+// Fields promoted through an embedded pointer are skipped as the embedded pointer can be nil
+func (g *generator) fields(t reflect.Type, typeName string) []string {
+	unsafe := g.qualifier("unsafe")
+	res := []string{}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous || isIgnored(field) {
+			continue
+		}
+		if byName, ok := t.FieldByName(field.Name); !ok || !equalIndex(byName.Index, field.Index) {
+			// The field name is ambiguous
+			continue
+		}
+		if promotedThroughPtr(t, field.Index) {
+			continue
+		}
+
+		res = append(res, strconv.Quote(field.Name)+": func(parent "+unsafe+"Pointer) "+unsafe+"Pointer { return "+unsafe+"Pointer(&(*"+typeName+")(parent)."+field.Name+") },")
+	}
+	return res
+}
+
+// methods returns the method bindings of the resolver methods of t
+// Methods with inputs or outputs that cannot be referenced from the generated package are skipped
+func (g *generator) methods(t reflect.Type, typeName string) []string {
+	res := []string{}
+	ptrType := reflect.PtrTo(t)
+	for i := 0; i < ptrType.NumMethod(); i++ {
+		method := ptrType.Method(i)
+		if !isResolver(method) || method.Type.IsVariadic() || method.Type.NumOut() == 0 {
+			continue
+		}
+
+		// The first input of method.Type is the receiver
+		ins := []string{}
+		for j := 1; j < method.Type.NumIn(); j++ {
+			inType, ok := g.typeExpr(method.Type.In(j))
+			if !ok {
+				ins = nil
+				break
+			}
+			ins = append(ins, "ins["+strconv.Itoa(j-1)+"].Interface().("+inType+")")
+		}
+		if ins == nil {
+			continue
+		}
+		referencable := true
+		for j := 0; j < method.Type.NumOut(); j++ {
+			_, ok := g.typeExpr(method.Type.Out(j))
+			referencable = referencable && ok
+		}
+		if !referencable {
+			continue
+		}
+
+		reflect := g.qualifier("reflect")
+		unsafe := g.qualifier("unsafe")
+		outs := []string{}
+		outValues := []string{}
+		for j := 0; j < method.Type.NumOut(); j++ {
+			out := "out" + strconv.Itoa(j)
+			outs = append(outs, out)
+			// Using a pointer to the output keeps the static type of interfaces and makes the value addressable so bindings can be used for it
+			outValues = append(outValues, reflect+"ValueOf(&"+out+").Elem()")
+		}
+
+		res = append(res, strconv.Quote(method.Name)+": func(parent "+unsafe+"Pointer, ins []"+reflect+"Value) []"+reflect+"Value {\n"+
+			strings.Join(outs, ", ")+" := (*"+typeName+")(parent)."+method.Name+"("+strings.Join(ins, ", ")+")\n"+
+			"return []"+reflect+"Value{"+strings.Join(outValues, ", ")+"}\n"+
+			"},")
+	}
+	return res
+}
+
+// typeExpr returns the go code of type t, ok is false if t cannot be referenced from the generated package
+func (g *generator) typeExpr(t reflect.Type) (expr string, ok bool) {
+	if t.Name() != "" {
+		if strings.Contains(t.Name(), "[") {
+			// Instantiated generic types are not supported
+			return "", false
+		}
+		if t.PkgPath() == "" {
+			// Builtin types like int and error
+			return t.Name(), true
+		}
+		if t.PkgPath() != g.opts.PkgPath && !isExported(t.Name()) {
+			return "", false
+		}
+		return g.qualifier(t.PkgPath()) + t.Name(), true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, ok := g.typeExpr(t.Elem())
+		return "*" + elem, ok
+	case reflect.Slice:
+		elem, ok := g.typeExpr(t.Elem())
+		return "[]" + elem, ok
+	case reflect.Array:
+		elem, ok := g.typeExpr(t.Elem())
+		return "[" + strconv.Itoa(t.Len()) + "]" + elem, ok
+	case reflect.Map:
+		key, keyOk := g.typeExpr(t.Key())
+		elem, elemOk := g.typeExpr(t.Elem())
+		return "map[" + key + "]" + elem, keyOk && elemOk
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", true
+		}
+		return "", false
+	case reflect.Struct:
+		// Inline structs are often used as arguments of resolver methods
+		fields := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() && field.PkgPath != g.opts.PkgPath {
+				return "", false
+			}
+			fieldType, ok := g.typeExpr(field.Type)
+			if !ok {
+				return "", false
+			}
+			fieldExpr := field.Name + " " + fieldType
+			if field.Anonymous {
+				fieldExpr = fieldType
+			}
+			if len(field.Tag) > 0 {
+				fieldExpr += " " + quoteTag(string(field.Tag))
+			}
+			fields = append(fields, fieldExpr)
+		}
+		return "struct{ " + strings.Join(fields, "; ") + " }", true
+	default:
+		return "", false
+	}
+}
+
+// qualifier returns the prefix used to reference a type of the package with path, for example "yarql."
+func (g *generator) qualifier(path string) string {
+	if path == g.opts.PkgPath {
+		return ""
+	}
+	if name, ok := g.imports[path]; ok {
+		return name + "."
+	}
+
+	name := pkgNameOfPath(path)
+	for i := 2; ; i++ {
+		if _, taken := g.pkgNames[name]; !taken {
+			break
+		}
+		name = pkgNameOfPath(path) + strconv.Itoa(i)
+	}
+	g.imports[path] = name
+	g.pkgNames[name] = path
+	return name + "."
+}
+
+// pkgName returns the package name of the named type t
+func pkgName(t reflect.Type) string {
+	name := t.String()
+	return name[:strings.LastIndex(name, "."+t.Name())]
+}
+
+// pkgNameOfPath returns the package name used for the import path, it assumes the package name matches the last element of the path
+func pkgNameOfPath(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	return strings.Map(func(c rune) rune {
+		if c == '-' || c == '.' {
+			return '_'
+		}
+		return c
+	}, name)
+}
+
+// isResolver returns true for the methods that yarql uses as field, see (*yarql.Schema).Parse
+func isResolver(method reflect.Method) bool {
+	name := strings.TrimPrefix(method.Name, "Resolve")
+	return len(name) < len(method.Name) && len(name) > 0 && isExported(name)
+}
+
+// quoteTag returns tag as a raw string literal if possible
+func quoteTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+func isIgnored(field reflect.StructField) bool {
+	return field.Tag.Get("gq") == "-"
+}
+
+func isExported(name string) bool {
+	return len(name) > 0 && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+func equalIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// promotedThroughPtr returns true if the field at index is promoted through an embedded pointer
+func promotedThroughPtr(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Ptr {
+			return true
+		}
+		t = field.Type
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package bindgen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"github.com/mjarkk/yarql"
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type testQuery struct {
+	Name     string
+	Ignored  string `gq:"-"`
+	internal string
+	testEmbedded
+	*testPtrEmbedded
+	Users []testUser
+}
+
+type testEmbedded struct {
+	Promoted int
+}
+
+type testPtrEmbedded struct {
+	ThroughPtr int
+}
+
+func (testQuery) ResolveUser(ctx *yarql.Ctx, args struct {
+	ID string `gq:"id"`
+}) (*testUser, error) {
+	return nil, nil
+}
+
+func (testQuery) ResolveCount() int {
+	return 0
+}
+
+func (testQuery) Helper() string {
+	return ""
+}
+
+type testUser struct {
+	Name    string
+	Friends func() []testFriend
+}
+
+type testFriend struct {
+	Name string
+}
+
+func TestGenerate(t *testing.T) {
+	source, err := Generate([]interface{}{testQuery{}}, Options{})
+	a.NoError(t, err)
+	code := string(source)
+
+	for _, expected := range []string{
+		"package bindgen",
+		"func RegisterBindings(s *yarql.Schema) error {",
+		"err = s.RegisterBinding(testQuery{}, yarql.Binding{",
+		"func(parent unsafe.Pointer) unsafe.Pointer { return unsafe.Pointer(&(*testQuery)(parent).Name) },",
+		"func(parent unsafe.Pointer) unsafe.Pointer { return unsafe.Pointer(&(*testQuery)(parent).Promoted) },",
+		`"ResolveUser": func(parent unsafe.Pointer, ins []reflect.Value) []reflect.Value {`,
+		"out0, out1 := (*testQuery)(parent).ResolveUser(ins[0].Interface().(*yarql.Ctx), ins[1].Interface().(struct {\n\t\t\t\t\tID string `gq:\"id\"`\n\t\t\t\t}))",
+		"return []reflect.Value{reflect.ValueOf(&out0).Elem(), reflect.ValueOf(&out1).Elem()}",
+		"out0 := (*testQuery)(parent).ResolveCount()",
+		"err = s.RegisterBinding(testUser{}, yarql.Binding{",
+		"err = s.RegisterBinding(testFriend{}, yarql.Binding{",
+	} {
+		a.True(t, strings.Contains(code, expected), expected+"\n\n"+code)
+	}
+	for _, unexpected := range []string{"Ignored", "internal", "ThroughPtr", "Helper"} {
+		a.False(t, strings.Contains(code, unexpected), unexpected+"\n\n"+code)
+	}
+
+	// The generated code must compile together with the types it binds
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+	for _, name := range []string{"bindgen.go", "bindgen_test.go"} {
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		a.NoError(t, err)
+		files = append(files, file)
+	}
+	file, err := parser.ParseFile(fset, "bindings_gen.go", source, 0)
+	a.NoError(t, err)
+	files = append(files, file)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("bindgen", fset, files, nil)
+	a.NoError(t, err)
+}
+
+func TestGenerateOtherPackage(t *testing.T) {
+	// Unexported types cannot be referenced from another package so only the exported yarql types get a binding
+	source, err := Generate([]interface{}{testQuery{}, yarql.PageInfo{}}, Options{Package: "graph", PkgPath: "example.com/graph"})
+	a.NoError(t, err)
+	code := string(source)
+	a.True(t, strings.Contains(code, "package graph"), code)
+	a.True(t, strings.Contains(code, "err = s.RegisterBinding(yarql.PageInfo{}, yarql.Binding{"), code)
+	a.False(t, strings.Contains(code, "testQuery"), code)
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	_, err := Generate(nil, Options{})
+	a.Error(t, err)
+	_, err = Generate([]interface{}{"not a struct"}, Options{})
+	a.Error(t, err)
+	_, err = Generate([]interface{}{struct{ A string }{}}, Options{})
+	a.Error(t, err)
+}
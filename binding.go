@@ -0,0 +1,121 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"unsafe"
+)
+
+// FieldBinding returns a pointer to a field of the struct parent points to
+type FieldBinding func(parent unsafe.Pointer) unsafe.Pointer
+
+// MethodBinding calls a method on the struct parent points to with ins as inputs and returns the outputs like reflect.Value.Call does
+type MethodBinding func(parent unsafe.Pointer, ins []reflect.Value) []reflect.Value
+
+// Binding contains static accessors of a struct type that are used instead of reflection to read fields and call resolver methods
+// Bindings are normally generated using the bindgen package
+type Binding struct {
+	// Fields contains the field bindings by go field name
+	Fields map[string]FieldBinding
+	// Methods contains the method bindings by go method name
+	Methods map[string]MethodBinding
+}
+
+// RegisterBinding registers the static accessors of a struct type
+// The executor uses the binding of a type when the go value of the type is addressable and falls back to reflection otherwise
+//
+// Example:
+//
+//	s.RegisterBinding(User{}, yarql.Binding{
+//		Fields: map[string]yarql.FieldBinding{
+//			"Name": func(parent unsafe.Pointer) unsafe.Pointer { return unsafe.Pointer(&(*User)(parent).Name) },
+//		},
+//	})
+func (s *Schema) RegisterBinding(goType interface{}, binding Binding) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterBinding() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t := reflect.TypeOf(goType)
+	if t.Kind() != reflect.Struct {
+		return errors.New("can only register a binding on struct types")
+	}
+	if t.Name() == "" {
+		return errors.New("cannot register a binding on an inline type")
+	}
+
+	for name, fieldBinding := range binding.Fields {
+		if fieldBinding == nil {
+			return errors.New("binding of field " + name + " cannot be nil")
+		}
+		if _, ok := t.FieldByName(name); !ok {
+			return errors.New(t.Name() + " has no field " + name + ", the binding is probably outdated")
+		}
+	}
+	for name, methodBinding := range binding.Methods {
+		if methodBinding == nil {
+			return errors.New("binding of method " + name + " cannot be nil")
+		}
+		if _, ok := reflect.PtrTo(t).MethodByName(name); !ok {
+			return errors.New(t.Name() + " has no method " + name + ", the binding is probably outdated")
+		}
+	}
+
+	s.bindings[t] = binding
+	return nil
+}
+
+// bindObj adds the registered binding of the struct type t to the fields and methods of typeObj
+func (c *parseCtx) bindObj(t reflect.Type, typeObj *obj) {
+	binding, ok := c.schema.bindings[t]
+	if !ok {
+		return
+	}
+
+	for _, field := range typeObj.objContents.all() {
+		if field.customObjValue != nil || field.customResolver != nil {
+			continue
+		}
+		if field.valueType == valueTypeMethod && field.method.isTypeMethod {
+			field.method.binding = binding.Methods[field.method.goFunctionName]
+		} else if len(field.goFieldName) > 0 {
+			fieldBinding, ok := binding.Fields[field.goFieldName]
+			if !ok {
+				continue
+			}
+			structField, _ := field.goStructField(t)
+			if byName, _ := t.FieldByName(field.goFieldName); !equalIndex(byName.Index, structField.Index) {
+				// The binding is for another field with the same go name
+				continue
+			}
+			field.fieldBinding = fieldBinding
+			field.goFieldType = structField.Type
+		}
+	}
+}
+
+// equalIndex returns true if the struct field indexes a and b are equal
+func equalIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// addressableRootValue returns value as an addressable value so bindings and methods with a pointer receiver can be used for the root types
+func addressableRootValue(value reflect.Value) reflect.Value {
+	if !value.IsValid() || value.CanAddr() {
+		return value
+	}
+	res := reflect.New(value.Type()).Elem()
+	res.Set(value)
+	return res
+}
@@ -0,0 +1,128 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestBindingQ struct {
+	Name  string
+	Users []TestBindingUser
+}
+
+func (TestBindingQ) ResolveUser(ctx *Ctx, args struct{ Name string }) (*TestBindingUser, error) {
+	if args.Name == "panic" {
+		panic("user panicked")
+	}
+	if len(args.Name) == 0 {
+		return nil, errors.New("name is required")
+	}
+	return &TestBindingUser{Name: args.Name + " " + ctx.GetPath().String()}, nil
+}
+
+type TestBindingUser struct {
+	Name string
+}
+
+// testBindingCalls counts the calls of the bindings by go name
+var testBindingCalls = map[string]int{}
+
+// registerTestBindings registers the bindings bindgen generates for TestBindingQ
+func registerTestBindings(t *testing.T, s *Schema) {
+	err := s.RegisterBinding(TestBindingQ{}, Binding{
+		Fields: map[string]FieldBinding{
+			"Name": func(parent unsafe.Pointer) unsafe.Pointer {
+				testBindingCalls["TestBindingQ.Name"]++
+				return unsafe.Pointer(&(*TestBindingQ)(parent).Name)
+			},
+			"Users": func(parent unsafe.Pointer) unsafe.Pointer {
+				testBindingCalls["TestBindingQ.Users"]++
+				return unsafe.Pointer(&(*TestBindingQ)(parent).Users)
+			},
+		},
+		Methods: map[string]MethodBinding{
+			"ResolveUser": func(parent unsafe.Pointer, ins []reflect.Value) []reflect.Value {
+				testBindingCalls["TestBindingQ.ResolveUser"]++
+				out0, out1 := (*TestBindingQ)(parent).ResolveUser(ins[0].Interface().(*Ctx), ins[1].Interface().(struct{ Name string }))
+				return []reflect.Value{reflect.ValueOf(&out0).Elem(), reflect.ValueOf(&out1).Elem()}
+			},
+		},
+	})
+	a.NoError(t, err)
+	err = s.RegisterBinding(TestBindingUser{}, Binding{
+		Fields: map[string]FieldBinding{
+			"Name": func(parent unsafe.Pointer) unsafe.Pointer {
+				testBindingCalls["TestBindingUser.Name"]++
+				return unsafe.Pointer(&(*TestBindingUser)(parent).Name)
+			},
+		},
+	})
+	a.NoError(t, err)
+}
+
+func bindingParse(t *testing.T, query string, withBindings bool) (string, []error) {
+	s := NewSchema()
+	if withBindings {
+		registerTestBindings(t, s)
+	}
+	err := s.Parse(TestBindingQ{Name: "root", Users: []TestBindingUser{{Name: "a"}, {Name: "b"}}}, M{}, nil)
+	a.NoError(t, err)
+	s = s.Copy()
+	errs := s.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+	return string(s.Result), errs
+}
+
+func TestBinding(t *testing.T) {
+	query := `{
+		name
+		users {name}
+		user(name: "c") {name}
+		invalid: user {name}
+		panics: user(name: "panic") {name}
+	}`
+	expected := `{"name":"root","users":[{"name":"a"},{"name":"b"}],"user":{"name":"c [\"user\"]"},"invalid":null,"panics":null}`
+
+	res, errs := bindingParse(t, query, false)
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, expected, res)
+
+	testBindingCalls = map[string]int{}
+	res, errs = bindingParse(t, query, true)
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, "name is required", errs[0].Error())
+	a.Equal(t, "resolver panicked: user panicked", errs[1].Error())
+	a.Equal(t, expected, res)
+
+	expectedCalls := map[string]int{
+		"TestBindingQ.Users":       1,
+		"TestBindingQ.ResolveUser": 3,
+	}
+	if !unsafeFieldOffsets {
+		// With the yarql_unsafe build tag the string fields are read using their offset instead of the binding
+		expectedCalls["TestBindingQ.Name"] = 1
+		// The users are resolved from an addressable slice and the user returned by ResolveUser is made addressable by the binding
+		expectedCalls["TestBindingUser.Name"] = 3
+	}
+	a.Equal(t, expectedCalls, testBindingCalls)
+}
+
+func TestRegisterBindingInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterBinding(nil, Binding{}))
+	a.Error(t, s.RegisterBinding("", Binding{}))
+	a.Error(t, s.RegisterBinding(struct{}{}, Binding{}))
+	a.Error(t, s.RegisterBinding(TestBindingQ{}, Binding{Fields: map[string]FieldBinding{"Unknown": nil}}))
+	a.Error(t, s.RegisterBinding(TestBindingQ{}, Binding{Fields: map[string]FieldBinding{
+		"Unknown": func(parent unsafe.Pointer) unsafe.Pointer { return parent },
+	}}))
+	a.Error(t, s.RegisterBinding(TestBindingQ{}, Binding{Methods: map[string]MethodBinding{
+		"ResolveUnknown": func(parent unsafe.Pointer, ins []reflect.Value) []reflect.Value { return nil },
+	}}))
+
+	a.NoError(t, s.Parse(TestBindingQ{}, M{}, nil))
+	a.Error(t, s.RegisterBinding(TestBindingQ{}, Binding{}))
+}
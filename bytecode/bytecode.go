@@ -1,10 +1,13 @@
 package bytecode
 
 import (
+	"bytes"
 	"encoding/hex"
 	"errors"
 	"hash"
 	"hash/fnv"
+	"sort"
+	"strconv"
 	"unicode/utf16"
 	"unicode/utf8"
 	"unsafe"
@@ -16,6 +19,7 @@ import (
 type ParserCtx struct {
 	Res                  []byte
 	FragmentLocations    []int
+	FieldLocations       []FieldLocation // Sorted by ResIdx
 	Query                []byte
 	charNr               int
 	Errors               []error
@@ -23,8 +27,75 @@ type ParserCtx struct {
 	hasTarget            bool
 	TargetIdx            int // -1 = no matching target was found, >= 0 = res index of target
 	Hasher               hash.Hash32
-	cache                *cache.BytecodeCache
+	Cache                *cache.BytecodeCache
 	CacheableQueryMinLen int // Default = 300
+	MaxAliases           int // Max amount of aliases in the query, 0 = no limit
+	MaxRootFields        int // Max amount of fields in the selection set of a operation, 0 = no limit
+	MaxQueryLength       int // Max length of the query in bytes, 0 = no limit
+	MaxTokens            int // Max amount of tokens in the query, 0 = no limit
+
+	aliases        int
+	rootFields     int
+	fieldDept      int
+	inOperation    bool
+	tokens         int
+	lastTokenAt    int
+	tokensExceeded bool
+
+	fragments       []fragmentDefinition
+	spreads         []fragmentSpread
+	fragmentStates  []uint8
+	currentFragment int // index within fragments of the fragment being parsed, -1 = operation
+	operations      []operationDefinition
+	variables       []variableDefinition
+	variableUsages  []variableUsage
+	inDefaultValue  bool // parsing the default value of a operation argument
+	documentParsed  bool // the end of the query is reached without critical errors
+}
+
+// variableDefinition is a argument of a operation, the name is stored as location within Query
+type variableDefinition struct {
+	operation int // index within operations
+	nameStart int
+	nameEnd   int
+	used      bool
+}
+
+// variableUsage is a variable used as input value, the name is stored as location within Query
+// Unlike Res the Query is not modified while parsing
+type variableUsage struct {
+	nameStart  int
+	nameEnd    int
+	inFragment int // index within fragments of the fragment containing the variable, -1 = operation
+	operation  int // index within operations of the operation containing the variable, -1 = fragment
+}
+
+// operationDefinition is a operation of the query, the name is stored as location within Res
+type operationDefinition struct {
+	kind       OperatorKind
+	nameStart  int
+	nameEnd    int
+	queryIdx   int
+	rootFields int // fields directly within the selection set, fields of fragment spreads not included
+}
+
+// fragmentDefinition is a fragment definition of the query, the name is stored as location within Res
+type fragmentDefinition struct {
+	nameStart  int
+	nameEnd    int
+	queryIdx   int
+	rootFields int // fields directly within the selection set, fields of fragment spreads not included
+}
+
+// fragmentSpread is a spread of a named fragment of the query
+type fragmentSpread struct {
+	nameStart  int
+	nameEnd    int
+	queryIdx   int
+	inFragment int // index within fragments of the fragment containing the spread, -1 = operation
+	operation  int // index within operations of the operation containing the spread, -1 = fragment
+	atRoot     bool
+	fragment   int // index within fragments of the spread fragment, -1 = undefined
 }
 
 // NewParserCtx returns a new instance of ParserCtx
@@ -32,10 +103,11 @@ func NewParserCtx() *ParserCtx {
 	return &ParserCtx{
 		Res:                  make([]byte, 2048),
 		FragmentLocations:    make([]int, 8),
+		FieldLocations:       make([]FieldLocation, 8),
 		Query:                make([]byte, 2048),
 		Errors:               []error{},
 		Hasher:               fnv.New32(),
-		cache:                &cache.BytecodeCache{},
+		Cache:                cache.New(cache.DefaultMaxEntries, 0),
 		CacheableQueryMinLen: 300,
 	}
 }
@@ -46,22 +118,41 @@ func (ctx *ParserCtx) ParseQueryToBytecode(target *string) {
 	*ctx = ParserCtx{
 		Res:                  ctx.Res[:0],
 		FragmentLocations:    ctx.FragmentLocations[:0],
+		FieldLocations:       ctx.FieldLocations[:0],
 		Query:                ctx.Query,
 		Errors:               ctx.Errors[:0],
 		target:               target,
 		hasTarget:            target != nil && len(*target) > 0,
 		TargetIdx:            -1,
 		Hasher:               ctx.Hasher,
-		cache:                ctx.cache,
+		Cache:                ctx.Cache,
 		CacheableQueryMinLen: ctx.CacheableQueryMinLen,
+		MaxAliases:           ctx.MaxAliases,
+		MaxRootFields:        ctx.MaxRootFields,
+		MaxQueryLength:       ctx.MaxQueryLength,
+		MaxTokens:            ctx.MaxTokens,
+		lastTokenAt:          -1,
+		fragments:            ctx.fragments[:0],
+		spreads:              ctx.spreads[:0],
+		fragmentStates:       ctx.fragmentStates[:0],
+		currentFragment:      -1,
+		operations:           ctx.operations[:0],
+		variables:            ctx.variables[:0],
+		variableUsages:       ctx.variableUsages[:0],
+	}
+
+	if ctx.MaxQueryLength > 0 && len(ctx.Query) > ctx.MaxQueryLength {
+		ctx.err("query is longer than the max of " + strconv.Itoa(ctx.MaxQueryLength) + " bytes")
+		return
 	}
 
 	cacheableQuery := len(ctx.Query) > ctx.CacheableQueryMinLen
 	if cacheableQuery {
-		res, fragmentLocations, targetIdx := ctx.cache.GetEntry(ctx.Query, target)
+		res, fragmentLocations, fieldLocations, targetIdx := ctx.Cache.GetEntry(ctx.Query, target)
 		if res != nil {
 			ctx.Res = append(ctx.Res, res...)
 			ctx.FragmentLocations = append(ctx.FragmentLocations, fragmentLocations...)
+			ctx.FieldLocations = append(ctx.FieldLocations, fieldLocations...)
 			ctx.TargetIdx = targetIdx
 			return
 		}
@@ -69,8 +160,13 @@ func (ctx *ParserCtx) ParseQueryToBytecode(target *string) {
 
 	for {
 		if ctx.parseOperatorOrFragment() {
+			if ctx.documentParsed {
+				ctx.checkFragments()
+				ctx.checkOperations()
+				ctx.checkVariables()
+			}
 			if cacheableQuery && len(ctx.Errors) == 0 {
-				ctx.cache.SetEntry(ctx.Query, ctx.Res, target, ctx.TargetIdx, ctx.FragmentLocations)
+				ctx.Cache.SetEntry(ctx.Query, ctx.Res, target, ctx.TargetIdx, ctx.FragmentLocations, ctx.FieldLocations)
 			}
 			return
 		}
@@ -89,27 +185,31 @@ func (ctx *ParserCtx) writeUint32(value uint32, at int) {
 func (ctx *ParserCtx) parseOperatorOrFragment() (stop bool) {
 	c, eof := ctx.mightIgnoreNextTokens()
 	if eof {
+		ctx.documentParsed = true
 		return true
 	}
 
 	operationStartsAt := len(ctx.Res)
+	ctx.inOperation = true
+	ctx.rootFields = 0
+	ctx.currentFragment = -1
 	if c == '{' {
 		if !ctx.hasTarget {
 			ctx.TargetIdx = operationStartsAt
 		}
 		ctx.instructionNewOperation(OperatorQuery)
-	} else if matches := ctx.matches("query", "mutation", "subscription"); matches != -1 {
+		ctx.operations = append(ctx.operations, operationDefinition{
+			kind:      OperatorQuery,
+			nameStart: len(ctx.Res),
+			nameEnd:   len(ctx.Res),
+			queryIdx:  ctx.charNr,
+		})
+	} else if kind, ok := ctx.matchesOperationType(); ok {
 		// Set the operation kind
 		if !ctx.hasTarget {
 			ctx.TargetIdx = operationStartsAt
 		}
-		if matches == 0 {
-			ctx.instructionNewOperation(OperatorQuery)
-		} else if matches == 1 {
-			ctx.instructionNewOperation(OperatorMutation)
-		} else {
-			ctx.instructionNewOperation(OperatorSubscription)
-		}
+		ctx.instructionNewOperation(kind)
 		hasArgsFlagLocation := len(ctx.Res) - 2
 		directivesCountLocation := len(ctx.Res) - 1
 
@@ -120,10 +220,17 @@ func (ctx *ParserCtx) parseOperatorOrFragment() (stop bool) {
 		}
 
 		startOfName := len(ctx.Res)
+		nameQueryIdx := ctx.charNr
 		_, criticalErr := ctx.parseAndWriteName()
 		if criticalErr {
 			return criticalErr
 		}
+		ctx.operations = append(ctx.operations, operationDefinition{
+			kind:      kind,
+			nameStart: startOfName,
+			nameEnd:   len(ctx.Res),
+			queryIdx:  nameQueryIdx,
+		})
 
 		name := ctx.Res[startOfName:]
 		if len(name) > 0 && ctx.hasTarget && b2s(name) == *ctx.target {
@@ -164,6 +271,7 @@ func (ctx *ParserCtx) parseOperatorOrFragment() (stop bool) {
 			return ctx.err(`expected selection set opener ("{") but got "` + string(c) + `"`)
 		}
 	} else if matches := ctx.matches("fragment"); matches != -1 {
+		ctx.inOperation = false
 		ctx.FragmentLocations = append(ctx.FragmentLocations, len(ctx.Res)+1)
 		ctx.instructionNewFragment()
 
@@ -172,6 +280,8 @@ func (ctx *ParserCtx) parseOperatorOrFragment() (stop bool) {
 		if eof {
 			return ctx.unexpectedEOF()
 		}
+		nameQueryIdx := ctx.charNr
+		nameStart := len(ctx.Res)
 		nameLen, criticalErr := ctx.parseAndWriteName()
 		if criticalErr {
 			return criticalErr
@@ -179,6 +289,12 @@ func (ctx *ParserCtx) parseOperatorOrFragment() (stop bool) {
 		if nameLen == 0 {
 			return ctx.err(`expected fragment name but got "` + string(ctx.currentC()) + `"`)
 		}
+		ctx.currentFragment = len(ctx.fragments)
+		ctx.fragments = append(ctx.fragments, fragmentDefinition{
+			nameStart: nameStart,
+			nameEnd:   len(ctx.Res),
+			queryIdx:  nameQueryIdx,
+		})
 
 		// Parse "on"
 		c, eof := ctx.mightIgnoreNextTokens()
@@ -274,6 +390,7 @@ func (ctx *ParserCtx) parseOperatorArgument() bool {
 	argLengthLocation := ctx.instructionNewOperationArg()
 
 	// Parse `some_name` of `query a($some_var: String = "a") {`
+	nameStart := ctx.charNr
 	nameLen, criticalErr := ctx.parseAndWriteName()
 	if criticalErr {
 		return criticalErr
@@ -281,6 +398,11 @@ func (ctx *ParserCtx) parseOperatorArgument() bool {
 	if nameLen == 0 {
 		return ctx.err(`expected argument name but got "` + string(ctx.currentC()) + `"`)
 	}
+	ctx.variables = append(ctx.variables, variableDefinition{
+		operation: len(ctx.operations) - 1,
+		nameStart: nameStart,
+		nameEnd:   nameStart + int(nameLen),
+	})
 
 	// Parse `:` of `query a($some_var: String = "a") {`
 	c, eof := ctx.mightIgnoreNextTokens()
@@ -320,7 +442,9 @@ func (ctx *ParserCtx) parseOperatorArgument() bool {
 			return ctx.unexpectedEOF()
 		}
 
+		ctx.inDefaultValue = true
 		criticalErr = ctx.parseInputValue()
+		ctx.inDefaultValue = false
 		if criticalErr {
 			return criticalErr
 		}
@@ -328,6 +452,12 @@ func (ctx *ParserCtx) parseOperatorArgument() bool {
 		ctx.Res = append(ctx.Res, 'f')
 	}
 
+	// Parse `@constraint(max: 10)` of `query a($some_var: Int @constraint(max: 10)) {`
+	_, criticalErr = ctx.parseDirectives()
+	if criticalErr {
+		return criticalErr
+	}
+
 	endOfArgument := len(ctx.Res)
 	ctx.writeUint32(uint32(endOfArgument-startOfArgument), argLengthLocation)
 	return false
@@ -481,6 +611,7 @@ func (ctx *ParserCtx) parseSelectionSet() bool {
 				ctx.instructionNewFragmentSpread(isInline)
 				directivesCountLocation := len(ctx.Res) - 5
 				startFragment := len(ctx.Res)
+				nameQueryIdx := ctx.charNr
 
 				nameLen, criticalErr := ctx.parseAndWriteName()
 				if criticalErr {
@@ -497,6 +628,20 @@ func (ctx *ParserCtx) parseSelectionSet() bool {
 					}
 					return ctx.err(`expected fragment name but got char: "` + string(c) + `"`)
 				}
+				if !isInline {
+					spread := fragmentSpread{
+						nameStart:  startFragment,
+						nameEnd:    startFragment + int(nameLen),
+						queryIdx:   nameQueryIdx,
+						inFragment: ctx.currentFragment,
+						operation:  -1,
+						atRoot:     ctx.fieldDept == 0,
+					}
+					if ctx.inOperation {
+						spread.operation = len(ctx.operations) - 1
+					}
+					ctx.spreads = append(ctx.spreads, spread)
+				}
 
 				if c == '@' {
 					amount, criticalErr := ctx.parseDirectives()
@@ -513,7 +658,10 @@ func (ctx *ParserCtx) parseSelectionSet() bool {
 						return ctx.err(`expected selection set open ("{") on inline fragment but got "` + string(c) + `"`)
 					}
 					ctx.charNr++
-					ctx.parseSelectionSet()
+					criticalErr := ctx.parseSelectionSet()
+					if criticalErr {
+						return criticalErr
+					}
 					ctx.instructionEnd()
 					c, eof = ctx.mightIgnoreNextTokens()
 					if eof {
@@ -542,6 +690,21 @@ func (ctx *ParserCtx) parseSelectionSet() bool {
 			return ctx.err(`unexpected character, expected valid name or selection closure but got: "` + string(ctx.currentC()) + `"`)
 		}
 
+		ctx.FieldLocations = append(ctx.FieldLocations, FieldLocation{
+			ResIdx:   startField - 10,
+			QueryIdx: ctx.charNr - int(aliasOrNameLen),
+		})
+
+		if ctx.inOperation && ctx.fieldDept == 0 {
+			ctx.rootFields++
+			ctx.operations[len(ctx.operations)-1].rootFields++
+			if ctx.MaxRootFields > 0 && ctx.rootFields > ctx.MaxRootFields {
+				return ctx.err("operation selects more than the max of " + strconv.Itoa(ctx.MaxRootFields) + " root fields")
+			}
+		} else if ctx.fieldDept == 0 {
+			ctx.fragments[ctx.currentFragment].rootFields++
+		}
+
 		c, eof := ctx.mightIgnoreNextTokens()
 		if eof {
 			return ctx.unexpectedEOF()
@@ -551,6 +714,11 @@ func (ctx *ParserCtx) parseSelectionSet() bool {
 		ctx.Res = append(ctx.Res, 0)
 
 		if c == ':' {
+			ctx.aliases++
+			if ctx.MaxAliases > 0 && ctx.aliases > ctx.MaxAliases {
+				return ctx.err("query contains more than the max of " + strconv.Itoa(ctx.MaxAliases) + " aliases")
+			}
+
 			ctx.charNr++
 			_, eof = ctx.mightIgnoreNextTokens()
 			if eof {
@@ -596,6 +764,7 @@ func (ctx *ParserCtx) parseSelectionSet() bool {
 				return ctx.unexpectedEOF()
 			}
 
+			argumentsStart := len(ctx.Res)
 			criticalErr := ctx.parseAssignmentSet(')')
 			if criticalErr {
 				return criticalErr
@@ -605,15 +774,30 @@ func (ctx *ParserCtx) parseSelectionSet() bool {
 			if eof {
 				return ctx.unexpectedEOF()
 			}
+
+			if c == '@' && ctx.Res[directivesCountLocation] == 0 {
+				// Directives defined after the arguments, the bytecode expects the directives before the arguments
+				directivesStart := len(ctx.Res)
+				amount, criticalErr := ctx.parseDirectives()
+				ctx.Res[directivesCountLocation] = amount
+				if criticalErr {
+					return criticalErr
+				}
+				c = ctx.currentC()
+
+				rotateBytes(ctx.Res[argumentsStart:], directivesStart-argumentsStart)
+			}
 		}
 
 		if c == '{' {
 			ctx.charNr++
 
+			ctx.fieldDept++
 			criticalErr := ctx.parseSelectionSet()
 			if criticalErr {
 				return criticalErr
 			}
+			ctx.fieldDept--
 
 			// ctx.charNr++
 
@@ -711,6 +895,7 @@ func (ctx *ParserCtx) parseInputValue() bool {
 
 	if c == '$' {
 		ctx.charNr++
+		nameStart := ctx.charNr
 		ctx.instructionNewValueVariable()
 		startOfVariable := len(ctx.Res)
 
@@ -722,6 +907,21 @@ func (ctx *ParserCtx) parseInputValue() bool {
 			return ctx.err(`variable input should have a name, got character: "` + string(ctx.currentC()) + `"`)
 		}
 
+		if ctx.inDefaultValue {
+			ctx.errAt(nameStart-1, "variable $"+b2s(ctx.Query[nameStart:ctx.charNr])+" cannot be used within a default value")
+		} else {
+			usage := variableUsage{
+				nameStart:  nameStart,
+				nameEnd:    ctx.charNr,
+				inFragment: ctx.currentFragment,
+				operation:  -1,
+			}
+			if ctx.inOperation {
+				usage.operation = len(ctx.operations) - 1
+			}
+			ctx.variableUsages = append(ctx.variableUsages, usage)
+		}
+
 		ctx.writeUint32(uint32(len(ctx.Res)-startOfVariable), startOfVariable-4)
 		return false
 	}
@@ -1122,6 +1322,16 @@ func (ctx *ParserCtx) mightIgnoreNextTokens() (nextC byte, eof bool) {
 
 		isIgnoredChar := ctx.isIgnoredToken(c)
 		if !isIgnoredChar {
+			if ctx.MaxTokens > 0 && ctx.charNr != ctx.lastTokenAt {
+				// Count the tokens here as this is called before reading almost every token
+				ctx.lastTokenAt = ctx.charNr
+				ctx.tokens++
+				if ctx.tokens > ctx.MaxTokens {
+					ctx.err("query contains more than the max of " + strconv.Itoa(ctx.MaxTokens) + " tokens")
+					ctx.tokensExceeded = true
+					return 0, true
+				}
+			}
 			return c, false
 		}
 
@@ -1178,6 +1388,37 @@ func (ctx *ParserCtx) parseComment() {
 	}
 }
 
+// matchesOperationType matches the operation type at the current charNr
+// Unknown operation types are reported and parsed as query so the other errors of the query are also reported
+func (ctx *ParserCtx) matchesOperationType() (kind OperatorKind, ok bool) {
+	switch ctx.matches("query", "mutation", "subscription") {
+	case 0:
+		return OperatorQuery, true
+	case 1:
+		return OperatorMutation, true
+	case 2:
+		return OperatorSubscription, true
+	}
+
+	startIdx := ctx.charNr
+	if ctx.matchesWord("fragment") != -1 {
+		ctx.charNr = startIdx
+		return 0, false
+	}
+	for {
+		c, eof := ctx.checkC(ctx.charNr)
+		if eof || !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || (c >= '0' && c <= '9' && ctx.charNr > startIdx)) {
+			break
+		}
+		ctx.charNr++
+	}
+	if ctx.charNr == startIdx {
+		return 0, false
+	}
+	ctx.errAt(startIdx, "unknown operation type "+string(ctx.Query[startIdx:ctx.charNr]))
+	return OperatorQuery, true
+}
+
 func (ctx *ParserCtx) matchesWord(oneOf ...string) int {
 	startIdx := ctx.charNr
 
@@ -1268,40 +1509,69 @@ func (e ErrorWLocation) Error() string {
 	return e.Err.Error()
 }
 
+// FieldLocation is an alias of cache.FieldLocation
+type FieldLocation = cache.FieldLocation
+
+// FieldLocationOf returns the line and column of the field of which the field instruction is at resIdx
+func (ctx *ParserCtx) FieldLocationOf(resIdx int) (line uint, column uint, ok bool) {
+	idx := sort.Search(len(ctx.FieldLocations), func(i int) bool {
+		return ctx.FieldLocations[i].ResIdx >= resIdx
+	})
+	if idx == len(ctx.FieldLocations) || ctx.FieldLocations[idx].ResIdx != resIdx {
+		return 0, 0, false
+	}
+	line, column = ctx.location(ctx.FieldLocations[idx].QueryIdx)
+	return line, column, true
+}
+
 func (ctx *ParserCtx) err(err string) bool {
-	line := uint(1)
-	column := uint(0)
+	return ctx.errAt(ctx.charNr, err)
+}
+
+// errAt adds a error with the location of charNr in the query
+func (ctx *ParserCtx) errAt(charNr int, err string) bool {
+	line, column := ctx.location(charNr)
+	ctx.Errors = append(ctx.Errors, ErrorWLocation{
+		errors.New(err),
+		line,
+		column,
+	})
+	return true
+}
+
+// location returns the line and column of charNr in the query, both start at 1
+func (ctx *ParserCtx) location(charNr int) (line uint, column uint) {
+	line = 1
+	column = 1
 	for idx, char := range ctx.Query {
-		if idx == ctx.charNr {
+		if idx == charNr {
 			break
 		}
 
 		switch char {
 		case '\n':
-			if column == 0 && idx > 0 && ctx.Query[idx-1] == '\r' {
+			if column == 1 && idx > 0 && ctx.Query[idx-1] == '\r' {
 				// don't count \r\n as 2 lines
 				continue
 			}
 			line++
-			column = 0
+			column = 1
 		case '\r':
 			line++
-			column = 0
+			column = 1
 		default:
 			column++
 		}
 	}
-
-	ctx.Errors = append(ctx.Errors, ErrorWLocation{
-		errors.New(err),
-		line,
-		uint(column),
-	})
-	return true
+	return line, column
 }
 
 func (ctx *ParserCtx) unexpectedEOF() bool {
 	// panic("DEBUG")
+	if ctx.tokensExceeded {
+		// The parser stopped as the query contains too many tokens, this error is already reported
+		return true
+	}
 	return ctx.err("unexpected EOF")
 }
 
@@ -1344,3 +1614,191 @@ func (ctx *ParserCtx) parseAndWriteName() (nameLength uint8, criticalError bool)
 func b2s(a []byte) string {
 	return *(*string)(unsafe.Pointer(&a))
 }
+
+// rotateBytes moves the bytes from mid to the end of b to the start of b without allocating
+func rotateBytes(b []byte, mid int) {
+	reverseBytes(b[:mid])
+	reverseBytes(b[mid:])
+	reverseBytes(b)
+}
+
+func reverseBytes(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// checkFragments validates the fragments of a parsed query
+// Spreads must point to a defined fragment, every fragment must be used by a operation and fragments cannot spread themselves
+// - https://spec.graphql.org/October2021/#sec-Fragment-spread-target-defined
+// - https://spec.graphql.org/October2021/#sec-Fragments-Must-Be-Used
+// - https://spec.graphql.org/October2021/#sec-Fragment-spreads-must-not-form-cycles
+func (ctx *ParserCtx) checkFragments() {
+	for i, spread := range ctx.spreads {
+		name := ctx.Res[spread.nameStart:spread.nameEnd]
+		ctx.spreads[i].fragment = -1
+		for j, fragment := range ctx.fragments {
+			if bytes.Equal(ctx.Res[fragment.nameStart:fragment.nameEnd], name) {
+				ctx.spreads[i].fragment = j
+				break
+			}
+		}
+		if ctx.spreads[i].fragment == -1 {
+			ctx.errAt(spread.queryIdx, "fragment "+string(name)+" not defined")
+		}
+	}
+
+	// Mark the fragments used by operations, directly or using other fragments
+	for range ctx.fragments {
+		ctx.fragmentStates = append(ctx.fragmentStates, 0)
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, spread := range ctx.spreads {
+			if spread.fragment != -1 && ctx.fragmentStates[spread.fragment] == 0 && (spread.inFragment == -1 || ctx.fragmentStates[spread.inFragment] == 1) {
+				ctx.fragmentStates[spread.fragment] = 1
+				changed = true
+			}
+		}
+	}
+	for i, fragment := range ctx.fragments {
+		if ctx.fragmentStates[i] == 0 {
+			ctx.errAt(fragment.queryIdx, "fragment "+string(ctx.Res[fragment.nameStart:fragment.nameEnd])+" is never used")
+		}
+		ctx.fragmentStates[i] = 0
+	}
+
+	for i := range ctx.fragments {
+		ctx.checkFragmentCycles(i)
+	}
+}
+
+// checkFragmentCycles reports spreads within the fragment that lead back to a fragment that is being checked
+// fragmentStates is used to track the fragments, 0 = not checked, 1 = being checked, 2 = checked
+func (ctx *ParserCtx) checkFragmentCycles(fragment int) {
+	if ctx.fragmentStates[fragment] != 0 {
+		return
+	}
+	ctx.fragmentStates[fragment] = 1
+	for _, spread := range ctx.spreads {
+		if spread.inFragment != fragment || spread.fragment == -1 {
+			continue
+		}
+		if ctx.fragmentStates[spread.fragment] == 1 {
+			ctx.errAt(spread.queryIdx, "cannot spread fragment "+string(ctx.Res[spread.nameStart:spread.nameEnd])+" within itself")
+			continue
+		}
+		ctx.checkFragmentCycles(spread.fragment)
+	}
+	ctx.fragmentStates[fragment] = 2
+}
+
+// checkOperations validates the operations of a parsed query
+// - https://spec.graphql.org/October2021/#sec-Operation-Name-Uniqueness
+// - https://spec.graphql.org/October2021/#sec-Lone-Anonymous-Operation
+// - https://spec.graphql.org/October2021/#sec-Single-root-field
+func (ctx *ParserCtx) checkOperations() {
+	for i, operation := range ctx.operations {
+		name := ctx.Res[operation.nameStart:operation.nameEnd]
+		if len(name) == 0 {
+			if len(ctx.operations) > 1 {
+				ctx.errAt(operation.queryIdx, "anonymous operations must be the only operation in the document")
+			}
+		} else {
+			for _, other := range ctx.operations[:i] {
+				if bytes.Equal(ctx.Res[other.nameStart:other.nameEnd], name) {
+					ctx.errAt(operation.queryIdx, "there can be only one operation named "+string(name))
+					break
+				}
+			}
+		}
+
+		if operation.kind == OperatorSubscription {
+			rootFields := operation.rootFields
+			for _, spread := range ctx.spreads {
+				if spread.operation == i && spread.atRoot && spread.fragment != -1 {
+					rootFields += ctx.fragmentRootFields(spread.fragment, 0)
+				}
+			}
+			if rootFields > 1 {
+				if len(name) == 0 {
+					ctx.errAt(operation.queryIdx, "anonymous subscription must select only one top level field")
+				} else {
+					ctx.errAt(operation.queryIdx, "subscription "+string(name)+" must select only one top level field")
+				}
+			}
+		}
+	}
+}
+
+// fragmentRootFields returns the amount of fields directly within the selection set of a fragment, fields of fragment spreads included
+func (ctx *ParserCtx) fragmentRootFields(fragment int, dept int) int {
+	if dept > len(ctx.fragments) {
+		// Fragment cycles are reported by checkFragments
+		return 0
+	}
+	rootFields := ctx.fragments[fragment].rootFields
+	for _, spread := range ctx.spreads {
+		if spread.inFragment == fragment && spread.atRoot && spread.fragment != -1 {
+			rootFields += ctx.fragmentRootFields(spread.fragment, dept+1)
+		}
+	}
+	return rootFields
+}
+
+// checkVariables validates the variables used by the operations of a parsed query, variables used within fragments belong to the operations that use the fragment
+// - https://spec.graphql.org/October2021/#sec-All-Variable-Uses-Defined
+// - https://spec.graphql.org/October2021/#sec-All-Variables-Used
+func (ctx *ParserCtx) checkVariables() {
+	for i, operation := range ctx.operations {
+		// Mark the fragments used by this operation
+		for j := range ctx.fragmentStates {
+			ctx.fragmentStates[j] = 0
+		}
+		for changed := true; changed; {
+			changed = false
+			for _, spread := range ctx.spreads {
+				if spread.fragment != -1 && ctx.fragmentStates[spread.fragment] == 0 && (spread.operation == i || (spread.inFragment != -1 && ctx.fragmentStates[spread.inFragment] == 1)) {
+					ctx.fragmentStates[spread.fragment] = 1
+					changed = true
+				}
+			}
+		}
+
+		operationName := ctx.Res[operation.nameStart:operation.nameEnd]
+		for _, usage := range ctx.variableUsages {
+			if usage.operation != i && (usage.inFragment == -1 || ctx.fragmentStates[usage.inFragment] != 1) {
+				continue
+			}
+
+			name := ctx.Query[usage.nameStart:usage.nameEnd]
+			defined := false
+			for j, variable := range ctx.variables {
+				if variable.operation == i && bytes.Equal(ctx.Query[variable.nameStart:variable.nameEnd], name) {
+					ctx.variables[j].used = true
+					defined = true
+					break
+				}
+			}
+			if !defined {
+				if len(operationName) == 0 {
+					ctx.errAt(usage.nameStart-1, "variable $"+string(name)+" is not defined")
+				} else {
+					ctx.errAt(usage.nameStart-1, "variable $"+string(name)+" is not defined by operation "+string(operationName))
+				}
+			}
+		}
+
+		for _, variable := range ctx.variables {
+			if variable.operation != i || variable.used {
+				continue
+			}
+			name := ctx.Query[variable.nameStart:variable.nameEnd]
+			if len(operationName) == 0 {
+				ctx.errAt(variable.nameStart-1, "variable $"+string(name)+" is never used")
+			} else {
+				ctx.errAt(variable.nameStart-1, "variable $"+string(name)+" is never used in operation "+string(operationName))
+			}
+		}
+	}
+}
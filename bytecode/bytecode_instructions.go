@@ -1,5 +1,10 @@
 package bytecode
 
+// Version is the version of the bytecode format
+// It's increased every time the format changes so bytecode persisted by an older version is not used
+// Version 2 added the directives of operator arguments
+const Version = 2
+
 // Action defines an action that should be taken based when parsing the schema
 type Action = byte
 
@@ -72,7 +77,7 @@ func (ctx *ParserCtx) instructionNewOperationArgs() {
 // 0 [ActionOperatorArg] [0000 (encoded uint32 telling how long this full instruction is)]
 //
 // additional required append:
-// [Name] 0 [Graphql Type] 0 [t/f (has a default value?)]
+// [Name] 0 [Graphql Type] 0 [t/f (has a default value?)] [default value (if t)] [directives...]
 //
 // returns:
 // the start location of the 4 bit encoded uint32
@@ -94,18 +94,21 @@ func TestParseQueryWithName(t *testing.T) {
 func TestParseQuerywithArgs(t *testing.T) {
 	newParseQueryAndExpectResult(
 		t,
-		`query banana($quality: [Int]) {}`,
+		`query banana($quality: [Int]) {a(b: $quality)}`,
 		testOperator{
 			name: "banana",
 			args: []testOperatorArg{
 				{name: "quality", bytecodeType: "lnInt"},
 			},
+			fields: []testField{{name: "a", arguments: []typeObjectValue{
+				{name: "b", value: testValue{kind: ValueVariable, variableValue: "quality"}},
+			}}},
 		}.toBytes(),
 	)
 
 	newParseQueryAndExpectResult(
 		t,
-		`query banana($quality: [Int!]! = [10]) {}`,
+		`query banana($quality: [Int!]! = [10]) {a(b: $quality)}`,
 		testOperator{
 			name: "banana",
 			args: []testOperatorArg{
@@ -120,12 +123,15 @@ func TestParseQuerywithArgs(t *testing.T) {
 					},
 				},
 			},
+			fields: []testField{{name: "a", arguments: []typeObjectValue{
+				{name: "b", value: testValue{kind: ValueVariable, variableValue: "quality"}},
+			}}},
 		}.toBytes(),
 	)
 
 	newParseQueryAndExpectResult(
 		t,
-		`query foo($bar: String = "bar", $baz: String = "baz") {}`,
+		`query foo($bar: String = "bar", $baz: String = "baz") {a(b: $bar, c: $baz)}`,
 		testOperator{
 			name: "foo",
 			args: []testOperatorArg{
@@ -140,21 +146,59 @@ func TestParseQuerywithArgs(t *testing.T) {
 					defaultValue: &testValue{kind: ValueString, stringValue: "baz"},
 				},
 			},
+			fields: []testField{{name: "a", arguments: []typeObjectValue{
+				{name: "b", value: testValue{kind: ValueVariable, variableValue: "bar"}},
+				{name: "c", value: testValue{kind: ValueVariable, variableValue: "baz"}},
+			}}},
 		}.toBytes(),
 	)
 
-	injectCodeSurviveTest(`query banana($quality: [Int!]! = [10]) {}`)
+	injectCodeSurviveTest(`query banana($quality: [Int!]! = [10]) {a(b: $quality)}`)
 }
 
-func TestParseMultipleSimpleQueries(t *testing.T) {
+func TestParseQueryWithArgDirectives(t *testing.T) {
+	query := `query banana($quality: Int = 10 @constraint(max: 20) @deprecated, $size: Int @deprecated) {a(b: $quality, c: $size)}`
 	newParseQueryAndExpectResult(
 		t,
-		`{}{}`,
-		append(
-			testOperator{}.toBytes(),
-			testOperator{}.toBytes()...,
-		),
+		query,
+		testOperator{
+			name: "banana",
+			args: []testOperatorArg{
+				{
+					name:         "quality",
+					bytecodeType: "nInt",
+					defaultValue: &testValue{kind: ValueInt, intValue: 10},
+					directives: []testDirective{
+						{name: "constraint", arguments: []typeObjectValue{
+							{name: "max", value: testValue{kind: ValueInt, intValue: 20}},
+						}},
+						{name: "deprecated"},
+					},
+				},
+				{
+					name:         "size",
+					bytecodeType: "nInt",
+					directives:   []testDirective{{name: "deprecated"}},
+				},
+			},
+			fields: []testField{{name: "a", arguments: []typeObjectValue{
+				{name: "b", value: testValue{kind: ValueVariable, variableValue: "quality"}},
+				{name: "c", value: testValue{kind: ValueVariable, variableValue: "size"}},
+			}}},
+		}.toBytes(),
 	)
+	injectCodeSurviveTest(query)
+}
+
+func TestParseMultipleSimpleQueries(t *testing.T) {
+	// Anonymous operations must be the only operation in the document but are still parsed
+	res, errs := parseQuery(`{}{}`)
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, "anonymous operations must be the only operation in the document", errs[0].Error())
+	a.Equal(t, hex.Dump(append(
+		testOperator{}.toBytes(),
+		testOperator{}.toBytes()...,
+	)), hex.Dump(res))
 }
 
 func TestParseMultipleQueries(t *testing.T) {
@@ -297,8 +341,9 @@ func TestParseQueryWithFieldWithFragmentSpread(t *testing.T) {
 				... baz
 				bar
 			}
-		}`,
-		testOperator{
+		}
+		fragment baz on Foo {}`,
+		append(testOperator{
 			fields: []testField{
 				{name: "some_field", fields: []testField{
 					{name: "foo"},
@@ -306,7 +351,7 @@ func TestParseQueryWithFieldWithFragmentSpread(t *testing.T) {
 					{name: "bar"},
 				}},
 			},
-		}.toBytes(),
+		}.toBytes(), testFragment{name: "baz", on: "Foo"}.toBytes()...),
 	)
 
 	// A query that starts with "on" should parse as a fragment pointer
@@ -318,8 +363,9 @@ func TestParseQueryWithFieldWithFragmentSpread(t *testing.T) {
 				... online
 				bar
 			}
-		}`,
-		testOperator{
+		}
+		fragment online on Foo {}`,
+		append(testOperator{
 			fields: []testField{
 				{name: "some_field", fields: []testField{
 					{name: "foo"},
@@ -327,7 +373,7 @@ func TestParseQueryWithFieldWithFragmentSpread(t *testing.T) {
 					{name: "bar"},
 				}},
 			},
-		}.toBytes(),
+		}.toBytes(), testFragment{name: "online", on: "Foo"}.toBytes()...),
 	)
 }
 
@@ -492,10 +538,17 @@ func TestParseArgumentValueTypes(t *testing.T) {
 	for _, option := range options {
 		t.Run(option.name, func(t *testing.T) {
 			query := `{baz(foo: ` + option.input + `)}`
+			var args []testOperatorArg
+			if option.output.kind == ValueVariable {
+				// Variables must be defined by the operation
+				query = `query($banana: Int) {baz(foo: ` + option.input + `)}`
+				args = []testOperatorArg{{name: "banana", bytecodeType: "nInt"}}
+			}
 			newParseQueryAndExpectResult(
 				t,
 				query,
 				testOperator{
+					args: args,
 					fields: []testField{
 						{
 							name: "baz",
@@ -564,16 +617,16 @@ func TestParseMultipleArguments(t *testing.T) {
 }
 
 func TestParseFragment(t *testing.T) {
-	query := `fragment Foo on Bar {}`
+	query := `{...Foo} fragment Foo on Bar {}`
 
 	newParseQueryAndExpectResult(
 		t,
 		query,
-		testFragment{
+		append(testOperator{fields: []testField{{name: "Foo", isFragment: true}}}.toBytes(), testFragment{
 			name:   "Foo",
 			on:     "Bar",
 			fields: []testField{},
-		}.toBytes(),
+		}.toBytes()...),
 	)
 
 	injectCodeSurviveTest(query)
@@ -582,18 +635,19 @@ func TestParseFragment(t *testing.T) {
 func TestParseFragmentWithFields(t *testing.T) {
 	newParseQueryAndExpectResult(
 		t,
-		`fragment Foo on Bar {
+		`{...Foo}
+		fragment Foo on Bar {
 			fieldA
 			bField
 		}`,
-		testFragment{
+		append(testOperator{fields: []testField{{name: "Foo", isFragment: true}}}.toBytes(), testFragment{
 			name: "Foo",
 			on:   "Bar",
 			fields: []testField{
 				{name: "fieldA"},
 				{name: "bField"},
 			},
-		}.toBytes(),
+		}.toBytes()...),
 	)
 }
 
@@ -657,6 +711,71 @@ func TestParseQueryWithFieldDirective(t *testing.T) {
 	injectCodeSurviveTest(query)
 }
 
+func TestParseQueryWithFieldDirectiveAfterArguments(t *testing.T) {
+	expected := testOperator{fields: []testField{{
+		name: "some_field",
+		arguments: []typeObjectValue{{
+			name:  "a",
+			value: testValue{kind: ValueInt, intValue: 1},
+		}},
+		directives: []testDirective{
+			{
+				name: "banana",
+				arguments: []typeObjectValue{{
+					name:  "b",
+					value: testValue{kind: ValueInt, intValue: 2},
+				}},
+			},
+			{name: "peer"},
+		},
+	}}}.toBytes()
+
+	query := `{some_field(a: 1) @banana(b: 2) @peer}`
+	newParseQueryAndExpectResult(t, query, expected)
+	injectCodeSurviveTest(query)
+}
+
+func TestParseQueryWithFieldDirectiveAfterArgumentsAndSelectionSet(t *testing.T) {
+	query := `{some_field(a: 1) @banana {other(b: 2) @peer}}`
+	newParseQueryAndExpectResult(t, query, testOperator{fields: []testField{{
+		name: "some_field",
+		arguments: []typeObjectValue{{
+			name:  "a",
+			value: testValue{kind: ValueInt, intValue: 1},
+		}},
+		directives: []testDirective{{name: "banana"}},
+		fields: []testField{{
+			name: "other",
+			arguments: []typeObjectValue{{
+				name:  "b",
+				value: testValue{kind: ValueInt, intValue: 2},
+			}},
+			directives: []testDirective{{name: "peer"}},
+		}},
+	}}}.toBytes())
+	injectCodeSurviveTest(query)
+}
+
+func TestRotateBytes(t *testing.T) {
+	testCases := []struct {
+		in       string
+		mid      int
+		expected string
+	}{
+		{"abcdef", 0, "abcdef"},
+		{"abcdef", 2, "cdefab"},
+		{"abcdef", 5, "fabcde"},
+		{"abcdef", 6, "abcdef"},
+		{"", 0, ""},
+	}
+
+	for _, testCase := range testCases {
+		b := []byte(testCase.in)
+		rotateBytes(b, testCase.mid)
+		a.Equal(t, testCase.expected, string(b), testCase.in)
+	}
+}
+
 func TestParseQueryWithFragmentDirective(t *testing.T) {
 	// Inline fragment
 	query := `{... on baz @foo {}}`
@@ -673,15 +792,15 @@ func TestParseQueryWithFragmentDirective(t *testing.T) {
 	injectCodeSurviveTest(query)
 
 	// Pointer to fragment
-	query = `{...baz@foo}`
+	query = `{...baz@foo} fragment baz on Foo {}`
 	newParseQueryAndExpectResult(
 		t,
 		query,
-		testOperator{fields: []testField{{
+		append(testOperator{fields: []testField{{
 			name:       "baz",
 			isFragment: true,
 			directives: []testDirective{{name: "foo"}},
-		}}}.toBytes(),
+		}}}.toBytes(), testFragment{name: "baz", on: "Foo"}.toBytes()...),
 	)
 	injectCodeSurviveTest(query)
 }
@@ -793,3 +912,275 @@ func injectCodeSurviveTest(baseQuery string, extraChars ...[][]byte) {
 
 	wg.Wait()
 }
+
+func TestMaxAliasesAndRootFields(t *testing.T) {
+	parse := func(query string, maxAliases, maxRootFields int) []error {
+		ctx := NewParserCtx()
+		ctx.MaxAliases = maxAliases
+		ctx.MaxRootFields = maxRootFields
+		ctx.Query = []byte(query)
+		ctx.ParseQueryToBytecode(nil)
+		return ctx.Errors
+	}
+
+	query := `query {a1: me {name} a2: me {n: name} ...f} fragment f on Query {a3: me {name} b c}`
+	a.Equal(t, 0, len(parse(query, 0, 0)))
+	a.Equal(t, 0, len(parse(query, 4, 3)))
+
+	errs := parse(query, 3, 0)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "query contains more than the max of 3 aliases", errs[0].Error())
+
+	// Fields in fragment definitions and selection sets of fields are not root fields
+	errs = parse(query, 0, 1)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "operation selects more than the max of 1 root fields", errs[0].Error())
+
+	// Fields of inline fragments on the operation are root fields
+	errs = parse(`{a ... on Query {b c}}`, 0, 2)
+	a.Equal(t, 1, len(errs))
+
+	// The root fields are counted per operation
+	a.Equal(t, 0, len(parse(`query a {a b} query b {c d}`, 0, 2)))
+}
+
+func TestFieldLocations(t *testing.T) {
+	ctx := NewParserCtx()
+	ctx.CacheableQueryMinLen = 0
+	ctx.Query = []byte("{\r\n  a\r\n  b: c {\n\t\td @foo\n  }\n  ...f\n}\nfragment f on Foo {e}")
+
+	expected := []struct {
+		name   string
+		line   uint
+		column uint
+	}{
+		{"a", 2, 3},
+		{"b", 3, 3},
+		{"d", 4, 3},
+		{"e", 8, 20},
+	}
+
+	// The second time the query is parsed it's read from the cache
+	for i := 0; i < 2; i++ {
+		ctx.ParseQueryToBytecode(nil)
+		a.Equal(t, 0, len(ctx.Errors))
+		a.Equal(t, len(expected), len(ctx.FieldLocations))
+		for idx, field := range expected {
+			resIdx := ctx.FieldLocations[idx].ResIdx
+			a.Equal(t, ActionField, ctx.Res[resIdx])
+			a.Equal(t, field.name, string(ctx.Res[resIdx+11]))
+
+			line, column, ok := ctx.FieldLocationOf(resIdx)
+			a.True(t, ok)
+			a.Equal(t, field.line, line, field.name)
+			a.Equal(t, field.column, column, field.name)
+		}
+	}
+
+	_, _, ok := ctx.FieldLocationOf(0)
+	a.False(t, ok)
+}
+
+func TestErrorLocation(t *testing.T) {
+	ctx := NewParserCtx()
+	ctx.Query = []byte("{\n  a(b: )\n}")
+	ctx.ParseQueryToBytecode(nil)
+	a.Equal(t, 1, len(ctx.Errors))
+	err := ctx.Errors[0].(ErrorWLocation)
+	a.Equal(t, uint(2), err.Line)
+	a.Equal(t, uint(8), err.Column)
+}
+
+func TestMaxQueryLengthAndTokens(t *testing.T) {
+	parse := func(query string, maxQueryLength, maxTokens int) []error {
+		ctx := NewParserCtx()
+		ctx.MaxQueryLength = maxQueryLength
+		ctx.MaxTokens = maxTokens
+		ctx.Query = []byte(query)
+		ctx.ParseQueryToBytecode(nil)
+		return ctx.Errors
+	}
+
+	query := `query {a(b: 1) {c d}}`
+	a.Equal(t, 0, len(parse(query, 0, 0)))
+	a.Equal(t, 0, len(parse(query, len(query), 20)))
+
+	errs := parse(query, len(query)-1, 0)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "query is longer than the max of 20 bytes", errs[0].Error())
+
+	errs = parse(query, 0, 3)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "query contains more than the max of 3 tokens", errs[0].Error())
+
+	// Ignored tokens like whitespace and comments are not counted
+	a.Equal(t, 0, len(parse("query {\n\t# comment\n\ta b\n}", 0, 20)))
+}
+
+func TestFragmentValidation(t *testing.T) {
+	parse := func(query string) []error {
+		ctx := NewParserCtx()
+		ctx.Query = []byte(query)
+		ctx.ParseQueryToBytecode(nil)
+		return ctx.Errors
+	}
+
+	// Fragments used by other fragments or only by one of the operations are valid
+	a.Equal(t, 0, len(parse(`query A {...a} query B {b} fragment a on Query {...b} fragment b on Query {c}`)))
+
+	tests := []struct {
+		query  string
+		err    string
+		column uint
+	}{
+		{`{...a}`, "fragment a not defined", 5},
+		{`{b} fragment a on Query {c}`, "fragment a is never used", 14},
+		{`{...a} fragment a on Query {...a}`, "cannot spread fragment a within itself", 32},
+		{`{...a} fragment a on Query {...b} fragment b on Query {... on Query {...a}}`, "cannot spread fragment a within itself", 73},
+	}
+	for _, test := range tests {
+		errs := parse(test.query)
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+		a.Equal(t, test.column, errs[0].(ErrorWLocation).Column, test.query)
+	}
+
+	// Fragments only used by unused fragments are also unused
+	errs := parse(`{b} fragment a on Query {...b} fragment b on Query {...a}`)
+	a.Equal(t, 3, len(errs))
+	a.Equal(t, "fragment a is never used", errs[0].Error())
+	a.Equal(t, "fragment b is never used", errs[1].Error())
+	a.Equal(t, "cannot spread fragment a within itself", errs[2].Error())
+}
+
+func TestOperationValidation(t *testing.T) {
+	parse := func(query string) []error {
+		ctx := NewParserCtx()
+		ctx.Query = []byte(query)
+		ctx.ParseQueryToBytecode(nil)
+		return ctx.Errors
+	}
+
+	a.Equal(t, 0, len(parse(`query a {b} mutation c {d} subscription e {f}`)))
+	a.Equal(t, 0, len(parse(`subscription a {...b} fragment b on Subscription {... on Subscription {c}}`)))
+
+	tests := []struct {
+		query  string
+		err    string
+		column uint
+	}{
+		{`query a {b} query a {c}`, "there can be only one operation named a", 19},
+		{`{b} query a {c}`, "anonymous operations must be the only operation in the document", 1},
+		{`foo a {b}`, "unknown operation type foo", 1},
+		{`subscription a {b c}`, "subscription a must select only one top level field", 14},
+		{`subscription {b ...c} fragment c on Subscription {d}`, "anonymous subscription must select only one top level field", 14},
+	}
+	for _, test := range tests {
+		errs := parse(test.query)
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+		a.Equal(t, test.column, errs[0].(ErrorWLocation).Column, test.query)
+	}
+
+	// All violations are reported together
+	errs := parse(`query a {b} foo a {c} subscription {d e}`)
+	a.Equal(t, 4, len(errs))
+	a.Equal(t, "unknown operation type foo", errs[0].Error())
+	a.Equal(t, "there can be only one operation named a", errs[1].Error())
+	a.Equal(t, "anonymous operations must be the only operation in the document", errs[2].Error())
+	a.Equal(t, "anonymous subscription must select only one top level field", errs[3].Error())
+}
+
+func TestVariableValidation(t *testing.T) {
+	parse := func(query string) []error {
+		ctx := NewParserCtx()
+		ctx.Query = []byte(query)
+		ctx.ParseQueryToBytecode(nil)
+		return ctx.Errors
+	}
+
+	a.Equal(t, 0, len(parse(`query a($b: Int) {c(d: $b)}`)))
+	a.Equal(t, 0, len(parse(`query a($b: Int) {...F} fragment F on Query {c(d: [{e: $b}])}`)))
+	a.Equal(t, 0, len(parse(`query a($b: Boolean) {c @include(if: $b)}`)))
+	a.Equal(t, 0, len(parse(`query a($b: Int) {...F} query c($b: Int) {...F} fragment F on Query {d(e: $b)}`)))
+
+	tests := []struct {
+		query  string
+		err    string
+		column uint
+	}{
+		{`query a {c(d: $b)}`, "variable $b is not defined by operation a", 15},
+		{`{c(d: $b)}`, "variable $b is not defined", 7},
+		{`query a($b: Int) {c}`, "variable $b is never used in operation a", 9},
+		{`query($b: Int) {c}`, "variable $b is never used", 7},
+		{`query a($b: Int = $c) {d(e: $b)}`, "variable $c cannot be used within a default value", 19},
+		{`query a {...F} query c($b: Int) {...F} fragment F on Query {d(e: $b)}`, "variable $b is not defined by operation a", 66},
+	}
+	for _, test := range tests {
+		errs := parse(test.query)
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+		a.Equal(t, test.column, errs[0].(ErrorWLocation).Column, test.query)
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	res, errs := parseQuery(`query Foo($a: Int = 1 @g) @d(b: [true]) {
+	c: e(f: $a) {
+		...G
+		... on H {
+			i
+		}
+	}
+}
+fragment G on J {
+	k
+}`)
+	a.Equal(t, 0, len(errs))
+
+	lines := strings.Split(strings.TrimSuffix(Disassemble(res), "\n"), "\n")
+	expected := []string{
+		`operation query "Foo" arguments=t directives=1`,
+		`  arguments length=30`,
+		`    argument "a" type="nInt" default=t length=25`,
+		`      value int "1"`,
+		`      directive "g" arguments=f`,
+		`  end`,
+		`  directive "d" arguments=t`,
+		`    value object length=22`,
+		`      object field "b"`,
+		`        value list length=10`,
+		`          value boolean true`,
+		`        end`,
+		`    end`,
+		`  field "e" alias="c" directives=0 length=62`,
+		`    value object length=13`,
+		`      object field "f"`,
+		`        value variable "a"`,
+		`    end`,
+		`    spread fragment "G" directives=0 length=1`,
+		`    spread inline "H" directives=0 length=19`,
+		`      field "i" directives=0 length=5`,
+		`        end`,
+		`      end`,
+		`    end`,
+		`  end`,
+		`fragment "G" on "J"`,
+		`  field "k" directives=0 length=5`,
+		`    end`,
+		`  end`,
+	}
+	a.Equal(t, len(expected), len(lines))
+	for i, line := range lines {
+		// Strip the offset and the key of fields as it's a hash of the field name
+		line = line[5:]
+		if idx := strings.Index(line, " key=0x"); idx != -1 {
+			line = line[:idx]
+		}
+		a.Equal(t, expected[i], line)
+	}
+
+	// Invalid bytecode results in a message with the offset of the invalid instruction
+	a.Equal(t, "0001 invalid bytecode\n", Disassemble([]byte{0, 'x'}))
+	a.True(t, strings.HasSuffix(Disassemble(res[:len(res)-3]), "invalid bytecode\n"))
+}
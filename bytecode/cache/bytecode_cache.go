@@ -2,78 +2,195 @@ package cache
 
 import (
 	"bytes"
-
-	"github.com/mjarkk/yarql/helpers"
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"unsafe"
 )
 
-// BytecodeCache contains the bytecode cache
-// The map index is the length of the query
-// The value is a slice with a list of queries
-type BytecodeCache map[int][]cacheEntry
+// DefaultMaxEntries is the default max amount of entries in the cache
+const DefaultMaxEntries = 1000
+
+// BytecodeCache is a LRU cache of parsed queries keyed by the hash of the query and operation target
+// It's safe for concurrent use so it can be shared between multiple parser contexts
+type BytecodeCache struct {
+	lock       sync.Mutex
+	entries    map[uint64]*list.Element
+	order      *list.List // front = most recently used
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	stats      Stats
+}
 
 type cacheEntry struct {
+	hash             uint64
+	size             int
 	query            []byte
 	bytecode         []byte
 	target           *string
 	targetIdx        int
 	fragmentLocation []int
+	fieldLocations   []FieldLocation
 }
 
-// GetEntry might return the bytecode, the fragment locations of the query and targetIdx
-func (c BytecodeCache) GetEntry(query []byte, target *string) ([]byte, []int, int) {
-	entries, ok := c[len(query)]
-	if !ok {
-		return nil, nil, -1
+// FieldLocation maps the start of a field in the bytecode to the start of the field in the query
+type FieldLocation struct {
+	ResIdx   int // index of the field instruction in the bytecode
+	QueryIdx int // index of the field name or alias in the query
+}
+
+// Stats contains statistics about the usage of the cache
+type Stats struct {
+	Hits      uint64 // Amount of queries found in the cache
+	Misses    uint64 // Amount of queries not found in the cache
+	Evictions uint64 // Amount of entries removed to stay within the limits
+	Entries   int    // Current amount of entries
+	Bytes     int    // Current approximate size of the entries in bytes
+}
+
+// New returns a new cache with limits, 0 = no limit
+func New(maxEntries, maxBytes int) *BytecodeCache {
+	return &BytecodeCache{
+		entries:    map[uint64]*list.Element{},
+		order:      list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
 	}
+}
 
-	for _, entry := range entries {
-		if bytes.Equal(entry.query, query) && ((target == nil && entry.target == nil) || (target != nil && entry.target != nil && *target == *entry.target)) {
-			return entry.bytecode, entry.fragmentLocation, entry.targetIdx
-		}
+// hashQuery returns the key of a query with operation target
+func hashQuery(query []byte, target *string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write(query)
+	if target != nil {
+		// Separate a nil target from an empty target
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(*target))
 	}
+	return hasher.Sum64()
+}
 
-	return nil, nil, -1
+func (e *cacheEntry) matches(query []byte, target *string) bool {
+	return bytes.Equal(e.query, query) && ((target == nil && e.target == nil) || (target != nil && e.target != nil && *target == *e.target))
 }
 
-// SetEntry sets a new entry in the cache
-func (c BytecodeCache) SetEntry(query, bytecode []byte, target *string, targetIdx int, fragmentLocation []int) {
-	if len(c) == 100 {
-		// Remove some random entries
-		// FIXME Dunno if this is a good value to start dropping stuff
-		var deleted uint8
-		for key := range c {
-			delete(c, key)
-			deleted++
-			if deleted == 5 {
-				break
-			}
-		}
-	}
+// GetEntry might return the bytecode, the fragment locations, the field locations of the query and targetIdx
+// The returned slices are shared with the cache and must not be modified
+func (c *BytecodeCache) GetEntry(query []byte, target *string) ([]byte, []int, []FieldLocation, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	queryLen := len(query)
-	entries, ok := c[queryLen]
+	element, ok := c.entries[hashQuery(query, target)]
 	if !ok {
-		entries = []cacheEntry{}
-	} else if len(entries) == 20 {
-		// Drop the last cache entry for this length query
-		// FIXME Dunno if this is a good value to start dropping stuff
-		entries = entries[:len(entries)-1]
+		c.stats.Misses++
+		return nil, nil, nil, -1
 	}
-
-	var targetCopy *string
-	if target != nil {
-		targetCopy = helpers.StrPtr(*target)
+	entry := element.Value.(*cacheEntry)
+	if !entry.matches(query, target) {
+		// Hash collision
+		c.stats.Misses++
+		return nil, nil, nil, -1
 	}
 
-	newCacheEntry := cacheEntry{
+	c.stats.Hits++
+	c.order.MoveToFront(element)
+	return entry.bytecode, entry.fragmentLocation, entry.fieldLocations, entry.targetIdx
+}
+
+// SetEntry sets a new entry in the cache, the least recently used entries are removed if the cache exceeds its limits
+func (c *BytecodeCache) SetEntry(query, bytecode []byte, target *string, targetIdx int, fragmentLocation []int, fieldLocations []FieldLocation) {
+	newCacheEntry := &cacheEntry{
+		hash:             hashQuery(query, target),
 		query:            make([]byte, len(query)),
 		bytecode:         make([]byte, len(bytecode)),
-		target:           targetCopy,
 		targetIdx:        targetIdx,
-		fragmentLocation: fragmentLocation,
+		fragmentLocation: make([]int, len(fragmentLocation)),
+		fieldLocations:   make([]FieldLocation, len(fieldLocations)),
+	}
+	if target != nil {
+		targetCopy := *target
+		newCacheEntry.target = &targetCopy
 	}
 	copy(newCacheEntry.query, query)
 	copy(newCacheEntry.bytecode, bytecode)
+	copy(newCacheEntry.fragmentLocation, fragmentLocation)
+	copy(newCacheEntry.fieldLocations, fieldLocations)
+	newCacheEntry.size = newCacheEntry.sizeOf()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.maxBytes > 0 && newCacheEntry.size > c.maxBytes {
+		// The entry would never fit
+		return
+	}
+
+	element, ok := c.entries[newCacheEntry.hash]
+	if ok {
+		// Replace the existing entry, this only happens on hash collisions or if the same query is set twice
+		c.remove(element)
+	}
+
+	c.entries[newCacheEntry.hash] = c.order.PushFront(newCacheEntry)
+	c.bytes += newCacheEntry.size
+	c.evict()
+}
+
+// sizeOf returns the approximate size of the entry in bytes
+func (e *cacheEntry) sizeOf() int {
+	size := int(unsafe.Sizeof(*e)) + len(e.query) + len(e.bytecode) +
+		len(e.fragmentLocation)*int(unsafe.Sizeof(int(0))) +
+		len(e.fieldLocations)*int(unsafe.Sizeof(FieldLocation{}))
+	if e.target != nil {
+		size += len(*e.target)
+	}
+	return size
+}
+
+// remove removes an element from the cache, expects the lock to be held
+func (c *BytecodeCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.hash)
+	c.bytes -= entry.size
+}
+
+// evict removes the least recently used entries until the cache is within its limits, expects the lock to be held
+func (c *BytecodeCache) evict() {
+	for (c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// SetLimits changes the limits of the cache, 0 = no limit
+// Entries are removed directly if the cache exceeds the new limits
+func (c *BytecodeCache) SetLimits(maxEntries, maxBytes int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.maxEntries = maxEntries
+	c.maxBytes = maxBytes
+	c.evict()
+}
+
+// Purge removes all entries from the cache, the statistics are kept
+func (c *BytecodeCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.entries = map[uint64]*list.Element{}
+	c.order.Init()
+	c.bytes = 0
+}
+
+// Stats returns the statistics of the cache
+func (c *BytecodeCache) Stats() Stats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	c[queryLen] = append([]cacheEntry{newCacheEntry}, entries...)
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	stats.Bytes = c.bytes
+	return stats
 }
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func TestCacheGetSet(t *testing.T) {
+	c := New(0, 0)
+	target := "foo"
+
+	res, _, _, targetIdx := c.GetEntry([]byte("{a}"), nil)
+	a.Nil(t, res)
+	a.Equal(t, -1, targetIdx)
+
+	c.SetEntry([]byte("{a}"), []byte("bytecode a"), nil, 1, []int{2}, []FieldLocation{{ResIdx: 3, QueryIdx: 4}})
+	c.SetEntry([]byte("{a}"), []byte("bytecode foo"), &target, 5, nil, nil)
+
+	res, fragmentLocations, fieldLocations, targetIdx := c.GetEntry([]byte("{a}"), nil)
+	a.Equal(t, "bytecode a", string(res))
+	a.Equal(t, []int{2}, fragmentLocations)
+	a.Equal(t, []FieldLocation{{ResIdx: 3, QueryIdx: 4}}, fieldLocations)
+	a.Equal(t, 1, targetIdx)
+
+	res, _, _, targetIdx = c.GetEntry([]byte("{a}"), &target)
+	a.Equal(t, "bytecode foo", string(res))
+	a.Equal(t, 5, targetIdx)
+
+	emptyTarget := ""
+	res, _, _, _ = c.GetEntry([]byte("{a}"), &emptyTarget)
+	a.Nil(t, res)
+
+	stats := c.Stats()
+	a.Equal(t, uint64(2), stats.Hits)
+	a.Equal(t, uint64(2), stats.Misses)
+	a.Equal(t, 2, stats.Entries)
+}
+
+func TestCacheLeastRecentlyUsed(t *testing.T) {
+	c := New(2, 0)
+	c.SetEntry([]byte("{a}"), []byte("a"), nil, 0, nil, nil)
+	c.SetEntry([]byte("{b}"), []byte("b"), nil, 0, nil, nil)
+
+	// Makes {b} the least recently used entry
+	res, _, _, _ := c.GetEntry([]byte("{a}"), nil)
+	a.NotNil(t, res)
+
+	c.SetEntry([]byte("{c}"), []byte("c"), nil, 0, nil, nil)
+	res, _, _, _ = c.GetEntry([]byte("{b}"), nil)
+	a.Nil(t, res)
+	res, _, _, _ = c.GetEntry([]byte("{a}"), nil)
+	a.NotNil(t, res)
+	res, _, _, _ = c.GetEntry([]byte("{c}"), nil)
+	a.NotNil(t, res)
+
+	stats := c.Stats()
+	a.Equal(t, uint64(1), stats.Evictions)
+	a.Equal(t, 2, stats.Entries)
+
+	c.SetLimits(1, 0)
+	stats = c.Stats()
+	a.Equal(t, uint64(2), stats.Evictions)
+	a.Equal(t, 1, stats.Entries)
+
+	c.Purge()
+	stats = c.Stats()
+	a.Equal(t, 0, stats.Entries)
+	a.Equal(t, 0, stats.Bytes)
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	c := New(0, 0)
+	c.SetEntry([]byte("{a}"), []byte("a"), nil, 0, nil, nil)
+	entrySize := c.Stats().Bytes
+	a.True(t, entrySize > 0)
+
+	c.SetLimits(0, entrySize*2)
+	c.SetEntry([]byte("{b}"), []byte("b"), nil, 0, nil, nil)
+	c.SetEntry([]byte("{c}"), []byte("c"), nil, 0, nil, nil)
+	stats := c.Stats()
+	a.Equal(t, 2, stats.Entries)
+	a.Equal(t, entrySize*2, stats.Bytes)
+
+	// Entries larger than the limit are never cached
+	c.SetEntry([]byte("{d}"), make([]byte, entrySize*2), nil, 0, nil, nil)
+	res, _, _, _ := c.GetEntry([]byte("{d}"), nil)
+	a.Nil(t, res)
+	a.Equal(t, 2, c.Stats().Entries)
+}
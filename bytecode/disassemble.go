@@ -0,0 +1,411 @@
+package bytecode
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Disassemble renders the bytecode of a parsed query (ParserCtx.Res) in a readable form
+// Every line contains the offset of the instruction, the name of the instruction and its operands, nested instructions are indented
+// If res is not valid bytecode the output stops with a line telling at what offset the bytecode became invalid
+//
+// Example output of `query Foo { a }`:
+//
+//	0001 operation query "Foo" arguments=f directives=0
+//	0009   field "a" directives=0 length=5 key=0x50c5d7e
+//	0023     end
+//	0025   end
+func Disassemble(res []byte) string {
+	d := disassembler{res: res}
+	d.disassemble()
+	return d.out.String()
+}
+
+// invalidBytecode is used to stop disassembling when the bytecode is not valid
+type invalidBytecode struct{}
+
+type disassembler struct {
+	res    []byte
+	charNr int
+	depth  int
+	out    strings.Builder
+}
+
+func (d *disassembler) disassemble() {
+	defer func() {
+		err := recover()
+		if err == nil {
+			return
+		}
+		if _, ok := err.(invalidBytecode); !ok {
+			panic(err)
+		}
+		d.line(d.charNr, "invalid bytecode")
+	}()
+
+	for d.charNr < len(d.res) {
+		d.expect(0)
+		switch d.seek() {
+		case ActionOperator:
+			d.operation()
+		case ActionFragment:
+			d.fragment()
+		default:
+			d.invalid()
+		}
+	}
+}
+
+// line writes a line for the instruction at offset
+func (d *disassembler) line(offset int, parts ...string) {
+	offsetStr := strconv.Itoa(offset)
+	for i := len(offsetStr); i < 4; i++ {
+		d.out.WriteByte('0')
+	}
+	d.out.WriteString(offsetStr)
+	d.out.WriteByte(' ')
+	for i := 0; i < d.depth; i++ {
+		d.out.WriteString("  ")
+	}
+	d.out.WriteString(strings.Join(parts, " "))
+	d.out.WriteByte('\n')
+}
+
+func (d *disassembler) invalid() {
+	panic(invalidBytecode{})
+}
+
+func (d *disassembler) seek() byte {
+	if d.charNr >= len(d.res) {
+		d.invalid()
+	}
+	return d.res[d.charNr]
+}
+
+func (d *disassembler) read() byte {
+	c := d.seek()
+	d.charNr++
+	return c
+}
+
+// expect reads the next byte and marks the bytecode as invalid if it's not c
+func (d *disassembler) expect(c byte) {
+	if d.read() != c {
+		d.charNr--
+		d.invalid()
+	}
+}
+
+func (d *disassembler) readUint32At(at int) int {
+	if at < 0 || at+4 > len(d.res) {
+		d.invalid()
+	}
+	data := d.res[at : at+4]
+	return int(uint32(data[0]) | (uint32(data[1]) << 8) | (uint32(data[2]) << 16) | (uint32(data[3]) << 24))
+}
+
+func (d *disassembler) readUint32() int {
+	res := d.readUint32At(d.charNr)
+	d.charNr += 4
+	return res
+}
+
+// readName reads until the next NULL byte and also reads the NULL byte
+func (d *disassembler) readName() string {
+	start := d.charNr
+	for d.read() != 0 {
+	}
+	return strconv.Quote(string(d.res[start : d.charNr-1]))
+}
+
+func (d *disassembler) readFlag() string {
+	c := d.read()
+	if c != 't' && c != 'f' {
+		d.charNr--
+		d.invalid()
+	}
+	return string(c)
+}
+
+func (d *disassembler) end() {
+	d.line(d.charNr, "end")
+	d.expect(ActionEnd)
+}
+
+func (d *disassembler) operation() {
+	offset := d.charNr
+	d.charNr++ // read [ActionOperator]
+
+	var kind string
+	switch d.read() {
+	case OperatorQuery:
+		kind = "query"
+	case OperatorMutation:
+		kind = "mutation"
+	case OperatorSubscription:
+		kind = "subscription"
+	default:
+		d.charNr--
+		d.invalid()
+	}
+	hasArguments := d.readFlag()
+	directivesCount := d.read()
+
+	// The name of the operation ends at the NULL byte before the arguments or selection set
+	nameStart := d.charNr
+	for d.seek() != 0 {
+		d.charNr++
+	}
+	name := strconv.Quote(string(d.res[nameStart:d.charNr]))
+	d.line(offset, "operation", kind, name, "arguments="+hasArguments, "directives="+strconv.Itoa(int(directivesCount)))
+	d.depth++
+
+	if hasArguments == "t" {
+		d.operationArguments()
+	}
+	d.expect(0)
+	d.directives(directivesCount)
+	d.selectionSet()
+	d.depth--
+}
+
+// operationArguments disassembles the arguments of an operation starting at the NULL byte followed by the length of the arguments
+func (d *disassembler) operationArguments() {
+	lengthAt := d.charNr + 1
+	d.charNr = lengthAt
+	length := d.readUint32()
+	d.expect(0)
+	d.line(d.charNr, "arguments", "length="+strconv.Itoa(length))
+	d.expect(ActionOperatorArgs)
+	d.depth++
+
+	// Every argument is followed by a NULL byte that is part of the argument length
+	d.expect(0)
+	for d.seek() != ActionEnd {
+		offset := d.charNr
+		d.expect(ActionOperatorArg)
+		argLen := d.readUint32()
+		name := d.readName()
+		qlType := d.readName()
+		hasDefault := d.readFlag()
+		d.line(offset, "argument", name, "type="+qlType, "default="+hasDefault, "length="+strconv.Itoa(argLen))
+		d.depth++
+		if hasDefault == "t" {
+			d.expect(0)
+			d.value()
+		}
+		if d.charNr < offset+argLen {
+			// The directives of the argument, every directive reads the NULL byte after it
+			d.expect(0)
+			for d.charNr < offset+argLen {
+				d.directives(1)
+			}
+		}
+		d.depth--
+		d.charNr = offset + 1 + argLen
+	}
+
+	d.depth--
+	d.end()
+}
+
+func (d *disassembler) fragment() {
+	offset := d.charNr
+	d.charNr++ // read [ActionFragment]
+	name := d.readName()
+
+	typeNameStart := d.charNr
+	for d.seek() != 0 {
+		d.charNr++
+	}
+	typeName := strconv.Quote(string(d.res[typeNameStart:d.charNr]))
+	d.line(offset, "fragment", name, "on", typeName)
+	d.charNr++ // read the NULL byte after the type name
+
+	d.depth++
+	d.selectionSet()
+	d.depth--
+}
+
+// selectionSet disassembles the selection set at the current charNr up to and including the end instruction
+// Fields are followed by a NULL byte, the NULL byte after the name of a spread takes this role for spreads
+func (d *disassembler) selectionSet() {
+	for {
+		switch d.seek() {
+		case ActionField:
+			d.field()
+		case ActionSpread:
+			d.spread()
+		case ActionEnd:
+			d.end()
+			return
+		default:
+			d.invalid()
+		}
+	}
+}
+
+func (d *disassembler) field() {
+	offset := d.charNr
+	d.charNr++ // read [ActionField]
+	directivesCount := d.read()
+	length := d.readUint32()
+	endOfField := d.charNr + 4 + length
+	key := d.readUint32()
+
+	aliasLen := int(d.read())
+	if d.charNr+aliasLen > len(d.res) {
+		d.invalid()
+	}
+	alias := string(d.res[d.charNr : d.charNr+aliasLen])
+	d.charNr += aliasLen
+	nameLen := int(d.read())
+	if d.charNr+nameLen > len(d.res) {
+		d.invalid()
+	}
+	name := string(d.res[d.charNr : d.charNr+nameLen])
+	d.charNr += nameLen
+	d.expect(0)
+
+	parts := []string{"field"}
+	if nameLen == 0 {
+		parts = append(parts, strconv.Quote(alias))
+	} else {
+		parts = append(parts, strconv.Quote(name), "alias="+strconv.Quote(alias))
+	}
+	parts = append(parts, "directives="+strconv.Itoa(int(directivesCount)), "length="+strconv.Itoa(length), "key=0x"+strconv.FormatUint(uint64(key), 16))
+	d.line(offset, parts...)
+
+	d.depth++
+	d.directives(directivesCount)
+	if d.seek() == ActionValue {
+		d.value()
+		d.expect(0)
+	}
+	d.selectionSet()
+	d.depth--
+
+	if d.charNr != endOfField {
+		d.charNr = endOfField
+		d.invalid()
+	}
+	d.expect(0)
+}
+
+func (d *disassembler) spread() {
+	offset := d.charNr
+	d.charNr++ // read [ActionSpread]
+	isInline := d.readFlag() == "t"
+	directivesCount := d.read()
+	length := d.readUint32()
+	endOfSpread := d.charNr + length + 1
+	name := d.readName()
+
+	kind := "fragment"
+	if isInline {
+		kind = "inline"
+	}
+	d.line(offset, "spread", kind, name, "directives="+strconv.Itoa(int(directivesCount)), "length="+strconv.Itoa(length))
+
+	d.depth++
+	d.directives(directivesCount)
+	if isInline {
+		d.selectionSet()
+	}
+	d.depth--
+
+	if d.charNr > endOfSpread {
+		d.charNr = endOfSpread
+		d.invalid()
+	}
+	d.charNr = endOfSpread
+}
+
+func (d *disassembler) directives(count uint8) {
+	for i := uint8(0); i < count; i++ {
+		offset := d.charNr
+		d.expect(ActionDirective)
+		hasArguments := d.readFlag()
+		name := d.readName()
+		d.line(offset, "directive", name, "arguments="+hasArguments)
+		if hasArguments == "t" {
+			d.depth++
+			d.value()
+			d.expect(0)
+			d.depth--
+		}
+	}
+}
+
+// value disassembles the value of which the [ActionValue] instruction is at the current charNr
+func (d *disassembler) value() {
+	offset := d.charNr
+	d.expect(ActionValue)
+	kind := d.read()
+	length := d.readUint32()
+	contentStart := d.charNr
+	contentEnd := contentStart + length
+	if contentEnd > len(d.res) {
+		d.invalid()
+	}
+
+	lengthStr := "length=" + strconv.Itoa(length)
+	switch kind {
+	case ValueList:
+		d.line(offset, "value list", lengthStr)
+		d.depth++
+		for {
+			d.expect(0)
+			if d.seek() == ActionEnd {
+				break
+			}
+			d.value()
+		}
+		d.depth--
+		d.end()
+	case ValueObject:
+		d.line(offset, "value object", lengthStr)
+		d.depth++
+		for {
+			d.expect(0)
+			if d.seek() == ActionEnd {
+				break
+			}
+			fieldOffset := d.charNr
+			d.expect(ActionObjectValueField)
+			d.line(fieldOffset, "object field", d.readName())
+			d.depth++
+			d.value()
+			d.depth--
+		}
+		d.depth--
+		d.end()
+	case ValueBoolean:
+		content := "false"
+		if length > 0 && d.res[contentStart] == '1' {
+			content = "true"
+		}
+		d.line(offset, "value boolean", content)
+		d.charNr = contentEnd
+	case ValueNull:
+		d.line(offset, "value null")
+	case ValueVariable, ValueInt, ValueFloat, ValueString, ValueEnum:
+		kinds := map[ValueKind]string{
+			ValueVariable: "variable",
+			ValueInt:      "int",
+			ValueFloat:    "float",
+			ValueString:   "string",
+			ValueEnum:     "enum",
+		}
+		d.line(offset, "value "+kinds[kind], strconv.Quote(string(d.res[contentStart:contentEnd])))
+		d.charNr = contentEnd
+	default:
+		d.charNr = offset + 1
+		d.invalid()
+	}
+
+	if d.charNr != contentEnd {
+		d.charNr = contentEnd
+		d.invalid()
+	}
+}
@@ -104,6 +104,7 @@ type testOperatorArg struct {
 	name         string // REQUIRED
 	bytecodeType string // REQUIRED
 	defaultValue *testValue
+	directives   []testDirective
 }
 
 func (o testOperatorArg) toBytes(res []byte) []byte {
@@ -119,6 +120,9 @@ func (o testOperatorArg) toBytes(res []byte) []byte {
 	} else {
 		res = append(res, 0, 'f')
 	}
+	for _, directive := range o.directives {
+		res = directive.toBytes(res)
+	}
 	end := len(res)
 
 	res = writeUint32At(res, start+1, uint32(end-start))
@@ -268,15 +272,15 @@ func (o testField) toBytes(res []byte) []byte {
 	for _, directive := range o.directives {
 		res = directive.toBytes(res)
 	}
-	for _, field := range o.fields {
-		res = field.toBytes(res)
-	}
 	if o.arguments != nil {
 		res = testValue{
 			kind:        ValueObject,
 			objectValue: o.arguments,
 		}.toBytes(res)
 	}
+	for _, field := range o.fields {
+		res = field.toBytes(res)
+	}
 	res = append(res, 0, 'e')
 
 	end := len(res)
@@ -0,0 +1,523 @@
+// Package clientgen generates a typed Go client from the introspection result of a schema
+//
+// The generated code contains a struct for every object, interface and input type, a string type with constants for every enum
+// and a method on the generated Client for every query and mutation field
+//
+// The cmd/yarqlclientgen command can be used with go:generate:
+//
+//	//go:generate go run github.com/mjarkk/yarql/cmd/yarqlclientgen -schema schema.json -package client -out client_gen.go
+package clientgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mjarkk/yarql"
+)
+
+// Options changes the generated code
+type Options struct {
+	// Package is the package name of the generated code, default client
+	Package string
+	// Scalars maps custom scalar names to go types, by default custom scalars are decoded as json.RawMessage
+	Scalars map[string]string
+}
+
+// GenerateFromSchema generates a client for a parsed schema, see Generate
+func GenerateFromSchema(s *yarql.Schema, opts Options) ([]byte, error) {
+	introspection, err := s.IntrospectionJSON()
+	if err != nil {
+		return nil, err
+	}
+	return Generate(introspection, opts)
+}
+
+// Generate generates the go source code of a client from introspection, the response of yarql.IntrospectionQuery
+// The response can be a full response like {"data":{"__schema":...}} or only the data
+func Generate(introspection []byte, opts Options) ([]byte, error) {
+	var response struct {
+		Data *struct {
+			Schema *schema `json:"__schema"`
+		} `json:"data"`
+		Schema *schema `json:"__schema"`
+	}
+	err := json.Unmarshal(introspection, &response)
+	if err != nil {
+		return nil, errors.New("invalid introspection result, " + err.Error())
+	}
+	s := response.Schema
+	if response.Data != nil && response.Data.Schema != nil {
+		s = response.Data.Schema
+	}
+	if s == nil {
+		return nil, errors.New("invalid introspection result, missing __schema")
+	}
+
+	if len(opts.Package) == 0 {
+		opts.Package = "client"
+	}
+	g := generator{schema: s, opts: opts, types: map[string]*fullType{}}
+	for _, t := range s.Types {
+		g.types[t.Name] = t
+	}
+	return g.generate()
+}
+
+type schema struct {
+	QueryType    *namedType  `json:"queryType"`
+	MutationType *namedType  `json:"mutationType"`
+	Types        []*fullType `json:"types"`
+}
+
+type namedType struct {
+	Name string `json:"name"`
+}
+
+type fullType struct {
+	Kind          string       `json:"kind"`
+	Name          string       `json:"name"`
+	Description   *string      `json:"description"`
+	Fields        []field      `json:"fields"`
+	InputFields   []inputValue `json:"inputFields"`
+	EnumValues    []enumValue  `json:"enumValues"`
+	PossibleTypes []typeRef    `json:"possibleTypes"`
+}
+
+type field struct {
+	Name              string       `json:"name"`
+	Description       *string      `json:"description"`
+	Args              []inputValue `json:"args"`
+	Type              typeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason *string      `json:"deprecationReason"`
+}
+
+type inputValue struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Type        typeRef `json:"type"`
+}
+
+type enumValue struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   *string  `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String returns the type as written in a query, for example [String!]!
+func (t typeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	default:
+		return *t.Name
+	}
+}
+
+// namedType returns the name of the type without list and non null wrappers
+func (t typeRef) namedType() string {
+	for t.OfType != nil {
+		t = *t.OfType
+	}
+	if t.Name == nil {
+		return ""
+	}
+	return *t.Name
+}
+
+// builtinScalars maps the scalars known by yarql to go types
+var builtinScalars = map[string]string{
+	"String":  "string",
+	"ID":      "string",
+	"Int":     "int32",
+	"Float":   "float64",
+	"Boolean": "bool",
+	"Int64":   "string",
+	"Time":    "time.Time",
+	"File":    "json.RawMessage",
+	"Upload":  "json.RawMessage",
+}
+
+type generator struct {
+	schema *schema
+	opts   Options
+	types  map[string]*fullType
+	out    bytes.Buffer
+
+	usesTime bool
+}
+
+func (g *generator) line(parts ...string) {
+	for _, part := range parts {
+		g.out.WriteString(part)
+	}
+	g.out.WriteByte('\n')
+}
+
+func (g *generator) comment(description *string, indent string) {
+	if description == nil || len(*description) == 0 {
+		return
+	}
+	for _, line := range strings.Split(*description, "\n") {
+		g.line(indent, "// ", line)
+	}
+}
+
+func (g *generator) generate() ([]byte, error) {
+	types := append([]*fullType{}, g.schema.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || strings.HasPrefix(t.Name, "_") {
+			continue
+		}
+		switch t.Kind {
+		case "OBJECT", "INTERFACE":
+			if g.isRootType(t.Name) {
+				continue
+			}
+			g.object(t)
+		case "INPUT_OBJECT":
+			g.input(t)
+		case "ENUM":
+			g.enum(t)
+		}
+	}
+	if g.schema.QueryType != nil {
+		g.operations("query", g.types[g.schema.QueryType.Name])
+	}
+	if g.schema.MutationType != nil {
+		g.operations("mutation", g.types[g.schema.MutationType.Name])
+	}
+	body := g.out.Bytes()
+
+	// The header is written after the body as the imports depend on the types used
+	g.out = bytes.Buffer{}
+	g.line("// Code generated by yarqlclientgen. DO NOT EDIT.")
+	g.line()
+	g.line("package ", g.opts.Package)
+	g.line()
+	g.line("import (")
+	g.line(`"bytes"`)
+	g.line(`"context"`)
+	g.line(`"encoding/json"`)
+	g.line(`"errors"`)
+	g.line(`"net/http"`)
+	if g.usesTime {
+		g.line(`"time"`)
+	}
+	g.line(")")
+	g.line()
+	g.out.WriteString(clientSource)
+	g.out.Write(body)
+
+	return format.Source(g.out.Bytes())
+}
+
+func (g *generator) isRootType(name string) bool {
+	return (g.schema.QueryType != nil && g.schema.QueryType.Name == name) || (g.schema.MutationType != nil && g.schema.MutationType.Name == name)
+}
+
+// goType returns the go type of t, nullable values are pointers except for lists
+func (g *generator) goType(t typeRef) string {
+	nonNull := t.Kind == "NON_NULL"
+	if nonNull {
+		t = *t.OfType
+	}
+
+	var res string
+	switch t.Kind {
+	case "LIST":
+		return "[]" + g.goType(*t.OfType)
+	case "SCALAR":
+		if goType, ok := g.opts.Scalars[*t.Name]; ok {
+			res = goType
+		} else if goType, ok := builtinScalars[*t.Name]; ok {
+			res = goType
+		} else {
+			res = "json.RawMessage"
+		}
+		if res == "time.Time" {
+			g.usesTime = true
+		}
+		if res == "json.RawMessage" {
+			// json.RawMessage can already contain null
+			return res
+		}
+	default:
+		res = goName(*t.Name)
+	}
+
+	if nonNull {
+		return res
+	}
+	return "*" + res
+}
+
+func (g *generator) object(t *fullType) {
+	g.comment(t.Description, "")
+	g.line("type ", goName(t.Name), " struct {")
+	if t.Kind == "INTERFACE" {
+		g.line("// Typename is the name of the implementation, only set if __typename is selected")
+		g.line("Typename string `json:\"__typename,omitempty\"`")
+	}
+	for _, f := range t.Fields {
+		g.comment(f.Description, "")
+		if f.IsDeprecated {
+			reason := "No longer supported"
+			if f.DeprecationReason != nil {
+				reason = *f.DeprecationReason
+			}
+			g.line("// Deprecated: ", reason)
+		}
+		g.line(goName(f.Name), " ", g.goType(f.Type), " `json:\"", f.Name, ",omitempty\"`")
+	}
+	g.line("}")
+	g.line()
+
+	// The fields that can be selected without arguments and sub selection, useful as default selection
+	leafFields := []string{}
+	for _, f := range t.Fields {
+		if len(f.Args) > 0 {
+			continue
+		}
+		if kind := g.kindOf(f.Type.namedType()); kind == "SCALAR" || kind == "ENUM" {
+			leafFields = append(leafFields, f.Name)
+		}
+	}
+	if len(leafFields) == 0 {
+		leafFields = append(leafFields, "__typename")
+	}
+	g.line("// ", goName(t.Name), "Fields selects all fields of ", t.Name, " without arguments that have no sub selection")
+	g.line("const ", goName(t.Name), "Fields = ", strconv.Quote("{"+strings.Join(leafFields, " ")+"}"))
+	g.line()
+}
+
+func (g *generator) kindOf(name string) string {
+	t, ok := g.types[name]
+	if !ok {
+		return ""
+	}
+	return t.Kind
+}
+
+func (g *generator) input(t *fullType) {
+	g.comment(t.Description, "")
+	g.line("type ", goName(t.Name), " struct {")
+	for _, f := range t.InputFields {
+		g.comment(f.Description, "")
+		tag := f.Name
+		if f.Type.Kind != "NON_NULL" {
+			tag += ",omitempty"
+		}
+		g.line(goName(f.Name), " ", g.goType(f.Type), " `json:\"", tag, "\"`")
+	}
+	g.line("}")
+	g.line()
+}
+
+func (g *generator) enum(t *fullType) {
+	name := goName(t.Name)
+	g.comment(t.Description, "")
+	g.line("type ", name, " string")
+	g.line()
+	g.line("// All possible ", t.Name, " values")
+	g.line("const (")
+	for _, value := range t.EnumValues {
+		g.comment(value.Description, "")
+		g.line(name, goName(strings.ToLower(value.Name)), " ", name, " = ", strconv.Quote(value.Name))
+	}
+	g.line(")")
+	g.line()
+}
+
+// operations generates a method on the client for every field of the root type
+func (g *generator) operations(operationType string, root *fullType) {
+	if root == nil {
+		return
+	}
+	for _, f := range root.Fields {
+		if strings.HasPrefix(f.Name, "_") {
+			continue
+		}
+		name := goName(f.Name)
+		if operationType == "mutation" {
+			name = "Mutate" + name
+		}
+
+		argsType := ""
+		if len(f.Args) > 0 {
+			argsType = name + "Args"
+			g.line("// ", argsType, " are the arguments of the ", f.Name, " ", operationType, " field")
+			g.line("type ", argsType, " struct {")
+			for _, arg := range f.Args {
+				g.comment(arg.Description, "")
+				g.line(goName(arg.Name), " ", g.goType(arg.Type), " `json:\"", arg.Name, "\"`")
+			}
+			g.line("}")
+			g.line()
+		}
+
+		hasSelection := true
+		switch g.kindOf(f.Type.namedType()) {
+		case "SCALAR", "ENUM":
+			hasSelection = false
+		}
+
+		operation := operationType + " " + name
+		if len(f.Args) > 0 {
+			variables := []string{}
+			arguments := []string{}
+			for _, arg := range f.Args {
+				variables = append(variables, "$"+arg.Name+": "+arg.Type.String())
+				arguments = append(arguments, arg.Name+": $"+arg.Name)
+			}
+			operation += "(" + strings.Join(variables, ", ") + ") { " + f.Name + "(" + strings.Join(arguments, ", ") + ")"
+		} else {
+			operation += " { " + f.Name
+		}
+
+		resultType := g.goType(f.Type)
+		g.comment(f.Description, "")
+		if f.IsDeprecated {
+			g.line("//")
+			g.line("// Deprecated: this field is deprecated")
+		}
+		params := "ctx context.Context"
+		if len(argsType) > 0 {
+			params += ", args " + argsType
+		}
+		if hasSelection {
+			g.line("// selection is the selection set of the result, for example ", goName(f.Type.namedType()), "Fields")
+			params += ", selection string"
+		}
+		g.line("func (c *Client) ", name, "(", params, ") (", resultType, ", error) {")
+		g.line("var res struct {")
+		g.line("Value ", resultType, " `json:", strconv.Quote(f.Name), "`")
+		g.line("}")
+		query := strconv.Quote(operation)
+		if hasSelection {
+			query += ` + " " + selection + " }"`
+		} else {
+			query = strconv.Quote(operation + " }")
+		}
+		variables := "nil"
+		if len(argsType) > 0 {
+			variables = "args"
+		}
+		g.line("err := c.Do(ctx, ", query, ", ", variables, ", &res)")
+		g.line("return res.Value, err")
+		g.line("}")
+		g.line()
+	}
+}
+
+// goName converts a graphql name into a exported go name
+func goName(name string) string {
+	res := strings.Builder{}
+	upper := true
+	for _, c := range name {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		res.WriteRune(c)
+	}
+	if res.Len() == 0 {
+		return "X"
+	}
+	s := res.String()
+	if s == "Id" {
+		return "ID"
+	}
+	if strings.HasSuffix(s, "Id") {
+		return s[:len(s)-2] + "ID"
+	}
+	return s
+}
+
+// clientSource is the client used by the generated operations
+const clientSource = `// Client executes operations against a graphql endpoint
+type Client struct {
+	// Endpoint is the url of the graphql endpoint
+	Endpoint string
+	// HTTPClient is used to send the requests, http.DefaultClient is used if nil
+	HTTPClient *http.Client
+	// Header is added to every request, for example to add a Authorization header
+	Header http.Header
+}
+
+// Error is a error returned by the graphql endpoint
+type Error struct {
+	Message string          ` + "`json:\"message\"`" + `
+	Path    json.RawMessage ` + "`json:\"path,omitempty\"`" + `
+}
+
+func (e Error) Error() string {
+	return e.Message
+}
+
+// Do executes query with variables and decodes the data of the response into result
+// If the response contains errors the first error is returned
+func (c *Client) Do(ctx context.Context, query string, variables interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var response struct {
+		Data   json.RawMessage ` + "`json:\"data\"`" + `
+		Errors []Error         ` + "`json:\"errors\"`" + `
+	}
+	err = json.NewDecoder(res.Body).Decode(&response)
+	if err != nil {
+		return errors.New("invalid response with status " + res.Status + ", " + err.Error())
+	}
+	if len(response.Errors) > 0 {
+		return response.Errors[0]
+	}
+	if len(response.Data) == 0 {
+		return errors.New("response without data")
+	}
+	return json.Unmarshal(response.Data, result)
+}
+
+`
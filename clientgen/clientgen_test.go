@@ -0,0 +1,115 @@
+package clientgen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mjarkk/yarql"
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type testRole uint8
+
+type testUser struct {
+	ID        uint `gq:"id,ID"`
+	Name      string
+	Email     *string
+	Role      testRole
+	CreatedAt time.Time
+	Friends   []testUser
+}
+
+type testQuery struct{}
+
+func (testQuery) ResolveUser(args struct{ ID string }) testUser {
+	return testUser{}
+}
+
+func (testQuery) ResolveUsers(args struct {
+	Filter *testUserFilter
+	First  *int
+}) []testUser {
+	return nil
+}
+
+func (testQuery) ResolveVersion() string {
+	return "1"
+}
+
+type testUserFilter struct {
+	Role      *testRole
+	NameLike  string
+	CreatedBy []string
+}
+
+type testMutation struct{}
+
+func (testMutation) ResolveRename(args struct{ ID, Name string }) testUser {
+	return testUser{}
+}
+
+func newTestSchema(t *testing.T) *yarql.Schema {
+	s := yarql.NewSchema()
+	_, err := s.RegisterEnum(map[string]testRole{"ADMIN": 0, "SUPER_USER": 1})
+	a.NoError(t, err)
+	a.NoError(t, s.Parse(testQuery{}, testMutation{}, nil))
+	return s
+}
+
+func TestGenerate(t *testing.T) {
+	source, err := GenerateFromSchema(newTestSchema(t), Options{Package: "myclient"})
+	a.NoError(t, err)
+	code := string(source)
+
+	for _, expected := range []string{
+		"package myclient",
+		"type TestUser struct {",
+		"\tID        string     `json:\"id,omitempty\"`",
+		"\tEmail     *string    `json:\"email,omitempty\"`",
+		"\tCreatedAt time.Time  `json:\"createdAt,omitempty\"`",
+		"\tFriends   []TestUser `json:\"friends,omitempty\"`",
+		"\tRole      TestRole   `json:\"role,omitempty\"`",
+		"const TestUserFields = \"{createdAt email id name role}\"",
+		"type TestRole string",
+		"TestRoleSuperUser TestRole = \"SUPER_USER\"",
+		"type TestUserFilter struct {",
+		"\tNameLike  string    `json:\"nameLike\"`",
+		"\tRole      *TestRole `json:\"role,omitempty\"`",
+		"func (c *Client) User(ctx context.Context, args UserArgs, selection string) (TestUser, error) {",
+		"err := c.Do(ctx, \"query User($ID: String) { user(ID: $ID)\"+\" \"+selection+\" }\", args, &res)",
+		"\tFirst  *int32          `json:\"first\"`",
+		"func (c *Client) Version(ctx context.Context) (string, error) {",
+		"err := c.Do(ctx, \"query Version { version }\", nil, &res)",
+		"func (c *Client) MutateRename(ctx context.Context, args MutateRenameArgs, selection string) (TestUser, error) {",
+	} {
+		a.True(t, strings.Contains(code, expected), expected+"\n\n"+code)
+	}
+
+	// The generated code must compile
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "client_gen.go", source, 0)
+	a.NoError(t, err)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("myclient", fset, []*ast.File{file}, nil)
+	a.NoError(t, err)
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	_, err := Generate([]byte(`{`), Options{})
+	a.Error(t, err)
+	_, err = Generate([]byte(`{"data":{}}`), Options{})
+	a.Error(t, err)
+}
+
+func TestGoName(t *testing.T) {
+	a.Equal(t, "ID", goName("id"))
+	a.Equal(t, "UserID", goName("userId"))
+	a.Equal(t, "SuperUser", goName("super_user"))
+	a.Equal(t, "Valid", goName("valid"))
+}
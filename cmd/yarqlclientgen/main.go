@@ -0,0 +1,54 @@
+// Command yarqlclientgen generates a typed Go client from the introspection result of a schema
+//
+// The introspection result can be created using (*yarql.Schema).IntrospectionJSON
+//
+// Usage:
+//
+//	yarqlclientgen -schema schema.json -package client -out client_gen.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mjarkk/yarql/clientgen"
+)
+
+func main() {
+	schemaFile := flag.String("schema", "schema.json", "the file containing the introspection result of the schema")
+	packageName := flag.String("package", "client", "the package name of the generated code")
+	out := flag.String("out", "client_gen.go", "the file to write the generated code to")
+	scalars := flag.String("scalars", "", "comma separated custom scalar to go type mappings, for example: Date=string,JSON=map[string]interface{}")
+	flag.Parse()
+
+	opts := clientgen.Options{Package: *packageName, Scalars: map[string]string{}}
+	if len(*scalars) > 0 {
+		for _, mapping := range strings.Split(*scalars, ",") {
+			parts := strings.SplitN(mapping, "=", 2)
+			if len(parts) != 2 {
+				exit(fmt.Errorf("invalid scalar mapping %q, expected Scalar=GoType", mapping))
+			}
+			opts.Scalars[parts[0]] = parts[1]
+		}
+	}
+
+	introspection, err := os.ReadFile(*schemaFile)
+	if err != nil {
+		exit(err)
+	}
+	source, err := clientgen.Generate(introspection, opts)
+	if err != nil {
+		exit(err)
+	}
+	err = os.WriteFile(*out, source, 0o644)
+	if err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "yarqlclientgen:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,42 @@
+// Command yarqldescgen generates the registration of schema descriptions from the doc comments of the go types within packages
+//
+// The arguments are the directories of the packages, defaults to the current directory.
+//
+// Usage:
+//
+//	yarqldescgen -out descriptions_gen.go . ./models
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mjarkk/yarql/descgen"
+)
+
+func main() {
+	packageName := flag.String("package", "", "the package name of the generated code, defaults to the package name of the first directory")
+	pkgPath := flag.String("pkgpath", "", "the import path of the generated code, defaults to the import path of the first directory")
+	out := flag.String("out", "descriptions_gen.go", "the file to write the generated code to")
+	flag.Parse()
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	source, err := descgen.Generate(dirs, descgen.Options{Package: *packageName, PkgPath: *pkgPath})
+	if err != nil {
+		exit(err)
+	}
+	err = os.WriteFile(*out, source, 0o644)
+	if err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "yarqldescgen:", err)
+	os.Exit(1)
+}
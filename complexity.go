@@ -0,0 +1,284 @@
+package yarql
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// fieldCost is a cost registered using (*Schema).RegisterFieldCost
+type fieldCost struct {
+	goType    reflect.Type
+	fieldName string
+	cost      int
+}
+
+// complexityMultiplierArgs are the field arguments that multiply the complexity of the field's selection set
+var complexityMultiplierArgs = map[string]bool{
+	"first": true,
+	"last":  true,
+	"limit": true,
+}
+
+// RegisterFieldCost overwrites the complexity cost of a field, fields cost 1 by default
+// fieldName is the graphql name of the field, this can also be a method or root resolver
+// The cost can also be set using the cost field tag (`gq:",cost=10"`)
+//
+// Example:
+//
+//	s.RegisterFieldCost(QueryRoot{}, "search", 50)
+func (s *Schema) RegisterFieldCost(goType interface{}, fieldName string, cost int) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterFieldCost() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+	if cost < 0 {
+		return errors.New("cost cannot be negative")
+	}
+
+	t, ok := structOrInterfaceType(goType)
+	if !ok {
+		return errors.New("can only register field costs on struct and interface types")
+	}
+
+	s.fieldCosts = append(s.fieldCosts, fieldCost{
+		goType:    t,
+		fieldName: fieldName,
+		cost:      cost,
+	})
+	return nil
+}
+
+// checkFieldCosts sets the costs registered using (*Schema).RegisterFieldCost on the fields
+func (c *parseCtx) checkFieldCosts() error {
+	for _, fieldCost := range c.schema.fieldCosts {
+		typeObj, ok, err := c.registeredType(fieldCost.goType)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("cannot register a field cost on " + fieldCost.goType.String())
+		}
+		field, ok := typeObj.objContents.getByName(fieldCost.fieldName)
+		if !ok {
+			return errors.New(typeObj.typeName + " has no field " + fieldCost.fieldName + " to register the cost on")
+		}
+		cost := fieldCost.cost
+		field.cost = &cost
+	}
+	return nil
+}
+
+// checkComplexity calculates the complexity of the operation at the current charNr and errors if it exceeds max
+// The charNr is restored afterwards
+func (ctx *Ctx) checkComplexity(max int) bool {
+	startCharNr := ctx.charNr
+	_, root, criticalErr := ctx.readOperation()
+	if criticalErr {
+		ctx.charNr = startCharNr
+		return criticalErr
+	}
+
+	complexity := ctx.selectionSetComplexity(root)
+	ctx.charNr = startCharNr
+	if complexity > max {
+		return ctx.err("query complexity of " + strconv.Itoa(complexity) + " exceeds the max complexity of " + strconv.Itoa(max))
+	}
+	return false
+}
+
+// Complexity returns the complexity of the operation that is executed, see (*yarql.Schema).RegisterFieldCost
+// Returns 0 if the query is not parsed yet or no operation was found
+func (ctx *Ctx) Complexity() int {
+	if ctx.query.TargetIdx == -1 || len(ctx.query.Errors) != 0 {
+		return 0
+	}
+	return ctx.operationComplexity()
+}
+
+// operationComplexity returns the complexity of the operation at the TargetIdx, expects the query to be parsed without errors
+func (ctx *Ctx) operationComplexity() int {
+	startCharNr := ctx.charNr
+	ctx.charNr = ctx.query.TargetIdx
+	_, root, criticalErr := ctx.readOperation()
+	complexity := 0
+	if criticalErr {
+		// The error is reported when executing the operation
+		ctx.query.Errors = ctx.query.Errors[:len(ctx.query.Errors)-1]
+	} else {
+		complexity = ctx.selectionSetComplexity(root)
+	}
+	ctx.charNr = startCharNr
+	return complexity
+}
+
+// selectionSetComplexity returns the complexity of the selection set at the current charNr
+func (ctx *Ctx) selectionSetComplexity(typeObj *obj) int {
+	complexity := 0
+	for {
+		switch ctx.readInst() {
+		case bytecode.ActionField:
+			complexity = addComplexity(complexity, ctx.fieldComplexity(typeObj))
+		case bytecode.ActionSpread:
+			complexity = addComplexity(complexity, ctx.spreadComplexity(typeObj))
+		default:
+			// End of the selection set
+			return complexity
+		}
+	}
+}
+
+// fieldComplexity returns the cost of the field at the current charNr plus the complexity of its selection set
+func (ctx *Ctx) fieldComplexity(typeObj *obj) int {
+	directivesCount := ctx.readInst()
+	fieldLen := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	nameKey := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	endOfField := ctx.charNr + int(fieldLen)
+
+	aliasLen := int(ctx.readInst())
+	name := ctx.query.Res[ctx.charNr : ctx.charNr+aliasLen]
+	ctx.skipInst(aliasLen)
+	nameLen := int(ctx.readInst())
+	if nameLen != 0 {
+		name = ctx.query.Res[ctx.charNr : ctx.charNr+nameLen]
+		ctx.skipInst(nameLen)
+	}
+	ctx.skipInst(1)
+
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.skipDirective()
+	}
+
+	var field *obj
+	if typeObj != nil {
+		field, _ = typeObj.objContents.get(nameKey, name)
+	}
+	cost := 1
+	if field != nil && field.cost != nil {
+		cost = *field.cost
+	}
+
+	multiplier := 1
+	if ctx.seekInst() == bytecode.ActionValue {
+		ctx.walkInputObject(func(key []byte) bool {
+			if complexityMultiplierArgs[b2s(key)] {
+				if value := ctx.complexityMultiplier(); value > multiplier {
+					multiplier = value
+				}
+			}
+			// Skip ActionValue, the value kind, the length of the value and the value itself
+			ctx.skipInst(6 + int(ctx.readUint32(ctx.charNr+2)))
+			return false
+		})
+	}
+
+	if ctx.seekInst() != bytecode.ActionEnd {
+		var fieldType *obj
+		if field != nil {
+			fieldType = ctx.schema.complexityType(field)
+		}
+		cost = addComplexity(cost, multiplyComplexity(multiplier, ctx.selectionSetComplexity(fieldType)))
+	}
+
+	ctx.charNr = endOfField + 1
+	return cost
+}
+
+// complexityMultiplier returns the integer value at the current charNr, variables are resolved
+// The charNr is not modified and errors are ignored as they are reported when executing the field
+func (ctx *Ctx) complexityMultiplier() int {
+	startCharNr := ctx.charNr
+	errorsLen := len(ctx.query.Errors)
+
+	var value int
+	goValue := reflect.ValueOf(&value).Elem()
+	switch ctx.query.Res[ctx.charNr+1] {
+	case bytecode.ValueInt, bytecode.ValueVariable:
+		ctx.bindInputToGoValue(&goValue, &input{kind: reflect.Int}, true)
+	}
+
+	ctx.charNr = startCharNr
+	if len(ctx.query.Errors) != errorsLen {
+		ctx.query.Errors = ctx.query.Errors[:errorsLen]
+		return 0
+	}
+	return value
+}
+
+// spreadComplexity returns the complexity of the fragment spread at the current charNr
+// Fragments on other types, for example the implementations of an interface, are all counted
+func (ctx *Ctx) spreadComplexity(typeObj *obj) int {
+	isInline := ctx.readInst() == 't'
+	directivesCount := ctx.readInst()
+	lenOfSpread := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read name or on inline fragment the type name
+	}
+	name := ctx.query.Res[nameStart : ctx.charNr-1]
+	endOfSpread := nameStart + int(lenOfSpread) + 1
+
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.skipDirective()
+	}
+
+	complexity := 0
+	if isInline {
+		complexity = ctx.selectionSetComplexity(ctx.schema.complexityFragmentType(typeObj, name))
+	} else if typeName, selectionSetAt, ok := ctx.findFragment(name); ok {
+		ctx.charNr = selectionSetAt
+		complexity = ctx.selectionSetComplexity(ctx.schema.complexityFragmentType(typeObj, typeName))
+	}
+
+	ctx.charNr = endOfSpread
+	return complexity
+}
+
+// complexityFragmentType returns the type a fragment is defined on
+func (s *Schema) complexityFragmentType(typeObj *obj, typeName []byte) *obj {
+	if typeObj != nil && b2s(typeObj.typeNameBytes) == b2s(typeName) {
+		return typeObj
+	}
+	fragmentType, _ := s.getTypeOrInterface(b2s(typeName))
+	return fragmentType
+}
+
+// complexityType returns the type of which the fields can be selected on field
+func (s *Schema) complexityType(field *obj) *obj {
+	for {
+		switch field.valueType {
+		case valueTypeArray, valueTypePtr:
+			field = field.innerContent
+		case valueTypeMethod:
+			field = &field.method.outType
+		case valueTypeObjRef, valueTypeInterfaceRef:
+			typeObj, _ := s.resolveRef(field)
+			return typeObj
+		default:
+			return field
+		}
+	}
+}
+
+func addComplexity(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func multiplyComplexity(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
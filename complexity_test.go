@@ -0,0 +1,84 @@
+package yarql
+
+import (
+	"strconv"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestComplexityQuery struct {
+	Name   string
+	Author TestComplexityUser
+	Heavy  string `gq:",cost=10"`
+}
+
+func (TestComplexityQuery) ResolveUsers(args struct {
+	First  *int
+	Offset int
+}) []TestComplexityUser {
+	return []TestComplexityUser{{Name: "a"}}
+}
+
+type TestComplexityUser struct {
+	Name    string
+	Friends []TestComplexityUser
+}
+
+func (TestComplexityUser) ResolveSearch(args struct{ Limit int }) []TestComplexityUser {
+	return nil
+}
+
+func TestQueryComplexity(t *testing.T) {
+	tests := []struct {
+		query      string
+		complexity int
+	}{
+		{`{name}`, 1},
+		{`{name heavy}`, 11},
+		{`{author {name friends {name}}}`, 4},
+		{`{users(first: 10) {name}}`, 11},
+		{`{users(offset: 10) {name}}`, 2},
+		{`{users(first: 10) {name friends {name}}}`, 31},
+		{`{author {search(limit: 2) {name}}}`, 8},
+		{`{author {...userFields ... on TestComplexityUser {friends {name}}}} fragment userFields on TestComplexityUser {name}`, 4},
+		{`query($n: Int) {users(first: $n) {name}}`, 21},
+		{`{__typename}`, 1},
+	}
+
+	for _, test := range tests {
+		// The max complexity is exactly the complexity of the query so it's allowed
+		s := NewSchema()
+		a.NoError(t, s.RegisterFieldCost(TestComplexityUser{}, "search", 5))
+		_, errs := bytecodeParse(t, s, test.query, TestComplexityQuery{}, M{}, ResolveOptions{NoMeta: true, MaxComplexity: test.complexity, Variables: `{"n": 20}`})
+		a.Equal(t, 0, len(errs), test.query)
+
+		if test.complexity == 1 {
+			// A max complexity of 0 disables the check
+			continue
+		}
+		s = NewSchema()
+		a.NoError(t, s.RegisterFieldCost(TestComplexityUser{}, "search", 5))
+		res, errs := bytecodeParse(t, s, test.query, TestComplexityQuery{}, M{}, ResolveOptions{NoMeta: true, MaxComplexity: test.complexity - 1, Variables: `{"n": 20}`})
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, "query complexity of "+strconv.Itoa(test.complexity)+" exceeds the max complexity of "+strconv.Itoa(test.complexity-1), errs[0].Error())
+		a.Equal(t, `{}`, res)
+	}
+}
+
+func TestQueryComplexityDisabled(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterFieldCost(TestComplexityUser{}, "search", 5))
+	_, errs := bytecodeParse(t, s, `{users(first: 100000) {friends {friends {name}}}}`, TestComplexityQuery{}, M{})
+	a.Equal(t, 0, len(errs))
+}
+
+func TestRegisterFieldCostInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterFieldCost(nil, "name", 1))
+	a.Error(t, s.RegisterFieldCost("", "name", 1))
+	a.Error(t, s.RegisterFieldCost(TestComplexityUser{}, "name", -1))
+
+	a.NoError(t, s.RegisterFieldCost(TestComplexityUser{}, "unknown", 1))
+	a.Error(t, s.Parse(TestComplexityQuery{}, M{}, nil))
+}
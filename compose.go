@@ -0,0 +1,254 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// AddSchema adds the query and mutation fields of module to the roots of s
+// This allows a modular monolith to keep a schema per module and serve them together as one schema
+// module must be parsed, its registrations like enums, directives, field resolvers and loaders are added to s
+//
+// Registrations on the same go type are taken from the schema that added them first,
+// an error is returned if a enum, directive or loader with the same name differs between the schemas
+// The root fields are checked for conflicts when s is parsed, types with the same name but a different go type are reported by Parse
+// Request level settings of module like middleware, extensions and the request logger are not used, set them on s instead
+//
+// Example:
+//
+//	s := yarql.NewSchema()
+//	s.AddSchema(accountsSchema)
+//	s.AddSchema(ordersSchema)
+//	err := s.Parse(QueryRoot{}, MethodRoot{}, nil)
+func (s *Schema) AddSchema(module *Schema) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).AddSchema() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if module == nil {
+		return errors.New("module cannot be nil")
+	}
+	if module == s {
+		return errors.New("a schema cannot be added to itself")
+	}
+	if !module.parsed || module.reload == nil {
+		return errors.New("(*yarql.Schema).AddSchema() can only add parsed schemas")
+	}
+	for _, added := range s.composed {
+		if added == module {
+			return errors.New("schema is already added")
+		}
+	}
+
+	// Use a copy of the registrations so module and s do not share the parsed state of directives and entities
+	registered := module.reload.registered.registrations()
+	err := s.checkComposedConflicts(registered, module)
+	if err != nil {
+		return err
+	}
+	s.addComposedRegistrations(registered)
+	s.composed = append(s.composed, module)
+	return nil
+}
+
+// checkComposedConflicts returns an error if the registrations of module conflict with the registrations of s
+func (s *Schema) checkComposedConflicts(registered *Schema, module *Schema) error {
+	for _, enum := range registered.definedEnums {
+		for _, definedEnum := range s.definedEnums {
+			if enum.typeName == definedEnum.typeName && enum.contentType != definedEnum.contentType {
+				return errors.New("cannot have 2 enums with the same name " + enum.typeName + ": " + enum.contentType.String() + " != " + definedEnum.contentType.String())
+			}
+		}
+	}
+
+	for _, directives := range registered.definedDirectives {
+		for _, directive := range directives {
+			defined := s.directiveByName(directive.Name)
+			if defined != nil && !sameFunc(defined.Method, directive.Method) {
+				return errors.New("cannot have 2 directives with the same name " + directive.Name)
+			}
+		}
+	}
+
+	for name, batch := range registered.loaders {
+		defined, ok := s.loaders[name]
+		if ok && !sameFunc(defined, batch) {
+			return errors.New("a loader with the name " + name + " is already registered")
+		}
+	}
+
+	for _, resolver := range registered.rootResolvers {
+		for _, definedResolver := range s.rootResolvers {
+			if resolver.isMutation == definedResolver.isMutation && resolver.name == definedResolver.name {
+				return errors.New("resolver " + resolver.name + " is already added")
+			}
+		}
+	}
+
+	for _, rootValue := range []reflect.Value{module.rootQueryValue, module.rootMethodValue} {
+		if _, ok := registered.fieldResolvers[rootValue.Type()]; ok {
+			return errors.New("field resolvers on the root " + rootValue.Type().String() + " of a added schema are not supported, use (*yarql.Schema).AddQueryResolver instead")
+		}
+	}
+
+	return nil
+}
+
+// addComposedRegistrations adds the registrations of a schema added using AddSchema to s, expects checkComposedConflicts to be called first
+func (s *Schema) addComposedRegistrations(registered *Schema) {
+	for _, enum := range registered.definedEnums {
+		if _, defined := s.getEnum(enum.contentType); defined == nil {
+			s.definedEnums = append(s.definedEnums, enum)
+		}
+	}
+
+	added := map[*Directive]bool{}
+	for location, directives := range registered.definedDirectives {
+		for _, directive := range directives {
+			if !added[directive] && s.directiveByName(directive.Name) != nil {
+				// Directives like @skip and @include are defined by every schema
+				continue
+			}
+			added[directive] = true
+			s.definedDirectives[location] = append(s.definedDirectives[location], directive)
+		}
+	}
+
+	for name, batch := range registered.loaders {
+		if _, ok := s.loaders[name]; !ok {
+			s.loaders[name] = batch
+		}
+	}
+	for name, mock := range registered.mocks {
+		if s.mocks == nil {
+			s.mocks = map[string]MockFunc{}
+		}
+		if _, ok := s.mocks[name]; !ok {
+			s.mocks[name] = mock
+		}
+	}
+
+	addMissing(s.typeOwners, registered.typeOwners)
+	addMissing(s.descriptions, registered.descriptions)
+	addMissing(s.fieldResolvers, registered.fieldResolvers)
+	addMissing(s.entities, registered.entities)
+	addMissing(s.typeFederation, registered.typeFederation)
+	addMissing(s.nodeFetchers, registered.nodeFetchers)
+	addMissing(s.typeAuth, registered.typeAuth)
+	addMissing(s.typeResolvers, registered.typeResolvers)
+	addMissing(s.customScalars, registered.customScalars)
+	addMissing(s.requestStates, registered.requestStates)
+	addMissing(s.bindings, registered.bindings)
+
+	s.rootResolvers = append(s.rootResolvers, registered.rootResolvers...)
+	s.fieldCosts = append(s.fieldCosts, registered.fieldCosts...)
+	s.fieldAuth = append(s.fieldAuth, registered.fieldAuth...)
+	s.memoizedFields = append(s.memoizedFields, registered.memoizedFields...)
+	s.fieldScopes = append(s.fieldScopes, registered.fieldScopes...)
+}
+
+// directiveByName returns the directive with name, nil if no such directive is registered
+func (s *Schema) directiveByName(name string) *Directive {
+	for _, directives := range s.definedDirectives {
+		for _, directive := range directives {
+			if directive.Name == name {
+				return directive
+			}
+		}
+	}
+	return nil
+}
+
+// addMissing adds the entries of the map from to the map to that are not yet in to
+// The key is not a type parameter as reflect.Type only satisfies comparable as of go 1.20
+func addMissing[V any](to, from map[reflect.Type]V) {
+	for key, value := range from {
+		if _, ok := to[key]; !ok {
+			to[key] = value
+		}
+	}
+}
+
+// sameFunc returns true if a and b are the same function
+func sameFunc(a, b interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// checkComposedSchemas adds the root fields of the schemas added using (*Schema).AddSchema to the roots of the schema
+func (c *parseCtx) checkComposedSchemas() error {
+	c.composedRoots = map[reflect.Type]*obj{}
+	for _, module := range c.schema.composed {
+		err := c.addComposedRoot(c.schema.rootQuery, module.rootQueryValue)
+		if err != nil {
+			return err
+		}
+		err = c.addComposedRoot(c.schema.rootMethod, module.rootMethodValue)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addComposedRoot adds the fields of the root of a added schema to root
+// The fields are resolved on rootValue so the type of the added root is not part of the schema
+func (c *parseCtx) addComposedRoot(root *obj, rootValue reflect.Value) error {
+	t := rootValue.Type()
+	composedRoot := obj{
+		valueType:   valueTypeObj,
+		typeName:    root.typeName,
+		objContents: objFields{},
+	}
+	err := c.checkStructFieldRecursive(t, &composedRoot)
+	if err != nil {
+		return err
+	}
+	err = c.checkTypeMethods(t, &composedRoot)
+	if err != nil {
+		return err
+	}
+
+	owner := c.schema.typeOwners[t]
+	role, hasAuth := c.schema.typeAuth[t]
+	for _, field := range composedRoot.objContents.all() {
+		name := string(field.qlFieldName)
+		if _, ok := root.objContents.getByName(name); ok {
+			return errors.New("cannot add field " + name + " of " + t.String() + ", field already defined on " + root.typeName)
+		}
+
+		var value reflect.Value
+		if field.valueType == valueTypeMethod {
+			value = typeMethod(rootValue, field.method)
+		} else {
+			value = structField(rootValue, field)
+		}
+		field.customObjValue = &value
+
+		// The type specific registrations of the added root apply to its fields
+		if len(field.owner) == 0 {
+			field.owner = owner
+		}
+		if hasAuth && field.auth == nil {
+			fieldRole := role
+			field.auth = &fieldRole
+			c.schema.auth = true
+		}
+		root.objContents.set(field)
+	}
+
+	c.composedRoots[t] = root
+	return nil
+}
+
+// registeredType returns the struct or interface type of goType for registrations on fields like (*Schema).RegisterFieldCost
+// The roots of schemas added using (*Schema).AddSchema return the root they are added to
+func (c *parseCtx) registeredType(goType reflect.Type) (typeObj *obj, ok bool, err error) {
+	if root, ok := c.composedRoots[goType]; ok {
+		return root, true, nil
+	}
+	ref, err := c.check(goType, false)
+	if err != nil {
+		return nil, false, err
+	}
+	typeObj, ok = c.schema.getTypeOrInterface(ref.typeName)
+	return typeObj, ok, nil
+}
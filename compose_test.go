@@ -0,0 +1,158 @@
+package yarql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestComposeUser struct {
+	ID   string `gq:",id"`
+	Name string
+}
+
+type TestComposeAccountsQuery struct {
+	Users []TestComposeUser
+}
+
+func (TestComposeAccountsQuery) ResolveUser(args struct{ ID string }) (TestComposeUser, error) {
+	if args.ID != "1" {
+		return TestComposeUser{}, errors.New("user not found")
+	}
+	return TestComposeUser{ID: "1", Name: "alice"}, nil
+}
+
+type TestComposeAccountsMethods struct{}
+
+func (*TestComposeAccountsMethods) ResolveRename(args struct{ Name string }) string {
+	return args.Name
+}
+
+type TestComposeOrder struct {
+	Status TestEnum2
+}
+
+type TestComposeOrdersQuery struct {
+	Orders []TestComposeOrder
+}
+
+type TestComposeOrdersMethods struct{}
+
+type TestComposeGatewayQuery struct {
+	Version int
+}
+
+type TestComposeGatewayMethods struct{}
+
+func newTestComposeModules(t *testing.T) (accounts *Schema, orders *Schema) {
+	accounts = NewSchema()
+	a.NoError(t, accounts.AddQueryResolver("me", func() TestComposeUser {
+		return TestComposeUser{ID: "2", Name: "bob"}
+	}))
+	a.NoError(t, accounts.RegisterTypeOwner(TestComposeAccountsQuery{}, "accounts"))
+	a.NoError(t, accounts.RegisterFieldCost(TestComposeAccountsQuery{}, "users", 50))
+	a.NoError(t, accounts.Parse(TestComposeAccountsQuery{Users: []TestComposeUser{{ID: "1", Name: "alice"}}}, TestComposeAccountsMethods{}, nil))
+
+	orders = NewSchema()
+	_, err := orders.RegisterEnum(map[string]TestEnum2{"FOO": TestEnum2Foo, "BAR": TestEnum2Bar})
+	a.NoError(t, err)
+	a.NoError(t, orders.Parse(TestComposeOrdersQuery{Orders: []TestComposeOrder{{Status: TestEnum2Bar}}}, TestComposeOrdersMethods{}, nil))
+
+	return accounts, orders
+}
+
+func TestAddSchema(t *testing.T) {
+	accounts, orders := newTestComposeModules(t)
+
+	s := NewSchema()
+	a.NoError(t, s.AddSchema(accounts))
+	a.NoError(t, s.AddSchema(orders))
+	a.NoError(t, s.Parse(TestComposeGatewayQuery{Version: 3}, TestComposeGatewayMethods{}, nil))
+
+	res, errs := s.Exec([]byte(`{version users {ID name} user(ID: "1") {name} me {name} orders {status}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"version":3,"users":[{"ID":"1","name":"alice"}],"user":{"name":"alice"},"me":{"name":"bob"},"orders":[{"status":"BAR"}]}`, string(res))
+
+	res, errs = s.Exec([]byte(`mutation {rename(name: "carol")}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"rename":"carol"}`, string(res))
+
+	// The owner and cost registered on the root of the module apply to its fields
+	_, errs = s.Exec([]byte(`{user(ID: "2") {name}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "accounts", errs[0].(ErrorWPath).Owner())
+
+	_, errs = s.Exec([]byte(`{users {name}}`), ResolveOptions{NoMeta: true, MaxComplexity: 10})
+	a.Equal(t, 1, len(errs))
+
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "type TestComposeGatewayQuery {"), sdl)
+	a.True(t, strings.Contains(sdl, "\tuser(ID: String): TestComposeUser!\n"), sdl)
+	a.False(t, strings.Contains(sdl, "TestComposeAccountsQuery"), sdl)
+	a.False(t, strings.Contains(sdl, "TestComposeOrdersQuery"), sdl)
+
+	// The modules keep working on their own
+	res, errs = accounts.Exec([]byte(`{me {name}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"me":{"name":"bob"}}`, string(res))
+}
+
+func TestAddSchemaReload(t *testing.T) {
+	accounts, orders := newTestComposeModules(t)
+
+	s := NewSchema()
+	a.NoError(t, s.AddSchema(accounts))
+	a.NoError(t, s.AddSchema(orders))
+	a.NoError(t, s.Parse(TestComposeGatewayQuery{Version: 1}, TestComposeGatewayMethods{}, nil))
+	a.NoError(t, s.Reload(TestComposeGatewayQuery{Version: 2}, TestComposeGatewayMethods{}, nil))
+
+	res, errs := s.Exec([]byte(`{version me {name} orders {status}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"version":2,"me":{"name":"bob"},"orders":[{"status":"BAR"}]}`, string(res))
+}
+
+type TestComposeConflictQuery struct {
+	Users []TestComposeUser
+}
+
+type TestComposeConflictMethods struct{}
+
+func TestAddSchemaConflicts(t *testing.T) {
+	accounts, orders := newTestComposeModules(t)
+
+	s := NewSchema()
+	a.Error(t, s.AddSchema(nil))
+	a.Error(t, s.AddSchema(s))
+	a.Error(t, s.AddSchema(NewSchema()))
+	a.NoError(t, s.AddSchema(accounts))
+	a.Error(t, s.AddSchema(accounts))
+
+	// Root fields with the same name
+	conflicting := NewSchema()
+	a.NoError(t, conflicting.Parse(TestComposeConflictQuery{}, TestComposeConflictMethods{}, nil))
+	a.NoError(t, s.AddSchema(conflicting))
+	err := s.Parse(TestComposeGatewayQuery{}, TestComposeGatewayMethods{}, nil)
+	a.Error(t, err)
+	a.True(t, strings.Contains(err.Error(), "field already defined"), err.Error())
+
+	s = NewSchema()
+	a.NoError(t, s.AddSchema(orders))
+	a.Error(t, s.AddSchema(orders))
+	a.NoError(t, s.Parse(TestComposeGatewayQuery{}, TestComposeGatewayMethods{}, nil))
+	a.Error(t, s.AddSchema(accounts))
+
+	// Root resolvers with the same name
+	s = NewSchema()
+	a.NoError(t, s.AddQueryResolver("me", func() string { return "" }))
+	a.Error(t, s.AddSchema(accounts))
+
+	// Loaders with the same name
+	s = NewSchema()
+	a.NoError(t, s.RegisterLoader("users", func(ctx *Ctx, keys []interface{}) ([]interface{}, error) { return nil, nil }))
+	module := NewSchema()
+	a.NoError(t, module.RegisterLoader("users", func(ctx *Ctx, keys []interface{}) ([]interface{}, error) { return nil, errors.New("other") }))
+	a.NoError(t, module.Parse(TestComposeOrdersQuery{}, TestComposeOrdersMethods{}, nil))
+	a.Error(t, s.AddSchema(module))
+}
@@ -0,0 +1,164 @@
+package yarql
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// typeNamer can be implemented by types of this package to generate their graphql type name
+// Used for generic types as their go type name is not a valid graphql name
+type typeNamer interface {
+	qlTypeName(c *parseCtx) (string, error)
+}
+
+// Connection is a relay connection of nodes of type T
+// The graphql type name is the name of T followed by Connection, for example: UserConnection
+// Connection[User] and Connection[*User] are the same graphql type, its nodes are nullable if any of them uses a pointer
+// See: https://relay.dev/graphql/connections.htm
+type Connection[T any] struct {
+	Edges    []Edge[T]
+	PageInfo PageInfo
+}
+
+func (Connection[T]) qlTypeName(c *parseCtx) (string, error) {
+	return connectionNodeTypeName(c, reflect.TypeOf((*T)(nil)).Elem(), "Connection")
+}
+
+// Edge is an edge of a relay connection
+// The graphql type name is the name of T followed by Edge, for example: UserEdge
+type Edge[T any] struct {
+	Node   T
+	Cursor string
+}
+
+func (Edge[T]) qlTypeName(c *parseCtx) (string, error) {
+	return connectionNodeTypeName(c, reflect.TypeOf((*T)(nil)).Elem(), "Edge")
+}
+
+// connectionNodeTypeName returns the graphql type name of the node type t followed by suffix
+func connectionNodeTypeName(c *parseCtx, t reflect.Type, suffix string) (string, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct && t.Kind() != reflect.Interface {
+		return "", errors.New("the node type of a connection must be a struct or interface, got " + t.String())
+	}
+
+	nodeObj, err := c.check(t, false)
+	if err != nil {
+		return "", err
+	}
+	return nodeObj.typeName + suffix, nil
+}
+
+// PageInfo is the page info of a relay connection
+type PageInfo struct {
+	HasNextPage     bool
+	HasPreviousPage bool
+	StartCursor     *string
+	EndCursor       *string
+}
+
+// ConnectionArgs are the relay connection arguments, can be used as method arguments
+//
+// Example:
+//
+//	func (Query) ResolveUsers(args yarql.ConnectionArgs) (yarql.Connection[User], error) {
+//		return yarql.ConnectionFromSlice(users, args)
+//	}
+type ConnectionArgs struct {
+	First  *int
+	After  *string
+	Last   *int
+	Before *string
+}
+
+const cursorPrefix = "connection:"
+
+// OffsetToCursor creates a cursor for the item at offset
+func OffsetToCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// CursorToOffset returns the offset of a cursor created by OffsetToCursor
+func CursorToOffset(cursor string) (int, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(decoded), cursorPrefix) {
+		return 0, errors.New("invalid cursor")
+	}
+	offset, err := strconv.Atoi(string(decoded[len(cursorPrefix):]))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return offset, nil
+}
+
+// ConnectionFromSlice creates a page of items based on the connection arguments
+// The cursors of the edges are created using OffsetToCursor
+func ConnectionFromSlice[T any](items []T, args ConnectionArgs) (Connection[T], error) {
+	start := 0
+	end := len(items)
+
+	if args.After != nil {
+		afterOffset, err := CursorToOffset(*args.After)
+		if err != nil {
+			return Connection[T]{}, err
+		}
+		if afterOffset+1 > start {
+			start = afterOffset + 1
+		}
+	}
+	if args.Before != nil {
+		beforeOffset, err := CursorToOffset(*args.Before)
+		if err != nil {
+			return Connection[T]{}, err
+		}
+		if beforeOffset < end {
+			end = beforeOffset
+		}
+	}
+	if start > end {
+		start = end
+	}
+	lowerBound, upperBound := start, end
+
+	if args.First != nil {
+		if *args.First < 0 {
+			return Connection[T]{}, errors.New("first cannot be less than 0")
+		}
+		if start+*args.First < end {
+			end = start + *args.First
+		}
+	}
+	if args.Last != nil {
+		if *args.Last < 0 {
+			return Connection[T]{}, errors.New("last cannot be less than 0")
+		}
+		if end-*args.Last > start {
+			start = end - *args.Last
+		}
+	}
+
+	connection := Connection[T]{
+		Edges: make([]Edge[T], end-start),
+		PageInfo: PageInfo{
+			HasPreviousPage: args.Last != nil && start > lowerBound,
+			HasNextPage:     args.First != nil && end < upperBound,
+		},
+	}
+	for idx := range connection.Edges {
+		connection.Edges[idx] = Edge[T]{
+			Node:   items[start+idx],
+			Cursor: OffsetToCursor(start + idx),
+		}
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+
+	return connection, nil
+}
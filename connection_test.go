@@ -0,0 +1,158 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestConnectionQ struct {
+	Users []TestConnectionUser `gq:"-"`
+}
+
+type TestConnectionUser struct {
+	Name string
+}
+
+func (q TestConnectionQ) ResolveUserConnection(args ConnectionArgs) (Connection[TestConnectionUser], error) {
+	return ConnectionFromSlice(q.Users, args)
+}
+
+type TestConnectionPost struct {
+	Title string
+}
+
+func (q TestConnectionQ) ResolvePostConnection(args ConnectionArgs) (Connection[*TestConnectionPost], error) {
+	posts := []*TestConnectionPost{{Title: "a"}, {Title: "b"}, nil}
+	return ConnectionFromSlice(posts, args)
+}
+
+func intPtr(v int) *int { return &v }
+
+func strPtr(v string) *string { return &v }
+
+func TestCursor(t *testing.T) {
+	offset, err := CursorToOffset(OffsetToCursor(12))
+	a.NoError(t, err)
+	a.Equal(t, 12, offset)
+
+	_, err = CursorToOffset("invalid")
+	a.Error(t, err)
+	_, err = CursorToOffset(ToGlobalID("User", "1"))
+	a.Error(t, err)
+}
+
+func TestConnectionFromSlice(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	nodes := func(connection Connection[string]) string {
+		res := []string{}
+		for _, edge := range connection.Edges {
+			res = append(res, edge.Node)
+		}
+		return strings.Join(res, "")
+	}
+
+	connection, err := ConnectionFromSlice(items, ConnectionArgs{})
+	a.NoError(t, err)
+	a.Equal(t, "abcde", nodes(connection))
+	a.False(t, connection.PageInfo.HasNextPage)
+	a.False(t, connection.PageInfo.HasPreviousPage)
+	a.Equal(t, OffsetToCursor(0), *connection.PageInfo.StartCursor)
+	a.Equal(t, OffsetToCursor(4), *connection.PageInfo.EndCursor)
+
+	connection, err = ConnectionFromSlice(items, ConnectionArgs{First: intPtr(2)})
+	a.NoError(t, err)
+	a.Equal(t, "ab", nodes(connection))
+	a.True(t, connection.PageInfo.HasNextPage)
+
+	connection, err = ConnectionFromSlice(items, ConnectionArgs{First: intPtr(2), After: connection.PageInfo.EndCursor})
+	a.NoError(t, err)
+	a.Equal(t, "cd", nodes(connection))
+	a.True(t, connection.PageInfo.HasNextPage)
+
+	connection, err = ConnectionFromSlice(items, ConnectionArgs{Last: intPtr(2)})
+	a.NoError(t, err)
+	a.Equal(t, "de", nodes(connection))
+	a.True(t, connection.PageInfo.HasPreviousPage)
+	a.False(t, connection.PageInfo.HasNextPage)
+
+	connection, err = ConnectionFromSlice(items, ConnectionArgs{Last: intPtr(2), Before: strPtr(OffsetToCursor(3))})
+	a.NoError(t, err)
+	a.Equal(t, "bc", nodes(connection))
+	a.True(t, connection.PageInfo.HasPreviousPage)
+
+	connection, err = ConnectionFromSlice(items, ConnectionArgs{After: strPtr(OffsetToCursor(10))})
+	a.NoError(t, err)
+	a.Equal(t, "", nodes(connection))
+	a.Nil(t, connection.PageInfo.StartCursor)
+
+	_, err = ConnectionFromSlice(items, ConnectionArgs{First: intPtr(-1)})
+	a.Error(t, err)
+	_, err = ConnectionFromSlice(items, ConnectionArgs{Last: intPtr(-1)})
+	a.Error(t, err)
+	_, err = ConnectionFromSlice(items, ConnectionArgs{After: strPtr("invalid")})
+	a.Error(t, err)
+}
+
+func TestConnectionResolve(t *testing.T) {
+	queries := TestConnectionQ{Users: []TestConnectionUser{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+
+	query := `{
+		userConnection(first: 2) {
+			edges {cursor node {name}}
+			pageInfo {hasNextPage hasPreviousPage startCursor endCursor}
+		}
+		postConnection(last: 2) {
+			edges {node {title}}
+		}
+	}`
+	res := bytecodeParseAndExpectNoErrs(t, query, queries, M{})
+	a.Equal(t, `{"userConnection":{"edges":[{"cursor":"`+OffsetToCursor(0)+`","node":{"name":"a"}},{"cursor":"`+OffsetToCursor(1)+`","node":{"name":"b"}}],"pageInfo":{"hasNextPage":true,"hasPreviousPage":false,"startCursor":"`+OffsetToCursor(0)+`","endCursor":"`+OffsetToCursor(1)+`"}},"postConnection":{"edges":[{"node":{"title":"b"}},{"node":null}]}}`, res)
+
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestConnectionQ{}, M{}, nil))
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "type TestConnectionUserConnection {\n\tedges: [TestConnectionUserEdge!]\n\tpageInfo: PageInfo!\n}"), sdl)
+	a.True(t, strings.Contains(sdl, "type TestConnectionUserEdge {\n\tcursor: String!\n\tnode: TestConnectionUser!\n}"), sdl)
+	a.True(t, strings.Contains(sdl, "userConnection(after: String, before: String, first: Int, last: Int): TestConnectionUserConnection!"), sdl)
+	a.True(t, strings.Contains(sdl, "startCursor: String\n"), sdl)
+	a.True(t, strings.Contains(sdl, "node: TestConnectionPost\n"), sdl)
+}
+
+type TestConnectionInvalidQ struct {
+	Strings Connection[string]
+}
+
+func TestConnectionInvalid(t *testing.T) {
+	a.Error(t, NewSchema().Parse(TestConnectionInvalidQ{}, M{}, nil))
+}
+
+type TestConnectionPointerVariantsQ struct {
+	A Connection[TestConnectionUser]
+	B Connection[*TestConnectionUser]
+	C Connection[TestConnectionUser]
+}
+
+func TestConnectionPointerVariants(t *testing.T) {
+	user := TestConnectionUser{Name: "a"}
+	queries := TestConnectionPointerVariantsQ{
+		A: Connection[TestConnectionUser]{Edges: []Edge[TestConnectionUser]{{Node: user, Cursor: "x"}}},
+		B: Connection[*TestConnectionUser]{Edges: []Edge[*TestConnectionUser]{{Node: &user, Cursor: "y"}, {Cursor: "z"}}},
+		C: Connection[TestConnectionUser]{Edges: []Edge[TestConnectionUser]{{Node: user, Cursor: "w"}}},
+	}
+	res := bytecodeParseAndExpectNoErrs(t, `{
+		a {edges {cursor node {name}}}
+		b {edges {cursor node {name}}}
+		c {edges {cursor node {name}}}
+	}`, queries, M{})
+	a.Equal(t, `{"a":{"edges":[{"cursor":"x","node":{"name":"a"}}]},"b":{"edges":[{"cursor":"y","node":{"name":"a"}},{"cursor":"z","node":null}]},"c":{"edges":[{"cursor":"w","node":{"name":"a"}}]}}`, res)
+
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestConnectionPointerVariantsQ{}, M{}, nil))
+	sdl := s.SDL()
+	a.Equal(t, 1, strings.Count(sdl, "type TestConnectionUserConnection {"), sdl)
+	a.Equal(t, 1, strings.Count(sdl, "type TestConnectionUserEdge {"), sdl)
+	a.True(t, strings.Contains(sdl, "\tnode: TestConnectionUser\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\ta: TestConnectionUserConnection!\n\tb: TestConnectionUserConnection!\n"), sdl)
+}
@@ -14,6 +14,11 @@ func (s *Schema) Copy() *Schema {
 	if !s.parsed {
 		panic("Schema has not been parsed yet, call Parse before attempting to copy it")
 	}
+	if s.reload != nil {
+		// The reload state and the roots of s are modified while holding the lock when root resolvers are added after Parse
+		s.reload.lock.Lock()
+		defer s.reload.lock.Unlock()
+	}
 
 	types := s.types.copy()
 	interfaces := s.interfaces.copy()
@@ -35,9 +40,10 @@ func (s *Schema) Copy() *Schema {
 	res := &Schema{
 		parsed: true,
 
-		types:      *types,
-		inTypes:    *s.inTypes.copy(),
-		interfaces: *interfaces,
+		types:        *types,
+		typeVariants: *s.typeVariants.copy(),
+		inTypes:      *s.inTypes.copy(),
+		interfaces:   *interfaces,
 
 		rootQuery:         s.rootQuery.copy(),
 		rootQueryValue:    s.rootQueryValue,
@@ -46,14 +52,78 @@ func (s *Schema) Copy() *Schema {
 		MaxDepth:          s.MaxDepth,
 		definedEnums:      enums,
 		definedDirectives: directives,
-
-		Result:           make([]byte, len(s.Result)),
+		typeOwners:        s.typeOwners,
+		descriptions:      s.descriptions,
+		fieldResolvers:    s.fieldResolvers,
+		entities:          s.entities,
+		entitiesByName:    s.entitiesByName,
+		typeFederation:    s.typeFederation,
+		federationByName:  s.federationByName,
+		nodeFetchers:      s.nodeFetchers,
+		nodesByName:       s.nodesByName,
+		fieldCosts:        s.fieldCosts,
+		memoizedFields:    s.memoizedFields,
+		typeAuth:          s.typeAuth,
+		typeResolvers:     s.typeResolvers,
+		fieldAuth:         s.fieldAuth,
+		auth:              s.auth,
+		customScalars:     s.customScalars,
+		description:       s.description,
+		federation:        s.federation,
+		federationV2:      s.federationV2,
+		mockSeed:          s.mockSeed,
+		mockResolvers:     s.mockResolvers,
+		mocks:             s.mocks,
+		bindings:          s.bindings,
+		executionStrategy: s.executionStrategy,
+		panicHandler:      s.panicHandler,
+		errorPresenter:    s.errorPresenter,
+		loaders:           s.loaders,
+		middleware:        s.middleware,
+		fieldResolver:     s.fieldResolver,
+		extensions:        s.extensions,
+		requestLogger:     s.requestLogger,
+		responseTransform: s.responseTransform,
+		injector:          s.injector,
+		rateLimiter:       s.rateLimiter,
+		fieldVisibility:   s.fieldVisibility,
+		fieldScopes:       s.fieldScopes,
+		view:              s.view,
+		requestStates:     s.requestStates,
+		precomputed:       s.precomputed,
+
+		MaxIntrospectionDepth:  s.MaxIntrospectionDepth,
+		MaxIntrospectionFields: s.MaxIntrospectionFields,
+		MaxAliases:             s.MaxAliases,
+		MaxRootFields:          s.MaxRootFields,
+		MaxQueryLength:         s.MaxQueryLength,
+		MaxTokens:              s.MaxTokens,
+		InitialResultSize:      s.InitialResultSize,
+		MaxResultSize:          s.MaxResultSize,
+		MaxListItems:           s.MaxListItems,
+		MaxRetainedResultSize:  s.MaxRetainedResultSize,
+		MaxBatchSize:           s.MaxBatchSize,
+		BatchConcurrency:       s.BatchConcurrency,
+		ShareBatchLoaders:      s.ShareBatchLoaders,
+
+		Result:           make([]byte, 0, s.InitialResultSize),
 		graphqlTypesMap:  nil,
 		graphqlTypesList: nil,
 		graphqlObjFields: map[string][]qlField{},
 	}
 
+	res.linkRefs()
 	res.ctx = s.ctx.copy(res)
+	res.pool = newSchemaPool(res)
+	if s.reload != nil {
+		// The registrations are never modified so they can be shared, reloading the copy doesn't affect s
+		res.reload = &reloadState{
+			registered: s.reload.registered,
+			queries:    s.reload.queries,
+			methods:    s.reload.methods,
+			options:    s.reload.options,
+		}
+	}
 
 	return res
 }
@@ -69,7 +139,7 @@ func (ctx *Ctx) copy(schema *Schema) *Ctx {
 		operatorHasArguments:     ctx.operatorHasArguments,
 		operatorArgumentsStartAt: ctx.operatorArgumentsStartAt,
 		tracingEnabled:           ctx.tracingEnabled,
-		tracing:                  ctx.tracing,
+		tracing:                  newTracer(),
 		prefRecordingStartTime:   ctx.prefRecordingStartTime,
 		rawVariables:             ctx.rawVariables,
 		variablesParsed:          false,
@@ -80,6 +150,8 @@ func (ctx *Ctx) copy(schema *Schema) *Ctx {
 		funcInputs:               []reflect.Value{},
 		values:                   nil,
 	}
+	res.query.Cache = ctx.query.Cache
+	res.query.CacheableQueryMinLen = ctx.query.CacheableQueryMinLen
 	res.ctxReflection = reflect.ValueOf(res)
 	return res
 }
@@ -96,6 +168,7 @@ func (m *Directive) copy() *Directive {
 		methodReflection: m.methodReflection, // Maybe TODO
 		parsedMethod:     parsedMethod,
 		Description:      m.Description,
+		Repeatable:       m.Repeatable,
 	}
 }
 
@@ -133,8 +206,16 @@ func (m *qlType) copy() *qlType {
 		*res.Name = *m.Name
 	}
 	if m.Description != nil {
-		res.Name = helpers.StrPtr("")
-		*res.Name = *m.Name
+		res.Description = helpers.StrPtr("")
+		*res.Description = *m.Description
+	}
+	if m.IsOneOf != nil {
+		isOneOf := *m.IsOneOf
+		res.IsOneOf = &isOneOf
+	}
+	if m.SpecifiedByURL != nil {
+		res.SpecifiedByURL = helpers.StrPtr("")
+		*res.SpecifiedByURL = *m.SpecifiedByURL
 	}
 	if m.Interfaces != nil {
 		res.Interfaces = make([]qlType, len(m.Interfaces))
@@ -174,11 +255,33 @@ func (o *obj) copy() *obj {
 		goTypeName:     o.goTypeName,
 		goPkgPath:      o.goPkgPath,
 		qlFieldName:    o.qlFieldName[:],
+		hidden:         o.hidden,
 		customObjValue: o.customObjValue, // maybe TODO
+		customResolver: o.customResolver,
+		federation:     o.federation,
 		structFieldIdx: o.structFieldIdx,
+		goFieldName:    o.goFieldName,
+		goFieldIndex:   o.goFieldIndex,
+		embeddedPtr:    o.embeddedPtr,
+		fieldBinding:   o.fieldBinding,
+		goFieldType:    o.goFieldType,
 		dataValueType:  o.dataValueType,
+		scalar:         o.scalar,
 		isID:           o.isID,
+		isInt64:        o.isInt64,
+		owner:          o.owner,
+		description:    o.description,
+		cost:           o.cost,
+		memoize:        o.memoize,
+		auth:           o.auth,
+		scope:          o.scope,
 		enumTypeIndex:  o.enumTypeIndex,
+		iterator:       o.iterator,
+		typeResolver:   o.typeResolver,
+		variant:        o.variant,
+
+		structFieldOffset:    o.structFieldOffset,
+		hasStructFieldOffset: o.hasStructFieldOffset,
 	}
 
 	if o.innerContent != nil {
@@ -190,9 +293,13 @@ func (o *obj) copy() *obj {
 	}
 
 	if o.objContents != nil {
-		res.objContents = map[uint32]*obj{}
-		for key, value := range o.objContents {
-			res.objContents[key] = value.copy()
+		res.objContents = objFields{}
+		for key, bucket := range o.objContents {
+			resBucket := make([]*obj, len(bucket))
+			for i, value := range bucket {
+				resBucket[i] = value.copy()
+			}
+			res.objContents[key] = resBucket
 		}
 	}
 
@@ -208,11 +315,19 @@ func (o *obj) copy() *obj {
 func (m *objMethod) copy() *objMethod {
 	res := objMethod{
 		isTypeMethod:   m.isTypeMethod,
+		ptrReceiver:    m.ptrReceiver,
 		goFunctionName: m.goFunctionName,
 		goType:         m.goType,
+		binding:        m.binding,
 		checkedIns:     m.checkedIns,
 		outNr:          m.outNr,
 		outType:        *m.outType.copy(),
+
+		parentInput:      m.parentInput,
+		parentInputIsPtr: m.parentInputIsPtr,
+
+		fieldArgs:      m.fieldArgs,
+		fieldArgInputs: m.fieldArgInputs,
 	}
 	if m.errorOutNr != nil {
 		errOutNr := 0
@@ -265,20 +380,28 @@ func (m *input) copy() *input {
 		isEnum:           m.isEnum,
 		enumTypeIndex:    m.enumTypeIndex,
 		isID:             m.isID,
+		isInt64:          m.isInt64,
 		isFile:           m.isFile,
+		isUpload:         m.isUpload,
 		isTime:           m.isTime,
+		isText:           m.isText,
+		scalar:           m.scalar,
 		goFieldIdx:       m.goFieldIdx,
 		gqFieldName:      m.gqFieldName,
+		required:         m.required,
+		description:      m.description,
 		elem:             elem,
 		isStructPointers: m.isStructPointers,
 		structName:       m.structName,
+		oneOf:            m.oneOf,
 		structContent:    structContent,
 	}
 }
 
 func (m *baseInput) copy() *baseInput {
 	res := &baseInput{
-		isCtx: m.isCtx,
+		isCtx:     m.isCtx,
+		isContext: m.isContext,
 	}
 	if m.goType != nil {
 		reflectType := reflect.TypeOf(0)
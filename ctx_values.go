@@ -0,0 +1,86 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// CtxValue returns the user defined value of key as T
+// ok is false if the value is not set or is not a T
+//
+// Example:
+//
+//	user, ok := yarql.CtxValue[*User](ctx, "user")
+func CtxValue[T any](ctx *Ctx, key string) (value T, ok bool) {
+	raw, found := ctx.GetValueOk(key)
+	if !found {
+		return value, false
+	}
+	value, ok = raw.(T)
+	return value, ok
+}
+
+// SetCtxValue sets a user defined value, equal to (*Ctx).SetValue but checks the type of the value at compile time
+func SetCtxValue[T any](ctx *Ctx, key string, value T) {
+	ctx.SetValue(key, value)
+}
+
+// RegisterRequestState registers a container of type T that is created for every request using create
+// Use RequestState to get the container within resolvers, this is a typed alternative to storing many values in (yarql.ResolveOptions).Values
+//
+// Example:
+//
+//	type State struct {
+//		User     *User
+//		Features map[string]bool
+//	}
+//
+//	yarql.RegisterRequestState(s, func(ctx *yarql.Ctx) *State {
+//		return &State{User: userFromHeader(ctx.Header())}
+//	})
+func RegisterRequestState[T any](s *Schema, create func(ctx *Ctx) T) error {
+	if s.parsed {
+		return errors.New("yarql.RegisterRequestState() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if create == nil {
+		return errors.New("create cannot be nil")
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if _, ok := s.requestStates[t]; ok {
+		return errors.New("a request state of type " + t.String() + " is already registered")
+	}
+	s.requestStates[t] = func(ctx *Ctx) interface{} {
+		return create(ctx)
+	}
+	return nil
+}
+
+// RequestState returns the container of type T of this request, see RegisterRequestState
+// The container is created on the first call within a request and reused for the rest of the request
+// Panics if no request state of type T is registered as this is a setup error
+//
+// Example:
+//
+//	func (QueryRoot) ResolveMe(ctx *yarql.Ctx) *User {
+//		return yarql.RequestState[*State](ctx).User
+//	}
+func RequestState[T any](ctx *Ctx) T {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if state, ok := ctx.requestStates[t]; ok {
+		value, _ := state.(T)
+		return value
+	}
+
+	create, ok := ctx.schema.requestStates[t]
+	if !ok {
+		panic("no request state of type " + t.String() + " registered, use yarql.RegisterRequestState")
+	}
+	state := create(ctx)
+	if ctx.requestStates == nil {
+		ctx.requestStates = map[reflect.Type]interface{}{}
+	}
+	ctx.requestStates[t] = state
+	value, _ := state.(T)
+	return value
+}
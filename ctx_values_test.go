@@ -0,0 +1,89 @@
+package yarql
+
+import (
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestCtxValuesData struct{}
+
+type testRequestState struct {
+	User  string
+	Calls int
+}
+
+func (TestCtxValuesData) ResolveUser(ctx *Ctx) string {
+	state := RequestState[*testRequestState](ctx)
+	state.Calls++
+	return state.User
+}
+
+func (TestCtxValuesData) ResolveCalls(ctx *Ctx) int {
+	return RequestState[*testRequestState](ctx).Calls
+}
+
+func (TestCtxValuesData) ResolveRole(ctx *Ctx) string {
+	role, ok := CtxValue[string](ctx, "role")
+	if !ok {
+		return "none"
+	}
+	return role
+}
+
+func TestCtxValue(t *testing.T) {
+	ctx := &Ctx{}
+	_, ok := CtxValue[string](ctx, "a")
+	a.False(t, ok)
+
+	SetCtxValue(ctx, "a", "b")
+	value, ok := CtxValue[string](ctx, "a")
+	a.True(t, ok)
+	a.Equal(t, "b", value)
+
+	// Values of another type are not returned
+	number, ok := CtxValue[int](ctx, "a")
+	a.False(t, ok)
+	a.Equal(t, 0, number)
+}
+
+func TestRequestState(t *testing.T) {
+	s := NewSchema()
+	creates := 0
+	a.NoError(t, RegisterRequestState(s, func(ctx *Ctx) *testRequestState {
+		creates++
+		user, _ := CtxValue[string](ctx, "user")
+		return &testRequestState{User: user}
+	}))
+	a.NoError(t, s.Parse(TestCtxValuesData{}, M{}, nil))
+	s = s.Copy()
+
+	errs := s.Resolve([]byte(`{a: user b: user calls role}`), ResolveOptions{NoMeta: true, Values: &map[string]interface{}{"user": "alice", "role": "admin"}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"alice","b":"alice","calls":2,"role":"admin"}`, string(s.Result))
+	a.Equal(t, 1, creates)
+
+	// Every request gets its own state
+	errs = s.Resolve([]byte(`{user calls role}`), ResolveOptions{NoMeta: true, Values: &map[string]interface{}{"user": "bob"}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"user":"bob","calls":1,"role":"none"}`, string(s.Result))
+	a.Equal(t, 2, creates)
+}
+
+func TestRequestStateNotRegistered(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestCtxValuesData{}, M{}, nil))
+	errs := s.Resolve([]byte(`{user}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "resolver panicked: no request state of type *yarql.testRequestState registered, use yarql.RegisterRequestState", errs[0].Error())
+}
+
+func TestRegisterRequestStateInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, RegisterRequestState[*testRequestState](s, nil))
+	a.NoError(t, RegisterRequestState(s, func(ctx *Ctx) *testRequestState { return nil }))
+	a.Error(t, RegisterRequestState(s, func(ctx *Ctx) *testRequestState { return nil }))
+
+	a.NoError(t, s.Parse(TestCtxValuesData{}, M{}, nil))
+	a.Error(t, RegisterRequestState(s, func(ctx *Ctx) int { return 0 }))
+}
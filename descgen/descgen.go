@@ -0,0 +1,385 @@
+// Package descgen generates the registration of schema descriptions from the doc comments of go types
+// The doc comments of struct and interface types, their fields and their resolver methods are used as descriptions of the matching graphql types and fields
+//
+// The generator parses the source of the packages so it can be used directly with go:generate using the yarqldescgen command:
+//
+//	//go:generate go run github.com/mjarkk/yarql/cmd/yarqldescgen -out descriptions_gen.go .
+//
+// The generated file contains a RegisterDescriptions(s *yarql.Schema) error function that should be called before (*yarql.Schema).Parse
+package descgen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options changes the generated code
+type Options struct {
+	// Package is the package name of the generated code, defaults to the package name of the first directory
+	Package string
+	// PkgPath is the import path of the package the generated code is placed in, types of this package are used without qualifier
+	// Defaults to the import path of the first directory
+	PkgPath string
+}
+
+const yarqlPkgPath = "github.com/mjarkk/yarql"
+
+// Generate generates the go source code that registers the descriptions of the types within the packages in dirs
+// The import paths of the packages are resolved using the go.mod file of the module the directory is part of
+// Types that cannot be referenced from the generated package, like unexported types of other packages and generic types, are skipped
+func Generate(dirs []string, opts Options) ([]byte, error) {
+	if len(dirs) == 0 {
+		return nil, errors.New("no package directories given")
+	}
+
+	g := generator{
+		opts:     opts,
+		imports:  map[string]string{},
+		pkgNames: map[string]string{},
+	}
+	for i, dir := range dirs {
+		pkg, err := parsePackage(dir)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			if len(g.opts.PkgPath) == 0 {
+				g.opts.PkgPath = pkg.path
+			}
+			if len(g.opts.Package) == 0 {
+				g.opts.Package = pkg.name
+			}
+		}
+		g.pkgs = append(g.pkgs, pkg)
+	}
+	if g.opts.PkgPath != yarqlPkgPath {
+		g.imports[yarqlPkgPath] = "yarql"
+		g.pkgNames["yarql"] = yarqlPkgPath
+	}
+
+	return g.generate()
+}
+
+// goPackage contains the descriptions found in a package
+type goPackage struct {
+	name  string
+	path  string
+	types map[string]*goType
+}
+
+// goType contains the descriptions of a struct or interface type
+type goType struct {
+	name        string
+	isInterface bool
+	description string
+	fields      map[string]string
+}
+
+// parsePackage parses the go files of the package in dir and collects the doc comments of its types
+func parsePackage(dir string) (*goPackage, error) {
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	pkgPath, err := importPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &goPackage{
+		name:  buildPkg.Name,
+		path:  pkgPath,
+		types: map[string]*goType{},
+	}
+	// The methods can be defined before the type so they are added after all types are known
+	methods := []*ast.FuncDecl{}
+
+	fset := token.NewFileSet()
+	for _, name := range buildPkg.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					doc := typeSpec.Doc
+					if doc == nil && len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					pkg.addType(typeSpec, doc)
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil && strings.HasPrefix(decl.Name.Name, "Resolve") {
+					methods = append(methods, decl)
+				}
+			}
+		}
+	}
+
+	for _, method := range methods {
+		receiver := method.Recv.List[0].Type
+		if star, ok := receiver.(*ast.StarExpr); ok {
+			receiver = star.X
+		}
+		ident, ok := receiver.(*ast.Ident)
+		if !ok {
+			// Methods of generic types
+			continue
+		}
+		t, ok := pkg.types[ident.Name]
+		if ok && !t.isInterface {
+			t.addField(method.Name.Name, method.Doc, nil)
+		}
+	}
+
+	return pkg, nil
+}
+
+// addType adds the struct or interface type of spec
+func (pkg *goPackage) addType(spec *ast.TypeSpec, doc *ast.CommentGroup) {
+	if spec.TypeParams != nil || spec.Assign.IsValid() {
+		// Generic types cannot be referenced without type arguments and aliases share the descriptions of the aliased type
+		return
+	}
+
+	t := &goType{
+		name:        spec.Name.Name,
+		description: docText(doc),
+		fields:      map[string]string{},
+	}
+	switch typeExpr := spec.Type.(type) {
+	case *ast.StructType:
+		for _, field := range typeExpr.Fields.List {
+			for _, name := range field.Names {
+				t.addField(name.Name, field.Doc, field.Comment)
+			}
+		}
+	case *ast.InterfaceType:
+		t.isInterface = true
+		for _, method := range typeExpr.Methods.List {
+			for _, name := range method.Names {
+				t.addField(name.Name, method.Doc, method.Comment)
+			}
+		}
+	default:
+		return
+	}
+	pkg.types[t.name] = t
+}
+
+// addField adds the description of the field or method name, the comment is used if there is no doc comment
+func (t *goType) addField(name string, doc *ast.CommentGroup, comment *ast.CommentGroup) {
+	if !ast.IsExported(name) {
+		return
+	}
+	description := docText(doc)
+	if len(description) == 0 {
+		description = docText(comment)
+	}
+	if len(description) > 0 {
+		t.fields[name] = description
+	}
+}
+
+// docText returns the text of a doc comment without comment markers and directives like //go:generate
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// importPath returns the import path of the package in dir using the go.mod file of the module dir is part of
+func importPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for moduleDir := dir; ; {
+		modulePath, ok, err := readModulePath(filepath.Join(moduleDir, "go.mod"))
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			rel, err := filepath.Rel(moduleDir, dir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(modulePath, filepath.ToSlash(rel)), nil
+		}
+
+		parent := filepath.Dir(moduleDir)
+		if parent == moduleDir {
+			return "", errors.New("no go.mod found for " + dir)
+		}
+		moduleDir = parent
+	}
+}
+
+// readModulePath returns the module path of the go.mod file, ok is false if the file doesn't exist
+func readModulePath(goModFile string) (modulePath string, ok bool, err error) {
+	file, err := os.Open(goModFile)
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "module") {
+			continue
+		}
+		modulePath = strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		if unquoted, err := strconv.Unquote(modulePath); err == nil {
+			modulePath = unquoted
+		}
+		return modulePath, true, nil
+	}
+	if scanner.Err() != nil {
+		return "", false, scanner.Err()
+	}
+	return "", false, errors.New("no module path found in " + goModFile)
+}
+
+type generator struct {
+	opts Options
+	pkgs []*goPackage
+	body bytes.Buffer
+	// imports maps package paths to the names used in the generated code
+	imports map[string]string
+	// pkgNames maps the names used in the generated code back to the package path
+	pkgNames map[string]string
+}
+
+func (g *generator) line(parts ...string) {
+	for _, part := range parts {
+		g.body.WriteString(part)
+	}
+	g.body.WriteByte('\n')
+}
+
+func (g *generator) generate() ([]byte, error) {
+	yarql := g.qualifier(yarqlPkgPath, "yarql")
+	registered := 0
+	for _, pkg := range g.pkgs {
+		typeNames := []string{}
+		for name := range pkg.types {
+			typeNames = append(typeNames, name)
+		}
+		sort.Strings(typeNames)
+
+		for _, name := range typeNames {
+			t := pkg.types[name]
+			if len(t.description) == 0 && len(t.fields) == 0 {
+				continue
+			}
+			if pkg.path != g.opts.PkgPath && !ast.IsExported(t.name) {
+				continue
+			}
+			if registered == 0 {
+				g.line("var err error")
+			}
+			registered++
+
+			typeName := g.qualifier(pkg.path, pkg.name) + t.name
+			value := typeName + "{}"
+			if t.isInterface {
+				value = "(*" + typeName + ")(nil)"
+			}
+			g.line("err = s.RegisterDescriptions(", value, ", ", yarql, "Descriptions{")
+			if len(t.description) > 0 {
+				g.line("Type: ", strconv.Quote(t.description), ",")
+			}
+			if len(t.fields) > 0 {
+				fieldNames := []string{}
+				for fieldName := range t.fields {
+					fieldNames = append(fieldNames, fieldName)
+				}
+				sort.Strings(fieldNames)
+
+				g.line("Fields: map[string]string{")
+				for _, fieldName := range fieldNames {
+					g.line(strconv.Quote(fieldName), ": ", strconv.Quote(t.fields[fieldName]), ",")
+				}
+				g.line("},")
+			}
+			g.line("})")
+			g.line("if err != nil {")
+			g.line("return err")
+			g.line("}")
+		}
+	}
+	g.line("return nil")
+	g.line("}")
+	body := g.body.Bytes()
+
+	header := bytes.NewBuffer(nil)
+	header.WriteString("// Code generated by github.com/mjarkk/yarql/descgen. DO NOT EDIT.\n\n")
+	header.WriteString("package " + g.opts.Package + "\n\nimport (\n")
+	paths := []string{}
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, importPath := range paths {
+		name := g.imports[importPath]
+		if name == path.Base(importPath) {
+			header.WriteString(strconv.Quote(importPath) + "\n")
+		} else {
+			header.WriteString(name + " " + strconv.Quote(importPath) + "\n")
+		}
+	}
+	header.WriteString(")\n\n")
+	header.WriteString("// RegisterDescriptions registers the descriptions of the go types on s, call it before (*yarql.Schema).Parse\n")
+	header.WriteString("func RegisterDescriptions(s *" + yarql + "Schema) error {\n")
+	header.Write(body)
+
+	res, err := format.Source(header.Bytes())
+	if err != nil {
+		return nil, errors.New("generated invalid go code, " + err.Error())
+	}
+	return res, nil
+}
+
+// qualifier returns the prefix used to reference the package pkgPath with the package name name from the generated code, like "yarql."
+func (g *generator) qualifier(pkgPath string, name string) string {
+	if pkgPath == g.opts.PkgPath {
+		return ""
+	}
+	if importName, ok := g.imports[pkgPath]; ok {
+		return importName + "."
+	}
+
+	importName := name
+	for i := 2; ; i++ {
+		if _, ok := g.pkgNames[importName]; !ok {
+			break
+		}
+		importName = name + strconv.Itoa(i)
+	}
+	g.imports[pkgPath] = importName
+	g.pkgNames[importName] = pkgPath
+	return importName + "."
+}
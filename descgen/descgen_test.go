@@ -0,0 +1,72 @@
+package descgen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	source, err := Generate([]string{"testdata/graph", "testdata/models"}, Options{})
+	a.NoError(t, err)
+	code := string(source)
+
+	for _, expected := range []string{
+		"package graph",
+		`"github.com/mjarkk/yarql/descgen/testdata/models"`,
+		"func RegisterDescriptions(s *yarql.Schema) error {",
+		"err = s.RegisterDescriptions(QueryRoot{}, yarql.Descriptions{\n\t\tType: \"QueryRoot is the root of all queries\",",
+		`"ResolveNode": "ResolveNode returns the node with the given id",`,
+		"err = s.RegisterDescriptions((*Node)(nil), yarql.Descriptions{",
+		`"ResolveID": "The unique id of the node",`,
+		"err = s.RegisterDescriptions(models.User{}, yarql.Descriptions{",
+		`"Email":      "The email address of the user",`,
+		`"Name":       "The full name of the user",`,
+		`"ResolveAge": "ResolveAge returns the age of the user in years",`,
+	} {
+		a.True(t, strings.Contains(code, expected), expected+"\n\n"+code)
+	}
+	for _, unexpected := range []string{"go:generate", "internal", "Helper", "settings", "Page", "undocumented"} {
+		a.False(t, strings.Contains(code, unexpected), unexpected+"\n\n"+code)
+	}
+
+	// The generated code must compile together with the types it describes
+	fset := token.NewFileSet()
+	files := []*ast.File{}
+	file, err := parser.ParseFile(fset, "testdata/graph/graph.go", nil, 0)
+	a.NoError(t, err)
+	files = append(files, file)
+	file, err = parser.ParseFile(fset, "descriptions_gen.go", source, 0)
+	a.NoError(t, err)
+	files = append(files, file)
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("graph", fset, files, nil)
+	a.NoError(t, err)
+}
+
+func TestGenerateOtherPackage(t *testing.T) {
+	// Unexported types cannot be referenced from another package
+	source, err := Generate([]string{"testdata/models"}, Options{Package: "graph", PkgPath: "example.com/graph"})
+	a.NoError(t, err)
+	code := string(source)
+	a.True(t, strings.Contains(code, "package graph"), code)
+	a.True(t, strings.Contains(code, "err = s.RegisterDescriptions(models.User{}, yarql.Descriptions{"), code)
+	a.False(t, strings.Contains(code, "settings"), code)
+
+	source, err = Generate([]string{"testdata/models"}, Options{})
+	a.NoError(t, err)
+	a.True(t, strings.Contains(string(source), "err = s.RegisterDescriptions(settings{}, yarql.Descriptions{"), string(source))
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	_, err := Generate(nil, Options{})
+	a.Error(t, err)
+	_, err = Generate([]string{"testdata/unknown"}, Options{})
+	a.Error(t, err)
+}
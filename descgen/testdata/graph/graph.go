@@ -0,0 +1,33 @@
+package graph
+
+import "github.com/mjarkk/yarql/descgen/testdata/models"
+
+//go:generate go run github.com/mjarkk/yarql/cmd/yarqldescgen -out descriptions_gen.go . ../models
+
+// QueryRoot is the root of all queries
+type QueryRoot struct {
+	Users []models.User
+}
+
+// ResolveNode returns the node with the given id
+func (QueryRoot) ResolveNode(args struct{ ID string }) Node {
+	return nil
+}
+
+type (
+	// Node is an object with an id
+	Node interface {
+		// The unique id of the node
+		ResolveID() string
+	}
+
+	// Page is a generic page of items
+	Page[T any] struct {
+		// The items of the page
+		Items []T
+	}
+)
+
+type undocumented struct {
+	Value string
+}
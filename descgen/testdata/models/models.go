@@ -0,0 +1,24 @@
+package models
+
+// User is a user of the app
+type User struct {
+	// The full name of the user
+	Name  string
+	Email string // The email address of the user
+	// internal fields are not part of the schema
+	internal string
+}
+
+// ResolveAge returns the age of the user in years
+func (*User) ResolveAge() int {
+	return 0
+}
+
+// Helper is not a resolver
+func (User) Helper() {}
+
+// settings is not exported so it cannot be registered from another package
+type settings struct {
+	// The theme of the app
+	Theme string
+}
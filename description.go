@@ -0,0 +1,72 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Descriptions are the descriptions of a struct or interface type and its fields, see (*Schema).RegisterDescriptions
+type Descriptions struct {
+	// Type is the description of the type
+	Type string
+	// Fields maps the go names of struct fields and resolver methods to their description, for example: Name or ResolveUser
+	Fields map[string]string
+}
+
+// RegisterDescriptions sets the descriptions of a struct or interface type and its fields shown in the introspection and schema definition language
+// The descriptions are used for the type as object, interface and input object
+// These can be generated from the doc comments of the go types using the yarqldescgen command, see the descgen package
+//
+// Example:
+//
+//	s.RegisterDescriptions(User{}, yarql.Descriptions{
+//		Type:   "A user of the app",
+//		Fields: map[string]string{"Name": "The full name of the user", "ResolveFriends": "The friends of the user"},
+//	})
+func (s *Schema) RegisterDescriptions(goType interface{}, descriptions Descriptions) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterDescriptions() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t, ok := structOrInterfaceType(goType)
+	if !ok {
+		return errors.New("can only register descriptions on struct and interface types")
+	}
+	if _, ok := s.descriptions[t]; ok {
+		return errors.New("descriptions for " + t.String() + " are already registered")
+	}
+
+	s.descriptions[t] = descriptions
+	return nil
+}
+
+// methodDescription returns the description of the method goName of t
+// Methods promoted from embedded structs use the descriptions registered on the embedded struct
+func (s *Schema) methodDescription(t reflect.Type, goName string) string {
+	description, ok := s.descriptions[t].Fields[goName]
+	if ok || t.Kind() != reflect.Struct {
+		return description
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.Anonymous {
+			continue
+		}
+		embeddedType := field.Type
+		if embeddedType.Kind() == reflect.Ptr {
+			embeddedType = embeddedType.Elem()
+		}
+		methodsType := embeddedType
+		if methodsType.Kind() != reflect.Interface {
+			methodsType = reflect.PtrTo(methodsType)
+		}
+		if _, ok := methodsType.MethodByName(goName); ok {
+			return s.methodDescription(embeddedType, goName)
+		}
+	}
+	return ""
+}
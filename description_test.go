@@ -0,0 +1,88 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestDescriptionsBase struct {
+	CreatedAt string
+}
+
+func (TestDescriptionsBase) ResolveAge() int { return 1 }
+
+type TestDescriptionsUser struct {
+	TestDescriptionsBase
+	Name string
+}
+
+type TestDescriptionsFilter struct {
+	Name string
+}
+
+type TestDescriptionsUsersArgs struct {
+	Filter *TestDescriptionsFilter
+}
+
+type TestDescriptionsQuery struct {
+	User TestDescriptionsUser
+}
+
+func (TestDescriptionsQuery) ResolveUsers(args TestDescriptionsUsersArgs) []TestDescriptionsUser {
+	return nil
+}
+
+func TestRegisterDescriptions(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterDescriptions(TestDescriptionsUser{}, Descriptions{
+		Type:   "A user of the app",
+		Fields: map[string]string{"Name": "The full name"},
+	}))
+	a.NoError(t, s.RegisterDescriptions(TestDescriptionsBase{}, Descriptions{
+		Fields: map[string]string{"CreatedAt": "Creation time", "ResolveAge": "Age in years"},
+	}))
+	a.NoError(t, s.RegisterDescriptions(TestDescriptionsFilter{}, Descriptions{
+		Type:   "Filters users",
+		Fields: map[string]string{"Name": "Only users with this name"},
+	}))
+	a.NoError(t, s.RegisterDescriptions(TestDescriptionsUsersArgs{}, Descriptions{
+		Fields: map[string]string{"Filter": "Filters the users"},
+	}))
+	a.NoError(t, s.RegisterDescriptions(TestDescriptionsQuery{}, Descriptions{
+		Fields: map[string]string{"ResolveUsers": "All users"},
+	}))
+	a.NoError(t, s.Parse(TestDescriptionsQuery{}, M{}, nil))
+
+	query := `{
+		user: __type(name: "TestDescriptionsUser") {description fields {name description}}
+		query: __type(name: "TestDescriptionsQuery") {fields {name description args {name description}}}
+		filter: __type(name: "TestDescriptionsFilter") {description inputFields {name description}}
+	}`
+	errs := s.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"user":{"description":"A user of the app","fields":[`+
+		`{"name":"age","description":"Age in years"},`+
+		`{"name":"createdAt","description":"Creation time"},`+
+		`{"name":"name","description":"The full name"}]},`+
+		`"query":{"fields":[`+
+		`{"name":"user","description":null,"args":[]},`+
+		`{"name":"users","description":"All users","args":[{"name":"filter","description":"Filters the users"}]}]},`+
+		`"filter":{"description":"Filters users","inputFields":[{"name":"name","description":"Only users with this name"}]}}`, string(s.Result))
+
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "\"\"\"\nA user of the app\n\"\"\"\ntype TestDescriptionsUser {\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\t\"\"\"\n\tThe full name\n\t\"\"\"\n\tname: String!\n"), sdl)
+}
+
+func TestRegisterDescriptionsInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterDescriptions(nil, Descriptions{}))
+	a.Error(t, s.RegisterDescriptions("", Descriptions{}))
+	a.NoError(t, s.RegisterDescriptions(TestDescriptionsUser{}, Descriptions{}))
+	a.Error(t, s.RegisterDescriptions(TestDescriptionsUser{}, Descriptions{}))
+
+	a.NoError(t, s.Parse(TestDescriptionsQuery{}, M{}, nil))
+	a.Error(t, s.RegisterDescriptions(TestDescriptionsQuery{}, Descriptions{}))
+}
@@ -1,8 +1,11 @@
 package yarql
 
 import (
+	"encoding/json"
 	"errors"
 	"reflect"
+
+	"github.com/mjarkk/yarql/bytecode"
 )
 
 // DirectiveLocation defines the location a directive can be used in
@@ -15,6 +18,8 @@ const (
 	DirectiveLocationFragment
 	// DirectiveLocationFragmentInline can be called from a inline fragment
 	DirectiveLocationFragmentInline
+	// DirectiveLocationVariableDefinition can be called from a variable definition of a operation
+	DirectiveLocationVariableDefinition
 )
 
 // String returns the DirectiveLocation as a string
@@ -26,6 +31,8 @@ func (l DirectiveLocation) String() string {
 		return "<DirectiveLocationFragment>"
 	case DirectiveLocationFragmentInline:
 		return "<DirectiveLocationFragmentInline>"
+	case DirectiveLocationVariableDefinition:
+		return "<DirectiveLocationVariableDefinition>"
 	default:
 		return "<UNKNOWN DIRECTIVE LOCATION>"
 	}
@@ -40,6 +47,8 @@ func (l DirectiveLocation) ToQlDirectiveLocation() __DirectiveLocation {
 		return directiveLocationFragmentSpread
 	case DirectiveLocationFragmentInline:
 		return directiveLocationInlineFragment
+	case DirectiveLocationVariableDefinition:
+		return directiveLocationVariableDefinition
 	default:
 		return directiveLocationField
 	}
@@ -51,12 +60,15 @@ type Directive struct {
 	Name  string
 	Where []DirectiveLocation
 	// Should be of type: func(args like any other method) DirectiveModifier
+	// Method can also return an error as second value, the error is reported and the request is not resolved any further
 	Method           interface{}
 	methodReflection reflect.Value
 	parsedMethod     *objMethod
 
 	// Not required
 	Description string
+	// Repeatable allows the directive to be used multiple times on the same location
+	Repeatable bool
 }
 
 // TODO
@@ -68,6 +80,12 @@ type DirectiveModifier struct {
 	// Skip field/(inline)fragment
 	Skip bool
 
+	// Mock skips the resolver of a field and writes mocked data instead
+	// The mocked data is MockValue or if empty a deterministic value generated based on the field's type
+	Mock bool
+	// MockValue is written as is to the response, it's expected to be valid JSON
+	MockValue []byte
+
 	// TODO make this
 	// ModifyOnWriteContent allows you to modify field JSON response data before it's written to the result
 	// Note that there is no checking for validation here it's up to you to return valid json
@@ -137,8 +155,12 @@ func checkDirective(directive *Directive) error {
 		return errors.New("method should return DirectiveModifier")
 	case 1:
 		// OK
+	case 2:
+		if methodType.Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
+			return errors.New("the second value returned by method should be an error")
+		}
 	default:
-		return errors.New("method should only return DirectiveModifier")
+		return errors.New("method should only return DirectiveModifier and optionally an error")
 	}
 
 	outType := methodType.Out(0)
@@ -147,6 +169,19 @@ func checkDirective(directive *Directive) error {
 		return errors.New("method should return DirectiveModifier")
 	}
 
+	structInputs := 0
+	for i := 0; i < methodType.NumIn(); i++ {
+		if methodType.In(i).Kind() == reflect.Struct {
+			structInputs++
+		}
+	}
+	if structInputs > 2 {
+		return errors.New("method can only have a struct with the directive arguments and a struct with the field arguments")
+	}
+	if structInputs == 2 && methodType.In(methodType.NumIn()-1).Kind() != reflect.Struct {
+		return errors.New("the struct with the field arguments must be the last argument of method")
+	}
+
 	directive.parsedMethod = &objMethod{
 		isTypeMethod: false,
 		goType:       methodType,
@@ -154,9 +189,146 @@ func checkDirective(directive *Directive) error {
 		ins:        []baseInput{},
 		inFields:   map[string]referToInput{},
 		checkedIns: false,
+
+		fieldArgs: structInputs == 2,
 	}
 
 	// Inputs checked in (s *Schema).Parse(..)
 
 	return nil
 }
+
+// skipDirective skips over the directive at the current charNr
+func (ctx *Ctx) skipDirective() {
+	ctx.skipInst(1) // read 'd'
+	hasArguments := ctx.readInst() == 't'
+	for ctx.readInst() != 0 {
+		// Read name
+	}
+	if hasArguments {
+		ctx.skipArguments()
+	}
+}
+
+// bindDirectiveFieldArgs binds the arguments of the field the directive is used on to the last input of method
+// Arguments of the field that are not defined in the input are ignored
+func (ctx *Ctx) bindDirectiveFieldArgs(method *objMethod) bool {
+	endOfDirective := ctx.charNr
+	defer func() {
+		ctx.charNr = endOfDirective
+	}()
+
+	for ctx.seekInst() == bytecode.ActionDirective {
+		ctx.skipDirective()
+	}
+	if ctx.seekInst() != bytecode.ActionValue {
+		// The field has no arguments
+		return false
+	}
+
+	fieldArgs := ctx.funcInputs[len(ctx.funcInputs)-1]
+	return ctx.walkInputObject(func(key []byte) bool {
+		input, ok := method.fieldArgInputs[b2s(key)]
+		if !ok {
+			// Skip ActionValue, the value kind, the length of the value and the value itself
+			ctx.skipInst(6 + int(ctx.readUint32(ctx.charNr+2)))
+			return false
+		}
+		goField := fieldArgs.Field(input.goFieldIdx)
+		_, criticalErr := ctx.bindInputToGoValue(&goField, &input, true)
+		return criticalErr
+	})
+}
+
+// Variable returns the name and the JSON value of the variable the directive method is currently called for
+// The value is the value provided with the request or the default value of the variable, nil if neither is set
+// An empty name is returned if the directive method is not called for a variable definition, see DirectiveLocationVariableDefinition
+func (ctx *Ctx) Variable() (name string, value json.RawMessage) {
+	return ctx.variableName, ctx.variableValue
+}
+
+// resolveVariableDirectives calls the directives defined on the variable definitions of the operation
+// The charNr is restored afterwards
+func (ctx *Ctx) resolveVariableDirectives() bool {
+	startAt := ctx.charNr
+	defer func() {
+		ctx.charNr = startAt
+		ctx.variableName = ""
+		ctx.variableValue = nil
+	}()
+
+	return ctx.walkVariableDirectives(func(name string, defaultAt int) bool {
+		ctx.variableName = name
+		ctx.variableValue = nil
+
+		variable, criticalErr := ctx.getExternalVariable(name)
+		if criticalErr {
+			return criticalErr
+		}
+		directivesAt := ctx.charNr
+		if variable != nil {
+			ctx.variableValue = variable.MarshalTo(nil)
+		} else if defaultAt != -1 {
+			ctx.charNr = defaultAt
+			ctx.variableValue, criticalErr = ctx.inputValueToJSON(nil)
+			if criticalErr {
+				return criticalErr
+			}
+		}
+		ctx.charNr = directivesAt
+
+		_, criticalErr = ctx.resolveDirective(DirectiveLocationVariableDefinition)
+		return criticalErr
+	})
+}
+
+// walkVariableDirectives calls onDirective for every directive defined on the variable definitions of the operation
+// When onDirective is called the charNr is at the start of the directive, onDirective is expected to read the full directive
+// defaultAt is the location of the default value of the variable or -1 if the variable has no default value
+func (ctx *Ctx) walkVariableDirectives(onDirective func(name string, defaultAt int) bool) bool {
+	if !ctx.operatorHasArguments {
+		return false
+	}
+
+	ctx.charNr = ctx.operatorArgumentsStartAt
+	ctx.skipInst(2)
+	for {
+		startOfArg := ctx.charNr
+		if ctx.readInst() == 'e' {
+			return false
+		}
+		argLen := ctx.readUint32(ctx.charNr)
+		ctx.charNr += 4
+		endOfArg := startOfArg + int(argLen)
+
+		nameStart := ctx.charNr
+		for ctx.readInst() != 0 {
+			// Read name
+		}
+		name := b2s(ctx.query.Res[nameStart : ctx.charNr-1])
+		for ctx.readInst() != 0 {
+			// Read type
+		}
+
+		defaultAt := -1
+		if ctx.readInst() == 't' {
+			// Skip the NULL byte, ActionValue, the value kind, the length of the value and the value itself
+			defaultAt = ctx.charNr + 1
+			ctx.skipInst(7 + int(ctx.readUint32(ctx.charNr+3)))
+		}
+
+		if ctx.charNr < endOfArg {
+			// Skip the NULL byte before the first directive, every directive also reads the NULL byte after it
+			ctx.skipInst(1)
+			ctx.usedDirectives = ctx.usedDirectives[:0]
+			for ctx.charNr < endOfArg {
+				criticalErr := onDirective(name, defaultAt)
+				if criticalErr {
+					return criticalErr
+				}
+			}
+		}
+
+		ctx.charNr = endOfArg + 1
+	}
+}
@@ -0,0 +1,470 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/mjarkk/yarql/bytecode"
+	"github.com/mjarkk/yarql/helpers"
+)
+
+// This file contains the extension points of the executor
+//
+// The default executor walks the query bytecode and resolves every field using reflection,
+// an ExecutionStrategy can replace that while re-using the building blocks of the default executor:
+//
+// - SelectionIterator walks over the fields of a selection set
+// - ArgumentBinder binds the arguments of a field to the inputs of its resolver
+// - ValueWriter writes JSON to the response
+
+// ExecutionStrategy executes an operation instead of the default executor
+type ExecutionStrategy interface {
+	// ExecuteOperation writes the data of the operation to the response
+	// The braces around the data object are written by the caller
+	// A returned error is added to the errors of the response,
+	// errors returned by the methods of Engine are already added to the response and are not added twice
+	ExecuteOperation(engine *Engine) error
+}
+
+// ExecutionStrategyFunc allows a function to be used as ExecutionStrategy
+type ExecutionStrategyFunc func(engine *Engine) error
+
+// ExecuteOperation implements ExecutionStrategy
+func (fn ExecutionStrategyFunc) ExecuteOperation(engine *Engine) error {
+	return fn(engine)
+}
+
+// SetExecutionStrategy replaces the default executor with strategy
+// Setting strategy to nil restores the default executor
+func (s *Schema) SetExecutionStrategy(strategy ExecutionStrategy) {
+	s.executionStrategy = strategy
+}
+
+// SelectionIterator iterates over the fields of a selection set
+// Fragments that apply to the type of the selection set are flattened and fields skipped by directives are left out
+type SelectionIterator interface {
+	// Next moves to the next field and returns false if there are no more fields
+	Next() bool
+	// Field returns the field the iterator currently points to
+	Field() SelectedField
+}
+
+// ArgumentBinder binds the arguments of a field to the inputs of the field's resolver
+type ArgumentBinder interface {
+	// BindArguments returns the inputs for calling the resolver of field
+	// The returned values are only valid until the next call to BindArguments
+	BindArguments(field SelectedField) ([]reflect.Value, error)
+}
+
+// ValueWriter writes JSON to the response
+type ValueWriter interface {
+	// WriteRaw writes b as is, b is expected to be valid JSON
+	WriteRaw(b []byte)
+	// WriteChar writes a single character like { or ,
+	WriteChar(b byte)
+	// WriteKey writes a object key followed by a :
+	WriteKey(key string)
+	// WriteString writes s as a JSON string
+	WriteString(s string)
+	// WriteNull writes null
+	WriteNull()
+}
+
+// SelectedField is a field selected by the query
+type SelectedField struct {
+	// Name is the name of the field in the schema
+	Name string
+	// Alias is the key of the field in the response, equals Name if no alias was used
+	Alias string
+	// ParentType is the name of the type the field is selected on
+	ParentType string
+	// HasArguments is true if arguments are passed to the field
+	HasArguments bool
+	// HasSelectionSet is true if the field has a selection set
+	HasSelectionSet bool
+
+	obj     *obj               // nil if the field is not defined on the parent type, for example __typename
+	parent  *obj               // the type the field is selected on
+	authErr error              // set if the user is not allowed to resolve the field, the error is already added to the response
+	mock    *DirectiveModifier // set if a directive marked the field as mocked
+	fieldAt int                // location of the field instruction in the bytecode
+	valueAt int                // location of the arguments or selection set of this field in the bytecode
+	endAt   int                // location of the end of the field in the bytecode
+	dept    uint8
+}
+
+// Exists returns false if the field is not defined on the parent type
+// Note that this is also the case for meta fields like __typename
+func (f SelectedField) Exists() bool {
+	return f.obj != nil
+}
+
+// Mocked returns true if a directive like @mock marked the field as mocked
+// The resolver of a mocked field should not be called, ResolveValue writes the mocked value instead
+func (f SelectedField) Mocked() bool {
+	return f.mock != nil
+}
+
+// Engine gives an ExecutionStrategy access to the building blocks of the default executor
+// An Engine is only valid during the ExecuteOperation call it was passed to
+type Engine struct {
+	ctx          *Ctx
+	kind         bytecode.OperatorKind
+	root         *obj
+	selectionsAt int
+}
+
+func (ctx *Ctx) executeStrategy(kind bytecode.OperatorKind, root *obj) bool {
+	engine := Engine{
+		ctx:          ctx,
+		kind:         kind,
+		root:         root,
+		selectionsAt: ctx.charNr,
+	}
+	err := ctx.schema.executionStrategy.ExecuteOperation(&engine)
+	if err == nil {
+		return false
+	}
+	if errors.As(err, &reportedError{}) {
+		// Error is already added to the response
+		return true
+	}
+	return ctx.err(err.Error())
+}
+
+// Ctx returns the request context
+func (e *Engine) Ctx() *Ctx {
+	return e.ctx
+}
+
+// OperationKind returns the kind of the operation that is executed
+func (e *Engine) OperationKind() bytecode.OperatorKind {
+	return e.kind
+}
+
+// RootValue returns the go value of the query or mutation root
+func (e *Engine) RootValue() reflect.Value {
+	if e.kind == bytecode.OperatorMutation {
+		return e.ctx.schema.rootMethodValue
+	}
+	return e.ctx.schema.rootQueryValue
+}
+
+// Execute runs the default executor
+// This allows strategies to only take over specific operations
+func (e *Engine) Execute() error {
+	e.ctx.charNr = e.selectionsAt
+	firstField := true
+	criticalErr := e.ctx.resolveSelectionSet(e.root, 0, &firstField)
+	if criticalErr {
+		return e.ctx.lastErr()
+	}
+	return nil
+}
+
+// Selections returns an iterator over the selection set of the operation
+func (e *Engine) Selections() (SelectionIterator, error) {
+	return e.ctx.newSelectionIterator(e.root, e.selectionsAt, 0)
+}
+
+// SubSelections returns an iterator over the selection set of field
+// typeName is the name of the object or interface type the selection set is resolved for and is used to determine which fragments apply,
+// for an interface type only the fragments on the interface itself apply
+func (e *Engine) SubSelections(field SelectedField, typeName string) (SelectionIterator, error) {
+	if field.authErr != nil {
+		return nil, field.authErr
+	}
+	if !field.HasSelectionSet {
+		return nil, errors.New("field " + field.Name + " has no selection set")
+	}
+	typeObj, ok := e.ctx.schema.getTypeOrInterface(typeName)
+	if !ok {
+		return nil, errors.New("unknown type " + typeName)
+	}
+
+	ctx := e.ctx
+	ctx.charNr = field.valueAt
+	ctx.skipArguments()
+	return ctx.newSelectionIterator(typeObj, ctx.charNr, field.dept+1)
+}
+
+// Arguments returns the default argument binder
+func (e *Engine) Arguments() ArgumentBinder {
+	return ctxArgumentBinder{e.ctx}
+}
+
+// Writer returns the default writer that writes to (*Schema).Result
+func (e *Engine) Writer() ValueWriter {
+	return resultWriter{e.ctx}
+}
+
+// FieldValue returns the go value of field within parent
+// For fields with a resolver the resolver function is returned
+// An invalid value is returned for fields promoted through a nil embedded pointer, ResolveValue writes null for it
+func (e *Engine) FieldValue(field SelectedField, parent reflect.Value) (reflect.Value, error) {
+	typeObjField := field.obj
+	if typeObjField == nil {
+		return reflect.Value{}, errors.New(field.Name + " does not exists on " + field.ParentType)
+	}
+	if field.authErr != nil {
+		return reflect.Value{}, field.authErr
+	}
+	if typeObjField.customObjValue != nil {
+		return *typeObjField.customObjValue, nil
+	}
+	if typeObjField.embeddedPtr != nil && nilEmbeddedPtr(parent, typeObjField.embeddedPtr) {
+		return reflect.Value{}, nil
+	}
+	if typeObjField.valueType == valueTypeMethod && typeObjField.method.isTypeMethod {
+		return typeMethod(parent, typeObjField.method), nil
+	}
+	return structField(parent, typeObjField), nil
+}
+
+// ResolveValue writes value of field to the response using the default executor
+// value is the go value of the field, for fields with a resolver this should be the resolver function
+// null is written if the user is not allowed to resolve the field
+// For mocked fields value is ignored and the mocked value is written, see (SelectedField).Mocked
+// Equal to the default executor the field is resolved through the middleware set using (*Schema).Use and null is written once the request is cancelled
+func (e *Engine) ResolveValue(field SelectedField, value reflect.Value) error {
+	if field.obj == nil {
+		return errors.New(field.Name + " does not exists on " + field.ParentType)
+	}
+	if field.authErr != nil {
+		e.ctx.writeNull()
+		return field.authErr
+	}
+
+	ctx := e.ctx
+	prefPathLen := len(ctx.path)
+	ctx.path = append(ctx.path, []byte(`,"`)...)
+	ctx.path = append(ctx.path, field.Alias...)
+	ctx.path = append(ctx.path, '"')
+	prefFieldAt := ctx.fieldAt
+	ctx.fieldAt = field.fieldAt
+
+	ctx.charNr = field.valueAt
+	criticalErr := ctx.resolveWrappedField(&wrappedField{
+		parent:        field.parent,
+		field:         field.obj,
+		name:          s2b(field.Name),
+		alias:         s2b(field.Alias),
+		dept:          field.dept,
+		hasSelection:  field.HasSelectionSet,
+		mock:          field.mock,
+		parentPathLen: prefPathLen,
+		endOfField:    field.endAt,
+		value:         value,
+		hasValue:      true,
+	})
+
+	ctx.path = ctx.path[:prefPathLen]
+	ctx.fieldAt = prefFieldAt
+	if criticalErr {
+		return ctx.lastErr()
+	}
+	return nil
+}
+
+// reportedError is an error that is already added to the response
+type reportedError struct {
+	err error
+}
+
+func (e reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e reportedError) Unwrap() error {
+	return e.err
+}
+
+// lastErr returns the last error added to the response
+func (ctx *Ctx) lastErr() error {
+	if len(ctx.query.Errors) == 0 {
+		return reportedError{errors.New("unknown error")}
+	}
+	return reportedError{ctx.query.Errors[len(ctx.query.Errors)-1]}
+}
+
+// selectionIterator is the default SelectionIterator that walks over the bytecode
+type selectionIterator struct {
+	fields []SelectedField
+	idx    int
+}
+
+func (it *selectionIterator) Next() bool {
+	it.idx++
+	return it.idx < len(it.fields)
+}
+
+func (it *selectionIterator) Field() SelectedField {
+	return it.fields[it.idx]
+}
+
+func (ctx *Ctx) newSelectionIterator(typeObj *obj, startAt int, dept uint8) (*selectionIterator, error) {
+	if dept == ctx.schema.MaxDepth {
+		return nil, errors.New("reached max dept")
+	}
+
+	ctx.charNr = startAt
+	it := &selectionIterator{idx: -1}
+	criticalErr := ctx.collectSelections(typeObj, dept, &it.fields)
+	if criticalErr {
+		return nil, ctx.lastErr()
+	}
+	return it, nil
+}
+
+func (ctx *Ctx) collectSelections(typeObj *obj, dept uint8, fields *[]SelectedField) bool {
+	for {
+		switch ctx.readInst() {
+		case bytecode.ActionEnd:
+			return false
+		case bytecode.ActionField:
+			criticalErr := ctx.collectField(typeObj, dept, fields)
+			if criticalErr {
+				return criticalErr
+			}
+		case bytecode.ActionSpread:
+			criticalErr := ctx.walkSpread(typeObj, func() bool {
+				return ctx.collectSelections(typeObj, dept, fields)
+			})
+			if criticalErr {
+				return criticalErr
+			}
+		default:
+			return ctx.err("unsupported operation " + string(ctx.lastInst()))
+		}
+	}
+}
+
+// collectField reads a field in the same way as resolveField but instead of resolving it the field is added to fields
+func (ctx *Ctx) collectField(typeObj *obj, dept uint8, fields *[]SelectedField) bool {
+	fieldAt := ctx.charNr - 1
+	directivesCount := ctx.readInst()
+
+	fieldLen := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	nameKey := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	endOfField := ctx.charNr + int(fieldLen)
+
+	aliasLen := int(ctx.readInst())
+	alias := b2s(ctx.query.Res[ctx.charNr : ctx.charNr+aliasLen])
+	ctx.skipInst(aliasLen)
+
+	name := alias
+	lenOfName := int(ctx.readInst())
+	if lenOfName != 0 {
+		name = b2s(ctx.query.Res[ctx.charNr : ctx.charNr+lenOfName])
+		ctx.skipInst(lenOfName)
+	}
+	ctx.skipInst(1)
+
+	var mock *DirectiveModifier
+	ctx.usedDirectives = ctx.usedDirectives[:0]
+	for i := uint8(0); i < directivesCount; i++ {
+		modifier, criticalErr := ctx.resolveDirective(DirectiveLocationField)
+		if criticalErr || modifier.Skip {
+			ctx.charNr = endOfField + 1
+			return criticalErr
+		}
+		if modifier.Mock {
+			mock = &modifier
+		}
+	}
+
+	field := SelectedField{
+		Name:         name,
+		Alias:        alias,
+		ParentType:   typeObj.typeName,
+		HasArguments: ctx.seekInst() == bytecode.ActionValue,
+		parent:       typeObj,
+		mock:         mock,
+		fieldAt:      fieldAt,
+		valueAt:      ctx.charNr,
+		endAt:        endOfField,
+		dept:         dept,
+	}
+	ctx.skipArguments()
+	field.HasSelectionSet = ctx.seekInst() != 'e'
+	if typeObjField, ok := ctx.selectableField(typeObj, nameKey, s2b(name)); ok {
+		field.obj = typeObjField
+
+		// Check the auth of the field here so the error is reported once with the path and location of the field
+		prefPathLen := len(ctx.path)
+		ctx.path = append(ctx.path, []byte(`,"`)...)
+		ctx.path = append(ctx.path, alias...)
+		ctx.path = append(ctx.path, '"')
+		prefFieldAt := ctx.fieldAt
+		ctx.fieldAt = fieldAt
+		if !ctx.fieldAuthorized(typeObjField) {
+			field.authErr = ctx.lastErr()
+		}
+		ctx.path = ctx.path[:prefPathLen]
+		ctx.fieldAt = prefFieldAt
+	}
+	*fields = append(*fields, field)
+
+	ctx.charNr = endOfField + 1
+	return false
+}
+
+// ctxArgumentBinder is the default ArgumentBinder
+type ctxArgumentBinder struct {
+	ctx *Ctx
+}
+
+func (b ctxArgumentBinder) BindArguments(field SelectedField) ([]reflect.Value, error) {
+	typeObj := field.obj
+	if typeObj == nil {
+		return nil, errors.New(field.Name + " does not exists on " + field.ParentType)
+	}
+	if field.authErr != nil {
+		return nil, field.authErr
+	}
+	for typeObj.valueType == valueTypePtr {
+		typeObj = typeObj.innerContent
+	}
+	if typeObj.valueType != valueTypeMethod {
+		if field.HasArguments {
+			return nil, errors.New("field arguments not allowed")
+		}
+		return nil, nil
+	}
+
+	ctx := b.ctx
+	ctx.charNr = field.valueAt
+	criticalErr := ctx.bindMethodInputs(typeObj.method, field.HasArguments)
+	if criticalErr {
+		return nil, ctx.lastErr()
+	}
+	return ctx.funcInputs, nil
+}
+
+// resultWriter is the default ValueWriter
+type resultWriter struct {
+	ctx *Ctx
+}
+
+func (w resultWriter) WriteRaw(b []byte) {
+	w.ctx.write(b)
+}
+
+func (w resultWriter) WriteChar(b byte) {
+	w.ctx.writeByte(b)
+}
+
+func (w resultWriter) WriteKey(key string) {
+	helpers.StringToJSON(key, &w.ctx.schema.Result)
+	w.ctx.writeByte(':')
+}
+
+func (w resultWriter) WriteString(s string) {
+	helpers.StringToJSON(s, &w.ctx.schema.Result)
+}
+
+func (w resultWriter) WriteNull() {
+	w.ctx.writeNull()
+}
@@ -0,0 +1,416 @@
+package yarql
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestEngineQ struct {
+	Name  string
+	User  TestEngineUser
+	Greet func(args struct{ Name string }) string
+}
+
+type TestEngineUser struct {
+	ID   uint `gq:"id,ID"`
+	Name string
+}
+
+func newTestEngineData() TestEngineQ {
+	return TestEngineQ{
+		Name: "root",
+		User: TestEngineUser{ID: 1, Name: "foo"},
+		Greet: func(args struct{ Name string }) string {
+			return "Hello " + args.Name
+		},
+	}
+}
+
+func engineParse(t *testing.T, query string, strategy ExecutionStrategy) (string, []error) {
+	s := NewSchema()
+	s.SetExecutionStrategy(strategy)
+	return bytecodeParse(t, s, query, newTestEngineData(), M{})
+}
+
+// defaultLikeStrategy resolves every root field using the default executor
+func defaultLikeStrategy(engine *Engine) error {
+	it, err := engine.Selections()
+	if err != nil {
+		return err
+	}
+
+	w := engine.Writer()
+	for first := true; it.Next(); first = false {
+		field := it.Field()
+		if !first {
+			w.WriteChar(',')
+		}
+		w.WriteKey(field.Alias)
+
+		value, err := engine.FieldValue(field, engine.RootValue())
+		if err != nil {
+			w.WriteNull()
+			return err
+		}
+		err = engine.ResolveValue(field, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestEngineDefaultLikeStrategy(t *testing.T) {
+	query := `{
+		name
+		... on TestEngineQ {
+			u: user {id name}
+		}
+		skipped: name @skip(if: true)
+		greet(name: "world")
+	}`
+
+	expected := bytecodeParseAndExpectNoErrs(t, query, newTestEngineData(), M{})
+	res, errs := engineParse(t, query, ExecutionStrategyFunc(defaultLikeStrategy))
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, expected, res)
+	a.Equal(t, `{"name":"root","u":{"id":"1","name":"foo"},"greet":"Hello world"}`, res)
+}
+
+func TestEngineAuth(t *testing.T) {
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	a.NoError(t, s.RegisterFieldAuth(TestAuthQuery{}, "users", "ADMIN"))
+	a.NoError(t, s.Parse(TestAuthQuery{Name: "a", Email: "a@example.com"}, M{}, nil))
+	s = s.Copy()
+
+	errs := s.Resolve([]byte(`{name email}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "not authenticated", errs[0].Error())
+	a.Equal(t, `{"name":"a","email":null}`, string(s.Result))
+
+	testAuthUsersCalls = 0
+	errs = s.Resolve([]byte(`{users}`), ResolveOptions{NoMeta: true, Authorizer: testAuthorizer{}})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, 0, testAuthUsersCalls)
+	a.Equal(t, `{"users":null}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{email users}`), ResolveOptions{NoMeta: true, Authorizer: testAuthorizer{roles: []string{"ADMIN"}}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, 1, testAuthUsersCalls)
+	a.Equal(t, `{"email":"a@example.com","users":["a","b"]}`, string(s.Result))
+}
+
+func TestEngineFieldVisibility(t *testing.T) {
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	_, errs := bytecodeParseWithOptions(t, s, testFieldVisibilityOptions, `{name secret}`, testFieldVisibilityData, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "secret does not exists on TestFieldVisibilityQuery", errs[0].Error())
+
+	s = NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	res, errs := bytecodeParseWithOptions(t, s, testFieldVisibilityOptions, `{secret}`, testFieldVisibilityData, M{}, ResolveOptions{NoMeta: true, Values: &map[string]interface{}{"admin": true}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"secret":"b"}`, res)
+}
+
+func TestEngineSchemaView(t *testing.T) {
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	a.NoError(t, s.RegisterFieldScope(TestSchemaViewQuery{}, "stats", "internal"))
+	a.NoError(t, s.Parse(testSchemaViewData, M{}, nil))
+	public := s.View("public")
+	internal := s.View("internal")
+
+	errs := public.Resolve([]byte(`{name auditLog}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "auditLog does not exists on TestSchemaViewQuery", errs[0].Error())
+
+	errs = public.Resolve([]byte(`{stats}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "stats does not exists on TestSchemaViewQuery", errs[0].Error())
+
+	errs = internal.Resolve([]byte(`{name auditLog stats}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"a","auditLog":["b"],"stats":1}`, string(internal.Result))
+}
+
+func TestEngineMockDirective(t *testing.T) {
+	query := `{
+		name @mock(value: "\"fake\"")
+		invoices @mock {
+			id
+			lines {amount}
+		}
+		released
+	}`
+	options := SchemaOptions{EnableMockDirective: true}
+	expected, errs := mockParse(t, query, options)
+	a.Equal(t, 0, len(errs))
+
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(func(engine *Engine) error {
+		it, err := engine.Selections()
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			field := it.Field()
+			a.Equal(t, field.Name != "released", field.Mocked())
+		}
+		return defaultLikeStrategy(engine)
+	}))
+	err := s.Parse(newTestMockData(), M{}, &options)
+	a.NoError(t, err)
+	errs = s.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, expected, string(s.Result))
+	a.True(t, strings.HasPrefix(expected, `{"name":"fake","invoices":[`))
+}
+
+func TestEngineExecute(t *testing.T) {
+	res, errs := engineParse(t, `{name}`, ExecutionStrategyFunc(func(engine *Engine) error {
+		return engine.Execute()
+	}))
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"root"}`, res)
+}
+
+func TestEngineSelections(t *testing.T) {
+	fields := []SelectedField{}
+	subFields := []SelectedField{}
+
+	_, errs := engineParse(t, `{
+		name
+		user { id ...userFields }
+		greet(name: "world")
+	}
+	fragment userFields on TestEngineUser { n: name }`, ExecutionStrategyFunc(func(engine *Engine) error {
+		it, err := engine.Selections()
+		if err != nil {
+			return err
+		}
+		for it.Next() {
+			field := it.Field()
+			fields = append(fields, field)
+			if field.HasSelectionSet {
+				subIt, err := engine.SubSelections(field, "TestEngineUser")
+				if err != nil {
+					return err
+				}
+				for subIt.Next() {
+					subFields = append(subFields, subIt.Field())
+				}
+			}
+		}
+		return nil
+	}))
+	a.Equal(t, 0, len(errs))
+
+	a.Equal(t, 3, len(fields))
+	a.Equal(t, "name", fields[0].Name)
+	a.Equal(t, "TestEngineQ", fields[0].ParentType)
+	a.Equal(t, "user", fields[1].Name)
+	a.True(t, fields[1].HasSelectionSet)
+	a.Equal(t, "greet", fields[2].Name)
+	a.True(t, fields[2].HasArguments)
+	a.False(t, fields[2].HasSelectionSet)
+
+	a.Equal(t, 2, len(subFields))
+	a.Equal(t, "id", subFields[0].Name)
+	a.Equal(t, "n", subFields[1].Alias)
+	a.Equal(t, "name", subFields[1].Name)
+	a.Equal(t, "TestEngineUser", subFields[1].ParentType)
+}
+
+type TestEngineInterfaceQ struct {
+	Named TestEngineNamed
+}
+
+type TestEngineNamed interface {
+	ResolveFoo() string
+	ResolveBar() string
+}
+
+type TestEnginePet struct{}
+
+func (TestEnginePet) ResolveFoo() string { return "foo" }
+func (TestEnginePet) ResolveBar() string { return "bar" }
+
+func TestEngineSubSelectionsInterface(t *testing.T) {
+	Implements((*TestEngineNamed)(nil), TestEnginePet{})
+
+	subFields := []SelectedField{}
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(func(engine *Engine) error {
+		it, err := engine.Selections()
+		if err != nil {
+			return err
+		}
+		it.Next()
+		subIt, err := engine.SubSelections(it.Field(), "TestEngineNamed")
+		if err != nil {
+			return err
+		}
+		for subIt.Next() {
+			subFields = append(subFields, subIt.Field())
+		}
+		return nil
+	}))
+	_, errs := bytecodeParse(t, s, `{named {foo ... on TestEngineNamed {bar}}}`, TestEngineInterfaceQ{}, M{})
+	a.Equal(t, 0, len(errs))
+
+	a.Equal(t, 2, len(subFields))
+	a.Equal(t, "foo", subFields[0].Name)
+	a.True(t, subFields[0].Exists())
+	a.Equal(t, "TestEngineNamed", subFields[0].ParentType)
+	a.Equal(t, "bar", subFields[1].Name)
+}
+
+func TestEngineBindArguments(t *testing.T) {
+	var greeting string
+	_, errs := engineParse(t, `{greet(name: "world")}`, ExecutionStrategyFunc(func(engine *Engine) error {
+		it, err := engine.Selections()
+		if err != nil {
+			return err
+		}
+		it.Next()
+		field := it.Field()
+
+		inputs, err := engine.Arguments().BindArguments(field)
+		if err != nil {
+			return err
+		}
+		fn, err := engine.FieldValue(field, engine.RootValue())
+		if err != nil {
+			return err
+		}
+		greeting = fn.Call(inputs)[0].String()
+
+		engine.Writer().WriteKey(field.Alias)
+		engine.Writer().WriteString(greeting)
+		return nil
+	}))
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, "Hello world", greeting)
+
+	_, errs = engineParse(t, `{greet(unknown: "world")}`, ExecutionStrategyFunc(func(engine *Engine) error {
+		it, _ := engine.Selections()
+		it.Next()
+		_, err := engine.Arguments().BindArguments(it.Field())
+		return err
+	}))
+	// The error should only be reported once
+	a.Equal(t, 1, len(errs))
+}
+
+func TestEngineStrategyError(t *testing.T) {
+	_, errs := engineParse(t, `{name}`, ExecutionStrategyFunc(func(engine *Engine) error {
+		return errors.New("oops")
+	}))
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "oops", errs[0].Error())
+}
+
+func TestEngineFieldValueUnknownField(t *testing.T) {
+	_, errs := engineParse(t, `{__typename}`, ExecutionStrategyFunc(func(engine *Engine) error {
+		it, _ := engine.Selections()
+		it.Next()
+		a.False(t, it.Field().Exists())
+		_, err := engine.FieldValue(it.Field(), reflect.Value{})
+		return err
+	}))
+	a.Equal(t, 1, len(errs))
+}
+
+type TestEngineEmbeddedQ struct {
+	*TestBytecodeResolveEmbeddedOwner
+	Name string
+}
+
+func TestEngineNilEmbeddedPointer(t *testing.T) {
+	query := `{name ownerID ownerName}`
+	expected := bytecodeParseAndExpectNoErrs(t, query, TestEngineEmbeddedQ{Name: "a"}, M{})
+	a.Equal(t, `{"name":"a","ownerID":null,"ownerName":null}`, expected)
+
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	res, errs := bytecodeParse(t, s, query, TestEngineEmbeddedQ{Name: "a"}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, expected, res)
+}
+
+func TestEngineMiddleware(t *testing.T) {
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	calls := []string{}
+	a.NoError(t, s.Use(func(next FieldResolver) FieldResolver {
+		return func(ctx *Ctx, field FieldInfo) error {
+			calls = append(calls, field.ParentType+"."+field.Name)
+			if field.Name == "secret" {
+				return errors.New("not allowed")
+			}
+			return next(ctx, field)
+		}
+	}))
+
+	// The root fields picked by the strategy are resolved through the middleware
+	query := `{public secret u: user {name}}`
+	res, errs := bytecodeParse(t, s, query, TestMiddlewareQuery{Public: "public", Secret: "secret", User: TestMiddlewareUser{Name: "user"}}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "not allowed", errs[0].Error())
+	a.Equal(t, `{"public":"public","secret":null,"u":{"name":"user"}}`, res)
+	a.Equal(t, []string{
+		"TestMiddlewareQuery.public",
+		"TestMiddlewareQuery.secret",
+		"TestMiddlewareQuery.user",
+		"TestMiddlewareUser.name",
+	}, calls)
+}
+
+func TestEngineCancelledContext(t *testing.T) {
+	c, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The fields picked by the strategy are not resolved once the request is cancelled
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	res, errs := bytecodeParse(t, s, `{a b}`, TestResolveSimpleQueryData{A: "a", B: "b"}, M{}, ResolveOptions{NoMeta: true, Context: c})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, context.Canceled.Error(), errs[0].Error())
+	a.Equal(t, `{"a":null,"b":null}`, res)
+}
+
+func TestEngineOwnerAndIntrospectionLimits(t *testing.T) {
+	s := NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	query := TestOwnersValidQuery{
+		Failing: func() (string, error) {
+			return "", errors.New("this field failed")
+		},
+	}
+	_, errs := bytecodeParse(t, s, `{failing}`, query, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "risk", errs[0].(ErrorWPath).Owner())
+
+	s = NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	s.MaxIntrospectionFields = 2
+	_, errs = bytecodeParse(t, s, `{__schema {queryType {name}} a}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 0, len(errs))
+
+	s = NewSchema()
+	s.SetExecutionStrategy(ExecutionStrategyFunc(defaultLikeStrategy))
+	s.MaxIntrospectionFields = 1
+	_, errs = bytecodeParse(t, s, `{__schema {queryType {name}} a}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "introspection query selects too many fields", errs[0].Error())
+}
@@ -101,37 +101,119 @@ func registerEnumCheck(enumMap interface{}) (*enum, error) {
 		return nil, nil
 	}
 
-	entries := make([]enumEntry, inputLen)
-	qlTypeEnumValues := make([]qlEnumValue, inputLen)
-
+	entries := make([]enumEntry, 0, inputLen)
 	iter := mapReflection.MapRange()
-	i := 0
 	for iter.Next() {
-		k := iter.Key()
-		keyStr := k.Interface().(string)
-		if keyStr == "" {
-			return nil, errors.New("RegisterEnum input map cannot contain empty keys")
+		entry, err := newEnumEntry(iter.Key().Interface().(string), iter.Value())
+		if err != nil {
+			return nil, err
 		}
+		entries = append(entries, entry)
+	}
+	// Map iteration is random so the values are sorted by name
+	sort.Slice(entries, func(a int, b int) bool { return entries[a].key < entries[b].key })
 
-		err := validGraphQlName([]byte(keyStr))
-		if err != nil {
-			return nil, errors.New(`RegisterEnum map key must start with an alphabetic character (lower or upper) followed by the same or a "_", key given: ` + keyStr)
+	return newEnum(contentType, entries), nil
+}
+
+// EnumValue is a value of an enum registered using (*Schema).RegisterOrderedEnum
+type EnumValue struct {
+	// Name is the graphql name of the enum value
+	Name string
+	// Value is the go value the enum value is mapped to, all values must have the same type
+	Value interface{}
+}
+
+// RegisterOrderedEnum registers a new enum type that keeps the order of values within the introspection and schema definition language
+// Enums registered using RegisterEnum have their values sorted by name
+//
+// Example:
+//
+//	s.RegisterOrderedEnum([]yarql.EnumValue{
+//		{Name: "LOW", Value: PriorityLow},
+//		{Name: "MEDIUM", Value: PriorityMedium},
+//		{Name: "HIGH", Value: PriorityHigh},
+//	})
+func (s *Schema) RegisterOrderedEnum(values []EnumValue) (added bool, err error) {
+	if s.parsed {
+		return false, errors.New("(*yarql.Schema).RegisterOrderedEnum() cannot be ran after (*yarql.Schema).Parse()")
+	}
+
+	enum, err := registerOrderedEnumCheck(values)
+	if enum == nil || err != nil {
+		return false, err
+	}
+
+	s.definedEnums = append(s.definedEnums, *enum)
+	return true, nil
+}
+
+func registerOrderedEnumCheck(values []EnumValue) (*enum, error) {
+	if len(values) == 0 {
+		// No point in registering enums with 0 items
+		return nil, nil
+	}
+
+	var contentType reflect.Type
+	entries := make([]enumEntry, len(values))
+	for i, value := range values {
+		if value.Value == nil {
+			return nil, errors.New("RegisterOrderedEnum value of " + value.Name + " cannot be nil")
+		}
+		valueType := reflect.TypeOf(value.Value)
+		if i == 0 {
+			contentType = valueType
+			if !validEnumType(contentType) || contentType.PkgPath() == "" || contentType.Name() == "" {
+				return nil, errors.New("RegisterOrderedEnum values must have a global custom type value (type Animals string) or (type Rules uint64)")
+			}
+		} else if valueType != contentType {
+			return nil, fmt.Errorf("RegisterOrderedEnum values must all have the same type, %s and %s given", contentType.String(), valueType.String())
+		}
+
+		for _, entry := range entries[:i] {
+			if entry.key == value.Name {
+				return nil, errors.New("RegisterOrderedEnum cannot contain duplicated names, name given twice: " + value.Name)
+			}
 		}
 
-		entries[i] = enumEntry{
-			keyBytes: []byte(keyStr),
-			key:      keyStr,
-			value:    iter.Value(),
+		entry, err := newEnumEntry(value.Name, reflect.ValueOf(value.Value))
+		if err != nil {
+			return nil, err
 		}
+		entries[i] = entry
+	}
+
+	return newEnum(contentType, entries), nil
+}
+
+func newEnumEntry(key string, value reflect.Value) (enumEntry, error) {
+	if key == "" {
+		return enumEntry{}, errors.New("RegisterEnum input map cannot contain empty keys")
+	}
+
+	err := validGraphQlName([]byte(key))
+	if err != nil {
+		return enumEntry{}, errors.New(`RegisterEnum map key must start with an alphabetic character (lower or upper) followed by the same or a "_", key given: ` + key)
+	}
+
+	return enumEntry{
+		keyBytes: []byte(key),
+		key:      key,
+		value:    value,
+	}, nil
+}
+
+// newEnum creates the enum of contentType, the graphql enum values are in the order of entries
+func newEnum(contentType reflect.Type, entries []enumEntry) *enum {
+	qlTypeEnumValues := make([]qlEnumValue, len(entries))
+	for i, entry := range entries {
 		qlTypeEnumValues[i] = qlEnumValue{
-			Name:              keyStr,
+			Name:              entry.key,
 			Description:       h.PtrToEmptyStr,
 			IsDeprecated:      false,
 			DeprecationReason: nil,
 		}
-		i++
 	}
-	sort.Slice(qlTypeEnumValues, func(a int, b int) bool { return qlTypeEnumValues[a].Name < qlTypeEnumValues[b].Name })
 
 	name := contentType.Name()
 	qlType := qlType{
@@ -147,5 +229,5 @@ func registerEnumCheck(enumMap interface{}) (*enum, error) {
 		entries:     entries,
 		typeName:    name,
 		qlType:      qlType,
-	}, nil
+	}
 }
@@ -105,3 +105,68 @@ func TestEnum(t *testing.T) {
 	}
 	a.Equal(t, `{"bar":"BAZ"}`, res)
 }
+
+func TestEnumArgumentIntrospection(t *testing.T) {
+	s := NewSchema()
+	_, err := s.RegisterEnum(map[string]TestEnum2{
+		"FOO": TestEnum2Foo,
+		"BAR": TestEnum2Bar,
+	})
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `{__type(name: "TestEnumFunctionInput") {fields {args {type {kind ofType {kind name}}}}}}`, TestEnumFunctionInput{}, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"fields":[{"args":[{"type":{"kind":"ENUM","ofType":null}}]}]}}`, res)
+}
+
+func TestRegisterOrderedEnum(t *testing.T) {
+	s := NewSchema()
+	added, err := s.RegisterOrderedEnum([]EnumValue{
+		{Name: "FOO", Value: TestEnum2Foo},
+		{Name: "BAR", Value: TestEnum2Bar},
+		{Name: "BAZ", Value: TestEnum2Baz},
+	})
+	a.True(t, added)
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `{bar(e: BAR) __type(name: "TestEnum2") {enumValues {name}}}`, TestEnumFunctionInput{}, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"bar":"BAR","__type":{"enumValues":[{"name":"FOO"},{"name":"BAR"},{"name":"BAZ"}]}}`, res)
+}
+
+func TestRegisterEnumSortedValues(t *testing.T) {
+	s := NewSchema()
+	_, err := s.RegisterEnum(map[string]TestEnum2{
+		"FOO": TestEnum2Foo,
+		"BAR": TestEnum2Bar,
+		"BAZ": TestEnum2Baz,
+	})
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `{__type(name: "TestEnum2") {enumValues {name}}}`, TestEnumFunctionInput{}, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"enumValues":[{"name":"BAR"},{"name":"BAZ"},{"name":"FOO"}]}}`, res)
+}
+
+func TestRegisterOrderedEnumFails(t *testing.T) {
+	type TestEnum string
+
+	res, err := registerOrderedEnumCheck(nil)
+	a.NoError(t, err)
+	a.Nil(t, res)
+
+	_, err = registerOrderedEnumCheck([]EnumValue{{Name: "A", Value: nil}})
+	a.Error(t, err, "Enum value cannot be nil")
+
+	_, err = registerOrderedEnumCheck([]EnumValue{{Name: "A", Value: "a"}})
+	a.Error(t, err, "Enum value must be a custom type")
+
+	_, err = registerOrderedEnumCheck([]EnumValue{{Name: "A", Value: TestEnum("a")}, {Name: "B", Value: TestEnum2Foo}})
+	a.Error(t, err, "Enum values must have the same type")
+
+	_, err = registerOrderedEnumCheck([]EnumValue{{Name: "A", Value: TestEnum("a")}, {Name: "A", Value: TestEnum("b")}})
+	a.Error(t, err, "Enum cannot have duplicated names")
+
+	_, err = registerOrderedEnumCheck([]EnumValue{{Name: "1", Value: TestEnum("a")}})
+	a.Error(t, err, "Enum cannot have an invalid graphql name")
+}
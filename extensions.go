@@ -0,0 +1,146 @@
+package yarql
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Extension hooks into the lifecycle of every operation, see (*yarql.Schema).RegisterExtension
+// Embed BaseExtension to only implement the hooks needed
+type Extension interface {
+	// ParseStart is called before the query is parsed, the returned query is parsed instead of query
+	// Returning an error rejects the request without parsing the query
+	ParseStart(ctx *Ctx, query []byte) ([]byte, error)
+	// ParseEnd is called after the query is parsed, errs contains the parse errors
+	ParseEnd(ctx *Ctx, errs []error)
+	// Validate is called before executing the operation, returning an error rejects the operation
+	Validate(ctx *Ctx) error
+	// ExecutionStart is called before the operation is executed
+	ExecutionStart(ctx *Ctx)
+	// ExecutionEnd is called after the operation is executed
+	ExecutionEnd(ctx *Ctx)
+	// ResponseEnd is called after the response is written, result is only valid during this call
+	ResponseEnd(ctx *Ctx, result []byte, errs []error)
+}
+
+// BaseExtension implements all hooks of Extension without doing anything
+type BaseExtension struct{}
+
+// ParseStart implements Extension
+func (BaseExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) { return query, nil }
+
+// ParseEnd implements Extension
+func (BaseExtension) ParseEnd(ctx *Ctx, errs []error) {}
+
+// Validate implements Extension
+func (BaseExtension) Validate(ctx *Ctx) error { return nil }
+
+// ExecutionStart implements Extension
+func (BaseExtension) ExecutionStart(ctx *Ctx) {}
+
+// ExecutionEnd implements Extension
+func (BaseExtension) ExecutionEnd(ctx *Ctx) {}
+
+// ResponseEnd implements Extension
+func (BaseExtension) ResponseEnd(ctx *Ctx, result []byte, errs []error) {}
+
+// RegisterExtension adds an extension that hooks into the lifecycle of every operation
+// The hooks of the extensions are called in the order the extensions are registered
+//
+// Example:
+//
+//	type Logger struct {
+//		yarql.BaseExtension
+//	}
+//
+//	func (Logger) ResponseEnd(ctx *yarql.Ctx, result []byte, errs []error) {
+//		log.Printf("resolved query with %d errors", len(errs))
+//	}
+//
+//	s.RegisterExtension(Logger{})
+func (s *Schema) RegisterExtension(extension Extension) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterExtension() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if extension == nil {
+		return errors.New("extension cannot be nil")
+	}
+
+	s.extensions = append(s.extensions, extension)
+	return nil
+}
+
+// extensionsParseStart calls the ParseStart hook of the extensions and returns the query to parse
+func (ctx *Ctx) extensionsParseStart(query []byte) ([]byte, error) {
+	for _, extension := range ctx.schema.extensions {
+		var err error
+		query, err = extension.ParseStart(ctx, query)
+		if err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+// executionStart marks the operation as executed and calls the ExecutionStart hook of the extensions
+func (ctx *Ctx) executionStart() {
+	ctx.executed = true
+	for _, extension := range ctx.schema.extensions {
+		extension.ExecutionStart(ctx)
+	}
+}
+
+func (ctx *Ctx) executionEnd() {
+	for _, extension := range ctx.schema.extensions {
+		extension.ExecutionEnd(ctx)
+	}
+}
+
+// extensionsValidate calls the Validate hook of the extensions and reports the first error
+func (ctx *Ctx) extensionsValidate() bool {
+	for _, extension := range ctx.schema.extensions {
+		err := extension.Validate(ctx)
+		if err != nil {
+			return ctx.err(err.Error())
+		}
+	}
+	return false
+}
+
+// responseExtension is a value added to the extensions object of the response
+type responseExtension struct {
+	key   string
+	value []byte
+}
+
+// SetExtension adds value as JSON under key to the extensions object of the response, this can be used for things like cache hints and cost reports
+// Setting a key that was already set replaces the value, the tracing key is reserved if tracing is enabled
+// Note that the extensions are not part of the response if (yarql.ResolveOptions).NoMeta is set
+func (ctx *Ctx) SetExtension(key string, value interface{}) error {
+	if key == "tracing" && ctx.tracingEnabled {
+		return errors.New("the tracing extension key is reserved as tracing is enabled")
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	for i, extension := range ctx.responseExtensions {
+		if extension.key == key {
+			ctx.responseExtensions[i].value = valueJSON
+			return nil
+		}
+	}
+	ctx.responseExtensions = append(ctx.responseExtensions, responseExtension{key: key, value: valueJSON})
+	return nil
+}
+
+// RequestExtensions returns the extensions object send by the client, nil if none where send
+// Extensions can use this to read things like the hash of a persisted query within ParseStart
+func (ctx *Ctx) RequestExtensions() json.RawMessage {
+	if len(ctx.rawExtensions) == 0 {
+		return nil
+	}
+	return json.RawMessage(ctx.rawExtensions)
+}
@@ -0,0 +1,203 @@
+package yarql
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+	"github.com/mjarkk/yarql/ast"
+)
+
+type testRecordingExtension struct {
+	BaseExtension
+	calls []string
+}
+
+func (e *testRecordingExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) {
+	e.calls = append(e.calls, "parseStart "+string(query))
+	return query, nil
+}
+
+func (e *testRecordingExtension) ParseEnd(ctx *Ctx, errs []error) {
+	e.calls = append(e.calls, "parseEnd "+strconv.Itoa(len(errs)))
+}
+
+func (e *testRecordingExtension) Validate(ctx *Ctx) error {
+	e.calls = append(e.calls, "validate "+strconv.Itoa(ctx.Complexity()))
+	return nil
+}
+
+func (e *testRecordingExtension) ExecutionStart(ctx *Ctx) {
+	e.calls = append(e.calls, "executionStart")
+}
+
+func (e *testRecordingExtension) ExecutionEnd(ctx *Ctx) {
+	e.calls = append(e.calls, "executionEnd")
+}
+
+func (e *testRecordingExtension) ResponseEnd(ctx *Ctx, result []byte, errs []error) {
+	e.calls = append(e.calls, "responseEnd "+string(result))
+}
+
+func TestExtensionHooks(t *testing.T) {
+	extension := &testRecordingExtension{}
+	s := NewSchema()
+	a.NoError(t, s.RegisterExtension(extension))
+
+	res, errs := bytecodeParse(t, s, `{a b}`, TestResolveSimpleQueryData{A: "a", B: "b"}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"a","b":"b"}`, res)
+	a.Equal(t, []string{
+		"parseStart {a b}",
+		"parseEnd 0",
+		"validate 2",
+		"executionStart",
+		"executionEnd",
+		`responseEnd {"a":"a","b":"b"}`,
+	}, extension.calls)
+
+	// Parse errors skip validation and execution
+	extension.calls = nil
+	s = NewSchema()
+	a.NoError(t, s.RegisterExtension(extension))
+	_, errs = bytecodeParse(t, s, `{a`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, []string{"parseStart {a", "parseEnd 1", "responseEnd {}"}, extension.calls)
+}
+
+type testPersistedQueryExtension struct {
+	BaseExtension
+	queries map[string]string
+}
+
+func (e testPersistedQueryExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) {
+	if len(query) > 0 && query[0] == '#' {
+		persisted, ok := e.queries[string(query[1:])]
+		if !ok {
+			return nil, errors.New("PersistedQueryNotFound")
+		}
+		return []byte(persisted), nil
+	}
+	return query, nil
+}
+
+type testMaxComplexityExtension struct {
+	BaseExtension
+}
+
+func (testMaxComplexityExtension) Validate(ctx *Ctx) error {
+	if ctx.Complexity() > 2 {
+		return errors.New("too complex")
+	}
+	return nil
+}
+
+func TestExtensionModifyAndReject(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterExtension(testPersistedQueryExtension{queries: map[string]string{"abc": `{a}`}}))
+	a.NoError(t, s.RegisterExtension(testMaxComplexityExtension{}))
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{A: "a"}, M{}, nil))
+	s = s.Copy()
+
+	errs := s.Resolve([]byte(`#abc`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"a"}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`#unknown`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "PersistedQueryNotFound", errs[0].Error())
+	a.Equal(t, `{}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{a b c}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "too complex", errs[0].Error())
+	a.Equal(t, `{}`, string(s.Result))
+
+	// The introspection query is precomputed but the extensions are still used
+	errs = s.Resolve([]byte(IntrospectionQuery), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "too complex", errs[0].Error())
+}
+
+type testForbiddenFieldExtension struct {
+	BaseExtension
+	documentInParseStart bool
+}
+
+func (e *testForbiddenFieldExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) {
+	e.documentInParseStart = ctx.Document() != nil
+	return query, nil
+}
+
+func (*testForbiddenFieldExtension) Validate(ctx *Ctx) error {
+	var err error
+	ast.Inspect(ctx.Document(), func(node ast.Node) bool {
+		if field, ok := node.(*ast.Field); ok && field.Name == "b" {
+			err = errors.New("field b is forbidden")
+		}
+		return err == nil
+	})
+	return err
+}
+
+func TestExtensionDocument(t *testing.T) {
+	extension := &testForbiddenFieldExtension{}
+	s := NewSchema()
+	a.NoError(t, s.RegisterExtension(extension))
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{A: "a", B: "b"}, M{}, nil))
+
+	errs := s.Resolve([]byte(`{a}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"a"}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{a b}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "field b is forbidden", errs[0].Error())
+	a.False(t, extension.documentInParseStart)
+}
+
+func TestRegisterExtensionInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterExtension(nil))
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{}, M{}, nil))
+	a.Error(t, s.RegisterExtension(BaseExtension{}))
+}
+
+type TestResponseExtensionsData struct{}
+
+func (TestResponseExtensionsData) ResolveA(ctx *Ctx) string {
+	ctx.SetExtension("cacheControl", map[string]int{"maxAge": 60})
+	ctx.SetExtension("cost", 1)
+	return "a"
+}
+
+func (TestResponseExtensionsData) ResolveB(ctx *Ctx) string {
+	ctx.SetExtension("cost", 2)
+	return "b"
+}
+
+func TestResponseExtensions(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestResponseExtensionsData{}, M{}, nil))
+	s = s.Copy()
+
+	errs := s.Resolve([]byte(`{a b}`), ResolveOptions{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":{"a":"a","b":"b"},"extensions":{"cacheControl":{"maxAge":60},"cost":2}}`, string(s.Result))
+
+	// The extensions are reset between requests
+	errs = s.Resolve([]byte(`{b}`), ResolveOptions{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":{"b":"b"},"extensions":{"cost":2}}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{b}`), ResolveOptions{Tracing: true})
+	a.Equal(t, 0, len(errs))
+	a.True(t, strings.HasPrefix(string(s.Result), `{"data":{"b":"b"},"extensions":{"tracing":{`))
+	a.True(t, strings.HasSuffix(string(s.Result), `},"cost":2}}`))
+
+	ctx := &Ctx{tracingEnabled: true}
+	a.Error(t, ctx.SetExtension("tracing", 1))
+	a.Error(t, ctx.SetExtension("invalid", func() {}))
+}
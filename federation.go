@@ -0,0 +1,331 @@
+package yarql
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mjarkk/yarql/bytecode"
+	"github.com/valyala/fastjson"
+)
+
+// Entity defines an Apollo federation entity
+// See: https://www.apollographql.com/docs/federation/v1/entities
+type Entity struct {
+	// Keys are the fields of the @key directives of the entity, for example "id" or "sku package { id }"
+	Keys []string
+	// Extends marks the entity as originating from another subgraph, the type is defined using "extend type"
+	Extends bool
+	// InterfaceObject marks the entity as @interfaceObject, a type representing an interface entity of another subgraph
+	// Requires the FederationV2 schema option
+	InterfaceObject bool
+	// Resolve returns the entity matching a representation send by the gateway
+	// The returned value must be of the registered type or a pointer to it, nil results in null
+	Resolve func(ctx *Ctx, representation EntityRepresentation) (interface{}, error)
+
+	goType   reflect.Type
+	typeName string
+}
+
+// EntityRepresentation is a reference to an entity send by the gateway
+//
+// Example:
+//
+//	{"__typename": "User", "id": "1"}
+type EntityRepresentation struct {
+	Typename string
+	// Raw contains the full JSON encoded representation
+	Raw json.RawMessage
+}
+
+// Decode decodes the representation into target using encoding/json
+func (r EntityRepresentation) Decode(target interface{}) error {
+	return json.Unmarshal(r.Raw, target)
+}
+
+// fieldFederation contains the federation directives of a field, set using the field tags:
+// external, requires=.., provides=.., shareable, inaccessible, override=.. and tag=..
+type fieldFederation struct {
+	external bool
+	requires string
+	provides string
+
+	// Federation v2 directives
+	shareable    bool
+	inaccessible bool
+	override     string
+	tags         []string
+}
+
+func (f fieldFederation) isV2() bool {
+	return f.shareable || f.inaccessible || len(f.override) > 0 || len(f.tags) > 0
+}
+
+// TypeFederation contains the Apollo federation v2 directives of a type
+type TypeFederation struct {
+	// Shareable allows the fields of the type to be resolved by multiple subgraphs
+	Shareable bool
+	// Inaccessible hides the type from the supergraph
+	Inaccessible bool
+	// Tags are added as @tag(name: ..) directives
+	Tags []string
+
+	typeName string
+}
+
+// _Service is the result of the federation _service query
+type _Service struct {
+	SDL string `gq:"sdl"`
+}
+
+// RegisterEntity marks goType as federation entity
+// Entities are only exposed if the EnableFederation schema option is set
+//
+// Example:
+//
+//	s.RegisterEntity(User{}, yarql.Entity{
+//		Keys: []string{"id"},
+//		Resolve: func(ctx *yarql.Ctx, representation yarql.EntityRepresentation) (interface{}, error) {
+//			var key struct{ ID string }
+//			err := representation.Decode(&key)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return db.GetUser(key.ID)
+//		},
+//	})
+func (s *Schema) RegisterEntity(goType interface{}, entity Entity) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterEntity() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t := reflect.TypeOf(goType)
+	if t.Kind() != reflect.Struct {
+		return errors.New("can only register struct types as entity")
+	}
+	if t.Name() == "" {
+		return errors.New("cannot register an inline type as entity")
+	}
+	if len(entity.Keys) == 0 {
+		return errors.New("entity " + t.Name() + " must have at least one key")
+	}
+	for _, key := range entity.Keys {
+		if len(strings.TrimSpace(key)) == 0 {
+			return errors.New("entity " + t.Name() + " cannot have an empty key")
+		}
+	}
+	if entity.Resolve == nil {
+		return errors.New("entity " + t.Name() + " must have a resolve function")
+	}
+
+	entity.goType = t
+	s.entities[t] = &entity
+	return nil
+}
+
+// RegisterTypeFederation sets the Apollo federation v2 directives of goType
+// Field directives are set using struct tags, for example: `gq:",shareable,tag=public"`
+// Requires the FederationV2 schema option
+//
+// Example:
+//
+//	s.RegisterTypeFederation(Money{}, yarql.TypeFederation{Shareable: true})
+func (s *Schema) RegisterTypeFederation(goType interface{}, directives TypeFederation) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterTypeFederation() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t := reflect.TypeOf(goType)
+	if t.Kind() != reflect.Struct {
+		return errors.New("can only register federation directives on struct types")
+	}
+	if t.Name() == "" {
+		return errors.New("cannot register federation directives on an inline type")
+	}
+	for _, tag := range directives.Tags {
+		if len(strings.TrimSpace(tag)) == 0 {
+			return errors.New("type " + t.Name() + " cannot have an empty tag")
+		}
+	}
+
+	s.typeFederation[t] = &directives
+	return nil
+}
+
+// injectFederation adds the federation _service and _entities fields to the query root
+func (s *Schema) injectFederation(ctx *parseCtx) error {
+	for _, entity := range s.entities {
+		entityObj, err := ctx.check(entity.goType, false)
+		if err != nil {
+			return err
+		}
+		if entity.InterfaceObject && !s.federationV2 {
+			return errors.New("entity " + entityObj.typeName + " is marked as interface object, this requires the FederationV2 schema option")
+		}
+		entity.typeName = entityObj.typeName
+		s.entitiesByName[entity.typeName] = entity
+	}
+
+	for goType, directives := range s.typeFederation {
+		typeObj, err := ctx.check(goType, false)
+		if err != nil {
+			return err
+		}
+		if !s.federationV2 {
+			return errors.New("federation directives of " + typeObj.typeName + " require the FederationV2 schema option")
+		}
+		directives.typeName = typeObj.typeName
+		s.federationByName[directives.typeName] = directives
+	}
+
+	if !s.federationV2 {
+		for _, typeObj := range s.types {
+			for _, field := range typeObj.objContents.all() {
+				if field.federation.isV2() {
+					return errors.New("federation directives of " + typeObj.typeName + "." + string(field.qlFieldName) + " require the FederationV2 schema option")
+				}
+			}
+		}
+	}
+
+	serviceResolver := reflect.ValueOf(func(ctx *Ctx) _Service {
+		return _Service{SDL: ctx.schema.SDL()}
+	})
+	serviceObj, err := ctx.checkStructFieldFunc("_service", serviceResolver.Type(), false, -1)
+	if err != nil {
+		return err
+	}
+	serviceObj.customObjValue = &serviceResolver
+	serviceObj.qlFieldName = []byte("_service")
+	serviceObj.hidden = true
+	s.rootQuery.objContents.set(serviceObj)
+
+	if len(s.entities) > 0 {
+		entitiesObj := &obj{
+			qlFieldName:    []byte("_entities"),
+			valueType:      valueTypeUndefined,
+			hidden:         true,
+			customResolver: resolveEntities,
+		}
+		s.rootQuery.objContents.set(entitiesObj)
+	}
+
+	return nil
+}
+
+// resolveEntities resolves _entities(representations: [_Any!]!): [_Entity]!
+func resolveEntities(ctx *Ctx, dept uint8, hasSubSelection bool) bool {
+	if ctx.seekInst() != bytecode.ActionValue {
+		ctx.writeNull()
+		return ctx.err("missing representations argument")
+	}
+
+	var representationsJSON []byte
+	criticalErr := ctx.walkInputObject(func(key []byte) bool {
+		if b2s(key) != "representations" {
+			return ctx.err("undefined input: " + b2s(key))
+		}
+		var criticalErr bool
+		representationsJSON, criticalErr = ctx.inputValueToJSON(representationsJSON[:0])
+		return criticalErr
+	})
+	if criticalErr {
+		ctx.writeNull()
+		return criticalErr
+	}
+
+	hasSubSelection = ctx.seekInst() != 'e'
+	if !hasSubSelection {
+		ctx.writeNull()
+		return ctx.err("must have a selection")
+	}
+
+	representations, err := fastjson.ParseBytes(representationsJSON)
+	if err != nil {
+		ctx.writeNull()
+		return ctx.err("invalid representations, " + err.Error())
+	}
+	if representations.Type() != fastjson.TypeArray {
+		ctx.writeNull()
+		return ctx.err("representations must be a list")
+	}
+
+	ctx.writeByte('[')
+	startCharNr := ctx.charNr
+	for i, representation := range representations.GetArray() {
+		if i > 0 {
+			ctx.writeByte(',')
+		}
+		ctx.charNr = startCharNr
+
+		prefPathLen := len(ctx.path)
+		ctx.path = append(ctx.path, ',')
+		ctx.path = strconv.AppendInt(ctx.path, int64(i), 10)
+
+		criticalErr := ctx.resolveEntity(representation, dept)
+		ctx.path = ctx.path[:prefPathLen]
+		if criticalErr {
+			return criticalErr
+		}
+	}
+	ctx.writeByte(']')
+
+	return false
+}
+
+func (ctx *Ctx) resolveEntity(representation *fastjson.Value, dept uint8) bool {
+	typename := string(representation.GetStringBytes("__typename"))
+	if len(typename) == 0 {
+		ctx.writeNull()
+		ctx.err("representation is missing __typename")
+		return false
+	}
+
+	entity, ok := ctx.schema.entitiesByName[typename]
+	if !ok {
+		ctx.writeNull()
+		ctx.err(typename + " is not an entity")
+		return false
+	}
+
+	value, err := entity.Resolve(ctx, EntityRepresentation{
+		Typename: typename,
+		Raw:      representation.MarshalTo(nil),
+	})
+	if err != nil {
+		ctx.writeNull()
+		ctx.resolverErr(err)
+		return false
+	}
+
+	goValue := reflect.ValueOf(value)
+	for goValue.Kind() == reflect.Ptr || goValue.Kind() == reflect.Interface {
+		if goValue.IsNil() {
+			break
+		}
+		goValue = goValue.Elem()
+	}
+	if !goValue.IsValid() || goValue.Kind() == reflect.Ptr || goValue.Kind() == reflect.Interface {
+		// The entity resolver returned nil
+		ctx.writeNull()
+		return false
+	}
+	if goValue.Type() != entity.goType {
+		ctx.writeNull()
+		ctx.err("entity resolver of " + typename + " returned " + goValue.Type().String() + " instead of " + entity.goType.String())
+		return false
+	}
+
+	ctx.setNextGoValue(goValue)
+	criticalErr := ctx.resolveFieldDataValue(ctx.schema.types[entity.typeName], dept, true)
+	ctx.currentReflectValueIdx--
+	return criticalErr
+}
@@ -0,0 +1,245 @@
+package yarql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestFederationQ struct {
+	Me TestFederationUser
+}
+
+type TestFederationUser struct {
+	ID       string `gq:"id,ID"`
+	Name     string
+	Reviews  []TestFederationReview `gq:",provides=author { name }"`
+	Email    string                 `gq:",external"`
+	Initials func() string          `gq:",requires=name"`
+}
+
+type TestFederationReview struct {
+	Body string
+}
+
+type TestFederationProduct struct {
+	Upc   string `gq:",external"`
+	Price int
+}
+
+var testFederationUsers = map[string]TestFederationUser{
+	"1": {ID: "1", Name: "Alice"},
+	"2": {ID: "2", Name: "Bob"},
+}
+
+func registerTestFederationEntities(t *testing.T, s *Schema) {
+	err := s.RegisterEntity(TestFederationUser{}, Entity{
+		Keys: []string{"id"},
+		Resolve: func(ctx *Ctx, representation EntityRepresentation) (interface{}, error) {
+			var key struct{ ID string }
+			err := representation.Decode(&key)
+			if err != nil {
+				return nil, err
+			}
+			user, ok := testFederationUsers[key.ID]
+			if !ok {
+				return nil, nil
+			}
+			return &user, nil
+		},
+	})
+	a.NoError(t, err)
+
+	err = s.RegisterEntity(TestFederationProduct{}, Entity{
+		Keys:    []string{"upc"},
+		Extends: true,
+		Resolve: func(ctx *Ctx, representation EntityRepresentation) (interface{}, error) {
+			if strings.Contains(string(representation.Raw), "fail") {
+				return nil, errors.New("product not found")
+			}
+			var key struct{ Upc string }
+			err := representation.Decode(&key)
+			return TestFederationProduct{Upc: key.Upc, Price: 10}, err
+		},
+	})
+	a.NoError(t, err)
+}
+
+func TestFederationEntities(t *testing.T) {
+	s := NewSchema()
+	registerTestFederationEntities(t, s)
+
+	query := `query ($representations: [_Any!]!) {
+		_entities(representations: $representations) {
+			__typename
+			... on TestFederationUser {
+				id
+				name
+			}
+			... on TestFederationProduct {
+				upc
+				price
+			}
+		}
+	}`
+	res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{EnableFederation: true}, query, TestFederationQ{}, M{}, ResolveOptions{
+		NoMeta:    true,
+		Variables: `{"representations": [{"__typename": "TestFederationUser", "id": "2"}, {"__typename": "TestFederationProduct", "upc": "abc"}, {"__typename": "TestFederationUser", "id": "3"}]}`,
+	})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"_entities":[{"__typename":"TestFederationUser","id":"2","name":"Bob"},{"__typename":"TestFederationProduct","upc":"abc","price":10},null]}`, res)
+}
+
+func TestFederationEntitiesInlineRepresentations(t *testing.T) {
+	s := NewSchema()
+	registerTestFederationEntities(t, s)
+
+	query := `{
+		_entities(representations: [{__typename: "TestFederationUser", id: "1"}]) {
+			... on TestFederationUser {
+				name
+			}
+		}
+	}`
+	res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{EnableFederation: true}, query, TestFederationQ{}, M{})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"_entities":[{"name":"Alice"}]}`, res)
+}
+
+func TestFederationEntitiesErrors(t *testing.T) {
+	s := NewSchema()
+	registerTestFederationEntities(t, s)
+
+	query := `{
+		_entities(representations: [{__typename: "TestFederationProduct", upc: "fail"}, {__typename: "TestFederationReview"}, {id: "1"}]) {
+			__typename
+		}
+	}`
+	res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{EnableFederation: true}, query, TestFederationQ{}, M{})
+	a.Equal(t, 3, len(errs))
+	a.Equal(t, "product not found", errs[0].Error())
+	a.Equal(t, `"_entities",0`, string(errs[0].(ErrorWPath).path))
+	a.Equal(t, `{"_entities":[null,null,null]}`, res)
+}
+
+func TestFederationService(t *testing.T) {
+	s := NewSchema()
+	registerTestFederationEntities(t, s)
+
+	res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{EnableFederation: true}, `{_service {sdl}}`, TestFederationQ{}, M{})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.True(t, strings.HasPrefix(res, `{"_service":{"sdl":"`))
+
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, `type TestFederationUser @key(fields: "id") {`), sdl)
+	a.True(t, strings.Contains(sdl, `extend type TestFederationProduct @key(fields: "upc") {`), sdl)
+	a.True(t, strings.Contains(sdl, `upc: String! @external`), sdl)
+	a.True(t, strings.Contains(sdl, `email: String! @external`), sdl)
+	a.True(t, strings.Contains(sdl, `initials: String @requires(fields: "name")`), sdl)
+	a.True(t, strings.Contains(sdl, `reviews: [TestFederationReview!] @provides(fields: "author { name }")`), sdl)
+	a.False(t, strings.Contains(sdl, `_Service`), sdl)
+	a.False(t, strings.Contains(sdl, `_entities`), sdl)
+}
+
+func TestFederationDisabled(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterEntity(TestFederationUser{}, Entity{
+		Keys: []string{"id"},
+		Resolve: func(ctx *Ctx, representation EntityRepresentation) (interface{}, error) {
+			return nil, nil
+		},
+	}))
+	_, errs := bytecodeParse(t, s, `{_service {sdl}}`, TestFederationQ{}, M{})
+	a.Equal(t, 1, len(errs))
+}
+
+func TestRegisterEntityInvalid(t *testing.T) {
+	s := NewSchema()
+	resolve := func(ctx *Ctx, representation EntityRepresentation) (interface{}, error) { return nil, nil }
+
+	a.Error(t, s.RegisterEntity(nil, Entity{Keys: []string{"id"}, Resolve: resolve}))
+	a.Error(t, s.RegisterEntity("", Entity{Keys: []string{"id"}, Resolve: resolve}))
+	a.Error(t, s.RegisterEntity(struct{}{}, Entity{Keys: []string{"id"}, Resolve: resolve}))
+	a.Error(t, s.RegisterEntity(TestFederationUser{}, Entity{Resolve: resolve}))
+	a.Error(t, s.RegisterEntity(TestFederationUser{}, Entity{Keys: []string{" "}, Resolve: resolve}))
+	a.Error(t, s.RegisterEntity(TestFederationUser{}, Entity{Keys: []string{"id"}}))
+	a.NoError(t, s.RegisterEntity(TestFederationUser{}, Entity{Keys: []string{"id"}, Resolve: resolve}))
+}
+
+type TestFederationV2Q struct {
+	Product TestFederationV2Product
+}
+
+type TestFederationV2Product struct {
+	ID       string `gq:"id,ID"`
+	Name     string `gq:",shareable"`
+	Price    TestFederationV2Money
+	InStock  bool   `gq:",override=inventory"`
+	Internal string `gq:",inaccessible,tag=internal,tag=private"`
+}
+
+type TestFederationV2Money struct {
+	Amount int
+}
+
+type TestFederationV2Media struct {
+	ID string `gq:"id,ID"`
+}
+
+func TestFederationV2SDL(t *testing.T) {
+	resolve := func(ctx *Ctx, representation EntityRepresentation) (interface{}, error) { return nil, nil }
+
+	s := NewSchema()
+	a.NoError(t, s.RegisterEntity(TestFederationV2Product{}, Entity{Keys: []string{"id"}, Resolve: resolve}))
+	a.NoError(t, s.RegisterEntity(TestFederationV2Media{}, Entity{Keys: []string{"id"}, InterfaceObject: true, Resolve: resolve}))
+	a.NoError(t, s.RegisterTypeFederation(TestFederationV2Money{}, TypeFederation{Shareable: true, Tags: []string{"public"}}))
+
+	// FederationV2 implies EnableFederation
+	_, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{FederationV2: true}, `{_service {sdl}}`, TestFederationV2Q{}, M{})
+	a.Equal(t, 0, len(errs))
+
+	sdl := s.SDL()
+	a.True(t, strings.HasPrefix(sdl, `extend schema @link(url: "https://specs.apollo.dev/federation/v2.3"`), sdl)
+	a.True(t, strings.Contains(sdl, `type TestFederationV2Media @key(fields: "id") @interfaceObject {`), sdl)
+	a.True(t, strings.Contains(sdl, `type TestFederationV2Money @shareable @tag(name: "public") {`), sdl)
+	a.True(t, strings.Contains(sdl, `name: String! @shareable`), sdl)
+	a.True(t, strings.Contains(sdl, `inStock: Boolean! @override(from: "inventory")`), sdl)
+	a.True(t, strings.Contains(sdl, `internal: String! @inaccessible @tag(name: "internal") @tag(name: "private")`), sdl)
+}
+
+func TestFederationV2RequiresOption(t *testing.T) {
+	resolve := func(ctx *Ctx, representation EntityRepresentation) (interface{}, error) { return nil, nil }
+
+	s := NewSchema()
+	a.Error(t, s.Parse(TestFederationV2Q{}, M{}, &SchemaOptions{EnableFederation: true}))
+
+	s = NewSchema()
+	a.NoError(t, s.RegisterTypeFederation(TestFederationV2Money{}, TypeFederation{Shareable: true}))
+	a.Error(t, s.Parse(TestFederationQ{}, M{}, &SchemaOptions{EnableFederation: true}))
+
+	s = NewSchema()
+	a.NoError(t, s.RegisterEntity(TestFederationV2Media{}, Entity{Keys: []string{"id"}, InterfaceObject: true, Resolve: resolve}))
+	a.Error(t, s.Parse(TestFederationQ{}, M{}, &SchemaOptions{EnableFederation: true}))
+
+	// Without federation the directives are ignored
+	s = NewSchema()
+	a.NoError(t, s.Parse(TestFederationV2Q{}, M{}, nil))
+	a.False(t, strings.Contains(s.SDL(), "@shareable"))
+}
+
+func TestRegisterTypeFederationInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterTypeFederation(nil, TypeFederation{}))
+	a.Error(t, s.RegisterTypeFederation("", TypeFederation{}))
+	a.Error(t, s.RegisterTypeFederation(struct{}{}, TypeFederation{}))
+	a.Error(t, s.RegisterTypeFederation(TestFederationV2Money{}, TypeFederation{Tags: []string{""}}))
+	a.NoError(t, s.RegisterTypeFederation(TestFederationV2Money{}, TypeFederation{Shareable: true}))
+}
@@ -0,0 +1,348 @@
+package yarql
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// mergeField is a field of a selection set, fields of fragments included, used to check if fields with the same response key can be merged
+// - https://spec.graphql.org/October2021/#sec-Field-Selection-Merging
+type mergeField struct {
+	fieldAt        int    // res index of the field instruction
+	parentType     *obj   // type the field is selected on, nil if unknown
+	field          *obj   // nil if unknown or __typename
+	fieldType      *obj   // type of which fields can be selected on field
+	alias          []byte // the response key
+	name           []byte
+	argsStart      int // res index of the arguments, -1 = no arguments
+	argsEnd        int // res index of the end of the arguments
+	selectionStart int // res index of the selection set, -1 = no selection set
+	class          int // index of the first field within mergeFields that is equal to this field, see (*Ctx).checkFieldsCanMerge
+}
+
+// mergeArg is a argument of a field, used to compare the arguments of two fields
+type mergeArg struct {
+	key   []byte
+	value []byte
+}
+
+// checkFieldMerging checks that the fields of the operation at the current charNr with the same response key can be merged
+// The charNr is restored afterwards
+func (ctx *Ctx) checkFieldMerging() bool {
+	startCharNr := ctx.charNr
+	_, root, criticalErr := ctx.readOperation()
+	if criticalErr {
+		ctx.charNr = startCharNr
+		return criticalErr
+	}
+
+	conflict := ctx.checkSelectionSetMerging(root)
+	ctx.charNr = startCharNr
+	return conflict
+}
+
+// checkSelectionSetMerging checks the selection set at the current charNr and the selection sets of its fields
+// Returns true if a conflict was reported
+func (ctx *Ctx) checkSelectionSetMerging(typeObj *obj) bool {
+	start := len(ctx.mergeFields)
+	ctx.collectMergeFields(typeObj)
+	end := len(ctx.mergeFields)
+
+	conflict := ctx.checkFieldsCanMerge(start, false)
+	for i := start; !conflict && i < end; i++ {
+		field := ctx.mergeFields[i]
+		if field.selectionStart != -1 {
+			ctx.charNr = field.selectionStart
+			conflict = ctx.checkSelectionSetMerging(field.fieldType)
+		}
+	}
+
+	ctx.mergeFields = ctx.mergeFields[:start]
+	return conflict
+}
+
+// checkFieldsCanMerge compares all fields from start within mergeFields with the same response key
+// If parentsExclusive is true the fields can never be selected on the same object so they are allowed to have a different name or arguments
+//
+// Fields with the same response key, parent type, name and arguments are equal for this check so only the first of them is compared with the other fields,
+// the selection sets of equal fields are merged and checked once. This keeps the check linear for queries that repeat the same field many times
+func (ctx *Ctx) checkFieldsCanMerge(start int, parentsExclusive bool) bool {
+	end := len(ctx.mergeFields)
+	if end-start < 2 {
+		return false
+	}
+
+	// classes contains per response key the index of the first field of every group of equal fields
+	classes := map[string][]int{}
+	for i := start; i < end; i++ {
+		field := &ctx.mergeFields[i]
+		field.class = i
+		for _, class := range classes[b2s(field.alias)] {
+			if ctx.sameMergeClass(ctx.mergeFields[class], *field) {
+				field.class = class
+				break
+			}
+		}
+		if field.class == i {
+			classes[b2s(field.alias)] = append(classes[b2s(field.alias)], i)
+		}
+	}
+
+	for i := start; i < end; i++ {
+		if ctx.mergeFields[i].class != i {
+			continue
+		}
+		for _, j := range classes[b2s(ctx.mergeFields[i].alias)] {
+			if j < i {
+				continue
+			}
+			a := ctx.mergeFields[i]
+			b := ctx.mergeFields[j]
+
+			exclusive := parentsExclusive
+			if i != j {
+				exclusive = parentsExclusive || (a.parentType != b.parentType &&
+					a.parentType != nil && a.parentType.valueType == valueTypeObj &&
+					b.parentType != nil && b.parentType.valueType == valueTypeObj)
+				if !exclusive {
+					if !bytes.Equal(a.name, b.name) {
+						return ctx.mergeConflict(b, string(a.name)+" and "+string(b.name)+" are different fields")
+					}
+					if !ctx.sameMergeArgs(a, b) {
+						return ctx.mergeConflict(b, "they have differing arguments")
+					}
+				}
+				if a.field != nil && b.field != nil {
+					typeA := wrapQLTypeInNonNull(ctx.schema.objToQLType(a.field))
+					typeB := wrapQLTypeInNonNull(ctx.schema.objToQLType(b.field))
+					if mergeTypesConflict(typeA, typeB) {
+						return ctx.mergeConflict(b, "they return conflicting types "+qlTypeString(typeA)+" and "+qlTypeString(typeB))
+					}
+				}
+			}
+
+			// The selection sets of the fields of both groups are merged in the response
+			subStart := len(ctx.mergeFields)
+			selectionSets := 0
+			for k := start; k < end; k++ {
+				field := ctx.mergeFields[k]
+				if (field.class == i || field.class == j) && field.selectionStart != -1 {
+					ctx.charNr = field.selectionStart
+					ctx.collectMergeFields(field.fieldType)
+					selectionSets++
+				}
+			}
+			conflict := false
+			if selectionSets > 1 {
+				conflict = ctx.checkFieldsCanMerge(subStart, exclusive)
+			}
+			ctx.mergeFields = ctx.mergeFields[:subStart]
+			if conflict {
+				return conflict
+			}
+		}
+	}
+	return false
+}
+
+// sameMergeClass returns true if a and b are selected on the same type with the same name and arguments
+func (ctx *Ctx) sameMergeClass(a, b mergeField) bool {
+	if a.parentType != b.parentType || !bytes.Equal(a.name, b.name) {
+		return false
+	}
+	if a.argsStart == -1 || b.argsStart == -1 {
+		return a.argsStart == b.argsStart
+	}
+	return bytes.Equal(ctx.query.Res[a.argsStart:a.argsEnd], ctx.query.Res[b.argsStart:b.argsEnd])
+}
+
+// collectMergeFields appends the fields of the selection set at the current charNr to mergeFields
+func (ctx *Ctx) collectMergeFields(typeObj *obj) {
+	for {
+		switch ctx.readInst() {
+		case bytecode.ActionField:
+			ctx.collectMergeField(typeObj)
+		case bytecode.ActionSpread:
+			ctx.collectMergeSpread(typeObj)
+		default:
+			// End of the selection set
+			return
+		}
+	}
+}
+
+// collectMergeField appends the field at the current charNr to mergeFields
+func (ctx *Ctx) collectMergeField(typeObj *obj) {
+	fieldAt := ctx.charNr - 1
+	directivesCount := ctx.readInst()
+	fieldLen := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	nameKey := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	endOfField := ctx.charNr + int(fieldLen)
+
+	aliasLen := int(ctx.readInst())
+	alias := ctx.query.Res[ctx.charNr : ctx.charNr+aliasLen]
+	name := alias
+	ctx.skipInst(aliasLen)
+	nameLen := int(ctx.readInst())
+	if nameLen != 0 {
+		name = ctx.query.Res[ctx.charNr : ctx.charNr+nameLen]
+		ctx.skipInst(nameLen)
+	}
+	ctx.skipInst(1)
+
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.skipDirective()
+	}
+
+	var field, fieldType *obj
+	if typeObj != nil {
+		field, _ = typeObj.objContents.get(nameKey, name)
+	}
+	if field != nil && field.customResolver == nil {
+		fieldType = ctx.schema.complexityType(field)
+	}
+
+	argsStart := -1
+	argsEnd := -1
+	if ctx.seekInst() == bytecode.ActionValue {
+		argsStart = ctx.charNr
+		ctx.skipArguments()
+		argsEnd = ctx.charNr
+	}
+	selectionStart := -1
+	if ctx.seekInst() != bytecode.ActionEnd {
+		selectionStart = ctx.charNr
+	}
+
+	ctx.mergeFields = append(ctx.mergeFields, mergeField{
+		fieldAt:        fieldAt,
+		parentType:     typeObj,
+		field:          field,
+		fieldType:      fieldType,
+		alias:          alias,
+		name:           name,
+		argsStart:      argsStart,
+		argsEnd:        argsEnd,
+		selectionStart: selectionStart,
+	})
+	ctx.charNr = endOfField + 1
+}
+
+// collectMergeSpread appends the fields of the fragment spread at the current charNr to mergeFields
+func (ctx *Ctx) collectMergeSpread(typeObj *obj) {
+	isInline := ctx.readInst() == 't'
+	directivesCount := ctx.readInst()
+	lenOfSpread := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read name or on inline fragment the type name
+	}
+	name := ctx.query.Res[nameStart : ctx.charNr-1]
+	endOfSpread := nameStart + int(lenOfSpread) + 1
+
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.skipDirective()
+	}
+
+	if isInline {
+		ctx.collectMergeFields(ctx.schema.complexityFragmentType(typeObj, name))
+	} else if typeName, selectionSetAt, ok := ctx.findFragment(name); ok {
+		ctx.charNr = selectionSetAt
+		ctx.collectMergeFields(ctx.schema.complexityFragmentType(typeObj, typeName))
+	}
+
+	ctx.charNr = endOfSpread
+}
+
+// sameMergeArgs returns true if field a and b have the same arguments, the order of the arguments is ignored
+func (ctx *Ctx) sameMergeArgs(a, b mergeField) bool {
+	startCharNr := ctx.charNr
+	argsStart := len(ctx.mergeArgs)
+
+	if a.argsStart != -1 {
+		ctx.charNr = a.argsStart
+		ctx.walkInputObject(func(key []byte) bool {
+			valueLen := 6 + int(ctx.readUint32(ctx.charNr+2))
+			ctx.mergeArgs = append(ctx.mergeArgs, mergeArg{key: key, value: ctx.query.Res[ctx.charNr : ctx.charNr+valueLen]})
+			ctx.skipInst(valueLen)
+			return false
+		})
+	}
+	aArgs := ctx.mergeArgs[argsStart:]
+
+	same := true
+	bArgsLen := 0
+	if b.argsStart != -1 {
+		ctx.charNr = b.argsStart
+		ctx.walkInputObject(func(key []byte) bool {
+			valueLen := 6 + int(ctx.readUint32(ctx.charNr+2))
+			value := ctx.query.Res[ctx.charNr : ctx.charNr+valueLen]
+			bArgsLen++
+
+			found := false
+			for _, arg := range aArgs {
+				if bytes.Equal(arg.key, key) {
+					found = bytes.Equal(arg.value, value)
+					break
+				}
+			}
+			if !found {
+				same = false
+			}
+			ctx.skipInst(valueLen)
+			return false
+		})
+	}
+
+	same = same && len(aArgs) == bArgsLen
+	ctx.mergeArgs = ctx.mergeArgs[:argsStart]
+	ctx.charNr = startCharNr
+	return same
+}
+
+// mergeConflict reports that field conflicts with a earlier field with the same response key
+func (ctx *Ctx) mergeConflict(field mergeField, reason string) bool {
+	return ctx.addErrAtField(field.fieldAt, errors.New(`fields "`+string(field.alias)+`" conflict because `+reason+`, use different aliases on the fields to fetch both if this was intentional`))
+}
+
+// mergeTypesConflict returns true if values of type a and b cannot be merged in the response
+// Lists and non null types must be equal and scalars and enums must be the same type, object types may differ as their fields are compared separately
+func mergeTypesConflict(a, b *qlType) bool {
+	for a != nil && b != nil {
+		aWrapped := a.Kind == typeKindList || a.Kind == typeKindNonNull
+		bWrapped := b.Kind == typeKindList || b.Kind == typeKindNonNull
+		if aWrapped || bWrapped {
+			if a.Kind != b.Kind {
+				return true
+			}
+			a = a.OfType
+			b = b.OfType
+			continue
+		}
+
+		if a.Kind == typeKindScalar || a.Kind == typeKindEnum || b.Kind == typeKindScalar || b.Kind == typeKindEnum {
+			if a.Kind != b.Kind || (a.Name == nil) != (b.Name == nil) {
+				return true
+			}
+			return a.Name != nil && *a.Name != *b.Name
+		}
+		return false
+	}
+	return false
+}
+
+// qlTypeString returns the type as written in the schema definition language, for example [String!]
+func qlTypeString(t *qlType) string {
+	if t == nil {
+		return "Unknown"
+	}
+	var res bytes.Buffer
+	writeSDLTypeRef(&res, t)
+	return res.String()
+}
@@ -0,0 +1,102 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestFieldMergingData struct {
+	User TestFieldMergingUser
+	Pets []TestFieldMergingPet
+}
+
+type TestFieldMergingUser struct {
+	Name string
+	Age  int
+}
+
+func (TestFieldMergingUser) ResolveFriends(args struct{ First int }) []TestFieldMergingUser {
+	return []TestFieldMergingUser{{Name: "b", Age: 2}}
+}
+
+type TestFieldMergingPet interface {
+	ResolveName() string
+}
+
+type TestFieldMergingDog struct {
+	Barks bool
+}
+
+func (TestFieldMergingDog) ResolveName() string { return "dog" }
+
+type TestFieldMergingCat struct {
+	Nickname string
+	Lives    int
+}
+
+func (TestFieldMergingCat) ResolveName() string { return "cat" }
+
+func TestFieldMerging(t *testing.T) {
+	Implements((*TestFieldMergingPet)(nil), TestFieldMergingDog{})
+	Implements((*TestFieldMergingPet)(nil), TestFieldMergingCat{})
+
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestFieldMergingData{
+		User: TestFieldMergingUser{Name: "a", Age: 1},
+		Pets: []TestFieldMergingPet{TestFieldMergingDog{Barks: true}, TestFieldMergingCat{Nickname: "c", Lives: 9}},
+	}, M{}, nil))
+	s = s.Copy()
+
+	validQueries := []string{
+		`{user {name name}}`,
+		`{user {friends(first: 1) {name} ...F}} fragment F on TestFieldMergingUser {friends(first: 1) {age}}`,
+		`{pets {... on TestFieldMergingDog {x: name} ... on TestFieldMergingCat {x: nickname}}}`,
+	}
+	for _, query := range validQueries {
+		errs := s.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+		a.Equal(t, 0, len(errs), query)
+		a.Equal(t, 0, len(s.Validate(query)), query)
+	}
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{user {x: name x: age}}`, `fields "x" conflict because name and age are different fields, use different aliases on the fields to fetch both if this was intentional`},
+		{`{user {friends(first: 1) {name} friends(first: 2) {name}}}`, `fields "friends" conflict because they have differing arguments, use different aliases on the fields to fetch both if this was intentional`},
+		{`{user {friends(first: 1) {x: name}} user {friends(first: 1) {x: age}}}`, `fields "x" conflict because name and age are different fields, use different aliases on the fields to fetch both if this was intentional`},
+		{`{pets {... on TestFieldMergingDog {x: barks} ... on TestFieldMergingCat {x: lives}}}`, `fields "x" conflict because they return conflicting types Boolean! and Int!, use different aliases on the fields to fetch both if this was intentional`},
+		{`{user {x: name ...F}} fragment F on TestFieldMergingUser {x: age}`, `fields "x" conflict because name and age are different fields, use different aliases on the fields to fetch both if this was intentional`},
+		{`{pets {... on TestFieldMergingCat {x: nickname} ... on TestFieldMergingDog {x: name} ... on TestFieldMergingDog {x: barks}}}`, `fields "x" conflict because they return conflicting types String! and Boolean!, use different aliases on the fields to fetch both if this was intentional`},
+		{`{user {friends(first: 1) {name}} user {friends(first: 1) {name}} user {friends(first: 1) {name: age}}}`, `fields "name" conflict because name and age are different fields, use different aliases on the fields to fetch both if this was intentional`},
+	}
+	for _, test := range tests {
+		errs := s.Resolve([]byte(test.query), ResolveOptions{NoMeta: true})
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+		a.Equal(t, `{}`, string(s.Result), test.query)
+
+		// Validate reports the same error
+		errs = s.Validate(test.query)
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+	}
+}
+
+func TestFieldMergingRepeatedFields(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestFieldMergingData{User: TestFieldMergingUser{Name: "a", Age: 1}}, M{}, nil))
+	s = s.Copy()
+
+	// Repeated fields are compared once, this would take very long if every pair of fields was compared
+	query := "{user {" + strings.Repeat("name friends(first: 1) {name} ", 5_000) + "}}"
+	errs := s.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.True(t, strings.HasPrefix(string(s.Result), `{"user":{"name":"a","friends":[{"name":"b"}],`))
+
+	query = "{user {" + strings.Repeat("name ", 5_000) + "name: age}}"
+	errs = s.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+}
@@ -0,0 +1,111 @@
+package yarql
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"unsafe"
+
+	"github.com/mjarkk/yarql/helpers"
+)
+
+// computeFieldOffsets stores the offsets of the plain data fields of the struct type t within typeObj
+// The offsets are used to read the values using unsafe.Pointer arithmetic if yarql is build with the yarql_unsafe build tag
+// Fields that need extra handling like IDs, Int64 fields and custom scalars and fields promoted through an embedded pointer get no offset
+func computeFieldOffsets(t reflect.Type, typeObj *obj) {
+	for _, field := range typeObj.objContents.all() {
+		if len(field.goFieldName) == 0 || field.valueType != valueTypeData || field.isID || field.isInt64 || field.scalar != nil {
+			continue
+		}
+		if field.customObjValue != nil || field.customResolver != nil {
+			continue
+		}
+
+		structField, ok := field.goStructField(t)
+		if !ok {
+			continue
+		}
+		offset, ok := fieldOffset(t, structField.Index)
+		if !ok {
+			continue
+		}
+		field.structFieldOffset = offset
+		field.hasStructFieldOffset = true
+	}
+}
+
+// fieldOffset returns the offset of the field at index within t, ok is false if the field is promoted through an embedded pointer
+func fieldOffset(t reflect.Type, index []int) (offset uintptr, ok bool) {
+	for i, fieldIdx := range index {
+		field := t.Field(fieldIdx)
+		offset += field.Offset
+		if i < len(index)-1 && field.Type.Kind() == reflect.Ptr {
+			return 0, false
+		}
+		t = field.Type
+	}
+	return offset, true
+}
+
+// writeDataAtOffset writes the plain data value of field within the struct parent points to
+// Returns false if nothing is written and the value should be resolved by resolveFieldDataValue, for example because an int overflows
+func (ctx *Ctx) writeDataAtOffset(field *obj, parent unsafe.Pointer) bool {
+	ptr := unsafe.Add(parent, field.structFieldOffset)
+	switch field.dataValueType {
+	case reflect.String:
+		helpers.StringToJSON(*(*string)(ptr), &ctx.schema.Result)
+	case reflect.Bool:
+		if *(*bool)(ptr) {
+			ctx.write([]byte("true"))
+		} else {
+			ctx.write([]byte("false"))
+		}
+	case reflect.Int:
+		value := *(*int)(ptr)
+		if value > math.MaxInt32 || value < math.MinInt32 {
+			return false
+		}
+		ctx.schema.Result = strconv.AppendInt(ctx.schema.Result, int64(value), 10)
+	case reflect.Int8:
+		ctx.schema.Result = strconv.AppendInt(ctx.schema.Result, int64(*(*int8)(ptr)), 10)
+	case reflect.Int16:
+		ctx.schema.Result = strconv.AppendInt(ctx.schema.Result, int64(*(*int16)(ptr)), 10)
+	case reflect.Int32:
+		ctx.schema.Result = strconv.AppendInt(ctx.schema.Result, int64(*(*int32)(ptr)), 10)
+	case reflect.Int64:
+		value := *(*int64)(ptr)
+		if value > math.MaxInt32 || value < math.MinInt32 {
+			return false
+		}
+		ctx.schema.Result = strconv.AppendInt(ctx.schema.Result, value, 10)
+	case reflect.Uint8:
+		ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(*(*uint8)(ptr)), 10)
+	case reflect.Uint16:
+		ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(*(*uint16)(ptr)), 10)
+	case reflect.Uint:
+		value := *(*uint)(ptr)
+		if value > math.MaxInt32 {
+			return false
+		}
+		ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(value), 10)
+	case reflect.Uint32:
+		value := *(*uint32)(ptr)
+		if value > math.MaxInt32 {
+			return false
+		}
+		ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(value), 10)
+	case reflect.Uint64:
+		value := *(*uint64)(ptr)
+		if value > math.MaxInt32 {
+			return false
+		}
+		ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, value, 10)
+	case reflect.Float32:
+		helpers.FloatToJSON(32, float64(*(*float32)(ptr)), &ctx.schema.Result)
+	case reflect.Float64:
+		helpers.FloatToJSON(64, *(*float64)(ptr), &ctx.schema.Result)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,6 @@
+//go:build !yarql_unsafe
+
+package yarql
+
+// unsafeFieldOffsets enables reading plain data fields using unsafe.Pointer arithmetic, see computeFieldOffsets
+const unsafeFieldOffsets = false
@@ -0,0 +1,74 @@
+package yarql
+
+import (
+	"math"
+	"testing"
+	"unsafe"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestFieldOffsetsQ struct {
+	ID    string `gq:"id,ID"`
+	Big   int64  `gq:",Int64"`
+	Title string
+	TestFieldOffsetsEmbedded
+	Items []TestFieldOffsetsItem
+}
+
+type TestFieldOffsetsEmbedded struct {
+	Flag bool
+}
+
+type TestFieldOffsetsItem struct {
+	Small int8
+	Count int
+	Size  uint32
+	Ratio float32
+	Score float64
+}
+
+func TestComputeFieldOffsets(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestFieldOffsetsQ{}, M{}, nil))
+
+	fieldOffsets := map[string]uintptr{}
+	for _, field := range s.types["TestFieldOffsetsQ"].objContents.all() {
+		if field.hasStructFieldOffset {
+			fieldOffsets[string(field.qlFieldName)] = field.structFieldOffset
+		}
+	}
+	a.Equal(t, map[string]uintptr{
+		"title": unsafe.Offsetof(TestFieldOffsetsQ{}.Title),
+		"flag":  unsafe.Offsetof(TestFieldOffsetsQ{}.TestFieldOffsetsEmbedded) + unsafe.Offsetof(TestFieldOffsetsEmbedded{}.Flag),
+	}, fieldOffsets)
+
+	items := s.types["TestFieldOffsetsItem"].objContents.all()
+	a.Equal(t, 5, len(items))
+	for _, field := range items {
+		a.True(t, field.hasStructFieldOffset, string(field.qlFieldName))
+	}
+}
+
+func TestWriteDataAtOffset(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestFieldOffsetsQ{}, M{}, nil))
+	ctx := &Ctx{schema: s}
+
+	item := TestFieldOffsetsItem{Small: -8, Count: 42, Size: 32, Ratio: 1.5, Score: 2.25}
+	for name, expected := range map[string]string{"small": "-8", "count": "42", "size": "32", "ratio": "1.5", "score": "2.25"} {
+		s.Result = s.Result[:0]
+		field, _ := s.types["TestFieldOffsetsItem"].objContents.getByName(name)
+		a.True(t, ctx.writeDataAtOffset(field, unsafe.Pointer(&item)), name)
+		a.Equal(t, expected, string(s.Result), name)
+	}
+
+	// Values that overflow the Int scalar are left to the reflection based resolver that reports the error
+	item = TestFieldOffsetsItem{Count: math.MaxInt32 + 1, Size: math.MaxInt32 + 1}
+	for _, name := range []string{"count", "size"} {
+		s.Result = s.Result[:0]
+		field, _ := s.types["TestFieldOffsetsItem"].objContents.getByName(name)
+		a.False(t, ctx.writeDataAtOffset(field, unsafe.Pointer(&item)), name)
+		a.Equal(t, "", string(s.Result), name)
+	}
+}
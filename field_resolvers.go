@@ -0,0 +1,118 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// fieldResolver is a function registered as field on a type
+type fieldResolver struct {
+	name string
+	fn   reflect.Value
+}
+
+// RegisterFieldResolver adds a field with the name fieldName resolved by fn to goType
+// This allows adding computed fields to types you do not own, like generated ORM models
+// The first argument of fn is the parent value (SomeType or *SomeType),
+// the other arguments and the return values follow the same rules as methods defined on structs
+//
+// Example:
+//
+//	s.RegisterFieldResolver(User{}, "fullName", func(parent User, ctx *yarql.Ctx) (string, error) {
+//		return parent.FirstName + " " + parent.LastName, nil
+//	})
+func (s *Schema) RegisterFieldResolver(goType interface{}, fieldName string, fn interface{}) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterFieldResolver() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t := reflect.TypeOf(goType)
+	if t.Kind() != reflect.Struct {
+		return errors.New("can only register field resolvers on struct types")
+	}
+	if t.Name() == "" {
+		return errors.New("cannot register field resolvers on an inline type")
+	}
+
+	err := validGraphQlName([]byte(fieldName))
+	if err != nil {
+		return errors.New(fieldName + " is not a valid graphql field name")
+	}
+
+	if fn == nil {
+		return errors.New("fn cannot be nil")
+	}
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func {
+		return errors.New("fn must be a function")
+	}
+	if fnValue.IsNil() {
+		return errors.New("fn cannot be nil")
+	}
+	fnType := fnValue.Type()
+	if fnType.NumIn() == 0 || (fnType.In(0) != t && fnType.In(0) != reflect.PtrTo(t)) {
+		return errors.New("the first argument of fn must be of type " + t.Name() + " or *" + t.Name())
+	}
+
+	for _, resolver := range s.fieldResolvers[t] {
+		if resolver.name == fieldName {
+			return errors.New("field resolver " + fieldName + " is already registered on " + t.Name())
+		}
+	}
+
+	s.fieldResolvers[t] = append(s.fieldResolvers[t], fieldResolver{
+		name: fieldName,
+		fn:   fnValue,
+	})
+	return nil
+}
+
+// checkFieldResolvers adds the field resolvers registered on t to res
+func (c *parseCtx) checkFieldResolvers(t reflect.Type, res *obj) error {
+	resolvers := c.schema.fieldResolvers[t]
+	for idx := range resolvers {
+		resolver := &resolvers[idx]
+
+		if _, ok := res.objContents.getByName(resolver.name); ok {
+			return errors.New("cannot register field resolver " + resolver.name + ", field already defined on " + res.typeName)
+		}
+
+		fnType := resolver.fn.Type()
+		methodObj, _, isID, err := c.checkFunction(resolver.name, fnType, false, false)
+		if err != nil {
+			return err
+		}
+
+		// The parent input is not an argument of the field, by marking the method as type method checkFunctionIns will skip it
+		methodObj.isTypeMethod = true
+		methodObj.parentInput = true
+		methodObj.parentInputIsPtr = fnType.In(0).Kind() == reflect.Ptr
+
+		res.objContents.set(&obj{
+			qlFieldName:    []byte(resolver.name),
+			valueType:      valueTypeMethod,
+			method:         methodObj,
+			customObjValue: &resolver.fn,
+			structFieldIdx: -1,
+			isID:           isID,
+		})
+	}
+	return nil
+}
+
+// parentInput returns the parent value of the method that is currently being resolved
+func (ctx *Ctx) parentInput(method *objMethod) reflect.Value {
+	parent := ctx.reflectValues[ctx.currentReflectValueIdx-1]
+	if !method.parentInputIsPtr {
+		return parent
+	}
+	if parent.CanAddr() {
+		return parent.Addr()
+	}
+	ptr := reflect.New(parent.Type())
+	ptr.Elem().Set(parent)
+	return ptr
+}
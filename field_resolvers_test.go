@@ -0,0 +1,87 @@
+package yarql
+
+import (
+	"errors"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+// TestFieldResolversModel mimics a type we do not own, like a generated ORM model
+type TestFieldResolversModel struct {
+	FirstName string
+	LastName  string
+}
+
+type TestFieldResolversQ struct {
+	User  TestFieldResolversModel
+	Users []TestFieldResolversModel
+}
+
+func TestRegisterFieldResolver(t *testing.T) {
+	s := NewSchema()
+	err := s.RegisterFieldResolver(TestFieldResolversModel{}, "fullName", func(parent TestFieldResolversModel, ctx *Ctx) (string, error) {
+		a.NotNil(t, ctx)
+		return parent.FirstName + " " + parent.LastName, nil
+	})
+	a.NoError(t, err)
+	err = s.RegisterFieldResolver(TestFieldResolversModel{}, "greet", func(parent *TestFieldResolversModel, args struct{ Greeting string }) string {
+		return args.Greeting + " " + parent.FirstName
+	})
+	a.NoError(t, err)
+
+	queries := TestFieldResolversQ{
+		User: TestFieldResolversModel{FirstName: "John", LastName: "Doe"},
+		Users: []TestFieldResolversModel{
+			{FirstName: "A", LastName: "B"},
+			{FirstName: "C", LastName: "D"},
+		},
+	}
+
+	res, errs := bytecodeParse(t, s, `{
+		user {firstName fullName greet(greeting: "Hello")}
+		users {fullName}
+	}`, queries, M{})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"user":{"firstName":"John","fullName":"John Doe","greet":"Hello John"},"users":[{"fullName":"A B"},{"fullName":"C D"}]}`, res)
+
+	s = NewSchema()
+	err = s.RegisterFieldResolver(TestFieldResolversModel{}, "fails", func(parent TestFieldResolversModel) (string, error) {
+		return "", errors.New("this field failed")
+	})
+	a.NoError(t, err)
+	_, errs = bytecodeParse(t, s, `{user {fails}}`, queries, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "this field failed", errs[0].Error())
+}
+
+func TestRegisterFieldResolverInvalid(t *testing.T) {
+	s := NewSchema()
+	fn := func(parent TestFieldResolversModel) string { return "" }
+
+	a.Error(t, s.RegisterFieldResolver(nil, "foo", fn))
+	a.Error(t, s.RegisterFieldResolver("not a struct", "foo", fn))
+	a.Error(t, s.RegisterFieldResolver(struct{}{}, "foo", fn))
+	a.Error(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "", fn))
+	a.Error(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "foo", nil))
+	a.Error(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "foo", "not a function"))
+	a.Error(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "foo", func() string { return "" }))
+	a.Error(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "foo", func(parent TestFieldResolversQ) string { return "" }))
+
+	a.NoError(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "foo", fn))
+	a.Error(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "foo", fn))
+}
+
+func TestRegisterFieldResolverAlreadyDefined(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "firstName", func(parent TestFieldResolversModel) string { return "" }))
+	a.Error(t, s.Parse(TestFieldResolversQ{}, M{}, nil))
+}
+
+func TestRegisterFieldResolverAfterParse(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestFieldResolversQ{}, M{}, nil))
+	a.Error(t, s.RegisterFieldResolver(TestFieldResolversModel{}, "foo", func(parent TestFieldResolversModel) string { return "" }))
+}
@@ -0,0 +1,46 @@
+package yarql
+
+// FieldVisibility decides per request if a field is part of the schema, see (yarql.SchemaOptions).FieldVisibility
+type FieldVisibility func(ctx *Ctx, typeName, fieldName string) bool
+
+// fieldVisible returns false if the field is hidden for this request by (yarql.SchemaOptions).FieldVisibility or not part of the schema view
+// Introspection fields are always visible
+func (ctx *Ctx) fieldVisible(typeObj *obj, field *obj) bool {
+	if !ctx.schema.inView(field.scope) {
+		return false
+	}
+	if ctx.schema.fieldVisibility == nil || ctx.introspecting || field.hidden {
+		return true
+	}
+	return ctx.schema.fieldVisibility(ctx, typeObj.typeName, string(field.qlFieldName))
+}
+
+// selectableField returns the field of typeObj with name, false if the field does not exist or is hidden for this request
+// Hidden fields are handled as if they don't exist, this is used by both the default executor and the Engine
+func (ctx *Ctx) selectableField(typeObj *obj, nameKey uint32, name []byte) (*obj, bool) {
+	field, ok := typeObj.objContents.get(nameKey, name)
+	if !ok || !ctx.fieldVisible(typeObj, field) {
+		return nil, false
+	}
+	return field, true
+}
+
+// visibleQLFields returns the introspection fields of typeName that are visible for this request
+// All fields are returned if there is no ctx, this is the case for the SDL
+func (ctx *Ctx) visibleQLFields(typeName string, fields []qlField) []qlField {
+	if ctx == nil {
+		return fields
+	}
+	fields = ctx.schema.viewQLFields(fields)
+	if ctx.schema.fieldVisibility == nil {
+		return fields
+	}
+
+	res := make([]qlField, 0, len(fields))
+	for _, field := range fields {
+		if ctx.schema.fieldVisibility(ctx, typeName, field.Name) {
+			res = append(res, field)
+		}
+	}
+	return res
+}
@@ -0,0 +1,69 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestFieldVisibilityQuery struct {
+	Name   string
+	Secret string
+	User   TestFieldVisibilityUser
+}
+
+type TestFieldVisibilityUser struct {
+	Name  string
+	Email string
+}
+
+var testFieldVisibilityData = TestFieldVisibilityQuery{Name: "a", Secret: "b", User: TestFieldVisibilityUser{Name: "c", Email: "d"}}
+
+var testFieldVisibilityOptions = &SchemaOptions{
+	FieldVisibility: func(ctx *Ctx, typeName, fieldName string) bool {
+		if ctx.GetValue("admin") == true {
+			return true
+		}
+		return !(typeName == "TestFieldVisibilityQuery" && fieldName == "secret") &&
+			!(typeName == "TestFieldVisibilityUser" && fieldName == "email")
+	},
+}
+
+func TestFieldVisibility(t *testing.T) {
+	res, errs := bytecodeParseWithOptions(t, NewSchema(), testFieldVisibilityOptions, `{name user {name}}`, testFieldVisibilityData, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"a","user":{"name":"c"}}`, res)
+
+	_, errs = bytecodeParseWithOptions(t, NewSchema(), testFieldVisibilityOptions, `{secret}`, testFieldVisibilityData, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "secret does not exists on TestFieldVisibilityQuery", errs[0].Error())
+
+	_, errs = bytecodeParseWithOptions(t, NewSchema(), testFieldVisibilityOptions, `{user {email}}`, testFieldVisibilityData, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "email does not exists on TestFieldVisibilityUser", errs[0].Error())
+
+	res, errs = bytecodeParseWithOptions(t, NewSchema(), testFieldVisibilityOptions, `{secret user {email}}`, testFieldVisibilityData, M{}, ResolveOptions{NoMeta: true, Values: &map[string]interface{}{"admin": true}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"secret":"b","user":{"email":"d"}}`, res)
+}
+
+func TestFieldVisibilityIntrospection(t *testing.T) {
+	query := `{
+		query: __type(name: "TestFieldVisibilityQuery") {fields {name}}
+		user: __type(name: "TestFieldVisibilityUser") {fields {name}}
+	}`
+
+	s := NewSchema()
+	res, errs := bytecodeParseWithOptions(t, s, testFieldVisibilityOptions, query, testFieldVisibilityData, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"query":{"fields":[{"name":"name"},{"name":"user"}]},"user":{"fields":[{"name":"name"}]}}`, res)
+	a.Equal(t, 0, len(s.precomputed))
+
+	// The SDL contains all fields
+	a.True(t, strings.Contains(s.SDL(), "\tsecret: String!\n"))
+
+	res, errs = bytecodeParseWithOptions(t, NewSchema(), testFieldVisibilityOptions, query, testFieldVisibilityData, M{}, ResolveOptions{NoMeta: true, Values: &map[string]interface{}{"admin": true}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"query":{"fields":[{"name":"name"},{"name":"secret"},{"name":"user"}]},"user":{"fields":[{"name":"email"},{"name":"name"}]}}`, res)
+}
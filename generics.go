@@ -0,0 +1,115 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// qlTypeName returns the graphql type name of the named struct or interface t
+func (c *parseCtx) qlTypeName(t reflect.Type) (string, error) {
+	goName := t.Name()
+	if newName, ok := renamedTypes[goName]; ok {
+		return newName, nil
+	}
+
+	if namer, ok := reflect.Zero(t).Interface().(typeNamer); ok {
+		return namer.qlTypeName(c)
+	}
+
+	if strings.IndexByte(goName, '[') == -1 {
+		return goName, nil
+	}
+
+	name, ok := qlGenericTypeName(goName)
+	if !ok || validGraphQlName([]byte(name)) != nil {
+		return "", errors.New("cannot create a graphql type name for " + goName + ", use TypeRename to name this type")
+	}
+	return name, nil
+}
+
+// qlGenericTypeName converts the go name of an instantiated generic type into a graphql type name
+// The names of the type arguments are prefixed to the name of the type, for example:
+//
+//	List[github.com/foo/bar.User] -> UserList
+//	Pair[bar.User,bar.Post]       -> UserPostPair
+//	List[[]*bar.User]             -> UserListList
+func qlGenericTypeName(goName string) (string, bool) {
+	typeArgsStart := strings.IndexByte(goName, '[')
+	if typeArgsStart == -1 || goName[len(goName)-1] != ']' {
+		return "", false
+	}
+
+	res := ""
+	for _, typeArg := range splitTypeArgs(goName[typeArgsStart+1 : len(goName)-1]) {
+		name, ok := qlTypeArgName(typeArg)
+		if !ok {
+			return "", false
+		}
+		res += name
+	}
+	return res + goName[:typeArgsStart], true
+}
+
+// qlTypeArgName returns the graphql name of a type argument within a generic type name
+func qlTypeArgName(typeArg string) (string, bool) {
+	switch {
+	case strings.HasPrefix(typeArg, "*"):
+		return qlTypeArgName(typeArg[1:])
+	case strings.HasPrefix(typeArg, "[]"):
+		name, ok := qlTypeArgName(typeArg[2:])
+		return name + "List", ok
+	case strings.HasPrefix(typeArg, "map["), strings.HasPrefix(typeArg, "func("), strings.HasPrefix(typeArg, "chan "), strings.HasPrefix(typeArg, "["):
+		return "", false
+	}
+
+	// Remove the package path, note that the type arguments of a generic type argument can also contain package paths
+	nameEnd := strings.IndexByte(typeArg, '[')
+	isGeneric := nameEnd != -1
+	if !isGeneric {
+		nameEnd = len(typeArg)
+	}
+	if pkgEnd := strings.LastIndexByte(typeArg[:nameEnd], '.'); pkgEnd != -1 {
+		typeArg = typeArg[pkgEnd+1:]
+	}
+
+	if newName, ok := renamedTypes[typeArg]; ok {
+		return newName, true
+	}
+	if isGeneric {
+		return qlGenericTypeName(typeArg)
+	}
+
+	switch typeArg {
+	case "string":
+		return "String", true
+	case "bool":
+		return "Boolean", true
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return "Int", true
+	case "float32", "float64":
+		return "Float", true
+	}
+	return strings.ToUpper(typeArg[:1]) + typeArg[1:], true
+}
+
+// splitTypeArgs splits the type arguments of a generic type name on the top level commas
+func splitTypeArgs(typeArgs string) []string {
+	res := []string{}
+	depth := 0
+	start := 0
+	for idx, c := range typeArgs {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				res = append(res, strings.TrimSpace(typeArgs[start:idx]))
+				start = idx + 1
+			}
+		}
+	}
+	return append(res, strings.TrimSpace(typeArgs[start:]))
+}
@@ -0,0 +1,108 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestGenericsList[T any] struct {
+	Items []T
+	Count int
+}
+
+type TestGenericsPair[A any, B any] struct {
+	First  A
+	Second B
+}
+
+type TestGenericsUser struct {
+	Name string
+}
+
+type TestGenericsPost struct {
+	Title string
+}
+
+type TestGenericsRenamed[T any] struct {
+	Value T
+}
+
+var _ = TypeRename(TestGenericsRenamed[string]{}, "RenamedString")
+
+type TestGenericsQ struct {
+	Users      TestGenericsList[TestGenericsUser]
+	Posts      TestGenericsList[TestGenericsPost]
+	Names      TestGenericsList[string]
+	UserLists  TestGenericsList[[]TestGenericsUser]
+	Nested     TestGenericsList[TestGenericsList[TestGenericsUser]]
+	Pair       TestGenericsPair[TestGenericsUser, TestGenericsPost]
+	Renamed    TestGenericsRenamed[string]
+	RenamedInt TestGenericsRenamed[int]
+}
+
+func TestQlGenericTypeName(t *testing.T) {
+	cases := map[string]string{
+		"List[github.com/foo/bar.User]":                          "UserList",
+		"List[*github.com/foo/bar.User]":                         "UserList",
+		"List[[]github.com/foo/bar.User]":                        "UserListList",
+		"Pair[github.com/foo/bar.User,gopkg.in/yaml.v3.Node]":    "UserNodePair",
+		"List[github.com/foo/bar.Page[github.com/foo/bar.User]]": "UserPageList",
+		"Box[string]":  "StringBox",
+		"Box[float64]": "FloatBox",
+		"Box[uint8]":   "IntBox",
+	}
+	for goName, expected := range cases {
+		name, ok := qlGenericTypeName(goName)
+		a.True(t, ok, goName)
+		a.Equal(t, expected, name, goName)
+	}
+
+	for _, goName := range []string{"Box[map[string]int]", "Box[func()]", "Box[[2]int]", "Box"} {
+		_, ok := qlGenericTypeName(goName)
+		a.False(t, ok, goName)
+	}
+}
+
+func TestGenericTypes(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestGenericsQ{}, M{}, nil))
+
+	sdl := s.SDL()
+	for _, expected := range []string{
+		"type TestGenericsUserTestGenericsList {\n\tcount: Int!\n\titems: [TestGenericsUser!]\n}",
+		"type TestGenericsPostTestGenericsList {",
+		"type StringTestGenericsList {\n\tcount: Int!\n\titems: [String!]\n}",
+		"type TestGenericsUserListTestGenericsList {",
+		"type TestGenericsUserTestGenericsListTestGenericsList {",
+		"type TestGenericsUserTestGenericsPostTestGenericsPair {",
+		"type RenamedString {",
+		"type IntTestGenericsRenamed {",
+		"users: TestGenericsUserTestGenericsList!",
+	} {
+		a.True(t, strings.Contains(sdl, expected), expected+"\n"+sdl)
+	}
+
+	queries := TestGenericsQ{
+		Users: TestGenericsList[TestGenericsUser]{Items: []TestGenericsUser{{Name: "a"}}, Count: 1},
+		Pair:  TestGenericsPair[TestGenericsUser, TestGenericsPost]{First: TestGenericsUser{Name: "b"}, Second: TestGenericsPost{Title: "c"}},
+	}
+	res := bytecodeParseAndExpectNoErrs(t, `{users {count items {name}} pair {first {name} second {title}}}`, queries, M{})
+	a.Equal(t, `{"users":{"count":1,"items":[{"name":"a"}]},"pair":{"first":{"name":"b"},"second":{"title":"c"}}}`, res)
+}
+
+type TestGenericsCollidingQ struct {
+	Users    TestGenericsList[TestGenericsUser]
+	UserPtrs TestGenericsList[*TestGenericsUser]
+}
+
+type TestGenericsInvalidQ struct {
+	Map TestGenericsRenamed[map[string]int]
+}
+
+func TestGenericTypesInvalid(t *testing.T) {
+	// List[User] and List[*User] have the same graphql name but are different go types
+	a.Error(t, NewSchema().Parse(TestGenericsCollidingQ{}, M{}, nil))
+	a.Error(t, NewSchema().Parse(TestGenericsInvalidQ{}, M{}, nil))
+}
@@ -1,5 +1,5 @@
 module github.com/mjarkk/yarql
 
-go 1.16
+go 1.18
 
 require github.com/valyala/fastjson v1.6.3
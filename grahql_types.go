@@ -12,7 +12,8 @@ import (
 var _ = TypeRename(qlSchema{}, "__Schema", true)
 
 type qlSchema struct {
-	Types func() []qlType `json:"-"`
+	Description *string                 `json:"description"`
+	Types       func(ctx *Ctx) []qlType `json:"-"`
 	// For testing perposes mainly
 	JSONTypes []qlType `json:"types" gq:"-"`
 
@@ -82,7 +83,7 @@ type qlType struct {
 	Description *string    `json:"description"`
 
 	// OBJECT and INTERFACE only
-	Fields func(isDeprecatedArgs) []qlField `json:"-"`
+	Fields func(ctx *Ctx, args isDeprecatedArgs) []qlField `json:"-"`
 
 	// OBJECT only
 	Interfaces []qlType `json:"interfaces"`
@@ -95,6 +96,7 @@ type qlType struct {
 
 	// INPUT_OBJECT only
 	InputFields func() []qlInputValue `json:"-"`
+	IsOneOf     *bool                 `json:"isOneOf"`
 
 	// NON_NULL and LIST only
 	OfType *qlType `json:"ofType"`
@@ -117,6 +119,9 @@ type qlField struct {
 	Type              qlType         `json:"type"`
 	IsDeprecated      bool           `json:"isDeprecated"`
 	DeprecationReason *string        `json:"deprecationReason"`
+
+	// The scope of the field, see (*Schema).View
+	scope string `json:"-" gq:"-"`
 }
 
 var _ = TypeRename(qlEnumValue{}, "__EnumValue", true)
@@ -158,6 +163,7 @@ const (
 	directiveLocationEnumValue
 	directiveLocationInputObject
 	directiveLocationInputFieldDefinition
+	directiveLocationVariableDefinition
 )
 
 var directiveLocationMap = map[string]__DirectiveLocation{
@@ -179,6 +185,7 @@ var directiveLocationMap = map[string]__DirectiveLocation{
 	"ENUM_VALUE":             directiveLocationEnumValue,
 	"INPUT_OBJECT":           directiveLocationInputObject,
 	"INPUT_FIELD_DEFINITION": directiveLocationInputFieldDefinition,
+	"VARIABLE_DEFINITION":    directiveLocationVariableDefinition,
 }
 
 var _ = TypeRename(qlDirective{}, "__Directive", true)
@@ -189,6 +196,7 @@ type qlDirective struct {
 	Locations     []__DirectiveLocation `json:"-"`
 	JSONLocations []string              `json:"locations" gq:"-"`
 	Args          []qlInputValue        `json:"args"`
+	IsRepeatable  bool                  `json:"isRepeatable"`
 }
 
 var (
@@ -217,6 +225,11 @@ var (
 		Name:        h.StrPtr("ID"),
 		Description: h.StrPtr("The ID scalar type represents a unique identifier, often used to refetch an object or as the key for a cache"),
 	}
+	scalarInt64 = qlType{
+		Kind:        typeKindScalar,
+		Name:        h.StrPtr("Int64"),
+		Description: h.StrPtr("The Int64 scalar type represents a signed 64‐bit numeric non‐fractional value. Values are send as strings as JSON numbers cannot precisely represent all 64‐bit values"),
+	}
 	scalarFile = qlType{
 		Kind:           typeKindScalar,
 		Name:           h.StrPtr("File"),
@@ -237,6 +250,7 @@ var scalars = map[string]qlType{
 	"Float":   scalarFloat,
 	"String":  scalarString,
 	"ID":      scalarID,
+	"Int64":   scalarInt64,
 	"File":    scalarFile,
 	"Time":    scalarTime,
 }
@@ -0,0 +1,87 @@
+package yarql
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+	zlibWriters = sync.Pool{New: func() interface{} { return zlib.NewWriter(nil) }}
+)
+
+// compressionWriter is a gzip or zlib writer that can be reused
+type compressionWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// negotiateEncoding returns the content encoding to compress the response with based on the Accept-Encoding header
+// gzip is preferred over deflate, returns an empty string if the client accepts neither
+func negotiateEncoding(acceptEncoding string) string {
+	gzipQuality := -1.0
+	deflateQuality := -1.0
+	wildcardQuality := -1.0
+
+	for _, entry := range strings.Split(acceptEncoding, ",") {
+		encoding := strings.TrimSpace(entry)
+		quality := 1.0
+		if idx := strings.IndexByte(encoding, ';'); idx != -1 {
+			param := strings.TrimSpace(encoding[idx+1:])
+			encoding = strings.TrimSpace(encoding[:idx])
+			if strings.HasPrefix(param, "q=") {
+				parsed, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		switch strings.ToLower(encoding) {
+		case "gzip":
+			gzipQuality = quality
+		case "deflate":
+			deflateQuality = quality
+		case "*":
+			wildcardQuality = quality
+		}
+	}
+
+	if gzipQuality == -1 {
+		gzipQuality = wildcardQuality
+	}
+	if deflateQuality == -1 {
+		deflateQuality = wildcardQuality
+	}
+
+	if gzipQuality > 0 && gzipQuality >= deflateQuality {
+		return "gzip"
+	}
+	if deflateQuality > 0 {
+		return "deflate"
+	}
+	return ""
+}
+
+// writeCompressed writes response compressed with encoding directly to w
+func writeCompressed(w http.ResponseWriter, encoding string, status int, response []byte) {
+	pool := &gzipWriters
+	if encoding == "deflate" {
+		pool = &zlibWriters
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+	w.WriteHeader(status)
+
+	writer := pool.Get().(compressionWriter)
+	writer.Reset(w)
+	writer.Write(response)
+	writer.Close()
+	writer.Reset(nil)
+	pool.Put(writer)
+}
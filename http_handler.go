@@ -0,0 +1,348 @@
+package yarql
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// HTTPHandlerOptions are options for yarql.HTTPHandler
+type HTTPHandlerOptions struct {
+	Tracing            bool                                         // https://github.com/apollographql/apollo-tracing
+	MaxBodySize        int64                                        // Max size of the request body in bytes, default 10MB
+	MaxMultipartMemory int64                                        // Max amount of bytes of multipart form files stored in memory, the rest is stored on disk, default 32MB
+	Values             func(r *http.Request) map[string]interface{} // Returns the values passed to the request context
+	MaxFileSize        int64                                        // Max size in bytes of a uploaded file, 0 = no limit
+	MaxUploadSize      int64                                        // Max size in bytes of all uploaded files together, 0 = no limit
+	StreamUploads      bool                                         // Stream multipart files to *yarql.Upload inputs instead of buffering the form, *multipart.FileHeader inputs are not supported
+	CompressMinSize    int                                          // Responses of at least this amount of bytes are compressed using gzip or deflate if the client accepts it, 0 = no compression
+	RequestID          func(r *http.Request) string                 // Returns the ID of the request, for example from the X-Request-ID header, see (*yarql.Ctx).SetRequestID
+}
+
+// HTTPHandler returns a http.Handler that resolves GraphQL requests using (*yarql.Schema).HandleRequest
+// Queries can be send using url parameters (GET), a json body or a multipart form for file uploads (POST)
+// The handler is safe for concurrent use as requests are resolved using copies of the schema, see (*yarql.Schema).Exec
+func HTTPHandler(s *Schema, opts *HTTPHandlerOptions) http.Handler {
+	if !s.parsed {
+		panic("Schema has not been parsed yet, call Parse before creating a http handler")
+	}
+
+	options := HTTPHandlerOptions{}
+	if opts != nil {
+		options = *opts
+	}
+	if options.MaxBodySize == 0 {
+		options.MaxBodySize = 10 << 20
+	}
+	if options.MaxMultipartMemory == 0 {
+		options.MaxMultipartMemory = 32 << 20
+	}
+
+	return &httpHandler{
+		schema:  s,
+		options: options,
+	}
+}
+
+type httpHandler struct {
+	schema  *Schema
+	options HTTPHandlerOptions
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType := ""
+	body := &limitedBody{ReadCloser: r.Body, remaining: h.options.MaxBodySize}
+	if r.Method == http.MethodPost {
+		var err error
+		contentType, _, err = mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			contentType = ""
+		}
+		r.Body = body
+	}
+
+	query := r.URL.Query()
+	var formErr error
+	formParsed := false
+	getForm := func() (*multipart.Form, error) {
+		if !formParsed {
+			formParsed = true
+			formErr = r.ParseMultipartForm(h.options.MaxMultipartMemory)
+		}
+		return r.MultipartForm, formErr
+	}
+	defer func() {
+		if r.MultipartForm != nil {
+			r.MultipartForm.RemoveAll()
+		}
+	}()
+
+	getFormField := func(key string) (string, error) {
+		form, err := getForm()
+		if err != nil {
+			return "", err
+		}
+		values := form.Value[key]
+		if len(values) == 0 {
+			return "", nil
+		}
+		return values[0], nil
+	}
+
+	requestOptions := &RequestOptions{
+		Context: r.Context(),
+		GetFormFile: func(key string) (*multipart.FileHeader, error) {
+			form, err := getForm()
+			if err != nil {
+				return nil, err
+			}
+			files := form.File[key]
+			if len(files) == 0 {
+				return nil, nil
+			}
+			return files[0], nil
+		},
+		Tracing:       h.options.Tracing,
+		Accept:        r.Header.Get("Accept"),
+		Header:        r.Header,
+		RemoteAddr:    r.RemoteAddr,
+		MaxFileSize:   h.options.MaxFileSize,
+		MaxUploadSize: h.options.MaxUploadSize,
+	}
+	if h.options.Values != nil {
+		requestOptions.Values = h.options.Values(r)
+	}
+	if h.options.RequestID != nil {
+		requestOptions.RequestID = h.options.RequestID(r)
+	}
+
+	if h.options.StreamUploads && contentType == "multipart/form-data" {
+		reader, err := r.MultipartReader()
+		stream := &multipartStream{reader: reader, err: err, fields: map[string]string{}, buffered: map[string]*Upload{}, maxFileSize: h.options.MaxFileSize, maxUploadSize: h.options.MaxUploadSize}
+		getFormField = stream.formField
+		requestOptions.GetUpload = stream.upload
+		requestOptions.GetFormFile = func(key string) (*multipart.FileHeader, error) {
+			return nil, errors.New("uploads are streamed, use *yarql.Upload instead of *multipart.FileHeader")
+		}
+	}
+
+	h.schema.ServeHTTPRequestResult(HTTPRequest{
+		Method:       r.Method,
+		GetQuery:     query.Get,
+		GetFormField: getFormField,
+		GetBody: func() []byte {
+			requestBody, err := io.ReadAll(r.Body)
+			if err != nil {
+				return nil
+			}
+			return requestBody
+		},
+		ContentType: contentType,
+		Options:     requestOptions,
+	}, func(result HTTPResult) {
+		status := result.Status
+		response := result.Body
+		if body.exceeded {
+			status = http.StatusRequestEntityTooLarge
+		}
+		for key, values := range result.Header {
+			w.Header()[key] = values
+		}
+		if h.options.CompressMinSize > 0 {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if len(response) >= h.options.CompressMinSize {
+				encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+				if len(encoding) > 0 {
+					// Compress directly from the result buffer of the schema
+					writeCompressed(w, encoding, status, response)
+					return
+				}
+			}
+		}
+		w.WriteHeader(status)
+		w.Write(response)
+	})
+}
+
+// HTTPRequest describes a http request resolved by (*yarql.Schema).ServeHTTPRequest
+// It can be used to build a handler for http frameworks, see yarql.HTTPHandler for a example
+// All functions must be set
+type HTTPRequest struct {
+	Method       string                           // GET or POST, other methods are rejected
+	GetQuery     func(key string) string          // URL value (needs to be un-escaped before returning)
+	GetFormField func(key string) (string, error) // get form field, only used if content type == form data
+	GetBody      func() []byte                    // get the request body
+	ContentType  string                           // the media type of the body without parameters like the charset
+	Options      *RequestOptions                  // optional options
+}
+
+// ServeHTTPRequest resolves a http request using a copy of the schema borrowed from a internal pool so it's safe for concurrent use
+// respond is called with the status code, content type and json response, the response is only valid during the call
+// The status codes are equal to (*yarql.Schema).HandleRequestResult, use ServeHTTPRequestResult to also get the response headers
+func (s *Schema) ServeHTTPRequest(req HTTPRequest, respond func(status int, contentType string, response []byte)) {
+	s.ServeHTTPRequestResult(req, func(result HTTPResult) {
+		respond(result.Status, result.ContentType, result.Body)
+	})
+}
+
+// ServeHTTPRequestResult is equal to ServeHTTPRequest but respond is called with the result of (*yarql.Schema).HandleRequestResult
+// The body and errors of the result are only valid during the call
+func (s *Schema) ServeHTTPRequestResult(req HTTPRequest, respond func(result HTTPResult)) {
+	pool := s.Current().pool
+	c := pool.Get().(*Schema)
+	respond(c.HandleRequestResult(req.Method, req.GetQuery, req.GetFormField, req.GetBody, req.ContentType, req.Options))
+	c.release()
+	pool.Put(c)
+}
+
+// limitedBody is a request body that returns an error after reading more than remaining bytes
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	exceeded  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Only report an error if there is actually more data
+		var next [1]byte
+		n, err := b.ReadCloser.Read(next[:])
+		if n == 0 {
+			return 0, err
+		}
+		b.exceeded = true
+		return 0, errors.New("request body too large")
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// multipartStream reads the parts of a multipart body in order so files can be streamed to *yarql.Upload inputs
+// The graphql-multipart-request-spec requires the operations and map fields to be send before the files
+// Files read before they are requested are buffered in memory
+type multipartStream struct {
+	reader        *multipart.Reader
+	err           error
+	fields        map[string]string
+	buffered      map[string]*Upload
+	bufferedSize  int64 // size of all files buffered so far
+	maxFileSize   int64 // max size of a buffered file, 0 = no limit
+	maxUploadSize int64 // max size of all buffered files together, 0 = no limit
+}
+
+// next reads the next part, non file parts are stored in fields
+// Returns nil if there are no more parts
+func (m *multipartStream) next() (*multipart.Part, error) {
+	for {
+		if m.err != nil {
+			if m.err == io.EOF {
+				return nil, nil
+			}
+			return nil, m.err
+		}
+
+		part, err := m.reader.NextPart()
+		if err != nil {
+			m.err = err
+			continue
+		}
+		if part.FileName() != "" {
+			return part, nil
+		}
+
+		value, err := io.ReadAll(part)
+		if err != nil {
+			m.err = err
+			continue
+		}
+		m.fields[part.FormName()] = string(value)
+	}
+}
+
+func (m *multipartStream) formField(key string) (string, error) {
+	for {
+		value, ok := m.fields[key]
+		if ok {
+			return value, nil
+		}
+
+		part, err := m.next()
+		if err != nil || part == nil {
+			return "", err
+		}
+		err = m.buffer(part)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (m *multipartStream) upload(key string) (*Upload, error) {
+	upload, ok := m.buffered[key]
+	if ok {
+		delete(m.buffered, key)
+		return upload, nil
+	}
+
+	for {
+		part, err := m.next()
+		if err != nil || part == nil {
+			return nil, err
+		}
+		if part.FormName() == key {
+			return &Upload{
+				Reader:      part,
+				Filename:    part.FileName(),
+				ContentType: part.Header.Get("Content-Type"),
+				Size:        -1,
+			}, nil
+		}
+		err = m.buffer(part)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// buffer reads a file part into memory so it can be used later
+// Files are not read beyond maxFileSize or beyond maxUploadSize of all buffered files together
+func (m *multipartStream) buffer(part *multipart.Part) error {
+	limit := int64(-1)
+	if m.maxFileSize > 0 {
+		limit = m.maxFileSize
+	}
+	if m.maxUploadSize > 0 && (limit < 0 || m.maxUploadSize-m.bufferedSize < limit) {
+		limit = m.maxUploadSize - m.bufferedSize
+	}
+
+	var reader io.Reader = part
+	if limit >= 0 {
+		reader = io.LimitReader(part, limit+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if m.maxFileSize > 0 && int64(len(data)) > m.maxFileSize {
+		return errors.New("file " + part.FormName() + " is larger than the max of " + strconv.FormatInt(m.maxFileSize, 10) + " bytes")
+	}
+	m.bufferedSize += int64(len(data))
+	if m.maxUploadSize > 0 && m.bufferedSize > m.maxUploadSize {
+		return errors.New("uploaded files are larger than the max of " + strconv.FormatInt(m.maxUploadSize, 10) + " bytes")
+	}
+	m.buffered[part.FormName()] = &Upload{
+		Reader:      bytes.NewReader(data),
+		Filename:    part.FileName(),
+		ContentType: part.Header.Get("Content-Type"),
+		Size:        int64(len(data)),
+	}
+	return nil
+}
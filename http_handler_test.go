@@ -0,0 +1,203 @@
+package yarql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func newTestHTTPHandler(t *testing.T, opts *HTTPHandlerOptions) http.Handler {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestResolveWithFileData{}, M{}, nil))
+	return HTTPHandler(s, opts)
+}
+
+func TestHTTPHandlerGet(t *testing.T) {
+	handler := newTestHTTPHandler(t, nil)
+
+	req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{foo}`), nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+	a.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	a.Equal(t, `{"data":{"foo":""}}`, res.Body.String())
+}
+
+func TestHTTPHandlerJSONBody(t *testing.T) {
+	handler := newTestHTTPHandler(t, nil)
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "query A {a: foo} query B {b: foo}", "operationName": "B"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+	a.Equal(t, `{"data":{"b":""}}`, res.Body.String())
+
+	// Errors within the query still result in a 200 status code
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{bar}"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{invalid`))
+	req.Header.Set("Content-Type", "application/json")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusBadRequest, res.Code)
+	a.Equal(t, `{"data":{},"errors":[{"message":"invalid json body"}],"extensions":{}}`, res.Body.String())
+}
+
+func TestHTTPHandlerMultipartForm(t *testing.T) {
+	handler := newTestHTTPHandler(t, nil)
+
+	body := bytes.NewBuffer(nil)
+	writer := multipart.NewWriter(body)
+	a.NoError(t, writer.WriteField("operations", `{"query": "{foo(file: \"FILE_ID\")}"}`))
+	file, err := writer.CreateFormFile("FILE_ID", "test.txt")
+	a.NoError(t, err)
+	file.Write([]byte("hello world"))
+	a.NoError(t, writer.Close())
+
+	req := httptest.NewRequest("POST", "/graphql", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+	a.Equal(t, `{"data":{"foo":"hello world"}}`, res.Body.String())
+}
+
+func TestHTTPHandlerRequestID(t *testing.T) {
+	handler := newTestHTTPHandler(t, &HTTPHandlerOptions{
+		RequestID: func(r *http.Request) string {
+			return r.Header.Get("X-Request-ID")
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{bar}`), nil)
+	req.Header.Set("X-Request-ID", "abc")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.True(t, strings.Contains(res.Body.String(), `"extensions":{"requestId":"abc"}`), res.Body.String())
+}
+
+func TestHTTPHandlerInvalidRequests(t *testing.T) {
+	handler := newTestHTTPHandler(t, &HTTPHandlerOptions{MaxBodySize: 20})
+
+	req := httptest.NewRequest("DELETE", "/graphql", nil)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	a.Equal(t, "GET, POST", res.Header().Get("Allow"))
+
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{foo}"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+
+	req = httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{foo foo}"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+}
+
+func TestHTTPHandlerGraphQLResponseMediaType(t *testing.T) {
+	handler := newTestHTTPHandler(t, nil)
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusBadRequest, res.Code)
+	a.Equal(t, "application/graphql-response+json", res.Header().Get("Content-Type"))
+}
+
+func TestHTTPHandlerCompression(t *testing.T) {
+	handler := newTestHTTPHandler(t, &HTTPHandlerOptions{CompressMinSize: 10})
+
+	req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{foo}`), nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+	a.Equal(t, "gzip", res.Header().Get("Content-Encoding"))
+	a.Equal(t, "Accept-Encoding", res.Header().Get("Vary"))
+	reader, err := gzip.NewReader(res.Body)
+	a.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	a.NoError(t, err)
+	a.Equal(t, `{"data":{"foo":""}}`, string(body))
+
+	req = httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{foo}`), nil)
+	req.Header.Set("Accept-Encoding", "deflate")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, "deflate", res.Header().Get("Content-Encoding"))
+	zlibReader, err := zlib.NewReader(res.Body)
+	a.NoError(t, err)
+	body, err = io.ReadAll(zlibReader)
+	a.NoError(t, err)
+	a.Equal(t, `{"data":{"foo":""}}`, string(body))
+
+	// Small responses are not compressed
+	handler = newTestHTTPHandler(t, &HTTPHandlerOptions{CompressMinSize: 100})
+	req = httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{foo}`), nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, "", res.Header().Get("Content-Encoding"))
+	a.Equal(t, `{"data":{"foo":""}}`, res.Body.String())
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	a.Equal(t, "", negotiateEncoding(""))
+	a.Equal(t, "gzip", negotiateEncoding("gzip"))
+	a.Equal(t, "gzip", negotiateEncoding("deflate, gzip"))
+	a.Equal(t, "deflate", negotiateEncoding("gzip;q=0.5, deflate"))
+	a.Equal(t, "deflate", negotiateEncoding("gzip;q=0, *"))
+	a.Equal(t, "gzip", negotiateEncoding("*"))
+	a.Equal(t, "", negotiateEncoding("br, identity"))
+}
+
+type TestHTTPHandlerRequestData struct{}
+
+func (TestHTTPHandlerRequestData) ResolveRequest(ctx *Ctx) string {
+	session, err := ctx.Cookie("session")
+	if err != nil {
+		return err.Error()
+	}
+	return ctx.Header().Get("X-Client") + " " + ctx.RemoteAddr() + " " + session.Value + " " + strconv.Itoa(len(ctx.Cookies()))
+}
+
+func TestHTTPHandlerRequestMetadata(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestHTTPHandlerRequestData{}, M{}, nil))
+	handler := HTTPHandler(s, nil)
+
+	req := httptest.NewRequest("GET", "/graphql?query="+url.QueryEscape(`{request}`), nil)
+	req.Header.Set("X-Client", "test")
+	req.Header.Set("Cookie", "session=abc; theme=dark")
+	req.RemoteAddr = "10.0.0.1:1234"
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, `{"data":{"request":"test 10.0.0.1:1234 abc 2"}}`, res.Body.String())
+
+	// Outside of http requests the metadata is empty
+	s = s.Copy()
+	errs := s.Resolve([]byte(`{request}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"request":"http: named cookie not present"}`, string(s.Result))
+}
@@ -5,7 +5,10 @@ import (
 	"context"
 	"errors"
 	"mime/multipart"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mjarkk/yarql/helpers"
 	"github.com/valyala/fastjson"
@@ -17,8 +20,23 @@ type RequestOptions struct {
 	Values      map[string]interface{}                          // Passed directly to the request context
 	GetFormFile func(key string) (*multipart.FileHeader, error) // Get form file to support file uploading
 	Tracing     bool                                            // https://github.com/apollographql/apollo-tracing
+	Accept      string                                          // The Accept header of the request, used to pick the media type of the response
+	Header      http.Header                                     // Headers of the http request, available within resolvers using (*Ctx).Header and (*Ctx).Cookie
+	RemoteAddr  string                                          // Network address of the client, available within resolvers using (*Ctx).RemoteAddr
+	RequestID   string                                          // ID of the request added to the extensions of errors and the request logger, see (*Ctx).SetRequestID
+
+	GetUpload     func(key string) (*Upload, error) // Get a streamed form file for *yarql.Upload inputs, if nil GetFormFile is used
+	MaxFileSize   int64                             // Max size in bytes of a uploaded file, 0 = no limit
+	MaxUploadSize int64                             // Max size in bytes of all uploaded files together, 0 = no limit
 }
 
+const (
+	// ContentTypeJSON is the media type of responses for clients that do not accept ContentTypeGraphQLResponse
+	ContentTypeJSON = "application/json"
+	// ContentTypeGraphQLResponse is the media type of responses defined by the GraphQL over HTTP spec
+	ContentTypeGraphQLResponse = "application/graphql-response+json"
+)
+
 // HandleRequest handles a http request and returns a response
 func (s *Schema) HandleRequest(
 	method string, // GET, POST, etc..
@@ -28,13 +46,159 @@ func (s *Schema) HandleRequest(
 	contentType string, // body content type, can be an empty string if method == "GET"
 	options *RequestOptions, // optional options
 ) ([]byte, []error) {
+	res, errs, _, _ := s.HandleRequestWithStatus(method, getQuery, getFormField, getBody, contentType, options)
+	return res, errs
+}
+
+// HandleRequestWithStatus is equal to HandleRequest but also returns the http status code and media type the response should be send with
+// The media type is picked based on (RequestOptions).Accept, the status codes follow the GraphQL over HTTP spec:
+//   - 400 if the request is invalid, for example if the body is not valid json
+//   - 400 if the query cannot be executed, for example because it contains syntax errors, only if the media type is ContentTypeGraphQLResponse
+//   - 406 if the client doesn't accept any of the supported media types
+//   - 200 otherwise, also if the response contains errors of resolvers
+func (s *Schema) HandleRequestWithStatus(
+	method string,
+	getQuery func(key string) string,
+	getFormField func(key string) (string, error),
+	getBody func() []byte,
+	contentType string,
+	options *RequestOptions,
+) (response []byte, errs []error, status int, responseContentType string) {
+	accept := ""
+	if options != nil {
+		accept = options.Accept
+	}
+	responseContentType, acceptable := negotiateResponseType(accept)
+	if !acceptable {
+		response, errs := requestErrResponse("none of the accepted media types are supported, accept " + ContentTypeGraphQLResponse + " or " + ContentTypeJSON)
+		return response, errs, 406, ContentTypeJSON
+	}
+
+	response, errs, status = s.handleRequest(method, getQuery, getFormField, getBody, contentType, options, responseContentType == ContentTypeGraphQLResponse)
+	return response, errs, status, responseContentType
+}
+
+// HTTPResult is the response to a http request, see (*Schema).HandleRequestResult
+type HTTPResult struct {
+	Body        []byte      // The json response, only valid until the schema resolves the next request
+	Errors      []error     // The errors in the response
+	Status      int         // The recommended http status code
+	ContentType string      // The media type of the body
+	Header      http.Header // The headers to send with the response, includes the Content-Type
+}
+
+// HandleRequestResult is equal to HandleRequestWithStatus but returns the response together with the recommended status code and headers
+// so http framework adapters don't have to map the result themselves
+// On top of the status codes of HandleRequestWithStatus:
+//   - 405 with an Allow header if the method is not GET or POST
+//   - 429 with a Retry-After header if all errors are a *RateLimitError, for example because the rate limiter rejected the operation
+func (s *Schema) HandleRequestResult(
+	method string,
+	getQuery func(key string) string,
+	getFormField func(key string) (string, error),
+	getBody func() []byte,
+	contentType string,
+	options *RequestOptions,
+) HTTPResult {
 	method = strings.ToUpper(method)
+	if method != http.MethodGet && method != http.MethodPost {
+		body, errs := requestErrResponse("method not allowed")
+		return HTTPResult{
+			Body:        body,
+			Errors:      errs,
+			Status:      http.StatusMethodNotAllowed,
+			ContentType: ContentTypeJSON,
+			Header:      http.Header{"Content-Type": {ContentTypeJSON}, "Allow": {"GET, POST"}},
+		}
+	}
+
+	body, errs, status, responseContentType := s.HandleRequestWithStatus(method, getQuery, getFormField, getBody, contentType, options)
+	res := HTTPResult{
+		Body:        body,
+		Errors:      errs,
+		Status:      status,
+		ContentType: responseContentType,
+		Header:      http.Header{"Content-Type": {responseContentType}},
+	}
 
-	errRes := func(errorMsg string) ([]byte, []error) {
-		response := []byte(`{"data":{},"errors":[{"message":`)
-		helpers.StringToJSON(errorMsg, &response)
-		response = append(response, []byte(`}],"extensions":{}}`)...)
-		return response, []error{errors.New(errorMsg)}
+	if retryAfter, ok := rateLimitedFor(errs); ok {
+		res.Status = http.StatusTooManyRequests
+		if retryAfter > 0 {
+			res.Header.Set("Retry-After", strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+		}
+	}
+
+	return res
+}
+
+// rateLimitedFor returns the longest RetryAfter of errs if all errors are a *RateLimitError
+func rateLimitedFor(errs []error) (retryAfter time.Duration, ok bool) {
+	if len(errs) == 0 {
+		return 0, false
+	}
+	for _, err := range errs {
+		var rateLimitErr *RateLimitError
+		if !errors.As(err, &rateLimitErr) {
+			return 0, false
+		}
+		if rateLimitErr.RetryAfter > retryAfter {
+			retryAfter = rateLimitErr.RetryAfter
+		}
+	}
+	return retryAfter, true
+}
+
+// negotiateResponseType returns the media type of the response based on the Accept header
+// Returns false if none of the accepted media types are supported
+func negotiateResponseType(accept string) (string, bool) {
+	if len(accept) == 0 {
+		return ContentTypeJSON, true
+	}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType := mediaRange
+		if idx := strings.IndexByte(mediaType, ';'); idx != -1 {
+			mediaType = mediaType[:idx]
+		}
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case ContentTypeGraphQLResponse:
+			return ContentTypeGraphQLResponse, true
+		case ContentTypeJSON, "application/*", "*/*":
+			return ContentTypeJSON, true
+		}
+	}
+	return ContentTypeJSON, false
+}
+
+// requestErrResponse returns a response for a request that could not be executed
+func requestErrResponse(errorMsg string) ([]byte, []error) {
+	response := []byte(`{"data":{},"errors":[{"message":`)
+	helpers.StringToJSON(errorMsg, &response)
+	response = append(response, []byte(`}],"extensions":{}}`)...)
+	return response, []error{errors.New(errorMsg)}
+}
+
+// handleRequest is equal to HandleRequest but also returns the status code of the response
+// strictStatus makes queries that could not be executed result in a 400 status code
+func (s *Schema) handleRequest(
+	method string,
+	getQuery func(key string) string,
+	getFormField func(key string) (string, error),
+	getBody func() []byte,
+	contentType string,
+	options *RequestOptions,
+	strictStatus bool,
+) ([]byte, []error, int) {
+	method = strings.ToUpper(method)
+
+	errRes := func(errorMsg string) ([]byte, []error, int) {
+		response, errs := requestErrResponse(errorMsg)
+		return response, errs, 400
+	}
+	singleRequestStatus := func() int {
+		if strictStatus && !s.ctx.executed {
+			return 400
+		}
+		return 200
 	}
 
 	if contentType == "application/json" || ((contentType == "text/plain" || contentType == "multipart/form-data") && method != "GET") {
@@ -57,11 +221,35 @@ func (s *Schema) HandleRequest(
 		if err != nil {
 			return errRes("invalid json body")
 		}
+		if contentType == "multipart/form-data" {
+			// The map field is optional, files can also be referenced directly by their form field name
+			uploadMap, err := getFormField("map")
+			if err == nil {
+				err = applyUploadMap(v, uploadMap)
+				if err != nil {
+					return errRes(err.Error())
+				}
+			}
+		}
 		if v.Type() == fastjson.TypeArray {
 			// Handle batch query
+			items := v.GetArray()
+			if s.MaxBatchSize > 0 && len(items) > s.MaxBatchSize {
+				return errRes("batch contains " + strconv.Itoa(len(items)) + " operations, the max is " + strconv.Itoa(s.MaxBatchSize))
+			}
+			var loaders *batchLoaders
+			if s.ShareBatchLoaders {
+				loaders = newBatchLoaders()
+			}
+			if s.BatchConcurrency > 1 && (options == nil || options.GetUpload == nil) {
+				// Streamed uploads are read in order so these batches are always resolved one by one
+				response, responseErrs := s.handleBatchConcurrently(items, options, loaders)
+				return response, responseErrs, 200
+			}
+
 			responseErrs := []error{}
 			response := bytes.NewBuffer([]byte("["))
-			for _, item := range v.GetArray() {
+			for _, item := range items {
 				// TODO potential speed improvement by executing all items at once
 				if item == nil {
 					continue
@@ -71,27 +259,29 @@ func (s *Schema) HandleRequest(
 					response.WriteByte(',')
 				}
 
-				query, operationName, variables, err := getBodyData(item)
+				query, operationName, variables, extensions, err := getBodyData(item)
 				if err != nil {
 					responseErrs = append(responseErrs, err)
-					res, _ := errRes(err.Error())
+					res, _, _ := errRes(err.Error())
 					response.Write(res)
 				} else {
 					errs := s.handleSingleRequest(
 						query,
 						variables,
 						operationName,
+						extensions,
 						options,
+						loaders,
 					)
 					responseErrs = append(responseErrs, errs...)
 					response.Write(s.Result)
 				}
 			}
 			response.WriteByte(']')
-			return response.Bytes(), responseErrs
+			return response.Bytes(), responseErrs, 200
 		}
 
-		query, operationName, variables, err := getBodyData(v)
+		query, operationName, variables, extensions, err := getBodyData(v)
 		if err != nil {
 			return errRes(err.Error())
 		}
@@ -99,29 +289,70 @@ func (s *Schema) HandleRequest(
 			query,
 			variables,
 			operationName,
+			extensions,
 			options,
+			nil,
 		)
-		return s.Result, errs
+		return s.Result, errs, singleRequestStatus()
+	}
+
+	extensions := getQuery("extensions")
+	if len(extensions) > 0 {
+		// Validate the extensions here as the json body is also validated before resolving
+		var p fastjson.Parser
+		v, err := p.Parse(extensions)
+		if err != nil {
+			return errRes("invalid extensions param, must be a valid JSON object")
+		}
+		if v.Type() != fastjson.TypeObject {
+			return errRes("expected extensions to be a key value object but got: " + v.Type().String())
+		}
 	}
 
 	errs := s.handleSingleRequest(
 		getQuery("query"),
 		getQuery("variables"),
 		getQuery("operationName"),
+		extensions,
 		options,
+		nil,
 	)
-	return s.Result, errs
+	return s.Result, errs, singleRequestStatus()
+}
+
+// ResponseTransformer rewrites the response of an operation handled by HandleRequest, see (*Schema).SetResponseTransformer
+// response is the complete JSON response of the operation and may be modified in place, the returned bytes are send instead
+type ResponseTransformer func(ctx *Ctx, response []byte) []byte
+
+// SetResponseTransformer sets a function that can rewrite the response of every operation before it's returned by HandleRequest
+// This allows things like adding values computed at the end of the request or stripping fields for legacy clients without parsing the JSON again
+// For batched requests the transformer is called for every operation in the batch
+//
+// Example:
+//
+//	s.SetResponseTransformer(func(ctx *yarql.Ctx, response []byte) []byte {
+//		if ctx.Header().Get("X-Legacy-Client") != "" {
+//			return bytes.ReplaceAll(response, []byte(`"createdAt"`), []byte(`"created_at"`))
+//		}
+//		return response
+//	})
+func (s *Schema) SetResponseTransformer(transformer ResponseTransformer) {
+	s.responseTransform = transformer
 }
 
 func (s *Schema) handleSingleRequest(
 	query,
 	variables,
-	operationName string,
+	operationName,
+	extensions string,
 	options *RequestOptions,
+	loaders *batchLoaders, // optional loader caches shared between the operations of a batched request
 ) []error {
 	resolveOptions := ResolveOptions{
 		OperatorTarget: operationName,
 		Variables:      variables,
+		Extensions:     extensions,
+		batchLoaders:   loaders,
 	}
 	if options != nil {
 		if options.Context != nil {
@@ -133,13 +364,23 @@ func (s *Schema) handleSingleRequest(
 		if options.GetFormFile != nil {
 			resolveOptions.GetFormFile = options.GetFormFile
 		}
+		resolveOptions.GetUpload = options.GetUpload
+		resolveOptions.MaxFileSize = options.MaxFileSize
+		resolveOptions.MaxUploadSize = options.MaxUploadSize
 		resolveOptions.Tracing = options.Tracing
+		resolveOptions.Header = options.Header
+		resolveOptions.RemoteAddr = options.RemoteAddr
+		resolveOptions.RequestID = options.RequestID
 	}
 
-	return s.Resolve(s2b(query), resolveOptions)
+	errs := s.Resolve(s2b(query), resolveOptions)
+	if s.responseTransform != nil {
+		s.Result = s.responseTransform(s.ctx, s.Result)
+	}
+	return errs
 }
 
-func getBodyData(body *fastjson.Value) (query, operationName, variables string, err error) {
+func getBodyData(body *fastjson.Value) (query, operationName, variables, extensions string, err error) {
 	if body.Type() != fastjson.TypeObject {
 		err = errors.New("body should be a object")
 		return
@@ -186,5 +427,95 @@ func getBodyData(body *fastjson.Value) (query, operationName, variables string,
 		}
 	}
 
+	jsonExtensions := body.Get("extensions")
+	if jsonExtensions != nil {
+		t := jsonExtensions.Type()
+		if t != fastjson.TypeNull {
+			if t != fastjson.TypeObject {
+				err = errors.New("expected extensions to be a key value object but got: " + t.String())
+				return
+			}
+			extensions = jsonExtensions.String()
+		}
+	}
+
 	return
 }
+
+// applyUploadMap sets the files of the map form field of the graphql-multipart-request-spec in the operations
+// The values at the paths in the map are replaced by the form field name of the file, as that's what File inputs expect
+// https://github.com/jaydenseric/graphql-multipart-request-spec
+func applyUploadMap(operations *fastjson.Value, uploadMap string) error {
+	if len(uploadMap) == 0 {
+		return nil
+	}
+
+	var p fastjson.Parser
+	mapValue, err := p.Parse(uploadMap)
+	if err != nil {
+		return errors.New("invalid json in map form field")
+	}
+	mapObj, err := mapValue.Object()
+	if err != nil {
+		return errors.New("expected map form field to be a key value object")
+	}
+
+	var arena fastjson.Arena
+	mapObj.Visit(func(fileKey []byte, pathsValue *fastjson.Value) {
+		if err != nil {
+			return
+		}
+		paths, pathsErr := pathsValue.Array()
+		if pathsErr != nil {
+			err = errors.New("expected the paths of file " + string(fileKey) + " in the map form field to be an array")
+			return
+		}
+		for _, pathValue := range paths {
+			path, pathErr := pathValue.StringBytes()
+			if pathErr != nil {
+				err = errors.New("expected the paths of file " + string(fileKey) + " in the map form field to be strings")
+				return
+			}
+			err = setUploadPath(operations, string(path), arena.NewStringBytes(fileKey))
+			if err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+// setUploadPath sets value at a object path like variables.input.files.0 within operations
+func setUploadPath(operations *fastjson.Value, path string, value *fastjson.Value) error {
+	parts := strings.Split(path, ".")
+	parent := operations
+	for i, part := range parts {
+		isLast := i == len(parts)-1
+
+		switch parent.Type() {
+		case fastjson.TypeObject:
+			if isLast {
+				parent.Set(part, value)
+				return nil
+			}
+			parent = parent.Get(part)
+		case fastjson.TypeArray:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(parent.GetArray()) {
+				return errors.New("invalid upload path " + path + ", " + part + " is not a index of the array")
+			}
+			if isLast {
+				parent.SetArrayItem(idx, value)
+				return nil
+			}
+			parent = parent.Get(part)
+		default:
+			parent = nil
+		}
+
+		if parent == nil {
+			return errors.New("invalid upload path " + path + ", " + part + " does not exist")
+		}
+	}
+	return nil
+}
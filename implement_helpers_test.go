@@ -1,9 +1,13 @@
 package yarql
 
 import (
+	"encoding/json"
 	"errors"
+	"mime/multipart"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	a "github.com/mjarkk/yarql/assert"
 )
@@ -34,6 +38,73 @@ func TestHandleRequestRequestInURL(t *testing.T) {
 	a.Equal(t, `{"data":{"a":{"bar":"baz"}}}`, string(res))
 }
 
+type testGetPersistedQueryExtension struct {
+	BaseExtension
+	queries map[string]string
+}
+
+func (e testGetPersistedQueryExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) {
+	extensions := ctx.RequestExtensions()
+	if extensions == nil {
+		return query, nil
+	}
+	var data struct {
+		PersistedQuery struct{ Sha256Hash string }
+	}
+	err := json.Unmarshal(extensions, &data)
+	if err != nil {
+		return nil, err
+	}
+	persisted, ok := e.queries[data.PersistedQuery.Sha256Hash]
+	if !ok {
+		return nil, errors.New("PersistedQueryNotFound")
+	}
+	return []byte(persisted), nil
+}
+
+func TestHandleRequestExtensionsInURL(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterExtension(testGetPersistedQueryExtension{queries: map[string]string{"abc": "{a {bar}}"}}))
+	err := s.Parse(TestResolveSchemaRequestWithFieldsData{A: TestResolveSchemaRequestWithFieldsDataInnerStruct{Bar: "baz"}}, M{}, nil)
+	a.NoError(t, err)
+
+	handle := func(extensions string) (string, []error, int) {
+		res, errs, status, _ := s.HandleRequestWithStatus(
+			"GET",
+			func(key string) string {
+				if key == "extensions" {
+					return extensions
+				}
+				return ""
+			},
+			func(key string) (string, error) { return "", errors.New("this should not be called") },
+			func() []byte { return nil },
+			"",
+			&RequestOptions{},
+		)
+		return string(res), errs, status
+	}
+
+	res, errs, status := handle(`{"persistedQuery": {"version": 1, "sha256Hash": "abc"}}`)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, 200, status)
+	a.Equal(t, `{"data":{"a":{"bar":"baz"}}}`, res)
+
+	_, errs, _ = handle(`{"persistedQuery": {"version": 1, "sha256Hash": "unknown"}}`)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "PersistedQueryNotFound", errs[0].Error())
+
+	_, errs, status = handle(`{"persistedQuery"`)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, 400, status)
+	a.Equal(t, "invalid extensions param, must be a valid JSON object", errs[0].Error())
+
+	_, errs, status = handle(`[]`)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, 400, status)
+	a.Equal(t, "expected extensions to be a key value object but got: array", errs[0].Error())
+}
+
 func TestHandleRequestRequestJsonBody(t *testing.T) {
 	s := NewSchema()
 	err := s.Parse(TestResolveSchemaRequestWithFieldsData{A: TestResolveSchemaRequestWithFieldsDataInnerStruct{Bar: "baz"}}, M{}, nil)
@@ -164,3 +235,213 @@ func TestHandleRequestRequestBatch(t *testing.T) {
 	}
 	a.Equal(t, `[{"data":{"a":{"bar":"baz"}}},{"data":{"a":{"foo":null}}}]`, string(res))
 }
+
+func TestHandleRequestWithStatus(t *testing.T) {
+	s := NewSchema()
+	err := s.Parse(TestResolveSchemaRequestWithFieldsData{A: TestResolveSchemaRequestWithFieldsDataInnerStruct{Bar: "baz"}}, M{}, nil)
+	a.NoError(t, err)
+
+	handle := func(query string, accept string) (string, int, string) {
+		res, _, status, contentType := s.HandleRequestWithStatus(
+			"GET",
+			func(key string) string {
+				if key == "query" {
+					return query
+				}
+				return ""
+			},
+			func(key string) (string, error) { return "", errors.New("this should not be called") },
+			func() []byte { return nil },
+			"",
+			&RequestOptions{Accept: accept},
+		)
+		return string(res), status, contentType
+	}
+
+	res, status, contentType := handle(`{a {bar}}`, "")
+	a.Equal(t, `{"data":{"a":{"bar":"baz"}}}`, res)
+	a.Equal(t, 200, status)
+	a.Equal(t, ContentTypeJSON, contentType)
+
+	// Parse errors only result in a 400 status code with the graphql response media type
+	_, status, contentType = handle(`{a {bar}`, "application/json")
+	a.Equal(t, 200, status)
+	a.Equal(t, ContentTypeJSON, contentType)
+	_, status, contentType = handle(`{a {bar}`, "application/graphql-response+json, application/json;q=0.9")
+	a.Equal(t, 400, status)
+	a.Equal(t, ContentTypeGraphQLResponse, contentType)
+
+	// Execution errors with partial data result in a 200 status code
+	_, status, _ = handle(`{a {bar unknown}}`, "application/graphql-response+json")
+	a.Equal(t, 200, status)
+
+	_, status, contentType = handle(`{a {bar}}`, "*/*")
+	a.Equal(t, 200, status)
+	a.Equal(t, ContentTypeJSON, contentType)
+
+	_, status, _ = handle(`{a {bar}}`, "text/html")
+	a.Equal(t, 406, status)
+}
+
+func TestHandleRequestResponseTransformer(t *testing.T) {
+	s := NewSchema()
+	s.SetResponseTransformer(func(ctx *Ctx, response []byte) []byte {
+		// Remove the closing } of the response and add a field
+		response = append(response[:len(response)-1], `,"operation":"`...)
+		response = append(response, ctx.OperationName()...)
+		return append(response, `"}`...)
+	})
+	err := s.Parse(TestResolveSchemaRequestWithFieldsData{A: TestResolveSchemaRequestWithFieldsDataInnerStruct{Bar: "baz"}}, M{}, nil)
+	a.NoError(t, err)
+
+	handle := func(body string) string {
+		res, errs := s.HandleRequest(
+			"POST",
+			func(key string) string { return "" },
+			func(key string) (string, error) { return "", errors.New("this should not be called") },
+			func() []byte { return []byte(body) },
+			"application/json",
+			nil,
+		)
+		a.Equal(t, 0, len(errs))
+		return string(res)
+	}
+
+	res := handle(`{"query": "query Foo {a {bar}}"}`)
+	a.Equal(t, `{"data":{"a":{"bar":"baz"}},"operation":"Foo"}`, res)
+
+	res = handle(`[{"query": "query Foo {a {bar}}"}, {"query": "query Bar {a {bar}}"}]`)
+	a.Equal(t, `[{"data":{"a":{"bar":"baz"}},"operation":"Foo"},{"data":{"a":{"bar":"baz"}},"operation":"Bar"}]`, res)
+
+	s.BatchConcurrency = 2
+	res = handle(`[{"query": "query Foo {a {bar}}"}, {"query": "query Bar {a {bar}}"}]`)
+	a.Equal(t, `[{"data":{"a":{"bar":"baz"}},"operation":"Foo"},{"data":{"a":{"bar":"baz"}},"operation":"Bar"}]`, res)
+}
+
+func TestHandleRequestResult(t *testing.T) {
+	s := NewSchema()
+	s.SetRateLimiter(func(ctx *Ctx, info OperationInfo) error {
+		if info.OperationName == "Limited" {
+			return &RateLimitError{RetryAfter: 1500 * time.Millisecond}
+		}
+		return nil
+	})
+	err := s.Parse(TestResolveSchemaRequestWithFieldsData{A: TestResolveSchemaRequestWithFieldsDataInnerStruct{Bar: "baz"}}, M{}, nil)
+	a.NoError(t, err)
+
+	handle := func(method string, query string) HTTPResult {
+		return s.HandleRequestResult(
+			method,
+			func(key string) string {
+				if key == "query" {
+					return query
+				}
+				return ""
+			},
+			func(key string) (string, error) { return "", errors.New("this should not be called") },
+			func() []byte { return nil },
+			"",
+			&RequestOptions{Accept: ContentTypeGraphQLResponse},
+		)
+	}
+
+	res := handle("get", `{a {bar}}`)
+	a.Equal(t, `{"data":{"a":{"bar":"baz"}}}`, string(res.Body))
+	a.Equal(t, 0, len(res.Errors))
+	a.Equal(t, http.StatusOK, res.Status)
+	a.Equal(t, ContentTypeGraphQLResponse, res.ContentType)
+	a.Equal(t, http.Header{"Content-Type": {ContentTypeGraphQLResponse}}, res.Header)
+
+	res = handle("GET", `{a {bar}`)
+	a.Equal(t, http.StatusBadRequest, res.Status)
+	a.Equal(t, 1, len(res.Errors))
+
+	res = handle("DELETE", `{a {bar}}`)
+	a.Equal(t, http.StatusMethodNotAllowed, res.Status)
+	a.Equal(t, "GET, POST", res.Header.Get("Allow"))
+	a.Equal(t, ContentTypeJSON, res.Header.Get("Content-Type"))
+
+	res = handle("GET", `query Limited {a {bar}}`)
+	a.Equal(t, http.StatusTooManyRequests, res.Status)
+	a.Equal(t, "2", res.Header.Get("Retry-After"))
+}
+
+type TestHandleRequestUploadData struct{}
+
+func (TestHandleRequestUploadData) ResolveFile(args struct{ File *multipart.FileHeader }) string {
+	if args.File == nil {
+		return ""
+	}
+	return args.File.Filename
+}
+
+type TestHandleRequestUploadInput struct {
+	Files []*multipart.FileHeader
+}
+
+func (TestHandleRequestUploadData) ResolveFiles(args struct{ Input TestHandleRequestUploadInput }) []string {
+	names := []string{}
+	for _, file := range args.Input.Files {
+		names = append(names, file.Filename)
+	}
+	return names
+}
+
+func TestHandleRequestMultipartRequestSpec(t *testing.T) {
+	s := NewSchema()
+	err := s.Parse(TestHandleRequestUploadData{}, M{}, nil)
+	a.NoError(t, err)
+
+	handle := func(operations, uploadMap string) (string, []error) {
+		res, errs := s.HandleRequest(
+			"POST",
+			func(key string) string { return "" },
+			func(key string) (string, error) {
+				switch key {
+				case "operations":
+					return operations, nil
+				case "map":
+					return uploadMap, nil
+				}
+				return "", errors.New("unknown form field")
+			},
+			func() []byte { return nil },
+			"multipart/form-data",
+			&RequestOptions{
+				GetFormFile: func(key string) (*multipart.FileHeader, error) {
+					return &multipart.FileHeader{Filename: "file " + key}, nil
+				},
+			},
+		)
+		return string(res), errs
+	}
+
+	res, errs := handle(
+		`{"query": "query ($file: Upload!) {file(file: $file)}", "variables": {"file": null}}`,
+		`{"0": ["variables.file"]}`,
+	)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":{"file":"file 0"}}`, res)
+
+	res, errs = handle(
+		`{"query": "query ($input: TestHandleRequestUploadInput) {files(input: $input)}", "variables": {"input": {"files": [null, null]}}}`,
+		`{"a": ["variables.input.files.0"], "b": ["variables.input.files.1"]}`,
+	)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":{"files":["file a","file b"]}}`, res)
+
+	// Batched operations
+	res, errs = handle(
+		`[{"query": "query ($file: Upload) {file(file: $file)}", "variables": {"file": null}}, {"query": "query ($file: Upload) {file(file: $file)}", "variables": {"file": null}}]`,
+		`{"0": ["0.variables.file", "1.variables.file"]}`,
+	)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `[{"data":{"file":"file 0"}},{"data":{"file":"file 0"}}]`, res)
+
+	_, errs = handle(
+		`{"query": "query ($file: Upload) {file(file: $file)}", "variables": {"file": null}}`,
+		`{"0": ["variables.other.file"]}`,
+	)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "invalid upload path variables.other.file, other does not exist", errs[0].Error())
+}
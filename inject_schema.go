@@ -20,7 +20,7 @@ func (s *Schema) injectQLTypes(ctx *parseCtx) {
 	ref.qlFieldName = []byte("__schema")
 	ref.hidden = true
 
-	s.rootQuery.objContents[getObjKey(ref.qlFieldName)] = ref
+	s.rootQuery.objContents.set(ref)
 
 	// Inject __type(name: String!): __Type
 	typeResolver := func(ctx *Ctx, args struct{ Name string }) *qlType {
@@ -35,38 +35,23 @@ func (s *Schema) injectQLTypes(ctx *parseCtx) {
 	functionObj.customObjValue = &typeResolverReflection
 	functionObj.qlFieldName = []byte("__type")
 	functionObj.hidden = true
-	s.rootQuery.objContents[getObjKey(functionObj.qlFieldName)] = functionObj
+	s.rootQuery.objContents.set(functionObj)
 }
 
 func (s *Schema) getQLSchema() qlSchema {
 	res := qlSchema{
-		Types:      s.getAllQLTypes,
+		Description: h.CheckStrPtr(s.description),
+		Types: func(ctx *Ctx) []qlType {
+			// The types are taken from the schema that resolves the query as views leave out types
+			return ctx.schema.getAllQLTypes()
+		},
 		Directives: s.getDirectives(),
 		QueryType: &qlType{
 			Kind:        typeKindObject,
 			Name:        h.StrPtr(s.rootQuery.typeName),
 			Description: h.PtrToEmptyStr,
-			Fields: func(isDeprecatedArgs) []qlField {
-				fields, ok := s.graphqlObjFields[s.rootQuery.typeName]
-				if ok {
-					return fields
-				}
-
-				res := []qlField{}
-				for _, item := range s.rootQuery.objContents {
-					if item.hidden {
-						continue
-					}
-					res = append(res, qlField{
-						Name: string(item.qlFieldName),
-						Args: s.getObjectArgs(item),
-						Type: *wrapQLTypeInNonNull(s.objToQLType(item)),
-					})
-				}
-				sort.Slice(res, func(a int, b int) bool { return res[a].Name < res[b].Name })
-
-				s.graphqlObjFields[s.rootQuery.typeName] = res
-				return res
+			Fields: func(ctx *Ctx, args isDeprecatedArgs) []qlField {
+				return ctx.visibleQLFields(s.rootQuery.typeName, s.qlFields(s.rootQuery))
 			},
 			Interfaces: []qlType{},
 		},
@@ -74,27 +59,8 @@ func (s *Schema) getQLSchema() qlSchema {
 			Kind:        typeKindObject,
 			Name:        h.StrPtr(s.rootMethod.typeName),
 			Description: h.PtrToEmptyStr,
-			Fields: func(isDeprecatedArgs) []qlField {
-				fields, ok := s.graphqlObjFields[s.rootMethod.typeName]
-				if ok {
-					return fields
-				}
-
-				res := []qlField{}
-				for _, item := range s.rootMethod.objContents {
-					if item.hidden {
-						continue
-					}
-					res = append(res, qlField{
-						Name: string(item.qlFieldName),
-						Args: s.getObjectArgs(item),
-						Type: *wrapQLTypeInNonNull(s.objToQLType(item)),
-					})
-				}
-				sort.Slice(res, func(a int, b int) bool { return res[a].Name < res[b].Name })
-
-				s.graphqlObjFields[s.rootQuery.typeName] = res
-				return res
+			Fields: func(ctx *Ctx, args isDeprecatedArgs) []qlField {
+				return ctx.visibleQLFields(s.rootMethod.typeName, s.qlFields(s.rootMethod))
 			},
 			Interfaces: []qlType{},
 		},
@@ -106,6 +72,32 @@ func (s *Schema) getQLSchema() qlSchema {
 	return res
 }
 
+// qlFields returns the introspection fields of a object or interface, the fields are cached in graphqlObjFields
+func (s *Schema) qlFields(item *obj) []qlField {
+	fields, ok := s.graphqlObjFields[item.typeName]
+	if ok {
+		return fields
+	}
+
+	res := []qlField{}
+	for _, innerItem := range item.objContents.all() {
+		if innerItem.hidden {
+			continue
+		}
+		res = append(res, qlField{
+			Name:        string(innerItem.qlFieldName),
+			Description: h.CheckStrPtr(innerItem.description),
+			Args:        s.getObjectArgs(innerItem),
+			Type:        *wrapQLTypeInNonNull(s.objToQLType(innerItem)),
+			scope:       innerItem.scope,
+		})
+	}
+	sort.Slice(res, func(a int, b int) bool { return res[a].Name < res[b].Name })
+
+	s.graphqlObjFields[item.typeName] = res
+	return res
+}
+
 func (s *Schema) getDirectives() []qlDirective {
 	res := []qlDirective{}
 
@@ -129,10 +121,11 @@ func (s *Schema) getDirectives() []qlDirective {
 				}
 			}
 			res = append(res, qlDirective{
-				Name:        directive.Name,
-				Description: h.CheckStrPtr(directive.Description),
-				Locations:   locations,
-				Args:        s.getMethodArgs(directive.parsedMethod.inFields),
+				Name:         directive.Name,
+				Description:  h.CheckStrPtr(directive.Description),
+				Locations:    locations,
+				Args:         s.getMethodArgs(directive.parsedMethod.inFields),
+				IsRepeatable: directive.Repeatable,
 			})
 		}
 	}
@@ -148,7 +141,7 @@ func (s *Schema) getAllQLTypes() []qlType {
 
 		s.graphqlTypesList = make(
 			[]qlType,
-			len(s.types)+len(s.inTypes)+len(s.definedEnums)+len(scalars)+len(s.interfaces),
+			len(s.types)+len(s.inTypes)+len(s.definedEnums)+len(scalars)+len(s.customScalars)+len(s.interfaces),
 		)
 
 		idx := 0
@@ -170,6 +163,10 @@ func (s *Schema) getAllQLTypes() []qlType {
 			s.graphqlTypesList[idx] = scalar
 			idx++
 		}
+		for _, scalar := range s.customScalars {
+			s.graphqlTypesList[idx] = *scalar
+			idx++
+		}
 		for _, qlInterface := range s.interfaces {
 			obj, _ := s.objToQLType(qlInterface)
 			s.graphqlTypesList[idx] = *obj
@@ -177,6 +174,9 @@ func (s *Schema) getAllQLTypes() []qlType {
 		}
 
 		sort.Slice(s.graphqlTypesList, func(a int, b int) bool { return *s.graphqlTypesList[a].Name < *s.graphqlTypesList[b].Name })
+		if len(s.view) > 0 {
+			s.graphqlTypesList = s.viewQLTypes(s.graphqlTypesList)
+		}
 	}
 
 	return s.graphqlTypesList
@@ -218,26 +218,49 @@ func (s *Schema) inputToQLType(in *input) (res *qlType, isNonNull bool) {
 		isNonNull = true
 		res = &scalarTime
 		return
+	} else if in.isText {
+		isNonNull = true
+		res = &scalarString
+		return
+	} else if in.isInt64 {
+		isNonNull = true
+		res = &scalarInt64
+		return
 	} else if in.isFile {
 		res = &scalarFile
 		return
+	} else if in.isEnum {
+		isNonNull = true
+		enumType := s.definedEnums[in.enumTypeIndex].qlType
+		res = &enumType
+		return
+	} else if in.scalar != nil {
+		isNonNull = true
+		res = in.scalar
+		return
 	}
 
 	switch in.kind {
 	case reflect.Struct:
 		isNonNull = true
 
+		// The description of in is the description of the field if in refers to the input object
+		description := ""
+		if structInput, ok := s.inTypes[in.structName]; ok {
+			description = structInput.description
+		}
 		res = &qlType{
 			Kind:        typeKindInputObject,
 			Name:        h.StrPtr(in.structName),
-			Description: h.PtrToEmptyStr,
+			Description: &description,
+			IsOneOf:     &in.oneOf,
 			InputFields: func() []qlInputValue {
 				res := make([]qlInputValue, len(in.structContent))
 				i := 0
 				for key, item := range in.structContent {
 					res[i] = qlInputValue{
 						Name:         key,
-						Description:  h.PtrToEmptyStr,
+						Description:  h.StrPtr(item.description),
 						Type:         *wrapQLTypeInNonNull(s.inputToQLType(&item)),
 						DefaultValue: nil, // We do not support this atm
 					}
@@ -248,9 +271,14 @@ func (s *Schema) inputToQLType(in *input) (res *qlType, isNonNull bool) {
 			},
 		}
 	case reflect.Array, reflect.Slice:
+		elem, elemIsNonNull := s.inputToQLType(in.elem)
+		if in.elem.isFile {
+			// A single file is optional but null is not allowed within a list of files
+			elemIsNonNull = true
+		}
 		res = &qlType{
 			Kind:   typeKindList,
-			OfType: wrapQLTypeInNonNull(s.inputToQLType(in.elem)),
+			OfType: wrapQLTypeInNonNull(elem, elemIsNonNull),
 		}
 	case reflect.Ptr:
 		// Basically sets the isNonNull to false
@@ -292,10 +320,12 @@ func (s *Schema) getObjectArgs(item *obj) []qlInputValue {
 func (s *Schema) getMethodArgs(inputs map[string]referToInput) []qlInputValue {
 	res := []qlInputValue{}
 	for key, value := range inputs {
+		// Missing and null arguments are bound as the zero value of their go type so only required arguments are non-null
+		argType, isNonNull := s.inputToQLType(&value.input)
 		res = append(res, qlInputValue{
 			Name:         key,
-			Description:  h.PtrToEmptyStr,
-			Type:         *wrapQLTypeInNonNull(s.inputToQLType(&value.input)),
+			Description:  h.StrPtr(value.input.description),
+			Type:         *wrapQLTypeInNonNull(argType, isNonNull && value.input.required),
 			DefaultValue: nil,
 		})
 	}
@@ -304,6 +334,10 @@ func (s *Schema) getMethodArgs(inputs map[string]referToInput) []qlInputValue {
 }
 
 func (s *Schema) objToQLType(item *obj) (res *qlType, isNonNull bool) {
+	if item.embeddedPtr != nil {
+		// The field is null if the embedded pointer it's promoted from is nil
+		defer func() { isNonNull = false }()
+	}
 	switch item.valueType {
 	case valueTypeUndefined:
 		// WUT??, we'll just look away and continue as if nothing happened
@@ -325,33 +359,15 @@ func (s *Schema) objToQLType(item *obj) (res *qlType, isNonNull bool) {
 				interfaceType, _ := s.objToQLType(implementation)
 				interfaces = append(interfaces, *interfaceType)
 			}
+			sort.Slice(interfaces, func(a int, b int) bool { return *interfaces[a].Name < *interfaces[b].Name })
 		}
 
 		res = &qlType{
 			Kind:        typeKindObject,
 			Name:        &item.typeName,
-			Description: h.PtrToEmptyStr,
-			Fields: func(args isDeprecatedArgs) []qlField {
-				fields, ok := s.graphqlObjFields[item.typeName]
-				if ok {
-					return fields
-				}
-
-				res := []qlField{}
-				for _, innerItem := range item.objContents {
-					if innerItem.hidden {
-						continue
-					}
-					res = append(res, qlField{
-						Name: string(innerItem.qlFieldName),
-						Args: s.getObjectArgs(innerItem),
-						Type: *wrapQLTypeInNonNull(s.objToQLType(innerItem)),
-					})
-				}
-				sort.Slice(res, func(a int, b int) bool { return res[a].Name < res[b].Name })
-
-				s.graphqlObjFields[item.typeName] = res
-				return res
+			Description: &item.description,
+			Fields: func(ctx *Ctx, args isDeprecatedArgs) []qlField {
+				return ctx.visibleQLFields(item.typeName, s.qlFields(item))
 			},
 			Interfaces: interfaces,
 		}
@@ -379,7 +395,7 @@ func (s *Schema) objToQLType(item *obj) (res *qlType, isNonNull bool) {
 		res = &qlType{
 			Kind:        typeKindInterface,
 			Name:        &item.typeName,
-			Description: h.PtrToEmptyStr,
+			Description: &item.description,
 			Interfaces:  []qlType{},
 			PossibleTypes: func() []qlType {
 				possibleTypes := make([]qlType, len(item.implementations))
@@ -387,29 +403,11 @@ func (s *Schema) objToQLType(item *obj) (res *qlType, isNonNull bool) {
 					item, _ := s.objToQLType(implementation)
 					possibleTypes[idx] = *item
 				}
+				sort.Slice(possibleTypes, func(a int, b int) bool { return *possibleTypes[a].Name < *possibleTypes[b].Name })
 				return possibleTypes
 			},
-			Fields: func(args isDeprecatedArgs) []qlField {
-				fields, ok := s.graphqlObjFields[item.typeName]
-				if ok {
-					return fields
-				}
-
-				res := []qlField{}
-				for _, innerItem := range item.objContents {
-					if item.hidden {
-						continue
-					}
-					res = append(res, qlField{
-						Name: string(innerItem.qlFieldName),
-						Args: s.getObjectArgs(innerItem),
-						Type: *wrapQLTypeInNonNull(s.objToQLType(innerItem)),
-					})
-				}
-				sort.Slice(res, func(a int, b int) bool { return res[a].Name < res[b].Name })
-
-				s.graphqlObjFields[item.typeName] = res
-				return res
+			Fields: func(ctx *Ctx, args isDeprecatedArgs) []qlField {
+				return ctx.visibleQLFields(item.typeName, s.qlFields(item))
 			},
 		}
 		return
@@ -424,6 +422,8 @@ func resolveObjToScalar(item *obj) *qlType {
 	case valueTypeData:
 		if item.isID {
 			res = scalarID
+		} else if item.scalar != nil {
+			res = *item.scalar
 		} else {
 			switch item.dataValueType {
 			case reflect.Bool:
@@ -442,6 +442,13 @@ func resolveObjToScalar(item *obj) *qlType {
 	case valueTypeTime:
 		res = scalarTime
 		return &res
+	case valueTypeText:
+		if item.isID {
+			res = scalarID
+		} else {
+			res = scalarString
+		}
+		return &res
 	}
 	return nil
 }
@@ -0,0 +1,18 @@
+package yarql
+
+// Injector is called at the start of every request before the query is parsed and before any resolver or extension hook is called
+// It can attach values like database handles, loggers and the authenticated user to the Ctx
+type Injector func(ctx *Ctx)
+
+// SetInjector sets a function that prepares the Ctx of every request, see Injector
+// The request headers, remote address, values and context are already set when the injector is called
+//
+// Example:
+//
+//	s.SetInjector(func(ctx *yarql.Ctx) {
+//		ctx.SetValue("db", db)
+//		ctx.SetAuthorizer(userFromToken(ctx.Header().Get("Authorization")))
+//	})
+func (s *Schema) SetInjector(injector Injector) {
+	s.injector = injector
+}
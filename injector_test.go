@@ -0,0 +1,61 @@
+package yarql
+
+import (
+	"errors"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestInjectorData struct{}
+
+func (TestInjectorData) ResolveDb(ctx *Ctx) string {
+	db, _ := CtxValue[string](ctx, "db")
+	return db
+}
+
+type testInjectorExtension struct {
+	BaseExtension
+	db *string
+}
+
+func (e testInjectorExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) {
+	*e.db, _ = CtxValue[string](ctx, "db")
+	return query, nil
+}
+
+func TestInjector(t *testing.T) {
+	calls := 0
+	extensionDB := ""
+	s := NewSchema()
+	s.SetInjector(func(ctx *Ctx) {
+		calls++
+		SetCtxValue(ctx, "db", "postgres "+ctx.Header().Get("Tenant"))
+	})
+	a.NoError(t, s.RegisterExtension(testInjectorExtension{db: &extensionDB}))
+	a.NoError(t, s.Parse(TestInjectorData{}, M{}, nil))
+	a.Equal(t, 0, calls, "precomputing the introspection should not call the injector")
+
+	errs := s.Resolve([]byte(`{db}`), ResolveOptions{NoMeta: true, Header: map[string][]string{"Tenant": {"a"}}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"db":"postgres a"}`, string(s.Result))
+	a.Equal(t, 1, calls)
+	a.Equal(t, "postgres a", extensionDB)
+
+	res, errs := s.HandleRequest(
+		"GET",
+		func(key string) string {
+			if key == "query" {
+				return "{db}"
+			}
+			return ""
+		},
+		func(key string) (string, error) { return "", errors.New("this should not be called") },
+		func() []byte { return nil },
+		"",
+		&RequestOptions{},
+	)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":{"db":"postgres "}}`, string(res))
+	a.Equal(t, 2, calls)
+}
@@ -0,0 +1,238 @@
+package yarql
+
+import (
+	"bytes"
+	"errors"
+	"math"
+)
+
+// IntrospectionQuery is the introspection query send by tools like GraphiQL to fetch the schema
+// The response to this query is computed while parsing the schema so it can be returned without resolving it
+const IntrospectionQuery = `query IntrospectionQuery {
+	__schema {
+		description
+		queryType {
+			name
+		}
+		mutationType {
+			name
+		}
+		subscriptionType {
+			name
+		}
+		types {
+			...FullType
+		}
+		directives {
+			name
+			description
+			isRepeatable
+			locations
+			args {
+				...InputValue
+			}
+		}
+	}
+}
+
+fragment FullType on __Type {
+	kind
+	name
+	description
+	specifiedByURL
+	fields(includeDeprecated: true) {
+		name
+		description
+		args {
+			...InputValue
+		}
+		type {
+			...TypeRef
+		}
+		isDeprecated
+		deprecationReason
+	}
+	inputFields {
+		...InputValue
+	}
+	interfaces {
+		...TypeRef
+	}
+	enumValues(includeDeprecated: true) {
+		name
+		description
+		isDeprecated
+		deprecationReason
+	}
+	possibleTypes {
+		...TypeRef
+	}
+}
+
+fragment InputValue on __InputValue {
+	name
+	description
+	type {
+		...TypeRef
+	}
+	defaultValue
+}
+
+fragment TypeRef on __Type {
+	kind
+	name
+	ofType {
+		kind
+		name
+		ofType {
+			kind
+			name
+			ofType {
+				kind
+				name
+				ofType {
+					kind
+					name
+					ofType {
+						kind
+						name
+						ofType {
+							kind
+							name
+							ofType {
+								kind
+								name
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+`
+
+// IntrospectionJSON returns the response of the IntrospectionQuery, like: {"data":{"__schema":{...}}}
+// This can be written to a file used by code generators like graphql-codegen or Apollo without starting a HTTP server
+// The introspection limits do not apply, extensions and the request logger are not called as this is not a real request
+func (s *Schema) IntrospectionJSON() ([]byte, error) {
+	if !s.parsed {
+		return nil, errors.New("(*yarql.Schema).IntrospectionJSON() cannot be ran before (*yarql.Schema).Parse()")
+	}
+	if s.reload != nil {
+		// The roots of s are modified while holding the lock when root resolvers are added after Parse
+		s.reload.lock.Lock()
+		defer s.reload.lock.Unlock()
+	}
+
+	introspection := s.introspectionSchema()
+	errs := introspection.Resolve([]byte(IntrospectionQuery), ResolveOptions{NoMeta: true})
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	res := make([]byte, 0, len(introspection.Result)+len(`{"data":}`))
+	res = append(res, `{"data":`...)
+	res = append(res, introspection.Result...)
+	return append(res, '}'), nil
+}
+
+// introspectionSchema returns a schema with its own context and result to resolve the IntrospectionQuery on outside of a request
+// It shares the parsed types of s but has none of its request hooks, like extensions, the request logger, the rate limiter and the injector,
+// and the introspection and result limits do not apply as the IntrospectionQuery is known to be safe
+func (s *Schema) introspectionSchema() *Schema {
+	res := &Schema{
+		parsed: true,
+
+		types:        s.types,
+		inTypes:      s.inTypes,
+		interfaces:   s.interfaces,
+		typeVariants: s.typeVariants,
+
+		rootQuery:         s.rootQuery,
+		rootQueryValue:    s.rootQueryValue,
+		rootMethod:        s.rootMethod,
+		rootMethodValue:   s.rootMethodValue,
+		MaxDepth:          s.MaxDepth,
+		definedEnums:      s.definedEnums,
+		definedDirectives: s.definedDirectives,
+		entities:          s.entities,
+		entitiesByName:    s.entitiesByName,
+		typeFederation:    s.typeFederation,
+		federationByName:  s.federationByName,
+		auth:              s.auth,
+		customScalars:     s.customScalars,
+		description:       s.description,
+		federation:        s.federation,
+		federationV2:      s.federationV2,
+		fieldVisibility:   s.fieldVisibility,
+		fieldScopes:       s.fieldScopes,
+		view:              s.view,
+
+		MaxIntrospectionDepth:  math.MaxUint8,
+		MaxIntrospectionFields: math.MaxInt,
+		InitialResultSize:      s.InitialResultSize,
+
+		graphqlObjFields: map[string][]qlField{},
+	}
+	res.ctx = newCtx(res)
+	return res
+}
+
+// precomputedQuery is a query of which the response is computed ahead of time
+type precomputedQuery struct {
+	bytecode  []byte
+	targetIdx int
+	result    []byte
+}
+
+// precomputeIntrospection resolves the IntrospectionQuery and stores its result
+// The query is resolved on the schema returned by introspectionSchema so s itself is not modified
+func (s *Schema) precomputeIntrospection() {
+	if s.executionStrategy != nil || s.fieldResolver != nil || s.fieldVisibility != nil {
+		return
+	}
+
+	introspection := s.introspectionSchema()
+	errs := introspection.Resolve([]byte(IntrospectionQuery), ResolveOptions{NoMeta: true})
+	if len(errs) > 0 {
+		return
+	}
+	s.precomputed = append(s.precomputed, precomputedQuery{
+		bytecode:  introspection.ctx.query.Res,
+		targetIdx: introspection.ctx.query.TargetIdx,
+		result:    introspection.Result,
+	})
+}
+
+// precomputedResult returns the precomputed result of the current query if there is one
+func (ctx *Ctx) precomputedResult() ([]byte, bool) {
+	if ctx.tracingEnabled || ctx.schema.executionStrategy != nil || ctx.schema.fieldResolver != nil {
+		return nil, false
+	}
+	for _, query := range ctx.schema.precomputed {
+		if query.targetIdx == ctx.query.TargetIdx && bytes.Equal(query.bytecode, ctx.query.Res) {
+			return query.result, true
+		}
+	}
+	return nil, false
+}
+
+// isIntrospectionField returns true for the __schema and __type fields
+func isIntrospectionField(field *obj) bool {
+	name := b2s(field.qlFieldName)
+	return name == "__schema" || name == "__type"
+}
+
+// checkIntrospectionLimits is called for every field resolved within an introspection field like __schema and __type
+// dept is the dept relative to the introspection field
+func (ctx *Ctx) checkIntrospectionLimits(dept uint8) bool {
+	ctx.introspectionFields++
+	if ctx.introspectionFields > ctx.schema.MaxIntrospectionFields {
+		return ctx.err("introspection query selects too many fields")
+	}
+	if dept > ctx.schema.MaxIntrospectionDepth {
+		return ctx.err("introspection query is too deeply nested")
+	}
+	return false
+}
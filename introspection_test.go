@@ -0,0 +1,99 @@
+package yarql
+
+import (
+	"encoding/json"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func TestIntrospectionQueryPrecomputed(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{}, M{}, nil))
+	s = s.Copy()
+	a.Equal(t, 1, len(s.precomputed))
+
+	errs := s.Resolve([]byte(IntrospectionQuery), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	precomputed := string(s.Result)
+
+	// Tracing disables the precomputed result so this resolves the query
+	errs = s.Resolve([]byte(IntrospectionQuery), ResolveOptions{NoMeta: true, Tracing: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, precomputed, string(s.Result))
+
+	// Make sure the precomputed result is written within the meta
+	errs = s.Resolve([]byte(IntrospectionQuery), ResolveOptions{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":`+precomputed+`}`, string(s.Result))
+}
+
+func TestIntrospectionMaxDepth(t *testing.T) {
+	query := `{__schema {types {fields {type {ofType {ofType {name}}}}}}}`
+
+	s := NewSchema()
+	_, errs := bytecodeParse(t, s, query, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 0, len(errs))
+
+	s = NewSchema()
+	s.MaxIntrospectionDepth = 4
+	_, errs = bytecodeParse(t, s, query, TestResolveSimpleQueryData{}, M{})
+	a.NotEqual(t, 0, len(errs))
+	a.Equal(t, "introspection query is too deeply nested", errs[0].Error())
+
+	// The depth is relative to the introspection field
+	_, errs = bytecodeParse(t, s, `{__type(name: "String") {name fields {type {name}}}}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 0, len(errs))
+}
+
+func TestIntrospectionMaxFields(t *testing.T) {
+	query := `{
+		a: __schema {types {name}}
+		b: __schema {types {name}}
+		c: __schema {types {name}}
+	}`
+
+	s := NewSchema()
+	_, errs := bytecodeParse(t, s, query, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 0, len(errs))
+
+	s = NewSchema()
+	s.MaxIntrospectionFields = 20
+	_, errs = bytecodeParse(t, s, query, TestResolveSimpleQueryData{}, M{})
+	a.NotEqual(t, 0, len(errs))
+	a.Equal(t, "introspection query selects too many fields", errs[0].Error())
+
+	// Other fields are not limited
+	_, errs = bytecodeParse(t, s, `{a b c d}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 0, len(errs))
+}
+
+func TestIntrospectionJSON(t *testing.T) {
+	s := NewSchema()
+	_, err := s.IntrospectionJSON()
+	a.Error(t, err)
+
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{}, M{}, nil))
+	// The introspection limits do not apply
+	s.MaxIntrospectionFields = 1
+
+	res, err := s.IntrospectionJSON()
+	a.NoError(t, err)
+	a.True(t, json.Valid(res))
+
+	var parsed struct {
+		Data struct {
+			Schema struct {
+				QueryType struct {
+					Name string `json:"name"`
+				} `json:"queryType"`
+				Types []struct {
+					Name string `json:"name"`
+				} `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	a.NoError(t, json.Unmarshal(res, &parsed))
+	a.Equal(t, "TestResolveSimpleQueryData", parsed.Data.Schema.QueryType.Name)
+	a.NotEqual(t, 0, len(parsed.Data.Schema.Types))
+}
@@ -0,0 +1,123 @@
+package yarql
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Iterator can be returned by resolvers to write the items of a list one by one without collecting them in a slice first
+// Next returns false once there are no more items, every interface with only a Next() (T, bool) method is seen as iterator
+// Functions with the signature func(yield func(T) bool) are also seen as iterator
+//
+// Example:
+//
+//	func (QueryRoot) ResolveEvents() yarql.Iterator[Event] {
+//		return db.StreamEvents()
+//	}
+type Iterator[T any] interface {
+	Next() (T, bool)
+}
+
+// iteratorKind is the kind of iterator of a list, see iteratorElem
+type iteratorKind uint8
+
+const (
+	iteratorNone iteratorKind = iota
+	// iteratorSeq is a func(yield func(T) bool)
+	iteratorSeq
+	// iteratorNext is a interface with only a Next() (T, bool) method
+	iteratorNext
+)
+
+// iteratorElem returns the type of the items of the iterator t
+func iteratorElem(t reflect.Type) (reflect.Type, iteratorKind) {
+	boolType := reflect.TypeOf(false)
+
+	switch t.Kind() {
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 || t.IsVariadic() {
+			return nil, iteratorNone
+		}
+		yield := t.In(0)
+		if yield.Kind() != reflect.Func || yield.NumIn() != 1 || yield.NumOut() != 1 || yield.Out(0) != boolType {
+			return nil, iteratorNone
+		}
+		return yield.In(0), iteratorSeq
+	case reflect.Interface:
+		if t.NumMethod() != 1 {
+			return nil, iteratorNone
+		}
+		next := t.Method(0)
+		if next.Name != "Next" || next.Type.NumIn() != 0 || next.Type.NumOut() != 2 || next.Type.Out(1) != boolType {
+			return nil, iteratorNone
+		}
+		return next.Type.Out(0), iteratorNext
+	}
+	return nil, iteratorNone
+}
+
+// resolveIterator writes the items of the iterator goValue as list
+// Iterating stops if the request is cancelled or the list items limit is reached
+func (ctx *Ctx) resolveIterator(typeObj *obj, goValue reflect.Value, dept uint8, hasSubSelection bool) {
+	if goValue.IsNil() {
+		ctx.writeNull()
+		return
+	}
+
+	itemObj := typeObj.innerContent
+	prefPathLen := len(ctx.path)
+	prefReflectValueIdx := ctx.currentReflectValueIdx
+
+	ctx.writeByte('[')
+	ctx.currentReflectValueIdx++
+
+	startCharNr := ctx.charNr
+	i := 0
+	yield := func(item reflect.Value) bool {
+		if ctx.isCancelled() || ctx.checkListItems(1) {
+			return false
+		}
+		if i > 0 {
+			ctx.writeByte(',')
+		}
+		ctx.charNr = startCharNr
+
+		ctx.path = append(ctx.path, ',')
+		ctx.path = strconv.AppendInt(ctx.path, int64(i), 10)
+
+		ctx.setGoValue(item)
+		ctx.resolveFieldDataValue(itemObj, dept, hasSubSelection)
+
+		ctx.path = ctx.path[:prefPathLen]
+		i++
+		return true
+	}
+	ctx.iterate(typeObj.iterator, goValue, yield)
+
+	// Restore the state in case the iterator panicked
+	ctx.path = ctx.path[:prefPathLen]
+	ctx.currentReflectValueIdx = prefReflectValueIdx
+	ctx.writeByte(']')
+}
+
+// iterate calls yield for every item of the iterator goValue until yield returns false, panics of the iterator are recovered like resolver panics
+func (ctx *Ctx) iterate(kind iteratorKind, goValue reflect.Value, yield func(item reflect.Value) bool) {
+	var outs []reflect.Value
+	defer ctx.recoverResolver(&outs)
+
+	if kind == iteratorSeq {
+		yieldFn := reflect.MakeFunc(goValue.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.ValueOf(yield(args[0]))}
+		})
+		goValue.Call([]reflect.Value{yieldFn})
+		return
+	}
+
+	next := goValue.Method(0)
+	for {
+		outs = next.Call(nil)
+		if !outs[1].Bool() || !yield(outs[0]) {
+			return
+		}
+	}
+}
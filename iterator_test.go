@@ -0,0 +1,91 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestIteratorItem struct {
+	ID int
+}
+
+type testIteratorCounter struct {
+	n   int
+	max int
+}
+
+func (c *testIteratorCounter) Next() (TestIteratorItem, bool) {
+	if c.n == c.max {
+		return TestIteratorItem{}, false
+	}
+	c.n++
+	return TestIteratorItem{ID: c.n}, true
+}
+
+type TestIteratorData struct {
+	Numbers func(yield func(int) bool)
+	Nil     func(yield func(int) bool)
+}
+
+func (TestIteratorData) ResolveItems(args struct{ Max int }) Iterator[TestIteratorItem] {
+	return &testIteratorCounter{max: args.Max}
+}
+
+func (TestIteratorData) ResolvePanics() func(yield func(string) bool) {
+	return func(yield func(string) bool) {
+		if !yield("a") {
+			return
+		}
+		panic("stream failed")
+	}
+}
+
+func newTestIteratorData(yielded *int) TestIteratorData {
+	return TestIteratorData{
+		Numbers: func(yield func(int) bool) {
+			for i := 1; i <= 5; i++ {
+				*yielded = i
+				if !yield(i) {
+					return
+				}
+			}
+		},
+	}
+}
+
+func TestBytecodeResolveIterator(t *testing.T) {
+	yielded := 0
+	res := bytecodeParseAndExpectNoErrs(t, `{numbers nil items(max: 3) {ID} empty: items {ID}}`, newTestIteratorData(&yielded), M{})
+	a.Equal(t, `{"numbers":[1,2,3,4,5],"nil":null,"items":[{"ID":1},{"ID":2},{"ID":3}],"empty":[]}`, res)
+	a.Equal(t, 5, yielded)
+}
+
+func TestBytecodeResolveIteratorMaxListItems(t *testing.T) {
+	yielded := 0
+	s := NewSchema()
+	s.MaxListItems = 2
+	res, errs := bytecodeParse(t, s, `{numbers}`, newTestIteratorData(&yielded), M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "response contains more than the max of 2 list items", errs[0].Error())
+	a.Equal(t, `{"numbers":[1,2]}`, res)
+
+	// The iterator is stopped once the limit is reached
+	a.Equal(t, 3, yielded)
+}
+
+func TestBytecodeResolveIteratorPanic(t *testing.T) {
+	res, errs := bytecodeParseAndExpectErrs(t, `{panics}`, TestIteratorData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "resolver panicked: stream failed", errs[0].Error())
+	a.Equal(t, `{"panics":["a"]}`, res)
+}
+
+func TestIteratorSDL(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestIteratorData{}, M{}, nil))
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "numbers: [Int!]"))
+	a.True(t, strings.Contains(sdl, "items(max: Int): [TestIteratorItem!]"))
+}
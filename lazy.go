@@ -0,0 +1,69 @@
+package yarql
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Lazy is a field value that is computed when the field is selected
+// Struct fields of type Lazy, or any other func() (T, error), can be assigned per object by the parent resolver, a nil Lazy resolves to null
+//
+// Example:
+//
+//	type Post struct {
+//		Title  string
+//		Author yarql.Lazy[User]
+//	}
+type Lazy[T any] func() (T, error)
+
+// NewLazy returns a Lazy that calls compute once, the result is reused if the field is selected multiple times
+// If compute loads a value that is still pending it's called again once the value is loaded
+func NewLazy[T any](compute func() (T, error)) Lazy[T] {
+	var lock sync.Mutex
+	var done bool
+	var value T
+	var err error
+	return func() (T, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if done {
+			return value, err
+		}
+		computedValue, computeErr := compute()
+		if errors.Is(computeErr, errLoadCollected) {
+			return computedValue, computeErr
+		}
+		done = true
+		value, err = computedValue, computeErr
+		return value, err
+	}
+}
+
+// LazyLoad queues key on loader and returns a Lazy that loads the value of key
+// As the keys are queued by the parent resolver all keys of a list are loaded in a single batch once the first Lazy is called
+//
+// Example:
+//
+//	func (QueryRoot) ResolvePosts(ctx *yarql.Ctx) []Post {
+//		posts := db.GetPosts()
+//		for i, post := range posts {
+//			posts[i].Author = yarql.LazyLoad[User](ctx.Loader("user"), post.AuthorID)
+//		}
+//		return posts
+//	}
+func LazyLoad[T any](loader *Loader, key interface{}) Lazy[T] {
+	loader.Queue(key)
+	return func() (T, error) {
+		var res T
+		value, err := loader.Load(key)
+		if err != nil || value == nil {
+			return res, err
+		}
+		res, ok := value.(T)
+		if !ok {
+			return res, fmt.Errorf("loader %s returned a value of type %T for key %v, expected %T", loader.name, value, key, res)
+		}
+		return res, nil
+	}
+}
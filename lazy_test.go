@@ -0,0 +1,97 @@
+package yarql
+
+import (
+	"errors"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestLazyQuery struct {
+	Calls *int `gq:"-"`
+}
+
+type TestLazyPost struct {
+	Title  string
+	Author Lazy[string]
+	Views  func() (int, error)
+}
+
+func (q TestLazyQuery) ResolvePosts(ctx *Ctx) []TestLazyPost {
+	posts := []TestLazyPost{{Title: "a"}, {Title: "b"}, {Title: "c"}}
+	for i := range posts {
+		authorID := i + 1
+		posts[i].Author = LazyLoad[string](ctx.Loader("user"), authorID)
+		posts[i].Views = NewLazy(func() (int, error) {
+			*q.Calls++
+			return authorID * 10, nil
+		})
+	}
+	return posts
+}
+
+func registerTestLazyLoader(t *testing.T, s *Schema, batches *[][]interface{}) {
+	a.NoError(t, s.RegisterLoader("user", func(ctx *Ctx, keys []interface{}) ([]interface{}, error) {
+		*batches = append(*batches, keys)
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			switch key {
+			case 2:
+				values[i] = 2
+			case 3:
+				values[i] = nil
+			default:
+				values[i] = "user 1"
+			}
+		}
+		return values, nil
+	}))
+}
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	batches := [][]interface{}{}
+
+	// Lazy values are only computed if they are selected
+	s := NewSchema()
+	registerTestLazyLoader(t, s, &batches)
+	_, errs := bytecodeParse(t, s, `{posts {title}}`, TestLazyQuery{Calls: &calls}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, 0, calls)
+	a.Equal(t, 0, len(batches))
+
+	s = NewSchema()
+	registerTestLazyLoader(t, s, &batches)
+	res, errs := bytecodeParse(t, s, `{posts {title a: views b: views}}`, TestLazyQuery{Calls: &calls}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"posts":[{"title":"a","a":10,"b":10},{"title":"b","a":20,"b":20},{"title":"c","a":30,"b":30}]}`, res)
+	a.Equal(t, 3, calls)
+}
+
+func TestLazyLoad(t *testing.T) {
+	calls := 0
+	batches := [][]interface{}{}
+	s := NewSchema()
+	registerTestLazyLoader(t, s, &batches)
+
+	res, errs := bytecodeParse(t, s, `{posts {author}}`, TestLazyQuery{Calls: &calls}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "loader user returned a value of type int for key 2, expected string", errs[0].Error())
+	a.Equal(t, `{"posts":[{"author":"user 1"},{"author":""},{"author":""}]}`, res)
+
+	// All keys are loaded in a single batch
+	a.Equal(t, [][]interface{}{{1, 2, 3}}, batches)
+}
+
+func TestNewLazyError(t *testing.T) {
+	calls := 0
+	lazy := NewLazy(func() (string, error) {
+		calls++
+		return "", errors.New("failed")
+	})
+	_, err := lazy()
+	a.Error(t, err)
+	_, err = lazy()
+	a.Error(t, err)
+	a.Equal(t, 1, calls)
+}
@@ -0,0 +1,196 @@
+package yarql
+
+import (
+	"sort"
+	"strings"
+)
+
+// LintRule is the rule that caused a LintWarning
+type LintRule string
+
+// All lint rules
+const (
+	// LintUnusedType is reported for registered types like enums and scalars that are not used by any field or argument
+	LintUnusedType LintRule = "unused-type"
+	// LintNameConflict is reported if multiple types have the same name, for example an enum and a struct
+	LintNameConflict LintRule = "name-conflict"
+	// LintCaseConflict is reported for types with names that only differ by case
+	LintCaseConflict LintRule = "case-conflict"
+	// LintNamingConvention is reported for types that are not PascalCase, fields and arguments that are not camelCase and enum values that are not UPPER_CASE
+	LintNamingConvention LintRule = "naming-convention"
+)
+
+// LintWarning is a possible problem in the schema reported by (*yarql.Schema).Lint
+type LintWarning struct {
+	Rule LintRule
+	// Path is the location of the problem, a type name like User, a field like User.name or a argument like User.friends(first)
+	Path    string
+	Message string
+}
+
+func (w LintWarning) String() string {
+	return w.Path + ": " + w.Message + " (" + string(w.Rule) + ")"
+}
+
+// Lint checks the schema for problems that are accepted by (*yarql.Schema).Parse but likely mistakes
+// The warnings are sorted by path
+// Note that fields returning interface{} are not reported as Parse already rejects them
+func (s *Schema) Lint() []LintWarning {
+	if !s.parsed {
+		panic("Schema has not been parsed yet, call Parse before attempting to lint the schema")
+	}
+
+	res := []LintWarning{}
+	warn := func(rule LintRule, path string, message string) {
+		res = append(res, LintWarning{Rule: rule, Path: path, Message: message})
+	}
+
+	allTypes := s.getAllQLTypes()
+	typesByName := map[string]qlType{}
+	namesByLowerName := map[string][]string{}
+	for _, qlType := range allTypes {
+		name := *qlType.Name
+		if isLintBuiltin(name) {
+			continue
+		}
+		if _, ok := typesByName[name]; ok {
+			warn(LintNameConflict, name, "multiple types have the name "+name+", only one of them can be used")
+			continue
+		}
+		typesByName[name] = qlType
+
+		lowerName := strings.ToLower(name)
+		namesByLowerName[lowerName] = append(namesByLowerName[lowerName], name)
+	}
+
+	for _, names := range namesByLowerName {
+		if len(names) > 1 {
+			sort.Strings(names)
+			for _, name := range names {
+				warn(LintCaseConflict, name, "type names "+strings.Join(names, ", ")+" only differ by case")
+			}
+		}
+	}
+
+	// Find the types that can be reached from the root types and directives
+	used := map[string]bool{}
+	var use func(t qlType)
+	use = func(t qlType) {
+		for t.OfType != nil {
+			t = *t.OfType
+		}
+		if t.Name == nil || used[*t.Name] {
+			return
+		}
+		name := *t.Name
+		used[name] = true
+		t, ok := typesByName[name]
+		if !ok {
+			return
+		}
+
+		switch t.Kind {
+		case typeKindObject, typeKindInterface:
+			for _, field := range t.Fields(nil, isDeprecatedArgs{IncludeDeprecated: true}) {
+				use(field.Type)
+				for _, arg := range field.Args {
+					use(arg.Type)
+				}
+			}
+			for _, implements := range t.Interfaces {
+				use(implements)
+			}
+			if t.PossibleTypes != nil {
+				for _, possibleType := range t.PossibleTypes() {
+					use(possibleType)
+				}
+			}
+		case typeKindInputObject:
+			for _, field := range t.InputFields() {
+				use(field.Type)
+			}
+		}
+	}
+	if typeObj, ok := typesByName[s.rootQuery.typeName]; ok {
+		use(typeObj)
+	}
+	if typeObj, ok := typesByName[s.rootMethod.typeName]; ok && hasVisibleFields(s.rootMethod) {
+		use(typeObj)
+	}
+	for _, directive := range s.getDirectives() {
+		for _, arg := range directive.Args {
+			use(arg.Type)
+		}
+	}
+
+	for name, qlType := range typesByName {
+		if !used[name] && name != s.rootMethod.typeName {
+			warn(LintUnusedType, name, "type is not used by any field or argument")
+		}
+
+		if !isLintPascalCase(name) {
+			warn(LintNamingConvention, name, "type names should be PascalCase")
+		}
+
+		switch qlType.Kind {
+		case typeKindObject, typeKindInterface:
+			for _, field := range qlType.Fields(nil, isDeprecatedArgs{IncludeDeprecated: true}) {
+				if !isLintCamelCase(field.Name) {
+					warn(LintNamingConvention, name+"."+field.Name, "field names should be camelCase")
+				}
+				for _, arg := range field.Args {
+					if !isLintCamelCase(arg.Name) {
+						warn(LintNamingConvention, name+"."+field.Name+"("+arg.Name+")", "argument names should be camelCase")
+					}
+				}
+			}
+		case typeKindInputObject:
+			for _, field := range qlType.InputFields() {
+				if !isLintCamelCase(field.Name) {
+					warn(LintNamingConvention, name+"."+field.Name, "input field names should be camelCase")
+				}
+			}
+		case typeKindEnum:
+			for _, value := range qlType.EnumValues(isDeprecatedArgs{IncludeDeprecated: true}) {
+				if !isLintUpperCase(value.Name) {
+					warn(LintNamingConvention, name+"."+value.Name, "enum values should be UPPER_CASE")
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Path != res[j].Path {
+			return res[i].Path < res[j].Path
+		}
+		return res[i].Rule < res[j].Rule
+	})
+	return res
+}
+
+// isLintBuiltin returns true for the types that are always part of the schema
+func isLintBuiltin(name string) bool {
+	_, isScalar := scalars[name]
+	return isScalar || introspectionTypes[name] || builtinScalars[name] || federationTypes[name] || strings.HasPrefix(name, "_")
+}
+
+func isLintPascalCase(name string) bool {
+	return len(name) > 0 && name[0] >= 'A' && name[0] <= 'Z' && !strings.Contains(name, "_")
+}
+
+// isLintCamelCase also accepts names starting with an underscore as these are used for internal fields like _entities
+func isLintCamelCase(name string) bool {
+	if strings.HasPrefix(name, "_") {
+		return true
+	}
+	return len(name) > 0 && name[0] >= 'a' && name[0] <= 'z' && !strings.Contains(name, "_")
+}
+
+func isLintUpperCase(name string) bool {
+	for _, c := range name {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' {
+			return false
+		}
+	}
+	return len(name) > 0
+}
@@ -0,0 +1,78 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestLintUsedEnum uint8
+type TestLintUnusedEnum uint8
+type TestLintUserType uint8
+
+type TestLintUser struct {
+	Name     string
+	LastName string `gq:"last_name"`
+	Role     TestLintUsedEnum
+}
+
+type TestLintuser struct {
+	Name string
+}
+
+type TestLintOther struct {
+	Name string
+}
+
+var _ = TypeRename(TestLintOther{}, "TestLintUserType")
+
+type TestLintData struct {
+	Users    []TestLintUser
+	Others   []TestLintuser
+	Conflict TestLintOther
+}
+
+func (TestLintData) ResolveSearch(args struct{ Search_term string }) string {
+	return args.Search_term
+}
+
+func TestLint(t *testing.T) {
+	s := NewSchema()
+	_, err := s.RegisterEnum(map[string]TestLintUsedEnum{"ADMIN": 0, "user": 1})
+	a.NoError(t, err)
+	_, err = s.RegisterEnum(map[string]TestLintUnusedEnum{"A": 0})
+	a.NoError(t, err)
+	// An enum with the same name as a struct type
+	_, err = s.RegisterEnum(map[string]TestLintUserType{"A": 0})
+	a.NoError(t, err)
+
+	a.NoError(t, s.Parse(TestLintData{}, M{}, nil))
+
+	warnings := []string{}
+	for _, warning := range s.Lint() {
+		warnings = append(warnings, warning.String())
+	}
+	a.Equal(t, []string{
+		"TestLintData.search(search_term): argument names should be camelCase (naming-convention)",
+		"TestLintUnusedEnum: type is not used by any field or argument (unused-type)",
+		"TestLintUsedEnum.user: enum values should be UPPER_CASE (naming-convention)",
+		"TestLintUser: type names TestLintUser, TestLintuser only differ by case (case-conflict)",
+		"TestLintUser.last_name: field names should be camelCase (naming-convention)",
+		"TestLintUserType: multiple types have the name TestLintUserType, only one of them can be used (name-conflict)",
+		"TestLintuser: type names TestLintUser, TestLintuser only differ by case (case-conflict)",
+	}, warnings)
+}
+
+func TestLintClean(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{}, M{}, nil))
+	a.Equal(t, 0, len(s.Lint()))
+}
+
+func TestLintNotParsed(t *testing.T) {
+	defer func() {
+		a.True(t, strings.Contains(recover().(string), "Parse"))
+	}()
+	NewSchema().Lint()
+}
@@ -0,0 +1,311 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// BatchFunc loads the values of keys in one go, for example using a single database query
+// The returned values must have the same length and order as keys
+type BatchFunc func(ctx *Ctx, keys []interface{}) ([]interface{}, error)
+
+// RegisterLoader registers a batch function that can be used within resolvers using (*yarql.Ctx).Loader(name)
+//
+// Example:
+//
+//	s.RegisterLoader("user", func(ctx *yarql.Ctx, keys []interface{}) ([]interface{}, error) {
+//		return db.GetUsersByIDs(keys)
+//	})
+func (s *Schema) RegisterLoader(name string, batch BatchFunc) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterLoader() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if len(name) == 0 {
+		return errors.New("loader name cannot be empty")
+	}
+	if batch == nil {
+		return errors.New("batch function cannot be nil")
+	}
+	if _, ok := s.loaders[name]; ok {
+		return errors.New("a loader with the name " + name + " is already registered")
+	}
+
+	s.loaders[name] = batch
+	return nil
+}
+
+// Loader batches and caches the loading of values during a single request
+// Keys must be comparable as they are used as map keys
+//
+// Resolvers are called one after another so a loader cannot wait for sibling resolvers to request their keys.
+// Instead the fields of a query that load a key that is not cached are deferred until the other fields are resolved, see (*Ctx).resolveDeferredFields.
+// Keys can also be queued, for example by the resolver of a list, once a key is loaded all queued keys are loaded within the same batch
+type Loader struct {
+	ctx   *Ctx
+	name  string
+	batch BatchFunc
+	cache *loaderCache
+}
+
+// loaderCache contains the loaded values of a loader, it's shared between the operations of a batched request if (*Schema).ShareBatchLoaders is set
+// The batch function is called while holding the lock so operations resolved at the same time do not load the same keys twice
+type loaderCache struct {
+	lock    sync.Mutex
+	results map[interface{}]loaderResult
+	queued  []interface{}
+}
+
+type loaderResult struct {
+	value interface{}
+	err   error
+}
+
+// batchLoaders contains the loader caches shared between the operations of a batched request
+type batchLoaders struct {
+	lock   sync.Mutex
+	caches map[string]*loaderCache
+}
+
+func newBatchLoaders() *batchLoaders {
+	return &batchLoaders{caches: map[string]*loaderCache{}}
+}
+
+func newLoaderCache() *loaderCache {
+	return &loaderCache{results: map[interface{}]loaderResult{}}
+}
+
+// cache returns the shared cache of the loader with name
+func (b *batchLoaders) cache(name string) *loaderCache {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	cache, ok := b.caches[name]
+	if !ok {
+		cache = newLoaderCache()
+		b.caches[name] = cache
+	}
+	return cache
+}
+
+// Loader returns the loader registered under name using (*yarql.Schema).RegisterLoader
+// The loader and its cache only live as long as the request, or as long as the batched request if (*Schema).ShareBatchLoaders is set
+func (ctx *Ctx) Loader(name string) *Loader {
+	if ctx.loaders == nil {
+		ctx.loaders = map[string]*Loader{}
+	}
+	loader, ok := ctx.loaders[name]
+	if !ok {
+		loader = &Loader{
+			ctx:   ctx,
+			name:  name,
+			batch: ctx.schema.loaders[name],
+		}
+		if ctx.batchLoaders != nil {
+			loader.cache = ctx.batchLoaders.cache(name)
+		} else {
+			loader.cache = newLoaderCache()
+		}
+		ctx.loaders[name] = loader
+	}
+	return loader
+}
+
+// Queue adds keys to the next batch without loading them
+func (l *Loader) Queue(keys ...interface{}) {
+	l.cache.lock.Lock()
+	defer l.cache.lock.Unlock()
+
+	l.queue(keys)
+}
+
+func (l *Loader) queue(keys []interface{}) {
+	for _, key := range keys {
+		if _, ok := l.cache.results[key]; !ok {
+			l.cache.queued = append(l.cache.queued, key)
+		}
+	}
+}
+
+// Prime sets the value of key in the cache so it doesn't have to be loaded
+func (l *Loader) Prime(key interface{}, value interface{}) {
+	l.cache.lock.Lock()
+	defer l.cache.lock.Unlock()
+
+	l.cache.results[key] = loaderResult{value: value}
+}
+
+// Load returns the value of key, if the value is not yet cached all queued keys are loaded together with key
+// If the batch function returns an error this error is returned for all keys of the batch
+func (l *Loader) Load(key interface{}) (interface{}, error) {
+	l.cache.lock.Lock()
+	defer l.cache.lock.Unlock()
+
+	result, ok := l.cache.results[key]
+	if !ok {
+		l.queue([]interface{}{key})
+		if l.ctx.collectingLoads {
+			l.ctx.loadPending = true
+			return nil, errLoadCollected
+		}
+		l.dispatch()
+		result = l.cache.results[key]
+	}
+	return result.value, result.err
+}
+
+// LoadMany returns the values of keys, all keys that are not yet cached are loaded in a single batch
+func (l *Loader) LoadMany(keys []interface{}) ([]interface{}, error) {
+	l.cache.lock.Lock()
+	defer l.cache.lock.Unlock()
+
+	l.queue(keys)
+	if l.ctx.collectingLoads {
+		for _, key := range keys {
+			if _, ok := l.cache.results[key]; !ok {
+				l.ctx.loadPending = true
+				return nil, errLoadCollected
+			}
+		}
+	}
+	l.dispatch()
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		result := l.cache.results[key]
+		if result.err != nil {
+			return nil, result.err
+		}
+		values[i] = result.value
+	}
+	return values, nil
+}
+
+// dispatch loads all queued keys, expects the lock of the cache to be held
+func (l *Loader) dispatch() {
+	cache := l.cache
+	if len(cache.queued) == 0 {
+		return
+	}
+
+	// Remove keys that are queued multiple times
+	keys := make([]interface{}, 0, len(cache.queued))
+	seen := map[interface{}]bool{}
+	for _, key := range cache.queued {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	cache.queued = cache.queued[:0]
+
+	var values []interface{}
+	var err error
+	if l.batch == nil {
+		err = errors.New("no loader registered with the name " + l.name)
+	} else {
+		values, err = l.batch(l.ctx, keys)
+		if err == nil && len(values) != len(keys) {
+			err = errors.New("loader " + l.name + " returned " + strconv.Itoa(len(values)) + " values for " + strconv.Itoa(len(keys)) + " keys")
+		}
+	}
+
+	for i, key := range keys {
+		if err != nil {
+			cache.results[key] = loaderResult{err: err}
+		} else {
+			cache.results[key] = loaderResult{value: values[i]}
+		}
+	}
+}
+
+// errLoadCollected is returned by a loader while collecting keys, the field that is being resolved is deferred
+var errLoadCollected = errors.New("the value is loaded after the keys of the sibling resolvers are collected")
+
+// deferredField is a field of which the resolver loaded a key that was not cached
+// The field is left out of the result and written at resultAt once the key is loaded
+type deferredField struct {
+	resultAt       int
+	charNr         int // location of the field within the bytecode
+	typeObj        *obj
+	dept           uint8
+	addCommaBefore bool
+	parent         reflect.Value
+	parentIdx      uint8
+	path           []byte // path of the parent
+}
+
+// deferField leaves the field that is currently being resolved out of the result so it can be resolved once the pending keys are loaded
+// resultStart and errorsCount are the length of the result and errors before the field was written
+func (ctx *Ctx) deferField(typeObj *obj, dept uint8, addCommaBefore bool, resultStart int, errorsCount int, parentPathLen int) {
+	ctx.loadPending = false
+	ctx.schema.Result = ctx.schema.Result[:resultStart]
+	ctx.query.Errors = ctx.query.Errors[:errorsCount]
+
+	ctx.deferredFields = append(ctx.deferredFields, deferredField{
+		resultAt:       resultStart,
+		charNr:         ctx.fieldAt + 1,
+		typeObj:        typeObj,
+		dept:           dept,
+		addCommaBefore: addCommaBefore,
+		parent:         ctx.getGoValue(),
+		parentIdx:      ctx.currentReflectValueIdx,
+		path:           append([]byte(nil), ctx.path[:parentPathLen]...),
+	})
+}
+
+// resolveDeferredFields loads the keys queued by the deferred fields in one batch per loader and writes the deferred fields at the location they were deferred from
+// Fields deferred while resolving the deferred fields, like the children of a deferred field, are deferred to the next call
+func (ctx *Ctx) resolveDeferredFields() bool {
+	deferred := ctx.deferredFields
+	ctx.deferredFields = nil
+
+	queued := false
+	for _, loader := range ctx.loaders {
+		loader.cache.lock.Lock()
+		if len(loader.cache.queued) > 0 {
+			queued = true
+			loader.dispatch()
+		}
+		loader.cache.lock.Unlock()
+	}
+	if !queued {
+		// The keys were loaded by another operation of the batch, there is nothing left to collect
+		ctx.collectingLoads = false
+	}
+
+	result := ctx.schema.Result
+	criticalErr := false
+
+	// shift is the amount of bytes inserted before the location of the next field
+	shift := 0
+	for _, field := range deferred {
+		ctx.schema.Result = ctx.deferredResult[:0]
+		ctx.path = append(ctx.path[:0], field.path...)
+		ctx.currentReflectValueIdx = field.parentIdx
+		ctx.reflectValues[field.parentIdx] = field.parent
+		ctx.charNr = field.charNr
+		nestedStart := len(ctx.deferredFields)
+		_, fieldCriticalErr := ctx.resolveField(field.typeObj, field.dept, field.addCommaBefore)
+		criticalErr = criticalErr || fieldCriticalErr
+
+		written := ctx.schema.Result
+		ctx.deferredResult = written
+		insertAt := field.resultAt + shift
+		result = append(result, written...)
+		copy(result[insertAt+len(written):], result[insertAt:len(result)-len(written)])
+		copy(result[insertAt:], written)
+		shift += len(written)
+
+		// Fields deferred by this field are deferred to the next call, their locations are relative to the start of this field
+		for i := nestedStart; i < len(ctx.deferredFields); i++ {
+			ctx.deferredFields[i].resultAt += insertAt
+		}
+	}
+
+	ctx.schema.Result = result
+	ctx.path = ctx.path[:0]
+	ctx.currentReflectValueIdx = 0
+	return criticalErr
+}
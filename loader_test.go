@@ -0,0 +1,236 @@
+package yarql
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestLoaderQuery struct{}
+
+func (TestLoaderQuery) ResolvePosts(ctx *Ctx) []TestLoaderPost {
+	posts := []TestLoaderPost{{AuthorID: 1}, {AuthorID: 2}, {AuthorID: 1}, {AuthorID: 3}}
+	for _, post := range posts {
+		ctx.Loader("user").Queue(post.AuthorID)
+	}
+	return posts
+}
+
+func (TestLoaderQuery) ResolveUnqueuedPosts() []TestLoaderPost {
+	return []TestLoaderPost{{AuthorID: 1}, {AuthorID: 2}, {AuthorID: 1}}
+}
+
+func (TestLoaderQuery) ResolveUser(ctx *Ctx, args struct{ ID int }) (string, error) {
+	user, err := ctx.Loader("user").Load(args.ID)
+	if err != nil {
+		return "", err
+	}
+	return user.(string), nil
+}
+
+type TestLoaderPost struct {
+	AuthorID int
+}
+
+func (p TestLoaderPost) ResolveAuthor(ctx *Ctx) (string, error) {
+	user, err := ctx.Loader("user").Load(p.AuthorID)
+	if err != nil {
+		return "", err
+	}
+	return user.(string), nil
+}
+
+func registerTestUserLoader(t *testing.T, s *Schema, batches *[][]interface{}) {
+	a.NoError(t, s.RegisterLoader("user", func(ctx *Ctx, keys []interface{}) ([]interface{}, error) {
+		*batches = append(*batches, keys)
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			if key == 3 {
+				return nil, errors.New("user 3 not found")
+			}
+			values[i] = "user " + string(rune('0'+key.(int)))
+		}
+		return values, nil
+	}))
+}
+
+func TestLoaderBatchesQueuedKeys(t *testing.T) {
+	batches := [][]interface{}{}
+	s := NewSchema()
+	registerTestUserLoader(t, s, &batches)
+
+	_, errs := bytecodeParse(t, s, `{posts {author}}`, TestLoaderQuery{}, M{})
+	a.Equal(t, 4, len(errs))
+	a.Equal(t, "user 3 not found", errs[0].Error())
+	a.Equal(t, 1, len(batches))
+	a.Equal(t, []interface{}{1, 2, 3}, batches[0])
+}
+
+func TestLoaderCachePerRequest(t *testing.T) {
+	batches := [][]interface{}{}
+	s := NewSchema()
+	registerTestUserLoader(t, s, &batches)
+	a.NoError(t, s.Parse(TestLoaderQuery{}, M{}, nil))
+
+	// The cache only lives as long as the request
+	s.Resolve([]byte(`{posts {author}}`), ResolveOptions{NoMeta: true})
+	s.Resolve([]byte(`{posts {author}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 2, len(batches))
+}
+
+func TestLoaderBatchesSiblingLoads(t *testing.T) {
+	batches := [][]interface{}{}
+	s := NewSchema()
+	registerTestUserLoader(t, s, &batches)
+
+	res, errs := bytecodeParse(t, s, `{unqueuedPosts {author} first: user(ID: 1) second: user(ID: 4)}`, TestLoaderQuery{}, M{})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"unqueuedPosts":[{"author":"user 1"},{"author":"user 2"},{"author":"user 1"}],"first":"user 1","second":"user 4"}`, res)
+	a.Equal(t, [][]interface{}{{1, 2, 4}}, batches)
+
+	// Errors of the batch are reported once per field
+	batches = [][]interface{}{}
+	s = NewSchema()
+	registerTestUserLoader(t, s, &batches)
+	_, errs = bytecodeParse(t, s, `{first: user(ID: 3) second: user(ID: 1)}`, TestLoaderQuery{}, M{})
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, "user 3 not found", errs[0].Error())
+	a.Equal(t, [][]interface{}{{3, 1}}, batches)
+}
+
+func TestLoaderCache(t *testing.T) {
+	batches := [][]interface{}{}
+	s := NewSchema()
+	registerTestUserLoader(t, s, &batches)
+	a.NoError(t, s.Parse(TestLoaderQuery{}, M{}, nil))
+	loader := s.ctx.Loader("user")
+
+	value, err := loader.Load(1)
+	a.NoError(t, err)
+	a.Equal(t, "user 1", value)
+
+	loader.Prime(5, "primed")
+	values, err := loader.LoadMany([]interface{}{1, 2, 5})
+	a.NoError(t, err)
+	a.Equal(t, []interface{}{"user 1", "user 2", "primed"}, values)
+	a.Equal(t, [][]interface{}{{1}, {2}}, batches)
+
+	_, err = s.ctx.Loader("unknown").Load(1)
+	a.Error(t, err)
+}
+
+func TestRegisterLoaderInvalid(t *testing.T) {
+	s := NewSchema()
+	batch := func(ctx *Ctx, keys []interface{}) ([]interface{}, error) { return nil, nil }
+	a.Error(t, s.RegisterLoader("", batch))
+	a.Error(t, s.RegisterLoader("a", nil))
+	a.NoError(t, s.RegisterLoader("a", batch))
+	a.Error(t, s.RegisterLoader("a", batch))
+
+	a.NoError(t, s.Parse(TestLoaderQuery{}, M{}, nil))
+	a.Error(t, s.RegisterLoader("b", batch))
+}
+
+func TestLoaderSharedBetweenBatchOperations(t *testing.T) {
+	batches := [][]interface{}{}
+	s := NewSchema()
+	a.NoError(t, s.RegisterLoader("user", func(ctx *Ctx, keys []interface{}) ([]interface{}, error) {
+		batches = append(batches, keys)
+		values := make([]interface{}, len(keys))
+		for i := range keys {
+			values[i] = "user"
+		}
+		return values, nil
+	}))
+	a.NoError(t, s.Parse(TestLoaderQuery{}, M{}, nil))
+	s.ShareBatchLoaders = true
+
+	body := `[` + strings.Repeat(`{"query": "{posts {author}}"},`, 4) + `{"query": "{posts {author}}"}]`
+	handle := func() {
+		_, errs := s.HandleRequest(
+			"POST",
+			func(key string) string { return "" },
+			func(key string) (string, error) { return "", errors.New("this should not be called") },
+			func() []byte { return []byte(body) },
+			"application/json",
+			nil,
+		)
+		a.Equal(t, 0, len(errs))
+	}
+
+	handle()
+	a.Equal(t, [][]interface{}{{1, 2, 3}}, batches)
+
+	// The cache only lives as long as the batched request
+	s.BatchConcurrency = 3
+	handle()
+	a.Equal(t, 2, len(batches))
+}
+
+type TestLoaderDeferQuery struct {
+	calls map[string]int `gq:"-"`
+}
+
+func (q TestLoaderDeferQuery) ResolveExpensive() string {
+	q.calls["expensive"]++
+	return "expensive"
+}
+
+func (q TestLoaderDeferQuery) ResolveItems() []TestLoaderDeferItem {
+	q.calls["items"]++
+	return []TestLoaderDeferItem{{ID: 1, calls: q.calls}, {ID: 2, calls: q.calls}}
+}
+
+type TestLoaderDeferItem struct {
+	ID    int
+	calls map[string]int `gq:"-"`
+}
+
+func (i TestLoaderDeferItem) ResolveName(ctx *Ctx) (string, error) {
+	i.calls["name"]++
+	name, err := ctx.Loader("name").Load(i.ID)
+	if err != nil {
+		return "", err
+	}
+	return name.(string), nil
+}
+
+func (i TestLoaderDeferItem) ResolveParent(ctx *Ctx) (*TestLoaderDeferItem, error) {
+	_, err := ctx.Loader("name").Load(i.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &TestLoaderDeferItem{ID: i.ID * 10, calls: i.calls}, nil
+}
+
+func TestLoaderOnlyDefersPendingFields(t *testing.T) {
+	calls := map[string]int{}
+	batches := [][]interface{}{}
+	s := NewSchema()
+	a.NoError(t, s.RegisterLoader("name", func(ctx *Ctx, keys []interface{}) ([]interface{}, error) {
+		batches = append(batches, keys)
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = "item " + strconv.Itoa(key.(int))
+		}
+		return values, nil
+	}))
+	a.NoError(t, s.Parse(TestLoaderDeferQuery{calls: calls}, M{}, nil))
+
+	out, errs := s.Exec([]byte(`{expensive items {ID name} after: expensive}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"expensive":"expensive","items":[{"ID":1,"name":"item 1"},{"ID":2,"name":"item 2"}],"after":"expensive"}`, string(out))
+	a.Equal(t, 2, calls["expensive"])
+	a.Equal(t, 1, calls["items"])
+	a.Equal(t, [][]interface{}{{1, 2}}, batches)
+
+	// Children of deferred fields are deferred to the next batch and fields are written in the order of the query
+	batches = [][]interface{}{}
+	out, errs = s.Exec([]byte(`{items {name parent {name ID} ID}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"items":[{"name":"item 1","parent":{"name":"item 10","ID":10},"ID":1},{"name":"item 2","parent":{"name":"item 20","ID":20},"ID":2}]}`, string(out))
+	a.Equal(t, [][]interface{}{{1, 2}, {10, 20}}, batches)
+}
@@ -0,0 +1,217 @@
+package yarql
+
+import (
+	"encoding/json"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// LookaheadField is a field selected within the selection set of the field that is being resolved, see (*yarql.Ctx).SelectedFields
+type LookaheadField struct {
+	// Name is the name of the field in the schema
+	Name string
+	// Alias is the key of the field in the response, equals Name if no alias was used
+	Alias string
+	// Arguments contains the arguments of the field as JSON object, variables are resolved
+	Arguments json.RawMessage
+	// Fields are the fields selected within the selection set of this field, nil if the field has no selection set
+	Fields []LookaheadField
+}
+
+// SelectedFields returns the fields selected within the selection set of the field that is currently being resolved
+// This allows a resolver to only fetch the data that is requested, for example by only selecting the needed columns of a database table
+//
+// Fragments are flattened and fields skipped by directives are left out
+// If the field returns an interface the fields of all fragments are included as the type of the value is not known yet
+// Returns nil if the field has no selection set or if no field is being resolved
+//
+// Example:
+//
+//	func (QueryRoot) ResolveUsers(ctx *yarql.Ctx) ([]User, error) {
+//		fields, err := ctx.SelectedFields()
+//		if err != nil {
+//			return nil, err
+//		}
+//		columns := []string{}
+//		for _, field := range fields {
+//			columns = append(columns, field.Name)
+//		}
+//		return db.SelectUsers(columns)
+//	}
+func (ctx *Ctx) SelectedFields() ([]LookaheadField, error) {
+	if ctx.fieldAt == -1 {
+		return nil, nil
+	}
+
+	startCharNr := ctx.charNr
+	errorsLen := len(ctx.query.Errors)
+
+	// Skip over the header of the field to its selection set
+	ctx.charNr = ctx.fieldAt + 1
+	directivesCount := ctx.readInst()
+	ctx.skipInst(8) // the length of the field and the name key
+	ctx.skipInst(int(ctx.readInst()))
+	ctx.skipInst(int(ctx.readInst()))
+	ctx.skipInst(1)
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.skipDirective()
+	}
+	ctx.skipArguments()
+
+	var fields []LookaheadField
+	criticalErr := false
+	if ctx.seekInst() != bytecode.ActionEnd {
+		var typeObj *obj
+		if ctx.field != nil {
+			typeObj = ctx.schema.complexityType(ctx.field)
+		}
+		fields = []LookaheadField{}
+		criticalErr = ctx.lookaheadSelectionSet(typeObj, &fields)
+	}
+
+	ctx.charNr = startCharNr
+	if criticalErr {
+		err := ctx.query.Errors[len(ctx.query.Errors)-1]
+		ctx.query.Errors = ctx.query.Errors[:errorsLen]
+		return nil, err
+	}
+	return fields, nil
+}
+
+// lookaheadSelectionSet adds the fields of the selection set at the current charNr to fields
+// typeObj is the type the selection set is selected on, nil if unknown
+func (ctx *Ctx) lookaheadSelectionSet(typeObj *obj, fields *[]LookaheadField) bool {
+	for {
+		switch ctx.readInst() {
+		case bytecode.ActionField:
+			criticalErr := ctx.lookaheadField(typeObj, fields)
+			if criticalErr {
+				return criticalErr
+			}
+		case bytecode.ActionSpread:
+			criticalErr := ctx.lookaheadSpread(typeObj, fields)
+			if criticalErr {
+				return criticalErr
+			}
+		default:
+			// End of the selection set
+			return false
+		}
+	}
+}
+
+// lookaheadField adds the field at the current charNr to fields, the charNr is moved to the end of the field
+func (ctx *Ctx) lookaheadField(typeObj *obj, fields *[]LookaheadField) bool {
+	directivesCount := ctx.readInst()
+	fieldLen := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	nameKey := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	endOfField := ctx.charNr + int(fieldLen)
+
+	aliasLen := int(ctx.readInst())
+	alias := ctx.query.Res[ctx.charNr : ctx.charNr+aliasLen]
+	ctx.skipInst(aliasLen)
+	name := alias
+	nameLen := int(ctx.readInst())
+	if nameLen != 0 {
+		name = ctx.query.Res[ctx.charNr : ctx.charNr+nameLen]
+		ctx.skipInst(nameLen)
+	}
+	ctx.skipInst(1)
+
+	ctx.usedDirectives = ctx.usedDirectives[:0]
+	for i := uint8(0); i < directivesCount; i++ {
+		modifier, criticalErr := ctx.resolveDirective(DirectiveLocationField)
+		if criticalErr || modifier.Skip {
+			ctx.charNr = endOfField + 1
+			return criticalErr
+		}
+	}
+
+	field := LookaheadField{
+		Name:      string(name),
+		Alias:     string(alias),
+		Arguments: json.RawMessage("{}"),
+	}
+	if ctx.seekInst() == bytecode.ActionValue {
+		argumentsAt := ctx.charNr
+		arguments, criticalErr := ctx.inputValueToJSON(nil)
+		if criticalErr {
+			return criticalErr
+		}
+		field.Arguments = arguments
+		ctx.charNr = argumentsAt
+		ctx.skipArguments()
+	}
+
+	if ctx.seekInst() != bytecode.ActionEnd {
+		var fieldType *obj
+		if typeObj != nil {
+			if typeObjField, ok := typeObj.objContents.get(nameKey, name); ok {
+				fieldType = ctx.schema.complexityType(typeObjField)
+			}
+		}
+		field.Fields = []LookaheadField{}
+		criticalErr := ctx.lookaheadSelectionSet(fieldType, &field.Fields)
+		if criticalErr {
+			return criticalErr
+		}
+	}
+	*fields = append(*fields, field)
+
+	ctx.charNr = endOfField + 1
+	return false
+}
+
+// lookaheadSpread adds the fields of the fragment spread at the current charNr to fields if the fragment applies to typeObj
+func (ctx *Ctx) lookaheadSpread(typeObj *obj, fields *[]LookaheadField) bool {
+	isInline := ctx.readInst() == 't'
+	directivesCount := ctx.readInst()
+	lenOfSpread := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read name or on inline fragment the type name
+	}
+	name := ctx.query.Res[nameStart : ctx.charNr-1]
+	endOfSpread := nameStart + int(lenOfSpread) + 1
+
+	location := DirectiveLocationFragment
+	if isInline {
+		location = DirectiveLocationFragmentInline
+	}
+	ctx.usedDirectives = ctx.usedDirectives[:0]
+	for i := uint8(0); i < directivesCount; i++ {
+		modifier, criticalErr := ctx.resolveDirective(location)
+		if criticalErr || modifier.Skip {
+			ctx.charNr = endOfSpread
+			return criticalErr
+		}
+	}
+
+	criticalErr := false
+	if isInline {
+		if ctx.lookaheadFragmentApplies(typeObj, name) {
+			criticalErr = ctx.lookaheadSelectionSet(typeObj, fields)
+		}
+	} else if typeName, selectionSetAt, ok := ctx.findFragment(name); ok {
+		if ctx.lookaheadFragmentApplies(typeObj, typeName) {
+			ctx.charNr = selectionSetAt
+			criticalErr = ctx.lookaheadSelectionSet(typeObj, fields)
+		}
+	}
+
+	ctx.charNr = endOfSpread
+	return criticalErr
+}
+
+// lookaheadFragmentApplies returns false if a fragment on typeName can never apply to a value of typeObj
+func (ctx *Ctx) lookaheadFragmentApplies(typeObj *obj, typeName []byte) bool {
+	if typeObj == nil || len(typeName) == 0 || typeObj.valueType == valueTypeInterface || b2s(typeObj.typeNameBytes) == b2s(typeName) {
+		return true
+	}
+	_, isInterface := ctx.schema.interfaces[b2s(typeName)]
+	return isInterface
+}
@@ -0,0 +1,101 @@
+package yarql
+
+import (
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestLookaheadData struct {
+	fields *[]LookaheadField
+	Other  TestLookaheadOther
+}
+
+type TestLookaheadUser struct {
+	ID   uint `gq:",id"`
+	Name string
+	Age  int
+}
+
+func (TestLookaheadUser) ResolveFriends(args struct{ First int }) []TestLookaheadUser {
+	return nil
+}
+
+type TestLookaheadOther struct {
+	Name string
+}
+
+func (d TestLookaheadData) ResolveUsers(ctx *Ctx) ([]TestLookaheadUser, error) {
+	fields, err := ctx.SelectedFields()
+	if err != nil {
+		return nil, err
+	}
+	*d.fields = fields
+	return []TestLookaheadUser{{ID: 1, Name: "a", Age: 2}}, nil
+}
+
+func (d TestLookaheadData) ResolveCount(ctx *Ctx) (int, error) {
+	fields, err := ctx.SelectedFields()
+	*d.fields = fields
+	return 1, err
+}
+
+func TestSelectedFields(t *testing.T) {
+	var fields []LookaheadField
+	data := TestLookaheadData{fields: &fields}
+
+	res := bytecodeParseAndExpectNoErrs(t, `{users {ID userName: name}}`, data, M{})
+	a.Equal(t, `{"users":[{"ID":"1","userName":"a"}]}`, res)
+	a.Equal(t, []LookaheadField{
+		{Name: "ID", Alias: "ID", Arguments: []byte("{}")},
+		{Name: "name", Alias: "userName", Arguments: []byte("{}")},
+	}, fields)
+
+	res = bytecodeParseAndExpectNoErrs(t, `{count}`, data, M{})
+	a.Equal(t, `{"count":1}`, res)
+	a.Nil(t, fields)
+}
+
+func TestSelectedFieldsNested(t *testing.T) {
+	var fields []LookaheadField
+	data := TestLookaheadData{fields: &fields}
+
+	query := `query($first: Int!, $skipAge: Boolean!) {
+		users {
+			name
+			age @skip(if: $skipAge)
+			friends(first: $first) {
+				...userFields
+			}
+			... on TestLookaheadOther {
+				name
+			}
+		}
+	}
+	fragment userFields on TestLookaheadUser {
+		ID
+		... on TestLookaheadUser {
+			age
+		}
+	}`
+	bytecodeParseAndExpectNoErrs(t, query, data, M{}, ResolveOptions{
+		NoMeta:    true,
+		Variables: `{"first": 10, "skipAge": true}`,
+	})
+	a.Equal(t, []LookaheadField{
+		{Name: "name", Alias: "name", Arguments: []byte("{}")},
+		{Name: "friends", Alias: "friends", Arguments: []byte(`{"first":10}`), Fields: []LookaheadField{
+			{Name: "ID", Alias: "ID", Arguments: []byte("{}")},
+			{Name: "age", Alias: "age", Arguments: []byte("{}")},
+		}},
+	}, fields)
+}
+
+func TestSelectedFieldsInvalidArguments(t *testing.T) {
+	var fields []LookaheadField
+	data := TestLookaheadData{fields: &fields}
+
+	res, errs := bytecodeParseAndExpectErrs(t, `query($first: Int!) {users {friends(first: $first) {name}}}`, data, M{})
+	a.Equal(t, `{"users":null}`, res)
+	a.Equal(t, 1, len(errs))
+}
@@ -0,0 +1,104 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// memoizedField is a field registered using (*Schema).RegisterMemoizedField
+type memoizedField struct {
+	goType    reflect.Type
+	fieldName string
+}
+
+// memoizedValue is the location of a memoized field value within the result
+type memoizedValue struct {
+	start int
+	end   int
+}
+
+// RegisterMemoizedField marks a field as memoized
+// When a memoized field is selected multiple times on the same parent with identical arguments and selection set (common with aliases and fragments),
+// the field is only resolved once per request and the response of the first selection is reused for the others
+// fieldName is the graphql name of the field, this can also be a method or root resolver
+// Memoized fields can also be marked using the memo field tag (`gq:",memo"`)
+//
+// Example:
+//
+//	s.RegisterMemoizedField(QueryRoot{}, "search")
+func (s *Schema) RegisterMemoizedField(goType interface{}, fieldName string) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterMemoizedField() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t, ok := structOrInterfaceType(goType)
+	if !ok {
+		return errors.New("can only register memoized fields on struct and interface types")
+	}
+
+	s.memoizedFields = append(s.memoizedFields, memoizedField{
+		goType:    t,
+		fieldName: fieldName,
+	})
+	return nil
+}
+
+// checkMemoizedFields marks the fields registered using (*Schema).RegisterMemoizedField as memoized
+func (c *parseCtx) checkMemoizedFields() error {
+	for _, memoizedField := range c.schema.memoizedFields {
+		typeObj, ok, err := c.registeredType(memoizedField.goType)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("cannot register a memoized field on " + memoizedField.goType.String())
+		}
+		field, ok := typeObj.objContents.getByName(memoizedField.fieldName)
+		if !ok {
+			return errors.New(typeObj.typeName + " has no field " + memoizedField.fieldName + " to memoize")
+		}
+		field.memoize = true
+	}
+	return nil
+}
+
+// writeMemoized writes the memoized value of the field that is currently being resolved if there is one
+// If there is no memoized value the key of the field is kept at the end of ctx.memoKeys so (*Ctx).memoize can store the value
+//
+// The key consists of the path of the parent, the field name and the bytecode of the arguments and selection set
+// Arguments are compared as written in the query so variables always have the same value within the request
+func (ctx *Ctx) writeMemoized(parentPathLen int, name []byte, endOfField int) (written bool, keyStart int) {
+	keyStart = len(ctx.memoKeys)
+	ctx.memoKeys = append(ctx.memoKeys, ctx.path[:parentPathLen]...)
+	ctx.memoKeys = append(ctx.memoKeys, 0)
+	ctx.memoKeys = append(ctx.memoKeys, name...)
+	ctx.memoKeys = append(ctx.memoKeys, 0)
+	ctx.memoKeys = append(ctx.memoKeys, ctx.query.Res[ctx.charNr:endOfField]...)
+
+	value, ok := ctx.memoized[string(ctx.memoKeys[keyStart:])]
+	if !ok || value.end > len(ctx.schema.Result) {
+		return false, keyStart
+	}
+
+	ctx.memoKeys = ctx.memoKeys[:keyStart]
+	ctx.schema.Result = append(ctx.schema.Result, ctx.schema.Result[value.start:value.end]...)
+	return true, keyStart
+}
+
+// memoize stores the value written since resultStart under the key created by (*Ctx).writeMemoized
+// Values that produced errors are not stored so the errors are reported for every selection of the field
+func (ctx *Ctx) memoize(keyStart int, resultStart int, store bool) {
+	if store {
+		if ctx.memoized == nil {
+			ctx.memoized = map[string]memoizedValue{}
+		}
+		ctx.memoized[string(ctx.memoKeys[keyStart:])] = memoizedValue{
+			start: resultStart,
+			end:   len(ctx.schema.Result),
+		}
+	}
+	ctx.memoKeys = ctx.memoKeys[:keyStart]
+}
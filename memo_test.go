@@ -0,0 +1,137 @@
+package yarql
+
+import (
+	"errors"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+// testMemoCalls counts the resolver calls by field
+var testMemoCalls = map[string]int{}
+
+type TestMemoQuery struct {
+	Users []TestMemoUser
+}
+
+func (TestMemoQuery) ResolveSearch(args struct{ Term string }) []TestMemoUser {
+	testMemoCalls["search"]++
+	if args.Term == "" {
+		return nil
+	}
+	return []TestMemoUser{{Name: args.Term}}
+}
+
+func (TestMemoQuery) ResolveFail() (string, error) {
+	testMemoCalls["fail"]++
+	return "", errors.New("failed")
+}
+
+func (TestMemoQuery) ResolveNotMemoized() string {
+	testMemoCalls["notMemoized"]++
+	return "not memoized"
+}
+
+type TestMemoUser struct {
+	Name string
+	Bio  func() string `gq:",memo"`
+}
+
+var testMemoData = TestMemoQuery{Users: []TestMemoUser{
+	{Name: "a", Bio: func() string { testMemoCalls["bio"]++; return "bio of a" }},
+	{Name: "b", Bio: func() string { testMemoCalls["bio"]++; return "bio of b" }},
+}}
+
+func registerTestMemoizedFields(t *testing.T, s *Schema) {
+	a.NoError(t, s.RegisterMemoizedField(TestMemoQuery{}, "search"))
+	a.NoError(t, s.RegisterMemoizedField(TestMemoQuery{}, "fail"))
+}
+
+func TestMemoizedFields(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+		calls    map[string]int
+	}{
+		{
+			`{a: search(term: "x") {name} b: search(term: "x") {name}}`,
+			`{"a":[{"name":"x"}],"b":[{"name":"x"}]}`,
+			map[string]int{"search": 1},
+		},
+		{
+			`{...f search(term: "x") {name}} fragment f on TestMemoQuery {search(term: "x") {name}}`,
+			`{"search":[{"name":"x"}],"search":[{"name":"x"}]}`,
+			map[string]int{"search": 1},
+		},
+		{
+			// Different arguments or selection sets are resolved separately
+			`{a: search(term: "x") {name} b: search(term: "y") {name} c: search(term: "x") {n: name}}`,
+			`{"a":[{"name":"x"}],"b":[{"name":"y"}],"c":[{"n":"x"}]}`,
+			map[string]int{"search": 3},
+		},
+		{
+			`query($term: String) {a: search(term: $term) {name} b: search(term: $term) {name}}`,
+			`{"a":[{"name":"z"}],"b":[{"name":"z"}]}`,
+			map[string]int{"search": 1},
+		},
+		{
+			// The same field on a different parent is resolved for every parent
+			`{users {a: bio b: bio}}`,
+			`{"users":[{"a":"bio of a","b":"bio of a"},{"a":"bio of b","b":"bio of b"}]}`,
+			map[string]int{"bio": 2},
+		},
+		{
+			`{a: notMemoized b: notMemoized}`,
+			`{"a":"not memoized","b":"not memoized"}`,
+			map[string]int{"notMemoized": 2},
+		},
+	}
+
+	for _, test := range tests {
+		s := NewSchema()
+		registerTestMemoizedFields(t, s)
+		testMemoCalls = map[string]int{}
+		res, errs := bytecodeParse(t, s, test.query, testMemoData, M{}, ResolveOptions{NoMeta: true, Variables: `{"term": "z"}`})
+		for _, err := range errs {
+			panic(err)
+		}
+		a.Equal(t, test.expected, res, test.query)
+		a.Equal(t, test.calls, testMemoCalls, test.query)
+	}
+}
+
+func TestMemoizedFieldsPerRequest(t *testing.T) {
+	s := NewSchema()
+	registerTestMemoizedFields(t, s)
+	a.NoError(t, s.Parse(testMemoData, M{}, nil))
+
+	// Values are not reused between requests
+	testMemoCalls = map[string]int{}
+	s.Resolve([]byte(`{search(term: "x") {name}}`), ResolveOptions{NoMeta: true})
+	s.Resolve([]byte(`{search(term: "x") {name}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 2, testMemoCalls["search"])
+}
+
+func TestMemoizedFieldWithErrors(t *testing.T) {
+	s := NewSchema()
+	registerTestMemoizedFields(t, s)
+
+	// Every selection of a field that failed reports its own error
+	testMemoCalls = map[string]int{}
+	_, errs := bytecodeParse(t, s, `{a: fail b: fail}`, testMemoData, M{})
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, 2, testMemoCalls["fail"])
+}
+
+func TestRegisterMemoizedFieldInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterMemoizedField(nil, "search"))
+	a.Error(t, s.RegisterMemoizedField("", "search"))
+
+	a.NoError(t, s.RegisterMemoizedField(TestMemoQuery{}, "unknown"))
+	a.Error(t, s.Parse(TestMemoQuery{}, M{}, nil))
+
+	s = NewSchema()
+	a.NoError(t, s.Parse(TestMemoQuery{}, M{}, nil))
+	a.Error(t, s.RegisterMemoizedField(TestMemoQuery{}, "search"))
+}
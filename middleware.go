@@ -0,0 +1,135 @@
+package yarql
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// FieldResolver resolves a field and writes its value to the response
+type FieldResolver func(ctx *Ctx, field FieldInfo) error
+
+// FieldMiddleware wraps the resolving of every field, see (*yarql.Schema).Use
+type FieldMiddleware func(next FieldResolver) FieldResolver
+
+// FieldInfo describes the field that is being resolved by a FieldResolver
+type FieldInfo struct {
+	// Name is the name of the field in the schema
+	Name string
+	// Alias is the key of the field in the response, equals Name if no alias was used
+	Alias string
+	// ParentType is the name of the type the field is selected on
+	ParentType string
+	// Parent is the go value the field is resolved on
+	Parent reflect.Value
+
+	ctx         *Ctx
+	argumentsAt int // location of the arguments in the bytecode, -1 if there are no arguments
+}
+
+// Arguments returns the arguments of the field as JSON object, variables are resolved
+func (f FieldInfo) Arguments() (json.RawMessage, error) {
+	if f.argumentsAt == -1 {
+		return json.RawMessage("{}"), nil
+	}
+
+	ctx := f.ctx
+	startCharNr := ctx.charNr
+	errorsLen := len(ctx.query.Errors)
+
+	ctx.charNr = f.argumentsAt
+	res, criticalErr := ctx.inputValueToJSON(nil)
+	ctx.charNr = startCharNr
+	if criticalErr {
+		err := ctx.query.Errors[len(ctx.query.Errors)-1]
+		ctx.query.Errors = ctx.query.Errors[:errorsLen]
+		return nil, err
+	}
+	return res, nil
+}
+
+// Use adds middleware that wraps the resolving of every field of the schema, excluding __typename
+// The first added middleware is the outer most middleware
+// If the middleware does not call next the field is set to null, errors returned by the middleware are added to the response
+// Must be called before (*yarql.Schema).Parse()
+//
+// Example:
+//
+//	err := s.Use(func(next yarql.FieldResolver) yarql.FieldResolver {
+//		return func(ctx *yarql.Ctx, field yarql.FieldInfo) error {
+//			start := time.Now()
+//			err := next(ctx, field)
+//			log.Println(field.ParentType, field.Name, time.Since(start))
+//			return err
+//		}
+//	})
+func (s *Schema) Use(middleware ...FieldMiddleware) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).Use() cannot be ran after (*yarql.Schema).Parse()")
+	}
+
+	s.middleware = append(s.middleware, middleware...)
+
+	resolver := FieldResolver(resolveMiddlewareField)
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		resolver = s.middleware[i](resolver)
+	}
+	s.fieldResolver = resolver
+	return nil
+}
+
+// middlewareField contains the state of the field that is being resolved through the middleware
+type middlewareField struct {
+	field       wrappedField
+	resolved    bool
+	criticalErr bool
+}
+
+// resolveMiddlewareField is the inner most FieldResolver that actually resolves the field
+func resolveMiddlewareField(ctx *Ctx, field FieldInfo) error {
+	state := &ctx.middlewareField
+	if state.resolved {
+		return errors.New("next cannot be called more than once")
+	}
+	state.resolved = true
+
+	criticalErr := ctx.resolveWrappedFieldValue(&state.field)
+	if criticalErr {
+		state.criticalErr = true
+		return ctx.lastErr()
+	}
+	return nil
+}
+
+// resolveFieldWithMiddleware resolves a field through the middleware set using (*yarql.Schema).Use
+func (ctx *Ctx) resolveFieldWithMiddleware(f *wrappedField) bool {
+	prefState := ctx.middlewareField
+	ctx.middlewareField = middlewareField{field: *f}
+
+	argumentsAt := -1
+	if ctx.seekInst() == bytecode.ActionValue {
+		argumentsAt = ctx.charNr
+	}
+
+	err := ctx.schema.fieldResolver(ctx, FieldInfo{
+		Name:        string(f.name),
+		Alias:       string(f.alias),
+		ParentType:  f.parent.typeName,
+		Parent:      ctx.getGoValue(),
+		ctx:         ctx,
+		argumentsAt: argumentsAt,
+	})
+
+	state := ctx.middlewareField
+	ctx.middlewareField = prefState
+
+	if !state.resolved {
+		ctx.writeNull()
+	}
+	if err != nil && !errors.As(err, &reportedError{}) {
+		ctx.resolverErr(err)
+	}
+	return state.criticalErr
+}
@@ -0,0 +1,127 @@
+package yarql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestMiddlewareQuery struct {
+	Public string
+	Secret string
+	User   TestMiddlewareUser
+}
+
+type TestMiddlewareUser struct {
+	Name string
+}
+
+func (TestMiddlewareQuery) ResolveGreet(args struct{ Name string }) string {
+	return "hello " + args.Name
+}
+
+func TestMiddleware(t *testing.T) {
+	s := NewSchema()
+	calls := []string{}
+	a.NoError(t, s.Use(
+		func(next FieldResolver) FieldResolver {
+			return func(ctx *Ctx, field FieldInfo) error {
+				calls = append(calls, "a:"+field.ParentType+"."+field.Name)
+				return next(ctx, field)
+			}
+		},
+		func(next FieldResolver) FieldResolver {
+			return func(ctx *Ctx, field FieldInfo) error {
+				calls = append(calls, "b:"+field.Alias)
+				if field.Name == "secret" {
+					return errors.New("not allowed")
+				}
+				return next(ctx, field)
+			}
+		},
+	))
+
+	query := `{public secret u: user {name}}`
+	res, errs := bytecodeParse(t, s, query, TestMiddlewareQuery{Public: "public", Secret: "secret", User: TestMiddlewareUser{Name: "user"}}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "not allowed", errs[0].Error())
+	a.Equal(t, `{"public":"public","secret":null,"u":{"name":"user"}}`, res)
+	a.Equal(t, []string{
+		"a:TestMiddlewareQuery.public",
+		"b:public",
+		"a:TestMiddlewareQuery.secret",
+		"b:secret",
+		"a:TestMiddlewareQuery.user",
+		"b:u",
+		"a:TestMiddlewareUser.name",
+		"b:name",
+	}, calls)
+}
+
+func TestMiddlewareFieldInfo(t *testing.T) {
+	s := NewSchema()
+	arguments := map[string]string{}
+	parents := []interface{}{}
+	a.NoError(t, s.Use(func(next FieldResolver) FieldResolver {
+		return func(ctx *Ctx, field FieldInfo) error {
+			args, err := field.Arguments()
+			if err != nil {
+				return err
+			}
+			arguments[field.Name] = string(args)
+			parents = append(parents, field.Parent.Interface())
+			return next(ctx, field)
+		}
+	}))
+
+	query := `query($name: String) {greet(name: $name) user {name}}`
+	res, errs := bytecodeParse(t, s, query, TestMiddlewareQuery{}, M{}, ResolveOptions{NoMeta: true, Variables: `{"name": "world"}`})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"greet":"hello world","user":{"name":""}}`, res)
+	a.Equal(t, map[string]string{"greet": `{"name":"world"}`, "user": `{}`, "name": `{}`}, arguments)
+	a.Equal(t, []interface{}{TestMiddlewareQuery{}, TestMiddlewareQuery{}, TestMiddlewareUser{}}, parents)
+}
+
+func TestMiddlewareNextCalledTwice(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Use(func(next FieldResolver) FieldResolver {
+		return func(ctx *Ctx, field FieldInfo) error {
+			next(ctx, field)
+			return next(ctx, field)
+		}
+	}))
+
+	res, errs := bytecodeParse(t, s, `{public}`, TestMiddlewareQuery{Public: "a"}, M{})
+	a.Equal(t, 1, len(errs))
+	a.True(t, strings.Contains(errs[0].Error(), "more than once"))
+	a.Equal(t, `{"public":"a"}`, res)
+}
+
+func TestMiddlewareIntrospection(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Use(func(next FieldResolver) FieldResolver {
+		return func(ctx *Ctx, field FieldInfo) error {
+			if strings.HasPrefix(field.Name, "__") {
+				return errors.New("introspection disabled")
+			}
+			return next(ctx, field)
+		}
+	}))
+
+	// The introspection result cannot be precomputed as the middleware might block it
+	_, errs := bytecodeParse(t, s, IntrospectionQuery, TestMiddlewareQuery{}, M{})
+	a.Equal(t, 0, len(s.precomputed))
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "introspection disabled", errs[0].Error())
+}
+
+func TestMiddlewareAfterParse(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestMiddlewareQuery{}, M{}, nil))
+	a.Error(t, s.Use(func(next FieldResolver) FieldResolver {
+		return next
+	}))
+	a.Nil(t, s.fieldResolver)
+}
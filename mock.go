@@ -0,0 +1,279 @@
+package yarql
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/mjarkk/yarql/bytecode"
+	"github.com/mjarkk/yarql/helpers"
+	"github.com/valyala/fastjson"
+)
+
+// mockDirective is registered when SchemaOptions.EnableMockDirective is set
+//
+// Example:
+//
+//	{
+//		user {
+//			name
+//			invoices @mock(value: "[{\"id\": \"1\", \"amount\": 10}]") {
+//				id
+//				amount
+//			}
+//			address @mock {
+//				street
+//			}
+//		}
+//	}
+func mockDirective() Directive {
+	return Directive{
+		Name:  "mock",
+		Where: []DirectiveLocation{DirectiveLocationField},
+		Method: func(args struct{ Value *string }) DirectiveModifier {
+			modifier := DirectiveModifier{Mock: true}
+			if args.Value != nil {
+				modifier.MockValue = []byte(*args.Value)
+			}
+			return modifier
+		},
+		Description: "Replaces the field value with mocked data without calling the field's resolver, value is expected to be JSON",
+	}
+}
+
+// MockFunc generates the mock value of a type, n is a deterministic number based on the mock seed and the path of the field
+// The returned value is encoded as JSON
+type MockFunc func(n uint64) interface{}
+
+// RegisterMock changes the data generated for the scalar or enum with typeName by @mock and SchemaOptions.MockResolvers
+//
+// Example:
+//
+//	s.RegisterMock("String", func(n uint64) interface{} {
+//		return names[n%uint64(len(names))]
+//	})
+func (s *Schema) RegisterMock(typeName string, generate MockFunc) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterMock() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if len(typeName) == 0 {
+		return errors.New("mock type name cannot be empty")
+	}
+	if generate == nil {
+		return errors.New("mock function cannot be nil")
+	}
+
+	if s.mocks == nil {
+		s.mocks = map[string]MockFunc{}
+	}
+	s.mocks[typeName] = generate
+	return nil
+}
+
+// writeRegisteredMock writes the value of the mock registered for the type of typeObj
+// Returns false if no mock is registered for the type
+func (ctx *Ctx) writeRegisteredMock(typeObj *obj, n uint64) (written bool, criticalErr bool) {
+	if len(ctx.schema.mocks) == 0 {
+		return false, false
+	}
+	qlType, _ := ctx.schema.objToQLType(typeObj)
+	if qlType == nil || qlType.Name == nil {
+		return false, false
+	}
+	generate, ok := ctx.schema.mocks[*qlType.Name]
+	if !ok {
+		return false, false
+	}
+
+	value, err := json.Marshal(generate(n))
+	if err != nil {
+		ctx.writeNull()
+		return true, ctx.err("invalid mock value, " + err.Error())
+	}
+	ctx.write(value)
+	return true, false
+}
+
+// writeMockValue writes a user defined mock value to the result
+func (ctx *Ctx) writeMockValue(value []byte) bool {
+	err := fastjson.ValidateBytes(value)
+	if err != nil {
+		ctx.writeNull()
+		return ctx.err("invalid mock value, " + err.Error())
+	}
+	ctx.write(value)
+	return false
+}
+
+// skipArguments skips over the arguments of a field if there are any
+func (ctx *Ctx) skipArguments() {
+	if ctx.seekInst() != bytecode.ActionValue {
+		return
+	}
+	// Skip ActionValue, ValueObject, the length of the object, the object itself and the NULL byte after it
+	ctx.skipInst(6 + int(ctx.readUint32(ctx.charNr+2)) + 1)
+}
+
+// mockNumber returns a deterministic number based on the schema's mock seed and the path of the current field
+func (ctx *Ctx) mockNumber() uint64 {
+	hasher := fnv.New64a()
+	seed := [8]byte{}
+	binary.LittleEndian.PutUint64(seed[:], uint64(ctx.schema.mockSeed))
+	hasher.Write(seed[:])
+	hasher.Write(ctx.path)
+	return hasher.Sum64()
+}
+
+// resolveMockValue writes a generated value for typeObj without calling any resolver
+// It follows the same structure as resolveFieldDataValue but doesn't read Go values
+func (ctx *Ctx) resolveMockValue(typeObj *obj, dept uint8, hasSubSelection bool) bool {
+	n := ctx.mockNumber()
+
+	switch typeObj.valueType {
+	case valueTypeUndefined:
+		ctx.writeNull()
+	case valueTypeArray:
+		typeObj = typeObj.innerContent
+
+		ctx.writeByte('[')
+		itemsLen := int(n%3) + 1
+		startCharNr := ctx.charNr
+		for i := 0; i < itemsLen; i++ {
+			ctx.charNr = startCharNr
+
+			prefPathLen := len(ctx.path)
+			ctx.path = append(ctx.path, ',')
+			ctx.path = strconv.AppendInt(ctx.path, int64(i), 10)
+
+			criticalErr := ctx.resolveMockValue(typeObj, dept, hasSubSelection)
+			ctx.path = ctx.path[:prefPathLen]
+			if criticalErr {
+				return criticalErr
+			}
+			if i != itemsLen-1 {
+				ctx.writeByte(',')
+			}
+		}
+		ctx.writeByte(']')
+	case valueTypeObj, valueTypeObjRef:
+		if !hasSubSelection {
+			ctx.writeNull()
+			return ctx.err("must have a selection")
+		}
+
+		var ok bool
+		if typeObj.valueType == valueTypeObjRef {
+			typeObj, ok = ctx.schema.resolveRef(typeObj)
+			if !ok {
+				ctx.writeNull()
+				return false
+			}
+		}
+
+		dept++
+		if dept == ctx.schema.MaxDepth {
+			ctx.writeNull()
+			return ctx.err("reached max dept")
+		}
+
+		ctx.writeByte('{')
+		isFirstField := true
+		criticalErr := ctx.resolveSelectionSet(typeObj, dept, &isFirstField)
+		ctx.writeByte('}')
+		return criticalErr
+	case valueTypeData:
+		if hasSubSelection {
+			ctx.writeNull()
+			return ctx.err("cannot have a selection set on this field")
+		}
+		if written, criticalErr := ctx.writeRegisteredMock(typeObj, n); written {
+			return criticalErr
+		}
+		ctx.writeMockData(typeObj, n)
+	case valueTypePtr:
+		return ctx.resolveMockValue(typeObj.innerContent, dept, hasSubSelection)
+	case valueTypeMethod:
+		return ctx.resolveMockValue(&typeObj.method.outType, dept, hasSubSelection)
+	case valueTypeEnum:
+		if written, criticalErr := ctx.writeRegisteredMock(typeObj, n); written {
+			return criticalErr
+		}
+		enum := ctx.schema.definedEnums[typeObj.enumTypeIndex]
+		values := enum.qlType.EnumValues(isDeprecatedArgs{})
+		if len(values) == 0 {
+			ctx.writeNull()
+			return false
+		}
+		ctx.writeQuoted([]byte(values[n%uint64(len(values))].Name))
+	case valueTypeTime:
+		if written, criticalErr := ctx.writeRegisteredMock(typeObj, n); written {
+			return criticalErr
+		}
+		// Somewhere between 1970 and 2000
+		ctx.writeByte('"')
+		helpers.TimeToIso8601String(&ctx.schema.Result, time.Unix(int64(n%946684800), 0).UTC())
+		ctx.writeByte('"')
+	case valueTypeText:
+		if written, criticalErr := ctx.writeRegisteredMock(typeObj, n); written {
+			return criticalErr
+		}
+		ctx.writeQuoted(strconv.AppendUint([]byte("mock_"), n%100000, 10))
+	case valueTypeInterface, valueTypeInterfaceRef:
+		if !hasSubSelection {
+			ctx.writeNull()
+			return ctx.err("must have a selection")
+		}
+
+		var ok bool
+		if typeObj.valueType == valueTypeInterfaceRef {
+			typeObj, ok = ctx.schema.resolveRef(typeObj)
+			if !ok {
+				ctx.writeNull()
+				return false
+			}
+		}
+
+		if len(typeObj.implementations) == 0 {
+			ctx.writeNull()
+			return false
+		}
+		implementation := typeObj.implementations[n%uint64(len(typeObj.implementations))]
+		return ctx.resolveMockValue(implementation, dept+1, hasSubSelection)
+	}
+
+	return false
+}
+
+func (ctx *Ctx) writeMockData(typeObj *obj, n uint64) {
+	switch typeObj.dataValueType {
+	case reflect.String:
+		if typeObj.isID {
+			ctx.writeQuoted(strconv.AppendUint(nil, n%100000, 10))
+		} else {
+			ctx.writeQuoted(strconv.AppendUint([]byte("mock_"), n%100000, 10))
+		}
+	case reflect.Bool:
+		if n%2 == 0 {
+			ctx.write([]byte("true"))
+		} else {
+			ctx.write([]byte("false"))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Stay within 0-99 so the value fits in every int kind
+		// ID and Int64 values are send as strings like the resolver does
+		if typeObj.isID || typeObj.isInt64 {
+			ctx.writeQuoted(strconv.AppendUint(nil, n%100, 10))
+		} else {
+			ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, n%100, 10)
+		}
+	case reflect.Float32, reflect.Float64:
+		helpers.FloatToJSON(64, float64(n%10000)/100, &ctx.schema.Result)
+	default:
+		ctx.writeNull()
+	}
+}
@@ -0,0 +1,194 @@
+package yarql
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+	"github.com/valyala/fastjson"
+)
+
+type TestMockDataQ struct {
+	Name     string
+	Released func() string
+	Invoices func() ([]TestMockInvoice, error)
+}
+
+type TestMockInvoice struct {
+	ID        uint `gq:"id,ID"`
+	Number    string
+	Reference int64 `gq:",int64"`
+	Paid      bool
+	Lines     []TestMockInvoiceLine
+}
+
+func (TestMockInvoice) ResolveTotal(args struct{ Currency string }) (float64, error) {
+	return 0, errors.New("not released")
+}
+
+type TestMockInvoiceLine struct {
+	Description string
+	Amount      int
+}
+
+func newTestMockData() TestMockDataQ {
+	return TestMockDataQ{
+		Name:     "real",
+		Released: func() string { return "released" },
+		Invoices: func() ([]TestMockInvoice, error) {
+			return nil, errors.New("not released")
+		},
+	}
+}
+
+func mockParse(t *testing.T, query string, options SchemaOptions) (string, []error) {
+	s := NewSchema()
+	err := s.Parse(newTestMockData(), M{}, &options)
+	a.NoError(t, err)
+	s = s.Copy()
+	errs := s.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+	return string(s.Result), errs
+}
+
+func TestMockDirectiveDisabledByDefault(t *testing.T) {
+	_, errs := mockParse(t, `{name @mock(value: "\"fake\"")}`, SchemaOptions{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "unknown directive mock", errs[0].Error())
+}
+
+func TestMockDirectiveWithValue(t *testing.T) {
+	res, errs := mockParse(t, `{
+		name
+		released
+		invoices @mock(value: "[{\"id\": \"1\", \"number\": \"INV-1\"}]") {
+			id
+			number
+		}
+	}`, SchemaOptions{EnableMockDirective: true})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"name":"real","released":"released","invoices":[{"id": "1", "number": "INV-1"}]}`, res)
+}
+
+func TestMockDirectiveInvalidValue(t *testing.T) {
+	res, errs := mockParse(t, `{name @mock(value: "{")}`, SchemaOptions{EnableMockDirective: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"name":null}`, res)
+}
+
+func TestMockDirectiveValueChecksField(t *testing.T) {
+	// Unknown fields are not mocked
+	res, errs := mockParse(t, `{unknown @mock(value: "\"fake\"")}`, SchemaOptions{EnableMockDirective: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"unknown":null}`, res)
+
+	// Fields that require auth are not mocked
+	s := NewSchema()
+	a.NoError(t, s.RegisterFieldAuth(TestMockDataQ{}, "name", ""))
+	a.NoError(t, s.Parse(newTestMockData(), M{}, &SchemaOptions{EnableMockDirective: true}))
+	s = s.Copy()
+	errs = s.Resolve([]byte(`{name @mock(value: "\"fake\"")}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"name":null}`, string(s.Result))
+}
+
+func TestMockDirectiveGenerated(t *testing.T) {
+	query := `{
+		name
+		invoices @mock {
+			__typename
+			id
+			number
+			reference
+			paid
+			total(currency: "EUR")
+			lines {
+				description
+				amount
+			}
+		}
+	}`
+
+	res, errs := mockParse(t, query, SchemaOptions{EnableMockDirective: true})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.NoError(t, fastjson.Validate(res), res)
+
+	parsedRes := fastjson.MustParse(res)
+	a.Equal(t, "real", string(parsedRes.GetStringBytes("name")))
+
+	invoices := parsedRes.GetArray("invoices")
+	a.NotEqual(t, 0, len(invoices))
+	for _, invoice := range invoices {
+		a.Equal(t, "TestMockInvoice", string(invoice.GetStringBytes("__typename")))
+		a.Equal(t, fastjson.TypeString, invoice.Get("id").Type())
+		a.Equal(t, fastjson.TypeString, invoice.Get("number").Type())
+		a.Equal(t, fastjson.TypeString, invoice.Get("reference").Type())
+		a.Equal(t, fastjson.TypeNumber, invoice.Get("total").Type())
+		a.NotEqual(t, 0, len(invoice.GetArray("lines")))
+	}
+
+	// The same seed should always generate the same data
+	resAgain, _ := mockParse(t, query, SchemaOptions{EnableMockDirective: true})
+	a.Equal(t, res, resAgain)
+
+	// Another seed should generate different data
+	resOtherSeed, _ := mockParse(t, query, SchemaOptions{EnableMockDirective: true, MockSeed: 42})
+	a.NotEqual(t, res, resOtherSeed)
+}
+
+func TestMockResolvers(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterMock("String", func(n uint64) interface{} {
+		return "name " + strconv.FormatUint(n%10, 10)
+	}))
+	a.NoError(t, s.RegisterMock("Boolean", func(n uint64) interface{} {
+		return true
+	}))
+	a.NoError(t, s.Parse(newTestMockData(), M{}, &SchemaOptions{MockResolvers: true}))
+	s = s.Copy()
+
+	errs := s.Resolve([]byte(`{
+		name
+		released
+		invoices {
+			id
+			reference
+			paid
+			total(currency: "EUR")
+		}
+	}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+
+	res := fastjson.MustParse(string(s.Result))
+	// Resolvers are not called and the registered mocks are used
+	a.True(t, strings.HasPrefix(string(res.GetStringBytes("name")), "name "))
+	a.True(t, strings.HasPrefix(string(res.GetStringBytes("released")), "name "))
+	invoices := res.GetArray("invoices")
+	a.NotEqual(t, 0, len(invoices))
+	for _, invoice := range invoices {
+		// ID fields are not affected by the String mock
+		a.False(t, strings.HasPrefix(string(invoice.GetStringBytes("id")), "name "))
+		a.True(t, invoice.GetBool("paid"))
+		// Int64 values are send as strings
+		a.Equal(t, fastjson.TypeString, invoice.Get("reference").Type())
+		a.Equal(t, fastjson.TypeNumber, invoice.Get("total").Type())
+	}
+
+	// Introspection is not mocked
+	errs = s.Resolve([]byte(`{__type(name: "TestMockInvoice") {name}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"name":"TestMockInvoice"}}`, string(s.Result))
+}
+
+func TestRegisterMockInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterMock("", func(uint64) interface{} { return nil }))
+	a.Error(t, s.RegisterMock("String", nil))
+	a.NoError(t, s.Parse(newTestMockData(), M{}, nil))
+	a.Error(t, s.RegisterMock("String", func(uint64) interface{} { return nil }))
+}
@@ -0,0 +1,75 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// OneOf can be embedded in an input struct to make it a oneOf input object, exactly one of the fields of the input must be set
+// The other fields of the struct must be pointers or slices so it can be checked which field is set
+//
+// Example:
+//
+//	type UserBy struct {
+//		yarql.OneOf
+//		ID    *int
+//		Email *string
+//	}
+//
+//	func (QueryRoot) ResolveUser(args struct{ By UserBy }) User {
+//		if args.By.ID != nil {
+//			return getUserByID(*args.By.ID)
+//		}
+//		return getUserByEmail(*args.By.Email)
+//	}
+type OneOf struct{}
+
+var oneOfType = reflect.TypeOf(OneOf{})
+
+// oneOfDirectiveDefinition is the definition of the @oneOf directive within the SDL, see https://github.com/graphql/graphql-spec/pull/825
+const oneOfDirectiveDefinition = "directive @oneOf on INPUT_OBJECT\n"
+
+// isOneOfInput returns true if the input struct type t embeds yarql.OneOf
+func isOneOfInput(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type == oneOfType {
+			return true
+		}
+	}
+	return false
+}
+
+// hasOneOfInputs returns true if the schema contains a oneOf input, used to add the @oneOf directive to the SDL
+func (s *Schema) hasOneOfInputs() bool {
+	for _, in := range s.inTypes {
+		if in.oneOf {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOneOfInputFields returns an error if a field of the oneOf input in cannot be unset
+func checkOneOfInputFields(in *input) error {
+	for name, field := range in.structContent {
+		if field.kind != reflect.Ptr && field.kind != reflect.Slice {
+			return errors.New("field " + name + " of oneOf input " + in.structName + " must be a pointer or slice")
+		}
+	}
+	return nil
+}
+
+// checkOneOfInput reports an error if not exactly one field of the oneOf input in is set on goValue
+func (ctx *Ctx) checkOneOfInput(goValue reflect.Value, in *input) bool {
+	setFields := 0
+	for _, field := range in.structContent {
+		if !goValue.Field(field.goFieldIdx).IsNil() {
+			setFields++
+		}
+	}
+	if setFields != 1 {
+		return ctx.err("exactly one field of the oneOf input " + in.structName + " must be set")
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package yarql
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestOneOfUserBy struct {
+	OneOf
+	ID    *int
+	Email *string
+}
+
+type TestOneOfQuery struct{}
+
+func (TestOneOfQuery) ResolveUser(args struct{ By TestOneOfUserBy }) string {
+	if args.By.ID != nil {
+		return "user " + strconv.Itoa(*args.By.ID)
+	}
+	return "user " + *args.By.Email
+}
+
+func TestOneOfInput(t *testing.T) {
+	res := bytecodeParseAndExpectNoErrs(t, `{a: user(by: {ID: 1}) b: user(by: {email: "a@b.c"})}`, TestOneOfQuery{}, M{})
+	a.Equal(t, `{"a":"user 1","b":"user a@b.c"}`, res)
+
+	for _, query := range []string{`{user(by: {})}`, `{user(by: {ID: 1, email: "a@b.c"})}`, `{user(by: {ID: null})}`} {
+		_, errs := bytecodeParseAndExpectErrs(t, query, TestOneOfQuery{}, M{})
+		a.Equal(t, 1, len(errs), query)
+		a.Equal(t, "exactly one field of the oneOf input TestOneOfUserBy must be set", errs[0].Error(), query)
+	}
+}
+
+func TestOneOfInputVariables(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestOneOfQuery{}, M{}, nil))
+
+	query := []byte(`query($by: TestOneOfUserBy) {user(by: $by)}`)
+	errs := s.Resolve(query, ResolveOptions{NoMeta: true, Variables: `{"by": {"email": "a@b.c"}}`})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"user":"user a@b.c"}`, string(s.Result))
+
+	errs = s.Resolve(query, ResolveOptions{NoMeta: true, Variables: `{"by": {"ID": 1, "email": "a@b.c"}}`})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "exactly one field of the oneOf input TestOneOfUserBy must be set", errs[0].Error())
+}
+
+func TestOneOfInputSchema(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestOneOfQuery{}, M{}, nil))
+
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, oneOfDirectiveDefinition))
+	a.True(t, strings.Contains(sdl, "input TestOneOfUserBy @oneOf {"))
+
+	errs := s.Resolve([]byte(`{__type(name: "TestOneOfUserBy") {isOneOf}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"isOneOf":true}}`, string(s.Result))
+}
+
+type TestOneOfInvalidBy struct {
+	OneOf
+	ID int
+}
+
+type TestOneOfInvalidQuery struct{}
+
+func (TestOneOfInvalidQuery) ResolveUser(args struct{ By TestOneOfInvalidBy }) string {
+	return ""
+}
+
+func TestOneOfInputInvalid(t *testing.T) {
+	err := NewSchema().Parse(TestOneOfInvalidQuery{}, M{}, nil)
+	a.Error(t, err)
+}
@@ -0,0 +1,124 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RegisterTypeOwner marks the team that owns a struct or interface type
+// Fields of the type without an owner field tag (`gq:",owner=payments"`) inherit this owner
+//
+// Example:
+//
+//	s.RegisterTypeOwner(Invoice{}, "payments")
+//	s.RegisterTypeOwner((*Node)(nil), "platform")
+func (s *Schema) RegisterTypeOwner(goType interface{}, owner string) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterTypeOwner() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	owner = strings.TrimSpace(owner)
+	if len(owner) == 0 {
+		return errors.New("owner cannot be empty")
+	}
+
+	t, ok := structOrInterfaceType(goType)
+	if !ok {
+		return errors.New("can only register an owner on struct and interface types")
+	}
+	if t.Name() == "" {
+		return errors.New("cannot register an owner on an inline type")
+	}
+
+	s.typeOwners[t] = owner
+	return nil
+}
+
+// ownerWithin returns the owner of a field and falls back to the owner of the type the field is defined in
+func (o *obj) ownerWithin(parent *obj) string {
+	if len(o.owner) > 0 {
+		return o.owner
+	}
+	return parent.owner
+}
+
+// structOrInterfaceType returns the type of goType used by registrations like (*Schema).RegisterTypeOwner
+// ok is false if goType is not a struct or interface, interfaces can be defined like: (*InterfaceType)(nil)
+func structOrInterfaceType(goType interface{}) (t reflect.Type, ok bool) {
+	t = reflect.TypeOf(goType)
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+		t = t.Elem()
+	}
+	return t, t.Kind() == reflect.Struct || t.Kind() == reflect.Interface
+}
+
+func (s *Schema) getTypeOrInterface(typeName string) (*obj, bool) {
+	typeObj, ok := s.types[typeName]
+	if ok {
+		return typeObj, true
+	}
+	typeObj, ok = s.interfaces[typeName]
+	return typeObj, ok
+}
+
+// TypeOwner returns the owner of a graphql type
+// An empty string is returned if the type has no owner or doesn't exist
+func (s *Schema) TypeOwner(typeName string) string {
+	typeObj, ok := s.getTypeOrInterface(typeName)
+	if !ok {
+		return ""
+	}
+	return typeObj.owner
+}
+
+// FieldOwner returns the owner of a field within a graphql type
+// If the field itself has no owner the owner of the type is returned
+func (s *Schema) FieldOwner(typeName string, fieldName string) string {
+	typeObj, ok := s.getTypeOrInterface(typeName)
+	if !ok {
+		return ""
+	}
+	field, ok := typeObj.objContents.getByName(fieldName)
+	if !ok {
+		return ""
+	}
+	return field.ownerWithin(typeObj)
+}
+
+// Owners returns every owner together with the types and fields they own
+// Types are listed by their name and fields are listed as Type.field
+// Fields that inherit their owner from the type they are defined in are not listed
+func (s *Schema) Owners() map[string][]string {
+	res := map[string][]string{}
+
+	addOwners := func(typesToCheck types) {
+		for typeName, typeObj := range typesToCheck {
+			if len(typeObj.owner) > 0 {
+				res[typeObj.owner] = append(res[typeObj.owner], typeName)
+			}
+			for _, field := range typeObj.objContents.all() {
+				if len(field.owner) > 0 && field.owner != typeObj.owner {
+					res[field.owner] = append(res[field.owner], typeName+"."+string(field.qlFieldName))
+				}
+			}
+		}
+	}
+	addOwners(s.types)
+	addOwners(s.interfaces)
+
+	for _, owned := range res {
+		sort.Strings(owned)
+	}
+	return res
+}
+
+// GetOwner returns the owner of the field that is currently being resolved
+// This can be used to group metrics or logs per owner
+func (ctx *Ctx) GetOwner() string {
+	return ctx.owner
+}
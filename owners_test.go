@@ -0,0 +1,84 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func TestOwnersFieldTag(t *testing.T) {
+	ctx := newParseCtx()
+	_, err := ctx.check(reflect.TypeOf(struct {
+		Foo string `gq:",owner=payments"`
+	}{}), false)
+	a.NoError(t, err)
+
+	_, err = newParseCtx().check(reflect.TypeOf(struct {
+		Foo string `gq:",owner="`
+	}{}), false)
+	a.Error(t, err)
+}
+
+type TestOwnersValidInvoice struct {
+	ID     uint `gq:",ID"`
+	Amount int  `gq:",owner=billing"`
+}
+
+type TestOwnersValidQuery struct {
+	Invoice TestOwnersValidInvoice
+	Failing func() (string, error) `gq:",owner=risk"`
+	Other   string
+}
+
+func TestOwnersMetadata(t *testing.T) {
+	s := NewSchema()
+	err := s.RegisterTypeOwner(TestOwnersValidInvoice{}, "payments")
+	a.NoError(t, err)
+	err = s.Parse(TestOwnersValidQuery{}, M{}, nil)
+	a.NoError(t, err)
+
+	a.Equal(t, "payments", s.TypeOwner("TestOwnersValidInvoice"))
+	a.Equal(t, "", s.TypeOwner("TestOwnersValidQuery"))
+	a.Equal(t, "payments", s.FieldOwner("TestOwnersValidInvoice", "ID"))
+	a.Equal(t, "billing", s.FieldOwner("TestOwnersValidInvoice", "amount"))
+	a.Equal(t, "risk", s.FieldOwner("TestOwnersValidQuery", "failing"))
+	a.Equal(t, "", s.FieldOwner("TestOwnersValidQuery", "other"))
+	a.Equal(t, "", s.FieldOwner("TestOwnersValidQuery", "doesNotExist"))
+
+	a.Equal(t, map[string][]string{
+		"payments": {"TestOwnersValidInvoice"},
+		"billing":  {"TestOwnersValidInvoice.amount"},
+		"risk":     {"TestOwnersValidQuery.failing"},
+	}, s.Owners())
+}
+
+func TestOwnersRegisterTypeOwner(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterTypeOwner(nil, "payments"))
+	a.Error(t, s.RegisterTypeOwner(TestOwnersValidInvoice{}, ""))
+	a.Error(t, s.RegisterTypeOwner("not a struct", "payments"))
+	a.Error(t, s.RegisterTypeOwner(struct{}{}, "payments"))
+	a.NoError(t, s.RegisterTypeOwner((*InterfaceType)(nil), "platform"))
+
+	err := s.Parse(TestOwnersValidQuery{}, M{}, nil)
+	a.NoError(t, err)
+	a.Error(t, s.RegisterTypeOwner(TestOwnersValidInvoice{}, "payments"), "should not be able to register owners after parse")
+}
+
+func TestOwnersErrorExtension(t *testing.T) {
+	s := NewSchema()
+	err := s.RegisterTypeOwner(TestOwnersValidInvoice{}, "payments")
+	a.NoError(t, err)
+
+	query := TestOwnersValidQuery{
+		Failing: func() (string, error) {
+			return "", errors.New("this field failed")
+		},
+	}
+	res, errs := bytecodeParse(t, s, `{failing}`, query, M{}, ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "risk", errs[0].(ErrorWPath).Owner())
+	a.Equal(t, `{"data":{"failing":""},"errors":[{"message":"this field failed","path":["failing"],"locations":[{"line":1,"column":2}],"extensions":{"owner":"risk"}}],"extensions":{}}`, res)
+}
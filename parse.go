@@ -2,6 +2,7 @@ package yarql
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"hash/fnv"
@@ -9,6 +10,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -48,6 +50,10 @@ type Schema struct {
 	inTypes    inputMap
 	interfaces types
 
+	// typeVariants contains the objects of the generic types of this package, like Connection[T], by their go type name
+	// Instantiations that only differ in pointers, like Connection[User] and Connection[*User], are the same graphql type but have a different go layout
+	typeVariants types
+
 	rootQuery         *obj
 	rootQueryValue    reflect.Value
 	rootMethod        *obj
@@ -55,7 +61,78 @@ type Schema struct {
 	MaxDepth          uint8 // Default 255
 	definedEnums      []enum
 	definedDirectives map[DirectiveLocation][]*Directive
+	typeOwners        map[reflect.Type]string
+	descriptions      map[reflect.Type]Descriptions
+	rootResolvers     []rootResolver
+	fieldResolvers    map[reflect.Type][]fieldResolver
+	entities          map[reflect.Type]*Entity
+	entitiesByName    map[string]*Entity
+	typeFederation    map[reflect.Type]*TypeFederation
+	federationByName  map[string]*TypeFederation
+	nodeFetchers      map[reflect.Type]*nodeFetcher
+	nodesByName       map[string]*nodeFetcher
+	fieldCosts        []fieldCost
+	typeAuth          map[reflect.Type]string
+	typeResolvers     map[reflect.Type]TypeResolver
+	fieldAuth         []fieldAuth
+	auth              bool // a type or field requires auth, used to add the @auth directive to the SDL
+	memoizedFields    []memoizedField
+	customScalars     map[reflect.Type]*qlType
+	description       string
+	federation        bool
+	federationV2      bool
+	executionStrategy ExecutionStrategy
+	panicHandler      PanicHandler
+	errorPresenter    ErrorPresenter
+	loaders           map[string]BatchFunc
+	middleware        []FieldMiddleware
+	fieldResolver     FieldResolver // middleware wrapped around resolveMiddlewareField
+	extensions        []Extension
+	requestLogger     func(info RequestInfo)
+	responseTransform ResponseTransformer
+	injector          Injector
+	rateLimiter       RateLimiter
+	fieldVisibility   FieldVisibility
+	fieldScopes       []fieldScope
+	view              string // the scope of the fields this schema contains, see (*Schema).View
+	requestStates     map[reflect.Type]func(ctx *Ctx) interface{}
+	mockSeed          int64
+	mockResolvers     bool
+	mocks             map[string]MockFunc
+	bindings          map[reflect.Type]Binding
+	precomputed       []precomputedQuery
 	ctx               *Ctx
+	pool              *sync.Pool // copies of the schema used by (*Schema).Exec
+	reload            *reloadState
+	composed          []*Schema // schemas added using (*Schema).AddSchema
+
+	// MaxIntrospectionDepth is the max dept of fields within introspection fields like __schema, default 15
+	MaxIntrospectionDepth uint8
+	// MaxIntrospectionFields is the max amount of fields resolved within introspection fields per request, default 100000
+	MaxIntrospectionFields int
+	// MaxAliases is the max amount of aliases in a query, 0 = no limit
+	MaxAliases int
+	// MaxRootFields is the max amount of fields in the selection set of a operation, 0 = no limit
+	MaxRootFields int
+	// MaxQueryLength is the max length of a query in bytes, 0 = no limit
+	MaxQueryLength int
+	// MaxTokens is the max amount of tokens in a query, 0 = no limit
+	MaxTokens int
+	// InitialResultSize is the initial capacity in bytes of the result buffer of the schema and its copies, default 16384
+	InitialResultSize int
+	// MaxResultSize is the max size of a response in bytes, execution stops with an error if the response gets larger, 0 = no limit
+	MaxResultSize int
+	// MaxListItems is the max amount of list items resolved within a request, execution stops with an error if more items are resolved, 0 = no limit
+	// Items of nested lists are all counted so a list of 1000 items with 1000 items each resolves 1001000 items
+	MaxListItems int
+	// MaxRetainedResultSize is the max capacity in bytes of the result buffer kept by schemas pooled by (*Schema).Exec, larger buffers are shrunk to InitialResultSize, default 1MB
+	MaxRetainedResultSize int
+	// MaxBatchSize is the max amount of operations in a batched request handled by (*Schema).HandleRequest, 0 = no limit
+	MaxBatchSize int
+	// BatchConcurrency is the max amount of operations of a batched request resolved at the same time using copies of the schema, batches are resolved one by one if <= 1
+	BatchConcurrency int
+	// ShareBatchLoaders shares the caches of loaders between the operations of a batched request so a value requested by multiple operations is only loaded once
+	ShareBatchLoaders bool
 
 	// Zero alloc variables
 	Result           []byte
@@ -76,13 +153,11 @@ const (
 	valueTypeMethod
 	valueTypeEnum
 	valueTypeTime
+	valueTypeText // a value implementing encoding.TextMarshaler, send as String scalar
 	valueTypeInterfaceRef
 	valueTypeInterface
 )
 
-// TODO Maybe add a pointer to the opj if valueType == valueTypeObjRef || valueType == valueTypeInterfaceRef
-//   Now we have to do a map lookup and that's quite slow
-
 type obj struct {
 	valueType     valueType
 	typeName      string
@@ -92,21 +167,58 @@ type obj struct {
 	qlFieldName   []byte
 	hidden        bool
 	isID          bool
+	isInt64       bool   // The value is send as Int64 scalar, set using the int64 field tag
+	owner         string // The team that owns this type or field, set using the owner field tag or (*Schema).RegisterTypeOwner
+	description   string // The description of this type or field, set using (*Schema).RegisterDescriptions
+	cost          *int   // The complexity cost of this field, set using the cost field tag or (*Schema).RegisterFieldCost
+	memoize       bool   // The field is resolved once per parent, arguments and selection set within a request, set using the memo field tag or (*Schema).RegisterMemoizedField
+
+	// The role required to resolve this field or type, an empty role only requires the user to be authenticated
+	// Set using the auth field tag, (*Schema).RegisterFieldAuth or (*Schema).RegisterTypeAuth
+	auth *string
+	// The scope of the schema views this field is part of, empty if the field is part of all views, see (*Schema).View
+	scope string
 
 	// Value type == valueTypeObj || valueTypeInterface
-	objContents map[uint32]*obj
+	objContents objFields
+
+	// Value type == valueTypeInterface, set using (*Schema).RegisterTypeResolver
+	typeResolver TypeResolver
+
+	// Value type == valueTypeObjRef || valueTypeInterfaceRef
+	// ref points to the object or interface with typeName, set by (*Schema).linkRefs so resolving a ref doesn't need a map lookup
+	ref *obj
+	// variant is the key of the object in Schema.typeVariants the ref points to
+	variant string
 
 	// Value type == valueTypeObj
 	customObjValue *reflect.Value // Mainly Graphql internal values like __schema
 
+	// customResolver resolves the field instead of the default resolver, used for fields without a go value like _entities
+	customResolver func(ctx *Ctx, dept uint8, hasSubSelection bool) bool
+
+	// Apollo federation directives of this field
+	federation fieldFederation
+
 	// Value is inside struct
 	structFieldIdx int
+	goFieldName    string
+	goFieldIndex   []int        // set if the field is promoted from an embedded struct
+	embeddedPtr    []int        // the index of the last embedded pointer the field or method is promoted through, the field is null if a pointer is nil
+	fieldBinding   FieldBinding // set if a binding is registered for the parent struct, see (*Schema).RegisterBinding
+	goFieldType    reflect.Type // the type of the struct field, set together with fieldBinding
+
+	// Value type == valueTypeData inside a struct, see computeFieldOffsets
+	structFieldOffset    uintptr
+	hasStructFieldOffset bool
 
 	// Value type == valueTypeArray || type == valueTypePtr
 	innerContent *obj
+	iterator     iteratorKind // set if the list is an iterator, see iteratorElem
 
 	// Value type == valueTypeData
 	dataValueType reflect.Kind
+	scalar        *qlType // set if the value is a custom scalar registered using (*Schema).RegisterScalar
 
 	// Value type == valueTypeMethod
 	method *objMethod
@@ -124,6 +236,64 @@ func getObjKey(key []byte) uint32 {
 	return hasher.Sum32()
 }
 
+// objFields contains the fields of an object or interface by the key of their name, see getObjKey
+// Names with the same key end up in the same bucket, lookups compare the name so a collision can't resolve the wrong field
+type objFields map[uint32][]*obj
+
+// get returns the field with name, key must be the key of name
+func (f objFields) get(key uint32, name []byte) (*obj, bool) {
+	for _, field := range f[key] {
+		if bytes.Equal(field.qlFieldName, name) {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// getByName returns the field with name
+func (f objFields) getByName(name string) (*obj, bool) {
+	return f.get(getObjKey([]byte(name)), []byte(name))
+}
+
+// all returns all fields in no particular order
+func (f objFields) all() []*obj {
+	res := make([]*obj, 0, len(f))
+	for _, bucket := range f {
+		res = append(res, bucket...)
+	}
+	return res
+}
+
+// set adds field or replaces the field with the same name
+func (f objFields) set(field *obj) {
+	key := getObjKey(field.qlFieldName)
+	bucket := f[key]
+	for i, existing := range bucket {
+		if bytes.Equal(existing.qlFieldName, field.qlFieldName) {
+			bucket[i] = field
+			return
+		}
+	}
+	f[key] = append(bucket, field)
+}
+
+// remove removes the field with name if there is one
+func (f objFields) remove(name string) {
+	key := getObjKey([]byte(name))
+	bucket := f[key]
+	for i, existing := range bucket {
+		if string(existing.qlFieldName) == name {
+			bucket = append(bucket[:i:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(f, key)
+	} else {
+		f[key] = bucket
+	}
+}
+
 func (o *obj) getRef() obj {
 	switch o.valueType {
 	case valueTypeObj:
@@ -147,13 +317,80 @@ func (o *obj) getRef() obj {
 	}
 }
 
+// linkRefs sets the ref of all objects and interface refs within the schema, see obj.ref
+func (s *Schema) linkRefs() {
+	linked := map[*obj]bool{}
+	var link func(o *obj)
+	link = func(o *obj) {
+		if o == nil || linked[o] {
+			return
+		}
+		linked[o] = true
+
+		switch o.valueType {
+		case valueTypeObjRef:
+			if o.variant != "" {
+				o.ref = s.typeVariants[o.variant]
+			} else {
+				o.ref = s.types[o.typeName]
+			}
+		case valueTypeInterfaceRef:
+			o.ref = s.interfaces[o.typeName]
+		}
+		link(o.innerContent)
+		if o.method != nil {
+			link(&o.method.outType)
+		}
+		for _, bucket := range o.objContents {
+			for _, field := range bucket {
+				link(field)
+			}
+		}
+		for _, implementation := range o.implementations {
+			link(implementation)
+		}
+	}
+
+	for _, typeObj := range s.types {
+		link(typeObj)
+	}
+	for _, typeObj := range s.typeVariants {
+		link(typeObj)
+	}
+	for _, typeObj := range s.interfaces {
+		link(typeObj)
+	}
+	link(s.rootQuery)
+	link(s.rootMethod)
+}
+
+// resolveRef returns the object or interface the ref typeObj points to
+// Refs that are not linked by linkRefs fall back to a lookup by name
+func (s *Schema) resolveRef(typeObj *obj) (*obj, bool) {
+	if typeObj.ref != nil {
+		return typeObj.ref, true
+	}
+	if typeObj.valueType == valueTypeInterfaceRef {
+		res, ok := s.interfaces[typeObj.typeName]
+		return res, ok
+	}
+	if typeObj.variant != "" {
+		res, ok := s.typeVariants[typeObj.variant]
+		return res, ok
+	}
+	res, ok := s.types[typeObj.typeName]
+	return res, ok
+}
+
 type objMethod struct {
 	// Is this a function field inside this object or a method attached to the struct
 	// true = func (*someStruct) ResolveFooBar() string {}
 	// false = ResolveFooBar func() string
 	isTypeMethod   bool
+	ptrReceiver    bool // the type method has a pointer receiver
 	goFunctionName string
 	goType         reflect.Type
+	binding        MethodBinding // set if a binding is registered for the struct of this type method, see (*Schema).RegisterBinding
 
 	ins        []baseInput             // The real function inputs
 	inFields   map[string]referToInput // Contains all the fields of all the ins
@@ -162,6 +399,14 @@ type objMethod struct {
 	outNr      int
 	outType    obj
 	errorOutNr *int
+
+	// The parent value is the first input of the function, used by field resolvers
+	parentInput      bool
+	parentInputIsPtr bool
+
+	// The last input is filled with the arguments of the field, used by directives
+	fieldArgs      bool
+	fieldArgInputs map[string]input
 }
 
 type inputMap map[string]*input
@@ -178,11 +423,17 @@ type input struct {
 	isEnum        bool
 	enumTypeIndex int
 	isID          bool
+	isInt64       bool
 	isFile        bool
+	isUpload      bool // isFile is also true
 	isTime        bool
+	isText        bool // the value implements encoding.TextUnmarshaler and is parsed from a String scalar
+	scalar        *qlType
 
 	goFieldIdx  int
 	gqFieldName string
+	required    bool   // the argument must be set, see the required gq tag modifier
+	description string // the description of the input object or of the field, set using (*Schema).RegisterDescriptions
 
 	// kind == Slice, Array or Ptr
 	elem *input
@@ -191,11 +442,13 @@ type input struct {
 	isStructPointers bool
 	structName       string
 	structContent    map[string]input
+	oneOf            bool // exactly one field must be set, see yarql.OneOf
 }
 
 type baseInput struct {
-	isCtx  bool
-	goType *reflect.Type
+	isCtx     bool
+	isContext bool // the input is a context.Context
+	goType    *reflect.Type
 }
 
 // SchemaOptions are options for creating a new schema
@@ -204,6 +457,35 @@ type SchemaOptions struct {
 	noMethodEqualToQueryChecks bool
 
 	SkipGraphqlTypesInjection bool
+
+	// Description is the description of the schema shown in the introspection and schema definition language
+	Description string
+
+	// EnableMockDirective adds the @mock(value: String) field directive
+	// Fields marked with @mock do not call their resolver but return the value or generated data matching the field's type
+	// Meant for development environments where not all resolvers are released yet
+	EnableMockDirective bool
+	// MockSeed changes the data generated by @mock and MockResolvers, the same seed always results in the same data
+	MockSeed int64
+	// MockResolvers resolves all fields with generated data matching the field's type instead of calling the resolvers
+	// This allows frontend development against the schema before the resolvers exist, introspection is not mocked
+	// The generated data can be changed per type using (*yarql.Schema).RegisterMock
+	MockResolvers bool
+
+	// EnableFederation adds the Apollo federation _service and _entities fields so the schema can be used as subgraph
+	EnableFederation bool
+	// FederationV2 marks the schema as Apollo federation v2 subgraph, this implies EnableFederation
+	// Required to use the v2 directives like @shareable, @inaccessible, @override, @tag and @interfaceObject
+	FederationV2 bool
+
+	// EnableRelay adds the relay node(id: ID!) and nodes(ids: [ID!]!) fields to the query root
+	// These fields use the fetchers registered using (*yarql.Schema).RegisterNodeFetcher()
+	EnableRelay bool
+
+	// FieldVisibility hides fields for a request if it returns false, hidden fields are left out of the introspection and cannot be queried
+	// This can be used to hide admin only fields entirely from other users, typeName and fieldName are graphql names
+	// Note that the introspection result is not precomputed if this is set
+	FieldVisibility FieldVisibility
 }
 
 type parseCtx struct {
@@ -211,19 +493,40 @@ type parseCtx struct {
 	unknownTypesCount  int
 	unknownInputsCount int
 	parsedMethods      []*objMethod
+	composedRoots      map[reflect.Type]*obj // the roots of the schemas added using (*Schema).AddSchema and the root they are added to
 }
 
 // NewSchema creates a new schema wherevia you can define the graphql types and make queries
 func NewSchema() *Schema {
 	s := &Schema{
 		types:             types{},
+		typeVariants:      types{},
 		inTypes:           inputMap{},
 		interfaces:        types{},
 		MaxDepth:          255,
 		graphqlObjFields:  map[string][]qlField{},
 		definedEnums:      []enum{},
 		definedDirectives: map[DirectiveLocation][]*Directive{},
-		Result:            make([]byte, 16384),
+		typeOwners:        map[reflect.Type]string{},
+		descriptions:      map[reflect.Type]Descriptions{},
+		typeAuth:          map[reflect.Type]string{},
+		typeResolvers:     map[reflect.Type]TypeResolver{},
+		requestStates:     map[reflect.Type]func(ctx *Ctx) interface{}{},
+		fieldResolvers:    map[reflect.Type][]fieldResolver{},
+		entities:          map[reflect.Type]*Entity{},
+		entitiesByName:    map[string]*Entity{},
+		typeFederation:    map[reflect.Type]*TypeFederation{},
+		federationByName:  map[string]*TypeFederation{},
+		nodeFetchers:      map[reflect.Type]*nodeFetcher{},
+		nodesByName:       map[string]*nodeFetcher{},
+		customScalars:     map[reflect.Type]*qlType{},
+		loaders:           map[string]BatchFunc{},
+		bindings:          map[reflect.Type]Binding{},
+
+		MaxIntrospectionDepth:  15,
+		MaxIntrospectionFields: 100000,
+		InitialResultSize:      16384,
+		MaxRetainedResultSize:  1 << 20,
 	}
 
 	added, err := s.RegisterEnum(directiveLocationMap)
@@ -249,7 +552,9 @@ func NewSchema() *Schema {
 			DirectiveLocationFragment,
 			DirectiveLocationFragmentInline,
 		},
-		Method: func(args struct{ If bool }) DirectiveModifier {
+		Method: func(args struct {
+			If bool `gq:",required"`
+		}) DirectiveModifier {
 			return DirectiveModifier{
 				Skip: args.If,
 			}
@@ -267,7 +572,9 @@ func NewSchema() *Schema {
 			DirectiveLocationFragment,
 			DirectiveLocationFragmentInline,
 		},
-		Method: func(args struct{ If bool }) DirectiveModifier {
+		Method: func(args struct {
+			If bool `gq:",required"`
+		}) DirectiveModifier {
 			return DirectiveModifier{
 				Skip: !args.If,
 			}
@@ -292,8 +599,17 @@ func (s *Schema) SetCacheRules(
 
 // Parse parses your queries and methods
 func (s *Schema) Parse(queries interface{}, methods interface{}, options *SchemaOptions) error {
-	s.rootQueryValue = reflect.ValueOf(queries)
-	s.rootMethodValue = reflect.ValueOf(methods)
+	// Parse modifies some registrations so a copy is kept for (*Schema).Reload
+	s.reload = &reloadState{
+		registered: s.registrations(),
+		queries:    queries,
+		methods:    methods,
+		options:    options,
+	}
+
+	// The root values are made addressable so bindings and methods with a pointer receiver can use them without copying them
+	s.rootQueryValue = addressableRootValue(reflect.ValueOf(queries))
+	s.rootMethodValue = addressableRootValue(reflect.ValueOf(methods))
 
 	ctx := &parseCtx{
 		schema:        s,
@@ -318,6 +634,54 @@ func (s *Schema) Parse(queries interface{}, methods interface{}, options *Schema
 	}
 	s.rootMethod = s.types[obj.typeName]
 
+	if options != nil {
+		s.description = options.Description
+	}
+
+	err = ctx.checkComposedSchemas()
+	if err != nil {
+		return err
+	}
+
+	if options != nil && options.EnableRelay {
+		err = s.addRelayResolvers()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ctx.checkRootResolvers()
+	if err != nil {
+		return err
+	}
+
+	if options != nil && options.EnableRelay {
+		err = ctx.checkNodeFetchers()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ctx.checkFieldCosts()
+	if err != nil {
+		return err
+	}
+
+	err = ctx.checkMemoizedFields()
+	if err != nil {
+		return err
+	}
+
+	err = ctx.checkFieldAuth()
+	if err != nil {
+		return err
+	}
+
+	err = ctx.checkFieldScopes()
+	if err != nil {
+		return err
+	}
+
 	if options == nil || !options.noMethodEqualToQueryChecks {
 		queryPkg := s.rootQuery.goPkgPath + s.rootQuery.goTypeName
 		methodPkg := s.rootMethod.goPkgPath + s.rootMethod.goTypeName
@@ -326,6 +690,27 @@ func (s *Schema) Parse(queries interface{}, methods interface{}, options *Schema
 		}
 	}
 
+	if options != nil && (options.EnableFederation || options.FederationV2) {
+		s.federation = true
+		s.federationV2 = options.FederationV2
+		err = s.injectFederation(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options != nil {
+		s.mockSeed = options.MockSeed
+		s.mockResolvers = options.MockResolvers
+		s.fieldVisibility = options.FieldVisibility
+	}
+	if options != nil && options.EnableMockDirective {
+		err = s.RegisterDirective(mockDirective())
+		if err != nil {
+			return err
+		}
+	}
+
 	if options == nil || !options.SkipGraphqlTypesInjection {
 		s.injectQLTypes(ctx)
 	}
@@ -350,12 +735,25 @@ func (s *Schema) Parse(queries interface{}, methods interface{}, options *Schema
 		}
 	}
 
+	s.linkRefs()
+
 	s.ctx = newCtx(s)
+	s.pool = newSchemaPool(s)
+	s.Result = make([]byte, 0, s.InitialResultSize)
 	s.parsed = true
 
+	if options == nil || !options.SkipGraphqlTypesInjection {
+		s.precomputeIntrospection()
+	}
+
 	return nil
 }
 
+// isPointerVariant returns true if the go types of a and b are instantiations of the same generic type that only differ in pointers
+func isPointerVariant(a, b *obj) bool {
+	return a.goPkgPath == b.goPkgPath && strings.ReplaceAll(a.goTypeName, "*", "") == strings.ReplaceAll(b.goTypeName, "*", "")
+}
+
 func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 	res := obj{
 		typeNameBytes: []byte(t.Name()),
@@ -369,29 +767,65 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 		return &res, nil
 	}
 
+	if isTextMarshaler(t) && c.schema.customScalars[t] == nil {
+		if _, enum := c.schema.getEnum(t); enum == nil {
+			res.valueType = valueTypeText
+			res.isID = hasIDTag
+			return &res, nil
+		}
+	}
+
+	if elem, iterator := iteratorElem(t); iterator != iteratorNone {
+		res.valueType = valueTypeArray
+		res.iterator = iterator
+		obj, err := c.check(elem, false)
+		if err != nil {
+			return nil, err
+		}
+		res.innerContent = obj
+		return &res, nil
+	}
+
+	// variant is set for the generic structs of this package like Connection[T], see Schema.typeVariants
+	variant := ""
+	replacesRegistered := false
+
 	switch t.Kind() {
 	case reflect.Struct:
 		if hasIDTag {
 			return nil, errors.New("structs cannot have ID attribute")
 		}
 		if res.typeName != "" {
-			newName, ok := renamedTypes[res.typeName]
-			if ok {
-				res.typeName = newName
-				res.typeNameBytes = []byte(newName)
+			newName, err := c.qlTypeName(t)
+			if err != nil {
+				return nil, err
+			}
+			res.typeName = newName
+			res.typeNameBytes = []byte(newName)
+
+			if _, ok := reflect.Zero(t).Interface().(typeNamer); ok {
+				variant = res.goPkgPath + "." + res.goTypeName
+				if v, ok := c.schema.typeVariants.Get(variant); ok {
+					res = v.getRef()
+					res.variant = variant
+					return &res, nil
+				}
 			}
 
 			v, ok := c.schema.types.Get(res.typeName)
 			if ok {
-				if v.goPkgPath != res.goPkgPath {
-					return nil, fmt.Errorf("cannot have 2 structs with same type name: %s(%s) != %s(%s)", v.goPkgPath, res.goTypeName, res.goPkgPath, res.goTypeName)
+				if variant != "" && isPointerVariant(v, &res) {
+					// Advertise the variant with the most pointers as its fields can be null
+					replacesRegistered = strings.Count(res.goTypeName, "*") > strings.Count(v.goTypeName, "*")
+				} else if v.goPkgPath != res.goPkgPath || v.goTypeName != res.goTypeName {
+					return nil, fmt.Errorf("cannot have 2 structs with same type name: %s(%s) != %s(%s)", v.goPkgPath, v.goTypeName, res.goPkgPath, res.goTypeName)
+				} else {
+					res = v.getRef()
+					return &res, nil
 				}
-
-				res = v.getRef()
-				return &res, nil
 			}
 
-			implementations := structImplementsMap[t.Name()]
+			implementations := c.schemaInterfaces(t, structImplementsMap[t.Name()])
 			for _, implementation := range implementations {
 				impl, err := c.check(implementation, false)
 				if err != nil {
@@ -406,12 +840,26 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 		}
 
 		res.valueType = valueTypeObj
-		res.objContents = map[uint32]*obj{}
+		res.objContents = objFields{}
+		res.owner = c.schema.typeOwners[t]
+		res.description = c.schema.descriptions[t].Type
+		if role, ok := c.schema.typeAuth[t]; ok {
+			res.auth = &role
+			c.schema.auth = true
+		}
 
-		typesInner := c.schema.types
-		typesInner[res.typeName] = &res
-		c.schema.types = typesInner
-		c.checkStructFieldRecursive(t, &res)
+		if variant != "" {
+			c.schema.typeVariants[variant] = &res
+		}
+		if _, ok := c.schema.types[res.typeName]; !ok || replacesRegistered {
+			typesInner := c.schema.types
+			typesInner[res.typeName] = &res
+			c.schema.types = typesInner
+		}
+		err := c.checkStructFieldRecursive(t, &res)
+		if err != nil {
+			return nil, err
+		}
 	case reflect.Array, reflect.Slice, reflect.Ptr:
 		isPtr := t.Kind() == reflect.Ptr
 		if isPtr {
@@ -433,16 +881,17 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 			return nil, errors.New("inline interfaces not allowed")
 		}
 
-		newName, ok := renamedTypes[res.typeName]
-		if ok {
-			res.typeName = newName
-			res.typeNameBytes = []byte(newName)
+		newName, err := c.qlTypeName(t)
+		if err != nil {
+			return nil, err
 		}
+		res.typeName = newName
+		res.typeNameBytes = []byte(newName)
 
 		v, ok := c.schema.interfaces.Get(res.typeName)
 		if ok {
-			if v.goPkgPath != res.goPkgPath {
-				return nil, fmt.Errorf("cannot have 2 interfaces with same type name: %s(%s) != %s(%s)", v.goPkgPath, res.goTypeName, res.goPkgPath, res.goTypeName)
+			if v.goPkgPath != res.goPkgPath || v.goTypeName != res.goTypeName {
+				return nil, fmt.Errorf("cannot have 2 interfaces with same type name: %s(%s) != %s(%s)", v.goPkgPath, v.goTypeName, res.goPkgPath, res.goTypeName)
 			}
 
 			res = v.getRef()
@@ -451,7 +900,14 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 
 		res.valueType = valueTypeInterface
 		res.implementations = []*obj{}
-		res.objContents = map[uint32]*obj{}
+		res.objContents = objFields{}
+		res.owner = c.schema.typeOwners[t]
+		res.description = c.schema.descriptions[t].Type
+		res.typeResolver = c.schema.typeResolvers[t]
+		if role, ok := c.schema.typeAuth[t]; ok {
+			res.auth = &role
+			c.schema.auth = true
+		}
 
 		// Store the interface so we don't get an infinite loop and can reference this one
 		interfaces := c.schema.interfaces
@@ -463,8 +919,8 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 			methodPkgName = "inline interface"
 		}
 
-		typesThatImplementInterface, ok := implementationMap[t.Name()]
-		if !ok {
+		typesThatImplementInterface := c.schemaImplementations(t, implementationMap[t.Name()])
+		if len(typesThatImplementInterface) == 0 {
 			return nil, errors.New("cannot register a interface without explicit implementations")
 		}
 		for _, interfaceType := range typesThatImplementInterface {
@@ -495,6 +951,7 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 		} else {
 			res.valueType = valueTypeData
 			res.dataValueType = t.Kind()
+			res.scalar = c.schema.customScalars[t]
 
 			if hasIDTag {
 				res.isID = hasIDTag
@@ -507,29 +964,32 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 	}
 
 	if res.valueType == valueTypeObj || res.valueType == valueTypeInterface {
-		for i := 0; i < t.NumMethod(); i++ {
-			method := t.Method(i)
-			methodObj, name, isID, err := c.checkFunction(method.Name, method.Type, true, false)
+		err := c.checkTypeMethods(t, &res)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.valueType == valueTypeObj {
+			err := c.checkFieldResolvers(t, &res)
 			if err != nil {
 				return nil, err
-			} else if methodObj == nil {
-				continue
-			}
-
-			qlFieldName := []byte(name)
-			res.objContents[getObjKey(qlFieldName)] = &obj{
-				qlFieldName:    qlFieldName,
-				valueType:      valueTypeMethod,
-				goPkgPath:      method.PkgPath,
-				goTypeName:     method.Name,
-				structFieldIdx: i,
-				method:         methodObj,
-				isID:           isID,
 			}
+			c.bindObj(t, &res)
+			computeFieldOffsets(t, &res)
 		}
 
 		if res.valueType == valueTypeInterface {
 			res = c.schema.interfaces.Add(res)
+		} else if variant != "" {
+			// Only the variant registered under the type name is part of the schema, the other variants are only used to resolve values
+			advertised := c.schema.types[res.typeName] == &res
+			typeObj := res
+			c.schema.typeVariants[variant] = &typeObj
+			if advertised {
+				c.schema.types[res.typeName] = &typeObj
+			}
+			res = typeObj.getRef()
+			res.variant = variant
 		} else {
 			res = c.schema.types.Add(res)
 		}
@@ -539,16 +999,89 @@ func (c *parseCtx) check(t reflect.Type, hasIDTag bool) (*obj, error) {
 	return &res, nil
 }
 
-func (c *parseCtx) checkStructFieldRecursive(t reflect.Type, res *obj) {
+// checkTypeMethods adds the resolver methods of the struct or interface type t to res
+func (c *parseCtx) checkTypeMethods(t reflect.Type, res *obj) error {
+	methodsType := t
+	if res.valueType == valueTypeObj {
+		// Also include the methods with a pointer receiver, see typeMethod
+		methodsType = reflect.PtrTo(t)
+	}
+	for i := 0; i < methodsType.NumMethod(); i++ {
+		method := methodsType.Method(i)
+		methodObj, name, isID, err := c.checkFunction(method.Name, method.Type, true, false)
+		if err != nil {
+			return err
+		} else if methodObj == nil {
+			continue
+		}
+		if methodsType != t {
+			_, hasValueReceiver := t.MethodByName(method.Name)
+			methodObj.ptrReceiver = !hasValueReceiver
+		}
+
+		var embeddedPtr []int
+		if res.valueType == valueTypeObj {
+			promotedName, promoted, hidden, ptrIndex := promotedMethod(t, method.Name, name)
+			if hidden {
+				continue
+			}
+			if promoted {
+				name = promotedName
+				embeddedPtr = ptrIndex
+				existing, ok := res.objContents.getByName(name)
+				if ok && existing.goFieldIndex == nil && len(existing.goFieldName) > 0 {
+					// The fields of t hide the methods promoted from embedded structs
+					continue
+				}
+			}
+		}
+
+		qlFieldName := []byte(name)
+		res.objContents.set(&obj{
+			qlFieldName:    qlFieldName,
+			valueType:      valueTypeMethod,
+			goPkgPath:      method.PkgPath,
+			goTypeName:     method.Name,
+			structFieldIdx: i,
+			method:         methodObj,
+			isID:           isID,
+			description:    c.schema.methodDescription(t, method.Name),
+			embeddedPtr:    embeddedPtr,
+		})
+	}
+	return nil
+}
+
+// checkStructFieldRecursive adds the fields of the struct type t and the fields promoted from its embedded structs to res
+// Like in go the fields of t hide the promoted fields with the same name and promoted fields with the same name at the same depth are left out
+func (c *parseCtx) checkStructFieldRecursive(t reflect.Type, res *obj) error {
+	embedded := []embeddedStruct{}
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 		if field.Anonymous {
-			c.checkStructFieldRecursive(field.Type, res)
+			tags, err := parseFieldTagGQ(&field)
+			if err != nil {
+				return err
+			}
+			if tags.ignore {
+				continue
+			}
+			if !tags.asField {
+				embeddedType := field.Type
+				isPtr := embeddedType.Kind() == reflect.Ptr
+				if isPtr {
+					embeddedType = embeddedType.Elem()
+				}
+				if embeddedType.Kind() == reflect.Struct {
+					embedded = append(embedded, embeddedStruct{idx: i, goType: embeddedType, prefix: tags.prefix, isPtr: isPtr})
+				}
+				continue
+			}
 		}
 
 		customName, obj, err := c.checkStructField(field, i)
 		if err != nil {
-			return
+			return err
 		}
 		if obj != nil {
 			name := formatGoNameToQL(field.Name)
@@ -556,31 +1089,159 @@ func (c *parseCtx) checkStructFieldRecursive(t reflect.Type, res *obj) {
 				name = *customName
 			}
 			obj.qlFieldName = []byte(name)
+			obj.description = c.schema.descriptions[t].Fields[field.Name]
 
-			res.objContents[getObjKey(obj.qlFieldName)] = obj
+			res.objContents.set(obj)
+		}
+	}
+
+	promoted := objFields{}
+	ambiguous := map[string]bool{}
+	for _, embedded := range embedded {
+		embeddedObj := obj{objContents: objFields{}}
+		err := c.checkStructFieldRecursive(embedded.goType, &embeddedObj)
+		if err != nil {
+			return err
+		}
+		for _, field := range embeddedObj.objContents.all() {
+			// Promoted fields are resolved using their index as their go name might be ambiguous
+			index := field.goFieldIndex
+			if index == nil {
+				index = []int{field.structFieldIdx}
+			}
+			field.goFieldIndex = append([]int{embedded.idx}, index...)
+			if field.embeddedPtr != nil {
+				field.embeddedPtr = append([]int{embedded.idx}, field.embeddedPtr...)
+			} else if embedded.isPtr {
+				field.embeddedPtr = []int{embedded.idx}
+			}
+
+			name := prefixFieldName(embedded.prefix, string(field.qlFieldName))
+			field.qlFieldName = []byte(name)
+			if _, ok := promoted.getByName(name); ok {
+				ambiguous[name] = true
+			}
+			promoted.set(field)
 		}
 	}
+	for _, field := range promoted.all() {
+		name := string(field.qlFieldName)
+		if ambiguous[name] {
+			continue
+		}
+		if _, ok := res.objContents.getByName(name); ok {
+			continue
+		}
+		res.objContents.set(field)
+	}
+	return nil
 }
 
-func (c *parseCtx) checkStructField(field reflect.StructField, idx int) (customName *string, obj *obj, err error) {
-	if field.Anonymous {
-		return nil, nil, nil
+// goStructField returns the struct field of o within the struct type t
+func (o *obj) goStructField(t reflect.Type) (reflect.StructField, bool) {
+	if o.goFieldIndex != nil {
+		// Like FieldByName the index is relative to t
+		field := t.FieldByIndex(o.goFieldIndex)
+		field.Index = o.goFieldIndex
+		return field, true
 	}
+	return t.FieldByName(o.goFieldName)
+}
 
-	var ignore, isID bool
-	customName, ignore, isID, err = parseFieldTagGQ(&field)
-	if ignore || err != nil {
+// embeddedStruct is a struct embedded in another struct of which the fields are promoted
+type embeddedStruct struct {
+	idx    int
+	goType reflect.Type
+	prefix string // set using the prefix field tag (`gq:",prefix=owner"`)
+	isPtr  bool
+}
+
+// prefixFieldName returns the name of a promoted field with the prefix of the embedded struct, for example: owner + name = ownerName
+func prefixFieldName(prefix string, name string) string {
+	if len(prefix) == 0 {
+		return name
+	}
+	return prefix + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// promotedMethod returns the graphql name of the method goName if it's promoted from an embedded struct of t
+// The prefixes of the embedded structs are applied to name, hidden is true if the method is promoted from a embedded struct that is ignored or exposed as field
+// ptrIndex is the index of the last embedded pointer the method is promoted through, see obj.embeddedPtr
+func promotedMethod(t reflect.Type, goName string, name string) (promotedName string, promoted bool, hidden bool, ptrIndex []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.Anonymous {
+			continue
+		}
+		embeddedType := field.Type
+		isPtr := embeddedType.Kind() == reflect.Ptr
+		if isPtr {
+			embeddedType = embeddedType.Elem()
+		}
+		methodsType := embeddedType
+		if methodsType.Kind() != reflect.Interface {
+			methodsType = reflect.PtrTo(methodsType)
+		}
+		if _, ok := methodsType.MethodByName(goName); !ok {
+			continue
+		}
+
+		tags, _ := parseFieldTagGQ(&field)
+		if tags.ignore || tags.asField {
+			return name, true, true, nil
+		}
+		if isPtr {
+			ptrIndex = []int{i}
+		}
+		if embeddedType.Kind() == reflect.Struct {
+			var innerPtrIndex []int
+			name, _, hidden, innerPtrIndex = promotedMethod(embeddedType, goName, name)
+			if innerPtrIndex != nil {
+				ptrIndex = append([]int{i}, innerPtrIndex...)
+			}
+		}
+		return prefixFieldName(tags.prefix, name), true, hidden, ptrIndex
+	}
+	return name, false, false, nil
+}
+
+func (c *parseCtx) checkStructField(field reflect.StructField, idx int) (customName *string, obj *obj, err error) {
+	tags, err := parseFieldTagGQ(&field)
+	if tags.ignore || err != nil {
 		return nil, nil, err
 	}
+	if field.Anonymous != tags.asField {
+		if field.Anonymous {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.New("the asField field tag argument can only be used on embedded structs, struct field: " + field.Name)
+	}
+	if len(tags.prefix) > 0 && !field.Anonymous {
+		return nil, nil, errors.New("the prefix field tag argument can only be used on embedded structs, struct field: " + field.Name)
+	}
+	customName = tags.newName
 
-	if field.Type.Kind() == reflect.Func {
-		obj, err = c.checkStructFieldFunc(field.Name, field.Type, isID, idx)
+	if _, iterator := iteratorElem(field.Type); field.Type.Kind() == reflect.Func && iterator == iteratorNone {
+		obj, err = c.checkStructFieldFunc(field.Name, field.Type, tags.isID, idx)
 	} else {
-		obj, err = c.check(field.Type, isID)
+		obj, err = c.check(field.Type, tags.isID)
 	}
 
+	if obj != nil && tags.isInt64 {
+		err = markInt64Obj(obj, tags.isID)
+	}
 	if obj != nil {
 		obj.structFieldIdx = idx
+		obj.goFieldName = field.Name
+		obj.owner = tags.owner
+		obj.cost = tags.cost
+		obj.memoize = tags.memoize
+		if tags.auth != nil {
+			obj.auth = tags.auth
+			c.schema.auth = true
+		}
+		obj.scope = tags.scope
+		obj.federation = tags.federation
 	}
 	return
 }
@@ -602,6 +1263,7 @@ func (c *parseCtx) checkStructFieldFunc(fieldName string, goType reflect.Type, h
 }
 
 var ctxType = reflect.TypeOf(Ctx{})
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
 
 func isCtx(t reflect.Type) bool {
 	return t.Kind() == reflect.Struct && ctxType.Name() == t.Name() && ctxType.PkgPath() == t.PkgPath()
@@ -617,8 +1279,8 @@ func (c *parseCtx) checkFunctionInputStruct(field *reflect.StructField, idx int)
 		return res, true, nil
 	}
 
-	newName, ignore, isID, err := parseFieldTagGQ(field)
-	if ignore {
+	tags, err := parseFieldTagGQ(field)
+	if tags.ignore {
 		// skip field
 		return res, true, nil
 	}
@@ -627,17 +1289,21 @@ func (c *parseCtx) checkFunctionInputStruct(field *reflect.StructField, idx int)
 	}
 
 	qlFieldName := formatGoNameToQL(field.Name)
-	if newName != nil {
-		qlFieldName = *newName
+	if tags.newName != nil {
+		qlFieldName = *tags.newName
 	}
 
-	res, err = c.checkFunctionInput(field.Type, isID)
+	res, err = c.checkFunctionInput(field.Type, tags.isID)
+	if err == nil && tags.isInt64 {
+		err = markInt64Input(&res, tags.isID)
+	}
 	if err != nil {
 		return input{}, false, wrapErr(err)
 	}
 
 	res.goFieldIdx = idx
 	res.gqFieldName = qlFieldName
+	res.required = tags.required
 
 	return
 }
@@ -648,12 +1314,22 @@ func (c *parseCtx) checkFunctionInput(t reflect.Type, hasIDTag bool) (input, err
 		kind: kind,
 	}
 
+	if isTextUnmarshaler(t) && c.schema.customScalars[t] == nil {
+		if _, enum := c.schema.getEnum(t); enum == nil {
+			res.isText = true
+			res.isID = hasIDTag
+			return res, nil
+		}
+	}
+
 	switch kind {
 	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
 		enumIndex, enum := c.schema.getEnum(t)
 		if enum != nil {
 			res.isEnum = true
 			res.enumTypeIndex = enumIndex
+		} else if scalar, ok := c.schema.customScalars[t]; ok && !hasIDTag {
+			res.scalar = scalar
 		} else if hasIDTag {
 			res.isID = true
 			err := checkValidIDKind(res.kind)
@@ -667,6 +1343,12 @@ func (c *parseCtx) checkFunctionInput(t reflect.Type, hasIDTag bool) (input, err
 			res.isFile = true
 			return res, nil
 		}
+		if t.AssignableTo(reflect.TypeOf(&Upload{})) {
+			// A streamed file, handled like a file header
+			res.isFile = true
+			res.isUpload = true
+			return res, nil
+		}
 
 		input, err := c.checkFunctionInput(t.Elem(), hasIDTag)
 		if err != nil {
@@ -692,6 +1374,8 @@ func (c *parseCtx) checkFunctionInput(t reflect.Type, hasIDTag bool) (input, err
 		if len(structName) == 0 {
 			c.unknownInputsCount++
 			structName = "__UnknownInput" + strconv.Itoa(c.unknownInputsCount)
+		} else if newStructName, ok := renamedInputTypes[structName]; ok {
+			structName = newStructName
 		} else {
 			newStructName, ok := renamedTypes[structName]
 			if ok {
@@ -700,7 +1384,7 @@ func (c *parseCtx) checkFunctionInput(t reflect.Type, hasIDTag bool) (input, err
 			_, equalTypeExist := c.schema.types[structName]
 			if equalTypeExist {
 				// types and inputs with the same name are not allowed in graphql, add __input as suffix
-				// TODO allow this value to be filledin by the user
+				// use InputTypeRename to choose a different name
 				structName = structName + "__input"
 			}
 		}
@@ -712,6 +1396,7 @@ func (c *parseCtx) checkFunctionInput(t reflect.Type, hasIDTag bool) (input, err
 
 			res.structName = structName
 			res.structContent = map[string]input{}
+			res.description = c.schema.descriptions[t].Type
 			for i := 0; i < t.NumField(); i++ {
 				field := t.Field(i)
 				input, skip, err := c.checkFunctionInputStruct(&field, i)
@@ -721,8 +1406,17 @@ func (c *parseCtx) checkFunctionInput(t reflect.Type, hasIDTag bool) (input, err
 				if err != nil {
 					return res, err
 				}
+				input.description = c.schema.descriptions[t].Fields[field.Name]
 				res.structContent[input.gqFieldName] = input
 			}
+
+			if isOneOfInput(t) {
+				res.oneOf = true
+				err := checkOneOfInputFields(&res)
+				if err != nil {
+					return res, err
+				}
+			}
 		}
 
 		return input{
@@ -844,6 +1538,14 @@ func (c *parseCtx) checkFunctionIns(method *objMethod) error {
 			input.isCtx = true
 		} else if isCtx(goType) {
 			return fmt.Errorf("%s ctx argument must be a pointer", method.goFunctionName)
+		} else if goType == contextType {
+			input.isContext = true
+		} else if typeKind == reflect.Struct && method.fieldArgs && i == totalInputs-1 {
+			input.goType = &goType
+			err := c.checkFieldArgInputs(method, goType)
+			if err != nil {
+				return err
+			}
 		} else if typeKind == reflect.Struct {
 			input.goType = &goType
 			for i := 0; i < goType.NumField(); i++ {
@@ -855,6 +1557,7 @@ func (c *parseCtx) checkFunctionIns(method *objMethod) error {
 				if err != nil {
 					return fmt.Errorf("%s, type %s (#%d)", err.Error(), goType.Name(), i)
 				}
+				input.description = c.schema.descriptions[goType].Fields[field.Name]
 
 				method.inFields[input.gqFieldName] = referToInput{
 					inputIdx: iInList,
@@ -872,6 +1575,24 @@ func (c *parseCtx) checkFunctionIns(method *objMethod) error {
 	return nil
 }
 
+// checkFieldArgInputs checks the struct that is filled with the arguments of the field a directive is used on
+func (c *parseCtx) checkFieldArgInputs(method *objMethod, goType reflect.Type) error {
+	method.fieldArgInputs = map[string]input{}
+	for i := 0; i < goType.NumField(); i++ {
+		field := goType.Field(i)
+		input, skip, err := c.checkFunctionInputStruct(&field, i)
+		if skip {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s, type %s (#%d)", err.Error(), goType.Name(), i)
+		}
+
+		method.fieldArgInputs[input.gqFieldName] = input
+	}
+	return nil
+}
+
 func formatGoNameToQL(input string) string {
 	if len(input) <= 1 {
 		return strings.ToLower(input)
@@ -885,7 +1606,24 @@ func formatGoNameToQL(input string) string {
 	return string(bytes.ToLower([]byte{input[0]})) + input[1:]
 }
 
-func parseFieldTagGQ(field *reflect.StructField) (newName *string, ignore bool, isID bool, err error) {
+// fieldTags contains the parsed contents of a gq struct tag
+type fieldTags struct {
+	newName    *string
+	ignore     bool
+	isID       bool
+	isInt64    bool
+	required   bool
+	owner      string
+	cost       *int
+	memoize    bool
+	auth       *string
+	scope      string
+	federation fieldFederation
+	asField    bool   // expose an embedded struct as field instead of promoting its fields
+	prefix     string // prefix for the names of the fields promoted from an embedded struct
+}
+
+func parseFieldTagGQ(field *reflect.StructField) (tags fieldTags, err error) {
 	val, ok := field.Tag.Lookup("gq")
 	if !ok {
 		return
@@ -898,17 +1636,88 @@ func parseFieldTagGQ(field *reflect.StructField) (newName *string, ignore bool,
 	nameArg := strings.TrimSpace(args[0])
 	if nameArg != "" {
 		if nameArg == "-" {
-			ignore = true
+			tags.ignore = true
 			return
 		}
 		err = validGraphQlName([]byte(nameArg))
-		newName = &nameArg
+		tags.newName = &nameArg
 	}
 
 	for _, modifier := range args[1:] {
-		switch strings.ToLower(strings.TrimSpace(modifier)) {
+		// Modifiers can have a value like: owner=payments
+		key, value := modifier, ""
+		if idx := strings.IndexByte(modifier, '='); idx != -1 {
+			key, value = modifier[:idx], strings.TrimSpace(modifier[idx+1:])
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		switch key {
 		case "id":
-			isID = true
+			tags.isID = true
+		case "required":
+			tags.required = true
+		case "int64":
+			tags.isInt64 = true
+		case "owner":
+			if value == "" {
+				err = errors.New("gq field tag argument owner requires a value, for example: owner=payments")
+				return
+			}
+			tags.owner = value
+		case "cost":
+			cost, convErr := strconv.Atoi(value)
+			if convErr != nil || cost < 0 {
+				err = errors.New("gq field tag argument cost requires a positive number, for example: cost=10")
+				return
+			}
+			tags.cost = &cost
+		case "memo":
+			tags.memoize = true
+		case "auth":
+			role := value
+			tags.auth = &role
+		case "scope":
+			if value == "" {
+				err = errors.New("gq field tag argument scope requires a value, for example: scope=internal")
+				return
+			}
+			tags.scope = value
+		case "asfield":
+			tags.asField = true
+		case "prefix":
+			if value == "" || validGraphQlName([]byte(value)) != nil {
+				err = errors.New("gq field tag argument prefix requires a valid graphql name, for example: prefix=owner")
+				return
+			}
+			tags.prefix = value
+		case "external":
+			tags.federation.external = true
+		case "shareable":
+			tags.federation.shareable = true
+		case "inaccessible":
+			tags.federation.inaccessible = true
+		case "override":
+			if value == "" {
+				err = errors.New("gq field tag argument override requires a value, for example: override=accounts")
+				return
+			}
+			tags.federation.override = value
+		case "tag":
+			if value == "" {
+				err = errors.New("gq field tag argument tag requires a value, for example: tag=public")
+				return
+			}
+			tags.federation.tags = append(tags.federation.tags, value)
+		case "requires", "provides":
+			if value == "" {
+				err = fmt.Errorf("gq field tag argument %s requires a value, for example: %s=id", key, key)
+				return
+			}
+			if key == "requires" {
+				tags.federation.requires = value
+			} else {
+				tags.federation.provides = value
+			}
 		default:
 			err = fmt.Errorf("unknown field tag gq argument: %s", modifier)
 			return
@@ -942,6 +1751,57 @@ func validGraphQlName(name []byte) error {
 	return nil
 }
 
+// markInt64Obj marks the (inner) value of a field labeled with the int64 tag as Int64 scalar
+func markInt64Obj(item *obj, hasIDTag bool) error {
+	for {
+		switch item.valueType {
+		case valueTypeMethod:
+			item = &item.method.outType
+			continue
+		case valueTypePtr, valueTypeArray:
+			item = item.innerContent
+			continue
+		}
+		break
+	}
+
+	if hasIDTag || item.isID {
+		return errors.New("a field cannot be labeled with both the ID and int64 property")
+	}
+	if item.valueType != valueTypeData || item.scalar != nil || checkValidInt64Kind(item.dataValueType) != nil {
+		return errors.New("only numbers can be labeld with the int64 property")
+	}
+	item.isInt64 = true
+	item.scalar = &scalarInt64
+	return nil
+}
+
+// markInt64Input marks the (inner) value of a argument labeled with the int64 tag as Int64 scalar
+func markInt64Input(in *input, hasIDTag bool) error {
+	for (in.kind == reflect.Ptr || in.kind == reflect.Slice || in.kind == reflect.Array) && in.elem != nil {
+		in = in.elem
+	}
+
+	if hasIDTag || in.isID {
+		return errors.New("a field cannot be labeled with both the ID and int64 property")
+	}
+	if in.isEnum || in.scalar != nil || checkValidInt64Kind(in.kind) != nil {
+		return errors.New("only numbers can be labeld with the int64 property")
+	}
+	in.isInt64 = true
+	return nil
+}
+
+func checkValidInt64Kind(kind reflect.Kind) error {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return errors.New("only numbers can be labeld with the int64 property")
+	}
+	return nil
+}
+
 func checkValidIDKind(kind reflect.Kind) error {
 	switch kind {
 	case reflect.String:
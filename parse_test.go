@@ -52,7 +52,7 @@ func TestCheckStructSimple(t *testing.T) {
 		"c": reflect.Float64,
 	}
 	for name, expectedType := range exists {
-		val, ok := typeObj.objContents[getObjKey([]byte(name))]
+		val, ok := typeObj.objContents.getByName(name)
 		a.True(t, ok)
 		a.Equal(t, valueTypeData, val.valueType)
 		a.Equal(t, expectedType, val.dataValueType)
@@ -74,7 +74,7 @@ func TestCheckStructWArray(t *testing.T) {
 	obj := ctx.schema.types[ref.typeName]
 
 	// Foo is an array
-	val, ok := obj.objContents[getObjKey([]byte("foo"))]
+	val, ok := obj.objContents.getByName("foo")
 	a.True(t, ok)
 	a.Equal(t, valueTypeArray, val.valueType)
 
@@ -96,7 +96,7 @@ func TestCheckStructWPtr(t *testing.T) {
 	obj := ctx.schema.types[ref.typeName]
 
 	// Foo is a ptr
-	val, ok := obj.objContents[getObjKey([]byte("foo"))]
+	val, ok := obj.objContents.getByName("foo")
 	a.True(t, ok)
 	a.Equal(t, valueTypePtr, val.valueType)
 
@@ -118,13 +118,13 @@ func TestCheckStructTags(t *testing.T) {
 	a.NoError(t, err)
 	obj := ctx.schema.types[ref.typeName]
 
-	_, ok := obj.objContents[getObjKey([]byte("otherName"))]
+	_, ok := obj.objContents.getByName("otherName")
 	a.True(t, ok, "name should now be called otherName")
 
-	_, ok = obj.objContents[getObjKey([]byte("name"))]
+	_, ok = obj.objContents.getByName("name")
 	a.False(t, ok, "name should now be called otherName and thus also not appear in the checkres")
 
-	_, ok = obj.objContents[getObjKey([]byte("hiddenField"))]
+	_, ok = obj.objContents.getByName("hiddenField")
 	a.False(t, ok, "hiddenField should be ignored")
 }
 
@@ -168,22 +168,22 @@ func TestCheckMethods(t *testing.T) {
 	a.Nil(t, err)
 	obj := ctx.schema.types[ref.typeName]
 
-	field, ok := obj.objContents[getObjKey([]byte("name"))]
+	field, ok := obj.objContents.getByName("name")
 	a.True(t, ok)
 	a.False(t, field.isID)
 	a.Nil(t, field.method.errorOutNr)
 
-	field, ok = obj.objContents[getObjKey([]byte("banana"))]
+	field, ok = obj.objContents.getByName("banana")
 	a.True(t, ok)
 	a.False(t, field.isID)
 	a.NotNil(t, field.method.errorOutNr)
 
-	field, ok = obj.objContents[getObjKey([]byte("peer"))]
+	field, ok = obj.objContents.getByName("peer")
 	a.True(t, ok)
 	a.False(t, field.isID)
 	a.Nil(t, field.method.errorOutNr)
 
-	field, ok = obj.objContents[getObjKey([]byte("id"))]
+	field, ok = obj.objContents.getByName("id")
 	a.True(t, ok)
 	a.True(t, field.isID)
 	a.Nil(t, field.method.errorOutNr)
@@ -228,7 +228,7 @@ func TestCheckStructFuncs(t *testing.T) {
 	a.Nil(t, err)
 	obj := ctx.schema.types[ref.typeName]
 
-	_, ok := obj.objContents[getObjKey([]byte("name"))]
+	_, ok := obj.objContents.getByName("name")
 	a.True(t, ok)
 }
 
@@ -258,3 +258,77 @@ func TestReferenceLoop3(t *testing.T) {
 	_, err := newParseCtx().check(reflect.TypeOf(ReferToSelf3{}), false)
 	a.Nil(t, err)
 }
+
+// The names an0zh and aBAja have the same key
+type TestObjFieldsCollisionData struct {
+	First  string `gq:"an0zh"`
+	Second string `gq:"aBAja"`
+}
+
+type TestObjFieldsCollisionSingle struct {
+	First string `gq:"an0zh"`
+}
+
+func TestObjFieldsCollision(t *testing.T) {
+	a.Equal(t, getObjKey([]byte("an0zh")), getObjKey([]byte("aBAja")))
+
+	ctx := newParseCtx()
+	ref, err := ctx.check(reflect.TypeOf(TestObjFieldsCollisionData{}), false)
+	a.Nil(t, err)
+	obj := ctx.schema.types[ref.typeName]
+	a.Equal(t, 2, len(obj.objContents.all()))
+
+	field, ok := obj.objContents.getByName("an0zh")
+	a.True(t, ok)
+	a.Equal(t, "First", field.goFieldName)
+	field, ok = obj.objContents.getByName("aBAja")
+	a.True(t, ok)
+	a.Equal(t, "Second", field.goFieldName)
+
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestObjFieldsCollisionData{First: "first", Second: "second"}, M{}, nil))
+	errs := s.Resolve([]byte(`{an0zh aBAja}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"an0zh":"first","aBAja":"second"}`, string(s.Result))
+
+	// A field that is not defined must not resolve the field with the same key
+	s = NewSchema()
+	a.NoError(t, s.Parse(TestObjFieldsCollisionSingle{First: "first"}, M{}, nil))
+	errs = s.Resolve([]byte(`{aBAja}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "aBAja does not exists on TestObjFieldsCollisionSingle", errs[0].Error())
+}
+
+type TestLinkRefsData struct {
+	Users  []TestLinkRefsUser
+	Friend func() *TestLinkRefsUser
+}
+
+type TestLinkRefsUser struct {
+	Name   string
+	Parent *TestLinkRefsUser
+}
+
+func TestLinkRefs(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestLinkRefsData{}, M{}, nil))
+
+	checkLinked := func(s *Schema) {
+		user := s.types["TestLinkRefsUser"]
+		users, _ := s.rootQuery.objContents.getByName("users")
+		a.Equal(t, valueTypeObjRef, users.innerContent.valueType)
+		a.True(t, users.innerContent.ref == user)
+
+		friend, _ := s.rootQuery.objContents.getByName("friend")
+		a.True(t, friend.method.outType.innerContent.ref == user)
+
+		parent, _ := user.objContents.getByName("parent")
+		a.True(t, parent.innerContent.ref == user)
+	}
+	checkLinked(s)
+
+	// The refs of a copy point to the types of the copy
+	copied := s.Copy()
+	checkLinked(copied)
+	a.False(t, copied.types["TestLinkRefsUser"] == s.types["TestLinkRefsUser"])
+}
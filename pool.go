@@ -0,0 +1,62 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// newSchemaPool returns a pool of copies of s used by (*yarql.Schema).Exec
+func newSchemaPool(s *Schema) *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			return s.Copy()
+		},
+	}
+}
+
+// Exec resolves a query equal to (*yarql.Schema).Resolve but is safe for concurrent use
+// The query is resolved using a copy of the schema borrowed from a internal pool, so the returned result and errors are copied
+// The copies are created lazily so changes to the schema after the first call to Exec might not apply to all copies
+// After (*yarql.Schema).Reload the queries are resolved using copies of the reloaded schema
+func (s *Schema) Exec(query []byte, opts ResolveOptions) ([]byte, []error) {
+	if !s.parsed {
+		return nil, []error{errors.New("(*yarql.Schema).Exec() cannot be ran before (*yarql.Schema).Parse()")}
+	}
+
+	pool := s.Current().pool
+	c := pool.Get().(*Schema)
+	errs := c.Resolve(query, opts)
+	result := append([]byte(nil), c.Result...)
+	if len(errs) > 0 {
+		errs = append([]error(nil), errs...)
+	} else {
+		errs = nil
+	}
+	c.release()
+	pool.Put(c)
+
+	return result, errs
+}
+
+// release clears the request specific values of a pooled schema so they can be garbage collected
+// Large result buffers are shrunk to not keep them in memory forever
+func (s *Schema) release() {
+	if s.MaxRetainedResultSize > 0 && cap(s.Result) > s.MaxRetainedResultSize {
+		s.Result = make([]byte, 0, s.InitialResultSize)
+	} else {
+		s.Result = s.Result[:0]
+	}
+
+	ctx := s.ctx
+	ctx.context = nil
+	ctx.header = nil
+	ctx.values = nil
+	ctx.getFormFile = nil
+	ctx.loaders = nil
+	ctx.batchLoaders = nil
+	ctx.reflectValues = [256]reflect.Value{}
+	for i := range ctx.funcInputs {
+		ctx.funcInputs[i] = reflect.Value{}
+	}
+}
@@ -0,0 +1,85 @@
+package yarql
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+func TestExec(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	res, errs := s.Exec([]byte(`{double(n: 1)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"double":2}`, string(res))
+
+	// The result is not overwritten by the next request
+	_, errs = s.Exec([]byte(`{double(n: 2)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"double":2}`, string(res))
+
+	_, errs = s.Exec([]byte(`{unknown}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+
+	_, errs = NewSchema().Exec([]byte(`{double(n: 1)}`), ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+}
+
+func TestExecConcurrent(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	var wg sync.WaitGroup
+	results := make([]string, 50)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, errs := s.Exec([]byte(`{double(n: `+strconv.Itoa(i)+`)}`), ResolveOptions{NoMeta: true})
+			if len(errs) == 0 {
+				results[i] = string(res)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		a.Equal(t, `{"double":`+strconv.Itoa(i*2)+`}`, result)
+	}
+}
+
+func TestExecConcurrentTracing(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	var wg sync.WaitGroup
+	resolvers := make([]int, 20)
+	for i := range resolvers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, errs := s.Exec([]byte(`{double(n: `+strconv.Itoa(i)+`)}`), ResolveOptions{Tracing: true})
+			if len(errs) > 0 {
+				return
+			}
+			var out struct {
+				Extensions struct {
+					Tracing tracer `json:"tracing"`
+				} `json:"extensions"`
+			}
+			if json.Unmarshal(res, &out) == nil {
+				resolvers[i] = len(out.Extensions.Tracing.Execution.Resolvers)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Every copy of the schema has its own tracer so the traces of requests do not end up in each other
+	for _, count := range resolvers {
+		a.Equal(t, 1, count)
+	}
+}
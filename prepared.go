@@ -0,0 +1,226 @@
+package yarql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// PreparedQuery is a parsed operation that can be executed many times using (*yarql.Schema).ExecutePrepared
+// A PreparedQuery is immutable and can be shared between goroutines and copies of the schema it was compiled with
+type PreparedQuery struct {
+	query             []byte
+	operationName     string
+	res               []byte
+	fragmentLocations []int
+	fieldLocations    []bytecode.FieldLocation
+	targetIdx         int
+}
+
+// Query returns the query the PreparedQuery was compiled from
+func (p *PreparedQuery) Query() string {
+	return string(p.query)
+}
+
+// OperationName returns the name of the operation that is executed, empty for anonymous operations
+func (p *PreparedQuery) OperationName() string {
+	return p.operationName
+}
+
+// Compile parses query once so it can be executed many times without parsing it again
+// If the query contains multiple operations the last one is executed, use (*yarql.Schema).CompileOperation to select a operation
+func (s *Schema) Compile(query string) (*PreparedQuery, []error) {
+	return s.CompileOperation(query, "")
+}
+
+// CompileOperation is equal to (*yarql.Schema).Compile but compiles the operation with operationName
+func (s *Schema) CompileOperation(query string, operationName string) (*PreparedQuery, []error) {
+	if !s.parsed {
+		return nil, []error{errors.New("(*yarql.Schema).Compile() cannot be ran before (*yarql.Schema).Parse()")}
+	}
+
+	parser := s.newParser(query)
+	if len(operationName) > 0 {
+		parser.ParseQueryToBytecode(&operationName)
+	} else {
+		parser.ParseQueryToBytecode(nil)
+	}
+	if len(parser.Errors) > 0 {
+		return nil, parser.Errors
+	}
+	if parser.TargetIdx == -1 {
+		if len(operationName) > 0 {
+			return nil, []error{errors.New("no operator with name " + operationName + " found")}
+		}
+		return nil, []error{errors.New("no operator found")}
+	}
+
+	// The name of the operation is located after the operation instruction, kind, arguments flag and directives count
+	name := parser.Res[parser.TargetIdx+5:]
+	return &PreparedQuery{
+		query:             parser.Query,
+		operationName:     string(name[:bytes.IndexByte(name, 0)]),
+		res:               parser.Res,
+		fragmentLocations: parser.FragmentLocations,
+		fieldLocations:    parser.FieldLocations,
+		targetIdx:         parser.TargetIdx,
+	}, nil
+}
+
+// preparedQueryMagic is the start of a serialized PreparedQuery
+var preparedQueryMagic = []byte("YQPQ")
+
+// MarshalBinary serializes the PreparedQuery so it can be stored on disk or in a cache like Redis and loaded back using (*yarql.PreparedQuery).UnmarshalBinary without parsing the query again
+// The data contains the bytecode version, loading data compiled by a version of yarql with a different bytecode format fails
+func (p *PreparedQuery) MarshalBinary() ([]byte, error) {
+	res := append([]byte{}, preparedQueryMagic...)
+	res = appendUvarint(res, bytecode.Version)
+	res = appendPreparedBytes(res, p.query)
+	res = appendPreparedBytes(res, []byte(p.operationName))
+	res = appendPreparedBytes(res, p.res)
+	res = appendUvarint(res, uint64(p.targetIdx))
+	res = appendUvarint(res, uint64(len(p.fragmentLocations)))
+	for _, location := range p.fragmentLocations {
+		res = appendUvarint(res, uint64(location))
+	}
+	res = appendUvarint(res, uint64(len(p.fieldLocations)))
+	for _, location := range p.fieldLocations {
+		res = appendUvarint(res, uint64(location.ResIdx))
+		res = appendUvarint(res, uint64(location.QueryIdx))
+	}
+	return res, nil
+}
+
+// appendUvarint is equal to binary.AppendUvarint that is not available in go 1.18
+func appendUvarint(res []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], value)
+	return append(res, buf[:n]...)
+}
+
+func appendPreparedBytes(res []byte, value []byte) []byte {
+	res = appendUvarint(res, uint64(len(value)))
+	return append(res, value...)
+}
+
+// UnmarshalBinary loads a PreparedQuery serialized by (*yarql.PreparedQuery).MarshalBinary
+//
+// Example:
+//
+//	query := &yarql.PreparedQuery{}
+//	err := query.UnmarshalBinary(data)
+func (p *PreparedQuery) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, preparedQueryMagic) {
+		return errors.New("data is not a serialized prepared query")
+	}
+	r := preparedReader{data: data[len(preparedQueryMagic):]}
+
+	version := r.uint()
+	if r.err == nil && version != bytecode.Version {
+		return errors.New("prepared query has bytecode version " + strconv.FormatUint(version, 10) + " but version " + strconv.Itoa(bytecode.Version) + " is required, compile the query again")
+	}
+
+	res := PreparedQuery{
+		query:         r.bytes(),
+		operationName: string(r.bytes()),
+		res:           r.bytes(),
+		targetIdx:     r.int(),
+	}
+	res.fragmentLocations = make([]int, r.length())
+	for i := range res.fragmentLocations {
+		res.fragmentLocations[i] = r.int()
+	}
+	res.fieldLocations = make([]bytecode.FieldLocation, r.length())
+	for i := range res.fieldLocations {
+		res.fieldLocations[i] = bytecode.FieldLocation{ResIdx: r.int(), QueryIdx: r.int()}
+	}
+
+	if r.err == nil && len(r.data) != 0 {
+		r.err = errors.New("unexpected data after the prepared query")
+	}
+	if r.err == nil && (res.targetIdx+1 >= len(res.res) || res.res[res.targetIdx+1] != bytecode.ActionOperator) {
+		r.err = errors.New("prepared query does not point to an operation")
+	}
+	if r.err != nil {
+		return errors.New("invalid prepared query: " + r.err.Error())
+	}
+
+	*p = res
+	return nil
+}
+
+// preparedReader reads the values of a serialized PreparedQuery, after an error all reads return zero values
+type preparedReader struct {
+	data []byte
+	err  error
+}
+
+func (r *preparedReader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.New("unexpected end of data")
+		return 0
+	}
+	r.data = r.data[n:]
+	return value
+}
+
+func (r *preparedReader) int() int {
+	value := r.uint()
+	if value > math.MaxInt32 {
+		r.err = errors.New("value out of range")
+		return 0
+	}
+	return int(value)
+}
+
+// length reads the length of a list, the length cannot be more than the remaining bytes as every item takes at least one byte
+func (r *preparedReader) length() int {
+	value := r.uint()
+	if value > uint64(len(r.data)) {
+		r.err = errors.New("unexpected end of data")
+		return 0
+	}
+	return int(value)
+}
+
+func (r *preparedReader) bytes() []byte {
+	length := r.length()
+	if r.err != nil {
+		return nil
+	}
+	value := append([]byte{}, r.data[:length]...)
+	r.data = r.data[length:]
+	return value
+}
+
+// ExecutePrepared executes a PreparedQuery, it behaves equal to (*yarql.Schema).Resolve without parsing the query
+// The OperatorTarget option is ignored as the operation is selected while compiling the query
+// Extensions are called like they are for (*yarql.Schema).Resolve, the query returned by their ParseStart hook is ignored
+func (s *Schema) ExecutePrepared(query *PreparedQuery, opts ResolveOptions) []error {
+	if query == nil {
+		s.Result = s.Result[:0]
+		return []error{errors.New("prepared query cannot be nil")}
+	}
+	return s.resolve(query.query, query, opts)
+}
+
+// newParser returns a parser for query with the limits of the schema that shares the query cache of the schema
+func (s *Schema) newParser(query string) *bytecode.ParserCtx {
+	parser := bytecode.NewParserCtx()
+	parser.Cache = s.ctx.query.Cache
+	parser.CacheableQueryMinLen = s.ctx.query.CacheableQueryMinLen
+	parser.MaxAliases = s.MaxAliases
+	parser.MaxRootFields = s.MaxRootFields
+	parser.MaxQueryLength = s.MaxQueryLength
+	parser.MaxTokens = s.MaxTokens
+	parser.Query = append(parser.Query[:0], query...)
+	return parser
+}
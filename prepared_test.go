@@ -0,0 +1,149 @@
+package yarql
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+type TestPreparedQueryData struct{}
+
+func (TestPreparedQueryData) ResolveDouble(args struct{ N int }) int {
+	return args.N * 2
+}
+
+func TestPreparedQuery(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	query, errs := s.Compile(`query Double($n: Int) {double(n: $n)}`)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, "Double", query.OperationName())
+	a.Equal(t, `query Double($n: Int) {double(n: $n)}`, query.Query())
+
+	for i := 0; i < 3; i++ {
+		errs = s.ExecutePrepared(query, ResolveOptions{NoMeta: true, Variables: `{"n": ` + strconv.Itoa(i) + `}`})
+		a.Equal(t, 0, len(errs))
+		a.Equal(t, `{"double":`+strconv.Itoa(i*2)+`}`, string(s.Result))
+	}
+
+	// A normal query in between does not affect the prepared query
+	errs = s.Resolve([]byte(`{a: double(n: 10)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	errs = s.ExecutePrepared(query, ResolveOptions{NoMeta: true, Variables: `{"n": 4}`})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"double":8}`, string(s.Result))
+}
+
+func TestPreparedQueryConcurrent(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	query, errs := s.Compile(`query($n: Int) {double(n: $n)}`)
+	a.Equal(t, 0, len(errs))
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int, s *Schema) {
+			defer wg.Done()
+			errs := s.ExecutePrepared(query, ResolveOptions{NoMeta: true, Variables: `{"n": ` + strconv.Itoa(i) + `}`})
+			if len(errs) == 0 {
+				results[i] = string(s.Result)
+			}
+		}(i, s.Copy())
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		a.Equal(t, `{"double":`+strconv.Itoa(i*2)+`}`, result)
+	}
+}
+
+func TestCompileOperation(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	query, errs := s.CompileOperation(`query A {double(n: 1)} query B {double(n: 2)}`, "A")
+	a.Equal(t, 0, len(errs))
+	errs = s.ExecutePrepared(query, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"double":2}`, string(s.Result))
+
+	_, errs = s.CompileOperation(`query A {double(n: 1)}`, "C")
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "no operator with name C found", errs[0].Error())
+
+	_, errs = s.Compile(`{double(n: 1)`)
+	a.Equal(t, 1, len(errs))
+
+	errs = s.ExecutePrepared(nil, ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+}
+
+func TestPreparedQueryExtensions(t *testing.T) {
+	extension := &testRecordingExtension{}
+	s := NewSchema()
+	a.NoError(t, s.RegisterExtension(extension))
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	query, errs := s.Compile(`{double(n: 1)}`)
+	a.Equal(t, 0, len(errs))
+	errs = s.ExecutePrepared(query, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, []string{
+		"parseStart {double(n: 1)}",
+		"parseEnd 0",
+		"validate 1",
+		"executionStart",
+		"executionEnd",
+		`responseEnd {"double":2}`,
+	}, extension.calls)
+}
+
+func TestPreparedQueryMarshalBinary(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestPreparedQueryData{}, M{}, nil))
+
+	compiled, errs := s.CompileOperation(`query Double($n: Int) {...F} query Other {double(n: 1)} fragment F on TestPreparedQueryData {double(n: $n)}`, "Double")
+	a.Equal(t, 0, len(errs))
+	data, err := compiled.MarshalBinary()
+	a.NoError(t, err)
+
+	query := &PreparedQuery{}
+	a.NoError(t, query.UnmarshalBinary(data))
+	a.Equal(t, compiled.Query(), query.Query())
+	a.Equal(t, "Double", query.OperationName())
+
+	errs = s.ExecutePrepared(query, ResolveOptions{NoMeta: true, Variables: `{"n": 3}`})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"double":6}`, string(s.Result))
+
+	// Invalid data is rejected
+	a.Error(t, query.UnmarshalBinary([]byte("abc")))
+	a.Error(t, query.UnmarshalBinary(data[:len(data)-1]))
+	a.Error(t, query.UnmarshalBinary(append(data, 0)))
+
+	// Data of a different bytecode version is rejected
+	otherVersion := append([]byte{}, data...)
+	otherVersion[len(preparedQueryMagic)] = bytecode.Version + 1
+	err = query.UnmarshalBinary(otherVersion)
+	a.Error(t, err)
+	a.True(t, strings.Contains(err.Error(), "compile the query again"))
+
+	// Data of version 1 has operator arguments without directives
+	otherVersion[len(preparedQueryMagic)] = 1
+	err = query.UnmarshalBinary(otherVersion)
+	a.Error(t, err)
+	a.True(t, strings.Contains(err.Error(), "bytecode version 1"))
+
+	// A failed unmarshal does not modify the query
+	errs = s.ExecutePrepared(query, ResolveOptions{NoMeta: true, Variables: `{"n": 4}`})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"double":8}`, string(s.Result))
+}
@@ -0,0 +1,24 @@
+package yarql
+
+import "github.com/mjarkk/yarql/bytecode/cache"
+
+// QueryCacheStats contains statistics about the cache of parsed queries
+type QueryCacheStats = cache.Stats
+
+// SetQueryCacheLimits sets the max amount of entries and the max size in bytes of the cache of parsed queries, 0 = no limit
+// The least recently used queries are removed first, by default the cache contains at most 1000 queries
+// Only queries longer than the length set using (*yarql.Schema).SetCacheRules are cached
+// The cache is shared between the schema and its copies
+func (s *Schema) SetQueryCacheLimits(maxEntries, maxBytes int) {
+	s.ctx.query.Cache.SetLimits(maxEntries, maxBytes)
+}
+
+// QueryCacheStats returns the statistics of the cache of parsed queries
+func (s *Schema) QueryCacheStats() QueryCacheStats {
+	return s.ctx.query.Cache.Stats()
+}
+
+// PurgeQueryCache removes all queries from the cache of parsed queries
+func (s *Schema) PurgeQueryCache() {
+	s.ctx.query.Cache.Purge()
+}
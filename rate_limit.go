@@ -0,0 +1,81 @@
+package yarql
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mjarkk/yarql/ast"
+)
+
+// OperationInfo contains information about the operation that is about to be executed, see (*yarql.Schema).SetRateLimiter
+type OperationInfo struct {
+	OperationName string                 // Empty for anonymous operations
+	OperationType string                 // query, mutation or subscription
+	Fingerprint   string                 // Hash of the normalized operation, equal for queries that only differ in formatting and literal values, see ast.Fingerprint
+	Complexity    int                    // The complexity of the operation, see (*yarql.Schema).RegisterFieldCost
+	Values        map[string]interface{} // The values of the Ctx, see (*yarql.Ctx).GetValue, nil if no values are set
+}
+
+// RateLimiter decides if an operation can be executed, returning an error rejects the operation
+// Return a *RateLimitError to tell the client when it can retry the operation
+type RateLimiter func(ctx *Ctx, info OperationInfo) error
+
+// RateLimitError rejects an operation because a rate limit is exceeded
+// The error is added to the response with a RATE_LIMITED code and the amount of seconds after which the operation can be retried in its extensions
+type RateLimitError struct {
+	Message    string        // The error message, defaults to "rate limit exceeded"
+	RetryAfter time.Duration // The time after which the operation can be retried, 0 = unknown
+}
+
+func (e *RateLimitError) Error() string {
+	if len(e.Message) == 0 {
+		return "rate limit exceeded"
+	}
+	return e.Message
+}
+
+// SetRateLimiter sets a function that is called before every operation is executed, after the query is parsed and the Validate hook of the extensions is called
+// This can be used to enforce quotas per operation, client or user
+//
+// Example:
+//
+//	s.SetRateLimiter(func(ctx *yarql.Ctx, info yarql.OperationInfo) error {
+//		if !limiter.Allow(info.Values["userID"], info.Complexity) {
+//			return &yarql.RateLimitError{RetryAfter: time.Minute}
+//		}
+//		return nil
+//	})
+func (s *Schema) SetRateLimiter(limiter RateLimiter) {
+	s.rateLimiter = limiter
+}
+
+// checkRateLimit calls the rate limiter with the operation at the TargetIdx and reports the error if the operation is rejected
+func (ctx *Ctx) checkRateLimit() bool {
+	operationType, operationName, ok := ctx.operation()
+	if !ok {
+		return false
+	}
+
+	info := OperationInfo{
+		OperationName: operationName,
+		OperationType: operationType,
+		Fingerprint:   ast.Fingerprint(ctx.Document(), operationName),
+		Complexity:    ctx.operationComplexity(),
+	}
+	if ctx.values != nil {
+		info.Values = *ctx.values
+	}
+
+	err := ctx.schema.rateLimiter(ctx, info)
+	if err == nil {
+		return false
+	}
+
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		// Keep the error as is so the extensions can be added to the response
+		ctx.query.Errors = append(ctx.query.Errors, err)
+		return true
+	}
+	return ctx.err(err.Error())
+}
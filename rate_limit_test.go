@@ -0,0 +1,68 @@
+package yarql
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestRateLimitData struct {
+	A string
+	B string
+}
+
+func TestRateLimiter(t *testing.T) {
+	var infos []OperationInfo
+	s := NewSchema()
+	s.SetRateLimiter(func(ctx *Ctx, info OperationInfo) error {
+		infos = append(infos, info)
+		switch info.Values["user"] {
+		case "limited":
+			return &RateLimitError{RetryAfter: 1500 * time.Millisecond}
+		case "blocked":
+			return errors.New("blocked")
+		}
+		return nil
+	})
+	a.NoError(t, s.Parse(TestRateLimitData{A: "a", B: "b"}, M{}, nil))
+	s = s.Copy()
+
+	errs := s.Resolve([]byte(`query Foo {a b}`), ResolveOptions{Values: &map[string]interface{}{"user": "ok"}})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":{"a":"a","b":"b"}}`, string(s.Result))
+	a.Equal(t, 1, len(infos))
+	a.Equal(t, "Foo", infos[0].OperationName)
+	a.Equal(t, "query", infos[0].OperationType)
+	a.Equal(t, 2, infos[0].Complexity)
+	a.Equal(t, "ok", infos[0].Values["user"])
+	a.Equal(t, 64, len(infos[0].Fingerprint))
+
+	// Queries that only differ in formatting have the same fingerprint
+	errs = s.Resolve([]byte("query Foo {\n\ta\n\tb\n}"), ResolveOptions{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, 2, len(infos))
+	a.Equal(t, infos[0].Fingerprint, infos[1].Fingerprint)
+	a.Nil(t, infos[1].Values)
+
+	errs = s.Resolve([]byte(`{a}`), ResolveOptions{Values: &map[string]interface{}{"user": "limited"}})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "rate limit exceeded", errs[0].Error())
+	a.Equal(t, `{"data":{},"errors":[{"message":"rate limit exceeded","extensions":{"code":"RATE_LIMITED","retryAfter":2}}],"extensions":{}}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{a}`), ResolveOptions{Values: &map[string]interface{}{"user": "blocked"}})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"data":{},"errors":[{"message":"blocked"}],"extensions":{}}`, string(s.Result))
+
+	// The precomputed introspection result is limited as well
+	errs = s.Resolve([]byte(IntrospectionQuery), ResolveOptions{Values: &map[string]interface{}{"user": "limited"}})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "IntrospectionQuery", infos[len(infos)-1].OperationName)
+
+	// Queries that cannot be parsed are rejected before calling the rate limiter
+	infos = nil
+	errs = s.Resolve([]byte(`{a`), ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, 0, len(infos))
+}
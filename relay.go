@@ -0,0 +1,196 @@
+package yarql
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Node is the relay Node interface used by the EnableRelay schema option
+// See: https://relay.dev/graphql/objectidentification.htm
+// Types implementing Node should return a global ID created using ToGlobalID
+//
+// Example:
+//
+//	func (u User) ResolveId() (string, yarql.AttrIsID) {
+//		return yarql.ToGlobalID("User", strconv.Itoa(u.ID)), 0
+//	}
+type Node interface {
+	ResolveId() (string, AttrIsID)
+}
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// NodeFetcher returns the node matching the local id, the returned value must be of the registered type or a pointer to it
+type NodeFetcher func(ctx *Ctx, id string) (interface{}, error)
+
+// nodeFetcher is a NodeFetcher registered on a type
+type nodeFetcher struct {
+	goType   reflect.Type
+	typeName string
+	fetch    NodeFetcher
+}
+
+// ToGlobalID creates a relay global ID from a type name and the local id of the type
+func ToGlobalID(typeName string, id string) string {
+	return base64.StdEncoding.EncodeToString([]byte(typeName + ":" + id))
+}
+
+// FromGlobalID returns the type name and local id of a global ID created by ToGlobalID
+func FromGlobalID(globalID string) (typeName string, id string, err error) {
+	decoded, err := base64.StdEncoding.DecodeString(globalID)
+	if err != nil {
+		return "", "", errors.New("invalid global id")
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return "", "", errors.New("invalid global id")
+	}
+	return parts[0], parts[1], nil
+}
+
+// RegisterNodeFetcher registers fetch as fetcher of goType for the root node(id: ID!) field
+// goType must implement the Node interface, there is no need to call Implements for it as it's added to the Node interface of this schema
+// Node fetchers are only used if the EnableRelay schema option is set
+//
+// Example:
+//
+//	s.RegisterNodeFetcher(User{}, func(ctx *yarql.Ctx, id string) (interface{}, error) {
+//		return db.GetUser(id)
+//	})
+func (s *Schema) RegisterNodeFetcher(goType interface{}, fetch NodeFetcher) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterNodeFetcher() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+	if fetch == nil {
+		return errors.New("fetch cannot be nil")
+	}
+
+	t := reflect.TypeOf(goType)
+	if t.Kind() != reflect.Struct {
+		return errors.New("can only register node fetchers on struct types")
+	}
+	if t.Name() == "" || t.PkgPath() == "" {
+		return errors.New("cannot register node fetchers on an inline type")
+	}
+	if !t.Implements(nodeType) {
+		return errors.New(t.Name() + " does not implement the yarql.Node interface")
+	}
+
+	s.nodeFetchers[t] = &nodeFetcher{
+		goType: t,
+		fetch:  fetch,
+	}
+	return nil
+}
+
+// addRelayResolvers adds the node and nodes fields to the query root
+func (s *Schema) addRelayResolvers() error {
+	err := s.addRootResolver(false, "node", func(ctx *Ctx, args struct {
+		ID string `gq:"id,ID,required"`
+	}) (Node, error) {
+		return ctx.fetchNode(args.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.addRootResolver(false, "nodes", func(ctx *Ctx, args struct {
+		IDs []string `gq:"ids,ID,required"`
+	}) ([]Node, error) {
+		// A node that cannot be fetched is null with an error at its index so the other nodes are still returned
+		nodes := make([]Node, len(args.IDs))
+		for idx, id := range args.IDs {
+			node, err := ctx.fetchNode(id)
+			if err != nil {
+				ctx.AddError(err, idx)
+				continue
+			}
+			nodes[idx] = node
+		}
+		return nodes, nil
+	})
+}
+
+// schemaImplementations returns the types that implement the interface t within this schema next to the types registered using Implements
+// The types with a node fetcher implement the Node interface
+func (c *parseCtx) schemaImplementations(t reflect.Type, registered []reflect.Type) []reflect.Type {
+	if t != nodeType {
+		return registered
+	}
+
+	fetcherTypes := []reflect.Type{}
+	for goType := range c.schema.nodeFetchers {
+		if !containsType(registered, goType) {
+			fetcherTypes = append(fetcherTypes, goType)
+		}
+	}
+	sort.Slice(fetcherTypes, func(a, b int) bool { return fetcherTypes[a].Name() < fetcherTypes[b].Name() })
+	return append(append([]reflect.Type{}, registered...), fetcherTypes...)
+}
+
+// schemaInterfaces returns the interfaces the struct t implements within this schema next to the interfaces registered using Implements
+func (c *parseCtx) schemaInterfaces(t reflect.Type, registered []reflect.Type) []reflect.Type {
+	if _, ok := c.schema.nodeFetchers[t]; !ok || containsType(registered, nodeType) {
+		return registered
+	}
+	return append(append([]reflect.Type{}, registered...), nodeType)
+}
+
+func containsType(list []reflect.Type, t reflect.Type) bool {
+	for _, item := range list {
+		if item == t {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNodeFetchers resolves the graphql type names of the types with a node fetcher
+func (c *parseCtx) checkNodeFetchers() error {
+	for _, fetcher := range c.schema.nodeFetchers {
+		typeObj, err := c.check(fetcher.goType, false)
+		if err != nil {
+			return err
+		}
+		fetcher.typeName = typeObj.typeName
+		c.schema.nodesByName[fetcher.typeName] = fetcher
+	}
+	return nil
+}
+
+// fetchNode returns the node of a global id
+func (ctx *Ctx) fetchNode(globalID string) (Node, error) {
+	typeName, id, err := FromGlobalID(globalID)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher, ok := ctx.schema.nodesByName[typeName]
+	if !ok {
+		return nil, errors.New("unknown node type " + typeName)
+	}
+
+	value, err := fetcher.fetch(ctx, id)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	goValue := reflect.ValueOf(value)
+	if goValue.Kind() == reflect.Ptr {
+		if goValue.IsNil() {
+			return nil, nil
+		}
+		goValue = goValue.Elem()
+	}
+	if goValue.Type() != fetcher.goType {
+		return nil, errors.New("node fetcher of " + typeName + " returned " + goValue.Type().String() + " instead of " + fetcher.goType.String())
+	}
+
+	return goValue.Interface().(Node), nil
+}
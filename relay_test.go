@@ -0,0 +1,143 @@
+package yarql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestRelayQ struct{}
+
+type TestRelayUser struct {
+	LocalID string `gq:"-"`
+	Name    string
+}
+
+func (u TestRelayUser) ResolveId() (string, AttrIsID) {
+	return ToGlobalID("TestRelayUser", u.LocalID), 0
+}
+
+type TestRelayPost struct {
+	LocalID string `gq:"-"`
+	Title   string
+}
+
+func (p TestRelayPost) ResolveId() (string, AttrIsID) {
+	return ToGlobalID("TestRelayPost", p.LocalID), 0
+}
+
+func registerTestNodeFetchers(t *testing.T, s *Schema) {
+	a.NoError(t, s.RegisterNodeFetcher(TestRelayUser{}, func(ctx *Ctx, id string) (interface{}, error) {
+		if id == "404" {
+			return nil, nil
+		}
+		return &TestRelayUser{LocalID: id, Name: "user " + id}, nil
+	}))
+	a.NoError(t, s.RegisterNodeFetcher(TestRelayPost{}, func(ctx *Ctx, id string) (interface{}, error) {
+		if id == "fail" {
+			return nil, errors.New("post not found")
+		}
+		return TestRelayPost{LocalID: id, Title: "post " + id}, nil
+	}))
+}
+
+func TestGlobalID(t *testing.T) {
+	globalID := ToGlobalID("User", "1:2")
+	typeName, id, err := FromGlobalID(globalID)
+	a.NoError(t, err)
+	a.Equal(t, "User", typeName)
+	a.Equal(t, "1:2", id)
+
+	_, _, err = FromGlobalID("not base64!")
+	a.Error(t, err)
+	_, _, err = FromGlobalID(ToGlobalID("", "1"))
+	a.Error(t, err)
+}
+
+func TestRelayNode(t *testing.T) {
+	s := NewSchema()
+	registerTestNodeFetchers(t, s)
+
+	query := `query ($id: ID!) {
+		user: node(id: $id) {
+			id
+			... on TestRelayUser { name }
+		}
+		post: node(id: "` + ToGlobalID("TestRelayPost", "2") + `") {
+			... on TestRelayPost { title }
+		}
+		missing: node(id: "` + ToGlobalID("TestRelayUser", "404") + `") { id }
+		nodes(ids: ["` + ToGlobalID("TestRelayUser", "3") + `"]) { id }
+	}`
+	res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{EnableRelay: true}, query, TestRelayQ{}, M{}, ResolveOptions{
+		NoMeta:    true,
+		Variables: `{"id": "` + ToGlobalID("TestRelayUser", "1") + `"}`,
+	})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"user":{"id":"`+ToGlobalID("TestRelayUser", "1")+`","name":"user 1"},"post":{"title":"post 2"},"missing":null,"nodes":[{"id":"`+ToGlobalID("TestRelayUser", "3")+`"}]}`, res)
+
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "node(id: ID!): Node"), sdl)
+	a.True(t, strings.Contains(sdl, "type TestRelayUser implements Node"), sdl)
+}
+
+func TestRelayNodeErrors(t *testing.T) {
+	for _, id := range []string{"invalid", ToGlobalID("Unknown", "1"), ToGlobalID("TestRelayPost", "fail")} {
+		s := NewSchema()
+		registerTestNodeFetchers(t, s)
+		res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{EnableRelay: true}, `{node(id: "`+id+`") {id}}`, TestRelayQ{}, M{})
+		a.Equal(t, 1, len(errs), id)
+		a.Equal(t, `{"node":null}`, res)
+	}
+}
+
+func TestRelayNodesErrors(t *testing.T) {
+	s := NewSchema()
+	registerTestNodeFetchers(t, s)
+
+	query := `{nodes(ids: ["` + ToGlobalID("TestRelayUser", "1") + `", "` + ToGlobalID("TestRelayPost", "fail") + `"]) {id}}`
+	res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{EnableRelay: true}, query, TestRelayQ{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "post not found", errs[0].Error())
+	a.Equal(t, `["nodes",1]`, string(errs[0].(ErrorWPath).Path()))
+	a.Equal(t, `{"nodes":[{"id":"`+ToGlobalID("TestRelayUser", "1")+`"},null]}`, res)
+}
+
+func TestRelayNodeImplementationsPerSchema(t *testing.T) {
+	s := NewSchema()
+	registerTestNodeFetchers(t, s)
+	a.NoError(t, s.Parse(TestRelayQ{}, M{}, &SchemaOptions{EnableRelay: true}))
+
+	// The node fetchers only add implementations to the Node interface of their own schema
+	_, ok := implementationMap["Node"]
+	a.False(t, ok)
+	a.Equal(t, 0, len(structImplementsMap["TestRelayUser"]))
+
+	s = NewSchema()
+	a.NoError(t, s.RegisterNodeFetcher(TestRelayUser{}, func(ctx *Ctx, id string) (interface{}, error) { return nil, nil }))
+	a.NoError(t, s.Parse(TestRelayQ{}, M{}, &SchemaOptions{EnableRelay: true}))
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "type TestRelayUser implements Node"), sdl)
+	a.False(t, strings.Contains(sdl, "TestRelayPost"), sdl)
+}
+
+func TestRelayDisabled(t *testing.T) {
+	s := NewSchema()
+	_, errs := bytecodeParse(t, s, `{node(id: "abc") {id}}`, TestRelayQ{}, M{})
+	a.Equal(t, 1, len(errs))
+}
+
+func TestRegisterNodeFetcherInvalid(t *testing.T) {
+	s := NewSchema()
+	fetch := func(ctx *Ctx, id string) (interface{}, error) { return nil, nil }
+
+	a.Error(t, s.RegisterNodeFetcher(nil, fetch))
+	a.Error(t, s.RegisterNodeFetcher(TestRelayUser{}, nil))
+	a.Error(t, s.RegisterNodeFetcher("", fetch))
+	a.Error(t, s.RegisterNodeFetcher(TestRelayQ{}, fetch))
+	a.NoError(t, s.RegisterNodeFetcher(TestRelayUser{}, fetch))
+}
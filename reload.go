@@ -0,0 +1,174 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// reloadState contains the schema swapped in by (*Schema).Reload
+type reloadState struct {
+	lock sync.Mutex
+	// current is the *Schema used for new requests
+	current atomic.Value
+	// registered contains the registrations of the schema before it was parsed, see (*Schema).registrations
+	registered *Schema
+	// the arguments of the last Parse or Reload, used to reload the schema after registering root resolvers
+	queries interface{}
+	methods interface{}
+	options *SchemaOptions
+}
+
+// Reload parses queries and methods into a new schema and swaps it in for the requests handled by Exec, ServeHTTPRequest and HTTPHandler
+// Requests that are being resolved finish using the previous schema, the registrations made before Parse like enums, directives and resolvers are reused
+// The settings like MaxDepth are taken from s at the moment Reload is called
+//
+// Reload is safe for concurrent use, use (*Schema).Current to get the reloaded schema for the SDL or introspection
+// Resolve and views created using (*Schema).View keep using the schema they were created from
+//
+// Example:
+//
+//	err := s.Reload(QueryRoot{Config: newConfig}, MethodRoot{}, nil)
+func (s *Schema) Reload(queries interface{}, methods interface{}, options *SchemaOptions) error {
+	if !s.parsed || s.reload == nil {
+		return errors.New("(*yarql.Schema).Reload() cannot be ran before (*yarql.Schema).Parse()")
+	}
+
+	s.reload.lock.Lock()
+	defer s.reload.lock.Unlock()
+
+	return s.reloadLocked(queries, methods, options)
+}
+
+// reloadLocked is Reload without taking the lock, expects the lock of s.reload to be held
+func (s *Schema) reloadLocked(queries interface{}, methods interface{}, options *SchemaOptions) error {
+	next := s.reload.registered.registrations()
+	next.copySettings(s)
+	err := next.Parse(queries, methods, options)
+	if err != nil {
+		return err
+	}
+
+	s.reload.queries = queries
+	s.reload.methods = methods
+	s.reload.options = options
+	s.reload.current.Store(next)
+	return nil
+}
+
+// Current returns the schema set by the last call to (*Schema).Reload, if the schema was never reloaded s is returned
+func (s *Schema) Current() *Schema {
+	if s.reload == nil {
+		return s
+	}
+	current, ok := s.reload.current.Load().(*Schema)
+	if !ok {
+		return s
+	}
+	return current
+}
+
+// registrations returns a new unparsed schema with the registrations of s
+// Values set by Parse are left out so the returned schema can be parsed
+func (s *Schema) registrations() *Schema {
+	res := &Schema{
+		types:            types{},
+		typeVariants:     types{},
+		inTypes:          inputMap{},
+		interfaces:       types{},
+		graphqlObjFields: map[string][]qlField{},
+
+		definedEnums:      append([]enum{}, s.definedEnums...),
+		definedDirectives: map[DirectiveLocation][]*Directive{},
+		typeOwners:        s.typeOwners,
+		descriptions:      s.descriptions,
+		rootResolvers:     append([]rootResolver{}, s.rootResolvers...),
+		fieldResolvers:    s.fieldResolvers,
+		entities:          map[reflect.Type]*Entity{},
+		entitiesByName:    map[string]*Entity{},
+		typeFederation:    map[reflect.Type]*TypeFederation{},
+		federationByName:  map[string]*TypeFederation{},
+		nodeFetchers:      map[reflect.Type]*nodeFetcher{},
+		nodesByName:       map[string]*nodeFetcher{},
+		fieldCosts:        append([]fieldCost{}, s.fieldCosts...),
+		typeAuth:          s.typeAuth,
+		typeResolvers:     s.typeResolvers,
+		fieldAuth:         append([]fieldAuth{}, s.fieldAuth...),
+		memoizedFields:    append([]memoizedField{}, s.memoizedFields...),
+		customScalars:     s.customScalars,
+		executionStrategy: s.executionStrategy,
+		panicHandler:      s.panicHandler,
+		errorPresenter:    s.errorPresenter,
+		loaders:           s.loaders,
+		middleware:        append([]FieldMiddleware{}, s.middleware...),
+		fieldResolver:     s.fieldResolver,
+		extensions:        append([]Extension{}, s.extensions...),
+		requestLogger:     s.requestLogger,
+		responseTransform: s.responseTransform,
+		injector:          s.injector,
+		rateLimiter:       s.rateLimiter,
+		fieldScopes:       append([]fieldScope{}, s.fieldScopes...),
+		requestStates:     s.requestStates,
+		mocks:             s.mocks,
+		bindings:          s.bindings,
+		composed:          append([]*Schema{}, s.composed...),
+	}
+	res.copySettings(s)
+
+	// The type names are set by Parse so every schema gets it's own copy
+	for goType, entity := range s.entities {
+		entityCopy := *entity
+		res.entities[goType] = &entityCopy
+	}
+	for goType, directives := range s.typeFederation {
+		directivesCopy := *directives
+		res.typeFederation[goType] = &directivesCopy
+	}
+	for goType, fetcher := range s.nodeFetchers {
+		fetcherCopy := *fetcher
+		res.nodeFetchers[goType] = &fetcherCopy
+	}
+
+	// The arguments of directives are parsed by Parse so the directives are checked again
+	directives := map[*Directive]*Directive{}
+	for location, definedDirectives := range s.definedDirectives {
+		directivesForLocation := make([]*Directive, len(definedDirectives))
+		for idx, directive := range definedDirectives {
+			directiveCopy, ok := directives[directive]
+			if !ok {
+				directiveCopy = &Directive{
+					Name:        directive.Name,
+					Where:       directive.Where,
+					Method:      directive.Method,
+					Description: directive.Description,
+					Repeatable:  directive.Repeatable,
+				}
+				checkDirective(directiveCopy)
+				directives[directive] = directiveCopy
+			}
+			directivesForLocation[idx] = directiveCopy
+		}
+		res.definedDirectives[location] = directivesForLocation
+	}
+
+	return res
+}
+
+// copySettings copies the exported settings of from to s
+func (s *Schema) copySettings(from *Schema) {
+	s.MaxDepth = from.MaxDepth
+	s.MaxIntrospectionDepth = from.MaxIntrospectionDepth
+	s.MaxIntrospectionFields = from.MaxIntrospectionFields
+	s.MaxAliases = from.MaxAliases
+	s.MaxRootFields = from.MaxRootFields
+	s.MaxQueryLength = from.MaxQueryLength
+	s.MaxTokens = from.MaxTokens
+	s.InitialResultSize = from.InitialResultSize
+	s.MaxResultSize = from.MaxResultSize
+	s.MaxListItems = from.MaxListItems
+	s.MaxRetainedResultSize = from.MaxRetainedResultSize
+	s.MaxBatchSize = from.MaxBatchSize
+	s.BatchConcurrency = from.BatchConcurrency
+	s.ShareBatchLoaders = from.ShareBatchLoaders
+}
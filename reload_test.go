@@ -0,0 +1,117 @@
+package yarql
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestReloadV1 struct {
+	Version int
+}
+
+type TestReloadV2 struct {
+	Version  int
+	Greeting string
+}
+
+func (TestReloadV2) ResolveFruit(args struct{ F TestEnum2 }) TestEnum2 {
+	return args.F
+}
+
+func registerTestReloadTypes(t *testing.T, s *Schema) {
+	_, err := s.RegisterEnum(map[string]TestEnum2{"FOO": TestEnum2Foo, "BAR": TestEnum2Bar})
+	a.NoError(t, err)
+	a.NoError(t, s.RegisterDirective(Directive{
+		Name:  "hide",
+		Where: []DirectiveLocation{DirectiveLocationField},
+		Method: func(args struct{ If bool }) DirectiveModifier {
+			return DirectiveModifier{Skip: args.If}
+		},
+	}))
+}
+
+func TestReload(t *testing.T) {
+	s := NewSchema()
+	registerTestReloadTypes(t, s)
+	res, errs := bytecodeParse(t, s, `{version}`, TestReloadV1{Version: 1}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"version":1}`, res)
+	a.Equal(t, s, s.Current())
+
+	a.NoError(t, s.Reload(TestReloadV2{Version: 2, Greeting: "hi"}, M{}, nil))
+	a.NotEqual(t, s, s.Current())
+
+	out, errs := s.Exec([]byte(`{version greeting hidden: version @hide(if: true) fruit(f: BAR)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"version":2,"greeting":"hi","fruit":"BAR"}`, string(out))
+
+	// Resolve keeps using the schema it was parsed with
+	errs = s.Resolve([]byte(`{version}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"version":1}`, string(s.Result))
+
+	a.False(t, strings.Contains(s.SDL(), "greeting"))
+	a.True(t, strings.Contains(s.Current().SDL(), "greeting"))
+
+	// Reloading again starts from the registrations and not from the previous reload
+	a.NoError(t, s.Reload(TestReloadV1{Version: 3}, M{}, nil))
+	out, errs = s.Exec([]byte(`{version}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"version":3}`, string(out))
+}
+
+func TestReloadInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.Reload(TestReloadV1{}, M{}, nil))
+
+	s = NewSchema()
+	registerTestReloadTypes(t, s)
+	a.NoError(t, s.Parse(TestReloadV1{Version: 1}, M{}, nil))
+	a.Error(t, s.Reload(TestReloadV1{}, TestReloadV1{}, nil))
+
+	// A failed reload keeps the current schema
+	res, errs := s.Exec([]byte(`{version}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"version":1}`, string(res))
+}
+
+func TestReloadRelay(t *testing.T) {
+	s := NewSchema()
+	registerTestNodeFetchers(t, s)
+	a.NoError(t, s.Parse(TestRelayQ{}, M{}, &SchemaOptions{EnableRelay: true}))
+	a.NoError(t, s.Reload(TestRelayQ{}, M{}, &SchemaOptions{EnableRelay: true}))
+	a.NoError(t, s.Reload(TestRelayQ{}, M{}, &SchemaOptions{EnableRelay: true}))
+
+	res, errs := s.Exec([]byte(`{node(id: "`+ToGlobalID("TestRelayUser", "1")+`") {... on TestRelayUser {name}}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"node":{"name":"user 1"}}`, string(res))
+}
+
+func TestReloadConcurrent(t *testing.T) {
+	s := NewSchema()
+	registerTestReloadTypes(t, s)
+	a.NoError(t, s.Parse(TestReloadV1{Version: 1}, M{}, nil))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			res, errs := s.Exec([]byte(`{version}`), ResolveOptions{NoMeta: true})
+			if len(errs) != 0 || (string(res) != `{"version":1}` && string(res) != `{"version":2}`) {
+				t.Errorf("unexpected result %s %v", res, errs)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			err := s.Reload(TestReloadV1{Version: 2}, M{}, nil)
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,609 @@
+package yarql
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/mjarkk/yarql/bytecode"
+	"github.com/mjarkk/yarql/helpers"
+	"github.com/valyala/fastjson"
+)
+
+// RemoteField delegates a root field to a remote graphql endpoint
+// The arguments and sub selection of the field are send to the remote endpoint and its JSON response is written as is to the result
+type RemoteField struct {
+	// URL of the remote graphql endpoint
+	URL string
+	// RemoteName is the name of the root field on the remote schema, defaults to the local field name
+	RemoteName string
+	// Signature is a function describing the arguments and response type of the field, it's never called
+	// Used to expose the field in the schema, if nil the field is hidden from introspection and the SDL
+	//
+	// Example:
+	//
+	//	func(args struct{ ID string }) Product { return Product{} }
+	Signature interface{}
+	// Client is used to send the requests, defaults to http.DefaultClient
+	Client *http.Client
+	// PrepareRequest is called before sending a request to the remote endpoint, for example to forward authorization headers
+	PrepareRequest func(ctx *Ctx, req *http.Request) error
+	// Directives are the names of the directives supported by the remote endpoint next to @skip and @include
+	// Other directives within the selection of the field, like @mock, are only known by this schema and are not forwarded
+	Directives []string
+	// MaxResponseSize is the max size of the response of the remote endpoint in bytes, default 10MB
+	MaxResponseSize int64
+}
+
+// AddRemoteQuery adds a field with the name name to the query root that is resolved by a remote graphql endpoint
+// Variables used within the field are forwarded to the remote endpoint
+//
+// Example:
+//
+//	s.AddRemoteQuery("products", yarql.RemoteField{
+//		URL: "http://products.internal/graphql",
+//	})
+func (s *Schema) AddRemoteQuery(name string, remote RemoteField) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).AddRemoteQuery() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	return s.addRemoteField(false, name, remote)
+}
+
+// AddRemoteMutation adds a field with the name name to the mutation root that is resolved by a remote graphql endpoint
+// See (*yarql.Schema).AddRemoteQuery() for more info
+func (s *Schema) AddRemoteMutation(name string, remote RemoteField) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).AddRemoteMutation() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	return s.addRemoteField(true, name, remote)
+}
+
+func (s *Schema) addRemoteField(isMutation bool, name string, remote RemoteField) error {
+	err := validGraphQlName([]byte(name))
+	if err != nil {
+		return errors.New(name + " is not a valid graphql field name")
+	}
+	if len(remote.URL) == 0 {
+		return errors.New("remote field " + name + " must have an URL")
+	}
+	if len(remote.RemoteName) == 0 {
+		remote.RemoteName = name
+	} else if validGraphQlName([]byte(remote.RemoteName)) != nil {
+		return errors.New(remote.RemoteName + " is not a valid graphql field name")
+	}
+
+	if remote.MaxResponseSize == 0 {
+		remote.MaxResponseSize = 10 << 20
+	}
+
+	var signature reflect.Value
+	if remote.Signature != nil {
+		signature = reflect.ValueOf(remote.Signature)
+		if signature.Kind() != reflect.Func {
+			return errors.New("the signature of remote field " + name + " must be a function")
+		}
+	}
+
+	for _, resolver := range s.rootResolvers {
+		if resolver.isMutation == isMutation && resolver.name == name {
+			return errors.New("resolver " + name + " is already added")
+		}
+	}
+
+	s.rootResolvers = append(s.rootResolvers, rootResolver{
+		isMutation: isMutation,
+		name:       name,
+		fn:         signature,
+		remote:     &remote,
+	})
+	return nil
+}
+
+// remoteFieldObj creates the obj of a remote root field
+func (c *parseCtx) remoteFieldObj(resolver *rootResolver) (*obj, error) {
+	remote := resolver.remote
+	isMutation := resolver.isMutation
+	customResolver := func(ctx *Ctx, dept uint8, hasSubSelection bool) bool {
+		return ctx.resolveRemoteField(remote, isMutation)
+	}
+
+	if !resolver.fn.IsValid() {
+		return &obj{
+			qlFieldName:    []byte(resolver.name),
+			valueType:      valueTypeUndefined,
+			hidden:         true,
+			customResolver: customResolver,
+		}, nil
+	}
+
+	functionObj, err := c.checkStructFieldFunc(resolver.name, resolver.fn.Type(), false, -1)
+	if err != nil {
+		return nil, err
+	}
+	functionObj.qlFieldName = []byte(resolver.name)
+	functionObj.customResolver = customResolver
+	return functionObj, nil
+}
+
+// resolveRemoteField sends the current field to the remote endpoint and writes the response
+func (ctx *Ctx) resolveRemoteField(remote *RemoteField, isMutation bool) bool {
+	p := remoteQueryPrinter{ctx: ctx, remote: remote}
+	query, criticalErr := p.print(remote.RemoteName, isMutation)
+	if criticalErr {
+		ctx.writeNull()
+		return criticalErr
+	}
+
+	body := []byte(`{"query":`)
+	helpers.StringToJSON(b2s(query), &body)
+	body = append(body, `,"variables":{`...)
+	first := true
+	for _, name := range p.variables {
+		variable, criticalErr := ctx.getExternalVariable(name)
+		if criticalErr {
+			ctx.writeNull()
+			return criticalErr
+		}
+		if variable == nil {
+			// The remote endpoint will use the default value
+			continue
+		}
+		if !first {
+			body = append(body, ',')
+		}
+		first = false
+		helpers.StringToJSON(name, &body)
+		body = append(body, ':')
+		body = variable.MarshalTo(body)
+	}
+	body = append(body, `}}`...)
+
+	response, err := remote.send(ctx, body)
+	if err != nil {
+		ctx.writeNull()
+		ctx.err("remote field request failed, " + err.Error())
+		return false
+	}
+
+	for _, remoteErr := range response.GetArray("errors") {
+		message := remoteErr.GetStringBytes("message")
+		if len(message) == 0 {
+			message = []byte("unknown error from remote field")
+		}
+		ctx.err(string(message))
+	}
+
+	value := response.Get("data", "r")
+	if value == nil {
+		ctx.writeNull()
+		return false
+	}
+	ctx.schema.Result = value.MarshalTo(ctx.schema.Result)
+	return false
+}
+
+// send posts body to the remote endpoint and parses the response
+func (remote *RemoteField) send(ctx *Ctx, body []byte) (*fastjson.Value, error) {
+	req, err := http.NewRequest(http.MethodPost, remote.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if goCtx := ctx.GetContext(); goCtx != nil {
+		req = req.WithContext(goCtx)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	if remote.PrepareRequest != nil {
+		err = remote.PrepareRequest(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := remote.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// Read one byte more than allowed to detect a too large response
+	resBody, err := ioutil.ReadAll(io.LimitReader(res.Body, remote.MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(resBody)) > remote.MaxResponseSize {
+		return nil, errors.New("response exceeds the max size of " + strconv.FormatInt(remote.MaxResponseSize, 10) + " bytes")
+	}
+
+	response, err := fastjson.ParseBytes(resBody)
+	if err != nil {
+		if res.StatusCode >= 300 {
+			return nil, errors.New("unexpected status " + res.Status)
+		}
+		return nil, err
+	}
+	if response.Type() != fastjson.TypeObject {
+		return nil, errors.New("unexpected response")
+	}
+	return response, nil
+}
+
+// remoteQueryPrinter converts the bytecode of a field back into a graphql query
+type remoteQueryPrinter struct {
+	ctx       *Ctx
+	remote    *RemoteField
+	res       []byte
+	variables []string
+}
+
+// print creates the query for the remote endpoint
+// The charNr of ctx is expected to be at the arguments or selection set of the field
+// The response of the remote field is aliased to r
+func (p *remoteQueryPrinter) print(remoteName string, isMutation bool) ([]byte, bool) {
+	ctx := p.ctx
+
+	p.res = append(p.res, "{r:"...)
+	p.res = append(p.res, remoteName...)
+	if ctx.seekInst() == bytecode.ActionValue {
+		criticalErr := p.printArguments()
+		if criticalErr {
+			return nil, criticalErr
+		}
+	}
+	if ctx.seekInst() != bytecode.ActionEnd {
+		criticalErr := p.printSelectionSet()
+		if criticalErr {
+			return nil, criticalErr
+		}
+	}
+	p.res = append(p.res, '}')
+	selection := p.res
+	p.res = nil
+
+	if isMutation {
+		p.res = append(p.res, "mutation"...)
+	} else {
+		p.res = append(p.res, "query"...)
+	}
+	if len(p.variables) > 0 {
+		p.res = append(p.res, '(')
+		for idx, name := range p.variables {
+			if idx > 0 {
+				p.res = append(p.res, ',')
+			}
+			criticalErr := p.printVariableDefinition(name)
+			if criticalErr {
+				return nil, criticalErr
+			}
+		}
+		p.res = append(p.res, ')')
+	}
+
+	return append(p.res, selection...), false
+}
+
+// printVariableDefinition prints the definition of an operation argument, like: $id:ID!="1"
+func (p *remoteQueryPrinter) printVariableDefinition(name string) bool {
+	ctx := p.ctx
+	if !ctx.findOperatorArgument(name) {
+		return ctx.err("variable " + name + " not defined")
+	}
+
+	p.res = append(p.res, '$')
+	p.res = append(p.res, name...)
+	p.res = append(p.res, ':')
+
+	typeStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// read type
+	}
+	p.printType(ctx.query.Res[typeStart : ctx.charNr-1])
+
+	if ctx.readInst() == 't' {
+		ctx.skipInst(1)
+		p.res = append(p.res, '=')
+		return p.printValue()
+	}
+	return false
+}
+
+// printType converts a bytecode graphql type like LNInt into [Int!]!
+func (p *remoteQueryPrinter) printType(qlType []byte) {
+	switch qlType[0] {
+	case 'l', 'L':
+		p.res = append(p.res, '[')
+		p.printType(qlType[1:])
+		p.res = append(p.res, ']')
+	default:
+		p.res = append(p.res, qlType[1:]...)
+	}
+	if qlType[0] == 'L' || qlType[0] == 'N' {
+		p.res = append(p.res, '!')
+	}
+}
+
+func (p *remoteQueryPrinter) printSelectionSet() bool {
+	ctx := p.ctx
+	p.res = append(p.res, '{')
+	for first := true; ; first = false {
+		inst := ctx.readInst()
+		if inst == bytecode.ActionEnd {
+			break
+		}
+		if !first {
+			p.res = append(p.res, ' ')
+		}
+
+		var criticalErr bool
+		switch inst {
+		case bytecode.ActionField:
+			criticalErr = p.printField()
+		case bytecode.ActionSpread:
+			criticalErr = p.printSpread()
+		default:
+			criticalErr = ctx.err("unsupported operation " + string(inst))
+		}
+		if criticalErr {
+			return criticalErr
+		}
+	}
+	p.res = append(p.res, '}')
+	return false
+}
+
+func (p *remoteQueryPrinter) printField() bool {
+	ctx := p.ctx
+	directivesCount := ctx.readInst()
+	fieldLen := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(8) // field length and name key
+	endOfField := ctx.charNr + int(fieldLen)
+
+	aliasLen := int(ctx.readInst())
+	p.res = append(p.res, ctx.query.Res[ctx.charNr:ctx.charNr+aliasLen]...)
+	ctx.skipInst(aliasLen)
+	nameLen := int(ctx.readInst())
+	if nameLen != 0 {
+		p.res = append(p.res, ':')
+		p.res = append(p.res, ctx.query.Res[ctx.charNr:ctx.charNr+nameLen]...)
+		ctx.skipInst(nameLen)
+	}
+	ctx.skipInst(1)
+
+	// The directives are located before the arguments in the bytecode but are defined after them in graphql
+	directivesStart := ctx.charNr
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.skipDirective()
+	}
+	if ctx.seekInst() == bytecode.ActionValue {
+		criticalErr := p.printArguments()
+		if criticalErr {
+			return criticalErr
+		}
+	}
+	argumentsEnd := ctx.charNr
+	ctx.charNr = directivesStart
+	criticalErr := p.printDirectives(directivesCount)
+	if criticalErr {
+		return criticalErr
+	}
+	ctx.charNr = argumentsEnd
+
+	if ctx.seekInst() != bytecode.ActionEnd {
+		criticalErr = p.printSelectionSet()
+		if criticalErr {
+			return criticalErr
+		}
+	}
+
+	ctx.charNr = endOfField + 1
+	return false
+}
+
+// printSpread prints a fragment spread, named fragments are printed as inline fragments
+func (p *remoteQueryPrinter) printSpread() bool {
+	ctx := p.ctx
+	isInline := ctx.readInst() == 't'
+	directivesCount := ctx.readInst()
+	ctx.skipInst(4) // length of the spread
+
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// read name
+	}
+	name := ctx.query.Res[nameStart : ctx.charNr-1]
+
+	p.res = append(p.res, "...on "...)
+	if isInline {
+		p.res = append(p.res, name...)
+		criticalErr := p.printDirectives(directivesCount)
+		if criticalErr {
+			return criticalErr
+		}
+		criticalErr = p.printSelectionSet()
+		ctx.charNr++
+		return criticalErr
+	}
+
+	typeName, selectionSetAt, ok := ctx.findFragment(name)
+	if !ok {
+		return ctx.err("fragment " + b2s(name) + " not defined")
+	}
+	p.res = append(p.res, typeName...)
+
+	criticalErr := p.printDirectives(directivesCount)
+	if criticalErr {
+		return criticalErr
+	}
+
+	originalCharNr := ctx.charNr
+	ctx.charNr = selectionSetAt
+	criticalErr = p.printSelectionSet()
+	ctx.charNr = originalCharNr
+	return criticalErr
+}
+
+// printDirectives prints the directives supported by the remote endpoint, see RemoteField.Directives
+func (p *remoteQueryPrinter) printDirectives(count uint8) bool {
+	ctx := p.ctx
+	for i := uint8(0); i < count; i++ {
+		ctx.skipInst(1) // read 'd'
+		hasArguments := ctx.readInst() == 't'
+
+		nameStart := ctx.charNr
+		for ctx.readInst() != 0 {
+			// read name
+		}
+		name := b2s(ctx.query.Res[nameStart : ctx.charNr-1])
+		if !p.remote.supportsDirective(name) {
+			ctx.skipArguments()
+			continue
+		}
+
+		p.res = append(p.res, '@')
+		p.res = append(p.res, name...)
+		if hasArguments {
+			criticalErr := p.printArguments()
+			if criticalErr {
+				return criticalErr
+			}
+		}
+	}
+	return false
+}
+
+// supportsDirective returns true if the remote endpoint supports the directive with name
+func (remote *RemoteField) supportsDirective(name string) bool {
+	if name == "skip" || name == "include" {
+		return true
+	}
+	for _, directive := range remote.Directives {
+		if directive == name {
+			return true
+		}
+	}
+	return false
+}
+
+// printArguments prints the arguments object at the current charNr like: (a:1,b:2)
+func (p *remoteQueryPrinter) printArguments() bool {
+	ctx := p.ctx
+	contentEnd := ctx.charNr + 6 + int(ctx.readUint32(ctx.charNr+2))
+
+	p.res = append(p.res, '(')
+	ctx.charNr += 6
+	criticalErr := p.printObjectFields()
+	if criticalErr {
+		return criticalErr
+	}
+	p.res = append(p.res, ')')
+
+	ctx.charNr = contentEnd + 1
+	return false
+}
+
+// printObjectFields prints the fields of an input object, the charNr is expected to be at the start of the object's content
+func (p *remoteQueryPrinter) printObjectFields() bool {
+	ctx := p.ctx
+	for i := 0; ; i++ {
+		c := ctx.readInst()
+		if c == 0 {
+			c = ctx.readInst()
+		}
+		if c == bytecode.ActionEnd {
+			return false
+		}
+
+		if i > 0 {
+			p.res = append(p.res, ',')
+		}
+		for {
+			c = ctx.readInst()
+			if c == 0 {
+				break
+			}
+			p.res = append(p.res, c)
+		}
+		p.res = append(p.res, ':')
+
+		criticalErr := p.printValue()
+		if criticalErr {
+			return criticalErr
+		}
+	}
+}
+
+// printValue prints the input value at the current charNr
+func (p *remoteQueryPrinter) printValue() bool {
+	ctx := p.ctx
+	kind := ctx.query.Res[ctx.charNr+1]
+	contentStart := ctx.charNr + 6
+	contentEnd := contentStart + int(ctx.readUint32(ctx.charNr+2))
+	content := ctx.query.Res[contentStart:contentEnd]
+
+	switch kind {
+	case bytecode.ValueInt, bytecode.ValueFloat, bytecode.ValueEnum:
+		p.res = append(p.res, content...)
+	case bytecode.ValueString:
+		helpers.StringToJSON(b2s(content), &p.res)
+	case bytecode.ValueBoolean:
+		if len(content) > 0 && content[0] == '1' {
+			p.res = append(p.res, "true"...)
+		} else {
+			p.res = append(p.res, "false"...)
+		}
+	case bytecode.ValueNull:
+		p.res = append(p.res, nullBytes...)
+	case bytecode.ValueVariable:
+		name := string(content)
+		p.res = append(p.res, '$')
+		p.res = append(p.res, name...)
+
+		known := false
+		for _, variable := range p.variables {
+			if variable == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			p.variables = append(p.variables, name)
+		}
+	case bytecode.ValueList:
+		p.res = append(p.res, '[')
+		ctx.charNr = contentStart + 1
+		for i := 0; ctx.seekInst() != bytecode.ActionEnd; i++ {
+			if i > 0 {
+				p.res = append(p.res, ',')
+			}
+			criticalErr := p.printValue()
+			if criticalErr {
+				return criticalErr
+			}
+		}
+		p.res = append(p.res, ']')
+	case bytecode.ValueObject:
+		p.res = append(p.res, '{')
+		ctx.charNr = contentStart
+		criticalErr := p.printObjectFields()
+		if criticalErr {
+			return criticalErr
+		}
+		p.res = append(p.res, '}')
+	default:
+		return ctx.err("unknown input value kind " + string(kind))
+	}
+
+	// Skip over the value and the NULL byte after it
+	ctx.charNr = contentEnd + 1
+	return false
+}
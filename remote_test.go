@@ -0,0 +1,178 @@
+package yarql
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestRemoteProduct struct {
+	ID   string `gq:"id,ID"`
+	Name string
+}
+
+type testRemoteRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func newTestRemoteServer(t *testing.T, response string, requests *[]testRemoteRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		a.Equal(t, "secret", r.Header.Get("Authorization"))
+
+		request := testRemoteRequest{}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		a.NoError(t, err)
+		*requests = append(*requests, request)
+
+		w.Write([]byte(response))
+	}))
+}
+
+func TestRemoteQuery(t *testing.T) {
+	requests := []testRemoteRequest{}
+	server := newTestRemoteServer(t, `{"data":{"r":{"id":"1","title":"Chair","ratio":1.5}}}`, &requests)
+	defer server.Close()
+
+	s := NewSchema()
+	err := s.AddRemoteQuery("product", RemoteField{
+		URL:        server.URL,
+		RemoteName: "productByID",
+		Signature:  func(args struct{ ID string }) TestRemoteProduct { return TestRemoteProduct{} },
+		PrepareRequest: func(ctx *Ctx, req *http.Request) error {
+			req.Header.Set("Authorization", "secret")
+			return nil
+		},
+	})
+	a.NoError(t, err)
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{}, M{}, nil))
+	s = s.Copy()
+
+	query := `query products($id: ID!, $withTitle: Boolean = true) {
+		a
+		p: product(id: $id, filter: {tags: ["a", "b"], kind: NEW, limit: 10, active: true, missing: null}) {
+			id
+			title: name(format: "short") @include(if: $withTitle)
+			...productFields
+			... on Product { ratio }
+		}
+	}
+	fragment productFields on Product { id }`
+	errs := s.Resolve([]byte(query), ResolveOptions{
+		NoMeta:    true,
+		Variables: `{"id": "1", "unused": "foo"}`,
+	})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"a":"","p":{"id":"1","title":"Chair","ratio":1.5}}`, string(s.Result))
+
+	a.Equal(t, 1, len(requests))
+	a.Equal(t, `query($id:ID!,$withTitle:Boolean=true){r:productByID(id:$id,filter:{tags:["a","b"],kind:NEW,limit:10,active:true,missing:null}){id title:name(format:"short")@include(if:$withTitle) ...on Product{id} ...on Product{ratio}}}`, requests[0].Query)
+	a.Equal(t, map[string]interface{}{"id": "1"}, requests[0].Variables)
+
+	// The field is part of the schema
+	a.True(t, len(s.SDL()) > 0)
+}
+
+func TestRemoteMutationErrors(t *testing.T) {
+	requests := []testRemoteRequest{}
+	server := newTestRemoteServer(t, `{"data":{"r":null},"errors":[{"message":"not allowed"}]}`, &requests)
+	defer server.Close()
+
+	s := NewSchema()
+	err := s.AddRemoteMutation("deleteProduct", RemoteField{
+		URL: server.URL,
+		PrepareRequest: func(ctx *Ctx, req *http.Request) error {
+			req.Header.Set("Authorization", "secret")
+			return nil
+		},
+	})
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `mutation {deleteProduct(id: "1")}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "not allowed", errs[0].Error())
+	a.Equal(t, `{"deleteProduct":null}`, res)
+	a.Equal(t, `mutation{r:deleteProduct(id:"1")}`, requests[0].Query)
+}
+
+func TestRemoteFieldRequestFailed(t *testing.T) {
+	s := NewSchema()
+	err := s.AddRemoteQuery("product", RemoteField{URL: "http://127.0.0.1:0/graphql"})
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `{product {id}}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"product":null}`, res)
+}
+
+func TestRemoteDirectives(t *testing.T) {
+	requests := []testRemoteRequest{}
+	server := newTestRemoteServer(t, `{"data":{"r":{"id":"1","name":"Chair"}}}`, &requests)
+	defer server.Close()
+
+	s := NewSchema()
+	err := s.AddRemoteQuery("product", RemoteField{
+		URL:        server.URL,
+		Directives: []string{"cached"},
+		PrepareRequest: func(ctx *Ctx, req *http.Request) error {
+			req.Header.Set("Authorization", "secret")
+			return nil
+		},
+	})
+	a.NoError(t, err)
+
+	// Only @skip, @include and the directives supported by the remote are forwarded
+	query := `query($withName: Boolean!, $value: String) {
+		product {
+			id @cached(seconds: 10)
+			name @include(if: $withName) @mock(value: $value)
+		}
+	}`
+	res, errs := bytecodeParse(t, s, query, TestResolveSimpleQueryData{}, M{}, ResolveOptions{
+		NoMeta:    true,
+		Variables: `{"withName": true, "value": "\"fake\""}`,
+	})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"product":{"id":"1","name":"Chair"}}`, res)
+	a.Equal(t, `query($withName:Boolean!){r:product{id@cached(seconds:10) name@include(if:$withName)}}`, requests[0].Query)
+	a.Equal(t, map[string]interface{}{"withName": true}, requests[0].Variables)
+}
+
+func TestRemoteMaxResponseSize(t *testing.T) {
+	requests := []testRemoteRequest{}
+	server := newTestRemoteServer(t, `{"data":{"r":{"id":"1","name":"Chair"}}}`, &requests)
+	defer server.Close()
+
+	s := NewSchema()
+	err := s.AddRemoteQuery("product", RemoteField{
+		URL:             server.URL,
+		MaxResponseSize: 10,
+		PrepareRequest: func(ctx *Ctx, req *http.Request) error {
+			req.Header.Set("Authorization", "secret")
+			return nil
+		},
+	})
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `{product {id name}}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "remote field request failed, response exceeds the max size of 10 bytes", errs[0].Error())
+	a.Equal(t, `{"product":null}`, res)
+}
+
+func TestAddRemoteFieldInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.AddRemoteQuery("", RemoteField{URL: "http://localhost"}))
+	a.Error(t, s.AddRemoteQuery("product", RemoteField{}))
+	a.Error(t, s.AddRemoteQuery("product", RemoteField{URL: "http://localhost", RemoteName: "-"}))
+	a.Error(t, s.AddRemoteQuery("product", RemoteField{URL: "http://localhost", Signature: "not a function"}))
+	a.NoError(t, s.AddRemoteQuery("product", RemoteField{URL: "http://localhost"}))
+	a.Error(t, s.AddRemoteQuery("product", RemoteField{URL: "http://localhost"}))
+	a.NoError(t, s.AddRemoteMutation("product", RemoteField{URL: "http://localhost"}))
+}
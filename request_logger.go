@@ -0,0 +1,83 @@
+package yarql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// RequestInfo contains information about a resolved request, see (*yarql.Schema).SetRequestLogger
+type RequestInfo struct {
+	OperationName string          // Empty for anonymous operations
+	OperationType string          // query, mutation or subscription, empty if no operation was found
+	Duration      time.Duration   // The time it took to parse and resolve the request
+	ErrorCount    int             // The amount of errors in the response
+	Errors        []error         // The errors in the response, only valid during the call
+	Complexity    int             // The complexity of the operation, 0 if no operation was found
+	Extensions    json.RawMessage // The extensions send by the client, nil if none where send
+	Context       context.Context // The request context
+	RequestID     string          // The ID of the request, see (*Ctx).SetRequestID
+}
+
+// SetRequestLogger sets a function that is called after every request, useful for logging and metrics
+//
+// Example:
+//
+//	s.SetRequestLogger(func(info yarql.RequestInfo) {
+//		log.Printf("%s %s took %s with %d errors", info.OperationType, info.OperationName, info.Duration, info.ErrorCount)
+//	})
+func (s *Schema) SetRequestLogger(logger func(info RequestInfo)) {
+	s.requestLogger = logger
+}
+
+// logRequest calls the request logger with the information of the current request
+// parsed tells if the query was parsed without errors, only then the operation can be read
+func (ctx *Ctx) logRequest(start time.Time, parsed bool) {
+	info := RequestInfo{
+		Duration:   time.Since(start),
+		ErrorCount: len(ctx.query.Errors),
+		Errors:     ctx.query.Errors,
+		Extensions: ctx.RequestExtensions(),
+		Context:    ctx.Context(),
+		RequestID:  ctx.requestID,
+	}
+
+	if parsed {
+		var ok bool
+		info.OperationType, info.OperationName, ok = ctx.operation()
+		if ok {
+			info.Complexity = ctx.operationComplexity()
+		}
+	}
+
+	ctx.schema.requestLogger(info)
+}
+
+// operation returns the type and name of the operation at the TargetIdx, ok is false if no operation was found
+// The result is only meaningful if the query was parsed without errors
+func (ctx *Ctx) operation() (operationType string, operationName string, ok bool) {
+	res := ctx.query.Res
+	target := ctx.query.TargetIdx
+	if target < 0 || len(res) <= target+5 {
+		return "", "", false
+	}
+
+	switch res[target+2] {
+	case bytecode.OperatorQuery:
+		operationType = "query"
+	case bytecode.OperatorMutation:
+		operationType = "mutation"
+	case bytecode.OperatorSubscription:
+		operationType = "subscription"
+	}
+	name := res[target+5:]
+	nameEnd := bytes.IndexByte(name, 0)
+	if nameEnd == -1 {
+		return "", "", false
+	}
+	operationName = string(name[:nameEnd])
+	return operationType, operationName, true
+}
@@ -0,0 +1,187 @@
+package yarql
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestRequestLoggerData struct {
+	A string
+}
+
+func (TestRequestLoggerData) ResolveFail() (string, error) {
+	return "", errors.New("failed")
+}
+
+func TestRequestLogger(t *testing.T) {
+	var infos []RequestInfo
+	s := NewSchema()
+	s.SetRequestLogger(func(info RequestInfo) {
+		info.Errors = nil
+		infos = append(infos, info)
+	})
+	a.NoError(t, s.Parse(TestRequestLoggerData{A: "a"}, M{}, nil))
+	a.Equal(t, 0, len(infos), "precomputing the introspection should not call the logger")
+	s = s.Copy()
+
+	errs := s.Resolve([]byte(`query Foo {a fail}`), ResolveOptions{Extensions: `{"client":"test"}`})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, 1, len(infos))
+	info := infos[0]
+	a.Equal(t, "Foo", info.OperationName)
+	a.Equal(t, "query", info.OperationType)
+	a.Equal(t, 1, info.ErrorCount)
+	a.Equal(t, 2, info.Complexity)
+	a.Equal(t, `{"client":"test"}`, string(info.Extensions))
+	a.NotNil(t, info.Context)
+	a.True(t, info.Duration > 0)
+
+	errs = s.Resolve([]byte(`{a`), ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, 2, len(infos))
+	info = infos[1]
+	a.Equal(t, "", info.OperationName)
+	a.Equal(t, "", info.OperationType)
+	a.Equal(t, 1, info.ErrorCount)
+	a.Equal(t, 0, info.Complexity)
+	a.Nil(t, info.Extensions)
+}
+
+func TestRequestLoggerHandleRequestExtensions(t *testing.T) {
+	var info RequestInfo
+	s := NewSchema()
+	s.SetRequestLogger(func(i RequestInfo) {
+		info = i
+	})
+	a.NoError(t, s.Parse(TestRequestLoggerData{A: "a"}, M{}, nil))
+
+	_, errs := s.HandleRequest(
+		"POST",
+		func(key string) string { return "" },
+		func(key string) (string, error) { return "", errors.New("this should not be called") },
+		func() []byte {
+			return []byte(`{"query": "{a}", "extensions": {"persistedQuery": {"version": 1}}}`)
+		},
+		"application/json",
+		&RequestOptions{},
+	)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"persistedQuery":{"version":1}}`, string(info.Extensions))
+
+	_, errs = s.HandleRequest(
+		"GET",
+		func(key string) string {
+			switch key {
+			case "query":
+				return "{a}"
+			case "extensions":
+				return `{"a":"b"}`
+			default:
+				return ""
+			}
+		},
+		func(key string) (string, error) { return "", errors.New("this should not be called") },
+		func() []byte { return nil },
+		"",
+		&RequestOptions{},
+	)
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"b"}`, string(info.Extensions))
+}
+
+func TestRequestID(t *testing.T) {
+	var info RequestInfo
+	s := NewSchema()
+	s.SetRequestLogger(func(i RequestInfo) {
+		info = i
+	})
+	a.NoError(t, s.Parse(TestRequestLoggerData{A: "a"}, M{}, nil))
+
+	errs := s.Resolve([]byte(`{a fail}`), ResolveOptions{RequestID: "req-1"})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"data":{"a":"a","fail":""},"errors":[{"message":"failed","path":["fail"],"locations":[{"line":1,"column":4}],"extensions":{"requestId":"req-1"}}],"extensions":{}}`, string(s.Result))
+	a.Equal(t, "req-1", info.RequestID)
+
+	// Without a request ID no extensions are added
+	errs = s.Resolve([]byte(`{fail}`), ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"data":{"fail":""},"errors":[{"message":"failed","path":["fail"],"locations":[{"line":1,"column":2}]}],"extensions":{}}`, string(s.Result))
+	a.Equal(t, "", info.RequestID)
+}
+
+func TestRequestIDInjector(t *testing.T) {
+	var info RequestInfo
+	s := NewSchema()
+	s.SetRequestLogger(func(i RequestInfo) {
+		info = i
+	})
+	s.SetInjector(func(ctx *Ctx) {
+		ctx.SetRequestID(ctx.Header().Get("X-Request-ID"))
+	})
+	a.NoError(t, s.Parse(TestRequestLoggerData{A: "a"}, M{}, nil))
+
+	errs := s.Resolve([]byte(`{b}`), ResolveOptions{Header: http.Header{"X-Request-Id": {"req-2"}}})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "req-2", info.RequestID)
+	a.True(t, strings.Contains(string(s.Result), `"extensions":{"requestId":"req-2"}`), string(s.Result))
+}
+
+type TestOperationData struct{}
+
+func (TestOperationData) ResolveOperation(ctx *Ctx) string {
+	return ctx.OperationType() + " " + ctx.OperationName() + " " + string(ctx.RequestExtensions())
+}
+
+type TestOperationMethods struct{}
+
+func (TestOperationMethods) ResolveOperation(ctx *Ctx) string {
+	return ctx.OperationType() + " " + ctx.OperationName()
+}
+
+func TestCtxOperation(t *testing.T) {
+	res := bytecodeParseAndExpectNoErrs(t, `query Foo {operation}`, TestOperationData{}, TestOperationMethods{}, ResolveOptions{
+		NoMeta:     true,
+		Extensions: `{"client":"web"}`,
+	})
+	a.Equal(t, `{"operation":"query Foo {\"client\":\"web\"}"}`, res)
+
+	res = bytecodeParseAndExpectNoErrs(t, `{operation}`, TestOperationData{}, TestOperationMethods{})
+	a.Equal(t, `{"operation":"query  "}`, res)
+
+	res = bytecodeParseAndExpectNoErrs(t, `query A {operation} mutation B {operation}`, TestOperationData{}, TestOperationMethods{}, ResolveOptions{
+		NoMeta:         true,
+		OperatorTarget: "B",
+	})
+	a.Equal(t, `{"operation":"mutation B"}`, res)
+}
+
+type TestOperationExtension struct {
+	BaseExtension
+	names []string
+}
+
+func (e *TestOperationExtension) ParseStart(ctx *Ctx, query []byte) ([]byte, error) {
+	e.names = append(e.names, ctx.OperationName())
+	return query, nil
+}
+
+func (e *TestOperationExtension) Validate(ctx *Ctx) error {
+	e.names = append(e.names, ctx.OperationName())
+	return nil
+}
+
+func TestCtxOperationExtension(t *testing.T) {
+	extension := &TestOperationExtension{}
+	s := NewSchema()
+	a.NoError(t, s.RegisterExtension(extension))
+	a.NoError(t, s.Parse(TestOperationData{}, TestOperationMethods{}, nil))
+
+	a.Equal(t, 0, len(s.Resolve([]byte(`query Foo {operation}`), ResolveOptions{})))
+	a.Equal(t, 0, len(s.Resolve([]byte(`query Bar {operation}`), ResolveOptions{})))
+	// The operation of the previous request should not be visible before the query is parsed
+	a.Equal(t, []string{"", "Foo", "", "Bar"}, extension.names)
+}
@@ -6,13 +6,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"mime/multipart"
+	"net/http"
 	"reflect"
+	"runtime/debug"
 	"strconv"
-	"strings"
 	"time"
 	"unsafe"
 
+	"github.com/mjarkk/yarql/ast"
 	"github.com/mjarkk/yarql/bytecode"
 	"github.com/mjarkk/yarql/helpers"
 	"github.com/valyala/fastjson"
@@ -24,16 +28,37 @@ type Ctx struct {
 	schema                   *Schema
 	query                    bytecode.ParserCtx
 	charNr                   int
+	fieldAt                  int // location of the field instruction of the field that is currently being resolved
 	context                  *context.Context
+	header                   http.Header // headers of the http request, see (*Ctx).Header
+	remoteAddr               string      // address of the client of the http request, see (*Ctx).RemoteAddr
+	requestID                string      // see (*Ctx).RequestID
+	authorizer               Authorizer  // checks the @auth directives, see (*Ctx).SetAuthorizer
+	cancelled                bool        // the request context is done or the result is too large and the error is reported
+	resultTooLarge           bool        // the result exceeds (*Schema).MaxResultSize and the error is reported
+	listItems                int         // amount of list items resolved within this request, see (*Schema).MaxListItems
+	executed                 bool        // the execution of the operation has started
 	path                     []byte
 	getFormFile              func(key string) (*multipart.FileHeader, error) // Get form file to support file uploading
+	getUpload                func(key string) (*Upload, error)               // Get a streamed form file
+	maxFileSize              int64                                           // max size of a uploaded file, 0 = no limit
+	maxUploadSize            int64                                           // max size of all uploaded files together, 0 = no limit
+	uploadedSize             int64                                           // size of the files uploaded within this request
+	uploadClosers            []io.Closer                                     // files opened for Upload inputs, closed after the request
 	operatorHasArguments     bool
 	operatorArgumentsStartAt int
 	tracingEnabled           bool
 	tracing                  *tracer
 	prefRecordingStartTime   time.Time
+	owner                    string // owner of the field that is currently being resolved
+	field                    *obj   // the field that is currently being resolved, see (*Ctx).SelectedFields
+	mocking                  bool   // resolving fields within a field marked with @mock or SchemaOptions.MockResolvers is set
+	introspecting            bool   // resolving fields within __schema or __type
+	introspectionDept        uint8  // dept of the __schema or __type field
+	introspectionFields      int    // amount of fields resolved within introspection fields
 
 	rawVariables        string
+	rawExtensions       string           // the extensions send by the client, see (*Ctx).RequestExtensions
 	variablesParsed     bool             // the rawVariables are parsed into variables
 	variablesJSONParser *fastjson.Parser // Used to parse the variables
 	variables           *fastjson.Value  // Parsed variables, only use this if variablesParsed == true
@@ -42,7 +67,28 @@ type Ctx struct {
 	reflectValues          [256]reflect.Value
 	currentReflectValueIdx uint8
 	funcInputs             []reflect.Value
-	ctxReflection          reflect.Value // ptr to the value
+	methodInputs           map[*baseInput]reflect.Value // input structs reused between calls, see (*Ctx).methodInput
+	memoized               map[string]memoizedValue     // values of memoized fields resolved within this request, see (*Schema).RegisterMemoizedField
+	memoKeys               []byte                       // keys of the memoized fields that are currently being resolved
+	requestStates          map[reflect.Type]interface{} // request states created within this request, see RequestState
+	boundParent            unsafe.Pointer               // the parent of the type method that is called next using its binding, see (*Schema).RegisterBinding
+	ctxReflection          reflect.Value                // ptr to the value
+	usedDirectives         []*Directive                 // directives used on the location that is currently being resolved
+	variableName           string                       // name of the variable of which the directives are being resolved, see (*Ctx).Variable
+	variableValue          []byte                       // JSON value of the variable of which the directives are being resolved
+	mergeFields            []mergeField                 // fields collected to check if fields with the same response key can be merged
+	mergeArgs              []mergeArg                   // arguments collected to compare the arguments of fields
+	argumentNames          [][]byte                     // names of the arguments collected to check for duplicated and missing arguments
+	document               *ast.Document                // document of the query, created on the first call of (*Ctx).Document
+
+	loaders            map[string]*Loader  // loaders used within this request, see (*Ctx).Loader
+	batchLoaders       *batchLoaders       // loader caches shared with the other operations of a batched request
+	collectingLoads    bool                // loaders queue the keys that are not cached instead of loading them, see (*Ctx).resolveDeferredFields
+	loadPending        bool                // a loader queued a key while collecting, the field that is being resolved is deferred
+	deferredFields     []deferredField     // fields waiting for the keys they load, see (*Ctx).deferField
+	deferredResult     []byte              // buffer the deferred fields are written to before they are inserted into the result
+	middlewareField    middlewareField     // the field that is currently being resolved through the middleware
+	responseExtensions []responseExtension // extensions added to the response, see (*Ctx).SetExtension
 
 	// public / kinda public fields
 	values *map[string]interface{} // API User values, user can put all their shitty things in here like poems or tax papers
@@ -111,17 +157,70 @@ func (ctx *Ctx) GetContext() context.Context {
 	return *ctx.context
 }
 
+// Context returns the Go request context, unlike GetContext this never returns nil
+// If no context was provided context.Background() is returned
+func (ctx *Ctx) Context() context.Context {
+	if ctx.context == nil {
+		return context.Background()
+	}
+	return *ctx.context
+}
+
 // SetContext overwrites the request's Go context
 func (ctx *Ctx) SetContext(newContext context.Context) {
 	if newContext == nil {
 		ctx.context = nil
-	} else if ctx == nil {
+	} else if ctx.context == nil {
 		ctx.context = &newContext
 	} else {
 		*ctx.context = newContext
 	}
 }
 
+// Header returns the headers of the http request, nil if the request was not resolved using one of the http handlers
+func (ctx *Ctx) Header() http.Header {
+	return ctx.header
+}
+
+// RemoteAddr returns the network address of the client that send the http request, empty if unknown
+func (ctx *Ctx) RemoteAddr() string {
+	return ctx.remoteAddr
+}
+
+// RequestID returns the ID of the request set using ResolveOptions.RequestID or (*Ctx).SetRequestID, empty if none is set
+func (ctx *Ctx) RequestID() string {
+	return ctx.requestID
+}
+
+// SetRequestID sets the ID of the request, the ID is added to the extensions of every error in the response and to the RequestInfo of the request logger
+// This allows correlating an error reported by a user with the server logs and traces
+func (ctx *Ctx) SetRequestID(id string) {
+	ctx.requestID = id
+}
+
+// OperationName returns the name of the operation that is executed, empty for anonymous operations or if the query is not parsed yet
+func (ctx *Ctx) OperationName() string {
+	_, name, _ := ctx.operation()
+	return name
+}
+
+// OperationType returns the type of the operation that is executed, query, mutation or subscription, empty if the query is not parsed yet
+// The extensions send by the client together with the operation can be read using (*Ctx).RequestExtensions
+func (ctx *Ctx) OperationType() string {
+	operationType, _, _ := ctx.operation()
+	return operationType
+}
+
+// Cookie returns the cookie with name send with the http request, returns http.ErrNoCookie if the cookie was not send
+func (ctx *Ctx) Cookie(name string) (*http.Cookie, error) {
+	return (&http.Request{Header: ctx.header}).Cookie(name)
+}
+
+// Cookies returns all cookies send with the http request
+func (ctx *Ctx) Cookies() []*http.Cookie {
+	return (&http.Request{Header: ctx.header}).Cookies()
+}
+
 // GetPath returns the graphql path to the current field json encoded
 func (ctx *Ctx) GetPath() json.RawMessage {
 	if len(ctx.path) == 0 {
@@ -130,6 +229,16 @@ func (ctx *Ctx) GetPath() json.RawMessage {
 	return append(append([]byte{'['}, ctx.path[1:]...), ']')
 }
 
+// Document returns the parsed query as an AST, this can be used to build custom validation rules, query cost analyzers or field logging
+// The document is created on the first call and reused within the operation
+// Returns nil if the query is not parsed yet or contains errors
+func (ctx *Ctx) Document() *ast.Document {
+	if ctx.document == nil && len(ctx.query.Res) != 0 && len(ctx.query.Errors) == 0 {
+		ctx.document = ast.FromBytecode(&ctx.query)
+	}
+	return ctx.document
+}
+
 func (ctx *Ctx) write(b []byte) {
 	ctx.schema.Result = append(ctx.schema.Result, b...)
 }
@@ -154,16 +263,32 @@ func (ctx *Ctx) writeNull() {
 type ResolveOptions struct {
 	NoMeta         bool            // Returns only the data
 	Context        context.Context // Request context
+	Header         http.Header     // Headers of the http request, see (*Ctx).Header
+	RemoteAddr     string          // Network address of the client, see (*Ctx).RemoteAddr
 	OperatorTarget string
 	Values         *map[string]interface{}                         // Passed directly to the request context
 	GetFormFile    func(key string) (*multipart.FileHeader, error) // Get form file to support file uploading
+	GetUpload      func(key string) (*Upload, error)               // Get a streamed form file for *yarql.Upload inputs, if nil GetFormFile is used
+	MaxFileSize    int64                                           // Max size in bytes of a uploaded file, 0 disables the check
+	MaxUploadSize  int64                                           // Max size in bytes of all uploaded files together, 0 disables the check
 	Variables      string                                          // Expects valid JSON or empty string
 	Tracing        bool                                            // https://github.com/apollographql/apollo-tracing
+	MaxComplexity  int                                             // Rejects queries with a higher complexity before executing them, 0 disables the check
+	Extensions     string                                          // The extensions send by the client, expects valid JSON or empty string
+	Authorizer     Authorizer                                      // Checks the @auth directives of fields and types, see (*Ctx).SetAuthorizer
+	RequestID      string                                          // Added to the extensions of errors and the request logger, see (*Ctx).SetRequestID
+
+	batchLoaders *batchLoaders // loader caches shared between the operations of a batched request
 }
 
 // Resolve resolves a query and returns errors if any
 // The result json is written to (*Schema).Result
 func (s *Schema) Resolve(query []byte, opts ResolveOptions) []error {
+	return s.resolve(query, nil, opts)
+}
+
+// resolve resolves a query, if prepared is set the query is not parsed but the bytecode of prepared is used
+func (s *Schema) resolve(query []byte, prepared *PreparedQuery, opts ResolveOptions) []error {
 	if !s.parsed {
 		fmt.Println("CALL (*yarql.Schema).Parse() before resolve")
 		return []error{errors.New("invalid setup")}
@@ -171,44 +296,112 @@ func (s *Schema) Resolve(query []byte, opts ResolveOptions) []error {
 
 	s.Result = s.Result[:0]
 
+	var start time.Time
+	if s.requestLogger != nil {
+		start = time.Now()
+	}
+
 	ctx := s.ctx
 	*ctx = Ctx{
 		schema:                 ctx.schema,
 		query:                  ctx.query,
 		charNr:                 0,
+		fieldAt:                -1,
 		context:                nil,
+		header:                 opts.Header,
+		remoteAddr:             opts.RemoteAddr,
+		requestID:              opts.RequestID,
+		authorizer:             opts.Authorizer,
+		cancelled:              false,
+		resultTooLarge:         false,
+		listItems:              0,
+		executed:               false,
 		path:                   ctx.path[:0],
 		getFormFile:            opts.GetFormFile,
+		getUpload:              opts.GetUpload,
+		maxFileSize:            opts.MaxFileSize,
+		maxUploadSize:          opts.MaxUploadSize,
+		uploadedSize:           0,
+		uploadClosers:          ctx.uploadClosers,
 		rawVariables:           opts.Variables,
+		rawExtensions:          opts.Extensions,
 		variablesParsed:        false,
 		variablesJSONParser:    ctx.variablesJSONParser,
 		variables:              ctx.variables,
 		tracingEnabled:         opts.Tracing,
 		tracing:                ctx.tracing,
 		prefRecordingStartTime: ctx.prefRecordingStartTime,
+		owner:                  "",
+		field:                  nil,
+		mocking:                ctx.schema.mockResolvers,
 		ctxReflection:          ctx.ctxReflection,
 
 		reflectValues:          ctx.reflectValues,
 		currentReflectValueIdx: 0,
 		funcInputs:             ctx.funcInputs,
+		methodInputs:           ctx.methodInputs,
+		memoized:               ctx.memoized,
+		memoKeys:               ctx.memoKeys[:0],
+		requestStates:          ctx.requestStates,
+		usedDirectives:         ctx.usedDirectives[:0],
+		mergeFields:            ctx.mergeFields[:0],
+		mergeArgs:              ctx.mergeArgs[:0],
+		argumentNames:          ctx.argumentNames[:0],
+		responseExtensions:     ctx.responseExtensions[:0],
+		batchLoaders:           opts.batchLoaders,
+		deferredResult:         ctx.deferredResult[:0],
 
 		values: opts.Values,
 	}
+	for key := range ctx.memoized {
+		delete(ctx.memoized, key)
+	}
+	for key := range ctx.requestStates {
+		delete(ctx.requestStates, key)
+	}
 	if opts.Tracing {
 		ctx.tracing.reset()
 	}
 	if opts.Context != nil {
 		ctx.context = &opts.Context
 	}
+	if ctx.schema.injector != nil {
+		ctx.schema.injector(ctx)
+	}
 	ctx.startTrace()
 
-	ctx.query.Query = append(ctx.query.Query[:0], query...)
+	// Forget the previous query so (*Ctx).Document does not return it within the ParseStart hook
+	ctx.query.Res = ctx.query.Res[:0]
+	parsedQuery, extensionErr := ctx.extensionsParseStart(query)
+	if prepared == nil {
+		query = parsedQuery
+	}
 
-	if len(opts.OperatorTarget) > 0 {
+	ctx.query.Query = append(ctx.query.Query[:0], query...)
+	ctx.query.MaxAliases = ctx.schema.MaxAliases
+	ctx.query.MaxRootFields = ctx.schema.MaxRootFields
+	ctx.query.MaxQueryLength = ctx.schema.MaxQueryLength
+	ctx.query.MaxTokens = ctx.schema.MaxTokens
+
+	if extensionErr != nil {
+		// An extension rejected the query, do not parse it
+		ctx.query.Res = ctx.query.Res[:0]
+		ctx.query.TargetIdx = -1
+		ctx.query.Errors = append(ctx.query.Errors[:0], extensionErr)
+	} else if prepared != nil {
+		ctx.query.Res = append(ctx.query.Res[:0], prepared.res...)
+		ctx.query.FragmentLocations = append(ctx.query.FragmentLocations[:0], prepared.fragmentLocations...)
+		ctx.query.FieldLocations = append(ctx.query.FieldLocations[:0], prepared.fieldLocations...)
+		ctx.query.TargetIdx = prepared.targetIdx
+		ctx.query.Errors = ctx.query.Errors[:0]
+	} else if len(opts.OperatorTarget) > 0 {
 		ctx.query.ParseQueryToBytecode(&opts.OperatorTarget)
 	} else {
 		ctx.query.ParseQueryToBytecode(nil)
 	}
+	for _, extension := range ctx.schema.extensions {
+		extension.ParseEnd(ctx, ctx.query.Errors)
+	}
 
 	if ctx.tracingEnabled {
 		// finish parsing trace
@@ -225,7 +418,8 @@ func (s *Schema) Resolve(query []byte, opts ResolveOptions) []error {
 		ctx.write([]byte(`{"data":`))
 	}
 
-	if len(ctx.query.Errors) == 0 {
+	parsed := len(ctx.query.Errors) == 0
+	if parsed {
 		ctx.charNr = ctx.query.TargetIdx
 		if ctx.charNr == -1 {
 			ctx.write([]byte("{}"))
@@ -234,21 +428,37 @@ func (s *Schema) Resolve(query []byte, opts ResolveOptions) []error {
 			} else {
 				ctx.err("no operator found")
 			}
+		} else if ctx.extensionsValidate() {
+			ctx.write([]byte("{}"))
+		} else if ctx.schema.rateLimiter != nil && ctx.checkRateLimit() {
+			ctx.write([]byte("{}"))
+		} else if cached, ok := ctx.precomputedResult(); ok {
+			ctx.executionStart()
+			dataStart := len(ctx.schema.Result)
+			ctx.write(cached)
+			ctx.dropTooLargeResult(dataStart)
+			ctx.executionEnd()
+		} else if ctx.checkFieldMerging() || ctx.checkArguments() {
+			ctx.write([]byte("{}"))
+		} else if opts.MaxComplexity > 0 && ctx.checkComplexity(opts.MaxComplexity) {
+			ctx.write([]byte("{}"))
 		} else {
+			ctx.executionStart()
+			dataStart := len(ctx.schema.Result)
 			ctx.writeByte('{')
 			ctx.resolveOperation()
 			ctx.writeByte('}')
+			ctx.dropTooLargeResult(dataStart)
+			ctx.executionEnd()
 		}
 	} else {
 		ctx.write([]byte("{}"))
 	}
 
 	if !opts.NoMeta {
-		// TODO support custom extensions
-
 		// Add errors to output
 		errsLen := len(ctx.query.Errors)
-		if errsLen == 0 && !ctx.tracingEnabled {
+		if errsLen == 0 && !ctx.tracingEnabled && len(ctx.responseExtensions) == 0 {
 			ctx.write([]byte(`}`))
 		} else {
 			if errsLen != 0 {
@@ -268,19 +478,19 @@ func (s *Schema) Resolve(query []byte, opts ResolveOptions) []error {
 					}
 					errWLocation, isErrWLocation := err.(bytecode.ErrorWLocation)
 					if isErrWLocation {
-						ctx.write([]byte(`,"locations":[{"line":`))
-						ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(errWLocation.Line), 10)
-						ctx.write([]byte(`,"column":`))
-						ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(errWLocation.Column), 10)
-						ctx.write([]byte{'}', ']'})
+						ctx.writeErrLocation(errWLocation.Line, errWLocation.Column)
+					} else if isErrWPath && errWPath.line > 0 {
+						ctx.writeErrLocation(errWPath.line, errWPath.column)
 					}
+					ctx.writeErrExtensions(err, errWPath.owner)
 					ctx.writeByte('}')
 				}
 				ctx.writeByte(']')
 			}
 
+			ctx.write([]byte(`,"extensions":{`))
 			if ctx.tracingEnabled {
-				ctx.write([]byte(`,"extensions":{"tracing":`))
+				ctx.write([]byte(`"tracing":`))
 				ctx.tracing.finish()
 				tracingJSON, err := json.Marshal(ctx.tracing)
 				if err == nil {
@@ -288,13 +498,27 @@ func (s *Schema) Resolve(query []byte, opts ResolveOptions) []error {
 				} else {
 					ctx.writeNull()
 				}
-				ctx.write([]byte{'}', '}'})
-			} else {
-				ctx.write([]byte(`,"extensions":{}}`))
 			}
+			for i, extension := range ctx.responseExtensions {
+				if i > 0 || ctx.tracingEnabled {
+					ctx.writeByte(',')
+				}
+				helpers.StringToJSON(extension.key, &ctx.schema.Result)
+				ctx.writeByte(':')
+				ctx.write(extension.value)
+			}
+			ctx.write([]byte{'}', '}'})
 		}
 	}
 
+	ctx.closeUploads()
+	for _, extension := range ctx.schema.extensions {
+		extension.ResponseEnd(ctx, ctx.schema.Result, ctx.query.Errors)
+	}
+	if s.requestLogger != nil {
+		ctx.logRequest(start, parsed)
+	}
+
 	return ctx.query.Errors
 }
 
@@ -319,30 +543,179 @@ func (ctx *Ctx) lastInst() byte {
 
 // ErrorWPath is an error mesage with a graphql path to the field that created the error
 type ErrorWPath struct {
-	err  error
-	path []byte // a json representation of the path without the [] around it
+	err    error
+	path   []byte // a json representation of the path without the [] around it
+	owner  string // owner of the field that created the error
+	line   uint   // location of the field that created the error in the query, 0 if unknown
+	column uint
 }
 
 func (e ErrorWPath) Error() string {
 	return e.err.Error()
 }
 
+// Owner returns the owner of the field that created the error
+func (e ErrorWPath) Owner() string {
+	return e.owner
+}
+
+// Path returns the response path of the field that created the error as JSON array, for example ["users",3,"name"]
+func (e ErrorWPath) Path() json.RawMessage {
+	return append(append([]byte{'['}, e.path...), ']')
+}
+
+// Location returns the line and column of the field that created the error, both are 0 if the location is unknown
+func (e ErrorWPath) Location() (line uint, column uint) {
+	return e.line, e.column
+}
+
+// Unwrap returns the underlaying error
+func (e ErrorWPath) Unwrap() error {
+	return e.err
+}
+
 func (ctx *Ctx) err(msg string) bool {
-	err := errors.New(msg)
+	return ctx.addErr(errors.New(msg))
+}
+
+// ErrorPresenter is called for every error returned by resolvers before it's added to the response
+// The returned error is added to the response instead, if nil is returned the error is left out of the response
+type ErrorPresenter func(ctx *Ctx, err error) error
+
+// SetErrorPresenter sets a presenter that can modify the errors of resolvers, for example to hide the details of internal errors
+func (s *Schema) SetErrorPresenter(presenter ErrorPresenter) {
+	s.errorPresenter = presenter
+}
+
+// resolverErr adds an error returned by a resolver to the response using the error presenter
+func (ctx *Ctx) resolverErr(err error) {
+	if ctx.collectingLoads && errors.Is(err, errLoadCollected) {
+		// The field is deferred and resolved again once the collected keys are loaded
+		return
+	}
+	if ctx.schema.errorPresenter != nil {
+		err = ctx.schema.errorPresenter(ctx, err)
+		if err == nil {
+			return
+		}
+	}
+	ctx.addErr(err)
+}
+
+// AddError adds err to the response without failing the field that is currently being resolved
+// The path segments are appended to the path of the field, use an int for a list index and a string for a field name
+// This allows resolvers that resolve many children at once to attach errors to the children
+// The error presenter is used, see (*yarql.Schema).SetErrorPresenter
+//
+// Example:
+//
+//	// Adds an error with the path ["users",3,"email"]
+//	ctx.AddError(errors.New("email not verified"), 3, "email")
+func (ctx *Ctx) AddError(err error, path ...interface{}) {
+	if err == nil {
+		return
+	}
+
+	prefPathLen := len(ctx.path)
+	for _, segment := range path {
+		ctx.path = append(ctx.path, ',')
+		switch segment := segment.(type) {
+		case int:
+			ctx.path = strconv.AppendInt(ctx.path, int64(segment), 10)
+		case string:
+			helpers.StringToJSON(segment, &ctx.path)
+		default:
+			ctx.path = ctx.path[:prefPathLen]
+			panic(fmt.Sprintf("path segments must be an int or string but got %T", segment))
+		}
+	}
+	ctx.resolverErr(err)
+	ctx.path = ctx.path[:prefPathLen]
+}
+
+func (ctx *Ctx) addErr(err error) bool {
 	if len(ctx.path) == 0 {
 		ctx.query.Errors = append(ctx.query.Errors, err)
 	} else {
 		copiedPath := make([]byte, len(ctx.path)-1)
 		copy(copiedPath, ctx.path[1:])
 
+		line, column, _ := ctx.query.FieldLocationOf(ctx.fieldAt)
 		ctx.query.Errors = append(ctx.query.Errors, ErrorWPath{
-			err:  err,
-			path: copiedPath,
+			err:    err,
+			path:   copiedPath,
+			owner:  ctx.owner,
+			line:   line,
+			column: column,
 		})
 	}
 	return true
 }
 
+// addErrAtField adds a error with the location of the field or fragment spread at fieldAt, used for errors found before executing the query
+func (ctx *Ctx) addErrAtField(fieldAt int, err error) bool {
+	line, column, ok := ctx.query.FieldLocationOf(fieldAt)
+	if !ok {
+		return ctx.addErr(err)
+	}
+	ctx.query.Errors = append(ctx.query.Errors, bytecode.ErrorWLocation{
+		Err:    err,
+		Line:   line,
+		Column: column,
+	})
+	return true
+}
+
+// writeErrExtensions writes the extensions of a error in the response if the error has any
+func (ctx *Ctx) writeErrExtensions(err error, owner string) {
+	code := ""
+	var rateLimitErr *RateLimitError
+	var authErr *AuthError
+	if errors.As(err, &rateLimitErr) {
+		code = "RATE_LIMITED"
+	} else if errors.As(err, &authErr) {
+		code = authErr.Code
+	}
+	if len(owner) == 0 && len(code) == 0 && len(ctx.requestID) == 0 {
+		return
+	}
+
+	ctx.write([]byte(`,"extensions":{`))
+	isFirst := true
+	writeKey := func(key string) {
+		if !isFirst {
+			ctx.writeByte(',')
+		}
+		isFirst = false
+		ctx.write([]byte(key))
+	}
+	if len(owner) > 0 {
+		writeKey(`"owner":`)
+		helpers.StringToJSON(owner, &ctx.schema.Result)
+	}
+	if len(code) > 0 {
+		writeKey(`"code":`)
+		helpers.StringToJSON(code, &ctx.schema.Result)
+	}
+	if rateLimitErr != nil && rateLimitErr.RetryAfter > 0 {
+		writeKey(`"retryAfter":`)
+		ctx.schema.Result = strconv.AppendInt(ctx.schema.Result, int64((rateLimitErr.RetryAfter+time.Second-1)/time.Second), 10)
+	}
+	if len(ctx.requestID) > 0 {
+		writeKey(`"requestId":`)
+		helpers.StringToJSON(ctx.requestID, &ctx.schema.Result)
+	}
+	ctx.writeByte('}')
+}
+
+func (ctx *Ctx) writeErrLocation(line, column uint) {
+	ctx.write([]byte(`,"locations":[{"line":`))
+	ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(line), 10)
+	ctx.write([]byte(`,"column":`))
+	ctx.schema.Result = strconv.AppendUint(ctx.schema.Result, uint64(column), 10)
+	ctx.write([]byte{'}', ']'})
+}
+
 func (ctx *Ctx) errf(msg string, args ...interface{}) bool {
 	return ctx.err(fmt.Sprintf(msg, args...))
 }
@@ -356,23 +729,58 @@ func (ctx *Ctx) readUint32(startAt int) uint32 {
 }
 
 func (ctx *Ctx) resolveOperation() bool {
+	kind, root, criticalErr := ctx.readOperation()
+	if criticalErr {
+		return criticalErr
+	}
+	criticalErr = ctx.resolveVariableDirectives()
+	if criticalErr {
+		return criticalErr
+	}
+
+	if ctx.schema.executionStrategy != nil {
+		return ctx.executeStrategy(kind, root)
+	}
+
+	firstField := true
+	if kind != bytecode.OperatorQuery || len(ctx.schema.loaders) == 0 {
+		return ctx.resolveSelectionSet(root, 0, &firstField)
+	}
+
+	// Mutations are resolved one after another so only the loads of queries are collected
+	ctx.collectingLoads = true
+	criticalErr = ctx.resolveSelectionSet(root, 0, &firstField)
+	for len(ctx.deferredFields) > 0 {
+		if ctx.resolveDeferredFields() {
+			criticalErr = true
+		}
+	}
+	ctx.collectingLoads = false
+	return criticalErr
+}
+
+// readOperation reads the operation header and returns the root type of the operation
+// After calling this the charNr is at the start of the operation's selection set
+func (ctx *Ctx) readOperation() (kind bytecode.OperatorKind, root *obj, criticalErr bool) {
 	ctx.charNr += 2 // read 0, [ActionOperator], [kind]
 
-	kind := ctx.readInst()
+	kind = ctx.readInst()
 	switch kind {
 	case bytecode.OperatorQuery:
 		ctx.reflectValues[0] = ctx.schema.rootQueryValue
+		root = ctx.schema.rootQuery
 	case bytecode.OperatorMutation:
 		ctx.reflectValues[0] = ctx.schema.rootMethodValue
+		root = ctx.schema.rootMethod
 	case bytecode.OperatorSubscription:
-		return ctx.err("subscriptions are not supported")
+		return kind, nil, ctx.err("subscriptions are not supported")
 	}
 
 	ctx.operatorHasArguments = ctx.readInst() == 't'
 	directivesCount := ctx.readInst()
 	if directivesCount > 0 {
 		// TODO
-		return ctx.err("operation directives unsupported")
+		return kind, nil, ctx.err("operation directives unsupported")
 	}
 
 	for {
@@ -390,11 +798,7 @@ func (ctx *Ctx) resolveOperation() bool {
 		ctx.skipInst(int(argumentsLen) + 5)
 	}
 
-	firstField := true
-	if kind == bytecode.OperatorMutation {
-		return ctx.resolveSelectionSet(ctx.schema.rootMethod, 0, &firstField)
-	}
-	return ctx.resolveSelectionSet(ctx.schema.rootQuery, 0, &firstField)
+	return kind, root, false
 }
 
 func (ctx *Ctx) resolveSelectionSet(typeObj *obj, dept uint8, firstField *bool) bool {
@@ -424,6 +828,13 @@ func (ctx *Ctx) resolveSelectionSet(typeObj *obj, dept uint8, firstField *bool)
 }
 
 func (ctx *Ctx) resolveSpread(typeObj *obj, dept uint8, firstField *bool) bool {
+	return ctx.walkSpread(typeObj, func() bool {
+		return ctx.resolveSelectionSet(typeObj, dept, firstField)
+	})
+}
+
+// walkSpread calls onSelectionSet with the charNr at the start of the fragment's selection set if the fragment applies to typeObj
+func (ctx *Ctx) walkSpread(typeObj *obj, onSelectionSet func() bool) bool {
 	isInline := ctx.readInst() == 't'
 	directivesCount := ctx.readInst()
 
@@ -439,7 +850,6 @@ func (ctx *Ctx) resolveSpread(typeObj *obj, dept uint8, firstField *bool) bool {
 			break
 		}
 	}
-	nameLen := endName - nameStart
 	name := ctx.query.Res[nameStart:endName]
 
 	if directivesCount != 0 {
@@ -448,6 +858,7 @@ func (ctx *Ctx) resolveSpread(typeObj *obj, dept uint8, firstField *bool) bool {
 			location = DirectiveLocationFragmentInline
 		}
 
+		ctx.usedDirectives = ctx.usedDirectives[:0]
 		for i := uint8(0); i < directivesCount; i++ {
 			modifer, criticalErr := ctx.resolveDirective(location)
 			if criticalErr || modifer.Skip {
@@ -463,50 +874,54 @@ func (ctx *Ctx) resolveSpread(typeObj *obj, dept uint8, firstField *bool) bool {
 			return false
 		}
 
-		criticalErr := ctx.resolveSelectionSet(typeObj, dept, firstField)
+		criticalErr := onSelectionSet()
 		ctx.charNr++
 		return criticalErr
 	}
 
-	ctxQueryResLen := len(ctx.query.Res)
+	typeName, selectionSetAt, ok := ctx.findFragment(name)
+	if !ok {
+		return ctx.err("fragment " + b2s(name) + " not defined")
+	}
+	if !bytes.Equal(typeObj.typeNameBytes, typeName) {
+		ctx.charNr = nameStart + int(lenOfDirective) + 1
+		return false
+	}
 
+	originalCharNr := ctx.charNr
+	ctx.charNr = selectionSetAt
+	criticalErr := onSelectionSet()
+	ctx.charNr = originalCharNr
+	return criticalErr
+}
+
+// findFragment looks up the fragment definition with name
+// Returns the type condition of the fragment and the location of its selection set, ok is false if the query has no fragment with name
+func (ctx *Ctx) findFragment(name []byte) (typeName []byte, selectionSetAt int, ok bool) {
+	res := ctx.query.Res
 	for _, location := range ctx.query.FragmentLocations {
 		fragmentNameStart := location + 1
-		fragmentNameEnd := fragmentNameStart + nameLen
-		if fragmentNameEnd >= ctxQueryResLen {
+		fragmentNameEnd := fragmentNameStart + len(name)
+		if fragmentNameEnd >= len(res) || res[fragmentNameEnd] != 0 || !bytes.Equal(res[fragmentNameStart:fragmentNameEnd], name) {
 			continue
 		}
-		if bytes.Equal(ctx.query.Res[fragmentNameStart:fragmentNameEnd], name) {
-			originalCharNr := ctx.charNr
-			ctx.charNr = fragmentNameEnd + 1
-
-			// Read the type
-			typeNameStart := ctx.charNr
-			var typeNameEnd int
-			for {
-				if ctx.readInst() == 0 {
-					typeNameEnd = ctx.charNr - 1
-					break
-				}
-			}
 
-			if !bytes.Equal(typeObj.typeNameBytes, ctx.query.Res[typeNameStart:typeNameEnd]) {
-				ctx.charNr = nameStart + int(lenOfDirective) + 1
-				return false
-			}
-
-			criticalErr := ctx.resolveSelectionSet(typeObj, dept, firstField)
-			ctx.charNr = originalCharNr
-			return criticalErr
+		typeNameStart := fragmentNameEnd + 1
+		typeNameEnd := typeNameStart
+		for res[typeNameEnd] != 0 {
+			typeNameEnd++
 		}
+		return res[typeNameStart:typeNameEnd], typeNameEnd + 1, true
 	}
-
-	return ctx.err("fragment " + b2s(name) + " not defined")
+	return nil, 0, false
 }
 
 func (ctx *Ctx) resolveField(typeObj *obj, dept uint8, addCommaBefore bool) (skipped bool, criticalErr bool) {
 	ctx.startTrace()
 
+	prefFieldAt := ctx.fieldAt
+	ctx.fieldAt = ctx.charNr - 1
+
 	directivesCount := ctx.readInst()
 
 	fieldLen := ctx.readUint32(ctx.charNr)
@@ -540,18 +955,25 @@ func (ctx *Ctx) resolveField(typeObj *obj, dept uint8, addCommaBefore bool) (ski
 	}
 	ctx.skipInst(1)
 
+	var mock *DirectiveModifier
 	if directivesCount != 0 {
+		ctx.usedDirectives = ctx.usedDirectives[:0]
 		for i := uint8(0); i < directivesCount; i++ {
 			modifier, criticalErr := ctx.resolveDirective(DirectiveLocationField)
 
 			if criticalErr || modifier.Skip {
 				// Restore the path
 				ctx.path = ctx.path[:prefPathLen]
+				ctx.fieldAt = prefFieldAt
 				ctx.charNr = endOfField + 1
 
 				return true, criticalErr
 			}
 
+			if modifier.Mock {
+				mock = &modifier
+			}
+
 			// TODO
 			// if modifier.ModifyOnWriteContent != nil {
 			// 	contentModifiers = append(contentModifiers, modifier.ModifyOnWriteContent)
@@ -559,6 +981,8 @@ func (ctx *Ctx) resolveField(typeObj *obj, dept uint8, addCommaBefore bool) (ski
 		}
 	}
 
+	fieldStart := len(ctx.schema.Result)
+	errorsCount := len(ctx.query.Errors)
 	if addCommaBefore {
 		ctx.writeByte(',')
 	}
@@ -568,10 +992,14 @@ func (ctx *Ctx) resolveField(typeObj *obj, dept uint8, addCommaBefore bool) (ski
 
 	fieldHasSelection := ctx.seekInst() != 'e'
 
-	typeObjField, ok := typeObj.objContents[nameKey]
+	typeObjField, ok := ctx.selectableField(typeObj, nameKey, ctx.query.Res[startOfName:endOfName])
+
 	if !ok {
 		name := b2s(ctx.query.Res[startOfName:endOfName])
-		if name == "__typename" {
+		if ctx.isCancelled() {
+			// The request was cancelled, stop resolving fields
+			ctx.writeNull()
+		} else if name == "__typename" {
 			if fieldHasSelection {
 				criticalErr = ctx.err("cannot have a selection set on this field")
 			} else {
@@ -581,22 +1009,28 @@ func (ctx *Ctx) resolveField(typeObj *obj, dept uint8, addCommaBefore bool) (ski
 			ctx.writeNull()
 			criticalErr = ctx.errf("%s does not exists on %s", name, typeObj.typeName)
 		}
+	} else if !ctx.fieldAuthorized(typeObjField) {
+		ctx.writeNull()
 	} else {
-		goValue := ctx.getGoValue()
-		if typeObjField.customObjValue != nil {
-			ctx.setNextGoValue(*typeObjField.customObjValue)
-		} else {
-			name := string(typeObjField.qlFieldName)
-			name = strings.ToUpper(name[:1]) + name[1:]
-			if typeObjField.valueType == valueTypeMethod && typeObjField.method.isTypeMethod {
-				ctx.setNextGoValue(goValue.MethodByName("Resolve" + name))
-			} else {
-				ctx.setNextGoValue(goValue.FieldByName(name))
-			}
-		}
+		criticalErr = ctx.resolveWrappedField(&wrappedField{
+			parent:        typeObj,
+			field:         typeObjField,
+			name:          ctx.query.Res[startOfName:endOfName],
+			alias:         alias,
+			dept:          dept,
+			hasSelection:  fieldHasSelection,
+			mock:          mock,
+			parentPathLen: prefPathLen,
+			endOfField:    endOfField,
+		})
 
-		criticalErr = ctx.resolveFieldDataValue(typeObjField, dept, fieldHasSelection)
-		ctx.currentReflectValueIdx--
+		if ctx.loadPending {
+			ctx.deferField(typeObj, dept, addCommaBefore, fieldStart, errorsCount, prefPathLen)
+			ctx.path = ctx.path[:prefPathLen]
+			ctx.fieldAt = prefFieldAt
+			ctx.charNr = endOfField + 1
+			return false, false
+		}
 
 		if ctx.tracingEnabled {
 			name := b2s(ctx.query.Res[startOfName:endOfName])
@@ -610,6 +1044,7 @@ func (ctx *Ctx) resolveField(typeObj *obj, dept uint8, addCommaBefore bool) (ski
 					ParentType:  typeObj.typeName,
 					FieldName:   name,
 					ReturnType:  returnType.String(),
+					Owner:       typeObjField.ownerWithin(typeObj),
 					StartOffset: offset,
 					Duration:    duration,
 				})
@@ -619,19 +1054,337 @@ func (ctx *Ctx) resolveField(typeObj *obj, dept uint8, addCommaBefore bool) (ski
 
 	// Restore the path
 	ctx.path = ctx.path[:prefPathLen]
+	ctx.fieldAt = prefFieldAt
 
 	ctx.charNr = endOfField + 1
 
 	return false, criticalErr
 }
 
+// wrappedField is a field that is resolved by (*Ctx).resolveWrappedField
+type wrappedField struct {
+	parent        *obj
+	field         *obj
+	name          []byte
+	alias         []byte
+	dept          uint8
+	hasSelection  bool
+	mock          *DirectiveModifier
+	parentPathLen int // length of ctx.path without this field
+	endOfField    int
+
+	// value is the go value of the field if it's given by an ExecutionStrategy,
+	// otherwise the field is read from the current go value
+	value    reflect.Value
+	hasValue bool
+}
+
+// resolveWrappedField writes the value of an authorized field to the response
+// The field is wrapped in what applies to every field: the introspection limits, cancellation, mocked values, memoization, the owner of the field and the middleware
+// Both the default executor and (*Engine).ResolveValue resolve fields using this
+// Expects ctx.path to contain the field and ctx.charNr to be at the arguments of the field
+func (ctx *Ctx) resolveWrappedField(f *wrappedField) (criticalErr bool) {
+	prefIntrospecting := ctx.introspecting
+	if ctx.introspecting {
+		criticalErr = ctx.checkIntrospectionLimits(f.dept - ctx.introspectionDept)
+	} else if f.parent == ctx.schema.rootQuery && isIntrospectionField(f.field) {
+		ctx.introspecting = true
+		ctx.introspectionDept = f.dept
+	}
+
+	if criticalErr {
+		ctx.writeNull()
+	} else if ctx.isCancelled() {
+		// The request was cancelled, stop resolving fields
+		ctx.writeNull()
+	} else if f.mock != nil && len(f.mock.MockValue) > 0 {
+		criticalErr = ctx.writeMockValue(f.mock.MockValue)
+	} else {
+		// Mocked values are generated so they are never memoized
+		memoize := f.field.memoize && f.mock == nil && !ctx.mocking && !ctx.collectingLoads
+		var memoKeyStart int
+		if memoize {
+			var written bool
+			written, memoKeyStart = ctx.writeMemoized(f.parentPathLen, f.name, f.endOfField)
+			if written {
+				ctx.introspecting = prefIntrospecting
+				return false
+			}
+		}
+		resultStart := len(ctx.schema.Result)
+		valueErrorsCount := len(ctx.query.Errors)
+
+		prefOwner := ctx.owner
+		ctx.owner = f.field.ownerWithin(f.parent)
+		prefField := ctx.field
+		ctx.field = f.field
+
+		if ctx.schema.fieldResolver != nil {
+			criticalErr = ctx.resolveFieldWithMiddleware(f)
+		} else {
+			criticalErr = ctx.resolveWrappedFieldValue(f)
+		}
+		ctx.owner = prefOwner
+		ctx.field = prefField
+
+		if memoize {
+			ctx.memoize(memoKeyStart, resultStart, !criticalErr && valueErrorsCount == len(ctx.query.Errors))
+		}
+	}
+
+	ctx.introspecting = prefIntrospecting
+	return criticalErr
+}
+
+// resolveWrappedFieldValue writes the value of f to the response
+func (ctx *Ctx) resolveWrappedFieldValue(f *wrappedField) bool {
+	if !f.hasValue || f.mock != nil || (ctx.mocking && !ctx.introspecting) {
+		return ctx.resolveFieldValue(f.field, f.dept, f.hasSelection, f.mock)
+	}
+	if !f.value.IsValid() {
+		ctx.writeNull()
+		return false
+	}
+
+	ctx.setNextGoValue(f.value)
+	criticalErr := ctx.resolveFieldDataValue(f.field, f.dept, f.hasSelection)
+	ctx.currentReflectValueIdx--
+	return criticalErr
+}
+
+// resolveFieldValue writes the value of field to the response
+func (ctx *Ctx) resolveFieldValue(field *obj, dept uint8, fieldHasSelection bool, mock *DirectiveModifier) bool {
+	if field.customResolver != nil {
+		return field.customResolver(ctx, dept, fieldHasSelection)
+	}
+
+	if mock != nil || (ctx.mocking && !ctx.introspecting) {
+		// Mocked fields have no go values so we do not read them and skip the arguments
+		ctx.skipArguments()
+		fieldHasSelection = ctx.seekInst() != 'e'
+
+		prefMocking := ctx.mocking
+		ctx.mocking = true
+		criticalErr := ctx.resolveMockValue(field, dept, fieldHasSelection)
+		ctx.mocking = prefMocking
+		return criticalErr
+	}
+
+	goValue := ctx.getGoValue()
+	if field.embeddedPtr != nil && nilEmbeddedPtr(goValue, field.embeddedPtr) {
+		ctx.writeNull()
+		return false
+	}
+	if field.customObjValue != nil {
+		ctx.setNextGoValue(*field.customObjValue)
+	} else if field.valueType == valueTypeMethod && field.method.isTypeMethod {
+		if field.method.binding != nil && goValue.CanAddr() {
+			// The method is called by callQlMethod using the binding, the parent is kept as go value for the tracer and errors
+			ctx.boundParent = unsafe.Pointer(goValue.UnsafeAddr())
+			ctx.setNextGoValue(goValue)
+		} else {
+			ctx.setNextGoValue(typeMethod(goValue, field.method))
+		}
+	} else if unsafeFieldOffsets && field.hasStructFieldOffset && !fieldHasSelection && goValue.CanAddr() && ctx.seekInst() != bytecode.ActionValue && ctx.writeDataAtOffset(field, unsafe.Pointer(goValue.UnsafeAddr())) {
+		// The value is written directly, fields with arguments or a selection set are resolved below to report the error
+		return false
+	} else if field.fieldBinding != nil && goValue.CanAddr() {
+		ctx.setNextGoValue(reflect.NewAt(field.goFieldType, field.fieldBinding(unsafe.Pointer(goValue.UnsafeAddr()))).Elem())
+	} else {
+		ctx.setNextGoValue(structField(goValue, field))
+	}
+
+	criticalErr := ctx.resolveFieldDataValue(field, dept, fieldHasSelection)
+	ctx.boundParent = nil
+	ctx.currentReflectValueIdx--
+	return criticalErr
+}
+
+// isCancelled returns true if the request context is done or the result is too large
+// The error of the context is only reported once
+func (ctx *Ctx) isCancelled() bool {
+	if ctx.cancelled {
+		return true
+	}
+	if ctx.checkResultSize() {
+		return true
+	}
+	if ctx.context == nil {
+		return false
+	}
+	err := (*ctx.context).Err()
+	if err == nil {
+		return false
+	}
+	ctx.cancelled = true
+	ctx.err(err.Error())
+	return true
+}
+
+// checkListItems adds the items of a list to the amount of list items resolved within this request
+// It returns true and reports the error if this exceeds (*Schema).MaxListItems, lists within introspection fields are not counted
+func (ctx *Ctx) checkListItems(items int) bool {
+	max := ctx.schema.MaxListItems
+	if max <= 0 || ctx.introspecting {
+		return false
+	}
+	if ctx.cancelled {
+		return true
+	}
+	ctx.listItems += items
+	if ctx.listItems <= max {
+		return false
+	}
+	ctx.cancelled = true
+	ctx.err("response contains more than the max of " + strconv.Itoa(max) + " list items")
+	return true
+}
+
+// dropTooLargeResult replaces the data written since dataStart with an empty object if the result exceeds (*Schema).MaxResultSize
+func (ctx *Ctx) dropTooLargeResult(dataStart int) {
+	if ctx.checkResultSize() {
+		ctx.schema.Result = append(ctx.schema.Result[:dataStart], '{', '}')
+	}
+}
+
+// checkResultSize returns true and reports the error if the result exceeds (*Schema).MaxResultSize
+func (ctx *Ctx) checkResultSize() bool {
+	if ctx.resultTooLarge {
+		return true
+	}
+	max := ctx.schema.MaxResultSize
+	if max <= 0 || len(ctx.schema.Result) <= max {
+		return false
+	}
+	ctx.cancelled = true
+	ctx.resultTooLarge = true
+	ctx.err("response is larger than the max of " + strconv.Itoa(max) + " bytes")
+	return true
+}
+
+// structField returns the struct field of parent
+// Promoted fields are looked up by their index, if they are promoted through a nil embedded pointer the zero value of the field is returned
+func structField(parent reflect.Value, field *obj) reflect.Value {
+	if field.goFieldIndex == nil {
+		return parent.FieldByName(field.goFieldName)
+	}
+	value, err := parent.FieldByIndexErr(field.goFieldIndex)
+	if err != nil {
+		return reflect.Zero(parent.Type().FieldByIndex(field.goFieldIndex).Type)
+	}
+	return value
+}
+
+// nilEmbeddedPtr returns true if one of the embedded pointers up to ptrIndex within parent is nil
+func nilEmbeddedPtr(parent reflect.Value, ptrIndex []int) bool {
+	value, err := parent.FieldByIndexErr(ptrIndex)
+	return err != nil || value.IsNil()
+}
+
+// typeMethod returns the type method of parent
+// Methods with a pointer receiver are called on the address of parent, or on a copy of parent if it's not addressable
+func typeMethod(parent reflect.Value, method *objMethod) reflect.Value {
+	if method.ptrReceiver && parent.Kind() != reflect.Ptr {
+		if parent.CanAddr() {
+			parent = parent.Addr()
+		} else {
+			ptr := reflect.New(parent.Type())
+			ptr.Elem().Set(parent)
+			parent = ptr
+		}
+	}
+	return parent.MethodByName(method.goFunctionName)
+}
+
 func (ctx *Ctx) callQlMethod(method *objMethod, goValue *reflect.Value, parseArguments bool) ([]reflect.Value, bool) {
+	criticalErr := ctx.bindMethodInputs(method, parseArguments)
+	if criticalErr {
+		return nil, criticalErr
+	}
+
+	if method.parentInput {
+		ctx.funcInputs = append(ctx.funcInputs, reflect.Value{})
+		copy(ctx.funcInputs[1:], ctx.funcInputs)
+		ctx.funcInputs[0] = ctx.parentInput(method)
+	}
+
+	if ctx.boundParent != nil {
+		parent := ctx.boundParent
+		ctx.boundParent = nil
+		return ctx.callBinding(method.binding, parent), false
+	}
+
+	outs := ctx.callResolver(*goValue)
+	return outs, false
+}
+
+// PanicHandler is called with the recovered value and the stack trace when a resolver panics
+type PanicHandler func(ctx *Ctx, recovered interface{}, stack []byte)
+
+// SetPanicHandler sets a handler that is called when a resolver or directive method panics, for example to log the stack trace
+// Panics are always recovered and reported as error of the field, setting handler to nil only disables the handler
+func (s *Schema) SetPanicHandler(handler PanicHandler) {
+	s.panicHandler = handler
+}
+
+// callResolver calls fn with ctx.funcInputs and recovers panics
+// If fn panics the panic is reported as error and nil is returned
+func (ctx *Ctx) callResolver(fn reflect.Value) (outs []reflect.Value) {
+	defer ctx.recoverResolver(&outs)
+	return fn.Call(ctx.funcInputs)
+}
+
+// callBinding calls binding with parent and ctx.funcInputs and recovers panics like callResolver
+func (ctx *Ctx) callBinding(binding MethodBinding, parent unsafe.Pointer) (outs []reflect.Value) {
+	defer ctx.recoverResolver(&outs)
+	return binding(parent, ctx.funcInputs)
+}
+
+// recoverResolver reports a panic of a resolver as error and sets outs to nil, must be deferred
+func (ctx *Ctx) recoverResolver(outs *[]reflect.Value) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	*outs = nil
+	if ctx.loadPending {
+		// The resolver probably used the value of a pending load, it's called again once the value is loaded
+		return
+	}
+	if ctx.schema.panicHandler != nil {
+		ctx.schema.panicHandler(ctx, recovered, debug.Stack())
+	}
+	ctx.resolverErr(fmt.Errorf("resolver panicked: %v", recovered))
+}
+
+// methodInput returns a zero value of the input struct in
+// The value is reused for every call of the method within this Ctx so binding the arguments doesn't allocate a new struct per call
+// This is safe as resolvers receive a copy of the struct
+func (ctx *Ctx) methodInput(in *baseInput) reflect.Value {
+	if ctx.methodInputs == nil {
+		ctx.methodInputs = map[*baseInput]reflect.Value{}
+	}
+	value, ok := ctx.methodInputs[in]
+	if !ok {
+		value = reflect.New(*in.goType).Elem()
+		ctx.methodInputs[in] = value
+		return value
+	}
+	value.Set(reflect.Zero(*in.goType))
+	return value
+}
+
+// bindMethodInputs fills ctx.funcInputs with the inputs for method
+// If parseArguments is true the arguments at the current charNr are bound to the inputs
+func (ctx *Ctx) bindMethodInputs(method *objMethod, parseArguments bool) bool {
 	ctx.funcInputs = ctx.funcInputs[:0]
-	for _, in := range method.ins {
+	for i, in := range method.ins {
 		if in.isCtx {
 			ctx.funcInputs = append(ctx.funcInputs, ctx.ctxReflection)
+		} else if in.isContext {
+			ctx.funcInputs = append(ctx.funcInputs, reflect.ValueOf(ctx.Context()))
 		} else {
-			ctx.funcInputs = append(ctx.funcInputs, reflect.New(*in.goType).Elem())
+			ctx.funcInputs = append(ctx.funcInputs, ctx.methodInput(&method.ins[i]))
 		}
 	}
 
@@ -649,12 +1402,11 @@ func (ctx *Ctx) callQlMethod(method *objMethod, goValue *reflect.Value, parseArg
 			},
 		)
 		if criticalErr {
-			return nil, criticalErr
+			return criticalErr
 		}
 	}
 
-	outs := goValue.Call(ctx.funcInputs)
-	return outs, false
+	return false
 }
 
 func (ctx *Ctx) resolveDirective(location DirectiveLocation) (modifer DirectiveModifier, criticalErr bool) {
@@ -687,13 +1439,35 @@ func (ctx *Ctx) resolveDirective(location DirectiveLocation) (modifer DirectiveM
 	if foundDirective == nil {
 		return modifer, ctx.err("unknown directive " + directiveName)
 	}
+	if !foundDirective.Repeatable {
+		for _, used := range ctx.usedDirectives {
+			if used == foundDirective {
+				return modifer, ctx.err("directive " + directiveName + " can only be used once at this location")
+			}
+		}
+	}
+	ctx.usedDirectives = append(ctx.usedDirectives, foundDirective)
 	method := foundDirective.parsedMethod
 
-	outs, criticalErr := ctx.callQlMethod(method, &foundDirective.methodReflection, hasArguments)
+	criticalErr = ctx.bindMethodInputs(method, hasArguments)
 	if criticalErr {
 		return modifer, criticalErr
 	}
+	if method.fieldArgs && location == DirectiveLocationField {
+		criticalErr = ctx.bindDirectiveFieldArgs(method)
+		if criticalErr {
+			return modifer, criticalErr
+		}
+	}
 
+	outs := ctx.callResolver(foundDirective.methodReflection)
+	if outs == nil {
+		// The directive method panicked
+		return modifer, true
+	}
+	if len(outs) == 2 && !outs[1].IsNil() {
+		return modifer, ctx.err(outs[1].Interface().(error).Error())
+	}
 	modifer = outs[0].Interface().(DirectiveModifier)
 	return modifer, false
 }
@@ -718,17 +1492,27 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 	case valueTypeUndefined:
 		ctx.writeNull()
 	case valueTypeArray:
+		if typeObj.iterator != iteratorNone {
+			ctx.resolveIterator(typeObj, goValue, dept, hasSubSelection)
+			return false
+		}
+
 		// Using unsafe.Pointer(goValue.Pointer()) instead of goValue.isNil as it is faster
 		if goValue.Kind() == reflect.Slice && unsafe.Pointer(goValue.Pointer()) == nil {
 			ctx.writeNull()
 			return false
 		}
 
+		goValueLen := goValue.Len()
+		if ctx.checkListItems(goValueLen) {
+			ctx.writeNull()
+			return false
+		}
+
 		typeObj = typeObj.innerContent
 
 		ctx.writeByte('[')
 		ctx.currentReflectValueIdx++
-		goValueLen := goValue.Len()
 
 		startCharNr := ctx.charNr
 		for i := 0; i < goValueLen; i++ {
@@ -757,12 +1541,16 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 
 		var ok bool
 		if typeObj.valueType == valueTypeObjRef {
-			typeObj, ok = ctx.schema.types[typeObj.typeName]
+			typeObj, ok = ctx.schema.resolveRef(typeObj)
 			if !ok {
 				ctx.writeNull()
 				return false
 			}
 		}
+		if typeObj.auth != nil && !ctx.authorized(*typeObj.auth) {
+			ctx.writeNull()
+			return false
+		}
 
 		dept++
 		if dept == ctx.schema.MaxDepth {
@@ -781,11 +1569,15 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 			return ctx.err("cannot have a selection set on this field")
 		}
 
-		if typeObj.isID && typeObj.dataValueType != reflect.String {
-			// Graphql ID fields are always strings
+		if (typeObj.isID && typeObj.dataValueType != reflect.String) || typeObj.isInt64 {
+			// Graphql ID fields are always strings and Int64 values are strings so they are not rounded by JSON parsers
 			ctx.writeByte('"')
 			ctx.valueToJSON(goValue, typeObj.dataValueType)
 			ctx.writeByte('"')
+		} else if !typeObj.isID && typeObj.scalar == nil && intOverflows(goValue, typeObj.dataValueType) {
+			// The Int scalar is a signed 32-bit integer, see: https://spec.graphql.org/October2021/#sec-Int.Result-Coercion
+			ctx.writeNull()
+			ctx.addErr(errors.New("Int cannot represent non 32-bit signed integer value: " + intToString(goValue, typeObj.dataValueType)))
 		} else {
 			ctx.valueToJSON(goValue, typeObj.dataValueType)
 		}
@@ -808,6 +1600,11 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 		if criticalErr {
 			return criticalErr
 		}
+		if outs == nil || ctx.loadPending {
+			// The method panicked or the field is deferred until the key it loads is loaded
+			ctx.writeNull()
+			return false
+		}
 
 		hasSubSelection = ctx.seekInst() != 'e'
 		if method.errorOutNr != nil {
@@ -818,19 +1615,14 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 					ctx.writeNull()
 					return ctx.err("returned a invalid kind of error")
 				} else if err != nil {
-					ctx.err(err.Error())
+					ctx.resolverErr(err)
 				}
 			}
 		}
 
-		if ctx.context != nil {
-			err := (*ctx.context).Err()
-			if err != nil {
-				// Context ended
-				ctx.err(err.Error())
-				ctx.writeNull()
-				return false
-			}
+		if ctx.isCancelled() {
+			ctx.writeNull()
+			return false
 		}
 
 		ctx.setGoValue(outs[method.outNr])
@@ -874,6 +1666,12 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 		} else {
 			ctx.writeNull()
 		}
+	case valueTypeText:
+		if hasSubSelection {
+			ctx.writeNull()
+			return ctx.err("cannot have a selection set on this field")
+		}
+		ctx.writeText(goValue)
 	case valueTypeInterface, valueTypeInterfaceRef:
 		if !hasSubSelection {
 			ctx.writeNull()
@@ -882,12 +1680,16 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 
 		var ok bool
 		if typeObj.valueType == valueTypeInterfaceRef {
-			typeObj, ok = ctx.schema.interfaces[typeObj.typeName]
+			typeObj, ok = ctx.schema.resolveRef(typeObj)
 			if !ok {
 				ctx.writeNull()
 				return false
 			}
 		}
+		if typeObj.auth != nil && !ctx.authorized(*typeObj.auth) {
+			ctx.writeNull()
+			return false
+		}
 
 		if goValue.IsNil() {
 			ctx.writeNull()
@@ -912,6 +1714,12 @@ func (ctx *Ctx) resolveFieldDataValue(typeObj *obj, dept uint8, hasSubSelection
 			}
 		}
 
+		if typeObj.typeResolver != nil {
+			criticalErr := ctx.resolveImplementation(typeObj, goValue, dept, hasSubSelection)
+			ctx.currentReflectValueIdx--
+			return criticalErr
+		}
+
 		ctx.currentReflectValueIdx--
 		ctx.writeNull()
 	}
@@ -984,7 +1792,13 @@ func (ctx *Ctx) bindOperatorArgumentTo(goValue *reflect.Value, valueStructure *i
 		}
 
 		typeName := b2s(ctx.query.Res[typeNameStart:typeNameEnd])
-		if resolvedValueStructure.isEnum {
+		if resolvedValueStructure.scalar != nil && typeName == *resolvedValueStructure.scalar.Name {
+			// Custom scalars are bound from their underlying go type, the type of their kind is checked below
+		} else if resolvedValueStructure.isText {
+			if typeName != "String" && typeName != "ID" {
+				return false, ctx.err("expected variable type String but got " + typeName)
+			}
+		} else if resolvedValueStructure.isEnum {
 			enum := ctx.schema.definedEnums[resolvedValueStructure.enumTypeIndex]
 			if typeName != enum.typeName && typeName != "String" {
 				return false, ctx.err("expected variable type " + enum.typeName + " but got " + typeName)
@@ -994,13 +1808,17 @@ func (ctx *Ctx) bindOperatorArgumentTo(goValue *reflect.Value, valueStructure *i
 				return false, ctx.err("expected variable type ID but got " + typeName)
 			}
 		} else if resolvedValueStructure.isFile {
-			if typeName != "File" && typeName != "String" {
+			if typeName != "File" && typeName != "Upload" && typeName != "String" {
 				return false, ctx.err("expected variable type File but got " + typeName)
 			}
 		} else if resolvedValueStructure.isTime {
 			if typeName != "Time" && typeName != "String" {
 				return false, ctx.err("expected variable type Time but got " + typeName)
 			}
+		} else if resolvedValueStructure.isInt64 {
+			if typeName != "Int64" && typeName != "Int" && typeName != "String" {
+				return false, ctx.err("expected variable type Int64 but got " + typeName)
+			}
 		} else {
 			switch resolvedValueStructure.kind {
 			case reflect.Bool:
@@ -1049,29 +1867,34 @@ func (ctx *Ctx) bindOperatorArgumentTo(goValue *reflect.Value, valueStructure *i
 }
 
 func (ctx *Ctx) bindExternalVariableValue(goValue *reflect.Value, valueStructure *input, argumentName string) (valueSet bool, found bool, criticalErr bool) {
+	variable, criticalErr := ctx.getExternalVariable(argumentName)
+	if variable == nil || criticalErr {
+		return false, false, criticalErr
+	}
+
+	valueSet, criticalErr = ctx.bindJSONToValue(goValue, valueStructure, variable)
+	return valueSet, true, criticalErr
+}
+
+// getExternalVariable returns the value of a variable provided with the request, nil is returned if the variable was not provided
+func (ctx *Ctx) getExternalVariable(argumentName string) (variable *fastjson.Value, criticalErr bool) {
 	if !ctx.variablesParsed {
 		if len(ctx.rawVariables) == 0 {
-			return false, false, false
+			return nil, false
 		}
 
 		ctx.variablesParsed = true
 		var err error
 		ctx.variables, err = ctx.variablesJSONParser.Parse(ctx.rawVariables)
 		if err != nil {
-			return false, false, ctx.err(err.Error())
+			return nil, ctx.err(err.Error())
 		}
 		if ctx.variables.Type() != fastjson.TypeObject {
-			return false, false, ctx.err("variables provided must be of type object")
+			return nil, ctx.err("variables provided must be of type object")
 		}
 	}
 
-	variable := ctx.variables.Get(argumentName)
-	if variable == nil {
-		return false, false, false
-	}
-
-	valueSet, criticalErr = ctx.bindJSONToValue(goValue, valueStructure, variable)
-	return valueSet, true, criticalErr
+	return ctx.variables.Get(argumentName), false
 }
 
 func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, jsonData *fastjson.Value) (valueSet bool, criticalErr bool) {
@@ -1084,9 +1907,11 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 	}
 
 	jsonDataType := jsonData.Type()
-	if valueStructure.isEnum || valueStructure.isID || valueStructure.isFile || valueStructure.isTime {
+	if valueStructure.isEnum || valueStructure.isID || valueStructure.isFile || valueStructure.isTime || valueStructure.isText {
 		if jsonDataType != fastjson.TypeString {
-			if valueStructure.isEnum {
+			if valueStructure.isText {
+				return false, ctx.err("cannot assign " + jsonDataType.String() + " to String value")
+			} else if valueStructure.isEnum {
 				return false, ctx.err("cannot assign " + jsonDataType.String() + " to Enum value")
 			} else if valueStructure.isID {
 				return false, ctx.err("cannot assign " + jsonDataType.String() + " to ID value")
@@ -1100,7 +1925,13 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 		}
 		stringValue := b2s(jsonData.GetStringBytes())
 
-		if valueStructure.isEnum {
+		if valueStructure.isText {
+			err := unmarshalText(goValue, stringValue)
+			if err != nil {
+				return false, ctx.err(err.Error())
+			}
+			valueSet = true
+		} else if valueStructure.isEnum {
 			if jsonDataType != fastjson.TypeString {
 				return false, ctx.err("cannot assign " + jsonDataType.String() + " to ID value")
 			}
@@ -1159,11 +1990,11 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 				return false, ctx.err("cannot assign " + jsonDataType.String() + " to Time value")
 			}
 
-			file, err := ctx.getFormFile(stringValue)
+			file, err := ctx.fileInput(valueStructure, stringValue)
 			if err != nil {
 				return false, ctx.err(err.Error())
 			}
-			goValue.Set(reflect.ValueOf(file))
+			goValue.Set(file)
 			valueSet = true
 		} else if valueStructure.isTime {
 			if jsonDataType != fastjson.TypeString {
@@ -1212,6 +2043,9 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 		if criticalErr {
 			return valueSet, criticalErr
 		}
+		if valueStructure.oneOf && ctx.checkOneOfInput(*goValue, valueStructure) {
+			return valueSet, true
+		}
 	case fastjson.TypeArray:
 		if goValue.Kind() != reflect.Slice {
 			return valueSet, ctx.err("cannot assign slice to " + goValue.String())
@@ -1223,10 +2057,13 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 
 		for i, variableArrayItem := range variableArray {
 			arrEntry := arr.Index(i)
-			_, criticalErr := ctx.bindJSONToValue(&arrEntry, valueStructure.elem, variableArrayItem)
+			entrySet, criticalErr := ctx.bindJSONToValue(&arrEntry, valueStructure.elem, variableArrayItem)
 			if criticalErr {
 				return valueSet, criticalErr
 			}
+			if !entrySet && valueStructure.elem.isFile {
+				return valueSet, ctx.err("cannot assign null to a file within a list")
+			}
 		}
 
 		goValue.Set(arr)
@@ -1263,6 +2100,9 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 						return false, ctx.errf("cannot assign %d to a 32bit integer", intVal)
 					}
 				}
+				if intInputOverflows(valueStructure, goValueKind, intVal) {
+					return false, ctx.err("Int cannot represent non 32-bit signed integer value: " + strconv.FormatInt(intVal, 10))
+				}
 
 				valueSet = true
 				goValue.SetInt(intVal)
@@ -1286,6 +2126,9 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 						return false, ctx.errf("cannot assign %d to a 32bit unsigned integer", uintVal)
 					}
 				}
+				if intInputOverflows(valueStructure, goValueKind, intVal) {
+					return false, ctx.err("Int cannot represent non 32-bit signed integer value: " + strconv.FormatInt(intVal, 10))
+				}
 
 				valueSet = true
 				goValue.SetUint(uintVal)
@@ -1317,7 +2160,12 @@ func (ctx *Ctx) bindJSONToValue(goValue *reflect.Value, valueStructure *input, j
 }
 
 func (ctx *Ctx) assignStringToValue(goValue *reflect.Value, valueStructure *input, stringValue string) bool {
-	if valueStructure.isEnum {
+	if valueStructure.isText {
+		err := unmarshalText(goValue, stringValue)
+		if err != nil {
+			return ctx.err(err.Error())
+		}
+	} else if valueStructure.isEnum {
 		enum := ctx.schema.definedEnums[valueStructure.enumTypeIndex]
 		for _, entry := range enum.entries {
 			if entry.key == stringValue {
@@ -1361,20 +2209,34 @@ func (ctx *Ctx) assignStringToValue(goValue *reflect.Value, valueStructure *inpu
 			return ctx.err("internal error: cannot assign to this ID field")
 		}
 	} else if valueStructure.isFile {
-		if ctx.getFormFile == nil {
-			return ctx.err("form files are not supported")
-		}
-		file, err := ctx.getFormFile(stringValue)
+		file, err := ctx.fileInput(valueStructure, stringValue)
 		if err != nil {
 			return ctx.err(err.Error())
 		}
-		goValue.Set(reflect.ValueOf(file))
+		goValue.Set(file)
 	} else if valueStructure.isTime {
 		parsedTime, err := helpers.ParseIso8601String(stringValue)
 		if err != nil {
 			return ctx.err(err.Error())
 		}
 		goValue.Set(reflect.ValueOf(parsedTime))
+	} else if valueStructure.isInt64 {
+		switch goValue.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			intValue, err := strconv.ParseInt(stringValue, 10, goValue.Type().Bits())
+			if err != nil {
+				return ctx.err("cannot assign " + stringValue + " to a " + strconv.Itoa(goValue.Type().Bits()) + "bit integer")
+			}
+			goValue.SetInt(intValue)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			uintValue, err := strconv.ParseUint(stringValue, 10, goValue.Type().Bits())
+			if err != nil {
+				return ctx.err("cannot assign " + stringValue + " to a " + strconv.Itoa(goValue.Type().Bits()) + "bit unsigned integer")
+			}
+			goValue.SetUint(uintValue)
+		default:
+			return ctx.err("internal error: cannot assign to this Int64 field")
+		}
 	} else if goValue.Kind() == reflect.String {
 		goValue.SetString(stringValue)
 	} else {
@@ -1478,6 +2340,9 @@ func (ctx *Ctx) bindInputToGoValue(goValue *reflect.Value, valueStructure *input
 					return false, ctx.err("cannot assign " + intValue + " to a 32bit integer")
 				}
 			}
+			if intInputOverflows(valueStructure, goValue.Kind(), value) {
+				return false, ctx.err("Int cannot represent non 32-bit signed integer value: " + intValue)
+			}
 
 			goValue.SetInt(value)
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -1500,6 +2365,9 @@ func (ctx *Ctx) bindInputToGoValue(goValue *reflect.Value, valueStructure *input
 					return false, ctx.err("cannot assign " + intValue + " to a 32bit unsigned integer")
 				}
 			}
+			if intInputOverflows(valueStructure, goValue.Kind(), int64(value)) {
+				return false, ctx.err("Int cannot represent non 32-bit signed integer value: " + intValue)
+			}
 
 			goValue.SetUint(value)
 		case reflect.Float32, reflect.Float64:
@@ -1592,12 +2460,16 @@ func (ctx *Ctx) bindInputToGoValue(goValue *reflect.Value, valueStructure *input
 		ctx.skipInst(1) // read NULL
 		for ctx.seekInst() != 'e' {
 			arrayEntry := reflect.New(arrItemType).Elem()
-			_, criticalErr := ctx.bindInputToGoValue(&arrayEntry, valueStructure.elem, variablesAllowed)
+			entrySet, criticalErr := ctx.bindInputToGoValue(&arrayEntry, valueStructure.elem, variablesAllowed)
 			if criticalErr {
 				return false, criticalErr
 			}
+			if !entrySet && valueStructure.elem.isFile {
+				return false, ctx.err("cannot assign null to a file within a list")
+			}
 			arr = reflect.Append(arr, arrayEntry)
 		}
+		ctx.skipInst(2) // read 'e' and the NULL byte after it
 
 		goValue.Set(arr)
 	case bytecode.ValueObject:
@@ -1625,6 +2497,9 @@ func (ctx *Ctx) bindInputToGoValue(goValue *reflect.Value, valueStructure *input
 		if criticalErr {
 			return valueSet, criticalErr
 		}
+		if valueStructure.oneOf && ctx.checkOneOfInput(*goValue, valueStructure) {
+			return valueSet, true
+		}
 	}
 	return valueSet, false
 }
@@ -1660,6 +2535,149 @@ func (ctx *Ctx) walkInputObject(onValueOfKey func(key []byte) bool) bool {
 	}
 }
 
+// inputValueToJSON appends the input value at the current charNr as JSON to res
+// This is used for input values without a go type like the federation _Any scalar
+func (ctx *Ctx) inputValueToJSON(res []byte) ([]byte, bool) {
+	kind := ctx.query.Res[ctx.charNr+1]
+	contentStart := ctx.charNr + 6
+	contentEnd := contentStart + int(ctx.readUint32(ctx.charNr+2))
+	content := ctx.query.Res[contentStart:contentEnd]
+
+	switch kind {
+	case bytecode.ValueInt, bytecode.ValueFloat:
+		res = append(res, content...)
+	case bytecode.ValueString:
+		helpers.StringToJSON(b2s(content), &res)
+	case bytecode.ValueBoolean:
+		if len(content) > 0 && content[0] == '1' {
+			res = append(res, "true"...)
+		} else {
+			res = append(res, "false"...)
+		}
+	case bytecode.ValueNull:
+		res = append(res, nullBytes...)
+	case bytecode.ValueEnum:
+		res = append(res, '"')
+		res = append(res, content...)
+		res = append(res, '"')
+	case bytecode.ValueVariable:
+		varName := b2s(content)
+		variable, criticalErr := ctx.getExternalVariable(varName)
+		if criticalErr {
+			return res, criticalErr
+		}
+		if variable != nil {
+			res = variable.MarshalTo(res)
+			break
+		}
+
+		// Use the default value of the variable
+		if !ctx.findOperatorArgument(varName) {
+			return res, ctx.err("variable " + varName + " not defined")
+		}
+		for {
+			c := ctx.readInst()
+			if c == 'n' || c == 'N' {
+				break
+			}
+		}
+		for ctx.readInst() != 0 {
+			// read type name
+		}
+		if ctx.readInst() != 't' {
+			return res, ctx.err("variable has no value nor default")
+		}
+		ctx.skipInst(1)
+		res, criticalErr = ctx.inputValueToJSON(res)
+		if criticalErr {
+			return res, criticalErr
+		}
+	case bytecode.ValueList:
+		res = append(res, '[')
+		ctx.charNr = contentStart + 1
+		for i := 0; ctx.seekInst() != bytecode.ActionEnd; i++ {
+			if i > 0 {
+				res = append(res, ',')
+			}
+			var criticalErr bool
+			res, criticalErr = ctx.inputValueToJSON(res)
+			if criticalErr {
+				return res, criticalErr
+			}
+		}
+		res = append(res, ']')
+	case bytecode.ValueObject:
+		res = append(res, '{')
+		ctx.charNr = contentStart
+		for i := 0; ; i++ {
+			c := ctx.readInst()
+			if c == 0 {
+				c = ctx.readInst()
+			}
+			if c == bytecode.ActionEnd {
+				break
+			}
+
+			keyStart := ctx.charNr
+			for ctx.readInst() != 0 {
+				// read key
+			}
+			if i > 0 {
+				res = append(res, ',')
+			}
+			helpers.StringToJSON(b2s(ctx.query.Res[keyStart:ctx.charNr-1]), &res)
+			res = append(res, ':')
+
+			var criticalErr bool
+			res, criticalErr = ctx.inputValueToJSON(res)
+			if criticalErr {
+				return res, criticalErr
+			}
+		}
+		res = append(res, '}')
+	default:
+		return res, ctx.err("unknown input value kind " + string(kind))
+	}
+
+	// Skip over the value and the NULL byte after it
+	ctx.charNr = contentEnd + 1
+	return res, false
+}
+
+// intOverflows returns true if value of kind is a number that cannot be represented by the 32-bit Int scalar
+func intOverflows(value reflect.Value, kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int64:
+		intValue := value.Int()
+		return intValue > math.MaxInt32 || intValue < math.MinInt32
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return value.Uint() > math.MaxInt32
+	}
+	return false
+}
+
+// intInputOverflows returns true if value is assigned to an input of kind that is an Int scalar but the value is outside the 32-bit range of the scalar
+// See: https://spec.graphql.org/October2021/#sec-Int.Input-Coercion
+func intInputOverflows(valueStructure *input, kind reflect.Kind, value int64) bool {
+	if valueStructure.isInt64 || valueStructure.isID || valueStructure.scalar != nil {
+		return false
+	}
+	switch kind {
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return value > math.MaxInt32 || value < math.MinInt32
+	}
+	return false
+}
+
+// intToString formats the int or uint value of kind
+func intToString(value reflect.Value, kind reflect.Kind) string {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	}
+	return strconv.FormatInt(value.Int(), 10)
+}
+
 func (ctx *Ctx) valueToJSON(in reflect.Value, kind reflect.Kind) {
 	switch kind {
 	case reflect.String:
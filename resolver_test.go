@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"mime/multipart"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -17,7 +18,11 @@ import (
 )
 
 func bytecodeParse(t *testing.T, s *Schema, query string, queries interface{}, methods interface{}, opts ...ResolveOptions) (string, []error) {
-	err := s.Parse(queries, methods, nil)
+	return bytecodeParseWithOptions(t, s, nil, query, queries, methods, opts...)
+}
+
+func bytecodeParseWithOptions(t *testing.T, s *Schema, schemaOptions *SchemaOptions, query string, queries interface{}, methods interface{}, opts ...ResolveOptions) (string, []error) {
+	err := s.Parse(queries, methods, schemaOptions)
 	a.NoError(t, err, query)
 
 	// Copy so we automatically also test if all fields are copied over correctly
@@ -242,6 +247,149 @@ func TestBytecodeResolveMethod(t *testing.T) {
 	a.Equal(t, `{"foo":null,"bar":"foo","baz":"bar"}`, res)
 }
 
+type TestBytecodeResolvePtrReceiverMethodItem struct {
+	Name string
+}
+
+func (i *TestBytecodeResolvePtrReceiverMethodItem) ResolveGreeting() string {
+	return "hello " + i.Name
+}
+
+type TestBytecodeResolvePtrReceiverMethodData struct {
+	Item  TestBytecodeResolvePtrReceiverMethodItem
+	Items []TestBytecodeResolvePtrReceiverMethodItem
+}
+
+func (d *TestBytecodeResolvePtrReceiverMethodData) ResolveItemName() string {
+	return d.Item.Name
+}
+
+func (d TestBytecodeResolvePtrReceiverMethodData) ResolveCopy() TestBytecodeResolvePtrReceiverMethodItem {
+	// The results of methods are not addressable so the method with the pointer receiver is called on a copy
+	return TestBytecodeResolvePtrReceiverMethodItem{Name: "d"}
+}
+
+func TestBytecodeResolvePtrReceiverMethod(t *testing.T) {
+	schema := TestBytecodeResolvePtrReceiverMethodData{
+		Item:  TestBytecodeResolvePtrReceiverMethodItem{Name: "a"},
+		Items: []TestBytecodeResolvePtrReceiverMethodItem{{Name: "b"}, {Name: "c"}},
+	}
+	res := bytecodeParseAndExpectNoErrs(t, `{itemName item {greeting} items {greeting} copy {greeting}}`, schema, M{})
+	a.Equal(t, `{"itemName":"a","item":{"greeting":"hello a"},"items":[{"greeting":"hello b"},{"greeting":"hello c"}],"copy":{"greeting":"hello d"}}`, res)
+}
+
+type TestBytecodeResolveEmbeddedTimestamps struct {
+	CreatedAt string
+	Title     string
+}
+
+func (t TestBytecodeResolveEmbeddedTimestamps) ResolveCreatedAtISO() string {
+	return t.CreatedAt + "T00:00:00Z"
+}
+
+func (TestBytecodeResolveEmbeddedTimestamps) ResolveKind() string {
+	return "timestamps"
+}
+
+func (TestBytecodeResolveEmbeddedTimestamps) ResolveSummary() string {
+	return "from timestamps"
+}
+
+type TestBytecodeResolveEmbeddedOwner struct {
+	OwnerID int
+	Title   string
+}
+
+func (o *TestBytecodeResolveEmbeddedOwner) ResolveOwnerName() string {
+	return "owner " + strconv.Itoa(o.OwnerID)
+}
+
+type TestBytecodeResolveEmbeddedPost struct {
+	TestBytecodeResolveEmbeddedTimestamps
+	*TestBytecodeResolveEmbeddedOwner
+	Name    string
+	Summary string
+}
+
+func (TestBytecodeResolveEmbeddedPost) ResolveKind() string {
+	return "post"
+}
+
+type TestBytecodeResolveEmbeddedData struct {
+	Posts []TestBytecodeResolveEmbeddedPost
+}
+
+func TestBytecodeResolveEmbeddedStructs(t *testing.T) {
+	schema := TestBytecodeResolveEmbeddedData{Posts: []TestBytecodeResolveEmbeddedPost{
+		{
+			TestBytecodeResolveEmbeddedTimestamps: TestBytecodeResolveEmbeddedTimestamps{CreatedAt: "2020-01-01"},
+			TestBytecodeResolveEmbeddedOwner:      &TestBytecodeResolveEmbeddedOwner{OwnerID: 1},
+			Name:                                  "a",
+			Summary:                               "from post",
+		},
+		{Name: "b"},
+	}}
+
+	query := `{posts {name createdAt createdAtISO ownerID ownerName kind summary}}`
+	res := bytecodeParseAndExpectNoErrs(t, query, schema, M{})
+	a.Equal(t, `{"posts":[`+
+		`{"name":"a","createdAt":"2020-01-01","createdAtISO":"2020-01-01T00:00:00Z","ownerID":1,"ownerName":"owner 1","kind":"post","summary":"from post"},`+
+		`{"name":"b","createdAt":"","createdAtISO":"T00:00:00Z","ownerID":null,"ownerName":null,"kind":"post","summary":""}`+
+		`]}`, res)
+
+	// The fields and methods promoted through an embedded pointer are null if the pointer is nil
+	s := NewSchema()
+	a.NoError(t, s.Parse(schema, M{}, nil))
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "\townerID: Int\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\townerName: String\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\tcreatedAt: String!\n"), sdl)
+
+	// Title is promoted from both embedded structs and thus ambiguous
+	_, errs := bytecodeParseAndExpectErrs(t, `{posts {title}}`, schema, M{})
+	a.Equal(t, 2, len(errs)) // one for every post
+	a.Equal(t, "title does not exists on TestBytecodeResolveEmbeddedPost", errs[0].Error())
+}
+
+type TestBytecodeResolveEmbeddedPrefixedPost struct {
+	TestBytecodeResolveEmbeddedTimestamps `gq:"meta,asField"`
+	*TestBytecodeResolveEmbeddedOwner     `gq:",prefix=owner"`
+	Title                                 string
+}
+
+type TestBytecodeResolveEmbeddedPrefixedData struct {
+	Post TestBytecodeResolveEmbeddedPrefixedPost
+}
+
+func TestBytecodeResolveEmbeddedStructsAsField(t *testing.T) {
+	schema := TestBytecodeResolveEmbeddedPrefixedData{Post: TestBytecodeResolveEmbeddedPrefixedPost{
+		TestBytecodeResolveEmbeddedTimestamps: TestBytecodeResolveEmbeddedTimestamps{CreatedAt: "2020-01-01", Title: "a"},
+		TestBytecodeResolveEmbeddedOwner:      &TestBytecodeResolveEmbeddedOwner{OwnerID: 1, Title: "b"},
+		Title:                                 "c",
+	}}
+
+	query := `{post {title ownerTitle ownerOwnerID ownerOwnerName meta {title createdAtISO kind}}}`
+	res := bytecodeParseAndExpectNoErrs(t, query, schema, M{})
+	a.Equal(t, `{"post":{"title":"c","ownerTitle":"b","ownerOwnerID":1,"ownerOwnerName":"owner 1","meta":{"title":"a","createdAtISO":"2020-01-01T00:00:00Z","kind":"timestamps"}}}`, res)
+
+	// The methods of embedded structs exposed as field are not promoted
+	_, errs := bytecodeParseAndExpectErrs(t, `{post {createdAtISO}}`, schema, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "createdAtISO does not exists on TestBytecodeResolveEmbeddedPrefixedPost", errs[0].Error())
+}
+
+func TestParseEmbeddedStructTagsInvalid(t *testing.T) {
+	a.Error(t, NewSchema().Parse(struct {
+		Name string `gq:",asField"`
+	}{}, M{}, nil))
+	a.Error(t, NewSchema().Parse(struct {
+		Name string `gq:",prefix=owner"`
+	}{}, M{}, nil))
+	a.Error(t, NewSchema().Parse(struct {
+		TestBytecodeResolveEmbeddedOwner `gq:",prefix=-"`
+	}{}, M{}, nil))
+}
+
 type TestBytecodeResolveMethodWithErrorResData struct{}
 
 func (TestBytecodeResolveMethodWithErrorResData) ResolveFoo() (*string, error) {
@@ -286,6 +434,12 @@ func TestBytecodeResolveMethodWithIntArgs(t *testing.T) {
 	a.Equal(t, `{"foo":{"a":1,"b":2,"c":3,"d":1.1}}`, res)
 }
 
+func TestBytecodeResolveMethodInputIsReset(t *testing.T) {
+	// The input struct is reused between calls so arguments of the previous call should not leak into the next call
+	res := bytecodeParseAndExpectNoErrs(t, `{x: foo(a: 1, b: 2, c: 3, d: 1.1) {a b c d} y: foo(b: 5) {a b c d}}`, TestResolveInputAllKindsOfNumbersData{}, M{})
+	a.Equal(t, `{"x":{"a":1,"b":2,"c":3,"d":1.1},"y":{"a":0,"b":5,"c":0,"d":0}}`, res)
+}
+
 func TestBytecodeResolveTypename(t *testing.T) {
 	schema := TestResolveStructTypeMethodData{}
 	res := bytecodeParseAndExpectNoErrs(t, `{__typename}`, schema, M{})
@@ -413,14 +567,19 @@ func TestBytecodeResolveCorrectMetaWithError(t *testing.T) {
 	if !json.Valid([]byte(res)) {
 		panic("invalid json: " + res)
 	}
-	a.Equal(t, `{"data":{"a":{"foo":null}},"errors":[{"message":"field arguments not allowed","path":["a","foo"]}],"extensions":{}}`, res)
+	a.Equal(t, `{"data":{"a":{"foo":null}},"errors":[{"message":"field arguments not allowed","path":["a","foo"],"locations":[{"line":3,"column":4}]}],"extensions":{}}`, res)
 }
 
 func TestBytecodeResolveWithArgs(t *testing.T) {
-	query := `query A($a: Int) {}`
+	query := `query A($a: Boolean = false) {__typename @include(if: $a)}`
 	schema := TestResolveEmptyQueryDataQ{}
 	res := bytecodeParseAndExpectNoErrs(t, query, schema, M{})
 	a.Equal(t, `{}`, res)
+
+	// Variables must be used
+	_, errs := bytecodeParse(t, NewSchema(), `query A($a: Int) {}`, schema, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "variable $a is never used in operation A", errs[0].Error())
 }
 
 func TestBytecodeResolveVariableInputWithDefault(t *testing.T) {
@@ -438,6 +597,25 @@ func TestBytecodeResolveVariable(t *testing.T) {
 	a.Equal(t, `{"bar":"foo"}`, res)
 }
 
+type TestBytecodeResolveListArgumentData struct{}
+
+func (TestBytecodeResolveListArgumentData) ResolveFoo(args struct {
+	A [][]string
+	B string
+}) []TestResolveSimpleQueryData {
+	res := []TestResolveSimpleQueryData{}
+	for _, entry := range args.A {
+		res = append(res, TestResolveSimpleQueryData{A: strings.Join(entry, ","), B: args.B})
+	}
+	return res
+}
+
+func TestBytecodeResolveListArgument(t *testing.T) {
+	query := `{foo(a: [["a", "b"], ["c"]], b: "d") {a b}}`
+	res := bytecodeParseAndExpectNoErrs(t, query, TestBytecodeResolveListArgumentData{}, M{})
+	a.Equal(t, `{"foo":[{"a":"a,b","b":"d"},{"a":"c","b":"d"}]}`, res)
+}
+
 type TestBytecodeResolveMultipleArgumentsData struct{}
 
 type TestBytecodeResolveMultipleArgumentsDataIO struct {
@@ -841,6 +1019,35 @@ func TestBytecodeResolveSpread(t *testing.T) {
 	a.Equal(t, `{"inner":{"fieldA":"a","fieldB":"b","fieldC":"c","fieldD":"d"}}`, res)
 }
 
+func TestBytecodeResolveSpreadWithNamePrefix(t *testing.T) {
+	// The fragment baz must not be matched by a fragment that starts with baz
+	query := `{
+		inner {
+			... baz
+			... bazAndMore
+		}
+	}
+
+	fragment bazAndMore on TestBytecodeResolveInlineSpreadDataInner {
+		fieldA
+	}
+
+	fragment baz on TestBytecodeResolveInlineSpreadDataInner {
+		fieldB
+	}`
+
+	schema := TestBytecodeResolveInlineSpreadData{
+		Inner: TestBytecodeResolveInlineSpreadDataInner{
+			"a",
+			"b",
+			"c",
+			"d",
+		},
+	}
+	res := bytecodeParseAndExpectNoErrs(t, query, schema, M{})
+	a.Equal(t, `{"inner":{"fieldB":"b","fieldA":"a"}}`, res)
+}
+
 // This is the request graphql playground makes to get the schema
 var schemaQuery = `
 query IntrospectionQuery {
@@ -958,7 +1165,7 @@ func TestBytecodeResolveSchemaRequestSimple(t *testing.T) {
 	schema := res.Schema
 	types := schema.JSONTypes
 
-	a.Equal(t, 17, len(types))
+	a.Equal(t, 18, len(types))
 
 	idx := 0
 	is := func(kind, name string) {
@@ -974,6 +1181,7 @@ func TestBytecodeResolveSchemaRequestSimple(t *testing.T) {
 	is("SCALAR", "Float")
 	is("SCALAR", "ID")
 	is("SCALAR", "Int")
+	is("SCALAR", "Int64")
 	is("OBJECT", "M")
 	is("SCALAR", "String")
 	is("OBJECT", "TestResolveSchemaRequestSimpleData")
@@ -1027,7 +1235,7 @@ func TestBytecodeResolveSchemaRequestWithFields(t *testing.T) {
 	schema := res.Schema
 	types := schema.JSONTypes
 
-	a.Equal(t, 22, len(types))
+	a.Equal(t, 23, len(types))
 
 	idx := 0
 	is := func(kind, name string) int {
@@ -1044,6 +1252,7 @@ func TestBytecodeResolveSchemaRequestWithFields(t *testing.T) {
 	is("SCALAR", "Float")
 	is("SCALAR", "ID")
 	is("SCALAR", "Int")
+	is("SCALAR", "Int64")
 	is("OBJECT", "M")
 	is("SCALAR", "String")
 	inputIdx := is("INPUT_OBJECT", "TestBytecodeResolveMultipleArgumentsDataIO")
@@ -1237,6 +1446,133 @@ func TestBytecodeResolveDirective(t *testing.T) {
 		a.Equal(t, 3, value)
 	})
 
+	t.Run("repeatable field directives", func(t *testing.T) {
+		tags := []string{}
+
+		s := NewSchema()
+		s.RegisterDirective(Directive{
+			Name:       "tag",
+			Where:      []DirectiveLocation{DirectiveLocationField},
+			Repeatable: true,
+			Method: func(args struct{ Name string }) DirectiveModifier {
+				tags = append(tags, args.Name)
+				return DirectiveModifier{}
+			},
+		})
+
+		query := `{b @tag(name: "x") @tag(name: "y")}`
+		res, errs := bytecodeParse(t, s, query, schema, M{})
+		for _, err := range errs {
+			panic(err.Error())
+		}
+		a.Equal(t, `{"b":"bar"}`, res, query)
+		a.Equal(t, []string{"x", "y"}, tags)
+
+		s = s.Copy()
+		errs = s.Resolve([]byte(`{__schema {directives {name isRepeatable}}}`), ResolveOptions{NoMeta: true})
+		a.Equal(t, 0, len(errs))
+		a.True(t, strings.Contains(string(s.Result), `{"name":"tag","isRepeatable":true}`), string(s.Result))
+		a.True(t, strings.Contains(string(s.Result), `{"name":"skip","isRepeatable":false}`), string(s.Result))
+		a.True(t, strings.Contains(s.SDL(), `directive @tag(name: String) repeatable on FIELD`), s.SDL())
+	})
+
+	t.Run("directive with ctx and field arguments", func(t *testing.T) {
+		type authArgs struct{ Role string }
+		type fieldArgs struct {
+			A     string
+			Other int
+		}
+		calls := []string{}
+
+		s := NewSchema()
+		err := s.RegisterDirective(Directive{
+			Name:  "auth",
+			Where: []DirectiveLocation{DirectiveLocationField},
+			Method: func(ctx *Ctx, args authArgs, field fieldArgs) DirectiveModifier {
+				calls = append(calls, string(ctx.GetPath())+" "+args.Role+" "+field.A)
+				return DirectiveModifier{Skip: args.Role != "admin" && field.A == "secret"}
+			},
+		})
+		a.NoError(t, err)
+
+		query := `query($role: String!) {
+			a: bar(a: "public") @auth(role: $role) @skip(if: false)
+			b: bar(b: 1, a: "secret") @auth(role: $role)
+			c: bar(a: "other") @skip(if: false) @auth(role: "admin")
+		}`
+		res, errs := bytecodeParse(t, s, query, TestResolveDirectiveFieldArgsData{}, M{}, ResolveOptions{NoMeta: true, Variables: `{"role": "user"}`})
+		for _, err := range errs {
+			panic(err.Error())
+		}
+		a.Equal(t, `{"a":"public","c":"other"}`, res)
+		a.Equal(t, []string{`["a"] user public`, `["b"] user secret`, `["c"] admin other`}, calls)
+	})
+
+	t.Run("variable definition directives", func(t *testing.T) {
+		calls := []string{}
+
+		s := NewSchema()
+		err := s.RegisterDirective(Directive{
+			Name:  "constraint",
+			Where: []DirectiveLocation{DirectiveLocationVariableDefinition},
+			Method: func(ctx *Ctx, args struct{ MaxLength int }) (DirectiveModifier, error) {
+				name, value := ctx.Variable()
+				calls = append(calls, name+"="+string(value))
+				if len(value) > args.MaxLength+2 {
+					return DirectiveModifier{}, errors.New("variable $" + name + " is too long")
+				}
+				return DirectiveModifier{}, nil
+			},
+		})
+		a.NoError(t, err)
+
+		query := `query($a: String @constraint(maxLength: 5), $b: String = "def" @constraint(maxLength: 3)) {a: bar(a: $a) b: bar(a: $b)}`
+		res, errs := bytecodeParse(t, s, query, TestResolveDirectiveFieldArgsData{}, M{}, ResolveOptions{NoMeta: true, Variables: `{"a": "abc"}`})
+		a.Equal(t, 0, len(errs))
+		a.Equal(t, `{"a":"abc","b":"def"}`, res)
+		a.Equal(t, []string{`a="abc"`, `b="def"`}, calls)
+
+		s = s.Copy()
+		errs = s.Resolve([]byte(query), ResolveOptions{NoMeta: true, Variables: `{"a": "abcdef"}`})
+		a.Equal(t, 1, len(errs))
+		a.Equal(t, "variable $a is too long", errs[0].Error())
+
+		errs = s.Resolve([]byte(`query($a: String @unknown) {bar(a: $a)}`), ResolveOptions{NoMeta: true})
+		a.Equal(t, 1, len(errs))
+		a.Equal(t, "unknown directive unknown", errs[0].Error())
+		a.Equal(t, 1, len(s.Validate(`query($a: String @unknown) {bar(a: $a)}`)))
+		a.Equal(t, 0, len(s.Validate(query)))
+
+		errs = s.Resolve([]byte(`{__schema {directives {name locations}}}`), ResolveOptions{NoMeta: true})
+		a.Equal(t, 0, len(errs))
+		a.True(t, strings.Contains(string(s.Result), `{"name":"constraint","locations":["VARIABLE_DEFINITION"]}`), string(s.Result))
+	})
+
+	t.Run("invalid directive method inputs", func(t *testing.T) {
+		s := NewSchema()
+		a.Error(t, s.RegisterDirective(Directive{
+			Name:   "a",
+			Where:  []DirectiveLocation{DirectiveLocationField},
+			Method: func(args struct{}, field struct{}, other struct{}) DirectiveModifier { return DirectiveModifier{} },
+		}))
+		a.Error(t, s.RegisterDirective(Directive{
+			Name:   "b",
+			Where:  []DirectiveLocation{DirectiveLocationField},
+			Method: func(args struct{}, field struct{}, ctx *Ctx) DirectiveModifier { return DirectiveModifier{} },
+		}))
+	})
+
+	t.Run("non repeatable field directives", func(t *testing.T) {
+		query := `{a b @skip(if: false) @skip(if: false)}`
+		res, errs := bytecodeParseAndExpectErrs(t, query, schema, M{})
+		a.Equal(t, 1, len(errs))
+		a.Equal(t, "directive skip can only be used once at this location", errs[0].Error())
+		a.Equal(t, `{"a":"foo"}`, res)
+
+		_, errs = bytecodeParseAndExpectErrs(t, `{... on TestResolveSimpleQueryData @skip(if: false) @skip(if: false) {a}}`, schema, M{})
+		a.Equal(t, 1, len(errs))
+	})
+
 	t.Run("inside fragment", func(t *testing.T) {
 		tests := []struct {
 			name    string
@@ -1276,6 +1612,15 @@ func TestBytecodeResolveDirective(t *testing.T) {
 	})
 }
 
+type TestResolveDirectiveFieldArgsData struct{}
+
+func (TestResolveDirectiveFieldArgsData) ResolveBar(args struct {
+	B int
+	A string
+}) string {
+	return args.A
+}
+
 func TestValueToJson(t *testing.T) {
 	stringValue := string(`a"b`)
 	boolTrue := bool(true)
@@ -1447,12 +1792,59 @@ type TestResolveMaxDeptData struct {
 	}
 }
 
+func TestBytecodeResolveErrorLocations(t *testing.T) {
+	query := `{
+	inner {
+		a
+		...f
+	}
+}
+fragment f on TestBytecodeResolvePanicInner { alias: panic }`
+	res, errs := bytecodeParse(t, NewSchema(), query, TestBytecodeResolvePanicData{}, M{}, ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	line, column := errs[0].(ErrorWPath).Location()
+	a.Equal(t, uint(7), line)
+	a.Equal(t, uint(47), column)
+	a.Equal(t, `{"data":{"inner":{"a":"","alias":null}},"errors":[{"message":"resolver panicked: oops","path":["inner","alias"],"locations":[{"line":7,"column":47}]}],"extensions":{}}`, res)
+}
+
+type TestBytecodeResolveErrorPathData struct {
+	Users []TestBytecodeResolveErrorPathUser
+}
+
+type TestBytecodeResolveErrorPathUser struct {
+	Friends []TestBytecodeResolveErrorPathFriend
+}
+
+type TestBytecodeResolveErrorPathFriend struct {
+	Nr int
+}
+
+func (f TestBytecodeResolveErrorPathFriend) ResolveName() (string, error) {
+	if f.Nr == 3 {
+		return "", errors.New("friend not found")
+	}
+	return "friend", nil
+}
+
+func TestBytecodeResolveErrorPath(t *testing.T) {
+	schema := TestBytecodeResolveErrorPathData{Users: []TestBytecodeResolveErrorPathUser{
+		{},
+		{Friends: []TestBytecodeResolveErrorPathFriend{{Nr: 1}, {Nr: 2}, {Nr: 3}}},
+	}}
+
+	res, errs := bytecodeParse(t, NewSchema(), `{users {friends {name}}}`, schema, M{}, ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `["users",1,"friends",2,"name"]`, string(errs[0].(ErrorWPath).Path()))
+	a.True(t, strings.Contains(res, `"path":["users",1,"friends",2,"name"]`), res)
+}
+
 func TestExecMaxDept(t *testing.T) {
 	s := NewSchema()
 	s.MaxDepth = 3
 	out, errs := bytecodeParse(t, s, `{foo{bar{baz{fooBar{barBaz{bazFoo}}}}}}`, TestResolveMaxDeptData{}, M{}, ResolveOptions{})
 	a.Greater(t, len(errs), 0)
-	a.Equal(t, `{"data":{"foo":{"bar":{"baz":null}}},"errors":[{"message":"reached max dept","path":["foo","bar","baz"]}],"extensions":{}}`, out)
+	a.Equal(t, `{"data":{"foo":{"bar":{"baz":null}}},"errors":[{"message":"reached max dept","path":["foo","bar","baz"],"locations":[{"line":1,"column":10}]}],"extensions":{}}`, out)
 }
 
 type TestResolveStructTypeMethodWithCtxData struct{}
@@ -1869,6 +2261,171 @@ func TestBytecodeResolveContext(t *testing.T) {
 	a.Equal(t, `{"foo":null}`, out)
 }
 
+type TestBytecodeResolveContextArgData struct{}
+
+func (TestBytecodeResolveContextArgData) ResolveValue(c context.Context) string {
+	return c.Value("key").(string)
+}
+
+func (TestBytecodeResolveContextArgData) ResolveCancel(c context.Context) bool {
+	c.Value("cancel").(context.CancelFunc)()
+	return c.Err() != nil
+}
+
+func TestBytecodeResolveContextArgument(t *testing.T) {
+	c := context.WithValue(context.Background(), "key", "value")
+	out := bytecodeParseAndExpectNoErrs(t, `{value}`, TestBytecodeResolveContextArgData{}, M{}, ResolveOptions{NoMeta: true, Context: c})
+	a.Equal(t, `{"value":"value"}`, out)
+}
+
+func TestBytecodeResolveContextCancelledBetweenFields(t *testing.T) {
+	c, cancel := context.WithCancel(context.WithValue(context.Background(), "key", "value"))
+	defer cancel()
+	c = context.WithValue(c, "cancel", cancel)
+
+	// The fields after the field that cancels the context are not resolved and the error is only reported once
+	opts := ResolveOptions{NoMeta: true, Context: c}
+	out, errs := bytecodeParseAndExpectErrs(t, `{a: value cancel b: value c: value}`, TestBytecodeResolveContextArgData{}, M{}, opts)
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, context.Canceled.Error(), errs[0].Error())
+	a.Equal(t, `{"a":"value","cancel":null,"b":null,"c":null}`, out)
+}
+
+type TestBytecodeResolvePanicData struct {
+	Inner TestBytecodeResolvePanicInner
+}
+
+type TestBytecodeResolvePanicInner struct {
+	A string
+}
+
+func (TestBytecodeResolvePanicInner) ResolvePanic() string {
+	panic("oops")
+}
+
+func TestBytecodeResolvePanic(t *testing.T) {
+	s := NewSchema()
+	var recovered interface{}
+	var stack []byte
+	s.SetPanicHandler(func(ctx *Ctx, r interface{}, s []byte) {
+		recovered = r
+		stack = s
+	})
+
+	res, errs := bytecodeParse(t, s, `{inner {panic a}}`, TestBytecodeResolvePanicData{Inner: TestBytecodeResolvePanicInner{A: "a"}}, M{}, ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "resolver panicked: oops", errs[0].Error())
+	a.Equal(t, `{"data":{"inner":{"panic":null,"a":"a"}},"errors":[{"message":"resolver panicked: oops","path":["inner","panic"],"locations":[{"line":1,"column":9}]}],"extensions":{}}`, res)
+	a.Equal(t, "oops", recovered)
+	a.True(t, strings.Contains(string(stack), "ResolvePanic"))
+
+	// Without a panic handler the panic is still recovered
+	res, errs = bytecodeParse(t, NewSchema(), `{inner {panic}}`, TestBytecodeResolvePanicData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"inner":{"panic":null}}`, res)
+}
+
+func TestBytecodeResolveDirectivePanic(t *testing.T) {
+	s := NewSchema()
+	s.RegisterDirective(Directive{
+		Name:  "panic",
+		Where: []DirectiveLocation{DirectiveLocationField},
+		Method: func() DirectiveModifier {
+			panic("oops")
+		},
+	})
+
+	_, errs := bytecodeParse(t, s, `{a b @panic}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "resolver panicked: oops", errs[0].Error())
+}
+
+var errTestInternal = errors.New("connection to db.internal:5432 refused")
+
+type TestBytecodeResolveErrorPresenterData struct{}
+
+func (TestBytecodeResolveErrorPresenterData) ResolveInternal() (string, error) {
+	return "", errTestInternal
+}
+
+func (TestBytecodeResolveErrorPresenterData) ResolvePublic() (string, error) {
+	return "", errors.New("not found")
+}
+
+func (TestBytecodeResolveErrorPresenterData) ResolveHidden() (string, error) {
+	return "", errors.New("hidden")
+}
+
+func TestBytecodeResolveErrorPresenter(t *testing.T) {
+	s := NewSchema()
+	var presented []error
+	s.SetErrorPresenter(func(ctx *Ctx, err error) error {
+		presented = append(presented, err)
+		if errors.Is(err, errTestInternal) {
+			return errors.New("internal server error")
+		}
+		if err.Error() == "hidden" {
+			return nil
+		}
+		return err
+	})
+
+	res, errs := bytecodeParse(t, s, `{internal public hidden}`, TestBytecodeResolveErrorPresenterData{}, M{}, ResolveOptions{})
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, `{"data":{"internal":"","public":"","hidden":""},"errors":[{"message":"internal server error","path":["internal"],"locations":[{"line":1,"column":2}]},{"message":"not found","path":["public"],"locations":[{"line":1,"column":11}]}],"extensions":{}}`, res)
+	a.Equal(t, 3, len(presented))
+	a.Equal(t, errTestInternal, presented[0])
+}
+
+type TestBytecodeResolveAddErrorData struct{}
+
+type TestBytecodeResolveAddErrorUser struct {
+	Name  string
+	Email string
+}
+
+func (TestBytecodeResolveAddErrorData) ResolveUsers(ctx *Ctx) []TestBytecodeResolveAddErrorUser {
+	ctx.AddError(errors.New("email not verified"), 1, "email")
+	ctx.AddError(errors.New("quoted \"name\""), "with \"quotes\"")
+	ctx.AddError(nil, 0)
+	return []TestBytecodeResolveAddErrorUser{{Name: "a"}, {Name: "b"}}
+}
+
+func (TestBytecodeResolveAddErrorData) ResolveInvalid(ctx *Ctx) string {
+	ctx.AddError(errors.New("invalid"), 1.5)
+	return ""
+}
+
+func TestBytecodeResolveAddError(t *testing.T) {
+	res, errs := bytecodeParse(t, NewSchema(), `{users {name}}`, TestBytecodeResolveAddErrorData{}, M{}, ResolveOptions{})
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, `{"data":{"users":[{"name":"a"},{"name":"b"}]},"errors":[{"message":"email not verified","path":["users",1,"email"],"locations":[{"line":1,"column":2}]},{"message":"quoted \"name\"","path":["users","with \"quotes\""],"locations":[{"line":1,"column":2}]}],"extensions":{}}`, res)
+	a.Equal(t, `["users",1,"email"]`, string(errs[0].(ErrorWPath).Path()))
+
+	s := NewSchema()
+	s.SetErrorPresenter(func(ctx *Ctx, err error) error {
+		return errors.New("presented")
+	})
+	_, errs = bytecodeParse(t, s, `{users {name}}`, TestBytecodeResolveAddErrorData{}, M{})
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, "presented", errs[0].Error())
+
+	// Unsupported path segments panic and are reported by the panic handler
+	res, errs = bytecodeParse(t, NewSchema(), `{invalid}`, TestBytecodeResolveAddErrorData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, `{"invalid":null}`, res)
+}
+
+func TestCtxContext(t *testing.T) {
+	ctx := &Ctx{}
+	a.Nil(t, ctx.GetContext())
+	a.Equal(t, context.Background(), ctx.Context())
+
+	c := context.WithValue(context.Background(), "key", "value")
+	ctx.SetContext(c)
+	a.Equal(t, c, ctx.Context())
+}
+
 func TestBytecodeResolveQueryCache(t *testing.T) {
 	testCases := []struct {
 		query  string
@@ -1912,6 +2469,34 @@ func TestBytecodeResolveQueryCache(t *testing.T) {
 	}
 }
 
+func TestQueryCacheStats(t *testing.T) {
+	s := NewSchema()
+	err := s.Parse(TestResolveSimpleQueryData{A: "1"}, M{}, nil)
+	a.NoError(t, err)
+
+	cacheQueryFromLen := 0
+	s.SetCacheRules(&cacheQueryFromLen)
+	s.PurgeQueryCache()
+	s.SetQueryCacheLimits(1, 0)
+	initialStats := s.QueryCacheStats()
+
+	// The cache and the cache rules are shared with the copy
+	c := s.Copy()
+	for _, query := range []string{`{a}`, `{a}`, `{a b}`, `{a}`} {
+		errs := c.Resolve([]byte(query), ResolveOptions{NoMeta: true})
+		a.Equal(t, 0, len(errs))
+	}
+
+	stats := s.QueryCacheStats()
+	a.Equal(t, initialStats.Hits+1, stats.Hits)
+	a.Equal(t, initialStats.Misses+3, stats.Misses)
+	a.Equal(t, initialStats.Evictions+2, stats.Evictions)
+	a.Equal(t, 1, stats.Entries)
+
+	s.PurgeQueryCache()
+	a.Equal(t, 0, c.QueryCacheStats().Entries)
+}
+
 type TestBytecodeResolveIDData struct {
 	DirectID int                    `gq:"directId,id"`
 	MethodID func() (int, AttrIsID) `gq:"methodId"`
@@ -1928,3 +2513,101 @@ func TestBytecodeResolveID(t *testing.T) {
 	out := bytecodeParseAndExpectNoErrs(t, query, schema, M{})
 	a.Equal(t, `{"directId":"2","methodId":"3"}`, out)
 }
+
+func TestBytecodeResolveMaxAliasesAndRootFields(t *testing.T) {
+	s := NewSchema()
+	s.MaxAliases = 2
+	s.MaxRootFields = 3
+
+	_, errs := bytecodeParse(t, s, `{a1: a a2: a b}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 0, len(errs))
+
+	res, errs := bytecodeParse(t, s, `{a1: a a2: a a3: a}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "query contains more than the max of 2 aliases", errs[0].Error())
+	a.Equal(t, `{}`, res)
+
+	_, errs = bytecodeParse(t, s, `{a b c d}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "operation selects more than the max of 3 root fields", errs[0].Error())
+}
+
+func TestBytecodeResolveMaxQueryLengthAndTokens(t *testing.T) {
+	s := NewSchema()
+	s.MaxQueryLength = 10
+	s.MaxTokens = 4
+
+	_, errs := bytecodeParse(t, s, `{a}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 0, len(errs))
+
+	res, errs := bytecodeParse(t, s, `{a b c d e}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "query is longer than the max of 10 bytes", errs[0].Error())
+	a.Equal(t, `{}`, res)
+
+	_, errs = bytecodeParse(t, s, `{a b c d}`, TestResolveSimpleQueryData{}, M{})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "query contains more than the max of 4 tokens", errs[0].Error())
+}
+
+func TestBytecodeResolveMaxResultSize(t *testing.T) {
+	s := NewSchema()
+	s.MaxResultSize = 20
+
+	res, errs := bytecodeParse(t, s, `{a}`, TestResolveSimpleQueryData{A: "a"}, M{}, ResolveOptions{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"data":{"a":"a"}}`, res)
+
+	res, errs = bytecodeParse(t, s, `{a b c d}`, TestResolveSimpleQueryData{A: "aaaaa", B: "b", C: "c", D: "d"}, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "response is larger than the max of 20 bytes", errs[0].Error())
+	a.Equal(t, `{}`, res)
+
+	// The precomputed introspection result is checked as well
+	_, errs = bytecodeParse(t, s, IntrospectionQuery, TestResolveSimpleQueryData{}, M{})
+	a.True(t, len(s.precomputed) > 0)
+	a.Equal(t, 1, len(errs))
+}
+
+func TestBytecodeResolveMaxListItems(t *testing.T) {
+	schema := TestBytecodeResolveErrorPathData{Users: []TestBytecodeResolveErrorPathUser{
+		{},
+		{Friends: []TestBytecodeResolveErrorPathFriend{{Nr: 1}, {Nr: 2}, {Nr: 3}}},
+	}}
+
+	s := NewSchema()
+	s.MaxListItems = 5
+	res, errs := bytecodeParse(t, s, `{users {friends {nr}}}`, schema, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"users":[{"friends":null},{"friends":[{"nr":1},{"nr":2},{"nr":3}]}]}`, res)
+
+	// The items of the nested lists are added to the items of the parent list
+	s = NewSchema()
+	s.MaxListItems = 4
+	res, errs = bytecodeParse(t, s, `{users {friends {nr}} other: users {friends {nr}}}`, schema, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "response contains more than the max of 4 list items", errs[0].Error())
+	a.Equal(t, `["users",1,"friends"]`, string(errs[0].(ErrorWPath).Path()))
+	a.Equal(t, `{"users":[{"friends":null},{"friends":null}],"other":null}`, res)
+
+	// Lists within introspection fields are not counted
+	_, errs = bytecodeParse(t, s, `{__schema {types {name}}}`, schema, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+}
+
+func TestResultBufferSize(t *testing.T) {
+	s := NewSchema()
+	s.InitialResultSize = 10
+	s.MaxRetainedResultSize = 100
+	a.NoError(t, s.Parse(TestResolveSimpleQueryData{A: strings.Repeat("a", 200)}, M{}, nil))
+	a.Equal(t, 10, cap(s.Result))
+
+	c := s.Copy()
+	a.Equal(t, 10, cap(c.Result))
+
+	errs := c.Resolve([]byte(`{a}`), ResolveOptions{})
+	a.Equal(t, 0, len(errs))
+	a.True(t, cap(c.Result) > 100)
+	c.release()
+	a.Equal(t, 10, cap(c.Result))
+}
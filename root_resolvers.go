@@ -0,0 +1,246 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// rootResolver is a standalone function that is added as a field to the query or mutation root
+type rootResolver struct {
+	isMutation bool
+	name       string
+	fn         reflect.Value
+	// remote is set for fields added using AddRemoteQuery or AddRemoteMutation
+	remote *RemoteField
+}
+
+// AddQueryResolver adds fn as a field with the name name to the query root
+// This allows packages to contribute query fields without editing the query root struct
+// fn follows the same rules as methods defined on structs
+//
+// If the schema is already parsed the field is added to its root and the schema is reloaded, see (*yarql.Schema).Reload
+//
+// Example:
+//
+//	s.AddQueryResolver("pluginVersion", func() string {
+//		return "1.0.0"
+//	})
+func (s *Schema) AddQueryResolver(name string, fn interface{}) error {
+	if s.parsed {
+		return s.reloadWithRootResolver(false, name, fn)
+	}
+	return s.addRootResolver(false, name, fn)
+}
+
+// AddMutationResolver adds fn as a field with the name name to the mutation root
+// See (*yarql.Schema).AddQueryResolver() for more info
+func (s *Schema) AddMutationResolver(name string, fn interface{}) error {
+	if s.parsed {
+		return s.reloadWithRootResolver(true, name, fn)
+	}
+	return s.addRootResolver(true, name, fn)
+}
+
+// reloadWithRootResolver adds a root resolver to a parsed schema
+// The resolver is added to the roots of s and to the registrations of s, the schema is then reloaded so requests handled by Exec also see the field
+// If adding the field to s or reloading the schema fails neither s nor the reloaded schema are changed
+func (s *Schema) reloadWithRootResolver(isMutation bool, name string, fn interface{}) error {
+	if s.reload == nil {
+		return errors.New("root resolvers cannot be added to a copy of a parsed schema")
+	}
+
+	s.reload.lock.Lock()
+	defer s.reload.lock.Unlock()
+
+	// The registrations are shared with copies of the schema so the resolver is added to a copy of them
+	previous := s.reload.registered
+	registered := previous.registrations()
+	err := registered.addRootResolver(isMutation, name, fn)
+	if err != nil {
+		return err
+	}
+
+	// Resolve, the SDL and the introspection of s use s itself, copies of s are made while holding the lock so they do not see a half added field
+	snapshot := s.snapshotRoots(isMutation, name)
+	err = s.addParsedRootResolver(registered.rootResolvers[len(registered.rootResolvers)-1])
+	if err != nil {
+		s.restoreRoots(snapshot)
+		return err
+	}
+
+	s.reload.registered = registered
+	err = s.reloadLocked(s.reload.queries, s.reload.methods, s.reload.options)
+	if err != nil {
+		s.reload.registered = previous
+		s.restoreRoots(snapshot)
+		return err
+	}
+
+	s.linkRefs()
+
+	// Forget the introspection values generated for the previous roots
+	s.graphqlTypesMap = nil
+	s.graphqlTypesList = nil
+	s.graphqlObjFields = map[string][]qlField{}
+	if len(s.precomputed) > 0 {
+		s.precomputed = nil
+		s.precomputeIntrospection()
+	}
+	return nil
+}
+
+// rootsSnapshot contains the parts of a parsed schema that are changed by adding a root resolver to it
+type rootsSnapshot struct {
+	root          *obj
+	name          string
+	field         *obj // the field with name on root before the resolver was added
+	types         types
+	typeVariants  types
+	interfaces    types
+	inTypes       inputMap
+	auth          bool
+	rootResolvers int
+}
+
+// snapshotRoots returns the state of s that is restored by restoreRoots
+func (s *Schema) snapshotRoots(isMutation bool, name string) rootsSnapshot {
+	root := s.rootQuery
+	if isMutation {
+		root = s.rootMethod
+	}
+	field, _ := root.objContents.getByName(name)
+
+	snapshot := rootsSnapshot{
+		root:          root,
+		name:          name,
+		field:         field,
+		types:         types{},
+		typeVariants:  types{},
+		interfaces:    types{},
+		inTypes:       inputMap{},
+		auth:          s.auth,
+		rootResolvers: len(s.rootResolvers),
+	}
+	for key, value := range s.types {
+		snapshot.types[key] = value
+	}
+	for key, value := range s.typeVariants {
+		snapshot.typeVariants[key] = value
+	}
+	for key, value := range s.interfaces {
+		snapshot.interfaces[key] = value
+	}
+	for key, value := range s.inTypes {
+		snapshot.inTypes[key] = value
+	}
+	return snapshot
+}
+
+// restoreRoots undoes adding a root resolver to s
+func (s *Schema) restoreRoots(snapshot rootsSnapshot) {
+	if snapshot.field != nil {
+		snapshot.root.objContents.set(snapshot.field)
+	} else {
+		snapshot.root.objContents.remove(snapshot.name)
+	}
+
+	s.types = snapshot.types
+	s.typeVariants = snapshot.typeVariants
+	s.interfaces = snapshot.interfaces
+	s.inTypes = snapshot.inTypes
+	s.auth = snapshot.auth
+	s.rootResolvers = s.rootResolvers[:snapshot.rootResolvers]
+}
+
+// addParsedRootResolver adds resolver to the roots of the parsed schema s
+func (s *Schema) addParsedRootResolver(resolver rootResolver) error {
+	s.rootResolvers = append(s.rootResolvers, resolver)
+	ctx := &parseCtx{
+		schema:        s,
+		parsedMethods: []*objMethod{},
+	}
+	err := ctx.checkRootResolver(&s.rootResolvers[len(s.rootResolvers)-1])
+	if err != nil {
+		return err
+	}
+	for _, method := range ctx.parsedMethods {
+		err = ctx.checkFunctionIns(method)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) addRootResolver(isMutation bool, name string, fn interface{}) error {
+	err := validGraphQlName([]byte(name))
+	if err != nil {
+		return errors.New(name + " is not a valid graphql field name")
+	}
+	if fn == nil {
+		return errors.New("fn cannot be nil")
+	}
+
+	fnValue := reflect.ValueOf(fn)
+	if fnValue.Kind() != reflect.Func {
+		return errors.New("fn must be a function")
+	}
+	if fnValue.IsNil() {
+		return errors.New("fn cannot be nil")
+	}
+
+	for _, resolver := range s.rootResolvers {
+		if resolver.isMutation == isMutation && resolver.name == name {
+			return errors.New("resolver " + name + " is already added")
+		}
+	}
+
+	s.rootResolvers = append(s.rootResolvers, rootResolver{
+		isMutation: isMutation,
+		name:       name,
+		fn:         fnValue,
+	})
+	return nil
+}
+
+// checkRootResolvers adds the root resolvers to the already parsed query and mutation roots
+func (c *parseCtx) checkRootResolvers() error {
+	for idx := range c.schema.rootResolvers {
+		err := c.checkRootResolver(&c.schema.rootResolvers[idx])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRootResolver adds resolver to the already parsed query or mutation root
+func (c *parseCtx) checkRootResolver(resolver *rootResolver) error {
+	root := c.schema.rootQuery
+	if resolver.isMutation {
+		root = c.schema.rootMethod
+	}
+
+	if _, ok := root.objContents.getByName(resolver.name); ok {
+		return errors.New("cannot add resolver " + resolver.name + ", field already defined on " + root.typeName)
+	}
+
+	if resolver.remote != nil {
+		remoteObj, err := c.remoteFieldObj(resolver)
+		if err != nil {
+			return err
+		}
+		root.objContents.set(remoteObj)
+		return nil
+	}
+
+	functionObj, err := c.checkStructFieldFunc(resolver.name, resolver.fn.Type(), false, -1)
+	if err != nil {
+		return err
+	}
+
+	functionObj.customObjValue = &resolver.fn
+	functionObj.qlFieldName = []byte(resolver.name)
+	root.objContents.set(functionObj)
+	return nil
+}
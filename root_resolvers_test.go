@@ -0,0 +1,142 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestRootResolversQ struct {
+	Name string
+}
+
+type TestRootResolversM struct{}
+
+type TestRootResolversPlugin struct {
+	Version string
+}
+
+func TestAddQueryResolver(t *testing.T) {
+	s := NewSchema()
+	err := s.AddQueryResolver("plugin", func() TestRootResolversPlugin {
+		return TestRootResolversPlugin{Version: "1.0.0"}
+	})
+	a.NoError(t, err)
+	err = s.AddQueryResolver("double", func(args struct{ Value int }) int {
+		return args.Value * 2
+	})
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `{name plugin {version} double(value: 21)}`, TestRootResolversQ{Name: "root"}, TestRootResolversM{})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"name":"root","plugin":{"version":"1.0.0"},"double":42}`, res)
+}
+
+func TestAddMutationResolver(t *testing.T) {
+	s := NewSchema()
+	called := false
+	err := s.AddMutationResolver("ping", func(ctx *Ctx) string {
+		called = true
+		return "pong"
+	})
+	a.NoError(t, err)
+
+	res, errs := bytecodeParse(t, s, `mutation {ping}`, TestRootResolversQ{}, TestRootResolversM{})
+	for _, err := range errs {
+		panic(err)
+	}
+	a.Equal(t, `{"ping":"pong"}`, res)
+	a.True(t, called)
+}
+
+func TestAddRootResolverInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.AddQueryResolver("", func() string { return "" }))
+	a.Error(t, s.AddQueryResolver("1abc", func() string { return "" }))
+	a.Error(t, s.AddQueryResolver("abc", nil))
+	a.Error(t, s.AddQueryResolver("abc", "not a function"))
+	a.Error(t, s.AddQueryResolver("abc", (func() string)(nil)))
+
+	a.NoError(t, s.AddQueryResolver("abc", func() string { return "" }))
+	a.Error(t, s.AddQueryResolver("abc", func() string { return "" }))
+	// A query and mutation can share the same name
+	a.NoError(t, s.AddMutationResolver("abc", func() string { return "" }))
+}
+
+func TestAddRootResolverAlreadyDefined(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.AddQueryResolver("name", func() string { return "" }))
+	err := s.Parse(TestRootResolversQ{}, TestRootResolversM{}, nil)
+	a.Error(t, err)
+}
+
+func TestAddRootResolverAfterParse(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestRootResolversQ{Name: "root"}, TestRootResolversM{}, nil))
+	copied := s.Copy()
+
+	// The schema is reloaded with the resolvers added to the roots
+	a.NoError(t, s.AddQueryResolver("abc", func() string { return "query" }))
+	a.NoError(t, s.AddMutationResolver("abc", func() string { return "mutation" }))
+	a.Error(t, s.AddQueryResolver("abc", func() string { return "" }))
+	a.Error(t, s.AddQueryResolver("name", func() string { return "" }))
+
+	res, errs := s.Exec([]byte(`{name abc}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"root","abc":"query"}`, string(res))
+
+	res, errs = s.Exec([]byte(`mutation {abc}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"abc":"mutation"}`, string(res))
+
+	// The fields are also added to s itself
+	errs = s.Resolve([]byte(`{name abc}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"name":"root","abc":"query"}`, string(s.Result))
+	a.True(t, strings.Contains(s.SDL(), "\tabc: String\n"))
+	introspection, err := s.IntrospectionJSON()
+	a.NoError(t, err)
+	a.True(t, strings.Contains(string(introspection), `"name":"abc"`))
+
+	// Copies of the schema keep their own root resolvers
+	_, errs = copied.Exec([]byte(`{abc}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.NoError(t, copied.AddQueryResolver("abc", func() string { return "copy" }))
+	res, errs = copied.Exec([]byte(`{abc}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"abc":"copy"}`, string(res))
+
+	// Reloading keeps the added resolvers
+	a.NoError(t, s.Reload(TestRootResolversQ{Name: "reloaded"}, TestRootResolversM{}, nil))
+	res, errs = s.Exec([]byte(`{name abc}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"reloaded","abc":"query"}`, string(res))
+}
+
+type TestRootResolversReloadedQ struct {
+	Other string
+}
+
+func TestAddRootResolverAfterParseFails(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestRootResolversQ{Name: "root"}, TestRootResolversM{}, nil))
+	a.NoError(t, s.Reload(TestRootResolversReloadedQ{Other: "reloaded"}, TestRootResolversM{}, nil))
+
+	// The reloaded roots have no name field but the roots of s do, so the field is not added anywhere
+	a.Error(t, s.AddQueryResolver("name", func() string { return "resolver" }))
+	_, errs := s.Exec([]byte(`{name}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	errs = s.Resolve([]byte(`{name}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"name":"root"}`, string(s.Result))
+
+	// The failed resolver is not kept
+	a.NoError(t, s.Reload(TestRootResolversReloadedQ{Other: "reloaded"}, TestRootResolversM{}, nil))
+	a.NoError(t, s.AddQueryResolver("abc", func() string { return "query" }))
+	res, errs := s.Exec([]byte(`{other abc}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"other":"reloaded","abc":"query"}`, string(res))
+}
@@ -0,0 +1,73 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+
+	h "github.com/mjarkk/yarql/helpers"
+)
+
+// Scalar describes a custom scalar, see (*yarql.Schema).RegisterScalar()
+type Scalar struct {
+	// Name is the graphql name of the scalar, defaults to the name of the go type
+	Name        string
+	Description string
+	// SpecifiedByURL links to a specification of the data format of the scalar
+	SpecifiedByURL string
+}
+
+// RegisterScalar registers goType as custom scalar
+// goType must be a named type with a bool, int, uint, float or string kind, values are send and parsed as their kind
+//
+// Example:
+//
+//	type UUID string
+//
+//	s.RegisterScalar(UUID(""), yarql.Scalar{
+//		SpecifiedByURL: "https://tools.ietf.org/html/rfc4122",
+//	})
+func (s *Schema) RegisterScalar(goType interface{}, scalar Scalar) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterScalar() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+
+	t := reflect.TypeOf(goType)
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
+	default:
+		return errors.New("can only register scalars of bool, int, uint, float or string kinds")
+	}
+	if t.Name() == "" || t.PkgPath() == "" {
+		return errors.New("cannot register an inline type as scalar")
+	}
+	if _, enum := s.getEnum(t); enum != nil {
+		return errors.New(t.Name() + " is already registered as enum")
+	}
+
+	if len(scalar.Name) == 0 {
+		scalar.Name = t.Name()
+	}
+	err := validGraphQlName([]byte(scalar.Name))
+	if err != nil {
+		return err
+	}
+	if _, ok := scalars[scalar.Name]; ok {
+		return errors.New("cannot overwrite the build in " + scalar.Name + " scalar")
+	}
+	for _, existing := range s.customScalars {
+		if *existing.Name == scalar.Name {
+			return errors.New("a scalar with the name " + scalar.Name + " is already registered")
+		}
+	}
+
+	s.customScalars[t] = &qlType{
+		Kind:           typeKindScalar,
+		Name:           h.StrPtr(scalar.Name),
+		Description:    h.StrPtr(scalar.Description),
+		SpecifiedByURL: h.CheckStrPtr(scalar.SpecifiedByURL),
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestScalarUUID string
+
+type TestScalarQuery struct {
+	Value    TestScalarUUID
+	Optional *TestScalarUUID
+	List     []TestScalarUUID
+}
+
+func (TestScalarQuery) ResolveEcho(args struct{ Value TestScalarUUID }) TestScalarUUID {
+	return args.Value
+}
+
+func registerTestUUIDScalar(t *testing.T, s *Schema) {
+	a.NoError(t, s.RegisterScalar(TestScalarUUID(""), Scalar{
+		Name:           "UUID",
+		Description:    "A universally unique identifier",
+		SpecifiedByURL: "https://tools.ietf.org/html/rfc4122",
+	}))
+}
+
+func TestScalarResolve(t *testing.T) {
+	s := NewSchema()
+	registerTestUUIDScalar(t, s)
+	res, errs := bytecodeParse(t, s, `{value echo(value: "b")}`, TestScalarQuery{Value: "a"}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"value":"a","echo":"b"}`, res)
+}
+
+func TestScalarIntrospection(t *testing.T) {
+	s := NewSchema()
+	registerTestUUIDScalar(t, s)
+	res, errs := bytecodeParse(t, s, `{__type(name: "UUID") {kind name description specifiedByURL}}`, TestScalarQuery{}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"kind":"SCALAR","name":"UUID","description":"A universally unique identifier","specifiedByURL":"https://tools.ietf.org/html/rfc4122"}}`, res)
+
+	s = NewSchema()
+	registerTestUUIDScalar(t, s)
+	res, errs = bytecodeParse(t, s, `{__type(name: "TestScalarQuery") {fields {name type {name ofType {name}} args {type {ofType {name}}}}}}`, TestScalarQuery{}, M{})
+	a.Equal(t, 0, len(errs))
+	a.True(t, strings.Contains(res, `{"name":"echo","type":{"name":null,"ofType":{"name":"UUID"}},"args":[{"type":{"ofType":null}}]}`), res)
+	a.True(t, strings.Contains(res, `{"name":"optional","type":{"name":"UUID","ofType":null},"args":[]}`), res)
+
+	s = NewSchema()
+	registerTestUUIDScalar(t, s)
+	res, errs = bytecodeParse(t, s, `{__type(name: "String") {specifiedByURL}}`, TestScalarQuery{}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"specifiedByURL":null}}`, res)
+}
+
+func TestScalarSDL(t *testing.T) {
+	s := NewSchema()
+	registerTestUUIDScalar(t, s)
+	a.NoError(t, s.Parse(TestScalarQuery{}, M{}, nil))
+
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "\"\"\"\nA universally unique identifier\n\"\"\"\nscalar UUID @specifiedBy(url: \"https://tools.ietf.org/html/rfc4122\")\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\tvalue: UUID!\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\tlist: [UUID!]\n"), sdl)
+}
+
+func TestSchemaDescription(t *testing.T) {
+	s := NewSchema()
+	res, errs := bytecodeParseWithOptions(t, s, &SchemaOptions{Description: "The test schema"}, `{__schema {description}}`, TestScalarQuery{}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__schema":{"description":"The test schema"}}`, res)
+	a.True(t, strings.HasPrefix(s.SDL(), "\"\"\"\nThe test schema\n\"\"\"\nschema {\n\tquery: TestScalarQuery\n}\n"), s.SDL())
+
+	res, errs = bytecodeParse(t, NewSchema(), `{__schema {description}}`, TestScalarQuery{}, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__schema":{"description":null}}`, res)
+}
+
+type TestScalarEnum int
+
+func TestRegisterScalarInvalid(t *testing.T) {
+	s := NewSchema()
+	_, err := s.RegisterEnum(map[string]TestScalarEnum{"A": 0})
+	a.NoError(t, err)
+
+	a.Error(t, s.RegisterScalar(nil, Scalar{}))
+	a.Error(t, s.RegisterScalar("", Scalar{}))
+	a.Error(t, s.RegisterScalar(TestScalarQuery{}, Scalar{}))
+	a.Error(t, s.RegisterScalar(TestScalarEnum(0), Scalar{}))
+	a.Error(t, s.RegisterScalar(TestScalarUUID(""), Scalar{Name: "String"}))
+	a.Error(t, s.RegisterScalar(TestScalarUUID(""), Scalar{Name: "in valid"}))
+	a.NoError(t, s.RegisterScalar(TestScalarUUID(""), Scalar{}))
+	a.Error(t, s.RegisterScalar(TestScalarUUID(""), Scalar{}))
+}
+
+type TestInt64Query struct {
+	Small    int
+	Large    int64
+	Unsigned uint64
+	Big      int64  `gq:",int64"`
+	BigPtr   *int64 `gq:",int64"`
+	BigList  []uint `gq:",int64"`
+	Echo     func(args struct {
+		Value int64 `gq:",int64"`
+	}) int64 `gq:",int64"`
+	Add func(args struct {
+		Value    int64
+		Unsigned uint64
+	}) int
+}
+
+func TestInt64(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestInt64Query{
+		Small:    1,
+		Large:    1 << 40,
+		Unsigned: 1 << 31,
+		Big:      1 << 40,
+		BigList:  []uint{1, 1 << 40},
+		Echo: func(args struct {
+			Value int64 `gq:",int64"`
+		}) int64 {
+			return args.Value
+		},
+		Add: func(args struct {
+			Value    int64
+			Unsigned uint64
+		}) int {
+			return int(args.Value) + int(args.Unsigned)
+		},
+	}, M{}, nil))
+
+	errs := s.Resolve([]byte(`{small big bigPtr bigList}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"small":1,"big":"1099511627776","bigPtr":null,"bigList":["1","1099511627776"]}`, string(s.Result))
+
+	// Values that do not fit in the 32-bit Int scalar are reported as field errors
+	errs = s.Resolve([]byte(`{small large unsigned}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, "Int cannot represent non 32-bit signed integer value: 1099511627776", errs[0].Error())
+	a.Equal(t, "Int cannot represent non 32-bit signed integer value: 2147483648", errs[1].Error())
+	a.Equal(t, `{"small":1,"large":null,"unsigned":null}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{a: echo(value: "1099511627776") b: echo(value: 12)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"1099511627776","b":"12"}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`query($a: Int64, $b: String) {a: echo(value: $a) b: echo(value: $b)}`), ResolveOptions{NoMeta: true, Variables: `{"a": "-1099511627776", "b": "1"}`})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"-1099511627776","b":"1"}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{echo(value: "abc")}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+
+	// Int inputs outside of the 32-bit range are rejected unless labeled with the int64 tag
+	errs = s.Resolve([]byte(`{add(value: 2147483646, unsigned: 1)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"add":2147483647}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{add(value: 1099511627776, unsigned: 1)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "Int cannot represent non 32-bit signed integer value: 1099511627776", errs[0].Error())
+
+	errs = s.Resolve([]byte(`{add(value: 1, unsigned: 2147483648)}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "Int cannot represent non 32-bit signed integer value: 2147483648", errs[0].Error())
+
+	errs = s.Resolve([]byte(`query($value: Int!, $unsigned: Int!) {add(value: $value, unsigned: $unsigned)}`), ResolveOptions{NoMeta: true, Variables: `{"value": -2147483649, "unsigned": 1}`})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "Int cannot represent non 32-bit signed integer value: -2147483649", errs[0].Error())
+
+	errs = s.Resolve([]byte(`query($value: Int!, $unsigned: Int!) {add(value: $value, unsigned: $unsigned)}`), ResolveOptions{NoMeta: true, Variables: `{"value": 1, "unsigned": 4294967296}`})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "Int cannot represent non 32-bit signed integer value: 4294967296", errs[0].Error())
+
+	errs = s.Resolve([]byte(`query($a: Int64) {a: echo(value: $a) b: echo(value: 1099511627776)}`), ResolveOptions{NoMeta: true, Variables: `{"a": 1099511627776}`})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"a":"1099511627776","b":"1099511627776"}`, string(s.Result))
+
+	errs = s.Resolve([]byte(`{__type(name: "TestInt64Query") {fields {name type {name ofType {name}}}}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	res := string(s.Result)
+	a.True(t, strings.Contains(res, `{"name":"big","type":{"name":null,"ofType":{"name":"Int64"}}}`), res)
+	a.True(t, strings.Contains(res, `{"name":"bigPtr","type":{"name":"Int64","ofType":null}}`), res)
+
+	a.Error(t, NewSchema().Parse(struct {
+		Name string `gq:",int64"`
+	}{}, M{}, nil))
+	a.Error(t, NewSchema().Parse(struct {
+		ID int `gq:",id,int64"`
+	}{}, M{}, nil))
+}
+
+func TestScalarVariable(t *testing.T) {
+	s := NewSchema()
+	registerTestUUIDScalar(t, s)
+	res, errs := bytecodeParse(t, s, `query($u: UUID!) {echo(value: $u)}`, TestScalarQuery{}, M{}, ResolveOptions{NoMeta: true, Variables: `{"u": "c"}`})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"echo":"c"}`, res)
+
+	// The type of the underlying go type is also accepted
+	s = NewSchema()
+	registerTestUUIDScalar(t, s)
+	res, errs = bytecodeParse(t, s, `query($u: String!) {echo(value: $u)}`, TestScalarQuery{}, M{}, ResolveOptions{NoMeta: true, Variables: `{"u": "d"}`})
+	a.Equal(t, 0, len(errs), errs)
+	a.Equal(t, `{"echo":"d"}`, res)
+
+	s = NewSchema()
+	registerTestUUIDScalar(t, s)
+	_, errs = bytecodeParse(t, s, `query($u: Int!) {echo(value: $u)}`, TestScalarQuery{}, M{}, ResolveOptions{NoMeta: true, Variables: `{"u": 1}`})
+	a.Equal(t, 1, len(errs))
+}
@@ -0,0 +1,181 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// fieldScope is a scope registered using (*Schema).RegisterFieldScope
+type fieldScope struct {
+	goType    reflect.Type
+	fieldName string
+	scope     string
+}
+
+// RegisterFieldScope limits a field to the schema views of scope, see (*Schema).View
+// fieldName is the graphql name of the field, this can also be a method or root resolver
+// The scope can also be set using the scope field tag (`gq:",scope=internal"`)
+//
+// Example:
+//
+//	s.RegisterFieldScope(QueryRoot{}, "auditLog", "internal")
+func (s *Schema) RegisterFieldScope(goType interface{}, fieldName string, scope string) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterFieldScope() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if goType == nil {
+		return errors.New("goType cannot be nil")
+	}
+	if len(scope) == 0 {
+		return errors.New("scope cannot be empty")
+	}
+
+	t, ok := structOrInterfaceType(goType)
+	if !ok {
+		return errors.New("can only register scopes on fields of struct and interface types")
+	}
+
+	s.fieldScopes = append(s.fieldScopes, fieldScope{
+		goType:    t,
+		fieldName: fieldName,
+		scope:     scope,
+	})
+	return nil
+}
+
+// checkFieldScopes sets the scopes registered using (*Schema).RegisterFieldScope on the fields
+func (c *parseCtx) checkFieldScopes() error {
+	for _, fieldScope := range c.schema.fieldScopes {
+		typeObj, ok, err := c.registeredType(fieldScope.goType)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("cannot register a scope on " + fieldScope.goType.String())
+		}
+		field, ok := typeObj.objContents.getByName(fieldScope.fieldName)
+		if !ok {
+			return errors.New(typeObj.typeName + " has no field " + fieldScope.fieldName + " to register the scope on")
+		}
+		field.scope = fieldScope.scope
+	}
+	return nil
+}
+
+// View returns a copy of the schema that only contains the fields without a scope and the fields with scope
+// Fields of other scopes are left out of the introspection and the SDL and cannot be queried, the schema View is called on contains all fields
+// Types that are only used by fields of other scopes are left out of the introspection and the SDL as well
+// The view is created from the parsed schema so the go types are not parsed again and the introspection of the view is precomputed
+//
+// Example:
+//
+//	type QueryRoot struct {
+//		Users    []User
+//		AuditLog []AuditEntry `gq:",scope=internal"`
+//	}
+//
+//	s.Parse(QueryRoot{}, MethodRoot{}, nil)
+//	public := s.View("public")     // Users
+//	internal := s.View("internal") // Users and AuditLog
+func (s *Schema) View(scope string) *Schema {
+	res := s.Copy()
+	res.view = scope
+	res.precomputed = nil
+	res.precomputeIntrospection()
+	return res
+}
+
+// inView returns false if the schema is a view and scope is another scope than the view's scope
+func (s *Schema) inView(scope string) bool {
+	return len(scope) == 0 || len(s.view) == 0 || scope == s.view
+}
+
+// viewQLFields returns the introspection fields that are part of the view
+func (s *Schema) viewQLFields(fields []qlField) []qlField {
+	if len(s.view) == 0 {
+		return fields
+	}
+
+	res := make([]qlField, 0, len(fields))
+	for _, field := range fields {
+		if s.inView(field.scope) {
+			res = append(res, field)
+		}
+	}
+	return res
+}
+
+// viewQLTypes leaves out the types that are only used by fields outside of the view
+// Types that are not used by any field, like entities and the types of directive arguments, are kept
+func (s *Schema) viewQLTypes(all []qlType) []qlType {
+	typesByName := make(map[string]qlType, len(all))
+	for _, qlType := range all {
+		typesByName[*qlType.Name] = qlType
+	}
+	used := s.usedQLTypes(typesByName, false)
+	usedInView := s.usedQLTypes(typesByName, true)
+
+	res := make([]qlType, 0, len(all))
+	for _, qlType := range all {
+		name := *qlType.Name
+		if !used[name] || usedInView[name] {
+			res = append(res, qlType)
+		}
+	}
+	return res
+}
+
+// usedQLTypes returns the names of the types that can be reached from the roots and directives of the schema
+// If inView is set only the fields that are part of the view are followed
+func (s *Schema) usedQLTypes(typesByName map[string]qlType, inView bool) map[string]bool {
+	used := map[string]bool{}
+	var use func(t *qlType)
+	use = func(t *qlType) {
+		for t != nil && t.OfType != nil {
+			t = t.OfType
+		}
+		if t == nil || t.Name == nil || used[*t.Name] {
+			return
+		}
+		typeDef, ok := typesByName[*t.Name]
+		if !ok {
+			return
+		}
+		used[*t.Name] = true
+
+		if typeDef.Fields != nil {
+			fields := typeDef.Fields(nil, isDeprecatedArgs{})
+			if inView {
+				fields = s.viewQLFields(fields)
+			}
+			for _, field := range fields {
+				use(&field.Type)
+				for _, arg := range field.Args {
+					use(&arg.Type)
+				}
+			}
+		}
+		for idx := range typeDef.Interfaces {
+			use(&typeDef.Interfaces[idx])
+		}
+		if typeDef.PossibleTypes != nil {
+			for _, possibleType := range typeDef.PossibleTypes() {
+				use(&possibleType)
+			}
+		}
+		if typeDef.InputFields != nil {
+			for _, inputField := range typeDef.InputFields() {
+				use(&inputField.Type)
+			}
+		}
+	}
+
+	use(&qlType{Name: &s.rootQuery.typeName})
+	use(&qlType{Name: &s.rootMethod.typeName})
+	for _, directive := range s.getDirectives() {
+		for _, arg := range directive.Args {
+			use(&arg.Type)
+		}
+	}
+	return used
+}
@@ -0,0 +1,125 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestSchemaViewQuery struct {
+	Name     string
+	AuditLog []string `gq:",scope=internal"`
+}
+
+func (TestSchemaViewQuery) ResolveStats() int {
+	return 1
+}
+
+var testSchemaViewData = TestSchemaViewQuery{Name: "a", AuditLog: []string{"b"}}
+
+func TestSchemaView(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterFieldScope(TestSchemaViewQuery{}, "stats", "internal"))
+	res, errs := bytecodeParse(t, s, `{name auditLog stats}`, testSchemaViewData, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"a","auditLog":["b"],"stats":1}`, res)
+
+	public := s.View("public")
+	internal := s.View("internal")
+
+	query := []byte(`{name auditLog stats}`)
+	errs = internal.Resolve(query, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"a","auditLog":["b"],"stats":1}`, string(internal.Result))
+
+	errs = public.Resolve([]byte(`{name}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"name":"a"}`, string(public.Result))
+
+	errs = public.Resolve([]byte(`{auditLog}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "auditLog does not exists on TestSchemaViewQuery", errs[0].Error())
+
+	// Copies of a view are views as well
+	errs = public.Copy().Resolve([]byte(`{stats}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 1, len(errs))
+}
+
+func TestSchemaViewIntrospection(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterFieldScope(TestSchemaViewQuery{}, "stats", "internal"))
+	a.NoError(t, s.Parse(testSchemaViewData, M{}, nil))
+	public := s.View("public")
+	internal := s.View("internal")
+
+	query := []byte(`{__type(name: "TestSchemaViewQuery") {fields {name}}}`)
+	errs := public.Resolve(query, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"fields":[{"name":"name"}]}}`, string(public.Result))
+
+	errs = internal.Resolve(query, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":{"fields":[{"name":"auditLog"},{"name":"name"},{"name":"stats"}]}}`, string(internal.Result))
+
+	// The precomputed introspection of a view only contains the fields of the view
+	a.True(t, len(public.precomputed) > 0)
+	errs = public.Resolve([]byte(IntrospectionQuery), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.False(t, strings.Contains(string(public.Result), `"auditLog"`))
+	errs = internal.Resolve([]byte(IntrospectionQuery), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.True(t, strings.Contains(string(internal.Result), `"auditLog"`))
+
+	a.False(t, strings.Contains(public.SDL(), "auditLog"))
+	a.True(t, strings.Contains(internal.SDL(), "auditLog"))
+	a.True(t, strings.Contains(s.SDL(), "auditLog"))
+}
+
+type TestSchemaViewTypesQuery struct {
+	Name     string
+	AuditLog []TestSchemaViewAuditEntry `gq:",scope=internal"`
+}
+
+type TestSchemaViewAuditEntry struct {
+	Action string
+}
+
+func TestSchemaViewTypes(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestSchemaViewTypesQuery{}, M{}, nil))
+	public := s.View("public")
+	internal := s.View("internal")
+
+	// Types only used by fields outside of the view are not part of the view
+	query := []byte(`{__schema {types {name}}}`)
+	errs := public.Resolve(query, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.False(t, strings.Contains(string(public.Result), `"TestSchemaViewAuditEntry"`))
+	a.True(t, strings.Contains(string(public.Result), `"TestSchemaViewTypesQuery"`))
+	errs = internal.Resolve(query, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.True(t, strings.Contains(string(internal.Result), `"TestSchemaViewAuditEntry"`))
+
+	errs = public.Resolve([]byte(`{__type(name: "TestSchemaViewAuditEntry") {name}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"__type":null}`, string(public.Result))
+
+	a.False(t, strings.Contains(public.SDL(), "TestSchemaViewAuditEntry"))
+	a.True(t, strings.Contains(internal.SDL(), "TestSchemaViewAuditEntry"))
+	a.True(t, strings.Contains(s.SDL(), "TestSchemaViewAuditEntry"))
+}
+
+func TestRegisterFieldScopeInvalid(t *testing.T) {
+	s := NewSchema()
+	a.Error(t, s.RegisterFieldScope(nil, "name", "internal"))
+	a.Error(t, s.RegisterFieldScope("", "name", "internal"))
+	a.Error(t, s.RegisterFieldScope(TestSchemaViewQuery{}, "name", ""))
+
+	a.NoError(t, s.RegisterFieldScope(TestSchemaViewQuery{}, "unknown", "internal"))
+	a.Error(t, s.Parse(TestSchemaViewQuery{}, M{}, nil))
+
+	s = NewSchema()
+	a.NoError(t, s.Parse(TestSchemaViewQuery{}, M{}, nil))
+	a.Error(t, s.RegisterFieldScope(TestSchemaViewQuery{}, "name", "internal"))
+}
@@ -0,0 +1,320 @@
+package yarql
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// introspectionTypes are the graphql types injected to make introspection work
+// These are not part of the schema definition language output
+var introspectionTypes = map[string]bool{
+	"__Schema":            true,
+	"__Type":              true,
+	"__TypeKind":          true,
+	"__Field":             true,
+	"__InputValue":        true,
+	"__EnumValue":         true,
+	"__Directive":         true,
+	"__DirectiveLocation": true,
+}
+
+// builtinScalars are the scalars defined by the graphql spec
+var builtinScalars = map[string]bool{
+	"Boolean": true,
+	"Int":     true,
+	"Float":   true,
+	"String":  true,
+	"ID":      true,
+}
+
+// federationTypes are the graphql types injected by the EnableFederation schema option
+var federationTypes = map[string]bool{
+	"_Service": true,
+}
+
+// federationV2Link imports the federation v2 directives, see: https://www.apollographql.com/docs/federation/federated-types/federated-directives
+const federationV2Link = `extend schema @link(url: "https://specs.apollo.dev/federation/v2.3", import: ["@key", "@external", "@requires", "@provides", "@shareable", "@inaccessible", "@override", "@tag", "@interfaceObject"])
+`
+
+// builtinDirectives are the directives defined by the graphql spec
+var builtinDirectives = map[string]bool{
+	"skip":    true,
+	"include": true,
+}
+
+// SDL returns the schema formatted in the graphql schema definition language
+// Owners of types and fields are added as comments above their definitions
+func (s *Schema) SDL() string {
+	if !s.parsed {
+		panic("Schema has not been parsed yet, call Parse before attempting to generate the SDL")
+	}
+
+	res := bytes.NewBuffer(nil)
+
+	if s.federationV2 {
+		res.WriteString(federationV2Link)
+	}
+
+	hasMutation := hasVisibleFields(s.rootMethod)
+	if len(s.description) > 0 || s.rootQuery.typeName != "Query" || (hasMutation && s.rootMethod.typeName != "Mutation") {
+		writeSDLSeparator(res)
+		writeSDLDescription(res, &s.description, "")
+		res.WriteString("schema {\n\tquery: " + s.rootQuery.typeName + "\n")
+		if hasMutation {
+			res.WriteString("\tmutation: " + s.rootMethod.typeName + "\n")
+		}
+		res.WriteString("}\n")
+	}
+
+	for _, directive := range s.getDirectives() {
+		if builtinDirectives[directive.Name] {
+			continue
+		}
+
+		writeSDLSeparator(res)
+		writeSDLDescription(res, directive.Description, "")
+		res.WriteString("directive @" + directive.Name)
+		writeSDLArgs(res, directive.Args)
+		if directive.IsRepeatable {
+			res.WriteString(" repeatable")
+		}
+		res.WriteString(" on ")
+		for idx, location := range directive.Locations {
+			if idx > 0 {
+				res.WriteString(" | ")
+			}
+			res.WriteString(directiveLocationName(location))
+		}
+		res.WriteByte('\n')
+	}
+
+	if s.auth {
+		writeSDLSeparator(res)
+		res.WriteString(authDirectiveDefinition)
+	}
+	if s.hasOneOfInputs() {
+		writeSDLSeparator(res)
+		res.WriteString(oneOfDirectiveDefinition)
+	}
+
+	for _, qlType := range s.getAllQLTypes() {
+		name := *qlType.Name
+		if introspectionTypes[name] || builtinScalars[name] || federationTypes[name] {
+			continue
+		}
+		if name == s.rootMethod.typeName && !hasMutation {
+			continue
+		}
+
+		writeSDLSeparator(res)
+		writeSDLDescription(res, qlType.Description, "")
+		writeSDLOwner(res, s.TypeOwner(name), "")
+
+		switch qlType.Kind {
+		case typeKindScalar:
+			res.WriteString("scalar " + name)
+			if qlType.SpecifiedByURL != nil {
+				res.WriteString(` @specifiedBy(url: "` + *qlType.SpecifiedByURL + `")`)
+			}
+			res.WriteByte('\n')
+		case typeKindObject, typeKindInterface:
+			entity := s.entitiesByName[name]
+			if qlType.Kind == typeKindObject {
+				if entity != nil && entity.Extends {
+					res.WriteString("extend ")
+				}
+				res.WriteString("type " + name)
+			} else {
+				res.WriteString("interface " + name)
+			}
+			for idx, implements := range qlType.Interfaces {
+				if idx == 0 {
+					res.WriteString(" implements ")
+				} else {
+					res.WriteString(" & ")
+				}
+				res.WriteString(*implements.Name)
+			}
+			if entity != nil {
+				for _, key := range entity.Keys {
+					res.WriteString(` @key(fields: ` + strconv.Quote(key) + `)`)
+				}
+				if entity.InterfaceObject {
+					res.WriteString(" @interfaceObject")
+				}
+			}
+			if directives := s.federationByName[name]; directives != nil {
+				if directives.Shareable {
+					res.WriteString(" @shareable")
+				}
+				if directives.Inaccessible {
+					res.WriteString(" @inaccessible")
+				}
+				writeSDLTags(res, directives.Tags)
+			}
+			typeObj, _ := s.getTypeOrInterface(name)
+			if typeObj != nil {
+				writeSDLAuth(res, typeObj.auth)
+			}
+
+			fields := s.viewQLFields(qlType.Fields(nil, isDeprecatedArgs{}))
+			if len(fields) == 0 {
+				res.WriteByte('\n')
+				continue
+			}
+
+			res.WriteString(" {\n")
+			for _, field := range fields {
+				writeSDLDescription(res, field.Description, "\t")
+				var fieldObj *obj
+				if typeObj != nil {
+					fieldObj, _ = typeObj.objContents.getByName(field.Name)
+				}
+				if fieldObj != nil && fieldObj.owner != typeObj.owner {
+					writeSDLOwner(res, fieldObj.owner, "\t")
+				}
+				res.WriteString("\t" + field.Name)
+				writeSDLArgs(res, field.Args)
+				res.WriteString(": ")
+				writeSDLTypeRef(res, &field.Type)
+				if fieldObj != nil && s.federation {
+					writeSDLFederation(res, fieldObj.federation)
+				}
+				if fieldObj != nil {
+					writeSDLAuth(res, fieldObj.auth)
+				}
+				res.WriteByte('\n')
+			}
+			res.WriteString("}\n")
+		case typeKindEnum:
+			res.WriteString("enum " + name + " {\n")
+			for _, value := range qlType.EnumValues(isDeprecatedArgs{}) {
+				writeSDLDescription(res, value.Description, "\t")
+				res.WriteString("\t" + value.Name + "\n")
+			}
+			res.WriteString("}\n")
+		case typeKindInputObject:
+			res.WriteString("input " + name)
+			if qlType.IsOneOf != nil && *qlType.IsOneOf {
+				res.WriteString(" @oneOf")
+			}
+			res.WriteString(" {\n")
+			for _, field := range qlType.InputFields() {
+				writeSDLDescription(res, field.Description, "\t")
+				res.WriteString("\t" + field.Name + ": ")
+				writeSDLTypeRef(res, &field.Type)
+				res.WriteByte('\n')
+			}
+			res.WriteString("}\n")
+		}
+	}
+
+	return res.String()
+}
+
+func hasVisibleFields(typeObj *obj) bool {
+	for _, field := range typeObj.objContents.all() {
+		if !field.hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSDLSeparator adds an empty line between definitions
+func writeSDLSeparator(res *bytes.Buffer) {
+	if res.Len() > 0 {
+		res.WriteByte('\n')
+	}
+}
+
+func writeSDLDescription(res *bytes.Buffer, description *string, indent string) {
+	if description == nil || len(*description) == 0 {
+		return
+	}
+	res.WriteString(indent + `"""` + "\n")
+	for _, line := range strings.Split(*description, "\n") {
+		res.WriteString(indent + strings.ReplaceAll(line, `"""`, `\"""`) + "\n")
+	}
+	res.WriteString(indent + `"""` + "\n")
+}
+
+func writeSDLOwner(res *bytes.Buffer, owner string, indent string) {
+	if len(owner) > 0 {
+		res.WriteString(indent + "# owner: " + owner + "\n")
+	}
+}
+
+func writeSDLFederation(res *bytes.Buffer, federation fieldFederation) {
+	if federation.external {
+		res.WriteString(" @external")
+	}
+	if len(federation.requires) > 0 {
+		res.WriteString(` @requires(fields: ` + strconv.Quote(federation.requires) + `)`)
+	}
+	if len(federation.provides) > 0 {
+		res.WriteString(` @provides(fields: ` + strconv.Quote(federation.provides) + `)`)
+	}
+	if federation.shareable {
+		res.WriteString(" @shareable")
+	}
+	if federation.inaccessible {
+		res.WriteString(" @inaccessible")
+	}
+	if len(federation.override) > 0 {
+		res.WriteString(` @override(from: ` + strconv.Quote(federation.override) + `)`)
+	}
+	writeSDLTags(res, federation.tags)
+}
+
+func writeSDLTags(res *bytes.Buffer, tags []string) {
+	for _, tag := range tags {
+		res.WriteString(` @tag(name: ` + strconv.Quote(tag) + `)`)
+	}
+}
+
+func writeSDLArgs(res *bytes.Buffer, args []qlInputValue) {
+	if len(args) == 0 {
+		return
+	}
+	res.WriteByte('(')
+	for idx, arg := range args {
+		if idx > 0 {
+			res.WriteString(", ")
+		}
+		res.WriteString(arg.Name + ": ")
+		writeSDLTypeRef(res, &arg.Type)
+		if arg.DefaultValue != nil {
+			res.WriteString(" = " + *arg.DefaultValue)
+		}
+	}
+	res.WriteByte(')')
+}
+
+func writeSDLTypeRef(res *bytes.Buffer, qlType *qlType) {
+	switch qlType.Kind {
+	case typeKindNonNull:
+		writeSDLTypeRef(res, qlType.OfType)
+		res.WriteByte('!')
+	case typeKindList:
+		res.WriteByte('[')
+		writeSDLTypeRef(res, qlType.OfType)
+		res.WriteByte(']')
+	default:
+		if qlType.Name != nil {
+			res.WriteString(*qlType.Name)
+		} else {
+			res.WriteString("Unknown")
+		}
+	}
+}
+
+func directiveLocationName(location __DirectiveLocation) string {
+	for name, value := range directiveLocationMap {
+		if value == location {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package yarql
+
+import (
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestSDLUser struct {
+	ID    uint `gq:",ID"`
+	Name  string
+	Email *string `gq:",owner=identity"`
+}
+
+type TestSDLQuery struct {
+	Users []TestSDLUser
+}
+
+func (TestSDLQuery) ResolveUser(args struct{ ID uint }) (*TestSDLUser, error) {
+	return nil, nil
+}
+
+type TestSDLMutation struct{}
+
+func (TestSDLMutation) ResolveRename(args struct {
+	ID   uint `gq:",ID"`
+	Name string
+}) TestSDLUser {
+	return TestSDLUser{}
+}
+
+func TestSDL(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.RegisterTypeOwner(TestSDLUser{}, "accounts"))
+	a.NoError(t, s.Parse(TestSDLQuery{}, TestSDLMutation{}, nil))
+
+	a.Equal(t, `schema {
+	query: TestSDLQuery
+	mutation: TestSDLMutation
+}
+
+"""
+The File scalar type references to a multipart file, often used to upload files to the server. Expects a string with the form file field name
+"""
+scalar File @specifiedBy(url: "https://github.com/mjarkk/yarql#file-upload")
+
+"""
+The Int64 scalar type represents a signed 64‐bit numeric non‐fractional value. Values are send as strings as JSON numbers cannot precisely represent all 64‐bit values
+"""
+scalar Int64
+
+type TestSDLMutation {
+	rename(ID: ID, name: String): TestSDLUser!
+}
+
+type TestSDLQuery {
+	user(ID: Int): TestSDLUser
+	users: [TestSDLUser!]
+}
+
+# owner: accounts
+type TestSDLUser {
+	ID: ID!
+	# owner: identity
+	email: String
+	name: String!
+}
+
+"""
+The Time scalar type references to a ISO 8601 date+time, often used to insert and/or view dates. Expects a string with the ISO 8601 format
+"""
+scalar Time @specifiedBy(url: "https://en.wikipedia.org/wiki/ISO_8601")
+`, s.SDL())
+}
+
+func TestSDLWithoutMutation(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestSDLQuery{}, M{}, nil))
+
+	sdl := s.SDL()
+	a.False(t, strings.Contains(sdl, "type M"))
+	a.False(t, strings.Contains(sdl, "mutation:"))
+	a.False(t, strings.Contains(sdl, "__Schema"))
+	a.False(t, strings.Contains(sdl, "scalar String"))
+}
@@ -0,0 +1,68 @@
+package yarql
+
+import (
+	"encoding"
+	"reflect"
+	"time"
+
+	"github.com/mjarkk/yarql/helpers"
+)
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// isTextMarshaler returns true if values of t can be send as String scalar using encoding.TextMarshaler
+// Pointer and interface types are excluded as the value they point to is checked instead
+func isTextMarshaler(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface || t == timeType {
+		return false
+	}
+	return t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+// isTextUnmarshaler returns true if t can be parsed from a String scalar using encoding.TextUnmarshaler
+func isTextUnmarshaler(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface || t == timeType {
+		return false
+	}
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// writeText writes goValue as JSON string using it's MarshalText method
+// If MarshalText returns an error null is written and the error is added to the response
+func (ctx *Ctx) writeText(goValue reflect.Value) {
+	marshaler, ok := goValue.Interface().(encoding.TextMarshaler)
+	if !ok {
+		// MarshalText has a pointer receiver
+		if goValue.CanAddr() {
+			marshaler = goValue.Addr().Interface().(encoding.TextMarshaler)
+		} else {
+			ptr := reflect.New(goValue.Type())
+			ptr.Elem().Set(goValue)
+			marshaler = ptr.Interface().(encoding.TextMarshaler)
+		}
+	}
+
+	text, err := marshaler.MarshalText()
+	if err != nil {
+		ctx.writeNull()
+		ctx.addErr(err)
+		return
+	}
+	helpers.StringToJSON(string(text), &ctx.schema.Result)
+}
+
+// unmarshalText sets goValue to the value parsed by it's UnmarshalText method
+func unmarshalText(goValue *reflect.Value, text string) error {
+	// The text is copied as UnmarshalText might keep a reference to it
+	ptr := reflect.New(goValue.Type())
+	err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	if err != nil {
+		return err
+	}
+	goValue.Set(ptr.Elem())
+	return nil
+}
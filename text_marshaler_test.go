@@ -0,0 +1,94 @@
+package yarql
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestTextUserID struct {
+	n int
+}
+
+func (id *TestTextUserID) MarshalText() ([]byte, error) {
+	if id.n < 0 {
+		return nil, errors.New("invalid user id")
+	}
+	return []byte("user-" + string(rune('0'+id.n))), nil
+}
+
+func (id *TestTextUserID) UnmarshalText(text []byte) error {
+	if !strings.HasPrefix(string(text), "user-") || len(text) != 6 {
+		return errors.New("invalid user id " + string(text))
+	}
+	id.n = int(text[5] - '0')
+	return nil
+}
+
+type TestTextData struct {
+	Addr      netip.Addr
+	AddrPtr   *netip.Addr
+	ID        TestTextUserID `gq:",id"`
+	InvalidID TestTextUserID
+}
+
+func (TestTextData) ResolveLookup(args struct {
+	Addr   netip.Addr
+	UserID *TestTextUserID `gq:",id"`
+}) string {
+	res := args.Addr.String()
+	if args.UserID != nil {
+		res += " " + string(rune('0'+args.UserID.n))
+	}
+	return res
+}
+
+func newTestTextData() TestTextData {
+	return TestTextData{
+		Addr:      netip.MustParseAddr("127.0.0.1"),
+		ID:        TestTextUserID{n: 4},
+		InvalidID: TestTextUserID{n: -1},
+	}
+}
+
+func TestBytecodeResolveTextMarshaler(t *testing.T) {
+	res := bytecodeParseAndExpectNoErrs(t, `{addr addrPtr ID}`, newTestTextData(), M{})
+	a.Equal(t, `{"addr":"127.0.0.1","addrPtr":null,"ID":"user-4"}`, res)
+
+	res, errs := bytecodeParseAndExpectErrs(t, `{invalidID}`, newTestTextData(), M{})
+	a.Equal(t, `{"invalidID":null}`, res)
+	a.Equal(t, 1, len(errs))
+}
+
+func TestBytecodeResolveTextUnmarshaler(t *testing.T) {
+	res := bytecodeParseAndExpectNoErrs(t, `{a: lookup(addr: "::1") b: lookup(addr: "10.0.0.1", userID: "user-2")}`, newTestTextData(), M{})
+	a.Equal(t, `{"a":"::1","b":"10.0.0.1 2"}`, res)
+
+	res = bytecodeParseAndExpectNoErrs(t, `query($addr: String!, $id: ID) {lookup(addr: $addr, userID: $id)}`, newTestTextData(), M{}, ResolveOptions{
+		NoMeta:    true,
+		Variables: `{"addr": "192.168.1.1", "id": "user-7"}`,
+	})
+	a.Equal(t, `{"lookup":"192.168.1.1 7"}`, res)
+
+	_, errs := bytecodeParseAndExpectErrs(t, `{lookup(addr: "not an ip")}`, newTestTextData(), M{})
+	a.Equal(t, 1, len(errs))
+
+	_, errs = bytecodeParseAndExpectErrs(t, `query($addr: String!) {lookup(addr: $addr)}`, newTestTextData(), M{}, ResolveOptions{
+		NoMeta:    true,
+		Variables: `{"addr": 1}`,
+	})
+	a.Equal(t, 1, len(errs))
+}
+
+func TestTextMarshalerSDL(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(newTestTextData(), M{}, nil))
+
+	sdl := s.SDL()
+	a.True(t, strings.Contains(sdl, "\taddr: String!\n"), sdl)
+	a.True(t, strings.Contains(sdl, "\tID: ID!\n"), sdl)
+	a.True(t, strings.Contains(sdl, "lookup(addr: String, userID: ID): String!"), sdl)
+}
@@ -7,10 +7,26 @@ import (
 )
 
 var renamedTypes = map[string]string{}
+var renamedInputTypes = map[string]string{}
 
 // TypeRename renames the graphql type of the input type
 // By default the typename of the struct is used but you might want to change this form time to time and with this you can
 func TypeRename(goType interface{}, newName string, force ...bool) string {
+	originalName, newName := validTypeRename(goType, newName, force...)
+	renamedTypes[originalName] = newName
+	return newName
+}
+
+// InputTypeRename renames the graphql input type of the input type without affecting the name of the output type
+// By default the input type uses the same name as the output type (see TypeRename) with __input appended if both are used
+func InputTypeRename(goType interface{}, newName string, force ...bool) string {
+	originalName, newName := validTypeRename(goType, newName, force...)
+	renamedInputTypes[originalName] = newName
+	return newName
+}
+
+// validTypeRename panics if goType cannot be renamed to newName and returns the go name of the type and the trimmed new name
+func validTypeRename(goType interface{}, newName string, force ...bool) (string, string) {
 	t := reflect.TypeOf(goType)
 	originalName := t.Name()
 
@@ -33,7 +49,5 @@ func TypeRename(goType interface{}, newName string, force ...bool) string {
 		}
 	}
 
-	renamedTypes[originalName] = newName
-
-	return newName
+	return originalName, newName
 }
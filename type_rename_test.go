@@ -11,6 +11,20 @@ var _ = TypeRename(TestTypeRenameData{}, "Foo")
 
 type TestTypeRenameData struct{}
 
+var _ = InputTypeRename(TestInputTypeRenameData{}, "CreateUserInput")
+
+type TestInputTypeRenameData struct {
+	Name string
+}
+
+type TestInputTypeRenameQuery struct {
+	User TestInputTypeRenameData
+}
+
+func (TestInputTypeRenameQuery) ResolveCreate(args struct{ Input TestInputTypeRenameData }) TestInputTypeRenameData {
+	return args.Input
+}
+
 func TestTypeRename(t *testing.T) {
 	ctx := newParseCtx()
 	obj, err := ctx.check(reflect.TypeOf(TestTypeRenameData{}), false)
@@ -21,6 +35,23 @@ func TestTypeRename(t *testing.T) {
 	a.True(t, ok)
 }
 
+func TestInputTypeRename(t *testing.T) {
+	s := NewSchema()
+	err := s.Parse(TestInputTypeRenameQuery{}, M{}, nil)
+	a.NoError(t, err)
+
+	_, ok := s.types["TestInputTypeRenameData"]
+	a.True(t, ok)
+	_, ok = s.inTypes["CreateUserInput"]
+	a.True(t, ok)
+	_, ok = s.inTypes["TestInputTypeRenameData__input"]
+	a.False(t, ok)
+
+	errs := s.Resolve([]byte(`{create(input: {name: "foo"}) {name}}`), ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"create":{"name":"foo"}}`, string(s.Result))
+}
+
 func TestTypeRenameFails(t *testing.T) {
 	a.Panics(t, func() {
 		TypeRename(TestTypeRenameData{}, "")
@@ -33,4 +64,8 @@ func TestTypeRenameFails(t *testing.T) {
 	a.Panics(t, func() {
 		TypeRename(123, "Foo")
 	}, "Should panic when giving a non struct")
+
+	a.Panics(t, func() {
+		InputTypeRename(TestTypeRenameData{}, "")
+	}, "Should panic when giving no input type rename name")
 }
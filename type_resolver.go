@@ -0,0 +1,105 @@
+package yarql
+
+import (
+	"errors"
+	"reflect"
+)
+
+// TypeResolver returns the graphql type name of an interface value, see (*Schema).RegisterTypeResolver
+type TypeResolver func(value interface{}) string
+
+// RegisterTypeResolver registers a type resolver for the interface interfaceValue
+// The type resolver is called if the go type of a interface value is not one of the implementations registered using yarql.Implements, for example if the implementation is wrapped
+// The value is unwrapped to the implementation with the returned type name by following pointers, interfaces and embedded structs
+//
+// Example:
+//
+//	type CachedUser struct {
+//		*User
+//		CachedAt time.Time
+//	}
+//
+//	s.RegisterTypeResolver((*Node)(nil), func(value interface{}) string {
+//		switch value.(type) {
+//		case CachedUser, *User:
+//			return "User"
+//		}
+//		return ""
+//	})
+func (s *Schema) RegisterTypeResolver(interfaceValue interface{}, resolver TypeResolver) error {
+	if s.parsed {
+		return errors.New("(*yarql.Schema).RegisterTypeResolver() cannot be ran after (*yarql.Schema).Parse()")
+	}
+	if interfaceValue == nil {
+		return errors.New("interfaceValue cannot be nil")
+	}
+	if resolver == nil {
+		return errors.New("resolver cannot be nil")
+	}
+
+	t := reflect.TypeOf(interfaceValue)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return errors.New("interfaceValue should be a pointer to a interface like: (*InterfaceType)(nil)")
+	}
+	t = t.Elem()
+	if _, ok := s.typeResolvers[t]; ok {
+		return errors.New("a type resolver for " + t.String() + " is already registered")
+	}
+
+	s.typeResolvers[t] = resolver
+	return nil
+}
+
+// resolveImplementation resolves goValue using the type resolver of the interface typeObj
+// goValue is expected to be the current go value
+func (ctx *Ctx) resolveImplementation(typeObj *obj, goValue reflect.Value, dept uint8, hasSubSelection bool) bool {
+	typeName := typeObj.typeResolver(goValue.Interface())
+	for _, implementation := range typeObj.implementations {
+		if implementation.typeName != typeName {
+			continue
+		}
+
+		value, ok := unwrapImplementation(goValue, implementation)
+		if !ok {
+			ctx.writeNull()
+			ctx.addErr(errors.New("cannot resolve a value of type " + goValue.Type().String() + " as " + typeName))
+			return false
+		}
+		ctx.setGoValue(value)
+		return ctx.resolveFieldDataValue(implementation, dept+1, hasSubSelection)
+	}
+
+	ctx.writeNull()
+	ctx.addErr(errors.New("type resolver of " + typeObj.typeName + " returned " + typeName + " which is not an implementation of " + typeObj.typeName))
+	return false
+}
+
+// unwrapImplementation searches for the value of the go type of implementation within value by following pointers, interfaces and embedded structs
+func unwrapImplementation(value reflect.Value, implementation *obj) (reflect.Value, bool) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return value, false
+	}
+
+	t := value.Type()
+	if t.Name() == implementation.goTypeName && t.PkgPath() == implementation.goPkgPath {
+		return value, true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.Anonymous || !field.IsExported() {
+			// The methods of values of unexported fields cannot be called
+			continue
+		}
+		embedded, ok := unwrapImplementation(value.Field(i), implementation)
+		if ok {
+			return embedded, true
+		}
+	}
+	return value, false
+}
@@ -0,0 +1,99 @@
+package yarql
+
+import (
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestTypeResolverNode interface {
+	ResolveKey() string
+}
+
+type TestTypeResolverUser struct {
+	Name string
+}
+
+func (TestTypeResolverUser) ResolveKey() string { return "user" }
+
+type TestTypeResolverPost struct {
+	Title string
+}
+
+func (TestTypeResolverPost) ResolveKey() string { return "post" }
+
+var _ = Implements((*TestTypeResolverNode)(nil), TestTypeResolverUser{})
+var _ = Implements((*TestTypeResolverNode)(nil), TestTypeResolverPost{})
+
+// TestTypeResolverCachedUser wraps a user, it's not a registered implementation of TestTypeResolverNode
+type TestTypeResolverCachedUser struct {
+	*TestTypeResolverUser
+	Hits int
+}
+
+type TestTypeResolverData struct {
+	Nodes []TestTypeResolverNode
+}
+
+func TestTypeResolver(t *testing.T) {
+	s := NewSchema()
+	err := s.RegisterTypeResolver((*TestTypeResolverNode)(nil), func(value interface{}) string {
+		switch value.(type) {
+		case TestTypeResolverCachedUser:
+			return "TestTypeResolverUser"
+		case *TestTypeResolverPost:
+			// The pointer is followed to the registered implementation
+			return "TestTypeResolverPost"
+		}
+		return ""
+	})
+	a.NoError(t, err)
+
+	schema := TestTypeResolverData{Nodes: []TestTypeResolverNode{
+		TestTypeResolverUser{Name: "a"},
+		TestTypeResolverCachedUser{TestTypeResolverUser: &TestTypeResolverUser{Name: "b"}},
+		&TestTypeResolverPost{Title: "c"},
+	}}
+	query := `{nodes {__typename key ... on TestTypeResolverUser {name} ... on TestTypeResolverPost {title}}}`
+	res, errs := bytecodeParse(t, s, query, schema, M{})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"nodes":[`+
+		`{"__typename":"TestTypeResolverUser","key":"user","name":"a"},`+
+		`{"__typename":"TestTypeResolverUser","key":"user","name":"b"},`+
+		`{"__typename":"TestTypeResolverPost","key":"post","title":"c"}`+
+		`]}`, res)
+}
+
+func TestTypeResolverInvalidType(t *testing.T) {
+	s := NewSchema()
+	err := s.RegisterTypeResolver((*TestTypeResolverNode)(nil), func(value interface{}) string {
+		if _, ok := value.(TestTypeResolverCachedUser); ok {
+			return "TestTypeResolverPost"
+		}
+		return "Unknown"
+	})
+	a.NoError(t, err)
+
+	schema := TestTypeResolverData{Nodes: []TestTypeResolverNode{
+		TestTypeResolverCachedUser{TestTypeResolverUser: &TestTypeResolverUser{Name: "b"}},
+		&TestTypeResolverUser{Name: "a"},
+	}}
+	res, errs := bytecodeParse(t, s, `{nodes {key}}`, schema, M{})
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, "cannot resolve a value of type yarql.TestTypeResolverCachedUser as TestTypeResolverPost", errs[0].Error())
+	a.Equal(t, "type resolver of TestTypeResolverNode returned Unknown which is not an implementation of TestTypeResolverNode", errs[1].Error())
+	a.Equal(t, `{"nodes":[null,null]}`, res)
+}
+
+func TestRegisterTypeResolverInvalid(t *testing.T) {
+	s := NewSchema()
+	resolver := func(value interface{}) string { return "" }
+	a.Error(t, s.RegisterTypeResolver(nil, resolver))
+	a.Error(t, s.RegisterTypeResolver((*TestTypeResolverNode)(nil), nil))
+	a.Error(t, s.RegisterTypeResolver(TestTypeResolverUser{}, resolver))
+	a.NoError(t, s.RegisterTypeResolver((*TestTypeResolverNode)(nil), resolver))
+	a.Error(t, s.RegisterTypeResolver((*TestTypeResolverNode)(nil), resolver))
+
+	a.NoError(t, s.Parse(TestTypeResolverData{}, M{}, nil))
+	a.Error(t, s.RegisterTypeResolver((*TestTypeResolverNode)(nil), resolver))
+}
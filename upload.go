@@ -0,0 +1,125 @@
+package yarql
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+)
+
+// Upload is a uploaded file that can be streamed, use it as method input instead of *multipart.FileHeader to read files without buffering them
+// The file can only be read during the resolver call
+//
+// Example:
+//
+//	func (Mutation) ResolveUpload(args struct{ File *yarql.Upload }) (int64, error) {
+//		return io.Copy(storage, args.File)
+//	}
+type Upload struct {
+	io.Reader
+	Filename    string
+	ContentType string
+	Size        int64 // -1 if the size is unknown as the file is streamed
+}
+
+// closeUploads closes the files opened for Upload inputs
+func (ctx *Ctx) closeUploads() {
+	for _, closer := range ctx.uploadClosers {
+		closer.Close()
+	}
+	ctx.uploadClosers = ctx.uploadClosers[:0]
+}
+
+// fileInput returns the *multipart.FileHeader or *Upload value for the form file with name key
+func (ctx *Ctx) fileInput(valueStructure *input, key string) (reflect.Value, error) {
+	if valueStructure.isUpload {
+		upload, err := ctx.upload(key)
+		return reflect.ValueOf(upload), err
+	}
+	file, err := ctx.formFile(key)
+	return reflect.ValueOf(file), err
+}
+
+func (ctx *Ctx) formFile(key string) (*multipart.FileHeader, error) {
+	if ctx.getFormFile == nil {
+		return nil, errors.New("form files are not supported")
+	}
+	file, err := ctx.getFormFile(key)
+	if err != nil || file == nil {
+		return file, err
+	}
+	err = ctx.countUpload(key, file.Size)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (ctx *Ctx) upload(key string) (*Upload, error) {
+	if ctx.getUpload != nil {
+		upload, err := ctx.getUpload(key)
+		if err != nil || upload == nil {
+			return upload, err
+		}
+		if upload.Size >= 0 {
+			err = ctx.countUpload(key, upload.Size)
+			if err != nil {
+				return nil, err
+			}
+		} else if ctx.maxFileSize > 0 || ctx.maxUploadSize > 0 {
+			// The size is unknown so count the bytes while reading
+			upload.Reader = &uploadLimitReader{ctx: ctx, reader: upload.Reader, name: key}
+		}
+		return upload, nil
+	}
+
+	file, err := ctx.formFile(key)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	ctx.uploadClosers = append(ctx.uploadClosers, reader)
+	return &Upload{
+		Reader:      reader,
+		Filename:    file.Filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Size:        file.Size,
+	}, nil
+}
+
+// countUpload adds size to the total upload size of the request and reports if one of the upload limits is exceeded
+func (ctx *Ctx) countUpload(name string, size int64) error {
+	if ctx.maxFileSize > 0 && size > ctx.maxFileSize {
+		return errors.New("file " + name + " is larger than the max of " + strconv.FormatInt(ctx.maxFileSize, 10) + " bytes")
+	}
+	ctx.uploadedSize += size
+	if ctx.maxUploadSize > 0 && ctx.uploadedSize > ctx.maxUploadSize {
+		return errors.New("uploaded files are larger than the max of " + strconv.FormatInt(ctx.maxUploadSize, 10) + " bytes")
+	}
+	return nil
+}
+
+// uploadLimitReader enforces the upload limits on streamed files with a unknown size
+type uploadLimitReader struct {
+	ctx    *Ctx
+	reader io.Reader
+	name   string
+	read   int64
+}
+
+func (r *uploadLimitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.ctx.maxFileSize > 0 && r.read > r.ctx.maxFileSize {
+		return n, errors.New("file " + r.name + " is larger than the max of " + strconv.FormatInt(r.ctx.maxFileSize, 10) + " bytes")
+	}
+	r.ctx.uploadedSize += int64(n)
+	if r.ctx.maxUploadSize > 0 && r.ctx.uploadedSize > r.ctx.maxUploadSize {
+		return n, errors.New("uploaded files are larger than the max of " + strconv.FormatInt(r.ctx.maxUploadSize, 10) + " bytes")
+	}
+	return n, err
+}
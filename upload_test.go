@@ -0,0 +1,186 @@
+package yarql
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestUploadData struct{}
+
+func (TestUploadData) ResolveUpload(args struct{ File *Upload }) (string, error) {
+	if args.File == nil {
+		return "", nil
+	}
+	contents, err := io.ReadAll(args.File)
+	if err != nil {
+		return "", err
+	}
+	return args.File.Filename + ": " + string(contents), nil
+}
+
+func (TestUploadData) ResolveHeader(args struct{ File *multipart.FileHeader }) string {
+	if args.File == nil {
+		return ""
+	}
+	return args.File.Filename
+}
+
+func (TestUploadData) ResolveHeaders(args struct{ Files []*multipart.FileHeader }) []string {
+	names := []string{}
+	for _, file := range args.Files {
+		names = append(names, file.Filename)
+	}
+	return names
+}
+
+func (TestUploadData) ResolveOptionalHeaders(args struct{ Files *[]*multipart.FileHeader }) []string {
+	if args.Files == nil {
+		return nil
+	}
+	names := []string{}
+	for _, file := range *args.Files {
+		names = append(names, file.Filename)
+	}
+	return names
+}
+
+func newTestMultipartForm(t *testing.T, fields map[string]string, files map[string]string) (*bytes.Buffer, string) {
+	body := bytes.NewBuffer(nil)
+	writer := multipart.NewWriter(body)
+	for _, key := range []string{"operations", "map"} {
+		if value, ok := fields[key]; ok {
+			a.NoError(t, writer.WriteField(key, value))
+		}
+	}
+	for key, contents := range files {
+		file, err := writer.CreateFormFile(key, key+".txt")
+		a.NoError(t, err)
+		file.Write([]byte(contents))
+	}
+	a.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestUploadFromFormFile(t *testing.T) {
+	body, contentType := newTestMultipartForm(t, nil, map[string]string{"a": "hello world"})
+	_, params, _ := mime.ParseMediaType(contentType)
+	form, err := multipart.NewReader(body, params["boundary"]).ReadForm(1024)
+	a.NoError(t, err)
+
+	getFormFile := func(key string) (*multipart.FileHeader, error) {
+		return form.File[key][0], nil
+	}
+
+	res, errs := bytecodeParse(t, NewSchema(), `{upload(file: "a")}`, TestUploadData{}, M{}, ResolveOptions{NoMeta: true, GetFormFile: getFormFile})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"upload":"a.txt: hello world"}`, res)
+
+	_, errs = bytecodeParse(t, NewSchema(), `{upload(file: "a")}`, TestUploadData{}, M{}, ResolveOptions{NoMeta: true, GetFormFile: getFormFile, MaxFileSize: 5})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "file a is larger than the max of 5 bytes", errs[0].Error())
+
+	_, errs = bytecodeParse(t, NewSchema(), `{a: header(file: "a") b: header(file: "a")}`, TestUploadData{}, M{}, ResolveOptions{NoMeta: true, GetFormFile: getFormFile, MaxUploadSize: 15})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "uploaded files are larger than the max of 15 bytes", errs[0].Error())
+}
+
+func TestHTTPHandlerStreamUploads(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestUploadData{}, M{}, nil))
+	handler := HTTPHandler(s, &HTTPHandlerOptions{StreamUploads: true, MaxFileSize: 11})
+
+	body, contentType := newTestMultipartForm(t,
+		map[string]string{
+			"operations": `{"query": "query ($file: Upload) {upload(file: $file)}", "variables": {"file": null}}`,
+			"map":        `{"0": ["variables.file"]}`,
+		},
+		map[string]string{"0": "hello world"},
+	)
+	req := httptest.NewRequest("POST", "/graphql", body)
+	req.Header.Set("Content-Type", contentType)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.Equal(t, `{"data":{"upload":"0.txt: hello world"}}`, res.Body.String())
+
+	body, contentType = newTestMultipartForm(t,
+		map[string]string{
+			"operations": `{"query": "query ($file: Upload) {upload(file: $file)}", "variables": {"file": null}}`,
+			"map":        `{"0": ["variables.file"]}`,
+		},
+		map[string]string{"0": "hello world!"},
+	)
+	req = httptest.NewRequest("POST", "/graphql", body)
+	req.Header.Set("Content-Type", contentType)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.True(t, strings.Contains(res.Body.String(), `"message":"file 0 is larger than the max of 11 bytes"`))
+
+	// Files send before the requested file are buffered within the same limit
+	body = bytes.NewBuffer(nil)
+	writer := multipart.NewWriter(body)
+	a.NoError(t, writer.WriteField("operations", `{"query": "query ($file: Upload) {upload(file: $file)}", "variables": {"file": null}}`))
+	a.NoError(t, writer.WriteField("map", `{"0": ["variables.file"]}`))
+	for _, key := range []string{"1", "0"} {
+		file, err := writer.CreateFormFile(key, key+".txt")
+		a.NoError(t, err)
+		file.Write([]byte(strings.Repeat(key, 100)))
+	}
+	a.NoError(t, writer.Close())
+	req = httptest.NewRequest("POST", "/graphql", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.True(t, strings.Contains(res.Body.String(), `"message":"file 1 is larger than the max of 11 bytes"`), res.Body.String())
+
+	// Buffered files that are never requested still count towards MaxUploadSize
+	handler = HTTPHandler(s, &HTTPHandlerOptions{StreamUploads: true, MaxUploadSize: 50})
+	body = bytes.NewBuffer(nil)
+	writer = multipart.NewWriter(body)
+	a.NoError(t, writer.WriteField("operations", `{"query": "query ($file: Upload) {upload(file: $file)}", "variables": {"file": null}}`))
+	a.NoError(t, writer.WriteField("map", `{"0": ["variables.file"]}`))
+	for _, key := range []string{"1", "0"} {
+		file, err := writer.CreateFormFile(key, key+".txt")
+		a.NoError(t, err)
+		if key == "1" {
+			file.Write([]byte(strings.Repeat(key, 100)))
+		} else {
+			file.Write([]byte(key))
+		}
+	}
+	a.NoError(t, writer.Close())
+	req = httptest.NewRequest("POST", "/graphql", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	a.True(t, strings.Contains(res.Body.String(), `"message":"uploaded files are larger than the max of 50 bytes"`), res.Body.String())
+}
+
+func TestUploadList(t *testing.T) {
+	getFormFile := func(key string) (*multipart.FileHeader, error) {
+		return &multipart.FileHeader{Filename: key + ".txt"}, nil
+	}
+
+	res, errs := bytecodeParse(t, NewSchema(), `{headers(files: ["a", "b"]) optionalHeaders(files: ["c"])}`, TestUploadData{}, M{}, ResolveOptions{NoMeta: true, GetFormFile: getFormFile})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"headers":["a.txt","b.txt"],"optionalHeaders":["c.txt"]}`, res)
+
+	res, errs = bytecodeParse(t, NewSchema(), `{optionalHeaders}`, TestUploadData{}, M{}, ResolveOptions{NoMeta: true, GetFormFile: getFormFile})
+	a.Equal(t, 0, len(errs))
+	a.Equal(t, `{"optionalHeaders":null}`, res)
+
+	_, errs = bytecodeParse(t, NewSchema(), `{headers(files: ["a", null])}`, TestUploadData{}, M{}, ResolveOptions{NoMeta: true, GetFormFile: getFormFile})
+	a.Equal(t, 1, len(errs))
+	a.Equal(t, "cannot assign null to a file within a list", errs[0].Error())
+
+	// Lists of files are exposed as [File!]
+	res, errs = bytecodeParse(t, NewSchema(), `{__type(name: "TestUploadData") {fields {name args {type {kind ofType {kind ofType {name}}}}}}}`, TestUploadData{}, M{}, ResolveOptions{NoMeta: true})
+	a.Equal(t, 0, len(errs))
+	a.True(t, strings.Contains(res, `{"name":"headers","args":[{"type":{"kind":"LIST","ofType":{"kind":"NON_NULL","ofType":{"name":"File"}}}}]}`))
+}
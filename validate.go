@@ -0,0 +1,296 @@
+package yarql
+
+import (
+	"errors"
+
+	"github.com/mjarkk/yarql/bytecode"
+)
+
+// Validate parses query and checks all operations and fragments within it against the schema without executing anything
+// This can be used to lint queries, for example the queries of a frontend within CI
+// The returned errors are the errors that executing the query would report for syntax errors, unknown fields, arguments, directives and fragments
+// Validate is safe for concurrent use
+func (s *Schema) Validate(query string) []error {
+	if !s.parsed {
+		return []error{errors.New("(*yarql.Schema).Validate() cannot be ran before (*yarql.Schema).Parse()")}
+	}
+
+	parser := s.newParser(query)
+	parser.ParseQueryToBytecode(nil)
+	if len(parser.Errors) > 0 {
+		return parser.Errors
+	}
+
+	ctx := &Ctx{
+		schema:  s,
+		query:   *parser,
+		fieldAt: -1,
+	}
+	ctx.validateDocument()
+	if len(ctx.query.Errors) == 0 {
+		return nil
+	}
+	return ctx.query.Errors
+}
+
+// validateDocument validates all operations and fragments of the parsed query
+func (ctx *Ctx) validateDocument() {
+	res := ctx.query.Res
+	foundOperation := false
+	for ctx.charNr+1 < len(res) {
+		ctx.path = ctx.path[:0]
+		switch res[ctx.charNr+1] {
+		case bytecode.ActionOperator:
+			foundOperation = true
+			ctx.validateOperation()
+		case bytecode.ActionFragment:
+			ctx.validateFragment()
+		default:
+			ctx.err("unsupported operation " + string(res[ctx.charNr+1]))
+			return
+		}
+	}
+
+	if !foundOperation {
+		ctx.err("no operator found")
+	}
+}
+
+// validateOperation validates the operation at the current charNr, equal to (*Ctx).readOperation the charNr is expected to be at the start of the operation
+func (ctx *Ctx) validateOperation() {
+	ctx.charNr += 2 // read 0, [ActionOperator]
+
+	var root *obj
+	switch ctx.readInst() {
+	case bytecode.OperatorQuery:
+		root = ctx.schema.rootQuery
+	case bytecode.OperatorMutation:
+		root = ctx.schema.rootMethod
+	case bytecode.OperatorSubscription:
+		ctx.err("subscriptions are not supported")
+	}
+
+	hasArguments := ctx.readInst() == 't'
+	directivesCount := ctx.readInst()
+	for ctx.readInst() != 0 {
+		// Read name
+	}
+	ctx.operatorHasArguments = hasArguments
+	if hasArguments {
+		ctx.operatorArgumentsStartAt = ctx.charNr + 5
+		ctx.skipInst(int(ctx.readUint32(ctx.charNr)) + 5)
+		ctx.validateVariableDirectives()
+	}
+	if directivesCount > 0 {
+		ctx.err("operation directives unsupported")
+		for i := uint8(0); i < directivesCount; i++ {
+			ctx.skipDirective()
+		}
+	}
+
+	selectionStart := ctx.charNr
+	ctx.checkSelectionSetMerging(root)
+	ctx.charNr = selectionStart
+	ctx.argumentsSelectionSet(root)
+	ctx.charNr = selectionStart
+	ctx.validateSelectionSet(root)
+}
+
+// validateVariableDirectives validates the directives and their arguments defined on the variable definitions of the operation
+func (ctx *Ctx) validateVariableDirectives() {
+	startAt := ctx.charNr
+	ctx.walkVariableDirectives(func(name string, defaultAt int) bool {
+		directiveAt := ctx.charNr
+		ctx.validateDirective(DirectiveLocationVariableDefinition)
+		ctx.charNr = directiveAt
+		ctx.argumentsDirectives(ctx.fieldAt, 1, DirectiveLocationVariableDefinition)
+		return false
+	})
+	ctx.charNr = startAt
+}
+
+// validateFragment validates the fragment definition at the current charNr against the type it's defined on
+func (ctx *Ctx) validateFragment() {
+	ctx.charNr += 2 // read 0, [ActionFragment]
+
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read name
+	}
+	name := ctx.query.Res[nameStart : ctx.charNr-1]
+
+	typeNameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read type name
+	}
+	typeName := b2s(ctx.query.Res[typeNameStart : ctx.charNr-1])
+
+	typeObj, ok := ctx.schema.getTypeOrInterface(typeName)
+	if !ok {
+		ctx.err("fragment " + b2s(name) + " is defined on unknown type " + typeName)
+	}
+	ctx.validateSelectionSet(typeObj)
+}
+
+// validateSelectionSet validates the fields and fragment spreads of the selection set at the current charNr
+// If typeObj is nil the selection set is walked without validating the fields, for example for fields of remote schemas
+func (ctx *Ctx) validateSelectionSet(typeObj *obj) {
+	for {
+		switch ctx.readInst() {
+		case bytecode.ActionField:
+			ctx.validateField(typeObj)
+		case bytecode.ActionSpread:
+			ctx.validateSpread(typeObj)
+		default:
+			// End of the selection set
+			return
+		}
+	}
+}
+
+// validateField validates the field at the current charNr, its directives, arguments and selection set
+func (ctx *Ctx) validateField(typeObj *obj) {
+	prefFieldAt := ctx.fieldAt
+	ctx.fieldAt = ctx.charNr - 1
+
+	directivesCount := ctx.readInst()
+	fieldLen := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	nameKey := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+	endOfField := ctx.charNr + int(fieldLen)
+
+	aliasLen := int(ctx.readInst())
+	alias := ctx.query.Res[ctx.charNr : ctx.charNr+aliasLen]
+	name := alias
+	ctx.skipInst(aliasLen)
+	nameLen := int(ctx.readInst())
+	if nameLen != 0 {
+		name = ctx.query.Res[ctx.charNr : ctx.charNr+nameLen]
+		ctx.skipInst(nameLen)
+	}
+	ctx.skipInst(1)
+
+	prefPathLen := len(ctx.path)
+	ctx.path = append(ctx.path, []byte(`,"`)...)
+	ctx.path = append(ctx.path, alias...)
+	ctx.path = append(ctx.path, '"')
+
+	ctx.usedDirectives = ctx.usedDirectives[:0]
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.validateDirective(DirectiveLocationField)
+	}
+
+	var field *obj
+	if typeObj != nil {
+		field, _ = typeObj.objContents.get(nameKey, name)
+	}
+
+	if ctx.seekInst() == bytecode.ActionValue {
+		// The arguments are checked by (*Ctx).argumentsSelectionSet
+		ctx.skipArguments()
+	}
+
+	hasSelection := ctx.seekInst() != bytecode.ActionEnd
+	if typeObj != nil && field == nil {
+		if b2s(name) != "__typename" {
+			ctx.errf("%s does not exists on %s", name, typeObj.typeName)
+		} else if hasSelection {
+			ctx.err("cannot have a selection set on this field")
+		}
+	}
+
+	var fieldType *obj
+	if field != nil {
+		fieldType = ctx.schema.complexityType(field)
+		if field.customResolver != nil {
+			// Fields like remote fields resolve their selection set themselves
+			fieldType = nil
+		} else if fieldType != nil && (fieldType.valueType == valueTypeObj || fieldType.valueType == valueTypeInterface) {
+			if !hasSelection {
+				ctx.err("must have a selection")
+			}
+		} else if hasSelection {
+			ctx.err("cannot have a selection set on this field")
+			fieldType = nil
+		}
+	}
+	if hasSelection {
+		ctx.validateSelectionSet(fieldType)
+	}
+
+	ctx.path = ctx.path[:prefPathLen]
+	ctx.fieldAt = prefFieldAt
+	ctx.charNr = endOfField + 1
+}
+
+// validateSpread validates the fragment spread at the current charNr
+// The selection set of a inline fragment is validated against its type, named fragments are validated with their definition
+func (ctx *Ctx) validateSpread(typeObj *obj) {
+	isInline := ctx.readInst() == 't'
+	directivesCount := ctx.readInst()
+	lenOfSpread := ctx.readUint32(ctx.charNr)
+	ctx.skipInst(4)
+
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read name or on inline fragment the type name
+	}
+	name := ctx.query.Res[nameStart : ctx.charNr-1]
+	endOfSpread := nameStart + int(lenOfSpread) + 1
+
+	location := DirectiveLocationFragment
+	if isInline {
+		location = DirectiveLocationFragmentInline
+	}
+	ctx.usedDirectives = ctx.usedDirectives[:0]
+	for i := uint8(0); i < directivesCount; i++ {
+		ctx.validateDirective(location)
+	}
+
+	if isInline {
+		var fragmentType *obj
+		if typeObj != nil {
+			fragmentType = ctx.schema.complexityFragmentType(typeObj, name)
+			if fragmentType == nil {
+				ctx.err("unknown type " + b2s(name))
+			}
+		}
+		ctx.validateSelectionSet(fragmentType)
+	} else if _, _, ok := ctx.findFragment(name); !ok {
+		ctx.err("fragment " + b2s(name) + " not defined")
+	}
+
+	ctx.charNr = endOfSpread
+}
+
+// validateDirective checks if the directive at the current charNr exists at location and is not repeated, the arguments are skipped
+func (ctx *Ctx) validateDirective(location DirectiveLocation) {
+	ctx.skipInst(1) // read 'd'
+	hasArguments := ctx.readInst() == 't'
+	nameStart := ctx.charNr
+	for ctx.readInst() != 0 {
+		// Read name
+	}
+	directiveName := b2s(ctx.query.Res[nameStart : ctx.charNr-1])
+	if hasArguments {
+		ctx.skipArguments()
+	}
+
+	for _, directive := range ctx.schema.definedDirectives[location] {
+		if directive.Name != directiveName {
+			continue
+		}
+		if !directive.Repeatable {
+			for _, used := range ctx.usedDirectives {
+				if used == directive {
+					ctx.err("directive " + directiveName + " can only be used once at this location")
+					return
+				}
+			}
+		}
+		ctx.usedDirectives = append(ctx.usedDirectives, directive)
+		return
+	}
+	ctx.err("unknown directive " + directiveName)
+}
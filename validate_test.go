@@ -0,0 +1,71 @@
+package yarql
+
+import (
+	"testing"
+
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type TestValidateData struct {
+	User TestValidateUser
+}
+
+type TestValidateUser struct {
+	Name string
+}
+
+func (TestValidateUser) ResolveFriends(args struct{ First int }) []TestValidateUser {
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestValidateData{}, M{}, nil))
+
+	a.Equal(t, 0, len(s.Validate(`{user {name friends(first: 2) {name __typename}}}`)))
+	a.Equal(t, 0, len(s.Validate(`query A {user {...UserFields}} query B {user {... on TestValidateUser {name}}} fragment UserFields on TestValidateUser {name}`)))
+	a.Equal(t, 0, len(s.Validate(IntrospectionQuery)))
+
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{`{user {`, "unexpected EOF"},
+		{``, "no operator found"},
+		{`{user {name}} fragment F on TestValidateUser {name}`, "fragment F is never used"},
+		{`{user {age}}`, "age does not exists on TestValidateUser"},
+		{`{user}`, "must have a selection"},
+		{`{user {name {first}}}`, "cannot have a selection set on this field"},
+		{`{user {friends(last: 2) {name}}}`, "undefined input: last"},
+		{`{user {name @unknown}}`, "unknown directive unknown"},
+		{`{user {name @skip(if: true) @skip(if: false)}}`, "directive skip can only be used once at this location"},
+		{`{user {...UserFields}}`, "fragment UserFields not defined"},
+		{`{user {... on Unknown {name}}}`, "unknown type Unknown"},
+		{`{user {...F}} fragment F on Unknown {name}`, "fragment F is defined on unknown type Unknown"},
+		{`subscription {user {name}}`, "subscriptions are not supported"},
+	}
+	for _, test := range tests {
+		errs := s.Validate(test.query)
+		a.Equal(t, 1, len(errs), test.query)
+		a.Equal(t, test.err, errs[0].Error(), test.query)
+	}
+}
+
+func TestValidateAllOperations(t *testing.T) {
+	s := NewSchema()
+	a.NoError(t, s.Parse(TestValidateData{}, M{}, nil))
+
+	// Unlike executing a query all operations and fragments are validated
+	errs := s.Validate(`query A {user {age}} query B {user {name ...F}} fragment F on TestValidateUser {email}`)
+	a.Equal(t, 2, len(errs))
+	a.Equal(t, "age does not exists on TestValidateUser", errs[0].Error())
+	a.Equal(t, "email does not exists on TestValidateUser", errs[1].Error())
+
+	// The errors contain the path and location of the field
+	errWPath, ok := errs[0].(ErrorWPath)
+	a.True(t, ok)
+	a.Equal(t, `["user","age"]`, string(errWPath.Path()))
+	line, column := errWPath.Location()
+	a.Equal(t, uint(1), line)
+	a.Equal(t, uint(16), column)
+}
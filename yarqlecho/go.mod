@@ -0,0 +1,23 @@
+module github.com/mjarkk/yarql/yarqlecho
+
+go 1.18
+
+require (
+	github.com/labstack/echo/v4 v4.10.0
+	github.com/mjarkk/yarql v0.0.0
+)
+
+require (
+	github.com/labstack/gommon v0.4.0 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fastjson v1.6.3 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.2.0 // indirect
+	golang.org/x/net v0.4.0 // indirect
+	golang.org/x/sys v0.3.0 // indirect
+	golang.org/x/text v0.5.0 // indirect
+)
+
+replace github.com/mjarkk/yarql => ../
@@ -0,0 +1,58 @@
+// Package yarqlecho mounts a yarql schema on a Echo router
+//
+// Example:
+//
+//	s := yarql.NewSchema()
+//	s.Parse(QueryRoot{}, MethodRoot{}, nil)
+//	e := echo.New()
+//	e.Any("/graphql", yarqlecho.Handler(s, &yarqlecho.Options{
+//		Values: func(c echo.Context) map[string]interface{} {
+//			return map[string]interface{}{"user": c.Get("user")}
+//		},
+//	}))
+package yarqlecho
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mjarkk/yarql"
+)
+
+// Options are options for Handler
+type Options struct {
+	Tracing            bool                                        // https://github.com/apollographql/apollo-tracing
+	MaxBodySize        int64                                       // Max size of the request body in bytes, default 10MB
+	MaxMultipartMemory int64                                       // Max amount of bytes of multipart form files stored in memory, default 32MB
+	Values             func(c echo.Context) map[string]interface{} // Returns the values passed to the request context, for example the authenticated user
+}
+
+// Handler returns a echo.HandlerFunc that resolves GraphQL requests using yarql.HTTPHandler
+func Handler(s *yarql.Schema, opts *Options) echo.HandlerFunc {
+	options := Options{}
+	if opts != nil {
+		options = *opts
+	}
+	handlerOptions := yarql.HTTPHandlerOptions{
+		Tracing:            options.Tracing,
+		MaxBodySize:        options.MaxBodySize,
+		MaxMultipartMemory: options.MaxMultipartMemory,
+	}
+
+	if options.Values == nil {
+		handler := yarql.HTTPHandler(s, &handlerOptions)
+		return func(c echo.Context) error {
+			handler.ServeHTTP(c.Response(), c.Request())
+			return nil
+		}
+	}
+
+	return func(c echo.Context) error {
+		requestOptions := handlerOptions
+		requestOptions.Values = func(r *http.Request) map[string]interface{} {
+			return options.Values(c)
+		}
+		yarql.HTTPHandler(s, &requestOptions).ServeHTTP(c.Response(), c.Request())
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package yarqlecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mjarkk/yarql"
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type testQuery struct{}
+
+func (testQuery) ResolveUser(ctx *yarql.Ctx) string {
+	user, _ := ctx.GetValue("user").(string)
+	return user
+}
+
+type testMethods struct{}
+
+func TestHandler(t *testing.T) {
+	s := yarql.NewSchema()
+	a.NoError(t, s.Parse(testQuery{}, testMethods{}, nil))
+
+	e := echo.New()
+	e.Any("/graphql", Handler(s, &Options{
+		Values: func(c echo.Context) map[string]interface{} {
+			return map[string]interface{}{"user": c.Request().Header.Get("X-User")}
+		},
+	}))
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{user}"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "alice")
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+	a.Equal(t, `{"data":{"user":"alice"}}`, res.Body.String())
+}
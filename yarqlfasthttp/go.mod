@@ -0,0 +1,17 @@
+module github.com/mjarkk/yarql/yarqlfasthttp
+
+go 1.18
+
+require (
+	github.com/mjarkk/yarql v0.0.0
+	github.com/valyala/fasthttp v1.44.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.0.4 // indirect
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fastjson v1.6.3 // indirect
+)
+
+replace github.com/mjarkk/yarql => ../
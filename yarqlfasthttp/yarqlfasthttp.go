@@ -0,0 +1,112 @@
+// Package yarqlfasthttp resolves GraphQL requests of fasthttp based frameworks like Fiber
+//
+// # The request body, url parameters and form values are read directly from the fasthttp request without copying the body
+//
+// Example:
+//
+//	s := yarql.NewSchema()
+//	s.Parse(QueryRoot{}, MethodRoot{}, nil)
+//	fasthttp.ListenAndServe(":8080", yarqlfasthttp.Handler(s, nil))
+//
+// Fiber example:
+//
+//	handler := yarqlfasthttp.Handler(s, nil)
+//	app.All("/graphql", func(c *fiber.Ctx) error {
+//		handler(c.Context())
+//		return nil
+//	})
+package yarqlfasthttp
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/mjarkk/yarql"
+	"github.com/valyala/fasthttp"
+)
+
+// Options are options for Handler
+type Options struct {
+	Tracing bool                                                  // https://github.com/apollographql/apollo-tracing
+	Values  func(ctx *fasthttp.RequestCtx) map[string]interface{} // Returns the values passed to the request context
+}
+
+// Handler returns a fasthttp.RequestHandler that resolves GraphQL requests using (*yarql.Schema).ServeHTTPRequestResult
+// Queries can be send using url parameters (GET), a json body or a multipart form for file uploads (POST)
+// The max body size is configured on the fasthttp server
+func Handler(s *yarql.Schema, opts *Options) fasthttp.RequestHandler {
+	options := Options{}
+	if opts != nil {
+		options = *opts
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		contentType := ctx.Request.Header.ContentType()
+		if idx := bytes.IndexByte(contentType, ';'); idx != -1 {
+			contentType = contentType[:idx]
+		}
+
+		requestOptions := &yarql.RequestOptions{
+			Context: ctx,
+			GetFormFile: func(key string) (*multipart.FileHeader, error) {
+				form, err := ctx.MultipartForm()
+				if err != nil {
+					return nil, err
+				}
+				files := form.File[key]
+				if len(files) == 0 {
+					return nil, nil
+				}
+				return files[0], nil
+			},
+			Tracing:    options.Tracing,
+			Accept:     string(ctx.Request.Header.Peek("Accept")),
+			Header:     requestHeader(&ctx.Request.Header),
+			RemoteAddr: ctx.RemoteAddr().String(),
+		}
+		if options.Values != nil {
+			requestOptions.Values = options.Values(ctx)
+		}
+
+		s.ServeHTTPRequestResult(yarql.HTTPRequest{
+			Method: string(ctx.Method()),
+			GetQuery: func(key string) string {
+				return string(ctx.QueryArgs().Peek(key))
+			},
+			GetFormField: func(key string) (string, error) {
+				form, err := ctx.MultipartForm()
+				if err != nil {
+					return "", err
+				}
+				values := form.Value[key]
+				if len(values) == 0 {
+					return "", nil
+				}
+				return values[0], nil
+			},
+			GetBody:     ctx.PostBody,
+			ContentType: string(bytes.TrimSpace(contentType)),
+			Options:     requestOptions,
+		}, func(result yarql.HTTPResult) {
+			for key, values := range result.Header {
+				for _, value := range values {
+					ctx.Response.Header.Add(key, value)
+				}
+			}
+			ctx.SetStatusCode(result.Status)
+			ctx.SetContentType(result.ContentType)
+			// SetBody copies the response as it's only valid during this call
+			ctx.SetBody(result.Body)
+		})
+	}
+}
+
+// requestHeader converts the fasthttp request headers to a http.Header for (*yarql.Ctx).Header
+func requestHeader(header *fasthttp.RequestHeader) http.Header {
+	res := http.Header{}
+	header.VisitAll(func(key, value []byte) {
+		res.Add(string(key), string(value))
+	})
+	return res
+}
@@ -0,0 +1,71 @@
+package yarqlfasthttp
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mjarkk/yarql"
+	a "github.com/mjarkk/yarql/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type testQuery struct {
+	Foo string
+}
+
+type testMethods struct{}
+
+func newTestHandler(t *testing.T) fasthttp.RequestHandler {
+	s := yarql.NewSchema()
+	a.NoError(t, s.Parse(testQuery{Foo: "bar"}, testMethods{}, nil))
+	return Handler(s, nil)
+}
+
+func newTestCtx(method, uri, contentType, body string) *fasthttp.RequestCtx {
+	req := fasthttp.Request{}
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	if len(contentType) > 0 {
+		req.Header.SetContentType(contentType)
+	}
+	req.SetBodyString(body)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&req, nil, nil)
+	return ctx
+}
+
+func TestHandlerGet(t *testing.T) {
+	ctx := newTestCtx("GET", "/graphql?query=%7Bfoo%7D", "", "")
+	newTestHandler(t)(ctx)
+	a.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	a.Equal(t, "application/json", string(ctx.Response.Header.ContentType()))
+	a.Equal(t, `{"data":{"foo":"bar"}}`, string(ctx.Response.Body()))
+}
+
+func TestHandlerPost(t *testing.T) {
+	ctx := newTestCtx("POST", "/graphql", "application/json; charset=utf-8", `{"query": "{foo}"}`)
+	newTestHandler(t)(ctx)
+	a.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	a.Equal(t, `{"data":{"foo":"bar"}}`, string(ctx.Response.Body()))
+
+	ctx = newTestCtx("POST", "/graphql", "application/json", `{invalid`)
+	newTestHandler(t)(ctx)
+	a.Equal(t, http.StatusBadRequest, ctx.Response.StatusCode())
+
+	ctx = newTestCtx("PUT", "/graphql", "application/json", `{"query": "{foo}"}`)
+	newTestHandler(t)(ctx)
+	a.Equal(t, http.StatusMethodNotAllowed, ctx.Response.StatusCode())
+	a.Equal(t, "GET, POST", string(ctx.Response.Header.Peek("Allow")))
+}
+
+func (testQuery) ResolveClient(ctx *yarql.Ctx) string {
+	return ctx.Header().Get("X-Client")
+}
+
+func TestHandlerRequestMetadata(t *testing.T) {
+	ctx := newTestCtx("GET", "/graphql?query=%7Bclient%7D", "", "")
+	ctx.Request.Header.Set("X-Client", "test")
+	newTestHandler(t)(ctx)
+	a.Equal(t, `{"data":{"client":"test"}}`, string(ctx.Response.Body()))
+}
@@ -0,0 +1,56 @@
+// Package yarqlgin mounts a yarql schema on a Gin router
+//
+// Example:
+//
+//	s := yarql.NewSchema()
+//	s.Parse(QueryRoot{}, MethodRoot{}, nil)
+//	r := gin.Default()
+//	r.Any("/graphql", yarqlgin.Handler(s, &yarqlgin.Options{
+//		Values: func(c *gin.Context) map[string]interface{} {
+//			return map[string]interface{}{"user": c.MustGet("user")}
+//		},
+//	}))
+package yarqlgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mjarkk/yarql"
+)
+
+// Options are options for Handler
+type Options struct {
+	Tracing            bool                                        // https://github.com/apollographql/apollo-tracing
+	MaxBodySize        int64                                       // Max size of the request body in bytes, default 10MB
+	MaxMultipartMemory int64                                       // Max amount of bytes of multipart form files stored in memory, default 32MB
+	Values             func(c *gin.Context) map[string]interface{} // Returns the values passed to the request context, for example the authenticated user
+}
+
+// Handler returns a gin.HandlerFunc that resolves GraphQL requests using yarql.HTTPHandler
+func Handler(s *yarql.Schema, opts *Options) gin.HandlerFunc {
+	options := Options{}
+	if opts != nil {
+		options = *opts
+	}
+	handlerOptions := yarql.HTTPHandlerOptions{
+		Tracing:            options.Tracing,
+		MaxBodySize:        options.MaxBodySize,
+		MaxMultipartMemory: options.MaxMultipartMemory,
+	}
+
+	if options.Values == nil {
+		handler := yarql.HTTPHandler(s, &handlerOptions)
+		return func(c *gin.Context) {
+			handler.ServeHTTP(c.Writer, c.Request)
+		}
+	}
+
+	return func(c *gin.Context) {
+		requestOptions := handlerOptions
+		requestOptions.Values = func(r *http.Request) map[string]interface{} {
+			return options.Values(c)
+		}
+		yarql.HTTPHandler(s, &requestOptions).ServeHTTP(c.Writer, c.Request)
+	}
+}
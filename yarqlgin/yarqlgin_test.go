@@ -0,0 +1,45 @@
+package yarqlgin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mjarkk/yarql"
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type testQuery struct{}
+
+func (testQuery) ResolveUser(ctx *yarql.Ctx) string {
+	user, _ := ctx.GetValue("user").(string)
+	return user
+}
+
+type testMethods struct{}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := yarql.NewSchema()
+	a.NoError(t, s.Parse(testQuery{}, testMethods{}, nil))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user", c.GetHeader("X-User"))
+	})
+	r.Any("/graphql", Handler(s, &Options{
+		Values: func(c *gin.Context) map[string]interface{} {
+			return map[string]interface{}{"user": c.GetString("user")}
+		},
+	}))
+
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{user}"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "alice")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	a.Equal(t, http.StatusOK, res.Code)
+	a.Equal(t, `{"data":{"user":"alice"}}`, res.Body.String())
+}
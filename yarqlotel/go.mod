@@ -0,0 +1,19 @@
+module github.com/mjarkk/yarql/yarqlotel
+
+go 1.18
+
+require (
+	github.com/mjarkk/yarql v0.0.0
+	go.opentelemetry.io/otel v1.14.0
+	go.opentelemetry.io/otel/sdk v1.14.0
+	go.opentelemetry.io/otel/trace v1.14.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/valyala/fastjson v1.6.3 // indirect
+	golang.org/x/sys v0.5.0 // indirect
+)
+
+replace github.com/mjarkk/yarql => ../
@@ -0,0 +1,133 @@
+// Package yarqlotel adds OpenTelemetry tracing to a yarql schema
+//
+// A span is created for every operation and optionally for every resolved field.
+// The spans are children of the span within the request context (yarql.ResolveOptions.Context) if there is one
+//
+// Example:
+//
+//	s := yarql.NewSchema()
+//	yarqlotel.New(yarqlotel.WithFieldSpans()).Register(s)
+//	s.Parse(QueryRoot{}, MethodRoot{}, nil)
+package yarqlotel
+
+import (
+	"context"
+
+	"github.com/mjarkk/yarql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/mjarkk/yarql/yarqlotel"
+
+// Tracer creates the spans, it's safe to use within multiple copies of a schema
+type Tracer struct {
+	yarql.BaseExtension
+
+	tracer     trace.Tracer
+	fieldSpans bool
+}
+
+// Option configures a Tracer
+type Option func(t *Tracer)
+
+// WithTracerProvider sets the tracer provider, defaults to the global tracer provider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *Tracer) {
+		t.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithFieldSpans enables a span per resolved field
+// Note that this creates a lot of spans for queries with lists
+func WithFieldSpans() Option {
+	return func(t *Tracer) {
+		t.fieldSpans = true
+	}
+}
+
+// New returns a new Tracer
+func New(options ...Option) *Tracer {
+	t := &Tracer{}
+	for _, option := range options {
+		option(t)
+	}
+	if t.tracer == nil {
+		t.tracer = otel.GetTracerProvider().Tracer(instrumentationName)
+	}
+	return t
+}
+
+// Register adds the tracer as extension to s and if field spans are enabled also as middleware
+// This must be called before (*yarql.Schema).Parse()
+func (t *Tracer) Register(s *yarql.Schema) error {
+	err := s.RegisterExtension(t)
+	if err != nil {
+		return err
+	}
+	if t.fieldSpans {
+		return s.Use(t.Middleware)
+	}
+	return nil
+}
+
+// operationSpanKey is the context key of the span of the operation
+// This makes sure we only end the span we started and not a span of the http request
+type operationSpanKey struct{}
+
+// ParseStart starts the span of the operation
+func (t *Tracer) ParseStart(ctx *yarql.Ctx, query []byte) ([]byte, error) {
+	spanCtx, span := t.tracer.Start(ctx.Context(), "graphql.operation",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("graphql.document", string(query))),
+	)
+	ctx.SetContext(context.WithValue(spanCtx, operationSpanKey{}, span))
+	return query, nil
+}
+
+// ResponseEnd ends the span of the operation
+func (t *Tracer) ResponseEnd(ctx *yarql.Ctx, result []byte, errs []error) {
+	span, ok := ctx.Context().Value(operationSpanKey{}).(trace.Span)
+	if !ok {
+		// A earlier extension rejected the query before the span was started
+		return
+	}
+
+	if len(errs) > 0 {
+		span.SetAttributes(attribute.Int("graphql.errors.count", len(errs)))
+		for _, err := range errs {
+			span.RecordError(err)
+		}
+		span.SetStatus(codes.Error, errs[0].Error())
+	}
+	span.End()
+}
+
+// Middleware creates a span for every resolved field, the spans have the field path, name and parent type as attributes
+// Fields resolved within the field are children of the span
+func (t *Tracer) Middleware(next yarql.FieldResolver) yarql.FieldResolver {
+	return func(ctx *yarql.Ctx, field yarql.FieldInfo) error {
+		parent := ctx.Context()
+		spanCtx, span := t.tracer.Start(parent, field.ParentType+"."+field.Name,
+			trace.WithAttributes(
+				attribute.String("graphql.field.path", string(ctx.GetPath())),
+				attribute.String("graphql.field.name", field.Name),
+				attribute.String("graphql.field.alias", field.Alias),
+				attribute.String("graphql.field.parent_type", field.ParentType),
+			),
+		)
+
+		ctx.SetContext(spanCtx)
+		err := next(ctx, field)
+		ctx.SetContext(parent)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		return err
+	}
+}
@@ -0,0 +1,83 @@
+package yarqlotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mjarkk/yarql"
+	a "github.com/mjarkk/yarql/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testQuery struct {
+	User testUser
+}
+
+type testUser struct {
+	Name string
+}
+
+type testMethods struct{}
+
+func (testQuery) ResolveFail() (string, error) {
+	return "", errors.New("failed")
+}
+
+func newTestSchema(t *testing.T, options ...Option) (*yarql.Schema, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := yarql.NewSchema()
+	a.NoError(t, New(append(options, WithTracerProvider(provider))...).Register(s))
+	a.NoError(t, s.Parse(testQuery{User: testUser{Name: "a"}}, testMethods{}, nil))
+	return s, recorder
+}
+
+func TestOperationSpan(t *testing.T) {
+	s, recorder := newTestSchema(t)
+
+	errs := s.Resolve([]byte(`{user {name}}`), yarql.ResolveOptions{Context: context.Background()})
+	a.Equal(t, 0, len(errs))
+
+	spans := recorder.Ended()
+	a.Equal(t, 1, len(spans))
+	a.Equal(t, "graphql.operation", spans[0].Name())
+	a.Equal(t, []attribute.KeyValue{attribute.String("graphql.document", `{user {name}}`)}, spans[0].Attributes())
+
+	errs = s.Resolve([]byte(`{fail}`), yarql.ResolveOptions{})
+	a.Equal(t, 1, len(errs))
+	spans = recorder.Ended()
+	a.Equal(t, 2, len(spans))
+	a.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestFieldSpans(t *testing.T) {
+	s, recorder := newTestSchema(t, WithFieldSpans())
+
+	errs := s.Resolve([]byte(`{u: user {name}}`), yarql.ResolveOptions{})
+	a.Equal(t, 0, len(errs))
+
+	// Spans are ended from the inner most field to the operation
+	spans := recorder.Ended()
+	a.Equal(t, 3, len(spans))
+	a.Equal(t, "testUser.name", spans[0].Name())
+	a.Equal(t, "testQuery.user", spans[1].Name())
+	a.Equal(t, "graphql.operation", spans[2].Name())
+
+	a.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	a.Equal(t, spans[2].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	a.True(t, hasAttribute(spans[0].Attributes(), attribute.String("graphql.field.path", `["u","name"]`)))
+}
+
+func hasAttribute(attributes []attribute.KeyValue, expected attribute.KeyValue) bool {
+	for _, attr := range attributes {
+		if attr == expected {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// Package yarqltest contains helpers for testing a schema, like executing queries and snapshot testing the responses
+//
+// The snapshots are golden files stored next to the tests, run the tests with the YARQLTEST_UPDATE=1 environment variable to create or update them:
+//
+//	YARQLTEST_UPDATE=1 go test ./...
+package yarqltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mjarkk/yarql"
+)
+
+// UpdateEnv is the environment variable that makes the assertions write the snapshots instead of comparing them
+const UpdateEnv = "YARQLTEST_UPDATE"
+
+// AssertQuery executes query against s and asserts the JSON response is equal to the content of goldenFile
+// The response includes the errors unless opts.NoMeta is set, in that case errors fail the test
+func AssertQuery(t testing.TB, s *yarql.Schema, query string, opts yarql.ResolveOptions, goldenFile string) {
+	t.Helper()
+
+	result, errs := s.Exec([]byte(query), opts)
+	if opts.NoMeta && len(errs) > 0 {
+		t.Errorf("query returned errors: %v", errs)
+		return
+	}
+	AssertJSON(t, result, goldenFile)
+}
+
+// Exec executes query against s and unmarshals the data of the response into out
+// vars are marshaled to JSON and used as the variables of the query, nil means no variables
+// Errors returned by the query or while unmarshaling the response fail the test
+//
+// Example:
+//
+//	var out struct {
+//		User struct {
+//			Name string `json:"name"`
+//		} `json:"user"`
+//	}
+//	yarqltest.Exec(t, s, `query($id: ID!) {user(id: $id) {name}}`, map[string]interface{}{"id": "1"}, &out)
+func Exec(t testing.TB, s *yarql.Schema, query string, vars interface{}, out interface{}) {
+	t.Helper()
+
+	opts := yarql.ResolveOptions{NoMeta: true}
+	if vars != nil {
+		variables, err := json.Marshal(vars)
+		if err != nil {
+			t.Fatalf("unable to marshal variables: %s", err.Error())
+			return
+		}
+		opts.Variables = string(variables)
+	}
+
+	result, errs := s.Exec([]byte(query), opts)
+	if len(errs) > 0 {
+		t.Fatalf("query returned errors: %v", errs)
+		return
+	}
+	err := json.Unmarshal(result, out)
+	if err != nil {
+		t.Fatalf("unable to unmarshal response: %s, %s", err.Error(), result)
+	}
+}
+
+// AssertJSON asserts data is equal to the JSON in goldenFile
+// The JSON is stored indented so changes are easy to review, the order of object keys is kept
+func AssertJSON(t testing.TB, data []byte, goldenFile string) {
+	t.Helper()
+
+	indented := bytes.NewBuffer(nil)
+	err := json.Indent(indented, data, "", "  ")
+	if err != nil {
+		t.Errorf("invalid JSON: %s, %s", err.Error(), data)
+		return
+	}
+	indented.WriteByte('\n')
+	assertGolden(t, indented.String(), goldenFile)
+}
+
+// AssertSchemaUnchanged asserts the schema definition language of s is equal to the content of file
+// This makes changes to the schema visible in code reviews and prevents accidental breaking changes
+func AssertSchemaUnchanged(t testing.TB, s *yarql.Schema, file string) {
+	t.Helper()
+	assertGolden(t, s.SDL(), file)
+}
+
+func assertGolden(t testing.TB, actual string, goldenFile string) {
+	t.Helper()
+
+	if len(os.Getenv(UpdateEnv)) > 0 {
+		err := os.MkdirAll(filepath.Dir(goldenFile), 0o755)
+		if err == nil {
+			err = os.WriteFile(goldenFile, []byte(actual), 0o644)
+		}
+		if err != nil {
+			t.Errorf("unable to update snapshot %s: %s", goldenFile, err.Error())
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Errorf("unable to read snapshot %s: %s, run the tests with %s=1 to create it", goldenFile, err.Error(), UpdateEnv)
+		return
+	}
+	if string(expected) != actual {
+		t.Errorf("snapshot %s does not match, run the tests with %s=1 to update it\n%s", goldenFile, UpdateEnv, diff(string(expected), actual))
+	}
+}
+
+// diff returns the first line that differs between expected and actual
+func diff(expected string, actual string) string {
+	expectedLines := strings.Split(expected, "\n")
+	actualLines := strings.Split(actual, "\n")
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		var expectedLine, actualLine string
+		if i < len(expectedLines) {
+			expectedLine = expectedLines[i]
+		}
+		if i < len(actualLines) {
+			actualLine = actualLines[i]
+		}
+		if expectedLine != actualLine {
+			line := strconv.Itoa(i + 1)
+			return "line " + line + ":\n- " + expectedLine + "\n+ " + actualLine
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package yarqltest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mjarkk/yarql"
+	a "github.com/mjarkk/yarql/assert"
+)
+
+type testMutation struct{}
+
+type testQuery struct {
+	Name  string
+	Names []string
+}
+
+func (q testQuery) ResolveGreet(args struct{ Name string }) string {
+	return "hello " + args.Name
+}
+
+func newTestSchema(t *testing.T) *yarql.Schema {
+	s := yarql.NewSchema()
+	a.NoError(t, s.Parse(testQuery{Name: "a", Names: []string{"b", "c"}}, testMutation{}, nil))
+	return s
+}
+
+// recorder captures the errors reported by the assertions
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertQuery(t *testing.T) {
+	s := newTestSchema(t)
+	golden := filepath.Join(t.TempDir(), "testdata", "query.json")
+
+	// Without a snapshot the assertion fails
+	r := &recorder{TB: t}
+	AssertQuery(r, s, `{name names}`, yarql.ResolveOptions{}, golden)
+	a.Equal(t, 1, len(r.errors))
+	a.True(t, strings.Contains(r.errors[0], UpdateEnv))
+
+	// Create the snapshot
+	t.Setenv(UpdateEnv, "1")
+	AssertQuery(t, s, `{name names}`, yarql.ResolveOptions{}, golden)
+	content, err := os.ReadFile(golden)
+	a.NoError(t, err)
+	a.Equal(t, "{\n  \"data\": {\n    \"name\": \"a\",\n    \"names\": [\n      \"b\",\n      \"c\"\n    ]\n  }\n}\n", string(content))
+
+	t.Setenv(UpdateEnv, "")
+	AssertQuery(t, s, `{name names}`, yarql.ResolveOptions{}, golden)
+
+	// A different response fails with the line that differs
+	r = &recorder{TB: t}
+	AssertQuery(r, s, `{name: names names}`, yarql.ResolveOptions{}, golden)
+	a.Equal(t, 1, len(r.errors))
+	a.True(t, strings.Contains(r.errors[0], "line 3:\n-     \"name\": \"a\",\n+     \"name\": ["), r.errors[0])
+
+	// Errors fail the test if they're not part of the response
+	r = &recorder{TB: t}
+	AssertQuery(r, s, `{unknown}`, yarql.ResolveOptions{NoMeta: true}, golden)
+	a.Equal(t, 1, len(r.errors))
+	a.True(t, strings.HasPrefix(r.errors[0], "query returned errors"))
+}
+
+func TestExec(t *testing.T) {
+	s := newTestSchema(t)
+
+	var out struct {
+		Name  string   `json:"name"`
+		Names []string `json:"names"`
+		Greet string   `json:"greet"`
+	}
+	Exec(t, s, `query($name: String!) {name names greet(name: $name)}`, map[string]interface{}{"name": "world"}, &out)
+	a.Equal(t, "a", out.Name)
+	a.Equal(t, []string{"b", "c"}, out.Names)
+	a.Equal(t, "hello world", out.Greet)
+
+	// Query errors fail the test
+	r := &recorder{TB: t}
+	Exec(r, s, `{unknown}`, nil, &out)
+	a.Equal(t, 1, len(r.errors))
+	a.True(t, strings.HasPrefix(r.errors[0], "query returned errors"))
+
+	// The data must fit into out
+	r = &recorder{TB: t}
+	var invalid struct {
+		Name int `json:"name"`
+	}
+	Exec(r, s, `{name}`, nil, &invalid)
+	a.Equal(t, 1, len(r.errors))
+	a.True(t, strings.HasPrefix(r.errors[0], "unable to unmarshal response"))
+}
+
+func TestAssertSchemaUnchanged(t *testing.T) {
+	s := newTestSchema(t)
+	file := filepath.Join(t.TempDir(), "schema.graphql")
+
+	t.Setenv(UpdateEnv, "1")
+	AssertSchemaUnchanged(t, s, file)
+	content, err := os.ReadFile(file)
+	a.NoError(t, err)
+	a.Equal(t, s.SDL(), string(content))
+
+	t.Setenv(UpdateEnv, "")
+	AssertSchemaUnchanged(t, s, file)
+
+	a.NoError(t, os.WriteFile(file, []byte("type Query {\n}\n"), 0o644))
+	r := &recorder{TB: t}
+	AssertSchemaUnchanged(r, s, file)
+	a.Equal(t, 1, len(r.errors))
+}